@@ -1,66 +0,0 @@
-package main
-
-import (
-	"fmt"
-	"math/rand"
-	"time"
-)
-
-type Result string
-type Search func(query string) Result
-
-var (
-	Web   = fakeSearch("web")
-	Image = fakeSearch("image")
-	Video = fakeSearch("video")
-)
-
-func fakeSearch(kind string) Search {
-	return func(query string) Result {
-		time.Sleep(time.Duration(rand.Intn(100)) * time.Millisecond)
-		return Result(fmt.Sprintf("%s result for %q\n", kind, query))
-	}
-}
-
-// I don't want to wait for slow server
-func Google(query string) []Result {
-	c := make(chan Result)
-
-	// each search performs in a goroutine
-	go func() {
-		c <- Web(query)
-	}()
-	go func() {
-		c <- Image(query)
-	}()
-	go func() {
-		c <- Video(query)
-	}()
-
-	var results []Result
-
-	// the global timeout for 3 queries
-	// it means after 50ms, it ignores the result from the server that taking response greater than 50ms
-	//
-	timeout := time.After(50 * time.Millisecond)
-	for i := 0; i < 3; i++ {
-		select {
-		case r := <-c:
-			results = append(results, r)
-		// this line ignore the slow server.
-		case <-timeout:
-			fmt.Println("timeout")
-			return results
-		}
-	}
-
-	return results
-}
-
-func main() {
-	start := time.Now()
-	results := Google("golang")
-	elapsed := time.Since(start)
-	fmt.Println(results)
-	fmt.Println(elapsed)
-}
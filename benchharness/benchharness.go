@@ -0,0 +1,212 @@
+// Package benchharness runs the module's benchmark workloads against worker
+// pools and limiters, so that another implementation can be measured with
+// the same jobs, arrival patterns and sizes as the reference ones:
+//
+//	func BenchmarkMyPool(b *testing.B) {
+//		benchharness.Pools(b, map[string]benchharness.NewPool{
+//			"mine":      newMyPool,
+//			"reference": benchharness.Reference,
+//		})
+//	}
+//
+// A workload pairs what one job costs, CPU, IO or a mix, with how jobs
+// arrive: back to back, or in a Poisson process with bursts and lulls.
+package benchharness
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"math/rand"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/lotusirous/gochan/pool"
+)
+
+// Workload describes a production-like job profile: what a single job
+// costs and how jobs arrive.
+type Workload struct {
+	Name string
+	// Job does the work for item i and returns a value so the compiler
+	// cannot optimize the work away.
+	Job func(i int) int
+	// Gap returns the time to wait before the next arrival. A nil Gap means
+	// jobs arrive back to back.
+	Gap func(r *rand.Rand) time.Duration
+}
+
+// CPU spins for roughly n iterations per job.
+func CPU(n int) func(int) int {
+	return func(i int) int {
+		sum := i
+		for j := 0; j < n; j++ {
+			sum += j * j
+		}
+		return sum
+	}
+}
+
+// IO simulates a network or disk call by sleeping for d.
+func IO(d time.Duration) func(int) int {
+	return func(i int) int {
+		time.Sleep(d)
+		return i
+	}
+}
+
+// Mixed makes every ioEvery-th job an IO call of d and the rest CPU work
+// of n iterations.
+func Mixed(n int, d time.Duration, ioEvery int) func(int) int {
+	cpu, io := CPU(n), IO(d)
+	return func(i int) int {
+		if i%ioEvery == 0 {
+			return io(i)
+		}
+		return cpu(i)
+	}
+}
+
+// Poisson returns exponentially distributed gaps with the given mean, which
+// makes arrivals a Poisson process: mostly short gaps with occasional bursts
+// and lulls, instead of a perfectly even stream.
+func Poisson(mean time.Duration) func(*rand.Rand) time.Duration {
+	return func(r *rand.Rand) time.Duration {
+		return time.Duration(r.ExpFloat64() * float64(mean))
+	}
+}
+
+// Workloads returns the profiles the module's own benchmarks run.
+func Workloads() []Workload {
+	return []Workload{
+		{Name: "CPU", Job: CPU(10_000)},
+		{Name: "IO", Job: IO(100 * time.Microsecond)},
+		{Name: "Mixed", Job: Mixed(10_000, 100*time.Microsecond, 4)},
+		{Name: "BurstyCPU", Job: CPU(10_000), Gap: Poisson(5 * time.Microsecond)},
+	}
+}
+
+// Arrivals calls submit n times following w's arrival process, with gaps
+// drawn from a source seeded with seed. Sleeping for every microsecond gap
+// would be dominated by timer overhead, so it tracks the schedule and only
+// sleeps once it is more than a millisecond ahead.
+func (w Workload) Arrivals(n int, seed int64, submit func(i int)) {
+	if w.Gap == nil {
+		for i := 0; i < n; i++ {
+			submit(i)
+		}
+		return
+	}
+	r := rand.New(rand.NewSource(seed))
+	start := time.Now()
+	var schedule time.Duration
+	for i := 0; i < n; i++ {
+		schedule += w.Gap(r)
+		if ahead := schedule - time.Since(start); ahead > time.Millisecond {
+			time.Sleep(ahead)
+		}
+		submit(i)
+	}
+}
+
+// Jobs is how many jobs each run of a scenario submits.
+const Jobs = 200
+
+// Sizes are the numbers of workers Pools tries.
+var Sizes = []int{1, 4, 16}
+
+// NewPool starts a pool under test that runs fn on the given number of
+// workers. Any pool.Pool will do.
+type NewPool func(fn pool.Func[int, int], workers int) pool.Pool[int, int]
+
+// Reference starts the module's goroutine pool.
+func Reference(fn pool.Func[int, int], workers int) pool.Pool[int, int] {
+	return pool.New(fn, pool.WithWorkers(workers))
+}
+
+// RunPool measures a pool on w: every iteration starts a pool with
+// newPool, submits Jobs jobs as they arrive, and waits for their results.
+func RunPool(b *testing.B, newPool NewPool, w Workload, workers int) {
+	ctx := b.Context()
+	fn := func(_ context.Context, i int) (int, error) { return w.Job(i), nil }
+	for i := 0; i < b.N; i++ {
+		p := newPool(fn, workers)
+		go func() {
+			defer p.Close()
+			w.Arrivals(Jobs, int64(i), func(j int) { p.Submit(ctx, j) })
+		}()
+		for range p.Results() {
+		}
+	}
+}
+
+// Pools runs RunPool for every pool in pools, every workload of Workloads
+// and every size of Sizes, as sub-benchmarks named workload/pool/workers.
+func Pools(b *testing.B, pools map[string]NewPool) {
+	for _, w := range Workloads() {
+		for _, name := range slices.Sorted(maps.Keys(pools)) {
+			newPool := pools[name]
+			for _, workers := range Sizes {
+				b.Run(fmt.Sprintf("%s/%s/%d", w.Name, name, workers), func(b *testing.B) {
+					RunPool(b, newPool, w, workers)
+				})
+			}
+		}
+	}
+}
+
+// Limiter is a rate or concurrency limiter under test: Wait blocks until
+// one more job may start, or ctx is done. The module's ratelimit.Limiter
+// is one, and so is the Limiter of golang.org/x/time/rate.
+type Limiter interface {
+	Wait(ctx context.Context) error
+}
+
+// Unlimited is a Limiter that never waits. Measure against it to see what
+// a limiter costs.
+var Unlimited Limiter = unlimited{}
+
+type unlimited struct{}
+
+func (unlimited) Wait(ctx context.Context) error { return ctx.Err() }
+
+// RunLimiter measures a limiter on w: every iteration lets Jobs jobs
+// arrive, waits on l before running each of them on its own goroutine, and
+// waits for them all. It reports the jobs admitted per second as jobs/s.
+func RunLimiter(b *testing.B, l Limiter, w Workload) {
+	ctx := b.Context()
+	start := time.Now()
+	admitted := 0
+	for i := 0; i < b.N; i++ {
+		done := make(chan struct{}, Jobs)
+		started := 0
+		w.Arrivals(Jobs, int64(i), func(j int) {
+			if l.Wait(ctx) != nil {
+				return
+			}
+			started++
+			go func() {
+				w.Job(j)
+				done <- struct{}{}
+			}()
+		})
+		for range started {
+			<-done
+		}
+		admitted += started
+	}
+	b.ReportMetric(float64(admitted)/time.Since(start).Seconds(), "jobs/s")
+}
+
+// Limiters runs RunLimiter for every limiter in limiters and every
+// workload of Workloads, as sub-benchmarks named workload/limiter.
+func Limiters(b *testing.B, limiters map[string]Limiter) {
+	for _, w := range Workloads() {
+		for _, name := range slices.Sorted(maps.Keys(limiters)) {
+			b.Run(w.Name+"/"+name, func(b *testing.B) {
+				RunLimiter(b, limiters[name], w)
+			})
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package benchharness
+
+import (
+	"context"
+	"math/rand"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lotusirous/gochan/pool"
+)
+
+func TestArrivals(t *testing.T) {
+	for _, w := range []Workload{
+		{Name: "BackToBack"},
+		{Name: "Poisson", Gap: Poisson(time.Microsecond)},
+	} {
+		var got []int
+		w.Arrivals(5, 1, func(i int) { got = append(got, i) })
+		if want := []int{0, 1, 2, 3, 4}; !slices.Equal(got, want) {
+			t.Errorf("%s: submitted %v, want %v", w.Name, got, want)
+		}
+	}
+}
+
+func TestPoissonMean(t *testing.T) {
+	gap := Poisson(time.Millisecond)
+	r := rand.New(rand.NewSource(1))
+	var sum time.Duration
+	const n = 10_000
+	for range n {
+		sum += gap(r)
+	}
+	if mean := sum / n; mean < 950*time.Microsecond || mean > 1050*time.Microsecond {
+		t.Errorf("mean gap = %v, want about 1ms", mean)
+	}
+}
+
+// counting wraps a pool to count the jobs that reach it.
+type counting struct {
+	pool.Pool[int, int]
+	submitted *atomic.Int64
+}
+
+func (c counting) Submit(ctx context.Context, job int) error {
+	c.submitted.Add(1)
+	return c.Pool.Submit(ctx, job)
+}
+
+func TestRunPool(t *testing.T) {
+	var submitted atomic.Int64
+	newPool := func(fn pool.Func[int, int], workers int) pool.Pool[int, int] {
+		return counting{Reference(fn, workers), &submitted}
+	}
+	r := testing.Benchmark(func(b *testing.B) {
+		RunPool(b, newPool, Workload{Name: "CPU", Job: CPU(10)}, 2)
+	})
+	if r.N == 0 || submitted.Load() < int64(r.N*Jobs) {
+		t.Errorf("%d runs submitted %d jobs, want %d per run", r.N, submitted.Load(), Jobs)
+	}
+}
+
+// every lets one job in per interval.
+type every struct{ tick *time.Ticker }
+
+func (e every) Wait(ctx context.Context) error {
+	select {
+	case <-e.tick.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func TestRunLimiterReportsRate(t *testing.T) {
+	l := every{time.NewTicker(50 * time.Microsecond)}
+	defer l.tick.Stop()
+	r := testing.Benchmark(func(b *testing.B) {
+		RunLimiter(b, l, Workload{Name: "CPU", Job: CPU(10)})
+	})
+	// A ticker drops ticks nobody waits for, so the rate may fall short of
+	// 20000/s but never exceed it.
+	if rate := r.Extra["jobs/s"]; rate <= 0 || rate > 21_000 {
+		t.Errorf("jobs/s = %v, want at most 20000", rate)
+	}
+}
+
+func BenchmarkReference(b *testing.B) {
+	Pools(b, map[string]NewPool{"GoroutinePool": Reference})
+	Limiters(b, map[string]Limiter{"Unlimited": Unlimited})
+}
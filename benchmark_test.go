@@ -3,9 +3,16 @@ package main
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"sync"
 	"testing"
 	"time"
+
+	"github.com/lotusirous/gochan/benchharness"
+	"github.com/lotusirous/gochan/chans"
+	"github.com/lotusirous/gochan/internal/lockfree"
+	"github.com/lotusirous/gochan/pool"
+	"github.com/lotusirous/gochan/selectutil"
 )
 
 // BenchmarkBoringPattern benchmarks the basic goroutine communication
@@ -59,6 +66,92 @@ func BenchmarkBoringPattern(b *testing.B) {
 	})
 }
 
+// BenchmarkMessagePooling compares allocating a formatted string per message
+// (as BenchmarkBoringPattern does) with reusing pooled message structs
+// through sync.Pool and pool.Objects (example 19). Run with -benchmem to see
+// the allocation difference.
+func BenchmarkMessagePooling(b *testing.B) {
+	type message struct {
+		seq  int
+		body []byte
+	}
+
+	b.Run("SprintfPerMessage", func(b *testing.B) {
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			ch := make(chan string, 100)
+
+			go func() {
+				defer close(ch)
+				for j := 0; j < 100; j++ {
+					ch <- fmt.Sprintf("msg %d", j)
+				}
+			}()
+
+			for range ch {
+			}
+		}
+	})
+
+	b.Run("PooledMessages", func(b *testing.B) {
+		msgPool := sync.Pool{
+			New: func() any { return &message{body: make([]byte, 0, 16)} },
+		}
+
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			ch := make(chan *message, 100)
+
+			go func() {
+				defer close(ch)
+				for j := 0; j < 100; j++ {
+					m := msgPool.Get().(*message)
+					m.seq = j
+					m.body = append(m.body[:0], "msg "...)
+					m.body = strconv.AppendInt(m.body, int64(j), 10)
+					ch <- m
+				}
+			}()
+
+			for m := range ch {
+				msgPool.Put(m)
+			}
+		}
+	})
+
+	b.Run("ObjectsPool", func(b *testing.B) {
+		msgPool := pool.NewObjects(pool.ObjectsConfig[*message]{
+			New:     func(context.Context) (*message, error) { return &message{body: make([]byte, 0, 16)}, nil },
+			Reset:   func(m *message) { m.body = m.body[:0] },
+			MaxIdle: 128,
+		})
+		ctx := context.Background()
+
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			ch := make(chan *message, 100)
+
+			go func() {
+				defer close(ch)
+				for j := 0; j < 100; j++ {
+					m, _ := msgPool.Get(ctx)
+					m.seq = j
+					m.body = append(m.body, "msg "...)
+					m.body = strconv.AppendInt(m.body, int64(j), 10)
+					ch <- m
+				}
+			}()
+
+			for m := range ch {
+				msgPool.Put(m)
+			}
+		}
+	})
+}
+
 // BenchmarkChannelTypes compares different channel configurations
 func BenchmarkChannelTypes(b *testing.B) {
 	b.Run("UnbufferedChannel", func(b *testing.B) {
@@ -171,13 +264,13 @@ func BenchmarkFanInPattern(b *testing.B) {
 					select {
 					case val, ok := <-c1:
 						if !ok {
-							c1 = nil
+							selectutil.Disable(&c1)
 						} else {
 							out <- val
 						}
 					case val, ok := <-c2:
 						if !ok {
-							c2 = nil
+							selectutil.Disable(&c2)
 						} else {
 							out <- val
 						}
@@ -216,6 +309,168 @@ func BenchmarkFanInPattern(b *testing.B) {
 			}
 		}
 	})
+
+	// The same select loop for any number of inputs, in selectutil.
+	b.Run("SelectutilMerge", func(b *testing.B) {
+		ctx := context.Background()
+		for i := 0; i < b.N; i++ {
+			ch1 := make(chan int, 50)
+			ch2 := make(chan int, 50)
+			go func() {
+				defer close(ch1)
+				for j := 0; j < 50; j++ {
+					ch1 <- j
+				}
+			}()
+			go func() {
+				defer close(ch2)
+				for j := 0; j < 50; j++ {
+					ch2 <- j + 100
+				}
+			}()
+
+			count := 0
+			for range selectutil.Merge(ctx, ch1, ch2) {
+				count++
+			}
+		}
+	})
+}
+
+// BenchmarkBatchedSends compares sending one item per channel operation with
+// sending slices of 64 items through the chans and pool packages.
+func BenchmarkBatchedSends(b *testing.B) {
+	const (
+		producers   = 4
+		perProducer = 1024
+		batchSize   = 64
+	)
+	ctx := context.Background()
+	square := func(_ context.Context, n int) (int, error) { return n * n, nil }
+
+	produce := func() []<-chan int {
+		inputs := make([]<-chan int, producers)
+		for p := range inputs {
+			ch := make(chan int)
+			inputs[p] = ch
+			go func() {
+				defer close(ch)
+				for j := 0; j < perProducer; j++ {
+					ch <- j
+				}
+			}()
+		}
+		return inputs
+	}
+
+	produceBatches := func() []<-chan []int {
+		inputs := make([]<-chan []int, producers)
+		for p := range inputs {
+			ch := make(chan []int)
+			inputs[p] = ch
+			go func() {
+				defer close(ch)
+				for j := 0; j < perProducer; j += batchSize {
+					batch := make([]int, batchSize)
+					for k := range batch {
+						batch[k] = j + k
+					}
+					ch <- batch
+				}
+			}()
+		}
+		return inputs
+	}
+
+	b.Run("FanIn/PerItem", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for range chans.FanIn(ctx, produce()...) {
+			}
+		}
+	})
+
+	b.Run("FanIn/Batched64", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for batch := range chans.FanIn(ctx, produceBatches()...) {
+				for range batch {
+				}
+			}
+		}
+	})
+
+	b.Run("WorkerPool/PerItem", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			p := pool.New(square, pool.WithWorkers(4))
+			go func() {
+				defer p.Close()
+				for j := 0; j < producers*perProducer; j++ {
+					p.Submit(ctx, j)
+				}
+			}()
+			for range p.Results() {
+			}
+		}
+	})
+
+	b.Run("WorkerPool/Batched64", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			p := pool.New(pool.Batch(square), pool.WithWorkers(4))
+			go func() {
+				defer p.Close()
+				for j := 0; j < producers*perProducer; j += batchSize {
+					batch := make([]int, batchSize)
+					for k := range batch {
+						batch[k] = j + k
+					}
+					p.Submit(ctx, batch)
+				}
+			}()
+			for r := range p.Results() {
+				for range r.Value {
+				}
+			}
+		}
+	})
+}
+
+// BenchmarkShardedFanIn compares merging many producers into one channel with
+// merging them through K intermediate shards first. Sharding only pays off
+// when producers really run in parallel; compare with -cpu=1,8.
+func BenchmarkShardedFanIn(b *testing.B) {
+	const perProducer = 256
+	ctx := context.Background()
+
+	produce := func(producers int) []<-chan int {
+		inputs := make([]<-chan int, producers)
+		for p := range inputs {
+			ch := make(chan int)
+			inputs[p] = ch
+			go func() {
+				defer close(ch)
+				for j := 0; j < perProducer; j++ {
+					ch <- j
+				}
+			}()
+		}
+		return inputs
+	}
+
+	for _, producers := range []int{16, 32, 64} {
+		b.Run(fmt.Sprintf("Producers%d/Single", producers), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				for range chans.FanIn(ctx, produce(producers)...) {
+				}
+			}
+		})
+		for _, shards := range []int{4, 8} {
+			b.Run(fmt.Sprintf("Producers%d/Shards%d", producers, shards), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					for range chans.ShardedFanIn(ctx, shards, produce(producers)...) {
+					}
+				}
+			})
+		}
+	}
 }
 
 // BenchmarkWorkerPool compares different worker pool configurations
@@ -334,6 +589,33 @@ func BenchmarkWorkerPool(b *testing.B) {
 	})
 }
 
+// BenchmarkWorkloadProfiles runs the worker pool and fan-in comparisons
+// against the production-like profiles of benchharness instead of a
+// uniform toy loop.
+func BenchmarkWorkloadProfiles(b *testing.B) {
+	ctx := context.Background()
+	benchharness.Pools(b, map[string]benchharness.NewPool{"WorkerPool": benchharness.Reference})
+
+	for _, w := range benchharness.Workloads() {
+		b.Run(fmt.Sprintf("%s/FanIn4", w.Name), func(b *testing.B) {
+			const producers = 4
+			for i := 0; i < b.N; i++ {
+				inputs := make([]<-chan int, producers)
+				for p := range inputs {
+					ch := make(chan int)
+					inputs[p] = ch
+					go func() {
+						defer close(ch)
+						w.Arrivals(benchharness.Jobs/producers, int64(i*producers+p), func(j int) { ch <- w.Job(j) })
+					}()
+				}
+				for range chans.FanIn(ctx, inputs...) {
+				}
+			}
+		})
+	}
+}
+
 // BenchmarkTimeoutPatterns compares different timeout implementations
 func BenchmarkTimeoutPatterns(b *testing.B) {
 	b.Run("ChannelTimeout", func(b *testing.B) {
@@ -409,6 +691,53 @@ func BenchmarkSynchronization(b *testing.B) {
 		})
 	})
 	
+	// A stats snapshot read constantly and updated once every 1000
+	// operations, behind an RWMutex and behind a seqlock.
+	type stats struct {
+		requests, errors, bytes, latencyNs uint64
+	}
+	const writeEvery = 1000
+	
+	b.Run("RWMutex_Snapshot", func(b *testing.B) {
+		var snap stats
+		var mu sync.RWMutex
+		
+		b.ResetTimer()
+		b.RunParallel(func(pb *testing.PB) {
+			for i := 0; pb.Next(); i++ {
+				if i%writeEvery == 0 {
+					mu.Lock()
+					snap.requests++
+					snap.bytes += 512
+					mu.Unlock()
+					continue
+				}
+				mu.RLock()
+				s := snap
+				mu.RUnlock()
+				_ = s
+			}
+		})
+	})
+	
+	b.Run("SeqLock_Snapshot", func(b *testing.B) {
+		snap := lockfree.NewSeqLock(stats{})
+		
+		b.ResetTimer()
+		b.RunParallel(func(pb *testing.PB) {
+			for i := 0; pb.Next(); i++ {
+				if i%writeEvery == 0 {
+					snap.Update(func(s *stats) {
+						s.requests++
+						s.bytes += 512
+					})
+					continue
+				}
+				_ = snap.Load()
+			}
+		})
+	})
+	
 	b.Run("Channel", func(b *testing.B) {
 		ch := make(chan int, 1)
 		ch <- 0
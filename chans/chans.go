@@ -0,0 +1,282 @@
+// Package chans contains reusable channel combinators extracted from the
+// numbered examples.
+//
+// Every combinator takes a context. When the context is done the combinator
+// stops reading its inputs, closes its output and lets its goroutines exit,
+// so abandoning a pipeline never leaks goroutines.
+package chans
+
+import (
+	"context"
+	"sync"
+)
+
+// FanIn merges inputs into a single channel (example 4). The output is
+// closed once every input is closed or ctx is done.
+func FanIn[T any](ctx context.Context, inputs ...<-chan T) <-chan T {
+	return forward(ctx, make(chan T), inputs)
+}
+
+// Batch groups values from in into slices of up to size values. A final,
+// shorter batch is sent when in is closed. Sending one slice instead of size
+// single values amortizes the cost of the channel synchronization. A size
+// below 1 is taken as 1.
+func Batch[T any](ctx context.Context, in <-chan T, size int) <-chan []T {
+	size = max(size, 1)
+	out := make(chan []T)
+	go func() {
+		defer close(out)
+		batch := make([]T, 0, size)
+		for {
+			v, ok := recv(ctx, in)
+			if !ok {
+				break
+			}
+			batch = append(batch, v)
+			if len(batch) < size {
+				continue
+			}
+			if !send(ctx, out, batch) {
+				return
+			}
+			batch = make([]T, 0, size)
+		}
+		if len(batch) > 0 && ctx.Err() == nil {
+			send(ctx, out, batch)
+		}
+	}()
+	return out
+}
+
+// Flatten is the inverse of Batch: it sends every value of every batch.
+func Flatten[T any](ctx context.Context, in <-chan []T) <-chan T {
+	out := make(chan T)
+	go func() {
+		defer close(out)
+		for {
+			batch, ok := recv(ctx, in)
+			if !ok {
+				return
+			}
+			for _, v := range batch {
+				if !send(ctx, out, v) {
+					return
+				}
+			}
+		}
+	}()
+	return out
+}
+
+// recv receives from in. It returns false if in is closed or ctx is done.
+func recv[T any](ctx context.Context, in <-chan T) (T, bool) {
+	select {
+	case v, ok := <-in:
+		return v, ok
+	case <-ctx.Done():
+		var zero T
+		return zero, false
+	}
+}
+
+// send sends v on out. It returns false if ctx is done first.
+func send[T any](ctx context.Context, out chan<- T, v T) bool {
+	select {
+	case out <- v:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// ShardedFanIn merges inputs like FanIn, but through shards intermediate
+// buffered channels: input i is forwarded to shard i%shards, and a final
+// combiner merges the shards. With many producers on many cores this spreads
+// the contention of a single output channel over several channels, each with
+// fewer senders. On a single core it only adds a hop.
+func ShardedFanIn[T any](ctx context.Context, shards int, inputs ...<-chan T) <-chan T {
+	if shards <= 1 || shards >= len(inputs) {
+		return FanIn(ctx, inputs...)
+	}
+	const shardBuffer = 64
+
+	merged := make([]<-chan T, shards)
+	for i := range merged {
+		var group []<-chan T
+		for j := i; j < len(inputs); j += shards {
+			group = append(group, inputs[j])
+		}
+		merged[i] = forward(ctx, make(chan T, shardBuffer), group)
+	}
+	return FanIn(ctx, merged...)
+}
+
+// forward copies every value of inputs to out and closes out when all inputs
+// are closed or ctx is done.
+func forward[T any](ctx context.Context, out chan T, inputs []<-chan T) <-chan T {
+	var wg sync.WaitGroup
+	for _, in := range inputs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				v, ok := recv(ctx, in)
+				if !ok || !send(ctx, out, v) {
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+	return out
+}
+
+// Tee copies every value of in to each of n outputs, the inverse of FanIn.
+// A value is only read from in once every output has received the
+// previous one, so the outputs move in lockstep at the pace of the slowest
+// consumer; every consumer must keep reading until its output is closed,
+// or ctx is done. Values are shared, not copied: consumers must not modify
+// them.
+func Tee[T any](ctx context.Context, in <-chan T, n int) []<-chan T {
+	outs := make([]chan T, n)
+	ro := make([]<-chan T, n)
+	for i := range outs {
+		outs[i] = make(chan T)
+		ro[i] = outs[i]
+	}
+	go func() {
+		defer func() {
+			for _, out := range outs {
+				close(out)
+			}
+		}()
+		for {
+			v, ok := recv(ctx, in)
+			if !ok {
+				return
+			}
+			for _, out := range outs {
+				if !send(ctx, out, v) {
+					return
+				}
+			}
+		}
+	}()
+	return ro
+}
+
+// Generate sends fn(0), fn(1), ... on the returned channel until ctx is done
+// (the boring generator of example 3). If n >= 0 it stops after n values and
+// closes the channel.
+func Generate[T any](ctx context.Context, n int, fn func(i int) T) <-chan T {
+	out := make(chan T)
+	go func() {
+		defer close(out)
+		for i := 0; n < 0 || i < n; i++ {
+			if !send(ctx, out, fn(i)) {
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// RingBuffer forwards in to a channel buffered with size values. When the
+// consumer falls behind and the buffer is full, the oldest value is dropped
+// to make room (example 17), so a slow consumer always sees recent values
+// and never blocks the producer. A size below 1 is taken as 1: without a
+// buffer there would be no oldest value to drop.
+func RingBuffer[T any](ctx context.Context, in <-chan T, size int) <-chan T {
+	out := make(chan T, max(size, 1))
+	go func() {
+		defer close(out)
+		for {
+			v, ok := recv(ctx, in)
+			if !ok {
+				return
+			}
+			for {
+				select {
+				case out <- v:
+				case <-ctx.Done():
+					return
+				default:
+					// Full: drop the oldest value and try again. The consumer
+					// may have drained it meanwhile, hence the select.
+					select {
+					case <-out:
+					default:
+					}
+					continue
+				}
+				break
+			}
+		}
+	}()
+	return out
+}
+
+// Map sends fn(v) for every value v received from in.
+func Map[In, Out any](ctx context.Context, in <-chan In, fn func(In) Out) <-chan Out {
+	out := make(chan Out)
+	go func() {
+		defer close(out)
+		for {
+			v, ok := recv(ctx, in)
+			if !ok || !send(ctx, out, fn(v)) {
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// Filter forwards only the values for which keep returns true.
+func Filter[T any](ctx context.Context, in <-chan T, keep func(T) bool) <-chan T {
+	out := make(chan T)
+	go func() {
+		defer close(out)
+		for {
+			v, ok := recv(ctx, in)
+			if !ok {
+				return
+			}
+			if keep(v) && !send(ctx, out, v) {
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// Take forwards the first n values of in and then closes its output. It
+// stops reading in, so whatever feeds in must also watch ctx to be released.
+func Take[T any](ctx context.Context, in <-chan T, n int) <-chan T {
+	out := make(chan T)
+	go func() {
+		defer close(out)
+		for range n {
+			v, ok := recv(ctx, in)
+			if !ok || !send(ctx, out, v) {
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// Collect receives from in until it is closed or ctx is done and returns
+// the values in arrival order.
+func Collect[T any](ctx context.Context, in <-chan T) []T {
+	var out []T
+	for {
+		v, ok := recv(ctx, in)
+		if !ok {
+			return out
+		}
+		out = append(out, v)
+	}
+}
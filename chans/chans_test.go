@@ -0,0 +1,178 @@
+package chans
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+)
+
+// generate sends 0..n-1 on a new channel and closes it.
+func generate(n int) <-chan int {
+	c := make(chan int)
+	go func() {
+		defer close(c)
+		for i := range n {
+			c <- i
+		}
+	}()
+	return c
+}
+
+func collect[T any](c <-chan T) []T {
+	var out []T
+	for v := range c {
+		out = append(out, v)
+	}
+	return out
+}
+
+func TestFanIn(t *testing.T) {
+	got := collect(FanIn(context.Background(), generate(3), generate(4)))
+	slices.Sort(got)
+	want := []int{0, 0, 1, 1, 2, 2, 3}
+	if !slices.Equal(got, want) {
+		t.Errorf("FanIn = %v, want %v", got, want)
+	}
+}
+
+func TestFanInStopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	never := make(chan int)
+	out := FanIn(ctx, never)
+	cancel()
+
+	select {
+	case _, ok := <-out:
+		if ok {
+			t.Fatal("unexpected value")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("FanIn did not close its output after cancel")
+	}
+}
+
+func TestBatchAndFlatten(t *testing.T) {
+	ctx := context.Background()
+	batches := collect(Batch(ctx, generate(10), 4))
+	if len(batches) != 3 || len(batches[0]) != 4 || len(batches[2]) != 2 {
+		t.Fatalf("Batch = %v", batches)
+	}
+
+	got := collect(Flatten(ctx, Batch(ctx, generate(10), 4)))
+	if !slices.Equal(got, collect(generate(10))) {
+		t.Errorf("Flatten(Batch) = %v", got)
+	}
+}
+
+func TestShardedFanIn(t *testing.T) {
+	for _, shards := range []int{0, 1, 3, 8} {
+		inputs := make([]<-chan int, 5)
+		for i := range inputs {
+			inputs[i] = generate(10)
+		}
+		got := collect(ShardedFanIn(context.Background(), shards, inputs...))
+		if len(got) != 50 {
+			t.Errorf("shards=%d: got %d values, want 50", shards, len(got))
+		}
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	got := collect(Generate(context.Background(), 3, func(i int) int { return i * 10 }))
+	if !slices.Equal(got, []int{0, 10, 20}) {
+		t.Errorf("Generate = %v", got)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	infinite := Generate(ctx, -1, func(i int) int { return i })
+	<-infinite
+	cancel()
+	for range infinite {
+	}
+}
+
+func TestTee(t *testing.T) {
+	outs := Tee(context.Background(), generate(5), 3)
+	got := make([][]int, len(outs))
+	done := make(chan int)
+	for i, out := range outs {
+		go func() {
+			got[i] = collect(out)
+			done <- i
+		}()
+	}
+	for range outs {
+		<-done
+	}
+	for i, g := range got {
+		if !slices.Equal(g, []int{0, 1, 2, 3, 4}) {
+			t.Errorf("output %d = %v", i, g)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	outs = Tee(ctx, Generate(ctx, -1, func(i int) int { return i }), 2)
+	<-outs[0] // outs[1] is never read
+	cancel()
+	for _, out := range outs {
+		for range out {
+		}
+	}
+}
+
+func TestBatchBelowOne(t *testing.T) {
+	for _, size := range []int{0, -1} {
+		if got := collect(Batch(context.Background(), generate(3), size)); len(got) != 3 || len(got[0]) != 1 {
+			t.Errorf("Batch of size %d = %v, want batches of one", size, got)
+		}
+	}
+}
+
+func TestRingBufferKeepsNewest(t *testing.T) {
+	in := make(chan int)
+	out := RingBuffer(context.Background(), in, 3)
+	for i := range 10 {
+		in <- i
+	}
+	close(in)
+
+	// The consumer may grab one value while the last send is in flight, so
+	// up to size+1 values survive, but always the newest ones.
+	got := collect(out)
+	if len(got) < 3 || len(got) > 4 || !slices.Equal(got[len(got)-3:], []int{7, 8, 9}) {
+		t.Errorf("RingBuffer = %v, want the newest values", got)
+	}
+}
+
+func TestRingBufferWithoutSize(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	in := make(chan int)
+	out := RingBuffer(ctx, in, 0)
+	for i := range 5 {
+		in <- i // nobody reads out yet
+	}
+	close(in)
+	if got := collect(out); len(got) < 1 || len(got) > 2 || got[len(got)-1] != 4 {
+		t.Errorf("RingBuffer of size 0 = %v, want the newest value", got)
+	}
+
+	in = make(chan int)
+	out = RingBuffer(ctx, in, 0)
+	in <- 1
+	cancel()
+	for range out { // closed once the goroutine sees ctx
+	}
+}
+
+func TestMapFilterTake(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	isEven := func(v int) bool { return v%2 == 0 }
+	square := func(v int) int { return v * v }
+	got := Collect(ctx, Take(ctx, Map(ctx, Filter(ctx, Generate(ctx, -1, func(i int) int { return i }), isEven), square), 4))
+	if want := []int{0, 4, 16, 36}; !slices.Equal(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
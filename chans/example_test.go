@@ -0,0 +1,64 @@
+package chans_test
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/lotusirous/gochan/chans"
+)
+
+func ExampleFanIn() {
+	ctx := context.Background()
+	joe := chans.Generate(ctx, 2, func(i int) string { return fmt.Sprint("joe ", i) })
+	ann := chans.Generate(ctx, 2, func(i int) string { return fmt.Sprint("ann ", i) })
+
+	// Values from different inputs interleave in no particular order.
+	got := chans.Collect(ctx, chans.FanIn(ctx, joe, ann))
+	slices.Sort(got)
+	fmt.Println(strings.Join(got, ", "))
+	// Output: ann 0, ann 1, joe 0, joe 1
+}
+
+func ExampleBatch() {
+	ctx := context.Background()
+	for batch := range chans.Batch(ctx, chans.Generate(ctx, 7, func(i int) int { return i }), 3) {
+		fmt.Println(batch)
+	}
+	// Output:
+	// [0 1 2]
+	// [3 4 5]
+	// [6]
+}
+
+func ExampleTee() {
+	ctx := context.Background()
+	outs := chans.Tee(ctx, chans.Generate(ctx, 3, func(i int) int { return i }), 2)
+	sums := make(chan int)
+	for _, out := range outs {
+		go func() {
+			sum := 0
+			for v := range out {
+				sum += v
+			}
+			sums <- sum
+		}()
+	}
+	fmt.Println(<-sums, <-sums)
+	// Output: 3 3
+}
+
+func ExampleStream() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel() // stops the endless generator
+	naturals := chans.Generate(ctx, -1, func(i int) int { return i })
+
+	evens := chans.NewStream(ctx, naturals).
+		Filter(func(n int) bool { return n%2 == 0 }).
+		Map(func(n int) int { return n * n }).
+		Take(4).
+		Collect()
+	fmt.Println(evens)
+	// Output: [0 4 16 36]
+}
@@ -0,0 +1,188 @@
+package chans
+
+import (
+	"context"
+	"time"
+)
+
+// Pair is a value from each side of a Join.
+type Pair[L, R any] struct {
+	Left  L
+	Right R
+}
+
+// JoinOn tells Join how to correlate the values of its two inputs.
+type JoinOn[L, R any, K comparable] struct {
+	LeftKey   func(L) K
+	RightKey  func(R) K
+	LeftTime  func(L) time.Time
+	RightTime func(R) time.Time
+	// Window is the largest difference between the times of two values
+	// that still match.
+	Window time.Duration
+	// LeftUnmatched and RightUnmatched, if set, are called with the values
+	// that expire, or are left over when the inputs end, without having
+	// matched anything. They turn the join into an outer join.
+	LeftUnmatched  func(L)
+	RightUnmatched func(R)
+}
+
+// Join correlates two streams: it sends a Pair for every left and right
+// value with equal keys whose times are at most on.Window apart, as soon as
+// the second of the two arrives. The output is closed once both inputs are
+// closed or ctx is done.
+//
+// Every value is buffered until it can no longer match: the times are event
+// times carried by the values, and a value expires once the newest time seen
+// on either input is more than Window past it. Inputs only need to be
+// roughly in time order; a value that arrives already expired is too late
+// for its matches, which may be gone, and is passed to the unmatched
+// callback right away.
+func Join[L, R any, K comparable](ctx context.Context, left <-chan L, right <-chan R, on JoinOn[L, R, K]) <-chan Pair[L, R] {
+	out := make(chan Pair[L, R])
+	go func() {
+		defer close(out)
+		var lbuf joinBuffer[K, L]
+		var rbuf joinBuffer[K, R]
+		var newest time.Time
+		expire := func() {
+			cutoff := newest.Add(-on.Window)
+			lbuf.expire(cutoff, on.LeftUnmatched)
+			rbuf.expire(cutoff, on.RightUnmatched)
+		}
+		for left != nil || right != nil {
+			select {
+			case l, ok := <-left:
+				if !ok {
+					left = nil
+					rbuf.flush(on.RightUnmatched) // nothing left to match them
+					continue
+				}
+				k, at := on.LeftKey(l), on.LeftTime(l)
+				if at.After(newest) {
+					newest = at
+					expire()
+				}
+				if at.Before(newest.Add(-on.Window)) {
+					call(on.LeftUnmatched, l)
+					continue
+				}
+				matched := false
+				for i := range rbuf.byKey[k] {
+					r := &rbuf.byKey[k][i]
+					if within(at, r.at, on.Window) {
+						if !send(ctx, out, Pair[L, R]{l, r.v}) {
+							return
+						}
+						matched, r.matched = true, true
+					}
+				}
+				if right != nil {
+					lbuf.add(k, l, at, matched)
+				} else if !matched {
+					call(on.LeftUnmatched, l)
+				}
+			case r, ok := <-right:
+				if !ok {
+					right = nil
+					lbuf.flush(on.LeftUnmatched)
+					continue
+				}
+				k, at := on.RightKey(r), on.RightTime(r)
+				if at.After(newest) {
+					newest = at
+					expire()
+				}
+				if at.Before(newest.Add(-on.Window)) {
+					call(on.RightUnmatched, r)
+					continue
+				}
+				matched := false
+				for i := range lbuf.byKey[k] {
+					l := &lbuf.byKey[k][i]
+					if within(at, l.at, on.Window) {
+						if !send(ctx, out, Pair[L, R]{l.v, r}) {
+							return
+						}
+						matched, l.matched = true, true
+					}
+				}
+				if left != nil {
+					rbuf.add(k, r, at, matched)
+				} else if !matched {
+					call(on.RightUnmatched, r)
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
+
+func within(a, b time.Time, window time.Duration) bool {
+	d := a.Sub(b)
+	return -window <= d && d <= window
+}
+
+func call[T any](fn func(T), v T) {
+	if fn != nil {
+		fn(v)
+	}
+}
+
+// joinBuffer holds the values of one side of a Join that may still match.
+type joinBuffer[K comparable, T any] struct {
+	byKey map[K][]joinEntry[T]
+	keys  []K // of the buffered values, in arrival order
+}
+
+type joinEntry[T any] struct {
+	v       T
+	at      time.Time
+	matched bool
+}
+
+func (b *joinBuffer[K, T]) add(k K, v T, at time.Time, matched bool) {
+	if b.byKey == nil {
+		b.byKey = make(map[K][]joinEntry[T])
+	}
+	b.byKey[k] = append(b.byKey[k], joinEntry[T]{v, at, matched})
+	b.keys = append(b.keys, k)
+}
+
+// expire drops the values older than cutoff, passing those that never
+// matched to unmatched. Values are dropped in arrival order, so a value
+// that arrived out of order may outstay its window a little, which is
+// harmless: within still decides what matches.
+func (b *joinBuffer[K, T]) expire(cutoff time.Time, unmatched func(T)) {
+	n := 0
+	for _, k := range b.keys {
+		es := b.byKey[k]
+		if !es[0].at.Before(cutoff) {
+			break
+		}
+		if !es[0].matched {
+			call(unmatched, es[0].v)
+		}
+		if len(es) == 1 {
+			delete(b.byKey, k)
+		} else {
+			b.byKey[k] = es[1:]
+		}
+		n++
+	}
+	b.keys = b.keys[n:]
+}
+
+// flush drops every value, passing those that never matched to unmatched.
+func (b *joinBuffer[K, T]) flush(unmatched func(T)) {
+	for _, k := range b.keys {
+		es := b.byKey[k]
+		if !es[0].matched {
+			call(unmatched, es[0].v)
+		}
+		b.byKey[k] = es[1:]
+	}
+	b.byKey, b.keys = nil, nil
+}
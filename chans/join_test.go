@@ -0,0 +1,106 @@
+package chans
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+)
+
+type event struct {
+	Key string
+	At  int // seconds
+}
+
+func (e event) time() time.Time { return time.Unix(int64(e.At), 0) }
+
+func (e event) key() string { return e.Key }
+
+func joinOn(unmatchedL, unmatchedR *[]event) JoinOn[event, event, string] {
+	return JoinOn[event, event, string]{
+		LeftKey:        event.key,
+		RightKey:       event.key,
+		LeftTime:       event.time,
+		RightTime:      event.time,
+		Window:         10 * time.Second,
+		LeftUnmatched:  func(e event) { *unmatchedL = append(*unmatchedL, e) },
+		RightUnmatched: func(e event) { *unmatchedR = append(*unmatchedR, e) },
+	}
+}
+
+func TestJoinWithinWindow(t *testing.T) {
+	ctx := context.Background()
+	left, right := make(chan event), make(chan event)
+	var lost, lostR []event
+	out := Join(ctx, left, right, joinOn(&lost, &lostR))
+
+	var got []Pair[event, event]
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for p := range out {
+			got = append(got, p)
+		}
+	}()
+	left <- event{"a", 0}
+	left <- event{"b", 1}
+	right <- event{"a", 5}  // matches a@0
+	right <- event{"a", 8}  // matches a@0 too
+	right <- event{"c", 9}  // no match yet
+	left <- event{"b", 30}  // expires everything before 20
+	right <- event{"a", 31} // a@0 is gone
+	right <- event{"b", 35} // matches b@30 only
+	left <- event{"c", 2}   // too late for c@9
+	close(left)
+	close(right)
+	<-done
+
+	want := []Pair[event, event]{
+		{event{"a", 0}, event{"a", 5}},
+		{event{"a", 0}, event{"a", 8}},
+		{event{"b", 30}, event{"b", 35}},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("pairs = %v, want %v", got, want)
+	}
+	if want := []event{{"b", 1}, {"c", 2}}; !slices.Equal(lost, want) {
+		t.Errorf("unmatched left = %v, want %v", lost, want)
+	}
+	if want := []event{{"c", 9}, {"a", 31}}; !slices.Equal(lostR, want) {
+		t.Errorf("unmatched right = %v, want %v", lostR, want)
+	}
+}
+
+func TestJoinOneSideClosed(t *testing.T) {
+	ctx := context.Background()
+	left, right := make(chan event), make(chan event)
+	var lost, lostR []event
+	out := Join(ctx, left, right, joinOn(&lost, &lostR))
+	go func() {
+		left <- event{"a", 0}
+		close(left)
+		right <- event{"a", 1}
+		right <- event{"b", 2}
+		close(right)
+	}()
+	got := Collect(ctx, out)
+	if want := []Pair[event, event]{{event{"a", 0}, event{"a", 1}}}; !slices.Equal(got, want) {
+		t.Errorf("pairs = %v, want %v", got, want)
+	}
+	if len(lost) != 0 || !slices.Equal(lostR, []event{{"b", 2}}) {
+		t.Errorf("unmatched = %v and %v, want only right b@2", lost, lostR)
+	}
+}
+
+func TestJoinStopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	left, right := make(chan event), make(chan event)
+	out := Join(ctx, left, right, JoinOn[event, event, string]{
+		LeftKey: event.key, RightKey: event.key, LeftTime: event.time, RightTime: event.time, Window: time.Second,
+	})
+	left <- event{"a", 0}
+	right <- event{"a", 0} // the pair is never received
+	cancel()
+	for range out {
+	}
+}
@@ -0,0 +1,45 @@
+package chans
+
+import (
+	"context"
+	"iter"
+)
+
+// ToSeq adapts a channel to a range-over-func iterator. Iteration ends when
+// c is closed or ctx is done.
+//
+// Breaking out of the loop stops receiving but cannot stop the sender; cancel
+// ctx afterwards so a producer that watches it can exit:
+//
+//	ctx, cancel := context.WithCancel(ctx)
+//	defer cancel()
+//	for v := range ToSeq(ctx, c) { ... }
+func ToSeq[T any](ctx context.Context, c <-chan T) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for {
+			v, ok := recv(ctx, c)
+			if !ok || !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// FromSeq runs seq in a new goroutine and sends its values on the returned
+// channel, which is closed when seq ends. If ctx is done first, seq is asked
+// to stop and the goroutine exits.
+func FromSeq[T any](ctx context.Context, seq iter.Seq[T]) <-chan T {
+	out := make(chan T)
+	go func() {
+		defer close(out)
+		for v := range seq {
+			if !send(ctx, out, v) {
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// Seq returns the stream as an iterator; see ToSeq.
+func (s Stream[T]) Seq() iter.Seq[T] { return ToSeq(s.ctx, s.c) }
@@ -0,0 +1,78 @@
+package chans
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestToSeqBreakAndCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	src := Generate(ctx, -1, func(i int) int { return i })
+
+	var got []int
+	for v := range ToSeq(ctx, src) {
+		if v == 3 {
+			break
+		}
+		got = append(got, v)
+	}
+	cancel()
+	if want := []int{0, 1, 2}; !slices.Equal(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	// Generate must notice the cancellation and close its channel.
+	select {
+	case <-drain(src):
+	case <-time.After(time.Second):
+		t.Fatal("producer still running after cancel")
+	}
+}
+
+func TestFromSeq(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	got := Collect(ctx, FromSeq(ctx, slices.Values([]string{"a", "b", "c"})))
+	if want := []string{"a", "b", "c"}; !slices.Equal(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	// Round trip through a stream.
+	s := NewStream(ctx, FromSeq(ctx, slices.Values([]int{1, 2, 3})))
+	if got := slices.Collect(s.Seq()); !slices.Equal(got, []int{1, 2, 3}) {
+		t.Fatalf("Seq got %v", got)
+	}
+}
+
+func TestFromSeqStopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	endless := func(yield func(int) bool) {
+		defer close(stopped)
+		for i := 0; yield(i); i++ {
+		}
+	}
+
+	c := FromSeq(ctx, endless)
+	<-c
+	cancel()
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("iterator still running after cancel")
+	}
+}
+
+// drain reads c until it is closed and then closes the returned channel.
+func drain[T any](c <-chan T) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		for range c {
+		}
+		close(done)
+	}()
+	return done
+}
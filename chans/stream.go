@@ -0,0 +1,59 @@
+package chans
+
+import "context"
+
+// Stream bundles a channel with the context that governs it, so combinators
+// can be chained without repeating the context:
+//
+//	evens := NewStream(ctx, in).Filter(isEven).Take(10).Collect()
+//
+// Every method is a thin wrapper around the free function of the same name;
+// use those directly when working with raw channels. Go methods cannot
+// introduce type parameters, so changing the element type goes through the
+// MapStream function instead of a method.
+type Stream[T any] struct {
+	ctx context.Context
+	c   <-chan T
+}
+
+// NewStream wraps c. Every stage derived from the stream stops when ctx is
+// done.
+func NewStream[T any](ctx context.Context, c <-chan T) Stream[T] {
+	return Stream[T]{ctx: ctx, c: c}
+}
+
+// Chan returns the underlying channel.
+func (s Stream[T]) Chan() <-chan T { return s.c }
+
+// Map applies fn to every value without changing the element type.
+func (s Stream[T]) Map(fn func(T) T) Stream[T] {
+	return NewStream(s.ctx, Map(s.ctx, s.c, fn))
+}
+
+// Filter keeps only the values for which keep returns true.
+func (s Stream[T]) Filter(keep func(T) bool) Stream[T] {
+	return NewStream(s.ctx, Filter(s.ctx, s.c, keep))
+}
+
+// Take ends the stream after n values.
+func (s Stream[T]) Take(n int) Stream[T] {
+	return NewStream(s.ctx, Take(s.ctx, s.c, n))
+}
+
+// Merge fans s and others into one stream.
+func (s Stream[T]) Merge(others ...Stream[T]) Stream[T] {
+	inputs := []<-chan T{s.c}
+	for _, o := range others {
+		inputs = append(inputs, o.c)
+	}
+	return NewStream(s.ctx, FanIn(s.ctx, inputs...))
+}
+
+// Collect blocks until the stream ends or its context is done and returns
+// every value received.
+func (s Stream[T]) Collect() []T { return Collect(s.ctx, s.c) }
+
+// MapStream is Map for functions that change the element type.
+func MapStream[In, Out any](s Stream[In], fn func(In) Out) Stream[Out] {
+	return NewStream(s.ctx, Map(s.ctx, s.c, fn))
+}
@@ -0,0 +1,32 @@
+package chans
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"testing"
+)
+
+func TestStream(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	evens := NewStream(ctx, generate(10)).Filter(func(v int) bool { return v%2 == 0 })
+	odds := NewStream(ctx, generate(10)).Filter(func(v int) bool { return v%2 == 1 })
+	got := evens.Merge(odds).Map(func(v int) int { return v * 10 }).Collect()
+	slices.Sort(got)
+	if want := []int{0, 10, 20, 30, 40, 50, 60, 70, 80, 90}; !slices.Equal(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestMapStreamTake(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	s := NewStream(ctx, Generate(ctx, -1, func(i int) int { return i }))
+	got := MapStream(s.Take(3), strconv.Itoa).Collect()
+	if want := []string{"0", "1", "2"}; !slices.Equal(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
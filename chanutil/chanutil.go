@@ -0,0 +1,121 @@
+// Package chanutil guards against the two panics of sharing a channel:
+// closing it twice, and sending on it after it was closed.
+//
+// The rule that prevents both is that only the single sender closes a
+// channel. When several goroutines may decide that the conversation is
+// over, such as the two players of a ping-pong game who both see the last
+// hit, nobody is the single sender. CloseOnce is a channel for that case:
+// any of them may close it, and a send that loses the race reports
+// ErrClosed instead of panicking.
+//
+// SafeSend and SafeClose do the same for a plain channel by recovering the
+// panic. They are for code that does not own the channel; code that does
+// should use CloseOnce, or make one goroutine responsible for closing.
+package chanutil
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrClosed is returned by sends on and closes of a closed channel.
+var ErrClosed = errors.New("chanutil: channel closed")
+
+// CloseOnce is a channel that any number of goroutines may send on and
+// close. The zero value is not usable; call NewCloseOnce.
+type CloseOnce[T any] struct {
+	c      chan T
+	closed atomic.Bool   // claimed by the first Close
+	done   chan struct{} // closed first, to turn senders away
+	// Send holds sending for reading while it may send on c, and Close
+	// holds it for writing while it closes c.
+	sending sync.RWMutex
+}
+
+// NewCloseOnce returns an open channel with room for size values.
+func NewCloseOnce[T any](size int) *CloseOnce[T] {
+	return &CloseOnce[T]{c: make(chan T, size), done: make(chan struct{})}
+}
+
+// C returns the channel to receive from. It is closed by Close, once the
+// values already sent have been received.
+func (c *CloseOnce[T]) C() <-chan T { return c.c }
+
+// Done returns a channel that is closed as soon as Close is called, for
+// use in a select.
+func (c *CloseOnce[T]) Done() <-chan struct{} { return c.done }
+
+// Send waits until v is sent. It returns ErrClosed if the channel is or
+// gets closed first, and ctx.Err() if ctx is done first; v is then not
+// sent. A Send racing with Close may go either way, but never panics.
+func (c *CloseOnce[T]) Send(ctx context.Context, v T) error {
+	c.sending.RLock()
+	defer c.sending.RUnlock()
+	select {
+	case <-c.done:
+		return ErrClosed
+	default:
+	}
+	select {
+	case c.c <- v:
+		return nil
+	case <-c.done:
+		return ErrClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close closes the channel, after waiting for the sends in progress to
+// give up. It returns ErrClosed if the channel was already closed, so
+// racing goroutines can all call Close and exactly one of them gets nil.
+func (c *CloseOnce[T]) Close() error {
+	if !c.closed.CompareAndSwap(false, true) {
+		return ErrClosed
+	}
+	close(c.done)
+	c.sending.Lock()
+	close(c.c)
+	c.sending.Unlock()
+	return nil
+}
+
+// SafeSend sends v on c, or returns ErrClosed instead of panicking if c is
+// closed, and ctx.Err() if ctx is done first.
+func SafeSend[T any](ctx context.Context, c chan<- T, v T) (err error) {
+	defer recoverClosed(&err)
+	select {
+	case c <- v:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// SafeClose closes c, or returns ErrClosed instead of panicking if c is
+// already closed.
+func SafeClose[T any](c chan<- T) (err error) {
+	defer recoverClosed(&err)
+	close(c)
+	return nil
+}
+
+// recoverClosed turns the runtime panics of a send on or a close of a
+// closed channel into ErrClosed, and lets any other panic through.
+func recoverClosed(err *error) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	if re, ok := r.(runtime.Error); ok {
+		switch re.Error() {
+		case "send on closed channel", "close of closed channel":
+			*err = ErrClosed
+			return
+		}
+	}
+	panic(r)
+}
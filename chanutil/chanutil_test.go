@@ -0,0 +1,161 @@
+package chanutil
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// panics reports whether fn panics.
+func panics(fn func()) (panicked bool) {
+	defer func() { panicked = recover() != nil }()
+	fn()
+	return false
+}
+
+// The two ways a ping-pong game panics when both players may end it by
+// closing the table: the second player to see the last hit closes it
+// again, or a player still passing the ball sends on it after the other
+// closed it.
+func TestPlainTablePanics(t *testing.T) {
+	table := make(chan int, 1)
+	close(table)
+	if !panics(func() { close(table) }) {
+		t.Error("second close did not panic")
+	}
+	if !panics(func() { table <- 1 }) {
+		t.Error("send after close did not panic")
+	}
+}
+
+// The same game on a CloseOnce: both players close the table when they
+// are done, and whoever loses the race gets ErrClosed.
+func TestPingPongBothClose(t *testing.T) {
+	const maxHits = 10
+	for range 100 {
+		table := NewCloseOnce[int](0)
+		closes := make(chan error, 2)
+		player := func() {
+			for {
+				hits, ok := <-table.C()
+				if !ok || hits+1 >= maxHits {
+					closes <- table.Close()
+					return
+				}
+				if err := table.Send(context.Background(), hits+1); err != nil {
+					closes <- table.Close()
+					return
+				}
+			}
+		}
+		go player()
+		go player()
+		if err := table.Send(context.Background(), 0); err != nil {
+			t.Fatal(err)
+		}
+
+		var won, lost int
+		for range 2 {
+			switch err := <-closes; {
+			case err == nil:
+				won++
+			case errors.Is(err, ErrClosed):
+				lost++
+			default:
+				t.Fatal(err)
+			}
+		}
+		if won != 1 || lost != 1 {
+			t.Fatalf("%d closes succeeded and %d failed, want 1 and 1", won, lost)
+		}
+	}
+}
+
+func TestConcurrentSendAndClose(t *testing.T) {
+	c := NewCloseOnce[int](4)
+	var sent, received, closed atomic.Int64
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				err := c.Send(context.Background(), 1)
+				if errors.Is(err, ErrClosed) {
+					return
+				}
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if sent.Add(1) == 1000 {
+					for range 3 {
+						if c.Close() == nil {
+							closed.Add(1)
+						}
+					}
+				}
+			}
+		}()
+	}
+	for range c.C() {
+		received.Add(1)
+	}
+	wg.Wait()
+	if n := closed.Load(); n != 1 {
+		t.Errorf("%d closes succeeded, want 1", n)
+	}
+	if sent.Load() != received.Load() {
+		t.Errorf("sent %d values but received %d", sent.Load(), received.Load())
+	}
+	if err := c.Send(context.Background(), 1); !errors.Is(err, ErrClosed) {
+		t.Errorf("Send after Close = %v, want ErrClosed", err)
+	}
+}
+
+func TestSendCanceled(t *testing.T) {
+	c := NewCloseOnce[int](0)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := c.Send(ctx, 1); !errors.Is(err, context.Canceled) {
+		t.Errorf("Send = %v, want context.Canceled", err)
+	}
+	select {
+	case <-c.Done():
+		t.Error("Done closed before Close")
+	default:
+	}
+	c.Close()
+	<-c.Done()
+}
+
+func TestSafeSendAndClose(t *testing.T) {
+	c := make(chan int, 1)
+	if err := SafeSend(context.Background(), c, 1); err != nil {
+		t.Errorf("SafeSend = %v", err)
+	}
+	if err := SafeClose(c); err != nil {
+		t.Errorf("SafeClose = %v", err)
+	}
+	if err := SafeClose(c); !errors.Is(err, ErrClosed) {
+		t.Errorf("second SafeClose = %v, want ErrClosed", err)
+	}
+	if err := SafeSend(context.Background(), c, 2); !errors.Is(err, ErrClosed) {
+		t.Errorf("SafeSend after close = %v, want ErrClosed", err)
+	}
+	if v := <-c; v != 1 {
+		t.Errorf("received %d, want 1", v)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := SafeSend(ctx, make(chan int), 1); !errors.Is(err, context.Canceled) {
+		t.Errorf("SafeSend = %v, want context.Canceled", err)
+	}
+	// Other panics are not theirs to hide.
+	if !panics(func() { SafeClose[int](nil) }) {
+		t.Error("SafeClose(nil) did not panic")
+	}
+}
@@ -0,0 +1,29 @@
+package chanutil_test
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lotusirous/gochan/chanutil"
+)
+
+func ExampleCloseOnce() {
+	results := chanutil.NewCloseOnce[string](1)
+
+	// Both the worker that finishes and the watchdog that gives up may
+	// close results; the second Close reports that it was too late.
+	fmt.Println(results.Send(context.Background(), "done"))
+	fmt.Println(results.Close())
+	fmt.Println(results.Close())
+	fmt.Println(results.Send(context.Background(), "timeout"))
+
+	for r := range results.C() {
+		fmt.Println(r)
+	}
+	// Output:
+	// <nil>
+	// <nil>
+	// chanutil: channel closed
+	// chanutil: channel closed
+	// done
+}
@@ -0,0 +1,212 @@
+// Package clock abstracts time so that code which sleeps and waits can be
+// tested, or simulated, without waiting for real.
+//
+// Code takes a Clock instead of calling the time package directly. Production
+// code passes Real; tests pass a Fake and move time forward explicitly.
+package clock
+
+import (
+	"container/heap"
+	"sync"
+	"time"
+)
+
+// Clock is the part of the time package that concurrent code needs.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+	Sleep(d time.Duration)
+	NewTimer(d time.Duration) Timer
+}
+
+// Timer is a time.Timer that can come from any Clock. A loop that waits
+// for a changing deadline should Reset one Timer rather than call After on
+// every pass: each After holds on to its channel until the deadline,
+// however long ago the loop stopped caring.
+//
+// As with time.Timer since Go 1.23, no stale value is received from C
+// after Stop or Reset returns.
+type Timer interface {
+	// C returns the channel on which the time is sent when the timer
+	// fires.
+	C() <-chan time.Time
+	// Stop prevents the timer from firing. It returns false if the timer
+	// had already fired or been stopped.
+	Stop() bool
+	// Reset changes the timer to fire after d. It returns whether the
+	// timer was active.
+	Reset(d time.Duration) bool
+}
+
+// Real is the system clock.
+var Real Clock = realClock{}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+func (realClock) Sleep(d time.Duration)                  { time.Sleep(d) }
+func (realClock) NewTimer(d time.Duration) Timer         { return realTimer{time.NewTimer(d)} }
+
+type realTimer struct{ *time.Timer }
+
+func (t realTimer) C() <-chan time.Time { return t.Timer.C }
+
+// Fake is a Clock that only moves when told to. Goroutines that call After,
+// Sleep or wait on a Timer wait until Advance moves the time past their deadline.
+//
+// The zero value is not usable; create one with NewFake.
+type Fake struct {
+	mu      sync.Mutex
+	changed *sync.Cond // broadcast whenever a waiter is added
+	now     time.Time
+	waiters waiters
+}
+
+// NewFake returns a fake clock set to start.
+func NewFake(start time.Time) *Fake {
+	f := &Fake{now: start}
+	f.changed = sync.NewCond(&f.mu)
+	return f
+}
+
+// Now returns the fake time.
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+// After returns a channel that receives the fake time once the clock has been
+// advanced by d. If d <= 0 the channel is ready immediately.
+func (f *Fake) After(d time.Duration) <-chan time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	c := make(chan time.Time, 1)
+	f.add(d, c)
+	return c
+}
+
+// NewTimer returns a Timer that fires once the clock has been advanced by
+// d. Until it fires or is stopped, it counts as a waiter.
+func (f *Fake) NewTimer(d time.Duration) Timer {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	t := &fakeTimer{f: f, c: make(chan time.Time, 1)}
+	f.add(d, t.c)
+	return t
+}
+
+// add makes c receive the time d from now. Call it with f.mu held.
+func (f *Fake) add(d time.Duration, c chan time.Time) {
+	if d <= 0 {
+		c <- f.now
+		return
+	}
+	heap.Push(&f.waiters, waiter{f.now.Add(d), c})
+	f.changed.Broadcast()
+}
+
+// remove takes the waiter on c off the heap, and drops a time already sent
+// on c. It reports whether c was waiting. Call it with f.mu held.
+func (f *Fake) remove(c chan time.Time) bool {
+	select {
+	case <-c:
+	default:
+	}
+	for i, w := range f.waiters {
+		if w.c == c {
+			heap.Remove(&f.waiters, i)
+			return true
+		}
+	}
+	return false
+}
+
+// Sleep blocks until the clock has been advanced by d.
+func (f *Fake) Sleep(d time.Duration) { <-f.After(d) }
+
+// Advance moves the clock forward by d and wakes, in deadline order, every
+// waiter whose deadline has passed. It returns how many were woken.
+func (f *Fake) Advance(d time.Duration) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = f.now.Add(d)
+	n := 0
+	for len(f.waiters) > 0 && !f.waiters[0].deadline.After(f.now) {
+		w := heap.Pop(&f.waiters).(waiter)
+		w.c <- f.now
+		n++
+	}
+	return n
+}
+
+type fakeTimer struct {
+	f *Fake
+	c chan time.Time
+}
+
+func (t *fakeTimer) C() <-chan time.Time { return t.c }
+
+func (t *fakeTimer) Stop() bool {
+	t.f.mu.Lock()
+	defer t.f.mu.Unlock()
+	return t.f.remove(t.c)
+}
+
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	t.f.mu.Lock()
+	defer t.f.mu.Unlock()
+	active := t.f.remove(t.c)
+	t.f.add(d, t.c)
+	return active
+}
+
+// Next returns the earliest deadline of a waiting goroutine, or false if
+// nobody is waiting. Advancing to it wakes at least one waiter.
+func (f *Fake) Next() (time.Time, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.waiters) == 0 {
+		return time.Time{}, false
+	}
+	return f.waiters[0].deadline, true
+}
+
+// Waiters returns how many After channels and Timers (including sleeping
+// goroutines) are waiting for their deadline.
+func (f *Fake) Waiters() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.waiters)
+}
+
+// BlockUntil waits until at least n goroutines are waiting on the clock. Use
+// it before Advance to make sure the goroutines under test reached their
+// Sleep; otherwise the advance could happen before they start waiting.
+func (f *Fake) BlockUntil(n int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for len(f.waiters) < n {
+		f.changed.Wait()
+	}
+}
+
+type waiter struct {
+	deadline time.Time
+	c        chan time.Time
+}
+
+// waiters is a min-heap ordered by deadline.
+type waiters []waiter
+
+func (h waiters) Len() int           { return len(h) }
+func (h waiters) Less(i, j int) bool { return h[i].deadline.Before(h[j].deadline) }
+func (h waiters) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *waiters) Push(x any)        { *h = append(*h, x.(waiter)) }
+func (h *waiters) Pop() any {
+	old := *h
+	w := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return w
+}
@@ -0,0 +1,126 @@
+package clock
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+var epoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func TestFakeAdvanceWakesInOrder(t *testing.T) {
+	f := NewFake(epoch)
+	var mu sync.Mutex
+	var woke []time.Duration
+	var wg sync.WaitGroup
+	for _, d := range []time.Duration{3 * time.Second, time.Second, 2 * time.Second} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			f.Sleep(d)
+			mu.Lock()
+			woke = append(woke, d)
+			mu.Unlock()
+		}()
+	}
+
+	f.BlockUntil(3)
+	if next, _ := f.Next(); !next.Equal(epoch.Add(time.Second)) {
+		t.Errorf("Next = %v, want epoch+1s", next)
+	}
+	if n := f.Advance(1500 * time.Millisecond); n != 1 {
+		t.Errorf("Advance woke %d, want 1", n)
+	}
+	f.BlockUntil(2) // the other two are still asleep
+	for {
+		mu.Lock()
+		n := len(woke)
+		mu.Unlock()
+		if n == 1 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if n := f.Advance(10 * time.Second); n != 2 {
+		t.Errorf("Advance woke %d, want 2", n)
+	}
+	wg.Wait()
+	if woke[0] != time.Second {
+		t.Errorf("first to wake slept %v, want 1s", woke[0])
+	}
+	if got := f.Now(); !got.Equal(epoch.Add(11500 * time.Millisecond)) {
+		t.Errorf("Now = %v", got)
+	}
+}
+
+func TestFakeAfterNonPositive(t *testing.T) {
+	f := NewFake(epoch)
+	select {
+	case got := <-f.After(0):
+		if !got.Equal(epoch) {
+			t.Errorf("After(0) sent %v", got)
+		}
+	default:
+		t.Fatal("After(0) not ready immediately")
+	}
+	if f.Waiters() != 0 {
+		t.Errorf("After(0) registered a waiter")
+	}
+}
+
+func TestFakeTimer(t *testing.T) {
+	f := NewFake(epoch)
+	tm := f.NewTimer(time.Second)
+	if f.Waiters() != 1 {
+		t.Fatalf("Waiters = %d after NewTimer, want 1", f.Waiters())
+	}
+	if !tm.Reset(2 * time.Second) {
+		t.Error("Reset of a pending timer returned false")
+	}
+	if f.Waiters() != 1 {
+		t.Fatalf("Waiters = %d after Reset, want 1", f.Waiters())
+	}
+	f.Advance(time.Second)
+	select {
+	case <-tm.C():
+		t.Fatal("timer fired at its old deadline")
+	default:
+	}
+	f.Advance(time.Second)
+	if got := <-tm.C(); !got.Equal(epoch.Add(2 * time.Second)) {
+		t.Errorf("timer sent %v, want epoch+2s", got)
+	}
+
+	tm.Reset(time.Second)
+	if !tm.Stop() {
+		t.Error("Stop of a pending timer returned false")
+	}
+	if tm.Stop() {
+		t.Error("second Stop returned true")
+	}
+	if f.Waiters() != 0 {
+		t.Errorf("Waiters = %d after Stop, want 0", f.Waiters())
+	}
+
+	// A time sent but not received is dropped by Reset.
+	tm.Reset(time.Second)
+	f.Advance(time.Second)
+	tm.Reset(time.Second)
+	select {
+	case <-tm.C():
+		t.Fatal("stale time received after Reset")
+	default:
+	}
+}
+
+func TestReal(t *testing.T) {
+	start := Real.Now()
+	Real.Sleep(time.Millisecond)
+	<-Real.After(time.Millisecond)
+	tm := Real.NewTimer(time.Hour)
+	tm.Reset(time.Millisecond)
+	<-tm.C()
+	if time.Since(start) < 3*time.Millisecond {
+		t.Error("Real clock did not wait")
+	}
+}
@@ -0,0 +1,25 @@
+package clock_test
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/lotusirous/gochan/clock"
+)
+
+func ExampleFake() {
+	clk := clock.NewFake(time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC))
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		clk.Sleep(time.Hour) // returns at once once the clock is advanced
+		fmt.Println("woke at", clk.Now().Format("15:04"))
+	}()
+
+	clk.BlockUntil(1)
+	fmt.Println(clk.Advance(time.Hour), "woken")
+	<-done
+	// Output:
+	// 1 woken
+	// woke at 10:00
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lotusirous/gochan/teach"
+)
+
+// Animation settings.
+const (
+	frameInterval = 50 * time.Millisecond
+	outputLines   = 5 // lines of the example's output under the board
+	minColumn     = 10
+)
+
+// clearScreen moves the cursor home and clears the terminal.
+const clearScreen = "\x1b[H\x1b[2J"
+
+// animation is an ASCII board of an example's participants and what passes
+// between them, built from its teach layout and pass events. It also
+// collects the example's output, which is shown under the board instead of
+// scrolling it away.
+type animation struct {
+	example string
+	start   time.Time
+
+	mu      sync.Mutex
+	nodes   []string          // left to right
+	holding map[string]string // what each participant holds
+	last    *teach.Event      // the most recent pass
+	edges   []edge            // in order of first use
+	passes  map[edge]int
+	output  []string
+	partial []byte // output after the last newline
+}
+
+type edge struct{ from, to string }
+
+func newAnimation(example string, start time.Time) *animation {
+	return &animation{
+		example: example,
+		start:   start,
+		holding: make(map[string]string),
+		passes:  make(map[edge]int),
+	}
+}
+
+// add folds an event into the board.
+func (a *animation) add(e teach.Event) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	switch e.Kind {
+	case teach.KindLayout:
+		layout := strings.Split(e.Text, "\n")
+		for _, n := range a.nodes {
+			if !slices.Contains(layout, n) {
+				layout = append(layout, n)
+			}
+		}
+		a.nodes = layout
+	case teach.KindPass:
+		a.node(e.Name)
+		a.node(e.To)
+		delete(a.holding, e.Name)
+		a.holding[e.To] = e.Text
+		a.last = &e
+		k := edge{e.Name, e.To}
+		if a.passes[k] == 0 {
+			a.edges = append(a.edges, k)
+		}
+		a.passes[k]++
+	}
+}
+
+func (a *animation) node(name string) {
+	if !slices.Contains(a.nodes, name) {
+		a.nodes = append(a.nodes, name)
+	}
+}
+
+// Write collects the example's output, keeping the last few lines.
+func (a *animation) Write(p []byte) (int, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.partial = append(a.partial, p...)
+	for {
+		i := bytes.IndexByte(a.partial, '\n')
+		if i < 0 {
+			break
+		}
+		a.output = append(a.output, string(a.partial[:i]))
+		a.partial = a.partial[i+1:]
+	}
+	if len(a.output) > outputLines {
+		a.output = a.output[len(a.output)-outputLines:]
+	}
+	return len(p), nil
+}
+
+// frame draws the board as it is at now.
+func (a *animation) frame(now time.Time) string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s  %v\n\n", a.example, now.Sub(a.start).Round(100*time.Millisecond))
+
+	col := minColumn
+	for _, n := range a.nodes {
+		col = max(col, len(n)+4)
+	}
+	for _, h := range a.holding {
+		col = max(col, len(h)+4)
+	}
+	row := func(cell func(n string) string) {
+		line := ""
+		for _, n := range a.nodes {
+			line += center(cell(n), col)
+		}
+		b.WriteString(strings.TrimRight(line, " ") + "\n")
+	}
+	row(func(n string) string { return "[" + n + "]" })
+	row(func(n string) string { return a.holding[n] })
+	b.WriteString(a.arrow(col) + "\n\n")
+
+	for _, k := range a.edges {
+		fmt.Fprintf(&b, "  %s -> %s  x%d\n", k.from, k.to, a.passes[k])
+	}
+	if len(a.output) > 0 {
+		b.WriteString("\noutput:\n")
+		for _, l := range a.output {
+			b.WriteString("  " + l + "\n")
+		}
+	}
+	return b.String()
+}
+
+// arrow draws the most recent pass from the center of one column to the
+// center of the other.
+func (a *animation) arrow(col int) string {
+	if a.last == nil {
+		return ""
+	}
+	from := slices.Index(a.nodes, a.last.Name)*col + col/2
+	to := slices.Index(a.nodes, a.last.To)*col + col/2
+	if from == to {
+		return ""
+	}
+	lo, hi := min(from, to), max(from, to)
+	line := []byte(strings.Repeat(" ", lo) + strings.Repeat("-", hi-lo+1))
+	if to > from {
+		line[len(line)-1] = '>'
+	} else {
+		line[lo] = '<'
+	}
+	return string(line)
+}
+
+func center(s string, width int) string {
+	if len(s) >= width {
+		return s
+	}
+	left := (width - len(s)) / 2
+	return strings.Repeat(" ", left) + s + strings.Repeat(" ", width-len(s)-left)
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lotusirous/gochan/teach"
+)
+
+func TestAnimationFrame(t *testing.T) {
+	start := time.Now()
+	a := newAnimation("13-adv-pingpong", start)
+	a.add(teach.Event{Kind: teach.KindPass, Name: "table", To: "ping", Text: "(o)"})
+	a.add(teach.Event{Kind: teach.KindLayout, Text: "ping\ntable\npong"})
+	for _, p := range [][2]string{{"ping", "table"}, {"table", "pong"}, {"pong", "table"}} {
+		a.add(teach.Event{Kind: teach.KindPass, Name: p[0], To: p[1], Text: "(o)"})
+	}
+	fmt.Fprint(a, "ping 1\npong 2\npo")
+
+	got := a.frame(start.Add(1500 * time.Millisecond))
+	want := `13-adv-pingpong  1.5s
+
+  [ping]   [table]    [pong]
+             (o)
+               <----------
+
+  table -> ping  x1
+  ping -> table  x1
+  table -> pong  x1
+  pong -> table  x1
+
+output:
+  ping 1
+  pong 2
+`
+	if got != want {
+		t.Errorf("frame:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestAnimationKeepsLastOutput(t *testing.T) {
+	a := newAnimation("x", time.Now())
+	for i := range 20 {
+		fmt.Fprintf(a, "line %d\n", i)
+	}
+	f := a.frame(time.Now())
+	if strings.Contains(f, "line 14") || !strings.Contains(f, "line 15\n  line 16\n  line 17\n  line 18\n  line 19\n") {
+		t.Errorf("frame shows the wrong output:\n%s", f)
+	}
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"text/tabwriter"
+	"time"
+
+	"github.com/lotusirous/gochan/teach"
+)
+
+// variant is one way of solving the problem of a comparison.
+type variant struct {
+	Label   string
+	Example string // directory name
+	Args    []string
+}
+
+// comparisons groups examples that solve the same problem, from the
+// sequential baseline to the concurrent versions. Each example must be
+// instrumented with teach, which reports its run time and allocations.
+var comparisons = map[string][]variant{
+	"google": {
+		{"sequential", "9-google1.0", nil},
+		{"concurrent", "10-google2.0", nil},
+		{"timeout", "11-google2.1", nil},
+		{"replicated", "12-google3.0", nil},
+	},
+}
+
+// findComparison returns the comparison that example e takes part in.
+func findComparison(e example) (string, []variant, error) {
+	for name, vs := range comparisons {
+		if slices.ContainsFunc(vs, func(v variant) bool { return v.Example == e.Name }) {
+			return name, vs, nil
+		}
+	}
+	return "", nil, fmt.Errorf("patterns: %s has no sequential and concurrent variants to compare", e.Name)
+}
+
+// withArgs returns vs with args added to the arguments of every variant.
+func withArgs(vs []variant, args []string) []variant {
+	vs = slices.Clone(vs)
+	for i := range vs {
+		vs[i].Args = append(slices.Clip(vs[i].Args), args...)
+	}
+	return vs
+}
+
+// compareOptions are the flags of the compare command.
+type compareOptions struct {
+	runs int
+}
+
+func parseCompare(args []string, stderr io.Writer) (compareOptions, []string, error) {
+	var o compareOptions
+	fs := flag.NewFlagSet("compare", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.IntVar(&o.runs, "n", 5, "runs of each variant")
+	if err := fs.Parse(args); err != nil {
+		return o, nil, err
+	}
+	if o.runs < 1 {
+		return o, nil, fmt.Errorf("patterns: -n must be at least 1, not %d", o.runs)
+	}
+	return o, fs.Args(), nil
+}
+
+// runStats is what one run of a variant reported when it exited.
+type runStats struct {
+	elapsed       time.Duration
+	allocs, bytes float64
+}
+
+// compare builds every variant once, runs them one after the other
+// o.runs times and prints a table of their mean and fastest times and
+// mean allocations.
+func compare(root, name string, vs []variant, o compareOptions, stdout, stderr io.Writer) error {
+	tmp, err := os.MkdirTemp("", "patterns-compare-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	fmt.Fprintf(stdout, "comparing %d variants of %s, %d runs each\n\n", len(vs), name, o.runs)
+	tw := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "variant\texample\tmean\tmin\tspeedup\tallocs\tbytes\t")
+	var baseline time.Duration
+	for i, v := range vs {
+		bin := filepath.Join(tmp, v.Example)
+		build := exec.Command("go", "build", "-o", bin, "./examples/"+v.Example)
+		build.Dir, build.Stdout, build.Stderr = root, stderr, stderr
+		if err := build.Run(); err != nil {
+			return err
+		}
+		var runs []runStats
+		for range o.runs {
+			s, err := measure(bin, v, stderr)
+			if err != nil {
+				return err
+			}
+			runs = append(runs, s)
+		}
+
+		var sum, fastest time.Duration
+		var allocs, bytes float64
+		for _, r := range runs {
+			sum += r.elapsed
+			if fastest == 0 || r.elapsed < fastest {
+				fastest = r.elapsed
+			}
+			allocs += r.allocs
+			bytes += r.bytes
+		}
+		n := time.Duration(len(runs))
+		mean := sum / n
+		if i == 0 {
+			baseline = mean
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%v\t%v\tx%.2f\t%.0f\t%.0f\t\n", v.Label, v.Example,
+			mean.Round(time.Microsecond), fastest.Round(time.Microsecond),
+			float64(baseline)/float64(mean), allocs/float64(n), bytes/float64(n))
+	}
+	return tw.Flush()
+}
+
+// measure runs bin once, discarding its output, and returns the stats it
+// reports through teach.
+func measure(bin string, v variant, stderr io.Writer) (runStats, error) {
+	l, err := listenEvents()
+	if err != nil {
+		return runStats{}, err
+	}
+	var s runStats
+	exited := false
+	collected := make(chan struct{})
+	go func() {
+		defer close(collected)
+		for e := range l.Events() {
+			if e.Kind != teach.KindExit {
+				continue
+			}
+			exited = true
+			switch e.Name {
+			case "elapsed":
+				s.elapsed = time.Duration(e.Value * float64(time.Second))
+			case "allocs":
+				s.allocs = e.Value
+			case "bytes":
+				s.bytes = e.Value
+			}
+		}
+	}()
+	cmd := exec.Command(bin, v.Args...)
+	cmd.Env = append(os.Environ(), l.Env())
+	cmd.Stdout, cmd.Stderr = io.Discard, stderr
+	err = cmd.Run()
+	l.Close()
+	<-collected
+	if err != nil {
+		return s, err
+	}
+	if !exited {
+		return s, fmt.Errorf("patterns: %s reported no stats; does its main call teach.Start?", v.Example)
+	}
+	return s, nil
+}
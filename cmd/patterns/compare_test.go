@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestComparisonsExist(t *testing.T) {
+	root, err := moduleRoot(".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	examples, err := listExamples(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, vs := range comparisons {
+		for _, v := range vs {
+			e, err := findExample(examples, v.Example)
+			if err != nil {
+				t.Errorf("%s: %v", name, err)
+				continue
+			}
+			if got, _, err := findComparison(e); err != nil || got != name {
+				t.Errorf("findComparison(%s) = %q, %v; want %s", e.Name, got, err, name)
+			}
+		}
+	}
+	if _, _, err := findComparison(example{8, "8-daisy-chan"}); err == nil {
+		t.Error("daisy-chan has variants to compare")
+	}
+}
+
+func TestCompare(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds and runs the Google search examples")
+	}
+	var out bytes.Buffer
+	if err := run([]string{"compare", "-n", "1", "google2.0", "-latency", "1ms"}, &out, &out); err != nil {
+		t.Fatalf("compare: %v\n%s", err, out.String())
+	}
+	for _, want := range []string{"4 variants of google", "sequential   9-google1.0", "x1.00", "replicated  12-google3.0"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output misses %q:\n%s", want, out.String())
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"embed"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/lotusirous/gochan/teach"
+)
+
+//go:embed dashboard
+var dashboardFiles embed.FS
+
+// Dashboard limits.
+const (
+	goroutineHistory = 300 // samples, 30s at teach.SampleInterval
+	recentEvents     = 50
+)
+
+// dashboard serves the live state of a running example: an embedded page
+// that polls /state, which is built from the example's teach events.
+type dashboard struct {
+	example string
+
+	mu         sync.Mutex
+	goroutines []float64
+	chans      map[string]chanLevel
+	stats      map[string]float64
+	recent     []teach.Event
+	finished   bool
+}
+
+type chanLevel struct {
+	Len int `json:"len"`
+	Cap int `json:"cap"`
+}
+
+func newDashboard(example string) *dashboard {
+	return &dashboard{
+		example: example,
+		chans:   make(map[string]chanLevel),
+		stats:   make(map[string]float64),
+	}
+}
+
+// add folds an event into the state.
+func (d *dashboard) add(e teach.Event) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	switch e.Kind {
+	case teach.KindGoroutines:
+		d.goroutines = append(d.goroutines, e.Value)
+		if len(d.goroutines) > goroutineHistory {
+			d.goroutines = d.goroutines[len(d.goroutines)-goroutineHistory:]
+		}
+	case teach.KindChan:
+		d.chans[e.Name] = chanLevel{int(e.Value), e.Cap}
+	case teach.KindStat, teach.KindExit:
+		d.stats[e.Name] = e.Value
+	default:
+		d.recent = append(d.recent, e)
+		if len(d.recent) > recentEvents {
+			d.recent = d.recent[len(d.recent)-recentEvents:]
+		}
+	}
+}
+
+// finish marks the example as exited.
+func (d *dashboard) finish() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.finished = true
+}
+
+type dashboardState struct {
+	Example    string               `json:"example"`
+	Finished   bool                 `json:"finished"`
+	Goroutines []float64            `json:"goroutines"`
+	Chans      map[string]chanLevel `json:"chans"`
+	Stats      map[string]float64   `json:"stats"`
+	Recent     []teach.Event        `json:"recent"`
+	Now        time.Time            `json:"now"`
+}
+
+func (d *dashboard) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/state":
+		d.mu.Lock()
+		state := dashboardState{d.example, d.finished, d.goroutines, d.chans, d.stats, d.recent, time.Now()}
+		w.Header().Set("Content-Type", "application/json")
+		err := json.NewEncoder(w).Encode(state)
+		d.mu.Unlock()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	case "/":
+		http.ServeFileFS(w, r, dashboardFiles, "dashboard/index.html")
+	default:
+		http.NotFound(w, r)
+	}
+}
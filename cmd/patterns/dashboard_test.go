@@ -0,0 +1,73 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/lotusirous/gochan/teach"
+)
+
+// connect points teach at a fresh listener and returns it.
+func connect(t *testing.T) *eventListener {
+	t.Helper()
+	l, err := listenEvents()
+	if err != nil {
+		t.Fatal(err)
+	}
+	name, value, _ := strings.Cut(l.Env(), "=")
+	t.Setenv(name, value)
+	return l
+}
+
+func TestDashboardState(t *testing.T) {
+	l := connect(t)
+	d := newDashboard("18-worker-pool")
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for e := range l.Events() {
+			d.add(e)
+		}
+	}()
+
+	stop := teach.Start()
+	jobs := teach.WatchChan("jobs", make(chan int, 8))
+	jobs <- 1
+	jobs <- 2
+	teach.Stat("finished", 5)
+	teach.Logf("worker 1 started job 3")
+	stop()
+	l.Close()
+	<-done
+	d.finish()
+
+	rec := httptest.NewRecorder()
+	d.ServeHTTP(rec, httptest.NewRequest("GET", "/state", nil))
+	var s dashboardState
+	if err := json.Unmarshal(rec.Body.Bytes(), &s); err != nil {
+		t.Fatal(err)
+	}
+	if s.Example != "18-worker-pool" || !s.Finished {
+		t.Errorf("state = %+v", s)
+	}
+	if c := s.Chans["jobs"]; c.Len != 2 || c.Cap != 8 {
+		t.Errorf("jobs channel = %+v, want 2/8", c)
+	}
+	if s.Stats["finished"] != 5 {
+		t.Errorf("stats = %v", s.Stats)
+	}
+	if len(s.Goroutines) == 0 {
+		t.Error("no goroutine samples")
+	}
+	if len(s.Recent) != 1 || s.Recent[0].Text != "worker 1 started job 3" {
+		t.Errorf("recent = %+v", s.Recent)
+	}
+
+	rec = httptest.NewRecorder()
+	d.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	if !strings.Contains(rec.Body.String(), "patterns dashboard") {
+		t.Errorf("index page:\n%s", rec.Body.String())
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+	"sync"
+
+	"github.com/lotusirous/gochan/teach"
+)
+
+// eventListener receives the events of examples instrumented with teach.
+type eventListener struct {
+	ln     net.Listener
+	events chan teach.Event
+	wg     sync.WaitGroup
+
+	mu    sync.Mutex
+	conns []net.Conn
+}
+
+// listenEvents starts listening on a local port. Every connection's events
+// are decoded onto Events until Close.
+func listenEvents() (*eventListener, error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	l := &eventListener{ln: ln, events: make(chan teach.Event, 256)}
+	l.wg.Add(1)
+	go func() {
+		defer l.wg.Done()
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			l.mu.Lock()
+			l.conns = append(l.conns, conn)
+			l.mu.Unlock()
+			l.wg.Add(1)
+			go func() {
+				defer l.wg.Done()
+				defer conn.Close()
+				s := bufio.NewScanner(conn)
+				for s.Scan() {
+					var e teach.Event
+					if json.Unmarshal(s.Bytes(), &e) == nil {
+						l.events <- e
+					}
+				}
+			}()
+		}
+	}()
+	return l, nil
+}
+
+// Env returns the environment variable that points an example at l.
+func (l *eventListener) Env() string { return teach.EnvEvents + "=" + l.ln.Addr().String() }
+
+// Events streams the events received. It is closed by Close.
+func (l *eventListener) Events() <-chan teach.Event { return l.events }
+
+// Send writes a line back to every connected example.
+func (l *eventListener) Send(line string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, c := range l.conns {
+		fmt.Fprintln(c, line)
+	}
+}
+
+// Close stops listening and waits for the connected examples to hang up,
+// which they do when they exit.
+func (l *eventListener) Close() {
+	l.ln.Close()
+	l.wg.Wait()
+	close(l.events)
+}
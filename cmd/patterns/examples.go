@@ -0,0 +1,70 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// example is one numbered directory under examples/.
+type example struct {
+	Num  int
+	Name string // directory name, e.g. "8-daisy-chan"
+}
+
+// Slug is the name without the number, e.g. "daisy-chan".
+func (e example) Slug() string {
+	_, slug, _ := strings.Cut(e.Name, "-")
+	return slug
+}
+
+// moduleRoot walks up from dir until it finds go.mod.
+func moduleRoot(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for {
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+			return dir, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", errors.New("patterns: go.mod not found; run from inside the repository")
+		}
+		dir = parent
+	}
+}
+
+// listExamples returns the examples under root/examples sorted by number.
+func listExamples(root string) ([]example, error) {
+	entries, err := os.ReadDir(filepath.Join(root, "examples"))
+	if err != nil {
+		return nil, err
+	}
+	var out []example
+	for _, e := range entries {
+		num, _, ok := strings.Cut(e.Name(), "-")
+		n, err := strconv.Atoi(num)
+		if !e.IsDir() || !ok || err != nil {
+			continue
+		}
+		out = append(out, example{Num: n, Name: e.Name()})
+	}
+	slices.SortFunc(out, func(a, b example) int { return a.Num - b.Num })
+	return out, nil
+}
+
+// findExample accepts "8", "8-daisy-chan" or "daisy-chan".
+func findExample(examples []example, query string) (example, error) {
+	for _, e := range examples {
+		if query == e.Name || query == e.Slug() || query == strconv.Itoa(e.Num) {
+			return e, nil
+		}
+	}
+	return example{}, fmt.Errorf("patterns: unknown example %q (see 'patterns list')", query)
+}
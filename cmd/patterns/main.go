@@ -0,0 +1,179 @@
+// Command patterns lists and runs the numbered examples.
+//
+// Usage:
+//
+//	patterns list
+//	patterns run [flags] <example> [args...]
+//	patterns compare [-n runs] <example or comparison> [args...]
+//	patterns verify [-solution] <exercise>
+//
+// An example is named by its number, its directory name or the directory name
+// without the number: "8", "8-daisy-chan" and "daisy-chan" are the same
+// example. Arguments after the name are passed to the example.
+//
+// Examples instrumented with the teach package stream events back to the
+// runner, which the flags of run use:
+//
+//	-dashboard addr   serve a live web dashboard of goroutines, channel fill
+//	                  levels, stats and recent events on addr
+//	-animate          redraw an ASCII board of the example's participants
+//	                  and what passes between them, such as the ball in
+//	                  ping-pong or the messages in fan-in
+//	-step             stop at each teach.Pause point in the example and wait
+//	                  for Enter, for a guided walkthrough
+//	-explain          start every line the example prints with teach.Printf
+//	                  with the role of the goroutine printing it, such as
+//	                  [worker-5], so interleavings are easy to follow
+//	-record file      write every event to file, including the sends and
+//	                  receives on channels instrumented with teach.Send
+//	                  and teach.Recv
+//	-replay file      run the example again with the send timing of a
+//	                  recording and report whether every channel received
+//	                  the same messages in the same order
+//	-trace file       write an execution trace to file; examples mark their
+//	                  work with runtime/trace tasks and regions, so
+//	                  "go tool trace file" shows which pattern step each
+//	                  goroutine was running
+//	-scenario file    pass the example the flags set in a JSON or YAML
+//	                  file, such as its worker count, arrival pattern and
+//	                  injected faults; a bare name is looked up in
+//	                  scenarios/, and the example may be left out if the
+//	                  scenario names it
+//
+// compare runs the sequential and concurrent variants of an example one
+// after the other and prints a table of their run times and allocations.
+// A comparison is named by any of its examples or by its own name, such as
+// "google" for the searches 9 to 12. Arguments after the name are passed
+// to every variant, so "patterns compare google -latency pareto:10ms,1.2"
+// compares the searches against backends with a heavy latency tail.
+//
+// The exercises under exercises/ each ask for one function. verify checks
+// an exercise with tests kept inside this command, for correctness and for
+// goroutine leaks; -solution checks the reference solution instead.
+//
+// The examples are separate main packages, so run builds and starts them with
+// "go run"; it must be used from inside the repository with a Go toolchain
+// on the PATH.
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+const usage = `usage:
+  patterns list
+  patterns run [-dashboard addr] [-animate] [-step] [-explain] [-record file] [-replay file] [-trace file] [-scenario file] <example> [args...]
+  patterns compare [-n runs] <example> [args...]
+  patterns verify [-solution] <exercise>
+`
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout, os.Stderr); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		var exit *exec.ExitError
+		if errors.As(err, &exit) {
+			os.Exit(exit.ExitCode())
+		}
+		os.Exit(2)
+	}
+}
+
+func run(args []string, stdout, stderr io.Writer) error {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return errors.New("patterns: missing command")
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	root, err := moduleRoot(wd)
+	if err != nil {
+		return err
+	}
+	examples, err := listExamples(root)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "list":
+		for _, e := range examples {
+			fmt.Fprintf(stdout, "%3d  %s\n", e.Num, e.Slug())
+		}
+		return nil
+	case "run":
+		o, rest, err := parseRun(args[1:], stderr)
+		if err != nil {
+			return err
+		}
+		var sc scenario
+		if o.scenario != "" {
+			if sc, err = loadScenario(root, o.scenario); err != nil {
+				return err
+			}
+			if len(rest) == 0 && sc.Example != "" {
+				rest = []string{sc.Example}
+			}
+		}
+		if len(rest) == 0 {
+			fmt.Fprint(stderr, usage)
+			return errors.New("patterns: run needs an example")
+		}
+		e, err := findExample(examples, rest[0])
+		if err != nil {
+			return err
+		}
+		exampleArgs := rest[1:]
+		if o.scenario != "" {
+			if exampleArgs, err = sc.apply(examples, e, exampleArgs); err != nil {
+				return err
+			}
+			if sc.About != "" {
+				fmt.Fprintf(stderr, "patterns: scenario: %s\n", sc.About)
+			}
+		}
+		return runExample(root, e, exampleArgs, o, stdout, stderr)
+	case "compare":
+		o, rest, err := parseCompare(args[1:], stderr)
+		if err != nil {
+			return err
+		}
+		if len(rest) == 0 {
+			fmt.Fprint(stderr, usage)
+			return errors.New("patterns: compare needs an example")
+		}
+		name, vs := rest[0], comparisons[rest[0]]
+		if vs == nil {
+			e, err := findExample(examples, rest[0])
+			if err != nil {
+				return err
+			}
+			if name, vs, err = findComparison(e); err != nil {
+				return err
+			}
+		}
+		return compare(root, name, withArgs(vs, rest[1:]), o, stdout, stderr)
+	case "verify":
+		o, rest, err := parseVerify(args[1:], stderr)
+		if err != nil {
+			return err
+		}
+		if len(rest) != 1 {
+			fmt.Fprint(stderr, usage)
+			return fmt.Errorf("patterns: verify needs one exercise (have %s)", strings.Join(listExercises(), ", "))
+		}
+		name, err := findExercise(rest[0])
+		if err != nil {
+			return err
+		}
+		return verifyExercise(root, name, o, stdout, stderr)
+	}
+	fmt.Fprint(stderr, usage)
+	return fmt.Errorf("patterns: unknown command %q", args[0])
+}
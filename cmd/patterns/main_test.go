@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFindExample(t *testing.T) {
+	root, err := moduleRoot(".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	examples, err := listExamples(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(examples) == 0 || examples[0].Num != 1 {
+		t.Fatalf("examples = %v, want them sorted from 1", examples)
+	}
+
+	for _, q := range []string{"8", "8-daisy-chan", "daisy-chan"} {
+		e, err := findExample(examples, q)
+		if err != nil || e.Name != "8-daisy-chan" {
+			t.Errorf("findExample(%q) = %v, %v", q, e, err)
+		}
+	}
+	if _, err := findExample(examples, "nope"); err == nil {
+		t.Error("findExample(nope) succeeded")
+	}
+}
+
+func TestList(t *testing.T) {
+	var out bytes.Buffer
+	if err := run([]string{"list"}, &out, &out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "  8  daisy-chan\n") {
+		t.Errorf("list output misses daisy-chan:\n%s", out.String())
+	}
+}
+
+func TestRunExample(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds an example with go run")
+	}
+	var out bytes.Buffer
+	if err := run([]string{"run", "daisy-chan"}, &out, &out); err != nil {
+		t.Fatalf("run daisy-chan: %v\n%s", err, out.String())
+	}
+	if first, _, _ := strings.Cut(out.String(), "\n"); first != "100001" {
+		t.Errorf("daisy-chan printed %q", out.String())
+	}
+}
+
+func TestRunTrace(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds an example with go run")
+	}
+	file := filepath.Join(t.TempDir(), "out.trace")
+	var out bytes.Buffer
+	if err := run([]string{"run", "-trace", file, "fanin"}, &out, &out); err != nil {
+		t.Fatalf("run -trace fanin: %v\n%s", err, out.String())
+	}
+	fi, err := os.Stat(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Size() == 0 {
+		t.Error("empty trace")
+	}
+	if !strings.Contains(out.String(), "go tool trace "+file) {
+		t.Errorf("output does not say how to view the trace:\n%s", out.String())
+	}
+}
+
+func TestUnknownCommand(t *testing.T) {
+	var out bytes.Buffer
+	if err := run([]string{"frobnicate"}, &out, &out); err == nil {
+		t.Error("unknown command succeeded")
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+
+	"github.com/lotusirous/gochan/teach"
+)
+
+// recorder writes every event of a run to a file, one JSON object per
+// line, for a later -replay.
+type recorder struct {
+	f   *os.File
+	w   *bufio.Writer
+	enc *json.Encoder
+	n   int
+	err error
+}
+
+func newRecorder(file string) (*recorder, error) {
+	f, err := os.Create(file)
+	if err != nil {
+		return nil, err
+	}
+	w := bufio.NewWriter(f)
+	return &recorder{f: f, w: w, enc: json.NewEncoder(w)}, nil
+}
+
+func (r *recorder) add(e teach.Event) {
+	if r.err == nil {
+		r.err = r.enc.Encode(e)
+		r.n++
+	}
+}
+
+// close flushes the file and returns the first error writing it.
+func (r *recorder) close() error {
+	return errors.Join(r.err, r.w.Flush(), r.f.Close())
+}
+
+// readRecording reads the events written by a recorder.
+func readRecording(file string) ([]teach.Event, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var events []teach.Event
+	dec := json.NewDecoder(f)
+	for dec.More() {
+		var e teach.Event
+		if err := dec.Decode(&e); err != nil {
+			return nil, fmt.Errorf("patterns: %s: %w", file, err)
+		}
+		events = append(events, e)
+	}
+	return events, nil
+}
+
+// receives returns the values received on each instrumented channel, in
+// order.
+func receives(events []teach.Event) map[string][]string {
+	got := make(map[string][]string)
+	for _, e := range events {
+		if e.Kind == teach.KindRecv {
+			got[e.Name] = append(got[e.Name], e.Text)
+		}
+	}
+	return got
+}
+
+// compareReceives describes, one line per channel, whether a replay
+// received what the recording did in the same order.
+func compareReceives(recorded, replayed map[string][]string) []string {
+	var lines []string
+	for _, name := range slices.Sorted(maps.Keys(recorded)) {
+		want, got := recorded[name], replayed[name]
+		i := 0
+		for i < min(len(want), len(got)) && want[i] == got[i] {
+			i++
+		}
+		var line string
+		switch {
+		case i < min(len(want), len(got)):
+			line = fmt.Sprintf("%s differs at message %d: recorded %q, replayed %q", name, i+1, want[i], got[i])
+		case len(want) != len(got):
+			line = fmt.Sprintf("%s received %d messages, the recording has %d", name, len(got), len(want))
+		default:
+			line = fmt.Sprintf("%s received the same %d messages in the same order", name, len(want))
+		}
+		lines = append(lines, line)
+	}
+	for _, name := range slices.Sorted(maps.Keys(replayed)) {
+		if _, ok := recorded[name]; !ok {
+			lines = append(lines, fmt.Sprintf("%s is not in the recording", name))
+		}
+	}
+	return lines
+}
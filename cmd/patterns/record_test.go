@@ -0,0 +1,57 @@
+package main
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/lotusirous/gochan/teach"
+)
+
+func TestRecording(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "run.rec")
+	r, err := newRecorder(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().Round(0)
+	want := []teach.Event{
+		{Time: now, Kind: teach.KindSend, Name: "fanIn", Text: "Joe 0", G: 7},
+		{Time: now.Add(time.Millisecond), Kind: teach.KindRecv, Name: "fanIn", Text: "Joe 0", G: 1},
+	}
+	for _, e := range want {
+		r.add(e)
+	}
+	if err := r.close(); err != nil {
+		t.Fatal(err)
+	}
+	got, err := readRecording(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.EqualFunc(got, want, func(a, b teach.Event) bool { return a.Time.Equal(b.Time) && a.Text == b.Text && a.G == b.G }) {
+		t.Errorf("read %+v, want %+v", got, want)
+	}
+}
+
+func TestCompareReceives(t *testing.T) {
+	recorded := map[string][]string{"fanIn": {"Joe 0", "Ann 0", "Joe 1"}, "done": {"ok"}}
+	for _, tc := range []struct {
+		replayed map[string][]string
+		want     []string
+	}{
+		{
+			map[string][]string{"fanIn": {"Joe 0", "Ann 0", "Joe 1"}, "done": {"ok"}},
+			[]string{"done received the same 1 messages in the same order", "fanIn received the same 3 messages in the same order"},
+		},
+		{
+			map[string][]string{"fanIn": {"Joe 0", "Joe 1", "Ann 0"}, "extra": {"x"}},
+			[]string{"done received 0 messages, the recording has 1", `fanIn differs at message 2: recorded "Ann 0", replayed "Joe 1"`, "extra is not in the recording"},
+		},
+	} {
+		if got := compareReceives(recorded, tc.replayed); !slices.Equal(got, tc.want) {
+			t.Errorf("compareReceives(%v) =\n%q\nwant\n%q", tc.replayed, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"time"
+
+	"github.com/lotusirous/gochan/teach"
+)
+
+// runOptions are the flags of the run command.
+type runOptions struct {
+	dashboard string
+	trace     string
+	animate   bool
+	step      bool
+	explain   bool
+	record    string
+	replay    string
+	scenario  string
+}
+
+func parseRun(args []string, stderr io.Writer) (runOptions, []string, error) {
+	var o runOptions
+	fs := flag.NewFlagSet("run", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.StringVar(&o.dashboard, "dashboard", "", "serve a live dashboard on `addr`, e.g. localhost:8080")
+	fs.BoolVar(&o.animate, "animate", false, "draw an ASCII animation of what the example's participants pass around")
+	fs.BoolVar(&o.step, "step", false, "stop at the example's pause points until Enter is pressed")
+	fs.BoolVar(&o.explain, "explain", false, "prefix the example's output with the role of the goroutine printing it")
+	fs.StringVar(&o.record, "record", "", "record the example's events, including instrumented sends and receives, to `file`")
+	fs.StringVar(&o.replay, "replay", "", "run the example with the send timing recorded in `file` and compare what it receives")
+	fs.StringVar(&o.trace, "trace", "", "write an execution trace to `file` for go tool trace")
+	fs.StringVar(&o.scenario, "scenario", "", "pass the example the flags set in a JSON or YAML scenario `file`")
+	if err := fs.Parse(args); err != nil {
+		return o, nil, err
+	}
+	return o, fs.Args(), nil
+}
+
+// runExample builds and runs e with go run. If any option needs the
+// example's teach events, it listens for them and hands every event to
+// each watcher.
+func runExample(root string, e example, args []string, o runOptions, stdout, stderr io.Writer) error {
+	cmd := exec.Command("go", append([]string{"run", "./examples/" + e.Name}, args...)...)
+	cmd.Dir = root
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, stdout, stderr
+	cmd.Env = os.Environ()
+
+	var watchers []func(teach.Event)
+	var after []func() // run once the example has exited, in order
+	var l *eventListener
+	if o.step {
+		// The runner reads the terminal, so the example gets no input.
+		cmd.Stdin = nil
+		cmd.Env = append(cmd.Env, teach.EnvStep+"=1")
+		s := newStepper(os.Stdin, stderr, func() { l.Send(teach.Resume) })
+		watchers = append(watchers, s.add)
+		after = append(after, s.close)
+	}
+	if o.animate {
+		a := newAnimation(e.Name, time.Now())
+		cmd.Stdout = a
+		watchers = append(watchers, a.add)
+		quit, drawn := make(chan struct{}), make(chan struct{})
+		go func() {
+			defer close(drawn)
+			tick := time.NewTicker(frameInterval)
+			defer tick.Stop()
+			for {
+				select {
+				case now := <-tick.C:
+					fmt.Fprint(stdout, clearScreen+a.frame(now))
+				case <-quit:
+					return
+				}
+			}
+		}()
+		after = append(after, func() {
+			close(quit)
+			<-drawn
+			fmt.Fprint(stdout, clearScreen+a.frame(time.Now()))
+		})
+	}
+	if o.explain {
+		cmd.Env = append(cmd.Env, teach.EnvExplain+"=1")
+	}
+	if o.record != "" {
+		r, err := newRecorder(o.record)
+		if err != nil {
+			return err
+		}
+		watchers = append(watchers, r.add)
+		after = append(after, func() {
+			if err := r.close(); err != nil {
+				fmt.Fprintln(stderr, "patterns: record:", err)
+				return
+			}
+			fmt.Fprintf(stderr, "patterns: recorded %d events to %s\n", r.n, o.record)
+		})
+	}
+	if o.replay != "" {
+		recording, err := readRecording(o.replay)
+		if err != nil {
+			return err
+		}
+		file, err := filepath.Abs(o.replay)
+		if err != nil {
+			return err
+		}
+		cmd.Env = append(cmd.Env, teach.EnvReplay+"="+file)
+		var replayed []teach.Event
+		watchers = append(watchers, func(e teach.Event) { replayed = append(replayed, e) })
+		after = append(after, func() {
+			for _, line := range compareReceives(receives(recording), receives(replayed)) {
+				fmt.Fprintln(stderr, "patterns: replay:", line)
+			}
+		})
+	}
+	if o.trace != "" {
+		// The example runs in the module root, so the file must not be
+		// relative to the caller's directory.
+		file, err := filepath.Abs(o.trace)
+		if err != nil {
+			return err
+		}
+		cmd.Env = append(cmd.Env, teach.EnvTrace+"="+file)
+		after = append(after, func() {
+			fmt.Fprintf(stderr, "patterns: trace written to %s; view it with go tool trace %[1]s\n", file)
+		})
+	}
+
+	if o.dashboard != "" {
+		d := newDashboard(e.Name)
+		ln, err := net.Listen("tcp", o.dashboard)
+		if err != nil {
+			return err
+		}
+		srv := &http.Server{Handler: d}
+		go srv.Serve(ln)
+		fmt.Fprintf(stderr, "patterns: dashboard on http://%s/\n", ln.Addr())
+		watchers = append(watchers, d.add)
+		after = append(after, func() { // last: it waits for Ctrl-C
+			d.finish()
+			fmt.Fprintln(stderr, "patterns: example exited; the dashboard stays up until interrupted")
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer stop()
+			<-ctx.Done()
+			srv.Close()
+		})
+	}
+
+	if len(watchers) == 0 {
+		err := cmd.Run()
+		for _, fn := range after {
+			fn()
+		}
+		return err
+	}
+	var err error
+	l, err = listenEvents()
+	if err != nil {
+		return err
+	}
+	cmd.Env = append(cmd.Env, l.Env())
+	delivered := make(chan struct{})
+	go func() {
+		defer close(delivered)
+		for ev := range l.Events() {
+			for _, w := range watchers {
+				w(ev)
+			}
+		}
+	}()
+	err = cmd.Run()
+	l.Close()
+	<-delivered
+	for _, fn := range after {
+		fn()
+	}
+	return err
+}
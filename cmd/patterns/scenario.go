@@ -0,0 +1,204 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// scenario is a reproducible setting of an example's flags, such as its
+// worker count, arrival pattern, message sizes and injected faults, kept in
+// a file so a demonstration can be shared and run again.
+//
+// A scenario is JSON:
+//
+//	{"example": "worker-pool", "about": "...", "flags": {"workers": 2, "failures": 0.1}}
+//
+// or the same in YAML, of which it reads the subset of one level of
+// scalars under flags, "#" comments and quoted strings:
+//
+//	example: worker-pool
+//	about: "..."
+//	flags:
+//	  workers: 2
+//	  failures: 0.1
+type scenario struct {
+	Example string            // example to run; optional
+	About   string            // what the scenario shows
+	Flags   map[string]string // flag name to value
+}
+
+// args returns the scenario's flags as example arguments, sorted by name.
+func (s scenario) args() []string {
+	var args []string
+	for _, name := range slices.Sorted(maps.Keys(s.Flags)) {
+		args = append(args, "-"+name+"="+s.Flags[name])
+	}
+	return args
+}
+
+// apply checks that the scenario is for e and returns its flags followed
+// by args, so flags given on the command line win.
+func (s scenario) apply(examples []example, e example, args []string) ([]string, error) {
+	if s.Example != "" {
+		want, err := findExample(examples, s.Example)
+		if err != nil {
+			return nil, fmt.Errorf("patterns: scenario: %w", err)
+		}
+		if want.Name != e.Name {
+			return nil, fmt.Errorf("patterns: the scenario is for %s, not %s", want.Name, e.Name)
+		}
+	}
+	return append(s.args(), args...), nil
+}
+
+// loadScenario reads the scenario file. A bare name that is not a file is
+// looked up in the scenarios directory of the repository, with or without
+// its extension, so "bursty" finds scenarios/bursty.yaml.
+func loadScenario(root, file string) (scenario, error) {
+	candidates := []string{file}
+	if !strings.ContainsRune(file, filepath.Separator) {
+		dir := filepath.Join(root, "scenarios")
+		for _, ext := range []string{"", ".yaml", ".yml", ".json"} {
+			candidates = append(candidates, filepath.Join(dir, file+ext))
+		}
+	}
+	for _, c := range candidates {
+		data, err := os.ReadFile(c)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return scenario{}, err
+		}
+		s, err := parseScenario(c, data)
+		if err != nil {
+			return scenario{}, fmt.Errorf("patterns: scenario %s: %w", c, err)
+		}
+		return s, nil
+	}
+	return scenario{}, fmt.Errorf("patterns: no scenario %s", file)
+}
+
+// parseScenario reads a scenario as JSON if file ends in .json or data
+// starts with "{", and as YAML otherwise.
+func parseScenario(file string, data []byte) (scenario, error) {
+	if filepath.Ext(file) == ".json" || bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		return parseScenarioJSON(data)
+	}
+	return parseScenarioYAML(data)
+}
+
+func parseScenarioJSON(data []byte) (scenario, error) {
+	var raw struct {
+		Example string         `json:"example"`
+		About   string         `json:"about"`
+		Flags   map[string]any `json:"flags"`
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	dec.UseNumber() // keep 1000000 from becoming 1e+06
+	if err := dec.Decode(&raw); err != nil {
+		return scenario{}, err
+	}
+	s := scenario{Example: raw.Example, About: raw.About, Flags: make(map[string]string)}
+	for name, v := range raw.Flags {
+		switch v := v.(type) {
+		case string:
+			s.Flags[name] = v
+		case json.Number:
+			s.Flags[name] = v.String()
+		case bool:
+			s.Flags[name] = strconv.FormatBool(v)
+		default:
+			return scenario{}, fmt.Errorf("flag %s: want a string, number or boolean, not %T", name, v)
+		}
+	}
+	return s, nil
+}
+
+func parseScenarioYAML(data []byte) (scenario, error) {
+	s := scenario{Flags: make(map[string]string)}
+	inFlags := false
+	for i, line := range strings.Split(string(data), "\n") {
+		bad := func(format string, args ...any) (scenario, error) {
+			return scenario{}, fmt.Errorf("line %d: "+format, append([]any{i + 1}, args...)...)
+		}
+		line = stripComment(line)
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		indented := line[0] == ' ' || line[0] == '\t'
+		key, value, ok := strings.Cut(strings.TrimSpace(line), ":")
+		if !ok {
+			return bad("want key: value")
+		}
+		key = strings.TrimSpace(key)
+		value, err := unquote(strings.TrimSpace(value))
+		if err != nil {
+			return bad("%s: %v", key, err)
+		}
+		if indented {
+			if !inFlags {
+				return bad("%s is indented, but not under flags", key)
+			}
+			s.Flags[key] = value
+			continue
+		}
+		inFlags = false
+		switch key {
+		case "example":
+			s.Example = value
+		case "about":
+			s.About = value
+		case "flags":
+			if value != "" {
+				return bad("flags takes an indented list of name: value lines")
+			}
+			inFlags = true
+		default:
+			return bad("unknown key %s; want example, about or flags", key)
+		}
+	}
+	return s, nil
+}
+
+// stripComment cuts line at a "#" that starts it or follows a space and is
+// not inside quotes.
+func stripComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// unquote removes YAML double quotes, with Go escapes, or single quotes,
+// where '' stands for '.
+func unquote(v string) (string, error) {
+	switch {
+	case len(v) >= 2 && v[0] == '"' && v[len(v)-1] == '"':
+		return strconv.Unquote(v)
+	case len(v) >= 2 && v[0] == '\'' && v[len(v)-1] == '\'':
+		return strings.ReplaceAll(v[1:len(v)-1], "''", "'"), nil
+	case strings.HasPrefix(v, "[") || strings.HasPrefix(v, "{"):
+		return "", errors.New("lists and maps are not supported")
+	}
+	return v, nil
+}
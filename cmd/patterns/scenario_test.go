@@ -0,0 +1,93 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseScenario(t *testing.T) {
+	yaml := `# a comment
+example: worker-pool
+about: "jobs # in bursts"
+flags:
+  workers: 2   # trailing comment
+  arrival: 'pareto:200ms,1.5'
+  quiet: true
+`
+	json := `{"example": "worker-pool", "about": "jobs # in bursts",
+		"flags": {"workers": 2, "arrival": "pareto:200ms,1.5", "quiet": true}}`
+	want := scenario{
+		Example: "worker-pool",
+		About:   "jobs # in bursts",
+		Flags:   map[string]string{"workers": "2", "arrival": "pareto:200ms,1.5", "quiet": "true"},
+	}
+	for file, data := range map[string]string{"s.yaml": yaml, "s.json": json, "s": json} {
+		got, err := parseScenario(file, []byte(data))
+		if err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: got %+v, %v; want %+v", file, got, err, want)
+		}
+	}
+	if args := want.args(); !slices.Equal(args, []string{"-arrival=pareto:200ms,1.5", "-quiet=true", "-workers=2"}) {
+		t.Errorf("args = %q", args)
+	}
+}
+
+func TestParseScenarioErrors(t *testing.T) {
+	for _, data := range []string{
+		"example worker-pool",
+		"  workers: 2",
+		"flags: 2",
+		"threads: 2",
+		"flags:\n  sizes: [1, 2]",
+		`{"flags": {"sizes": [1, 2]}}`,
+		`{"example": "fanin", "workers": 2}`,
+	} {
+		if s, err := parseScenario("s", []byte(data)); err == nil {
+			t.Errorf("parsed %q as %+v", data, s)
+		}
+	}
+}
+
+func TestScenarioApply(t *testing.T) {
+	examples := []example{{4, "4-fanin"}, {18, "18-worker-pool"}}
+	s := scenario{Example: "worker-pool", Flags: map[string]string{"jobs": "8"}}
+	args, err := s.apply(examples, examples[1], []string{"-jobs=2"})
+	if err != nil || !slices.Equal(args, []string{"-jobs=8", "-jobs=2"}) {
+		t.Errorf("apply = %q, %v", args, err)
+	}
+	if _, err := s.apply(examples, examples[0], nil); err == nil || !strings.Contains(err.Error(), "for 18-worker-pool") {
+		t.Errorf("apply to fanin: %v", err)
+	}
+}
+
+func TestShippedScenarios(t *testing.T) {
+	root, err := moduleRoot(".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	examples, err := listExamples(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, _ := filepath.Glob(filepath.Join(root, "scenarios", "*"))
+	if len(files) == 0 {
+		t.Fatal("no scenarios")
+	}
+	for _, f := range files {
+		name := strings.TrimSuffix(filepath.Base(f), filepath.Ext(f))
+		s, err := loadScenario(root, name)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if _, err := findExample(examples, s.Example); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+	if _, err := loadScenario(root, "no-such-scenario"); !strings.Contains(err.Error(), "no scenario") {
+		t.Errorf("missing scenario: %v", err)
+	}
+}
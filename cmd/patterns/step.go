@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+
+	"github.com/lotusirous/gochan/teach"
+)
+
+// stepper walks through an example's pause points: it reports each one and
+// waits for Enter before letting the example continue. Once its input ends
+// it lets every later pause through at once.
+type stepper struct {
+	pauses chan string
+	done   chan struct{}
+}
+
+func newStepper(in io.Reader, out io.Writer, resume func()) *stepper {
+	s := &stepper{pauses: make(chan string, 64), done: make(chan struct{})}
+	go func() {
+		defer close(s.done)
+		r := bufio.NewReader(in)
+		interactive := true
+		for label := range s.pauses {
+			if interactive {
+				fmt.Fprintf(out, "patterns: paused at %q; press Enter to continue\n", label)
+				_, err := r.ReadString('\n')
+				interactive = err == nil
+			}
+			resume()
+		}
+	}()
+	return s
+}
+
+// add queues the pause points among the events.
+func (s *stepper) add(e teach.Event) {
+	if e.Kind == teach.KindPause {
+		s.pauses <- e.Text
+	}
+}
+
+// close waits for the queued pauses to be answered.
+func (s *stepper) close() {
+	close(s.pauses)
+	<-s.done
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/lotusirous/gochan/teach"
+)
+
+func TestStepper(t *testing.T) {
+	var out bytes.Buffer
+	resumed := make(chan struct{}, 3)
+	s := newStepper(strings.NewReader("\n"), &out, func() { resumed <- struct{}{} })
+	s.add(teach.Event{Kind: teach.KindLog, Text: "not a pause"})
+	s.add(teach.Event{Kind: teach.KindPause, Text: "first"})
+	s.add(teach.Event{Kind: teach.KindPause, Text: "second"})
+	s.add(teach.Event{Kind: teach.KindPause, Text: "third"})
+	s.close()
+
+	if len(resumed) != 3 {
+		t.Errorf("resumed %d pauses, want 3", len(resumed))
+	}
+	// Enter answers the first pause; the input then ends, and the rest
+	// go through without a prompt.
+	want := "patterns: paused at \"first\"; press Enter to continue\n" +
+		"patterns: paused at \"second\"; press Enter to continue\n"
+	if out.String() != want {
+		t.Errorf("output:\n%s\nwant:\n%s", out.String(), want)
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// harness holds, for every exercise, the tests that verify checks it with
+// and a reference solution. They stay out of exercises/ so the tests are
+// hidden until verify lays them over the exercise with go test -overlay.
+//
+//go:embed harness
+var harness embed.FS
+
+// listExercises returns the names of the exercises that have a harness.
+func listExercises() []string {
+	entries, _ := fs.ReadDir(harness, "harness")
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	return names
+}
+
+// findExercise accepts an exercise by name, with or without an
+// "-exercise" suffix: "fanin" and "fanin-exercise" are the same.
+func findExercise(q string) (string, error) {
+	q = strings.TrimSuffix(q, "-exercise")
+	for _, name := range listExercises() {
+		if name == q {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("patterns: unknown exercise %q (have %s)", q, strings.Join(listExercises(), ", "))
+}
+
+// verifyOptions are the flags of the verify command.
+type verifyOptions struct {
+	solution bool
+}
+
+func parseVerify(args []string, stderr io.Writer) (verifyOptions, []string, error) {
+	var o verifyOptions
+	fs := flag.NewFlagSet("verify", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.BoolVar(&o.solution, "solution", false, "verify the reference solution instead of exercises/<name>")
+	if err := fs.Parse(args); err != nil {
+		return o, nil, err
+	}
+	return o, fs.Args(), nil
+}
+
+// verifyExercise runs the hidden tests of an exercise against the code in
+// root/exercises/name, or against the reference solution.
+func verifyExercise(root, name string, o verifyOptions, stdout, stderr io.Writer) error {
+	tmp, err := os.MkdirTemp("", "patterns-verify-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	dir := filepath.Join(root, "exercises", name)
+	replace := make(map[string]string)
+	lay := func(embedded, target string) error {
+		src, err := harness.ReadFile("harness/" + name + "/" + embedded)
+		if err != nil {
+			return err
+		}
+		file := filepath.Join(tmp, strings.TrimSuffix(embedded, ".in"))
+		replace[filepath.Join(dir, target)] = file
+		return os.WriteFile(file, src, 0o644)
+	}
+	if err := lay("verify_test.go.in", "verify_test.go"); err != nil {
+		return err
+	}
+	if o.solution {
+		if err := lay("solution.go.in", name+".go"); err != nil {
+			return err
+		}
+	}
+	overlay, err := json.Marshal(map[string]any{"Replace": replace})
+	if err != nil {
+		return err
+	}
+	overlayFile := filepath.Join(tmp, "overlay.json")
+	if err := os.WriteFile(overlayFile, overlay, 0o644); err != nil {
+		return err
+	}
+
+	cmd := exec.Command("go", "test", "-overlay", overlayFile, "-count=1", "-v", "./exercises/"+name)
+	cmd.Dir = root
+	cmd.Stdout, cmd.Stderr = stdout, stderr
+	if err := cmd.Run(); err != nil {
+		var exit *exec.ExitError
+		if errors.As(err, &exit) {
+			return fmt.Errorf("patterns: %s is not done yet; see the failures above", name)
+		}
+		return err
+	}
+	fmt.Fprintf(stdout, "patterns: %s passes, with no goroutine leaks\n", name)
+	return nil
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestFindExercise(t *testing.T) {
+	for _, q := range []string{"fanin", "fanin-exercise"} {
+		if name, err := findExercise(q); err != nil || name != "fanin" {
+			t.Errorf("findExercise(%q) = %q, %v", q, name, err)
+		}
+	}
+	if _, err := findExercise("nope"); err == nil {
+		t.Error("findExercise(nope) succeeded")
+	}
+}
+
+func TestVerifySolutions(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs go test on every exercise")
+	}
+	for _, name := range listExercises() {
+		var out bytes.Buffer
+		if err := run([]string{"verify", "-solution", name}, &out, &out); err != nil {
+			t.Errorf("the reference solution of %s fails: %v\n%s", name, err, out.String())
+		}
+	}
+}
+
+func TestVerifySkeleton(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs go test on an exercise")
+	}
+	var out bytes.Buffer
+	err := run([]string{"verify", "fanin"}, &out, &out)
+	if err == nil {
+		t.Fatalf("the unsolved exercise passes:\n%s", out.String())
+	}
+	if !strings.Contains(out.String(), "TODO: implement FanIn") {
+		t.Errorf("output does not show why:\n%s", out.String())
+	}
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// blocking matches the names of the functions and methods that, by the
+// module's conventions, may wait for another goroutine, and so must take a
+// context as their first parameter to give up waiting.
+var blocking = regexp.MustCompile(`^(Acquire|Atomically|Call|Collect|Do|Exchange[AB]?|Get|Modify|Put|Recv|Receive|Release|Renew|Run|Send|Serve|Sleep|Submit(At|After)?|Synchronize|Take|Wait)$`)
+
+// notBlocking lists the functions and methods with a blocking name that
+// take no context, and why that is fine.
+var notBlocking = map[string]string{
+	"chans.Stream.Collect":        "the stream carries the context it was made with",
+	"chans.Stream.Take":           "the stream carries the context it was made with",
+	"clock.Clock.Sleep":           "mirrors time.Sleep; ctxutil.Sleep is the cancellable form",
+	"clock.Fake.Sleep":            "mirrors time.Sleep; ctxutil.Sleep is the cancellable form",
+	"errs.Collect":                "waits only for its own functions, which close over their context",
+	"hashring.Ring.Get":           "never blocks",
+	"ivar.IVar.Put":               "never blocks",
+	"lockfree.Combiner.Do":        "waits like a mutex, for operations that must not block",
+	"lockfree.Domain.Collect":     "never blocks",
+	"lockfree.Domain.Synchronize": "waits for pinned goroutines, which must not block while pinned",
+	"lockfree.Hazard.Release":     "never blocks",
+	"lockfree.Hazards.Acquire":    "never blocks",
+	"pipeline.Pipeline.Wait":      "the stages stop when the context given to New is done",
+	"pool.Objects.Put":            "never blocks",
+	"safego.Do":                   "runs fn on the calling goroutine",
+	"stm.Var.Get":                 "reads within a transaction and never blocks",
+	"teach.Sleep":                 "mirrors time.Sleep to pace examples, and returns at once during a replay",
+	"vclock.Process.Receive":      "never blocks",
+	"watch.Value.Get":             "never blocks",
+}
+
+// libraries parses the non-test files of the module's packages, by
+// directory. It skips the examples, exercises and commands, which are
+// programs rather than APIs, the test helpers under internal, and directories with a go.mod of their own,
+// which are other modules. The experimental packages under internal are
+// libraries like the rest.
+func libraries(t *testing.T, mode parser.Mode) map[string][]*ast.File {
+	t.Helper()
+	files := make(map[string][]*ast.File)
+	fset := token.NewFileSet()
+	err := filepath.WalkDir(".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			switch path {
+			case "examples", "exercises", "cmd", "scenarios",
+				filepath.Join("internal", "exampletest"), filepath.Join("internal", "exercisetest"):
+				return filepath.SkipDir
+			}
+			if _, err := os.Stat(filepath.Join(path, "go.mod")); err == nil && path != "." {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") || filepath.Dir(path) == "." {
+			return nil
+		}
+		f, err := parser.ParseFile(fset, path, nil, mode)
+		if err != nil {
+			return err
+		}
+		files[filepath.Dir(path)] = append(files[filepath.Dir(path)], f)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+// api returns the parameters of the exported functions and methods,
+// including interface methods, of the module's packages, by qualified
+// name such as pool.Pool.Submit.
+func api(t *testing.T) map[string]*ast.FieldList {
+	t.Helper()
+	funcs := make(map[string]*ast.FieldList)
+	for _, files := range libraries(t, 0) {
+		for _, f := range files {
+			addAPI(funcs, f)
+		}
+	}
+	return funcs
+}
+
+func addAPI(funcs map[string]*ast.FieldList, f *ast.File) {
+	pkg := f.Name.Name
+	for _, decl := range f.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			if recv := receiver(decl); decl.Name.IsExported() && (recv == "" || ast.IsExported(recv)) {
+				funcs[pkg+"."+strings.TrimPrefix(recv+"."+decl.Name.Name, ".")] = decl.Type.Params
+			}
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				ts, ok := spec.(*ast.TypeSpec)
+				if !ok || !ts.Name.IsExported() {
+					continue
+				}
+				iface, ok := ts.Type.(*ast.InterfaceType)
+				if !ok {
+					continue
+				}
+				for _, m := range iface.Methods.List {
+					if fn, ok := m.Type.(*ast.FuncType); ok && len(m.Names) > 0 && m.Names[0].IsExported() {
+						funcs[pkg+"."+ts.Name.Name+"."+m.Names[0].Name] = fn.Params
+					}
+				}
+			}
+		}
+	}
+}
+
+// receiver returns the name of fn's receiver type, or "" for a function.
+func receiver(fn *ast.FuncDecl) string {
+	if fn.Recv == nil {
+		return ""
+	}
+	typ := fn.Recv.List[0].Type
+	if star, ok := typ.(*ast.StarExpr); ok {
+		typ = star.X
+	}
+	switch x := typ.(type) {
+	case *ast.IndexExpr:
+		typ = x.X
+	case *ast.IndexListExpr:
+		typ = x.X
+	}
+	return typ.(*ast.Ident).Name
+}
+
+func isContext(e ast.Expr) bool {
+	sel, ok := e.(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != "Context" {
+		return false
+	}
+	id, ok := sel.X.(*ast.Ident)
+	return ok && id.Name == "context"
+}
+
+func TestBlockingCallsTakeContext(t *testing.T) {
+	funcs := api(t)
+	for name := range notBlocking {
+		if _, ok := funcs[name]; !ok {
+			t.Errorf("notBlocking lists %s, which does not exist", name)
+		}
+	}
+	for name, params := range funcs {
+		method := name[strings.LastIndexByte(name, '.')+1:]
+		if !blocking.MatchString(method) {
+			continue
+		}
+		_, exempt := notBlocking[name]
+		takesCtx := len(params.List) > 0 && isContext(params.List[0].Type)
+		switch {
+		case !takesCtx && !exempt:
+			t.Errorf("%s may block but does not take a context first", name)
+		case takesCtx && exempt:
+			t.Errorf("%s takes a context now; remove it from notBlocking", name)
+		}
+	}
+}
+
+func TestContextComesFirst(t *testing.T) {
+	for name, params := range api(t) {
+		for i, p := range params.List {
+			if i > 0 && isContext(p.Type) {
+				t.Errorf("%s takes a context after other parameters", name)
+			}
+		}
+	}
+}
@@ -0,0 +1,218 @@
+// Package cron parses cron expressions and computes when they fire next.
+//
+// The standard five fields are supported (minute, hour, day of month, month,
+// day of week) with *, lists, ranges, steps and month and weekday names, as
+// well as the @yearly, @annually, @monthly, @weekly, @daily, @midnight and
+// @hourly shorthands and "@every <duration>". A "CRON_TZ=<zone> " prefix,
+// or ParseIn, evaluates the expression in that time zone.
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule tells when a recurring job runs next.
+type Schedule interface {
+	// Next returns the first activation strictly after t.
+	Next(t time.Time) time.Time
+}
+
+// Every is the "@every <duration>" schedule: it fires every d, counted
+// from the previous activation.
+type Every time.Duration
+
+// Next implements Schedule.
+func (e Every) Next(t time.Time) time.Time { return t.Add(time.Duration(e)) }
+
+// Spec is a parsed five-field expression. Each field is a bit set of the
+// values it matches.
+type Spec struct {
+	minute, hour, dom, month, dow uint64
+	domStar, dowStar              bool // field was "*", see dayMatches
+	loc                           *time.Location
+}
+
+var shorthands = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+type field struct {
+	name     string
+	min, max int
+	names    []string // names[i] stands for min+i
+}
+
+var fields = []field{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12, names: []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}},
+	{name: "day of week", min: 0, max: 7, names: []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}},
+}
+
+// Parse parses expr in the local time zone, unless it starts with
+// CRON_TZ=<zone>.
+func Parse(expr string) (Schedule, error) { return ParseIn(expr, time.Local) }
+
+// ParseIn parses expr and evaluates it in loc, unless it starts with
+// CRON_TZ=<zone>.
+func ParseIn(expr string, loc *time.Location) (Schedule, error) {
+	expr = strings.TrimSpace(expr)
+	if rest, ok := strings.CutPrefix(expr, "CRON_TZ="); ok {
+		zone, e, _ := strings.Cut(rest, " ")
+		l, err := time.LoadLocation(zone)
+		if err != nil {
+			return nil, fmt.Errorf("cron: %w", err)
+		}
+		loc, expr = l, strings.TrimSpace(e)
+	}
+	if d, ok := strings.CutPrefix(expr, "@every "); ok {
+		every, err := time.ParseDuration(strings.TrimSpace(d))
+		if err != nil || every <= 0 {
+			return nil, fmt.Errorf("cron: bad @every duration %q", d)
+		}
+		return Every(every), nil
+	}
+	if s, ok := shorthands[expr]; ok {
+		expr = s
+	}
+
+	parts := strings.Fields(expr)
+	if len(parts) != len(fields) {
+		return nil, fmt.Errorf("cron: %q: want %d fields, got %d", expr, len(fields), len(parts))
+	}
+	sets := make([]uint64, len(fields))
+	for i, p := range parts {
+		set, err := parseField(p, fields[i])
+		if err != nil {
+			return nil, fmt.Errorf("cron: %q: %w", expr, err)
+		}
+		sets[i] = set
+	}
+	s := &Spec{
+		minute: sets[0], hour: sets[1], dom: sets[2], month: sets[3], dow: sets[4],
+		domStar: parts[2] == "*" || strings.HasPrefix(parts[2], "*/"),
+		dowStar: parts[4] == "*" || strings.HasPrefix(parts[4], "*/"),
+		loc:     loc,
+	}
+	if s.dow&(1<<7) != 0 { // 7 is another name for Sunday
+		s.dow |= 1
+	}
+	return s, nil
+}
+
+// MustParse is Parse that panics on error, for expressions fixed in code.
+func MustParse(expr string) Schedule {
+	s, err := Parse(expr)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// parseField parses a comma separated list of *, n, a-b, each optionally
+// followed by /step.
+func parseField(s string, f field) (uint64, error) {
+	var set uint64
+	for _, item := range strings.Split(s, ",") {
+		rng, stepStr, hasStep := strings.Cut(item, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepStr)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("bad step %q in %s", stepStr, f.name)
+			}
+			step = n
+		}
+
+		lo, hi := f.min, f.max
+		switch {
+		case rng == "*":
+		case strings.Contains(rng, "-"):
+			a, b, _ := strings.Cut(rng, "-")
+			var err error
+			if lo, err = f.value(a); err != nil {
+				return 0, err
+			}
+			if hi, err = f.value(b); err != nil {
+				return 0, err
+			}
+			if lo > hi {
+				return 0, fmt.Errorf("empty range %q in %s", rng, f.name)
+			}
+		default:
+			v, err := f.value(rng)
+			if err != nil {
+				return 0, err
+			}
+			lo = v
+			if !hasStep {
+				hi = v // "a/n" means a to max, stepping by n
+			}
+		}
+		for v := lo; v <= hi; v += step {
+			set |= 1 << v
+		}
+	}
+	return set, nil
+}
+
+func (f field) value(s string) (int, error) {
+	for i, name := range f.names {
+		if strings.EqualFold(s, name) {
+			return f.min + i, nil
+		}
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil || v < f.min || v > f.max {
+		return 0, fmt.Errorf("bad %s %q", f.name, s)
+	}
+	return v, nil
+}
+
+// Next implements Schedule. The result is in the Spec's time zone.
+func (s *Spec) Next(t time.Time) time.Time {
+	// Step in absolute time rather than with Truncate, which rounds in UTC
+	// and is wrong for zones with a half-hour offset.
+	t = t.In(s.loc)
+	t = t.Add(time.Minute - time.Duration(t.Second())*time.Second - time.Duration(t.Nanosecond()))
+	// Every pattern repeats within a few years (Feb 29 on a given weekday
+	// being the slowest); give up after that for impossible dates such as
+	// Feb 30.
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		switch {
+		case s.month&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, s.loc)
+		case !s.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, s.loc)
+		case s.hour&(1<<uint(t.Hour())) == 0:
+			t = t.Add(time.Duration(60-t.Minute()) * time.Minute)
+		case s.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// dayMatches follows the classic cron rule: when both day fields are
+// restricted, a day matching either of them fires.
+func (s *Spec) dayMatches(t time.Time) bool {
+	dom := s.dom&(1<<uint(t.Day())) != 0
+	dow := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domStar || s.dowStar {
+		return dom && dow
+	}
+	return dom || dow
+}
@@ -0,0 +1,90 @@
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNext(t *testing.T) {
+	from := time.Date(2024, time.March, 15, 10, 30, 20, 0, time.UTC) // a Friday
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"* * * * *", "2024-03-15 10:31"},
+		{"*/15 * * * *", "2024-03-15 10:45"},
+		{"0 * * * *", "2024-03-15 11:00"},
+		{"5,35 9-17 * * *", "2024-03-15 10:35"},
+		{"0 9 * * mon-fri", "2024-03-18 09:00"},
+		{"0 0 1 * *", "2024-04-01 00:00"},
+		{"0 12 * jun *", "2024-06-01 12:00"},
+		{"0 0 29 feb *", "2028-02-29 00:00"},
+		{"0 0 13 * 5", "2024-03-22 00:00"}, // day 13 OR any Friday
+		{"30 2 * * 7", "2024-03-17 02:30"}, // 7 is Sunday
+		{"0 22/1 * * *", "2024-03-15 22:00"},
+		{"@daily", "2024-03-16 00:00"},
+		{"@weekly", "2024-03-17 00:00"},
+		{"@hourly", "2024-03-15 11:00"},
+		{"@yearly", "2025-01-01 00:00"},
+	}
+	for _, tt := range tests {
+		s, err := ParseIn(tt.expr, time.UTC)
+		if err != nil {
+			t.Errorf("%q: %v", tt.expr, err)
+			continue
+		}
+		if got := s.Next(from).Format("2006-01-02 15:04"); got != tt.want {
+			t.Errorf("%q: Next = %s, want %s", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestEvery(t *testing.T) {
+	s, err := Parse("@every 90s")
+	if err != nil {
+		t.Fatal(err)
+	}
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	if got := s.Next(from); !got.Equal(from.Add(90 * time.Second)) {
+		t.Errorf("Next = %v", got)
+	}
+}
+
+func TestTimeZones(t *testing.T) {
+	from := time.Date(2024, time.March, 15, 0, 0, 0, 0, time.UTC)
+	s, err := Parse("CRON_TZ=Asia/Kolkata 0 9 * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 09:00 in India is 03:30 UTC.
+	if got := s.Next(from).UTC().Format("15:04"); got != "03:30" {
+		t.Errorf("Next = %s UTC, want 03:30", got)
+	}
+
+	// 2:30 does not exist on the day New York springs forward; the job runs
+	// at the next matching minute that does.
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip(err)
+	}
+	s, _ = ParseIn("30 2 * * *", ny)
+	got := s.Next(time.Date(2024, time.March, 10, 0, 0, 0, 0, ny))
+	if want := time.Date(2024, time.March, 11, 2, 30, 0, 0, ny); !got.Equal(want) {
+		t.Errorf("Next across DST = %v, want %v", got, want)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, expr := range []string{
+		"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *",
+		"* * * * 8", "5-1 * * * *", "*/0 * * * *", "@every", "@every -1s",
+		"CRON_TZ=Nowhere/City * * * * *", "* * * foo *",
+	} {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("Parse(%q) succeeded", expr)
+		}
+	}
+	if s, _ := ParseIn("0 0 30 feb *", time.UTC); !s.Next(time.Now()).IsZero() {
+		t.Error("an impossible date must never fire")
+	}
+}
@@ -0,0 +1,29 @@
+package cron_test
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/lotusirous/gochan/cron"
+)
+
+func ExampleParse() {
+	weekdays, err := cron.Parse("30 9 * * 1-5")
+	if err != nil {
+		panic(err)
+	}
+	t := time.Date(2024, 3, 8, 12, 0, 0, 0, time.UTC) // a Friday
+	for range 2 {
+		t = weekdays.Next(t)
+		fmt.Println(t.Format("Mon 15:04"))
+	}
+	// Output:
+	// Mon 09:30
+	// Tue 09:30
+}
+
+func ExampleEvery() {
+	t := time.Date(2024, 1, 1, 10, 7, 0, 0, time.UTC)
+	fmt.Println(cron.Every(15 * time.Minute).Next(t).Format("15:04"))
+	// Output: 10:22
+}
@@ -0,0 +1,38 @@
+// Package ctxutil contains small helpers for the context patterns used across
+// the examples (16-context, 6-select-timeout).
+package ctxutil
+
+import (
+	"context"
+	"time"
+)
+
+// Sleep pauses for d or until ctx is done, whichever comes first. It returns
+// ctx.Err() if it was interrupted. This is sleepAndTalk from 16-context
+// without the talking.
+func Sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Do runs fn in its own goroutine and waits for its result or for ctx to be
+// done (6-select-timeout). When ctx wins, Do returns ctx.Err() immediately;
+// fn keeps running in the background and its result is discarded, so fn
+// should itself stop early when it can.
+func Do[T any](ctx context.Context, fn func() T) (T, error) {
+	c := make(chan T, 1) // buffered so the goroutine can always finish
+	go func() { c <- fn() }()
+	select {
+	case v := <-c:
+		return v, nil
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	}
+}
@@ -0,0 +1,67 @@
+package ctxutil
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSleep(t *testing.T) {
+	if err := Sleep(context.Background(), time.Millisecond); err != nil {
+		t.Errorf("Sleep returned %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := Sleep(ctx, time.Minute); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Sleep returned %v, want deadline exceeded", err)
+	}
+	if time.Since(start) > time.Second {
+		t.Error("Sleep ignored the context deadline")
+	}
+}
+
+func TestDo(t *testing.T) {
+	v, err := Do(context.Background(), func() string { return "done" })
+	if err != nil || v != "done" {
+		t.Errorf("Do = %q, %v", v, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	release := make(chan struct{})
+	defer close(release)
+	_, err = Do(ctx, func() string {
+		<-release
+		return "too late"
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Do returned %v, want deadline exceeded", err)
+	}
+}
+
+func TestFromQuit(t *testing.T) {
+	quit := make(chan bool)
+	ctx, cancel := FromQuit(context.Background(), quit)
+	defer cancel()
+
+	quit <- true
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("context not canceled after quit")
+	}
+}
+
+func TestToQuit(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	quit := ToQuit[bool](ctx)
+	cancel()
+	select {
+	case <-quit:
+	case <-time.After(time.Second):
+		t.Fatal("quit not closed after cancel")
+	}
+}
@@ -0,0 +1,40 @@
+package ctxutil_test
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/lotusirous/gochan/ctxutil"
+)
+
+func ExampleDo() {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// fn would take an hour; Do gives up as soon as ctx is done.
+	_, err := ctxutil.Do(ctx, func() int {
+		time.Sleep(time.Hour)
+		return 42
+	})
+	fmt.Println(err)
+	// Output: context canceled
+}
+
+func ExampleFromQuit() {
+	quit := make(chan bool)
+	ctx, cancel := ctxutil.FromQuit(context.Background(), quit)
+	defer cancel()
+
+	quit <- true
+	<-ctx.Done()
+	fmt.Println(ctx.Err())
+	// Output: context canceled
+}
+
+func ExampleSleep() {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	fmt.Println(ctxutil.Sleep(ctx, time.Hour))
+	// Output: context canceled
+}
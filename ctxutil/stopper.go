@@ -0,0 +1,30 @@
+package ctxutil
+
+import "context"
+
+// The stopper helpers convert between the two ways of telling a goroutine to
+// stop: the quit channel of example 7 and a context. They let new
+// context-based code call legacy quit-channel code and the other way round.
+
+// FromQuit returns a context that is canceled when quit receives a value or
+// is closed, or when parent is done. Call cancel to release the watcher
+// goroutine once the context is no longer needed.
+func FromQuit[T any](parent context.Context, quit <-chan T) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(parent)
+	go func() {
+		select {
+		case <-quit:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
+}
+
+// ToQuit returns a quit channel that is closed when ctx is done, for code
+// that still takes a quit chan bool (or any other element type).
+func ToQuit[T any](ctx context.Context) <-chan T {
+	quit := make(chan T)
+	context.AfterFunc(ctx, func() { close(quit) })
+	return quit
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+const module = "github.com/lotusirous/gochan"
+
+// TestNoRequirements keeps the module free of dependencies, so importing
+// any of its packages pulls in nothing but the standard library.
+// Integrations with third-party libraries, such as a Prometheus exporter
+// for pool.Metrics, belong in a directory with a go.mod of its own.
+func TestNoRequirements(t *testing.T) {
+	mod, err := os.ReadFile("go.mod")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range strings.Split(string(mod), "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "require") {
+			t.Errorf("go.mod: %s; put integrations in a submodule", line)
+		}
+	}
+}
+
+// TestLibrariesImportOnlyStdlib checks every import of the packages, build
+// tagged files included: the standard library and the module's own
+// packages are fine, but not the programs or the teaching and simulation
+// aids, which may grow heavier dependencies than a fan-in helper should
+// carry.
+func TestLibrariesImportOnlyStdlib(t *testing.T) {
+	for dir, files := range libraries(t, 0) {
+		for _, f := range files {
+			for _, imp := range f.Imports {
+				p, _ := strconv.Unquote(imp.Path.Value)
+				if rel, ok := strings.CutPrefix(p, module+"/"); ok {
+					switch strings.Split(rel, "/")[0] {
+					case "cmd", "examples", "exercises", "teach":
+						t.Errorf("%s imports %s, which is not a library", dir, p)
+					case "internal":
+						switch rel {
+						case "internal/exampletest", "internal/exercisetest", "internal/sim":
+							t.Errorf("%s imports %s, which is not a library", dir, p)
+						}
+					}
+					continue
+				}
+				if first := strings.Split(p, "/")[0]; strings.Contains(first, ".") {
+					t.Errorf("%s imports %s from outside the standard library", dir, p)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package errs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// IsTimeout reports whether err, or any error it wraps, is a timeout. That
+// covers context.DeadlineExceeded, os.ErrDeadlineExceeded, net.Error values
+// whose Timeout method returns true and any other error with such a method,
+// including ones buried in an errors.Join or a multi-%w fmt.Errorf. Errors of
+// this module that wrap a context error, such as scatter.ErrNotEnough after a
+// deadline, are classified the same way.
+func IsTimeout(err error) bool {
+	return walk(err, func(err error) bool {
+		t, ok := err.(interface{ Timeout() bool })
+		return ok && t.Timeout()
+	})
+}
+
+// IsCanceled reports whether err was caused by a cancellation rather than a
+// timeout: it wraps context.Canceled and is not also a timeout.
+func IsCanceled(err error) bool {
+	return errors.Is(err, context.Canceled) && !IsTimeout(err)
+}
+
+// Cause returns the reason behind err when err is ctx's own error. A context
+// cancelled with context.WithCancelCause or WithTimeoutCause only reports
+// context.Canceled or context.DeadlineExceeded from Err; Cause returns an
+// error that wraps both that and the recorded cause, so errors.Is, IsTimeout
+// and IsCanceled keep working while the cause becomes visible. Any other
+// err, including nil, is returned unchanged.
+func Cause(ctx context.Context, err error) error {
+	ctxErr := ctx.Err()
+	if err == nil || ctxErr == nil || !errors.Is(err, ctxErr) {
+		return err
+	}
+	cause := context.Cause(ctx)
+	if cause == nil || errors.Is(err, cause) {
+		return err
+	}
+	return fmt.Errorf("%w: %w", err, cause)
+}
+
+// walk walks the tree of errors wrapped by err, depth first, and reports
+// whether f holds for one of them.
+func walk(err error, f func(error) bool) bool {
+	for err != nil {
+		if f(err) {
+			return true
+		}
+		switch u := err.(type) {
+		case interface{ Unwrap() error }:
+			err = u.Unwrap()
+		case interface{ Unwrap() []error }:
+			for _, err := range u.Unwrap() {
+				if walk(err, f) {
+					return true
+				}
+			}
+			return false
+		default:
+			return false
+		}
+	}
+	return false
+}
@@ -0,0 +1,73 @@
+package errs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestIsTimeoutAndIsCanceled(t *testing.T) {
+	dns := &net.DNSError{Err: "no answer", Name: "example.com", IsTimeout: true}
+	tests := []struct {
+		name              string
+		err               error
+		timeout, canceled bool
+	}{
+		{"nil", nil, false, false},
+		{"plain", errA, false, false},
+		{"deadline", context.DeadlineExceeded, true, false},
+		{"os deadline", os.ErrDeadlineExceeded, true, false},
+		{"net", &net.OpError{Op: "read", Err: os.ErrDeadlineExceeded}, true, false},
+		{"dns", dns, true, false},
+		{"wrapped deadline", fmt.Errorf("fetch: %w", context.DeadlineExceeded), true, false},
+		{"joined", errors.Join(errA, fmt.Errorf("b: %w", dns)), true, false},
+		{"canceled", fmt.Errorf("fetch: %w", context.Canceled), false, true},
+		{"canceled by timeout", errors.Join(context.Canceled, context.DeadlineExceeded), true, false},
+	}
+	for _, tt := range tests {
+		if got := IsTimeout(tt.err); got != tt.timeout {
+			t.Errorf("%s: IsTimeout = %v, want %v", tt.name, got, tt.timeout)
+		}
+		if got := IsCanceled(tt.err); got != tt.canceled {
+			t.Errorf("%s: IsCanceled = %v, want %v", tt.name, got, tt.canceled)
+		}
+	}
+}
+
+func TestCause(t *testing.T) {
+	ctx, cancel := context.WithCancelCause(t.Context())
+	if err := Cause(ctx, errA); err != errA {
+		t.Errorf("Cause before cancel = %v, want errA", err)
+	}
+	cancel(errB)
+
+	err := Cause(ctx, fmt.Errorf("fetch: %w", ctx.Err()))
+	if !errors.Is(err, errB) || !IsCanceled(err) {
+		t.Errorf("Cause = %v, want errB and a cancellation", err)
+	}
+	if err := Cause(ctx, errA); err != errA {
+		t.Errorf("Cause of an unrelated error = %v, want errA", err)
+	}
+	if err := Cause(ctx, nil); err != nil {
+		t.Errorf("Cause(nil) = %v", err)
+	}
+
+	ctx, stop := context.WithTimeoutCause(t.Context(), time.Nanosecond, errA)
+	defer stop()
+	<-ctx.Done()
+	err = Cause(ctx, ctx.Err())
+	if !errors.Is(err, errA) || !IsTimeout(err) {
+		t.Errorf("Cause = %v, want errA and a timeout", err)
+	}
+
+	ctx, stop = context.WithTimeout(t.Context(), time.Nanosecond)
+	defer stop()
+	<-ctx.Done()
+	if err := Cause(ctx, ctx.Err()); err != context.DeadlineExceeded {
+		t.Errorf("Cause without a cause = %v, want the plain error", err)
+	}
+}
@@ -0,0 +1,123 @@
+package errs
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/lotusirous/gochan/chans"
+	"github.com/lotusirous/gochan/clock"
+)
+
+// Summary is one report from a Deduper.
+type Summary struct {
+	Err error // the first error with this message in the window
+
+	// Suppressed is 0 when Err is reported for the first time. Otherwise
+	// this is a summary sent when the window closes, and Suppressed counts
+	// the identical errors dropped since the first report.
+	Suppressed int
+
+	Window time.Duration
+}
+
+func (s Summary) String() string {
+	if s.Suppressed == 0 {
+		return s.Err.Error()
+	}
+	return fmt.Sprintf("%v (repeated %d more times in %v)", s.Err, s.Suppressed, s.Window)
+}
+
+// Deduper merges error streams and keeps repeated errors from flooding the
+// output. Errors are identical when their messages are equal. The first of
+// them is reported at once; the rest are counted for Window and reported as a
+// single summary when the window closes.
+//
+// The zero value deduplicates within one second on the real clock.
+type Deduper struct {
+	Window time.Duration
+	Clock  clock.Clock // nil means clock.Real
+}
+
+type seen struct {
+	err        error
+	until      time.Time
+	suppressed int
+}
+
+// Merge fans in every input and returns the deduplicated stream. Nil errors
+// are ignored. When every input is closed, summaries still pending are sent
+// immediately and the output is closed. When ctx is done the output is
+// closed and pending summaries are dropped.
+func (d *Deduper) Merge(ctx context.Context, inputs ...<-chan error) <-chan Summary {
+	window := d.Window
+	if window <= 0 {
+		window = time.Second
+	}
+	clk := d.Clock
+	if clk == nil {
+		clk = clock.Real
+	}
+
+	in := chans.FanIn(ctx, inputs...)
+	out := make(chan Summary)
+	go func() {
+		defer close(out)
+		send := func(e *seen, suppressed int) bool {
+			select {
+			case out <- Summary{e.err, suppressed, window}:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		// Windows open in arrival order, so they also close in that order
+		// and only the oldest one needs a timer.
+		byMsg := map[string]*seen{}
+		var open []*seen
+		var expire <-chan time.Time
+		for {
+			if expire == nil && len(open) > 0 {
+				expire = clk.After(open[0].until.Sub(clk.Now()))
+			}
+			select {
+			case err, ok := <-in:
+				if !ok {
+					for _, e := range open {
+						if e.suppressed > 0 && !send(e, e.suppressed) {
+							return
+						}
+					}
+					return
+				}
+				if err == nil {
+					continue
+				}
+				if e, ok := byMsg[err.Error()]; ok {
+					e.suppressed++
+					continue
+				}
+				e := &seen{err: err, until: clk.Now().Add(window)}
+				byMsg[err.Error()] = e
+				open = append(open, e)
+				if !send(e, 0) {
+					return
+				}
+			case now := <-expire:
+				expire = nil
+				for len(open) > 0 && !open[0].until.After(now) {
+					e := open[0]
+					open = open[1:]
+					delete(byMsg, e.err.Error())
+					if e.suppressed > 0 && !send(e, e.suppressed) {
+						return
+					}
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
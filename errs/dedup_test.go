@@ -0,0 +1,80 @@
+package errs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/lotusirous/gochan/clock"
+)
+
+func TestDeduperSummarizesRepeats(t *testing.T) {
+	fake := clock.NewFake(time.Time{})
+	d := Deduper{Window: time.Minute, Clock: fake}
+	stage1, stage2 := make(chan error), make(chan error)
+	out := d.Merge(t.Context(), stage1, stage2)
+
+	stage1 <- errors.New("disk full")
+	if s := <-out; s.Err.Error() != "disk full" || s.Suppressed != 0 {
+		t.Fatalf("first report = %v", s)
+	}
+	stage1 <- errors.New("disk full")
+	stage1 <- errors.New("disk full")
+	stage1 <- nil
+	// Reports from one input arrive in order, so once errB is reported the
+	// duplicates before it have all been counted.
+	stage1 <- errB
+	if s := <-out; s.Err != errB || s.Suppressed != 0 {
+		t.Fatalf("second report = %v", s)
+	}
+
+	fake.BlockUntil(1)
+	fake.Advance(time.Minute)
+	s := <-out
+	if s.Err.Error() != "disk full" || s.Suppressed != 2 {
+		t.Errorf("summary = %+v, want 2 suppressed", s)
+	}
+	if got, want := s.String(), "disk full (repeated 2 more times in 1m0s)"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+
+	// A new window has started: the error is reported again.
+	stage2 <- errors.New("disk full")
+	if s := <-out; s.Suppressed != 0 {
+		t.Errorf("report after the window = %+v, want a fresh report", s)
+	}
+
+	close(stage1)
+	close(stage2)
+	if s, ok := <-out; ok {
+		t.Errorf("unexpected %+v, want the output closed", s)
+	}
+}
+
+func TestDeduperFlushesOnClose(t *testing.T) {
+	d := Deduper{Window: time.Hour}
+	in := make(chan error, 4)
+	in <- errA
+	in <- errA
+	in <- errA
+	close(in)
+
+	var got []Summary
+	for s := range d.Merge(t.Context(), in) {
+		got = append(got, s)
+	}
+	if len(got) != 2 || got[0].Suppressed != 0 || got[1].Suppressed != 2 {
+		t.Errorf("got %+v, want a report and a summary of 2", got)
+	}
+}
+
+func TestDeduperStopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	d := Deduper{}
+	out := d.Merge(ctx, make(chan error))
+	cancel()
+	if _, ok := <-out; ok {
+		t.Error("output not closed after cancel")
+	}
+}
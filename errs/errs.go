@@ -0,0 +1,59 @@
+// Package errs helps concurrent code report every error instead of only the
+// first one, or none.
+package errs
+
+import (
+	"errors"
+	"sync"
+)
+
+// Collector gathers errors from any number of goroutines. The zero value is
+// ready to use.
+type Collector struct {
+	mu   sync.Mutex
+	errs []error
+}
+
+// Add records err. A nil err is ignored, so the result of a call can be
+// passed directly: c.Add(doSomething()).
+func (c *Collector) Add(err error) {
+	if err == nil {
+		return
+	}
+	c.mu.Lock()
+	c.errs = append(c.errs, err)
+	c.mu.Unlock()
+}
+
+// Len returns how many errors were recorded.
+func (c *Collector) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.errs)
+}
+
+// Err returns all recorded errors joined with errors.Join, in the order they
+// were added, or nil if there were none. errors.Is and errors.As see every
+// one of them.
+func (c *Collector) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return errors.Join(c.errs...)
+}
+
+// Collect runs every fn in its own goroutine, waits for all of them and
+// returns their errors joined in the order of fns. Unlike the first-error
+// patterns it never stops early.
+func Collect(fns ...func() error) error {
+	errs := make([]error, len(fns))
+	var wg sync.WaitGroup
+	for i, fn := range fns {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = fn()
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
@@ -0,0 +1,56 @@
+package errs
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+var errA, errB = errors.New("a"), errors.New("b")
+
+func TestCollector(t *testing.T) {
+	var c Collector
+	if c.Err() != nil {
+		t.Fatal("empty collector has an error")
+	}
+
+	var wg sync.WaitGroup
+	for i := range 100 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			switch {
+			case i%10 == 0:
+				c.Add(fmt.Errorf("job %d: %w", i, errA))
+			case i%25 == 1:
+				c.Add(errB)
+			default:
+				c.Add(nil)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if c.Len() != 14 {
+		t.Errorf("Len = %d, want 14", c.Len())
+	}
+	err := c.Err()
+	if !errors.Is(err, errA) || !errors.Is(err, errB) {
+		t.Errorf("Err() = %v, want both errA and errB", err)
+	}
+}
+
+func TestCollect(t *testing.T) {
+	err := Collect(
+		func() error { return errA },
+		func() error { return nil },
+		func() error { return errB },
+	)
+	if got, want := err.Error(), "a\nb"; got != want {
+		t.Errorf("Collect = %q, want %q (in order)", got, want)
+	}
+	if err := Collect(func() error { return nil }); err != nil {
+		t.Errorf("Collect = %v, want nil", err)
+	}
+}
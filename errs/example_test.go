@@ -0,0 +1,57 @@
+package errs_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lotusirous/gochan/clock"
+	"github.com/lotusirous/gochan/errs"
+)
+
+func ExampleCollect() {
+	err := errs.Collect(
+		func() error { return errors.New("disk a: full") },
+		func() error { return nil },
+		func() error { return errors.New("disk c: read-only") },
+	)
+	fmt.Println(err)
+	// Output:
+	// disk a: full
+	// disk c: read-only
+}
+
+func ExampleFailFast() {
+	results, err := errs.FailFast(context.Background(),
+		func(ctx context.Context) (string, error) { return "", errors.New("auth down") },
+		func(ctx context.Context) (string, error) {
+			<-ctx.Done() // cut short by the failure
+			return "", context.Cause(ctx)
+		},
+	)
+	fmt.Println(err)
+	fmt.Println(results[1].Err)
+	// Output:
+	// auth down
+	// auth down
+}
+
+func ExampleDeduper() {
+	// The fake clock stands still, so every error falls in the first window
+	// however slow the machine is.
+	clk := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	d := &errs.Deduper{Window: time.Minute, Clock: clk}
+
+	in := make(chan error, 3)
+	for range 3 {
+		in <- errors.New("db: connection refused")
+	}
+	close(in)
+	for s := range d.Merge(context.Background(), in) {
+		fmt.Println(s)
+	}
+	// Output:
+	// db: connection refused
+	// db: connection refused (repeated 2 more times in 1m0s)
+}
@@ -0,0 +1,45 @@
+package errs
+
+import (
+	"context"
+	"sync"
+
+	"github.com/lotusirous/gochan/result"
+)
+
+// FailFast runs every fn concurrently with a shared context. The first fn to
+// fail cancels that context for its siblings, with the failure as the
+// cancellation cause (see context.Cause), and FailFast returns it once every
+// fn has returned.
+//
+// Unlike errgroup-style helpers, work that finished before the failure is not
+// thrown away: results[i] holds what fns[i] returned, so the caller can keep
+// the successful results and see which tasks were cut short. Compare Collect,
+// which lets every task run to completion.
+func FailFast[T any](ctx context.Context, fns ...func(context.Context) (T, error)) ([]result.Result[T], error) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	results := make([]result.Result[T], len(fns))
+	var (
+		once  sync.Once
+		first error
+		wg    sync.WaitGroup
+	)
+	for i, fn := range fns {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err := fn(ctx)
+			results[i] = result.Of(v, err)
+			if err != nil {
+				once.Do(func() {
+					first = err
+					cancel(err)
+				})
+			}
+		}()
+	}
+	wg.Wait()
+	return results, first
+}
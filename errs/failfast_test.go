@@ -0,0 +1,50 @@
+package errs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestFailFast(t *testing.T) {
+	boom := errors.New("boom")
+	var cause error
+	results, err := FailFast(t.Context(),
+		func(context.Context) (int, error) { return 1, nil },
+		func(ctx context.Context) (int, error) {
+			<-ctx.Done() // a slow sibling, only stopped by the failure
+			cause = context.Cause(ctx)
+			return 0, ctx.Err()
+		},
+		func(context.Context) (int, error) {
+			time.Sleep(10 * time.Millisecond) // let the first task finish
+			return 0, boom
+		},
+	)
+	if err != boom {
+		t.Fatalf("err = %v, want boom", err)
+	}
+	if cause != boom {
+		t.Errorf("sibling saw cause %v, want boom", cause)
+	}
+	if v, err := results[0].Unwrap(); v != 1 || err != nil {
+		t.Errorf("results[0] = %v, %v; want 1, nil", v, err)
+	}
+	if !errors.Is(results[1].Err, context.Canceled) {
+		t.Errorf("results[1].Err = %v, want context.Canceled", results[1].Err)
+	}
+}
+
+func TestFailFastAllSucceed(t *testing.T) {
+	square := func(n int) func(context.Context) (int, error) {
+		return func(context.Context) (int, error) { return n * n, nil }
+	}
+	results, err := FailFast(t.Context(), square(2), square(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].Value != 4 || results[1].Value != 9 {
+		t.Errorf("results = %v", results)
+	}
+}
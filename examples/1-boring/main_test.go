@@ -0,0 +1,22 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/lotusirous/gochan/internal/exampletest"
+)
+
+func TestMain(m *testing.M) { exampletest.Main(m, main) }
+
+func TestMainOutput(t *testing.T) {
+	if testing.Short() {
+		t.Skip("main listens for 2s")
+	}
+	out := exampletest.Run(t)
+	for _, want := range []string{"I'm listening", "boring! 0", "You're boring. I'm leaving"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output misses %q:\n%s", want, out)
+		}
+	}
+}
@@ -1,9 +1,13 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
-	"math/rand"
 	"time"
+
+	"github.com/lotusirous/gochan/internal/sim"
+	"github.com/lotusirous/gochan/teach"
 )
 
 type Result string
@@ -15,9 +19,15 @@ var (
 	Video = fakeSearch("video")
 )
 
+// backend simulates the search servers. Try a heavy tail with
+// -latency pareto:10ms,1.2 to see the total follow the slowest one.
+var backend = sim.Service{Latency: sim.Uniform(0, 100*time.Millisecond)}
+
 func fakeSearch(kind string) Search {
 	return func(query string) Result {
-		time.Sleep(time.Duration(rand.Intn(100)) * time.Millisecond)
+		if err := backend.Call(context.Background()); err != nil {
+			return Result(fmt.Sprintf("%s failed: %v\n", kind, err))
+		}
 		return Result(fmt.Sprintf("%s result for %q\n", kind, query))
 	}
 }
@@ -45,6 +55,12 @@ func Google(query string) []Result {
 }
 
 func main() {
+	// Stream events to the patterns runner, if it started us.
+	defer teach.Start()()
+
+	backend.AddFlags(flag.CommandLine, "")
+	flag.Parse()
+
 	start := time.Now()
 	results := Google("golang")
 	elapsed := time.Since(start)
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/lotusirous/gochan/internal/exampletest"
+)
+
+func TestMain(m *testing.M) { exampletest.Main(m, main) }
+
+func TestGoogleQueriesEveryKind(t *testing.T) {
+	var kinds []string
+	for _, r := range Google("golang") {
+		if !strings.Contains(string(r), `result for "golang"`) {
+			t.Errorf("unexpected result %q", r)
+		}
+		kinds = append(kinds, strings.Fields(string(r))[0])
+	}
+	slices.Sort(kinds)
+	if want := []string{"image", "video", "web"}; !slices.Equal(kinds, want) {
+		t.Errorf("got kinds %v, want %v", kinds, want)
+	}
+}
+
+func TestMainOutput(t *testing.T) {
+	if out := exampletest.Run(t); !strings.Contains(out, "web result") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+}
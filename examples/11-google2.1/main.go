@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/lotusirous/gochan/internal/sim"
+	"github.com/lotusirous/gochan/scatter"
+	"github.com/lotusirous/gochan/teach"
+)
+
+type Result string
+
+// server simulates the search servers. Run with -failures 0.3 to see
+// the policies cope with errors, not only with slow replies.
+var server = sim.Service{Latency: sim.Uniform(0, 100*time.Millisecond)}
+
+// fakeSearch is a backend for kind that replies once the simulated server
+// does, or gives up when the gather no longer waits for it.
+func fakeSearch(kind string) scatter.Backend[string, Result] {
+	return scatter.Backend[string, Result]{Name: kind, Call: func(ctx context.Context, q string) (Result, error) {
+		if err := server.Call(ctx); err != nil {
+			return "", err
+		}
+		return Result(fmt.Sprintf("%s result for %q\n", kind, q)), nil
+	}}
+}
+
+// backends are the searches to scatter the query to.
+func backends() []scatter.Backend[string, Result] {
+	return []scatter.Backend[string, Result]{
+		fakeSearch("web"),
+		fakeSearch("image"),
+		fakeSearch("video"),
+	}
+}
+
+// search sends the query to every backend and gathers replies by policy.
+func search(ctx context.Context, query string, p scatter.Policy) (scatter.Outcome[Result], error) {
+	return scatter.Gather(ctx, query, p, backends()...)
+}
+
+// I don't want to wait for slow server
+func Google(query string) []Result {
+	// the global timeout for 3 queries
+	// it means after 50ms, it ignores the result from the server that taking response greater than 50ms
+	out, _ := search(context.Background(), query, scatter.BestEffort(50*time.Millisecond))
+	if len(out.Missing) > 0 {
+		fmt.Println("timeout:", out.Missing)
+	}
+	return out.Values()
+}
+
+func main() {
+	// Report run time and allocations when patterns compare runs us.
+	defer teach.Start()()
+
+	policy := flag.String("policy", "best", "how to gather: all, best or quorum")
+	server.AddFlags(flag.CommandLine, "")
+	flag.Parse()
+
+	var p scatter.Policy
+	switch *policy {
+	case "all":
+		p = scatter.RequireAll()
+	case "best":
+		p = scatter.BestEffort(50 * time.Millisecond)
+	case "quorum":
+		p = scatter.Quorum(2)
+	default:
+		fmt.Println("unknown policy", *policy)
+		return
+	}
+
+	start := time.Now()
+	out, err := search(context.Background(), "golang", p)
+	elapsed := time.Since(start)
+	fmt.Println(out.Values())
+	fmt.Println("from:", out.Contributors(), "missing:", out.Missing)
+	if err != nil {
+		fmt.Println("error:", err)
+	}
+	fmt.Println(elapsed)
+}
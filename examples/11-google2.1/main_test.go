@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lotusirous/gochan/internal/exampletest"
+	"github.com/lotusirous/gochan/scatter"
+)
+
+func TestMain(m *testing.M) { exampletest.Main(m, main) }
+
+func TestGoogleRespectsTimeout(t *testing.T) {
+	start := time.Now()
+	results := Google("golang")
+	if elapsed := time.Since(start); elapsed > 90*time.Millisecond {
+		t.Errorf("Google took %v, want about 50ms at most", elapsed)
+	}
+	if len(results) > 3 {
+		t.Errorf("got %d results, want at most 3", len(results))
+	}
+}
+
+func TestSearchPolicies(t *testing.T) {
+	out, err := search(context.Background(), "golang", scatter.RequireAll())
+	if err != nil || len(out.Replies) != 3 {
+		t.Errorf("all: %d replies, err %v; want 3, nil", len(out.Replies), err)
+	}
+	out, err = search(context.Background(), "golang", scatter.Quorum(2))
+	if err != nil || len(out.Replies) != 2 || len(out.Missing) != 1 {
+		t.Errorf("quorum: replies %v, missing %v, err %v", out.Contributors(), out.Missing, err)
+	}
+}
+
+func TestMainOutput(t *testing.T) {
+	for _, policy := range []string{"all", "best", "quorum"} {
+		if out := exampletest.Run(t, "-policy="+policy); !strings.Contains(out, "from: [") {
+			t.Errorf("-policy=%s: unexpected output:\n%s", policy, out)
+		}
+	}
+}
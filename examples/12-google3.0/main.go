@@ -1,9 +1,13 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
-	"math/rand"
 	"time"
+
+	"github.com/lotusirous/gochan/internal/sim"
+	"github.com/lotusirous/gochan/teach"
 )
 
 type Result string
@@ -18,9 +22,15 @@ var (
 	Video2 = fakeSearch("video2")
 )
 
+// backend simulates every replica. With -latency pareto:10ms,1.2 the
+// replicas rescue most slow queries that would otherwise time out.
+var backend = sim.Service{Latency: sim.Uniform(0, 100*time.Millisecond)}
+
 func fakeSearch(kind string) Search {
 	return func(query string) Result {
-		time.Sleep(time.Duration(rand.Intn(100)) * time.Millisecond)
+		if err := backend.Call(context.Background()); err != nil {
+			return Result(fmt.Sprintf("%s failed: %v\n", kind, err))
+		}
 		return Result(fmt.Sprintf("%s result for %q\n", kind, query))
 	}
 }
@@ -73,6 +83,12 @@ func Google(query string) []Result {
 }
 
 func main() {
+	// Stream events, including the run time, to the patterns runner.
+	defer teach.Start()()
+
+	backend.AddFlags(flag.CommandLine, "")
+	flag.Parse()
+
 	start := time.Now()
 	results := Google("golang")
 	elapsed := time.Since(start)
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lotusirous/gochan/internal/exampletest"
+)
+
+func TestMain(m *testing.M) { exampletest.Main(m, main) }
+
+func TestFirstTakesFastestReplica(t *testing.T) {
+	slow := func(q string) Result { time.Sleep(time.Second); return "slow" }
+	fast := func(q string) Result { return "fast" }
+	if got := First("golang", slow, fast); got != "fast" {
+		t.Errorf("First = %q, want fast", got)
+	}
+}
+
+func TestGoogleRespectsTimeout(t *testing.T) {
+	start := time.Now()
+	if results := Google("golang"); len(results) > 3 {
+		t.Errorf("got %d results, want at most 3", len(results))
+	}
+	if elapsed := time.Since(start); elapsed > 90*time.Millisecond {
+		t.Errorf("Google took %v, want about 50ms at most", elapsed)
+	}
+}
+
+func TestMainOutput(t *testing.T) {
+	if out := exampletest.Run(t); !strings.Contains(out, "[") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+}
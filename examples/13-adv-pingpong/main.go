@@ -1,8 +1,11 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"time"
+
+	"github.com/lotusirous/gochan/teach"
 )
 
 type Ball struct{ hits int }
@@ -10,20 +13,31 @@ type Ball struct{ hits int }
 func player(name string, table chan *Ball) {
 	for {
 		ball := <-table // player grabs the ball
+		teach.Pass("table", name, "(o)")
 		ball.hits++
 		fmt.Println(name, ball.hits)
 		time.Sleep(100 * time.Millisecond)
+		teach.Pass(name, "table", "(o)")
 		table <- ball // pass the ball
 	}
 }
 
 func main() {
+	// Stream events to the patterns runner, if it started us; run with
+	// -animate to watch the ball.
+	defer teach.Start()()
+	teach.Layout("ping", "table", "pong")
+
 	table := make(chan *Ball)
 
 	go player("ping", table)
 	go player("pong", table)
+	ctx := context.Background()
+	teach.Pause(ctx, "both players are blocked receiving from the empty table")
 	table <- new(Ball) // game on; toss the ball
 	time.Sleep(1 * time.Second)
+	teach.Pause(ctx, "main takes the ball next, so the players block forever")
 	<-table // game over, grab the ball
+	teach.Pass("table", "main", "(o)")
 	fmt.Println("Game finished")
 }
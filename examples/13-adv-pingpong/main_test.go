@@ -0,0 +1,25 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/lotusirous/gochan/internal/exampletest"
+)
+
+func TestMain(m *testing.M) { exampletest.Main(m, main) }
+
+func TestMainOutput(t *testing.T) {
+	out := exampletest.Run(t)
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) < 3 || lines[len(lines)-1] != "Game finished" {
+		t.Fatalf("unexpected output:\n%s", out)
+	}
+	// Hits count up by one on every pass, whoever holds the ball.
+	for i, line := range lines[:len(lines)-1] {
+		if _, hits, _ := strings.Cut(line, " "); hits != strconv.Itoa(i+1) {
+			t.Errorf("line %d = %q, want hit %d", i, line, i+1)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/lotusirous/gochan/internal/exampletest"
+)
+
+func TestMain(m *testing.M) { exampletest.Main(m, main) }
+
+func TestSubscribeAndClose(t *testing.T) {
+	s := Subscribe(Fetch("blog.golang.org"))
+	it := <-s.Updates() // the first fetch happens right away
+	if it.Channel != "blog.golang.org" || it.Title != "Item 0" {
+		t.Errorf("got %+v", it)
+	}
+	if err := s.Close(); err != nil {
+		t.Errorf("Close = %v", err)
+	}
+	if _, ok := <-s.Updates(); ok {
+		t.Error("Updates still open after Close")
+	}
+}
+
+func TestMergeClosesEverySubscription(t *testing.T) {
+	m := Merge(Subscribe(Fetch("a")), Subscribe(Fetch("b")))
+	<-m.Updates()
+	if err := m.Close(); err != nil {
+		t.Errorf("Close = %v", err)
+	}
+}
+
+func TestMainOutput(t *testing.T) {
+	if testing.Short() {
+		t.Skip("main streams for 3s")
+	}
+	out := exampletest.Run(t)
+	if !strings.Contains(out, "blog.golang.org Item 0") || !strings.HasSuffix(out, "Subscription demo finished\n") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+}
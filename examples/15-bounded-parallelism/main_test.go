@@ -0,0 +1,61 @@
+package main
+
+import (
+	"crypto/md5"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lotusirous/gochan/internal/exampletest"
+)
+
+func TestMain(m *testing.M) { exampletest.Main(m, main) }
+
+// tree writes a few files under a temporary directory.
+func tree(t *testing.T) (string, map[string]string) {
+	dir := t.TempDir()
+	files := map[string]string{"a.txt": "gopher", "sub/b.txt": "concurrency", "sub/c.txt": ""}
+	for name, body := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir, files
+}
+
+func TestMD5All(t *testing.T) {
+	dir, files := tree(t)
+	sums, err := MD5All(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sums) != len(files) {
+		t.Fatalf("got %d sums, want %d", len(sums), len(files))
+	}
+	for name, body := range files {
+		if got := sums[filepath.Join(dir, name)]; got != md5.Sum([]byte(body)) {
+			t.Errorf("%s: wrong sum %x", name, got)
+		}
+	}
+}
+
+func TestMD5AllMissingRoot(t *testing.T) {
+	if _, err := MD5All(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("MD5All on a missing directory succeeded")
+	}
+}
+
+func TestMainOutput(t *testing.T) {
+	dir, _ := tree(t)
+	out := exampletest.Run(t, dir)
+	want := fmt.Sprintf("%x  %s\n", md5.Sum([]byte("gopher")), filepath.Join(dir, "a.txt"))
+	if !strings.HasPrefix(out, want) {
+		t.Errorf("output does not start with %q:\n%s", want, out)
+	}
+}
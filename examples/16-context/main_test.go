@@ -0,0 +1,32 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/lotusirous/gochan/internal/exampletest"
+)
+
+func TestMain(m *testing.M) { exampletest.Main(m, main) }
+
+func TestSleepAndTalkStopsOnCancel(t *testing.T) {
+	out := exampletest.Run(t)
+	if !strings.Contains(out, "context canceled") || strings.Contains(out, "hello") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+}
+
+func TestHandlerStopsWhenClientLeaves(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req := httptest.NewRequestWithContext(ctx, "GET", "/", nil)
+	rec := httptest.NewRecorder()
+
+	handler(rec, req)
+	if rec.Code != http.StatusInternalServerError || !strings.Contains(rec.Body.String(), "context canceled") {
+		t.Errorf("got %d %q", rec.Code, rec.Body.String())
+	}
+}
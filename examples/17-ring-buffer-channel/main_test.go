@@ -0,0 +1,37 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/lotusirous/gochan/internal/exampletest"
+)
+
+func TestMain(m *testing.M) { exampletest.Main(m, main) }
+
+func TestRingBufferKeepsNewest(t *testing.T) {
+	in, out := make(chan int), make(chan int, 4)
+	go NewRingBuffer(in, out).Run()
+	for i := range 10 {
+		in <- i
+	}
+	close(in)
+
+	var got []int
+	for v := range out {
+		got = append(got, v)
+	}
+	// The reader may start before Run stored the last value, in which case
+	// one older value slips through.
+	if len(got) < 4 || len(got) > 5 || !slices.Equal(got[len(got)-4:], []int{6, 7, 8, 9}) {
+		t.Errorf("got %v, want [6 7 8 9] with at most one older value", got)
+	}
+}
+
+func TestMainOutput(t *testing.T) {
+	out := exampletest.Run(t)
+	if n := strings.Count(out, "\n"); n < 4 || n > 5 || !strings.HasSuffix(out, " 9\n") {
+		t.Errorf("want the 4 newest values, got:\n%s", out)
+	}
+}
@@ -0,0 +1,145 @@
+// Credit:
+// https://gobyexample.com/worker-pools
+
+// Worker pool benefits:
+// - Efficiency because it distributes the work across threads.
+// - Flow control: Limit work in flight
+
+// Disadvantage of worker:
+// Lifetimes complexity: clean up and idle worker
+
+// Principles:
+// Start goroutines whenever you have the concurrent work to do.
+// The goroutine should exit as soon as posible the work is done. This helps us
+// to clean up the resources and manage the lifetimes correctly.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"runtime/trace"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/lotusirous/gochan/internal/sim"
+	"github.com/lotusirous/gochan/teach"
+)
+
+// finished and failed count jobs for the patterns dashboard.
+var finished, failed atomic.Int64
+
+// work is what a job does: by default it takes a second and always
+// succeeds. The -work, -failures and -seed flags change it, usually through
+// a patterns run -scenario file.
+var work = sim.Service{Latency: sim.Fixed(time.Second)}
+
+func worker(id int, jobs <-chan int, results chan<- int) {
+	for j := range jobs {
+		fmt.Println("worker", id, "started job", j)
+		time.Sleep(time.Second)
+		fmt.Println("worker", id, "fnished job", j)
+		results <- j * 2
+	}
+}
+
+func workerEfficient(id int, jobs <-chan int, results chan<- int) {
+	// sync.WaitGroup helps us to manage the job
+	var wg sync.WaitGroup
+	for j := range jobs {
+
+		wg.Add(1)
+		// we start a goroutine to run the job
+		go func(job int) {
+			// name the goroutine for patterns run -explain, and make
+			// each job a trace task, so go tool trace groups its regions
+			// under one name instead of an anonymous goroutine.
+			ctx := teach.As(context.Background(), fmt.Sprintf("job-%d", job))
+			ctx, task := trace.NewTask(ctx, "job")
+			trace.Logf(ctx, "job", "worker %d job %d", id, job)
+			defer task.End()
+
+			// start the job
+			teach.Println(ctx, "worker", id, "started job", job)
+			var err error
+			trace.WithRegion(ctx, "work", func() {
+				err = work.Call(ctx)
+			})
+			if err != nil {
+				teach.Printf(ctx, "worker %d failed job %d: %v\n", id, job, err)
+				teach.Stat("failed", float64(failed.Add(1)))
+			} else {
+				teach.Println(ctx, "worker", id, "fnished job", job)
+				teach.Stat("finished", float64(finished.Add(1)))
+			}
+			trace.WithRegion(ctx, "send result", func() {
+				results <- job * 2
+			})
+			wg.Done()
+
+		}(j)
+
+	}
+	// With a help to manage the lifetimes of goroutines
+	// we can add more handler when a goroutine finished
+	wg.Wait()
+}
+func main() {
+	// Stream events to the patterns runner, if it started us.
+	defer teach.Start()()
+
+	numbWorkers := flag.Int("workers", 3, "workers receiving jobs")
+	numbJobs := flag.Int("jobs", 8, "jobs to send")
+	burst := flag.Int("burst", 0, "jobs sent back to back between arrival gaps (0: all of them)")
+	var arrival sim.Service
+	sim.LatencyVar(flag.CommandLine, &arrival.Latency, "arrival", "gap between bursts of jobs: 50ms, uniform:min,max or pareto:scale,alpha")
+	sim.LatencyVar(flag.CommandLine, &work.Latency, "work", "time one job takes: 1s, uniform:min,max or pareto:scale,alpha")
+	flag.Float64Var(&work.Failures, "failures", 0, "chance from 0 to 1 that a job fails")
+	flag.Func("seed", "seed the arrival and work draws for repeatable runs", func(v string) error {
+		n, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			return err
+		}
+		arrival.Seed(n)
+		work.Seed(n + 1)
+		return nil
+	})
+	flag.Parse()
+
+	jobs := teach.WatchChan("jobs", make(chan int, *numbJobs))
+	results := teach.WatchChan("results", make(chan int, *numbJobs))
+
+	// 1. Start the worker
+	// it is a fixed pool of goroutines receive and perform tasks from a channel
+
+	// In this example, we define a fixed number of workers, 3 by default.
+	// they receive the `jobs` from the channel jobs
+	// we also naming the worker name with `w` variable.
+	for w := 1; w <= *numbWorkers; w++ {
+		go workerEfficient(w, jobs, results)
+	}
+	ctx := teach.As(context.Background(), "main")
+	teach.Pause(ctx, fmt.Sprintf("%d workers are blocked receiving from the empty jobs channel", *numbWorkers))
+
+	// 2. send the work
+	// other goroutine sends the work to the channels
+
+	// in this example, the `main` goroutine sends the work to the channel
+	// `jobs`, in bursts if -burst and -arrival say so
+	for j := 1; j <= *numbJobs; j++ {
+		if *burst > 0 && j > 1 && (j-1)%*burst == 0 {
+			arrival.Call(ctx)
+		}
+		jobs <- j
+	}
+	close(jobs)
+	teach.Println(ctx, "Closed job")
+	teach.Pause(ctx, "all jobs are queued and jobs is closed; workers drain it, then their range loops end")
+	for a := 1; a <= *numbJobs; a++ {
+		<-results
+	}
+	close(results)
+
+}
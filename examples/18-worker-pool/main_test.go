@@ -0,0 +1,44 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/lotusirous/gochan/internal/exampletest"
+)
+
+func TestMain(m *testing.M) { exampletest.Main(m, main) }
+
+func TestWorkerEfficientDoublesEveryJob(t *testing.T) {
+	jobs, results := make(chan int, 3), make(chan int, 3)
+	for j := 1; j <= 3; j++ {
+		jobs <- j
+	}
+	close(jobs)
+	workerEfficient(1, jobs, results) // returns once every job is done
+	close(results)
+
+	var got []int
+	for r := range results {
+		got = append(got, r)
+	}
+	slices.Sort(got)
+	if want := []int{2, 4, 6}; !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestMainOutput(t *testing.T) {
+	out := exampletest.Run(t)
+	if n := strings.Count(out, "fnished job"); n != 8 {
+		t.Errorf("%d jobs finished, want 8:\n%s", n, out)
+	}
+}
+
+func TestMainFlags(t *testing.T) {
+	out := exampletest.Run(t, "-workers", "2", "-jobs", "5", "-burst", "2", "-arrival", "1ms", "-work", "1ms", "-failures", "1")
+	if n := strings.Count(out, "failed job"); n != 5 {
+		t.Errorf("%d jobs failed, want all 5:\n%s", n, out)
+	}
+}
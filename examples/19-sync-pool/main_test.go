@@ -0,0 +1,34 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/lotusirous/gochan/internal/exampletest"
+)
+
+func TestMain(m *testing.M) { exampletest.Main(m, main) }
+
+func TestPipelineReusesMessages(t *testing.T) {
+	before := allocated.Load()
+	total := 0
+	for m := range shout(fanIn(boring("Joe", 1000), boring("Ann", 1000))) {
+		if !strings.HasSuffix(string(m.Body), "!") {
+			t.Fatalf("message %q was not shouted", m.Body)
+		}
+		total++
+		putMessage(m)
+	}
+	if total != 2000 {
+		t.Errorf("got %d messages, want 2000", total)
+	}
+	if n := allocated.Load() - before; n >= 1000 {
+		t.Errorf("allocated %d messages for 2000 sends, pooling is not working", n)
+	}
+}
+
+func TestMainOutput(t *testing.T) {
+	if out := exampletest.Run(t); !strings.Contains(out, "processed 200000 messages") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/lotusirous/gochan/internal/exampletest"
+)
+
+func TestMain(m *testing.M) { exampletest.Main(m, main) }
+
+func TestBoringSendsInOrder(t *testing.T) {
+	c := make(chan string)
+	go boring("hi", c)
+	for _, want := range []string{"hi 0", "hi 1"} {
+		if got := <-c; got != want {
+			t.Fatalf("got %q, want %q", got, want)
+		}
+	}
+}
+
+func TestMainOutput(t *testing.T) {
+	if testing.Short() {
+		t.Skip("main waits for 5 random delays")
+	}
+	out := exampletest.Run(t)
+	if !strings.Contains(out, `You say: "boring! 4"`) || !strings.HasSuffix(out, "I'm leaving\n") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+}
@@ -0,0 +1,101 @@
+// The quit channel of example 7 and a context do the same job: tell a
+// goroutine to stop. This example shows both side by side and how
+// ctxutil.FromQuit and ctxutil.ToQuit let one style drive the other.
+//
+// Prefer the context version in new code: it composes with timeouts and
+// deadlines, carries a reason (ctx.Err()), and cancelling it twice is safe,
+// whereas closing a quit channel twice panics.
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/lotusirous/gochan/ctxutil"
+)
+
+// boringQuit is example 7: it stops when quit receives or is closed.
+func boringQuit(msg string, quit <-chan bool) <-chan string {
+	c := make(chan string)
+	go func() {
+		defer close(c)
+		for i := 0; ; i++ {
+			select {
+			case c <- fmt.Sprintf("%s %d", msg, i):
+			case <-quit:
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+	return c
+}
+
+// boringCtx is the same generator, stopped by a context instead.
+func boringCtx(ctx context.Context, msg string) <-chan string {
+	c := make(chan string)
+	go func() {
+		defer close(c)
+		for i := 0; ; i++ {
+			select {
+			case c <- fmt.Sprintf("%s %d", msg, i):
+			case <-ctx.Done():
+				return
+			}
+			if ctxutil.Sleep(ctx, 10*time.Millisecond) != nil {
+				return
+			}
+		}
+	}()
+	return c
+}
+
+// take prints n messages from c.
+func take(c <-chan string, n int) {
+	for range n {
+		fmt.Println(<-c)
+	}
+}
+
+// drain reads c until it is closed, which proves that the generator really
+// stopped.
+func drain(c <-chan string) {
+	for range c {
+	}
+}
+
+func main() {
+	fmt.Println("-- quit channel")
+	quit := make(chan bool)
+	c := boringQuit("Joe", quit)
+	take(c, 3)
+	close(quit)
+	drain(c)
+
+	fmt.Println("-- context")
+	ctx, cancel := context.WithCancel(context.Background())
+	c = boringCtx(ctx, "Ann")
+	take(c, 3)
+	cancel()
+	drain(c)
+
+	fmt.Println("-- context driving legacy quit code")
+	ctx, cancel = context.WithTimeout(context.Background(), 35*time.Millisecond)
+	defer cancel()
+	c = boringQuit("Joe", ctxutil.ToQuit[bool](ctx))
+	for msg := range c { // ends when the timeout closes the quit channel
+		fmt.Println(msg)
+	}
+
+	fmt.Println("-- quit channel driving context code")
+	quit = make(chan bool)
+	ctx, cancel = ctxutil.FromQuit(context.Background(), quit)
+	defer cancel()
+	c = boringCtx(ctx, "Ann")
+	take(c, 3)
+	quit <- true
+	drain(c)
+
+	fmt.Println("Everybody stopped.")
+}
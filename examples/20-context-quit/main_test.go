@@ -0,0 +1,26 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/lotusirous/gochan/internal/exampletest"
+)
+
+func TestMain(m *testing.M) { exampletest.Main(m, main) }
+
+func TestBoringCtxStops(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	c := boringCtx(ctx, "Ann")
+	take(c, 2)
+	cancel()
+	drain(c)
+}
+
+func TestMainOutput(t *testing.T) {
+	out := exampletest.Run(t)
+	if !strings.HasSuffix(out, "Everybody stopped.\n") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+}
@@ -0,0 +1,89 @@
+// Chinese whispers: like the daisy chain (example 8), but every goroutine in
+// the line changes the message before passing it on.
+//
+// The work per hop is tiny, so the run time is dominated by the channel hand
+// off between goroutines. Comparing with the same transformations applied in
+// a plain loop shows how that per-hop overhead adds up over a long chain.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+)
+
+// whisper is the message passed down the line.
+type whisper struct {
+	Hops int
+	Text []byte
+}
+
+// transform is what every goroutine does to the message.
+type transform func(whisper) whisper
+
+var transforms = map[string]transform{
+	// increment only counts the hops, like the daisy chain.
+	"increment": func(w whisper) whisper {
+		w.Hops++
+		return w
+	},
+	// append also mishears one more letter. The slice is passed on, not
+	// copied, so the backing array is shared down the chain.
+	"append": func(w whisper) whisper {
+		w.Hops++
+		w.Text = append(w.Text, byte('a'+w.Hops%26))
+		return w
+	},
+}
+
+func hop(t transform, left chan<- whisper, right <-chan whisper) {
+	left <- t(<-right)
+}
+
+// whispers passes start through a chain of n goroutines applying t.
+func whispers(n int, t transform, start whisper) whisper {
+	leftmost := make(chan whisper)
+	left := leftmost
+	for range n {
+		right := make(chan whisper)
+		go hop(t, left, right)
+		left = right
+	}
+	go func(c chan whisper) { c <- start }(left)
+	return <-leftmost
+}
+
+// direct applies t n times in a loop: the same result without goroutines.
+func direct(n int, t transform, start whisper) whisper {
+	for range n {
+		start = t(start)
+	}
+	return start
+}
+
+func main() {
+	n := flag.Int("n", 10_000, "number of goroutines in the line")
+	mode := flag.String("mode", "append", "transformation at each hop: increment or append")
+	flag.Parse()
+
+	t, ok := transforms[*mode]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown -mode %q\n", *mode)
+		os.Exit(2)
+	}
+
+	start := time.Now()
+	w := whispers(*n, t, whisper{Text: []byte("gopher")})
+	chained := time.Since(start)
+
+	start = time.Now()
+	direct(*n, t, whisper{Text: []byte("gopher")})
+	looped := time.Since(start)
+
+	tail := w.Text[max(len(w.Text)-20, 0):]
+	fmt.Printf("after %d hops the message ends in %q\n", w.Hops, tail)
+	perHop := func(d time.Duration) time.Duration { return d / time.Duration(max(*n, 1)) }
+	fmt.Printf("goroutines: %v (%v per hop)\n", chained, perHop(chained))
+	fmt.Printf("plain loop: %v (%v per hop)\n", looped, perHop(looped))
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/lotusirous/gochan/internal/exampletest"
+)
+
+func TestMain(m *testing.M) { exampletest.Main(m, main) }
+
+func TestWhispersMatchesDirect(t *testing.T) {
+	for name, tr := range transforms {
+		t.Run(name, func(t *testing.T) {
+			got := whispers(100, tr, whisper{Text: []byte("go")})
+			want := direct(100, tr, whisper{Text: []byte("go")})
+			if got.Hops != 100 || string(got.Text) != string(want.Text) {
+				t.Errorf("whispers = %d %q, want %d %q", got.Hops, got.Text, want.Hops, want.Text)
+			}
+		})
+	}
+}
+
+func TestMainOutput(t *testing.T) {
+	out := exampletest.Run(t, "-n", "100", "-mode", "increment")
+	if !strings.HasPrefix(out, `after 100 hops the message ends in "gopher"`) {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+}
+
+// BenchmarkWhispers measures the cost per hop through goroutines and
+// channels against the same transformation in a plain loop. The difference
+// is the price of the hand off, paid once per hop.
+func BenchmarkWhispers(b *testing.B) {
+	const n = 10_000
+	impls := []struct {
+		name string
+		run  func(int, transform, whisper) whisper
+	}{{"goroutines", whispers}, {"loop", direct}}
+
+	for _, mode := range []string{"increment", "append"} {
+		for _, impl := range impls {
+			b.Run(fmt.Sprintf("%s/%s", mode, impl.name), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					impl.run(n, transforms[mode], whisper{})
+				}
+				b.ReportMetric(float64(b.Elapsed().Nanoseconds())/float64(b.N*n), "ns/hop")
+			})
+		}
+	}
+}
@@ -0,0 +1,104 @@
+// The concurrent prime sieve: a generator sends 2, 3, 4, ... and every prime
+// found adds a filter goroutine that drops its multiples. Each number flows
+// through the filters of all smaller primes until one drops it or it comes
+// out the end as the next prime.
+//
+// It is a beautiful demonstration of goroutines and channels and a terrible
+// way to find primes: compare with the plain sieve of Eratosthenes with
+// BenchmarkSieve. The pipeline is stopped through a context, so no filter is
+// left blocked once enough primes were found.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"time"
+)
+
+// generate sends 2, 3, 4, ... until ctx is done.
+func generate(ctx context.Context) <-chan int {
+	out := make(chan int)
+	go func() {
+		defer close(out)
+		for i := 2; ; i++ {
+			select {
+			case out <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// filter forwards the values of in that are not multiples of prime.
+func filter(ctx context.Context, in <-chan int, prime int) <-chan int {
+	out := make(chan int)
+	go func() {
+		defer close(out)
+		for i := range in {
+			if i%prime == 0 {
+				continue
+			}
+			select {
+			case out <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// primes returns every prime <= limit using the goroutine sieve.
+func primes(ctx context.Context, limit int) []int {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel() // stops the generator and every filter
+
+	var out []int
+	ch := generate(ctx)
+	for {
+		p, ok := <-ch
+		if !ok || p > limit {
+			return out
+		}
+		out = append(out, p)
+		ch = filter(ctx, ch, p)
+	}
+}
+
+// sequential is the sieve of Eratosthenes on a slice.
+func sequential(limit int) []int {
+	if limit < 2 {
+		return nil
+	}
+	composite := make([]bool, limit+1)
+	var out []int
+	for i := 2; i <= limit; i++ {
+		if composite[i] {
+			continue
+		}
+		out = append(out, i)
+		for j := i * i; j <= limit; j += i {
+			composite[j] = true
+		}
+	}
+	return out
+}
+
+func main() {
+	limit := flag.Int("limit", 10_000, "find primes up to this number")
+	flag.Parse()
+
+	start := time.Now()
+	ps := primes(context.Background(), *limit)
+	elapsed := time.Since(start)
+
+	fmt.Printf("%d primes up to %d, the last ones: %v\n", len(ps), *limit, ps[max(len(ps)-5, 0):])
+	fmt.Printf("goroutine sieve: %v with %d filter goroutines\n", elapsed, len(ps))
+
+	start = time.Now()
+	sequential(*limit)
+	fmt.Printf("sequential sieve: %v\n", time.Since(start))
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lotusirous/gochan/internal/exampletest"
+)
+
+func TestMain(m *testing.M) { exampletest.Main(m, main) }
+
+func TestPrimes(t *testing.T) {
+	want := []int{2, 3, 5, 7, 11, 13, 17, 19, 23, 29}
+	if got := primes(context.Background(), 30); !slices.Equal(got, want) {
+		t.Errorf("primes(30) = %v, want %v", got, want)
+	}
+	if got := sequential(30); !slices.Equal(got, want) {
+		t.Errorf("sequential(30) = %v, want %v", got, want)
+	}
+	if got := primes(context.Background(), 1); len(got) != 0 {
+		t.Errorf("primes(1) = %v, want none", got)
+	}
+}
+
+func TestPrimesMatchesSequential(t *testing.T) {
+	if got, want := primes(context.Background(), 5000), sequential(5000); !slices.Equal(got, want) {
+		t.Errorf("the sieves disagree: %d vs %d primes", len(got), len(want))
+	}
+}
+
+func TestPrimesDoesNotLeak(t *testing.T) {
+	before := runtime.NumGoroutine()
+	primes(context.Background(), 1000) // 168 filters
+
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d goroutines still running, had %d before", runtime.NumGoroutine(), before)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestMainOutput(t *testing.T) {
+	out := exampletest.Run(t, "-limit", "100")
+	if !strings.HasPrefix(out, "25 primes up to 100, the last ones: [73 79 83 89 97]") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+}
+
+// BenchmarkSieve compares the goroutine sieve, where every number is handed
+// through a channel per smaller prime, with the sieve of Eratosthenes on a
+// slice.
+func BenchmarkSieve(b *testing.B) {
+	ctx := context.Background()
+	for _, limit := range []int{1_000, 10_000} {
+		b.Run(fmt.Sprintf("goroutines/limit=%d", limit), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				primes(ctx, limit)
+			}
+		})
+		b.Run(fmt.Sprintf("sequential/limit=%d", limit), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				sequential(limit)
+			}
+		})
+	}
+}
@@ -0,0 +1,178 @@
+// The dining philosophers: n philosophers sit around a table with one fork
+// between each pair, and each needs both neighbouring forks to eat.
+//
+// If everybody picks up the left fork first, everybody can end up holding
+// one fork and waiting forever for the other: a deadlock. Three classic ways
+// out are implemented here:
+//
+//   - ordered: always pick up the lower-numbered fork first, so a cycle of
+//     waiting philosophers cannot form.
+//   - waiter: a semaphore lets at most n-1 philosophers reach for forks at
+//     the same time, so at least one of them gets both.
+//   - trylock: pick up the left fork, try the right one, and if it is taken
+//     put the left one back and retry after a random backoff.
+//
+// The naive strategy deadlocks on purpose. A watchdog notices that nobody
+// has eaten for a while and reports it, which is how such bugs show up in
+// real programs: not as a crash, but as progress that stops.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var errDeadlock = errors.New("no philosopher ate for too long: deadlock")
+
+// table holds the forks and the bookkeeping shared by all strategies.
+type table struct {
+	forks  []sync.Mutex
+	waiter chan struct{} // seats at the table for the waiter strategy
+	meals  atomic.Int64  // total meals eaten, watched by the watchdog
+}
+
+func newTable(n int) *table {
+	return &table{forks: make([]sync.Mutex, n), waiter: make(chan struct{}, n-1)}
+}
+
+// A strategy picks up both forks of philosopher i and returns a function
+// that puts them down.
+type strategy func(t *table, i int) (release func())
+
+var strategies = map[string]strategy{
+	"naive":   naive,
+	"ordered": ordered,
+	"waiter":  waiter,
+	"trylock": trylock,
+}
+
+// forksOf returns the indexes of the forks to the left and right of i.
+func (t *table) forksOf(i int) (left, right int) {
+	return i, (i + 1) % len(t.forks)
+}
+
+// naive picks up the left fork, then the right one. The pause in between
+// makes the deadlock certain instead of merely possible.
+func naive(t *table, i int) func() {
+	l, r := t.forksOf(i)
+	t.forks[l].Lock()
+	time.Sleep(10 * time.Millisecond)
+	t.forks[r].Lock()
+	return func() { t.forks[r].Unlock(); t.forks[l].Unlock() }
+}
+
+// ordered picks up the lower-numbered fork first. The last philosopher thus
+// reaches right before left, which breaks the cycle.
+func ordered(t *table, i int) func() {
+	l, r := t.forksOf(i)
+	first, second := min(l, r), max(l, r)
+	t.forks[first].Lock()
+	t.forks[second].Lock()
+	return func() { t.forks[second].Unlock(); t.forks[first].Unlock() }
+}
+
+// waiter asks for one of n-1 seats before picking up left then right, just
+// like naive but without the pause.
+func waiter(t *table, i int) func() {
+	t.waiter <- struct{}{}
+	l, r := t.forksOf(i)
+	t.forks[l].Lock()
+	t.forks[r].Lock()
+	return func() { t.forks[r].Unlock(); t.forks[l].Unlock(); <-t.waiter }
+}
+
+// trylock never waits while holding a fork.
+func trylock(t *table, i int) func() {
+	l, r := t.forksOf(i)
+	backoff := time.Millisecond
+	for {
+		t.forks[l].Lock()
+		if t.forks[r].TryLock() {
+			return func() { t.forks[r].Unlock(); t.forks[l].Unlock() }
+		}
+		t.forks[l].Unlock()
+		time.Sleep(rand.N(backoff)) // random, so neighbours do not retry in lockstep
+		backoff = min(2*backoff, 20*time.Millisecond)
+	}
+}
+
+// dine lets n philosophers eat meals times each using s. It returns how
+// many meals each one ate, or errDeadlock if nobody ate for stall. After a
+// deadlock the stuck goroutines are left behind; the program is expected to
+// exit.
+func dine(s strategy, n, meals int, stall time.Duration) ([]int, error) {
+	t := newTable(n)
+	eaten := make([]int, n)
+	done := make(chan struct{})
+
+	var wg sync.WaitGroup
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range meals {
+				time.Sleep(rand.N(2 * time.Millisecond)) // think
+				release := s(t, i)
+				eaten[i]++
+				t.meals.Add(1)
+				time.Sleep(rand.N(2 * time.Millisecond)) // eat
+				release()
+			}
+		}()
+	}
+	go func() { wg.Wait(); close(done) }()
+
+	// The watchdog: progress means the meal counter moved since last time.
+	tick := time.NewTicker(stall)
+	defer tick.Stop()
+	last := int64(-1)
+	for {
+		select {
+		case <-done:
+			return eaten, nil
+		case <-tick.C:
+			now := t.meals.Load()
+			if now == last {
+				return nil, errDeadlock
+			}
+			last = now
+		}
+	}
+}
+
+func main() {
+	name := flag.String("strategy", "all", "naive, ordered, waiter, trylock or all (all but naive)")
+	n := flag.Int("n", 5, "number of philosophers")
+	meals := flag.Int("meals", 10, "meals per philosopher")
+	flag.Parse()
+	if *n < 2 {
+		// One philosopher's left and right fork would be the same fork.
+		fmt.Fprintln(os.Stderr, "-n must be at least 2")
+		os.Exit(2)
+	}
+
+	names := []string{*name}
+	if *name == "all" {
+		names = []string{"ordered", "waiter", "trylock"}
+	}
+	for _, name := range names {
+		s, ok := strategies[name]
+		if !ok {
+			fmt.Fprintf(os.Stderr, "unknown strategy %q\n", name)
+			os.Exit(2)
+		}
+		start := time.Now()
+		eaten, err := dine(s, *n, *meals, 200*time.Millisecond)
+		if err != nil {
+			fmt.Printf("%-8s %v\n", name, err)
+			continue
+		}
+		fmt.Printf("%-8s meals %v in %v\n", name, eaten, time.Since(start).Round(time.Millisecond))
+	}
+}
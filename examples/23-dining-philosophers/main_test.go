@@ -0,0 +1,36 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lotusirous/gochan/internal/exampletest"
+)
+
+func TestMain(m *testing.M) { exampletest.Main(m, main) }
+
+func TestStrategiesAvoidDeadlock(t *testing.T) {
+	for _, name := range []string{"ordered", "waiter", "trylock"} {
+		t.Run(name, func(t *testing.T) {
+			eaten, err := dine(strategies[name], 5, 5, time.Second)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, n := range eaten {
+				if n != 5 {
+					t.Errorf("philosopher %d ate %d meals, want 5", i, n)
+				}
+			}
+		})
+	}
+}
+
+// The naive strategy runs in a child process: its philosophers stay blocked
+// forever, which would leak goroutines into the other tests.
+func TestWatchdogDetectsDeadlock(t *testing.T) {
+	out := exampletest.Run(t, "-strategy", "naive")
+	if !strings.Contains(out, errDeadlock.Error()) {
+		t.Errorf("watchdog did not report the deadlock:\n%s", out)
+	}
+}
@@ -0,0 +1,195 @@
+// The bounded-buffer (producer/consumer) problem: producers put items into a
+// buffer of fixed capacity and block when it is full, consumers take items
+// out and block when it is empty.
+//
+// Go's buffered channel is exactly this, so the first implementation is one
+// line. The other two are the textbook solutions it replaces: a mutex with two
+// condition variables, and Dijkstra's version with two counting semaphores
+// (free slots and filled slots) plus a mutex for the ring itself.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// buffer is a FIFO queue of bounded capacity.
+type buffer[T any] interface {
+	Put(T)  // blocks while the buffer is full
+	Get() T // blocks while the buffer is empty
+}
+
+// chanBuffer is a buffered channel.
+type chanBuffer[T any] chan T
+
+func newChanBuffer[T any](capacity int) chanBuffer[T] { return make(chan T, capacity) }
+
+func (b chanBuffer[T]) Put(v T) { b <- v }
+func (b chanBuffer[T]) Get() T  { return <-b }
+
+// ring is the storage shared by the lock-based implementations.
+type ring[T any] struct {
+	items      []T
+	head, size int
+}
+
+func (r *ring[T]) full() bool  { return r.size == len(r.items) }
+func (r *ring[T]) empty() bool { return r.size == 0 }
+
+func (r *ring[T]) push(v T) {
+	r.items[(r.head+r.size)%len(r.items)] = v
+	r.size++
+}
+
+func (r *ring[T]) pop() T {
+	v := r.items[r.head]
+	r.head = (r.head + 1) % len(r.items)
+	r.size--
+	return v
+}
+
+// condBuffer guards the ring with a mutex and waits on two conditions.
+type condBuffer[T any] struct {
+	mu                sync.Mutex
+	notFull, notEmpty sync.Cond
+	ring              ring[T]
+}
+
+func newCondBuffer[T any](capacity int) *condBuffer[T] {
+	b := &condBuffer[T]{ring: ring[T]{items: make([]T, capacity)}}
+	b.notFull.L, b.notEmpty.L = &b.mu, &b.mu
+	return b
+}
+
+func (b *condBuffer[T]) Put(v T) {
+	b.mu.Lock()
+	for b.ring.full() { // always re-check: Wait can return spuriously
+		b.notFull.Wait()
+	}
+	b.ring.push(v)
+	b.mu.Unlock()
+	b.notEmpty.Signal()
+}
+
+func (b *condBuffer[T]) Get() T {
+	b.mu.Lock()
+	for b.ring.empty() {
+		b.notEmpty.Wait()
+	}
+	v := b.ring.pop()
+	b.mu.Unlock()
+	b.notFull.Signal()
+	return v
+}
+
+// semaphore is a counting semaphore: the number of tokens in the channel is
+// its value.
+type semaphore chan struct{}
+
+func newSemaphore(capacity, value int) semaphore {
+	s := make(semaphore, capacity)
+	for range value {
+		s <- struct{}{}
+	}
+	return s
+}
+
+func (s semaphore) acquire() { <-s }             // P
+func (s semaphore) release() { s <- struct{}{} } // V
+
+// semBuffer is Dijkstra's solution: free counts empty slots, filled counts
+// items, and the mutex only protects the ring indexes.
+type semBuffer[T any] struct {
+	free, filled semaphore
+	mu           sync.Mutex
+	ring         ring[T]
+}
+
+func newSemBuffer[T any](capacity int) *semBuffer[T] {
+	return &semBuffer[T]{
+		free:   newSemaphore(capacity, capacity),
+		filled: newSemaphore(capacity, 0),
+		ring:   ring[T]{items: make([]T, capacity)},
+	}
+}
+
+func (b *semBuffer[T]) Put(v T) {
+	b.free.acquire()
+	b.mu.Lock()
+	b.ring.push(v)
+	b.mu.Unlock()
+	b.filled.release()
+}
+
+func (b *semBuffer[T]) Get() T {
+	b.filled.acquire()
+	b.mu.Lock()
+	v := b.ring.pop()
+	b.mu.Unlock()
+	b.free.release()
+	return v
+}
+
+var implementations = []struct {
+	name string
+	new  func(capacity int) buffer[int]
+}{
+	{"channel", func(c int) buffer[int] { return newChanBuffer[int](c) }},
+	{"cond", func(c int) buffer[int] { return newCondBuffer[int](c) }},
+	{"semaphore", func(c int) buffer[int] { return newSemBuffer[int](c) }},
+}
+
+// run has producers put 1..items each into b while consumers take every
+// item out. It returns the sum of all items consumed.
+func run(b buffer[int], producers, consumers, items int) int {
+	total := producers * items
+	sums := make([]int, consumers)
+
+	var wg sync.WaitGroup
+	for range producers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 1; i <= items; i++ {
+				b.Put(i)
+			}
+		}()
+	}
+	for c := range consumers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// Split the items evenly; the first consumer takes the remainder.
+			n := total / consumers
+			if c == 0 {
+				n += total % consumers
+			}
+			for range n {
+				sums[c] += b.Get()
+			}
+		}()
+	}
+	wg.Wait()
+
+	sum := 0
+	for _, s := range sums {
+		sum += s
+	}
+	return sum
+}
+
+func main() {
+	capacity := flag.Int("capacity", 16, "buffer capacity")
+	producers := flag.Int("producers", 4, "number of producers")
+	consumers := flag.Int("consumers", 4, "number of consumers")
+	items := flag.Int("items", 100_000, "items per producer")
+	flag.Parse()
+
+	for _, impl := range implementations {
+		start := time.Now()
+		sum := run(impl.new(*capacity), *producers, *consumers, *items)
+		fmt.Printf("%-9s sum %d in %v\n", impl.name, sum, time.Since(start).Round(time.Millisecond))
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lotusirous/gochan/internal/exampletest"
+)
+
+func TestMain(m *testing.M) { exampletest.Main(m, main) }
+
+func TestFIFO(t *testing.T) {
+	for _, impl := range implementations {
+		t.Run(impl.name, func(t *testing.T) {
+			b := impl.new(3)
+			go func() {
+				for i := range 100 {
+					b.Put(i)
+				}
+			}()
+			for i := range 100 {
+				if got := b.Get(); got != i {
+					t.Fatalf("Get = %d, want %d", got, i)
+				}
+			}
+		})
+	}
+}
+
+func TestNoItemLostOrDuplicated(t *testing.T) {
+	const producers, consumers, items = 4, 3, 1000
+	want := producers * items * (items + 1) / 2
+	for _, impl := range implementations {
+		t.Run(impl.name, func(t *testing.T) {
+			if got := run(impl.new(5), producers, consumers, items); got != want {
+				t.Errorf("sum = %d, want %d", got, want)
+			}
+		})
+	}
+}
+
+func TestPutBlocksWhenFull(t *testing.T) {
+	for _, impl := range implementations {
+		t.Run(impl.name, func(t *testing.T) {
+			b := impl.new(2)
+			b.Put(1)
+			b.Put(2)
+
+			put := make(chan struct{})
+			go func() { b.Put(3); close(put) }()
+			select {
+			case <-put:
+				t.Fatal("Put did not block on a full buffer")
+			case <-time.After(10 * time.Millisecond):
+			}
+			b.Get()
+			<-put
+		})
+	}
+}
+
+func TestMainOutput(t *testing.T) {
+	out := exampletest.Run(t, "-items", "100")
+	if n := strings.Count(out, "sum 20200"); n != 3 {
+		t.Errorf("want 3 implementations with sum 20200:\n%s", out)
+	}
+}
+
+// BenchmarkBoundedBuffer compares the three implementations with one and
+// with several producers and consumers. Run with -cpu=1,4 to see how they
+// behave under contention.
+func BenchmarkBoundedBuffer(b *testing.B) {
+	const items = 1000
+	for _, pc := range []int{1, 4} {
+		for _, impl := range implementations {
+			b.Run(fmt.Sprintf("%s/%dx%d", impl.name, pc, pc), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					run(impl.new(16), pc, pc, items)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,91 @@
+// The sleeping barber: a barber shop has one barber, one barber chair and a
+// waiting room with a few chairs. The barber sleeps when nobody is waiting.
+// A customer who finds every waiting chair taken leaves.
+//
+// In Go the waiting room is a buffered channel. The barber sleeping is a
+// goroutine blocked on receive, and a customer leaving is a send in a select
+// with a default case. That last part is load shedding: when a server is
+// saturated, rejecting work right away is better than an ever-growing queue.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/lotusirous/gochan/teach"
+)
+
+type customer struct {
+	id      int
+	arrived time.Time
+}
+
+// stats is the outcome of a day at the shop.
+type stats struct {
+	Served, TurnedAway int
+	MaxWait            time.Duration
+}
+
+// shop runs one barber with chairs waiting chairs. Customers arrive with
+// gap() between them and a haircut takes cut. If verbose, every event is
+// printed.
+func shop(chairs, customers int, gap func() time.Duration, cut time.Duration, verbose bool) stats {
+	// log names the goroutine printing for patterns run -explain.
+	log := func(ctx context.Context, format string, args ...any) {
+		if verbose {
+			teach.Printf(ctx, format+"\n", args...)
+		}
+	}
+	waiting := make(chan customer, chairs)
+
+	var s stats
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() { // the barber
+		defer wg.Done()
+		ctx := teach.As(context.Background(), "barber")
+		for c := range waiting { // blocked here means asleep
+			wait := time.Since(c.arrived)
+			s.MaxWait = max(s.MaxWait, wait)
+			log(ctx, "barber: cutting customer %d (waited %v)", c.id, wait.Round(time.Millisecond))
+			time.Sleep(cut)
+			s.Served++
+		}
+	}()
+
+	door := teach.As(context.Background(), "door") // customers arrive in this goroutine
+	for id := range customers {
+		time.Sleep(gap())
+		select {
+		case waiting <- customer{id, time.Now()}:
+			log(door, "customer %d: sits down (%d waiting)", id, len(waiting))
+		default:
+			log(door, "customer %d: no free chair, leaves", id)
+			s.TurnedAway++
+		}
+	}
+	close(waiting) // closing time: finish the customers still waiting
+	wg.Wait()
+	return s
+}
+
+func main() {
+	chairs := flag.Int("chairs", 3, "chairs in the waiting room")
+	customers := flag.Int("customers", 30, "customers arriving during the day")
+	rate := flag.Float64("rate", 25, "average arrivals per second")
+	cut := flag.Duration("cut", 50*time.Millisecond, "time for one haircut")
+	quiet := flag.Bool("quiet", false, "print only the summary")
+	flag.Parse()
+
+	// Exponential gaps make arrivals a Poisson process: bursts and lulls.
+	mean := time.Duration(float64(time.Second) / *rate)
+	gap := func() time.Duration { return time.Duration(rand.ExpFloat64() * float64(mean)) }
+
+	s := shop(*chairs, *customers, gap, *cut, !*quiet)
+	fmt.Printf("served %d, turned away %d, longest wait %v\n",
+		s.Served, s.TurnedAway, s.MaxWait.Round(time.Millisecond))
+}
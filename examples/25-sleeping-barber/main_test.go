@@ -0,0 +1,40 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lotusirous/gochan/internal/exampletest"
+)
+
+func TestMain(m *testing.M) { exampletest.Main(m, main) }
+
+func noGap() time.Duration { return 0 }
+
+func TestEveryoneServedWhenBarberIsFast(t *testing.T) {
+	slow := func() time.Duration { return 2 * time.Millisecond }
+	s := shop(3, 10, slow, 0, false)
+	if s.Served != 10 || s.TurnedAway != 0 {
+		t.Errorf("got %+v, want all 10 served", s)
+	}
+}
+
+func TestFullWaitingRoomTurnsCustomersAway(t *testing.T) {
+	// Ten customers at once and a slow barber: at most one in the chair and
+	// three waiting get served.
+	s := shop(3, 10, noGap, 20*time.Millisecond, false)
+	if s.Served+s.TurnedAway != 10 {
+		t.Fatalf("customers lost: %+v", s)
+	}
+	if s.Served < 3 || s.Served > 4 {
+		t.Errorf("served %d, want 3 or 4", s.Served)
+	}
+}
+
+func TestMainOutput(t *testing.T) {
+	out := exampletest.Run(t, "-customers", "5", "-rate", "1000", "-cut", "1ms", "-quiet")
+	if !strings.HasPrefix(out, "served ") || strings.Count(out, "\n") != 1 {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+}
@@ -0,0 +1,226 @@
+// Readers–writers: any number of readers may hold the lock together, but a
+// writer needs it alone. The interesting question is who goes next when both
+// are waiting, because the answer decides who can starve:
+//
+//   - reader preference: new readers join as long as any reader is inside.
+//     Overlapping readers can keep a writer out forever.
+//   - writer preference: a waiting writer goes before every waiting reader.
+//     A steady stream of writers can keep readers out forever.
+//   - fair: requests are served in arrival order; consecutive readers at the
+//     head of the queue go in together.
+//
+// sync.RWMutex blocks new readers once a writer is waiting, so it behaves
+// close to the fair mode. The channel-based lock below makes the policy
+// explicit: a single goroutine owns the state and grants requests.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type policy int
+
+const (
+	readerPreference policy = iota
+	writerPreference
+	fair
+)
+
+func (p policy) String() string {
+	return [...]string{"reader-pref", "writer-pref", "fair"}[p]
+}
+
+// rwLocker is implemented by sync.RWMutex and by chanRW.
+type rwLocker interface {
+	RLock()
+	RUnlock()
+	Lock()
+	Unlock()
+}
+
+type request struct {
+	write bool
+	grant chan struct{}
+}
+
+// chanRW is a readers–writer lock run by a manager goroutine.
+type chanRW struct {
+	requests chan request
+	released chan bool // true for a writer
+	quit     chan struct{}
+}
+
+func newChanRW(p policy) *chanRW {
+	l := &chanRW{
+		requests: make(chan request),
+		released: make(chan bool),
+		quit:     make(chan struct{}),
+	}
+	go l.manage(p)
+	return l
+}
+
+func (l *chanRW) acquire(write bool) {
+	r := request{write, make(chan struct{})}
+	l.requests <- r
+	<-r.grant
+}
+
+func (l *chanRW) RLock()   { l.acquire(false) }
+func (l *chanRW) Lock()    { l.acquire(true) }
+func (l *chanRW) RUnlock() { l.released <- false }
+func (l *chanRW) Unlock()  { l.released <- true }
+
+// Close stops the manager goroutine. The lock must not be used afterwards.
+func (l *chanRW) Close() { close(l.quit) }
+
+func (l *chanRW) manage(p policy) {
+	var (
+		queue   []request
+		readers int
+		writing bool
+	)
+	for {
+		// Grant as much as the policy allows before waiting again.
+		for !writing {
+			i := next(p, queue, readers)
+			if i < 0 {
+				break
+			}
+			r := queue[i]
+			queue = append(queue[:i], queue[i+1:]...)
+			if r.write {
+				writing = true
+			} else {
+				readers++
+			}
+			close(r.grant)
+		}
+
+		select {
+		case r := <-l.requests:
+			queue = append(queue, r)
+		case write := <-l.released:
+			if write {
+				writing = false
+			} else {
+				readers--
+			}
+		case <-l.quit:
+			return
+		}
+	}
+}
+
+// next returns the index of the queued request to grant, or -1 if the
+// policy says everybody has to wait. No writer is holding the lock.
+func next(p policy, queue []request, readers int) int {
+	first := func(write bool) int {
+		for i, r := range queue {
+			if r.write == write {
+				return i
+			}
+		}
+		return -1
+	}
+	switch {
+	case len(queue) == 0:
+		return -1
+	case p == readerPreference:
+		if i := first(false); i >= 0 {
+			return i // readers may always join other readers
+		}
+	case p == writerPreference:
+		if w := first(true); w >= 0 {
+			if readers > 0 {
+				return -1 // let the readers inside finish, admit nobody else
+			}
+			return w
+		}
+		return 0 // only readers are waiting
+	case p == fair:
+		if !queue[0].write {
+			return 0
+		}
+	}
+	if readers == 0 {
+		return 0
+	}
+	return -1
+}
+
+// waits records the longest time readers and writers waited for the lock.
+type waits struct {
+	reader, writer atomic.Int64 // nanoseconds
+}
+
+func (w *waits) observe(write bool, d time.Duration) {
+	m := &w.reader
+	if write {
+		m = &w.writer
+	}
+	for {
+		old := m.Load()
+		if int64(d) <= old || m.CompareAndSwap(old, int64(d)) {
+			return
+		}
+	}
+}
+
+// workload runs readers and writers against l for the given duration.
+// Readers hold the lock a little longer than writers and overlap, which is
+// what makes reader preference dangerous.
+func workload(l rwLocker, readers, writers int, d time.Duration) (maxRead, maxWrite time.Duration) {
+	var w waits
+	stop := time.Now().Add(d)
+	var wg sync.WaitGroup
+	run := func(write bool, hold time.Duration) {
+		defer wg.Done()
+		for time.Now().Before(stop) {
+			start := time.Now()
+			if write {
+				l.Lock()
+			} else {
+				l.RLock()
+			}
+			w.observe(write, time.Since(start))
+			time.Sleep(hold)
+			if write {
+				l.Unlock()
+			} else {
+				l.RUnlock()
+			}
+		}
+	}
+	for range readers {
+		wg.Add(1)
+		go run(false, 2*time.Millisecond)
+	}
+	for range writers {
+		wg.Add(1)
+		go run(true, time.Millisecond)
+	}
+	wg.Wait()
+	return time.Duration(w.reader.Load()), time.Duration(w.writer.Load())
+}
+
+func main() {
+	readers := flag.Int("readers", 8, "reader goroutines")
+	writers := flag.Int("writers", 2, "writer goroutines")
+	d := flag.Duration("d", 300*time.Millisecond, "how long to run each lock")
+	flag.Parse()
+
+	fmt.Printf("%-12s %14s %14s\n", "lock", "max read wait", "max write wait")
+	r, w := workload(new(sync.RWMutex), *readers, *writers, *d)
+	fmt.Printf("%-12s %14v %14v\n", "RWMutex", r.Round(time.Millisecond), w.Round(time.Millisecond))
+	for _, p := range []policy{readerPreference, writerPreference, fair} {
+		l := newChanRW(p)
+		r, w := workload(l, *readers, *writers, *d)
+		l.Close()
+		fmt.Printf("%-12s %14v %14v\n", p, r.Round(time.Millisecond), w.Round(time.Millisecond))
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lotusirous/gochan/internal/exampletest"
+)
+
+func TestMain(m *testing.M) { exampletest.Main(m, main) }
+
+// locks returns every implementation, with a cleanup for the managers.
+func locks(t *testing.T) map[string]rwLocker {
+	ls := map[string]rwLocker{"RWMutex": new(sync.RWMutex)}
+	for _, p := range []policy{readerPreference, writerPreference, fair} {
+		l := newChanRW(p)
+		t.Cleanup(l.Close)
+		ls[p.String()] = l
+	}
+	return ls
+}
+
+func TestExclusion(t *testing.T) {
+	for name, l := range locks(t) {
+		t.Run(name, func(t *testing.T) {
+			var readers, writers, maxReaders atomic.Int32
+			var wg sync.WaitGroup
+			for i := range 20 {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for range 20 {
+						if i%4 == 0 {
+							l.Lock()
+							if writers.Add(1) != 1 || readers.Load() != 0 {
+								t.Error("writer is not alone")
+							}
+							writers.Add(-1)
+							l.Unlock()
+							continue
+						}
+						l.RLock()
+						n := readers.Add(1)
+						if writers.Load() != 0 {
+							t.Error("reader and writer inside together")
+						}
+						for m := maxReaders.Load(); n > m && !maxReaders.CompareAndSwap(m, n); m = maxReaders.Load() {
+						}
+						time.Sleep(100 * time.Microsecond)
+						readers.Add(-1)
+						l.RUnlock()
+					}
+				}()
+			}
+			wg.Wait()
+			if maxReaders.Load() < 2 {
+				t.Errorf("readers never shared the lock")
+			}
+		})
+	}
+}
+
+// TestStarvation runs the same mixed workload against every lock. Each
+// preference starves the other side for the whole run; the fair lock and
+// sync.RWMutex keep every wait short.
+func TestStarvation(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs each lock for 150ms")
+	}
+	const d = 150 * time.Millisecond
+	const starved, short = 100 * time.Millisecond, 50 * time.Millisecond
+
+	for name, l := range locks(t) {
+		t.Run(name, func(t *testing.T) {
+			read, write := workload(l, 8, 2, d)
+			switch name {
+			case "reader-pref":
+				if write < starved {
+					t.Errorf("writers waited at most %v, expected them to starve", write)
+				}
+			case "writer-pref":
+				if read < starved {
+					t.Errorf("readers waited at most %v, expected them to starve", read)
+				}
+			default:
+				if read > short || write > short {
+					t.Errorf("max waits read %v, write %v; want both under %v", read, write, short)
+				}
+			}
+		})
+	}
+}
+
+func TestMainOutput(t *testing.T) {
+	out := exampletest.Run(t, "-d", "20ms")
+	for _, want := range []string{"RWMutex", "reader-pref", "writer-pref", "fair"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output misses %s:\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+// Ping-pong (example 13) generalized to N players sitting in a ring: the ball
+// goes from each player to the next, round and round, until a hit limit is
+// reached.
+//
+// Ending the game cleanly is the interesting part. Closing a shared table
+// channel from inside a player races with the other player sending on it.
+// Here every player has its own inbox, only the referee decides the game is
+// over, and it does so by cancelling a context. Players never close
+// channels, so nobody can send on a closed one.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Ball is passed from player to player.
+type Ball struct {
+	Hits int
+	Last string // who hit it last
+}
+
+// play runs a game between players until the ball has been hit limit times.
+// onHit, if not nil, is called by every player after each hit. play returns
+// the ball after the last hit, once every player goroutine has exited, or
+// ctx.Err() if ctx is done first.
+func play(ctx context.Context, players []string, limit int, onHit func(Ball)) (Ball, error) {
+	if len(players) == 0 {
+		return Ball{}, errors.New("no players")
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Buffered by one so a hit never waits for the next player to be ready.
+	inbox := make([]chan *Ball, len(players))
+	for i := range inbox {
+		inbox[i] = make(chan *Ball, 1)
+	}
+	over := make(chan *Ball, 1) // the last hitter hands the ball to the referee
+
+	var wg sync.WaitGroup
+	for i, name := range players {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			next := inbox[(i+1)%len(players)]
+			for {
+				var ball *Ball
+				select {
+				case ball = <-inbox[i]:
+				case <-ctx.Done():
+					return
+				}
+				ball.Hits++
+				ball.Last = name
+				if onHit != nil {
+					onHit(*ball)
+				}
+				out := next
+				if ball.Hits >= limit {
+					out = over
+				}
+				select {
+				case out <- ball:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	inbox[0] <- new(Ball) // toss the ball to the first player
+
+	var ball Ball
+	var err error
+	select {
+	case b := <-over:
+		ball = *b
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	cancel() // game over: every player leaves the table
+	wg.Wait()
+	return ball, err
+}
+
+func main() {
+	names := flag.String("players", "ping,pong,pang", "comma-separated player names")
+	limit := flag.Int("hits", 9, "hits before the game ends")
+	delay := flag.Duration("delay", 100*time.Millisecond, "pause after every hit")
+	flag.Parse()
+
+	ball, err := play(context.Background(), strings.Split(*names, ","), *limit, func(b Ball) {
+		fmt.Println(b.Last, b.Hits)
+		time.Sleep(*delay)
+	})
+	if err != nil {
+		fmt.Println("game aborted:", err)
+		return
+	}
+	fmt.Printf("Game finished after %d hits, last hit by %s\n", ball.Hits, ball.Last)
+}
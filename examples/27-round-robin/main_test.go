@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lotusirous/gochan/internal/exampletest"
+)
+
+func TestMain(m *testing.M) { exampletest.Main(m, main) }
+
+func TestRoundRobinOrder(t *testing.T) {
+	players := []string{"a", "b", "c", "d"}
+	var mu sync.Mutex
+	var order []string
+	ball, err := play(context.Background(), players, 10, func(b Ball) {
+		mu.Lock()
+		order = append(order, b.Last)
+		mu.Unlock()
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ball.Hits != 10 || ball.Last != "b" {
+		t.Errorf("final ball = %+v, want 10 hits by b", ball)
+	}
+	if got, want := strings.Join(order, ""), "abcdabcdab"; got != want {
+		t.Errorf("hit order = %s, want %s", got, want)
+	}
+}
+
+func TestPlayersExit(t *testing.T) {
+	before := runtime.NumGoroutine()
+	for n := 1; n <= 5; n++ {
+		players := make([]string, n)
+		for i := range players {
+			players[i] = fmt.Sprint("p", i)
+		}
+		if _, err := play(context.Background(), players, 3*n+1, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if after := runtime.NumGoroutine(); after > before {
+		t.Errorf("%d goroutines left behind", after-before)
+	}
+}
+
+func TestPlayHonorsContext(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := play(ctx, []string{"ping", "pong"}, 1_000_000, func(Ball) { time.Sleep(time.Millisecond) })
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("play = %v, want deadline exceeded", err)
+	}
+}
+
+func TestMainOutput(t *testing.T) {
+	out := exampletest.Run(t, "-players", "ping,pong", "-hits", "4", "-delay", "0")
+	want := "ping 1\npong 2\nping 3\npong 4\nGame finished after 4 hits, last hit by pong\n"
+	if out != want {
+		t.Errorf("got:\n%s\nwant:\n%s", out, want)
+	}
+}
@@ -0,0 +1,170 @@
+// A bank with k tellers and a waiting area of limited size: customers arrive
+// at random (a Poisson process), wait in line if every teller is busy, and
+// leave if the waiting area is full. The simulation reports how long people
+// waited, how many were turned away and how busy the tellers were.
+//
+// The tellers are a worker pool of goroutines that really sleep for each
+// service. They sleep on a fake clock (package clock), which the dispatcher
+// advances from one event to the next as soon as every busy teller is
+// asleep. Hours of bank time take milliseconds, and a given seed always
+// produces the same report, so the statistics can be tested exactly.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/lotusirous/gochan/clock"
+)
+
+type config struct {
+	Servers   int           // tellers
+	Queue     int           // places in the waiting area
+	Customers int           // arrivals to simulate
+	Rate      float64       // mean arrivals per second
+	Service   time.Duration // mean service time
+	Seed      uint64
+}
+
+// report holds the statistics of one run.
+type report struct {
+	Arrived, Served, Rejected int
+	Waits                     []time.Duration // of served customers, sorted
+	Busy                      time.Duration   // total service time of all tellers
+	Elapsed                   time.Duration   // simulated time
+}
+
+func (r report) MeanWait() time.Duration {
+	if len(r.Waits) == 0 {
+		return 0
+	}
+	var sum time.Duration
+	for _, w := range r.Waits {
+		sum += w
+	}
+	return sum / time.Duration(len(r.Waits))
+}
+
+// Percentile returns the wait below which p percent of the customers waited.
+func (r report) Percentile(p float64) time.Duration {
+	if len(r.Waits) == 0 {
+		return 0
+	}
+	return r.Waits[min(int(p/100*float64(len(r.Waits))), len(r.Waits)-1)]
+}
+
+// Utilization is the fraction of the simulated time the tellers were busy.
+func (r report) Utilization(servers int) float64 {
+	return float64(r.Busy) / float64(r.Elapsed*time.Duration(servers))
+}
+
+type customer struct {
+	arrived time.Time
+	service time.Duration
+}
+
+var epoch = time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+
+func simulate(cfg config) report {
+	clk := clock.NewFake(epoch)
+	rng := rand.New(rand.NewPCG(cfg.Seed, 0))
+	exp := func(mean time.Duration) time.Duration {
+		// At least 1ns: a zero sleep would not register with the clock.
+		return max(time.Duration(rng.ExpFloat64()*float64(mean)), 1)
+	}
+	gap := time.Duration(float64(time.Second) / cfg.Rate)
+
+	// The tellers. jobs is unbuffered and only sent to when a teller is idle.
+	jobs := make(chan customer)
+	done := make(chan customer, cfg.Servers)
+	var wg sync.WaitGroup
+	for range cfg.Servers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for c := range jobs {
+				clk.Sleep(c.service)
+				done <- c
+			}
+		}()
+	}
+
+	var r report
+	var line []customer
+	busy := 0
+	serve := func(c customer) {
+		r.Waits = append(r.Waits, clk.Now().Sub(c.arrived))
+		r.Busy += c.service
+		busy++
+		jobs <- c
+	}
+
+	nextArrival := epoch.Add(exp(gap))
+	for r.Arrived < cfg.Customers || busy > 0 {
+		// Wait until every busy teller sleeps, then jump to the next event:
+		// the next arrival or the end of the earliest service.
+		clk.BlockUntil(busy)
+		arriving := r.Arrived < cfg.Customers
+		next := nextArrival
+		if t, ok := clk.Next(); ok && (!arriving || t.Before(next)) {
+			next = t
+		}
+		for range clk.Advance(next.Sub(clk.Now())) {
+			<-done
+			busy--
+			r.Served++
+		}
+
+		if arriving && !clk.Now().Before(nextArrival) {
+			r.Arrived++
+			c := customer{arrived: clk.Now(), service: exp(cfg.Service)}
+			switch {
+			case busy < cfg.Servers:
+				serve(c)
+			case len(line) < cfg.Queue:
+				line = append(line, c)
+			default:
+				r.Rejected++
+			}
+			nextArrival = nextArrival.Add(exp(gap))
+		}
+		for busy < cfg.Servers && len(line) > 0 {
+			serve(line[0])
+			line = line[1:]
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	r.Elapsed = clk.Now().Sub(epoch)
+	slices.Sort(r.Waits)
+	return r
+}
+
+func main() {
+	var cfg config
+	flag.IntVar(&cfg.Servers, "servers", 3, "number of tellers")
+	flag.IntVar(&cfg.Queue, "queue", 10, "places in the waiting area")
+	flag.IntVar(&cfg.Customers, "customers", 10_000, "customers to simulate")
+	flag.Float64Var(&cfg.Rate, "rate", 5, "mean arrivals per second")
+	flag.DurationVar(&cfg.Service, "service", 500*time.Millisecond, "mean service time")
+	flag.Uint64Var(&cfg.Seed, "seed", 1, "random seed")
+	flag.Parse()
+
+	start := time.Now()
+	r := simulate(cfg)
+	load := cfg.Rate * cfg.Service.Seconds() / float64(cfg.Servers)
+
+	fmt.Printf("%d tellers, %d waiting places, offered load %.0f%%\n", cfg.Servers, cfg.Queue, 100*load)
+	fmt.Printf("simulated %v in %v\n", r.Elapsed.Round(time.Second), time.Since(start).Round(time.Millisecond))
+	fmt.Printf("arrived %d, served %d, turned away %d (%.1f%%)\n",
+		r.Arrived, r.Served, r.Rejected, 100*float64(r.Rejected)/float64(max(r.Arrived, 1)))
+	fmt.Printf("wait: mean %v, p50 %v, p95 %v, max %v\n",
+		r.MeanWait().Round(time.Millisecond), r.Percentile(50).Round(time.Millisecond),
+		r.Percentile(95).Round(time.Millisecond), r.Percentile(100).Round(time.Millisecond))
+	fmt.Printf("teller utilization %.0f%%\n", 100*r.Utilization(cfg.Servers))
+}
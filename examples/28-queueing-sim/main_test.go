@@ -0,0 +1,73 @@
+package main
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lotusirous/gochan/internal/exampletest"
+)
+
+func TestMain(m *testing.M) { exampletest.Main(m, main) }
+
+var bank = config{Servers: 3, Queue: 5, Customers: 2000, Rate: 5, Service: 500 * time.Millisecond, Seed: 7}
+
+func TestDeterministic(t *testing.T) {
+	a, b := simulate(bank), simulate(bank)
+	if !reflect.DeepEqual(a, b) {
+		t.Fatalf("two runs with the same seed differ:\n%+v\n%+v", a, b)
+	}
+	other := bank
+	other.Seed++
+	if reflect.DeepEqual(a, simulate(other)) {
+		t.Error("a different seed produced the same report")
+	}
+}
+
+func TestEveryCustomerAccountedFor(t *testing.T) {
+	r := simulate(bank)
+	if r.Arrived != bank.Customers || r.Served+r.Rejected != r.Arrived || len(r.Waits) != r.Served {
+		t.Errorf("arrived %d, served %d, rejected %d, waits %d", r.Arrived, r.Served, r.Rejected, len(r.Waits))
+	}
+	if u := r.Utilization(bank.Servers); u <= 0 || u > 1 {
+		t.Errorf("utilization %v out of range", u)
+	}
+}
+
+// TestMM1 checks the simulation against queueing theory: with one server,
+// an unbounded queue, arrival rate λ and service rate μ, the mean wait in
+// line is λ / (μ(μ-λ)).
+func TestMM1(t *testing.T) {
+	cfg := config{Servers: 1, Queue: math.MaxInt, Customers: 50_000, Rate: 1, Service: 500 * time.Millisecond, Seed: 1}
+	lambda, mu := 1.0, 2.0
+	want := lambda / (mu * (mu - lambda)) // 0.5s
+
+	r := simulate(cfg)
+	if got := r.MeanWait().Seconds(); math.Abs(got-want)/want > 0.1 {
+		t.Errorf("mean wait %.3fs, theory says %.3fs", got, want)
+	}
+	if r.Rejected != 0 {
+		t.Errorf("rejected %d with an unbounded queue", r.Rejected)
+	}
+}
+
+func TestNoQueueRejectsWhileBusy(t *testing.T) {
+	// Arrivals every 10ms on average, each service takes 10s on average:
+	// the single teller is almost always busy.
+	r := simulate(config{Servers: 1, Queue: 0, Customers: 100, Rate: 100, Service: 10 * time.Second, Seed: 3})
+	if r.Served > 5 || r.Rejected < 95 {
+		t.Errorf("served %d, rejected %d; expected nearly everybody turned away", r.Served, r.Rejected)
+	}
+	if r.Percentile(100) != 0 {
+		t.Errorf("without a queue nobody waits, got max wait %v", r.Percentile(100))
+	}
+}
+
+func TestMainOutput(t *testing.T) {
+	out := exampletest.Run(t, "-customers", "100")
+	if !strings.Contains(out, "arrived 100,") || !strings.Contains(out, "teller utilization") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+}
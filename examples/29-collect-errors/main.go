@@ -0,0 +1,109 @@
+// The worker pool from example 18, with jobs that can fail.
+//
+// A results channel of plain values has nowhere to put an error, so the
+// usual shortcut is to skip failed jobs, or log them and move on. The caller
+// then sees fewer results and cannot tell which jobs were lost or why. Here
+// every worker records its failures in an errs.Collector, and once the pool
+// has drained the caller gets all of them back as one joined error.
+//
+// A job that panics would take the whole program down with it, since recover
+// only works in the goroutine that panicked. Each worker therefore runs its
+// jobs through safego.Do, which turns the panic into one more failed job.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"sync"
+
+	"github.com/lotusirous/gochan/errs"
+	"github.com/lotusirous/gochan/safego"
+)
+
+var errOdd = errors.New("odd input")
+
+// process doubles jobs, rejects odd multiples of 3 and panics on multiples
+// of 7, standing in for work that sometimes fails.
+func process(j int) (int, error) {
+	if j%7 == 0 {
+		panic(fmt.Sprintf("job %d: corrupt input", j))
+	}
+	if j%3 == 0 && j%2 == 1 {
+		return 0, fmt.Errorf("job %d: %w", j, errOdd)
+	}
+	return j * 2, nil
+}
+
+// dropping is the lossy version: a failed job simply produces no result.
+func dropping(jobs []int, workers int) []int {
+	return runPool(jobs, workers, process, nil)
+}
+
+// collecting runs the same pool but keeps every error.
+func collecting(jobs []int, workers int) ([]int, error) {
+	var c errs.Collector
+	results := runPool(jobs, workers, process, &c)
+	return results, c.Err()
+}
+
+// runPool feeds jobs to a fixed number of workers and gathers the successful
+// results. Failures go to c, or are dropped when c is nil.
+func runPool(jobs []int, workers int, fn func(int) (int, error), c *errs.Collector) []int {
+	in := make(chan int)
+	out := make(chan int, len(jobs))
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range in {
+				var v int
+				err := safego.Do(func() (err error) {
+					v, err = fn(j)
+					return err
+				})
+				if err != nil {
+					if c != nil {
+						c.Add(err)
+					}
+					continue
+				}
+				out <- v
+			}
+		}()
+	}
+	for _, j := range jobs {
+		in <- j
+	}
+	close(in)
+	wg.Wait()
+	close(out)
+
+	var results []int
+	for v := range out {
+		results = append(results, v)
+	}
+	return results
+}
+
+func main() {
+	n := flag.Int("jobs", 12, "number of jobs")
+	workers := flag.Int("workers", 3, "number of workers")
+	flag.Parse()
+
+	jobs := make([]int, *n)
+	for i := range jobs {
+		jobs[i] = i + 1
+	}
+
+	fmt.Printf("dropping:   %d of %d jobs returned a result, no idea why\n",
+		len(dropping(jobs, *workers)), *n)
+
+	results, err := collecting(jobs, *workers)
+	fmt.Printf("collecting: %d of %d jobs returned a result\n", len(results), *n)
+	if err != nil {
+		fmt.Println("failed jobs:")
+		fmt.Println(err)
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/lotusirous/gochan/internal/exampletest"
+	"github.com/lotusirous/gochan/safego"
+)
+
+func TestMain(m *testing.M) { exampletest.Main(m, main) }
+
+func TestCollectingReportsEveryFailure(t *testing.T) {
+	jobs := make([]int, 30)
+	for i := range jobs {
+		jobs[i] = i + 1
+	}
+	results, err := collecting(jobs, 4)
+	if len(results) != 22 {
+		t.Errorf("%d results, want 22", len(results))
+	}
+	if !errors.Is(err, errOdd) {
+		t.Fatalf("err = %v, want errOdd", err)
+	}
+	var pe *safego.PanicError
+	if !errors.As(err, &pe) {
+		t.Errorf("err = %v, want the panics as *safego.PanicError", err)
+	}
+	for _, j := range []string{"job 3:", "job 7:", "job 9:", "job 14:", "job 15:", "job 21:", "job 27:", "job 28:"} {
+		if !strings.Contains(err.Error(), j) {
+			t.Errorf("error does not mention %q:\n%v", j, err)
+		}
+	}
+
+	if got := dropping(jobs, 4); len(got) != len(results) {
+		t.Errorf("dropping returned %d results, want %d", len(got), len(results))
+	}
+}
+
+func TestMainOutput(t *testing.T) {
+	out := exampletest.Run(t)
+	if !strings.Contains(out, "9 of 12 jobs returned a result") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+	if n := strings.Count(out, "odd input"); n != 2 {
+		t.Errorf("%d failures reported, want 2:\n%s", n, out)
+	}
+	if !strings.Contains(out, "panic: job 7: corrupt input") {
+		t.Errorf("panic not reported:\n%s", out)
+	}
+}
@@ -1,9 +1,12 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"math/rand"
 	"time"
+
+	"github.com/lotusirous/gochan/chans"
 )
 
 // boring is a function that returns a channel to communicate with it.
@@ -26,6 +29,15 @@ func boring(msg string) <-chan string {
 	return c // return a channel to caller.
 }
 
+// boringCtx is boring with a way out: it stops as soon as ctx is done, so the
+// consumer can walk away early without leaking the goroutine.
+func boringCtx(ctx context.Context, msg string) <-chan string {
+	return chans.Generate(ctx, -1, func(i int) string {
+		time.Sleep(time.Duration(rand.Intn(1e2)) * time.Millisecond)
+		return fmt.Sprintf("%s %d", msg, i)
+	})
+}
+
 func main() {
 
 	joe := boring("Joe")
@@ -43,4 +55,15 @@ func main() {
 	// }
 	fmt.Println("You're both boring. I'm leaving")
 
+	// Since Go 1.23 a channel can be consumed as an iterator. Breaking out of
+	// the loop is fine: cancel tells the generator to stop sending.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	for msg := range chans.NewStream(ctx, boringCtx(ctx, "Ann")).Seq() {
+		fmt.Println(msg)
+		if msg == "Ann 4" {
+			break
+		}
+	}
+	fmt.Println("Ann, you're boring too.")
 }
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/lotusirous/gochan/internal/exampletest"
+)
+
+func TestMain(m *testing.M) { exampletest.Main(m, main) }
+
+func TestBoringGenerates(t *testing.T) {
+	c := boring("Joe")
+	if got := <-c; got != "Joe 0" {
+		t.Fatalf("got %q, want Joe 0", got)
+	}
+}
+
+func TestBoringCtxStopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	c := boringCtx(ctx, "Ann")
+	if got := <-c; got != "Ann 0" {
+		t.Fatalf("got %q, want Ann 0", got)
+	}
+	cancel()
+	for range c { // must be closed eventually
+	}
+}
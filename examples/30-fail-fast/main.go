@@ -0,0 +1,101 @@
+// Two ways to run a batch of tasks when some of them can fail.
+//
+// Wait for all (errs.Collect, example 29): every task runs to the end and the
+// caller gets every error. Right when the tasks are independent.
+//
+// Fail fast (errs.FailFast): the first failure cancels a shared context, so
+// the remaining tasks stop early instead of doing work nobody will use. The
+// batch finishes as soon as the failure is known, yet the tasks that had
+// already completed still hand back their results.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/lotusirous/gochan/ctxutil"
+	"github.com/lotusirous/gochan/errs"
+)
+
+// task fakes a download that takes d and fails if bad is set.
+type task struct {
+	Name string
+	D    time.Duration
+	Bad  bool
+}
+
+func (t task) run(ctx context.Context) (string, error) {
+	if err := ctxutil.Sleep(ctx, t.D); err != nil {
+		return "", fmt.Errorf("%s: %w", t.Name, err)
+	}
+	if t.Bad {
+		return "", fmt.Errorf("%s: %w", t.Name, errBroken)
+	}
+	return t.Name + " ok", nil
+}
+
+var errBroken = errors.New("broken")
+
+func tasks(unit time.Duration) []task {
+	return []task{
+		{"a", 1 * unit, false},
+		{"b", 2 * unit, false},
+		{"c", 3 * unit, true},
+		{"d", 6 * unit, false},
+		{"e", 9 * unit, false},
+	}
+}
+
+func failFast(ctx context.Context, ts []task) ([]string, error) {
+	fns := make([]func(context.Context) (string, error), len(ts))
+	for i, t := range ts {
+		fns[i] = t.run
+	}
+	results, err := errs.FailFast(ctx, fns...)
+	var done []string
+	for _, r := range results {
+		if r.OK() {
+			done = append(done, r.Value)
+		}
+	}
+	return done, err
+}
+
+func waitAll(ctx context.Context, ts []task) ([]string, error) {
+	done := make([]string, len(ts))
+	fns := make([]func() error, len(ts))
+	for i, t := range ts {
+		fns[i] = func() (err error) {
+			done[i], err = t.run(ctx)
+			return err
+		}
+	}
+	err := errs.Collect(fns...)
+	var ok []string
+	for _, s := range done {
+		if s != "" {
+			ok = append(ok, s)
+		}
+	}
+	return ok, err
+}
+
+func main() {
+	unit := flag.Duration("unit", 100*time.Millisecond, "time unit for the fake tasks")
+	flag.Parse()
+	ts := tasks(*unit)
+	ctx := context.Background()
+
+	start := time.Now()
+	done, err := waitAll(ctx, ts)
+	fmt.Printf("wait for all: %v after %v\n  completed: %v\n  error: %v\n",
+		len(done), time.Since(start).Round(*unit), done, err)
+
+	start = time.Now()
+	done, err = failFast(ctx, ts)
+	fmt.Printf("fail fast:    %v after %v\n  completed: %v\n  error: %v\n",
+		len(done), time.Since(start).Round(*unit), done, err)
+}
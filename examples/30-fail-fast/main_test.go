@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lotusirous/gochan/internal/exampletest"
+)
+
+func TestMain(m *testing.M) { exampletest.Main(m, main) }
+
+const unit = 20 * time.Millisecond
+
+func TestFailFastKeepsCompletedResults(t *testing.T) {
+	start := time.Now()
+	done, err := failFast(context.Background(), tasks(unit))
+	elapsed := time.Since(start)
+
+	if !errors.Is(err, errBroken) {
+		t.Fatalf("err = %v, want errBroken", err)
+	}
+	if want := []string{"a ok", "b ok"}; !slices.Equal(done, want) {
+		t.Errorf("completed = %v, want %v", done, want)
+	}
+	if elapsed >= 6*unit {
+		t.Errorf("took %v, the slow tasks were not cancelled", elapsed)
+	}
+}
+
+func TestWaitAllRunsEverything(t *testing.T) {
+	done, err := waitAll(context.Background(), tasks(unit))
+	if !errors.Is(err, errBroken) {
+		t.Fatalf("err = %v, want errBroken", err)
+	}
+	if len(done) != 4 {
+		t.Errorf("completed = %v, want 4 tasks", done)
+	}
+}
+
+func TestMainOutput(t *testing.T) {
+	out := exampletest.Run(t, "-unit=10ms")
+	for _, want := range []string{"wait for all: 4", "fail fast:    2", "c: broken"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output lacks %q:\n%s", want, out)
+		}
+	}
+}
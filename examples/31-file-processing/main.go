@@ -0,0 +1,317 @@
+// Processing a large CSV or JSON Lines file with a worker pool.
+//
+// Reading a file is sequential, but parsing and transforming its records is
+// not. The reader cuts the input into chunks of whole records and numbers
+// them; a pool of workers parses the chunks, formats their output and sums
+// up their records; the writer merges the per-chunk totals and writes the
+// output.
+//
+// In unordered mode the writer writes chunks as they finish. In ordered mode
+// it holds chunks that finish early until the ones before them are written,
+// like the sequence restoring of example 5, so the output lines come out in
+// input order at the cost of a small reorder buffer.
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/lotusirous/gochan/pool"
+)
+
+// record is one sale: a row of region,product,qty,price in CSV, or an
+// object with those keys in JSON Lines.
+type record struct {
+	Region  string  `json:"region"`
+	Product string  `json:"product"`
+	Qty     int     `json:"qty"`
+	Price   float64 `json:"price"`
+}
+
+// parser decodes the records of a chunk and calls fn for each one, or with
+// an error for each line that does not parse.
+type parser func(data []byte, fn func(record, error))
+
+func parseCSV(data []byte, fn func(record, error)) {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.FieldsPerRecord = 4
+	r.ReuseRecord = true
+	for {
+		f, err := r.Read()
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			fn(record{}, err)
+			continue
+		}
+		if f[0] == "region" { // header
+			continue
+		}
+		qty, err1 := strconv.Atoi(f[2])
+		price, err2 := strconv.ParseFloat(f[3], 64)
+		fn(record{f[0], f[1], qty, price}, errors.Join(err1, err2))
+	}
+}
+
+func parseJSONL(data []byte, fn func(record, error)) {
+	for line := range bytes.Lines(data) {
+		var rec record
+		fn(rec, json.Unmarshal(line, &rec))
+	}
+}
+
+// summary is what the aggregation produces.
+type summary struct {
+	Records int
+	Bad     int
+	Totals  map[string]float64 // sales per region
+}
+
+func (s *summary) merge(o summary) {
+	s.Records += o.Records
+	s.Bad += o.Bad
+	for k, v := range o.Totals {
+		s.Totals[k] += v
+	}
+}
+
+// format is how the records of an input are written.
+type format struct {
+	parse parser
+	// quoted is set when a double quote opens or closes a field in which
+	// line ends are data, as in CSV. JSON Lines escapes its line ends.
+	quoted bool
+}
+
+var (
+	csvFormat   = format{parseCSV, true}
+	jsonlFormat = format{parseJSONL, false}
+)
+
+// chunk is a run of whole records of the input, numbered in reading order.
+type chunk struct {
+	Seq  int
+	Data []byte
+}
+
+// processed is a chunk's output lines and partial summary.
+type processed struct {
+	Seq int
+	Out []byte
+	summary
+}
+
+// process parses a chunk and formats one region,product,amount line per
+// record. It is the unit of work both modes share.
+func process(c chunk, parse parser) processed {
+	p := processed{Seq: c.Seq, summary: summary{Totals: make(map[string]float64)}}
+	parse(c.Data, func(rec record, err error) {
+		if err != nil {
+			p.Bad++
+			return
+		}
+		amount := float64(rec.Qty) * rec.Price
+		p.Records++
+		p.Totals[rec.Region] += amount
+		p.Out = fmt.Appendf(p.Out, "%s,%s,%.2f\n", rec.Region, rec.Product, amount)
+	})
+	return p
+}
+
+// chunks cuts r into chunks of about size bytes, splitting only at line
+// ends, and passes them to emit until it returns false. With quoted set it
+// splits only at line ends outside double quotes, so that a quoted CSV
+// field with a line break stays in one chunk.
+func chunks(r io.Reader, size int, quoted bool, emit func(chunk) bool) error {
+	br := bufio.NewReaderSize(r, size)
+	for seq := 0; ; seq++ {
+		data := make([]byte, size)
+		n, err := io.ReadFull(br, data)
+		data = data[:n]
+		if err == nil {
+			rest, rerr := br.ReadBytes('\n') // finish the last line
+			data = append(data, rest...)
+			err = rerr
+			// An odd number of quotes leaves a field open: the line end
+			// is inside it, so read on to the end of the record.
+			open := quoted && bytes.Count(data, []byte{'"'})%2 == 1
+			for open && err == nil {
+				rest, err = br.ReadBytes('\n')
+				data = append(data, rest...)
+				open = bytes.Count(rest, []byte{'"'})%2 == 0
+			}
+		}
+		if len(data) > 0 && !emit(chunk{seq, data}) {
+			return nil
+		}
+		switch err {
+		case nil:
+		case io.EOF, io.ErrUnexpectedEOF:
+			return nil
+		default:
+			return err
+		}
+	}
+}
+
+// sequential is the baseline: read, process and write one chunk at a time.
+func sequential(r io.Reader, w io.Writer, f format, chunkSize int) (summary, error) {
+	s := summary{Totals: make(map[string]float64)}
+	var werr error
+	err := chunks(r, chunkSize, f.quoted, func(c chunk) bool {
+		p := process(c, f.parse)
+		s.merge(p.summary)
+		_, werr = w.Write(p.Out)
+		return werr == nil
+	})
+	return s, errors.Join(err, werr)
+}
+
+// parallel processes the chunks on a pool of workers. With ordered set the
+// output is the same as sequential's.
+func parallel(r io.Reader, w io.Writer, f format, chunkSize, workers int, ordered bool) (summary, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	p := pool.New(func(ctx context.Context, c chunk) (processed, error) {
+		return process(c, f.parse), nil
+	}, pool.WithWorkers(workers))
+
+	rerr := make(chan error, 1)
+	go func() {
+		defer p.Close()
+		rerr <- chunks(r, chunkSize, f.quoted, func(c chunk) bool {
+			return p.Submit(ctx, c) == nil
+		})
+	}()
+
+	s := summary{Totals: make(map[string]float64)}
+	var werr error
+	write := func(out processed) {
+		s.merge(out.summary)
+		if werr == nil {
+			if _, werr = w.Write(out.Out); werr != nil {
+				cancel() // stop reading; drain what is in flight
+			}
+		}
+	}
+	next := 0
+	held := make(map[int]processed)
+	for res := range p.Results() {
+		if !ordered {
+			write(res.Value)
+			continue
+		}
+		held[res.Value.Seq] = res.Value
+		for out, ok := held[next]; ok; out, ok = held[next] {
+			delete(held, next)
+			write(out)
+			next++
+		}
+	}
+	return s, errors.Join(<-rerr, werr)
+}
+
+var regions = []string{"north", "south", "east", "west"}
+
+// generate writes n sample records, with a malformed one every 997 lines.
+func generate(w io.Writer, n int, jsonl bool) error {
+	bw := bufio.NewWriter(w)
+	if !jsonl {
+		bw.WriteString("region,product,qty,price\n")
+	}
+	for i := range n {
+		rec := record{regions[i%len(regions)], fmt.Sprintf("p%03d", i%211), i%9 + 1, float64(i%50) + 0.99}
+		switch {
+		case i%997 == 996:
+			bw.WriteString("not a record\n")
+		case jsonl:
+			b, _ := json.Marshal(rec)
+			bw.Write(append(b, '\n'))
+		default:
+			fmt.Fprintf(bw, "%s,%s,%d,%.2f\n", rec.Region, rec.Product, rec.Qty, rec.Price)
+		}
+	}
+	return bw.Flush()
+}
+
+func main() {
+	in := flag.String("in", "", "input .csv or .jsonl file (default: generate one)")
+	out := flag.String("out", "", "output file (default: discard)")
+	n := flag.Int("n", 200000, "records to generate without -in")
+	jsonl := flag.Bool("jsonl", false, "generate JSON Lines rather than CSV")
+	workers := flag.Int("workers", 4, "parsing workers")
+	ordered := flag.Bool("ordered", true, "write output in input order")
+	chunkSize := flag.Int("chunk", 64<<10, "chunk size in bytes")
+	flag.Parse()
+
+	if *in == "" {
+		ext := ".csv"
+		if *jsonl {
+			ext = ".jsonl"
+		}
+		f, err := os.CreateTemp("", "records-*"+ext)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer os.Remove(f.Name())
+		if err := errors.Join(generate(f, *n, *jsonl), f.Close()); err != nil {
+			log.Fatal(err)
+		}
+		*in = f.Name()
+	}
+	form := csvFormat
+	if filepath.Ext(*in) == ".jsonl" {
+		form = jsonlFormat
+	}
+
+	var w io.Writer = io.Discard
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer f.Close()
+		bw := bufio.NewWriter(f)
+		defer bw.Flush()
+		w = bw
+	}
+
+	for _, mode := range []string{"sequential", "parallel"} {
+		f, err := os.Open(*in)
+		if err != nil {
+			log.Fatal(err)
+		}
+		start := time.Now()
+		var s summary
+		if mode == "sequential" {
+			s, err = sequential(f, io.Discard, form, *chunkSize)
+		} else {
+			s, err = parallel(f, w, form, *chunkSize, *workers, *ordered)
+		}
+		f.Close()
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("%-10s %d records, %d bad, in %v\n", mode, s.Records, s.Bad, time.Since(start).Round(time.Millisecond))
+		if mode == "parallel" {
+			for _, r := range slices.Sorted(maps.Keys(s.Totals)) {
+				fmt.Printf("  %-5s %14.2f\n", r, s.Totals[r])
+			}
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/lotusirous/gochan/internal/exampletest"
+)
+
+func TestMain(m *testing.M) { exampletest.Main(m, main) }
+
+func input(t testing.TB, n int, jsonl bool) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := generate(&buf, n, jsonl); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func sameSummary(t *testing.T, got, want summary) {
+	t.Helper()
+	if got.Records != want.Records || got.Bad != want.Bad || len(got.Totals) != len(want.Totals) {
+		t.Fatalf("summary = %d records, %d bad, %d regions; want %d, %d, %d",
+			got.Records, got.Bad, len(got.Totals), want.Records, want.Bad, len(want.Totals))
+	}
+	for k, v := range want.Totals {
+		if math.Abs(got.Totals[k]-v) > 1e-6*v { // summed in a different order
+			t.Errorf("total %s = %f, want %f", k, got.Totals[k], v)
+		}
+	}
+}
+
+func TestParallelMatchesSequential(t *testing.T) {
+	for _, jsonl := range []bool{false, true} {
+		t.Run(fmt.Sprint("jsonl=", jsonl), func(t *testing.T) {
+			data := input(t, 5000, jsonl)
+			form := csvFormat
+			if jsonl {
+				form = jsonlFormat
+			}
+			var want bytes.Buffer
+			ws, err := sequential(bytes.NewReader(data), &want, form, 1<<10)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ws.Records != 4995 || ws.Bad != 5 {
+				t.Fatalf("sequential: %d records, %d bad; want 4995, 5", ws.Records, ws.Bad)
+			}
+
+			var ordered bytes.Buffer
+			s, err := parallel(bytes.NewReader(data), &ordered, form, 1<<10, 4, true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			sameSummary(t, s, ws)
+			if !bytes.Equal(ordered.Bytes(), want.Bytes()) {
+				t.Error("ordered output differs from sequential")
+			}
+
+			var unordered bytes.Buffer
+			s, err = parallel(bytes.NewReader(data), &unordered, form, 1<<10, 4, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			sameSummary(t, s, ws)
+			got, exp := strings.Split(unordered.String(), "\n"), strings.Split(want.String(), "\n")
+			slices.Sort(got)
+			slices.Sort(exp)
+			if !slices.Equal(got, exp) {
+				t.Error("unordered output has different lines than sequential")
+			}
+		})
+	}
+}
+
+func TestChunksSplitAtLineEnds(t *testing.T) {
+	data := []byte("aaa\nbbbbbbb\nc\nlast line without newline")
+	var got [][]byte
+	if err := chunks(bytes.NewReader(data), 4, false, func(c chunk) bool {
+		if c.Seq != len(got) {
+			t.Errorf("chunk %d has Seq %d", len(got), c.Seq)
+		}
+		got = append(got, c.Data)
+		return true
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if joined := bytes.Join(got, nil); !bytes.Equal(joined, data) {
+		t.Errorf("chunks join to %q", joined)
+	}
+	for _, c := range got[:len(got)-1] {
+		if c[len(c)-1] != '\n' {
+			t.Errorf("chunk %q splits a line", c)
+		}
+	}
+}
+
+func TestChunksKeepQuotedLineBreaks(t *testing.T) {
+	data := []byte("region,product,qty,price\n" +
+		"north,\"two\nline \"\"name\"\"\",1,2.50\n" +
+		"south,\"a\n\nb\",2,1.00\n" +
+		"east,plain,3,1.00\n")
+	var got [][]byte
+	if err := chunks(bytes.NewReader(data), 4, true, func(c chunk) bool {
+		got = append(got, c.Data)
+		return true
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 4 {
+		t.Fatalf("%d chunks, want one per record: %q", len(got), got)
+	}
+	var want bytes.Buffer
+	s, err := sequential(bytes.NewReader(data), &want, csvFormat, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Records != 3 || s.Bad != 0 {
+		t.Errorf("%d records, %d bad; want 3, 0", s.Records, s.Bad)
+	}
+}
+
+func TestMainOutput(t *testing.T) {
+	out := exampletest.Run(t, "-n=3000", "-jsonl")
+	for _, want := range []string{"sequential 2997 records, 3 bad", "parallel   2997 records, 3 bad", "north"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output lacks %q:\n%s", want, out)
+		}
+	}
+}
+
+func benchmarkFile(b *testing.B, run func(io.Reader, io.Writer) (summary, error)) {
+	data := input(b, 100000, false)
+	b.SetBytes(int64(len(data)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := run(bytes.NewReader(data), io.Discard); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkSequential(b *testing.B) {
+	benchmarkFile(b, func(r io.Reader, w io.Writer) (summary, error) {
+		return sequential(r, w, csvFormat, 64<<10)
+	})
+}
+
+func BenchmarkParallelOrdered(b *testing.B) {
+	benchmarkFile(b, func(r io.Reader, w io.Writer) (summary, error) {
+		return parallel(r, w, csvFormat, 64<<10, 4, true)
+	})
+}
+
+func BenchmarkParallelUnordered(b *testing.B) {
+	benchmarkFile(b, func(r io.Reader, w io.Writer) (summary, error) {
+		return parallel(r, w, csvFormat, 64<<10, 4, false)
+	})
+}
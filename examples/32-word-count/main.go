@@ -0,0 +1,115 @@
+// Word count, the "hello world" of MapReduce, on the mapreduce package.
+//
+// Lines are the input records. The map phase splits a line into words and
+// emits (word, 1) for each; the shuffle routes every word to the reducer
+// that owns it; the reduce phase adds up the ones. No worker shares a map
+// with another, so there is no lock anywhere.
+//
+// Pass file names to count their words, or run without arguments to count
+// a short built-in text.
+package main
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/lotusirous/gochan/mapreduce"
+)
+
+const sample = `It was the best of times, it was the worst of times,
+it was the age of wisdom, it was the age of foolishness,
+it was the epoch of belief, it was the epoch of incredulity,
+it was the season of Light, it was the season of Darkness,
+it was the spring of hope, it was the winter of despair.`
+
+var wordCount = mapreduce.Job[string, string, int, int]{
+	Map: func(line string, emit func(string, int)) {
+		for _, w := range strings.FieldsFunc(line, func(r rune) bool {
+			return !unicode.IsLetter(r) && r != '\''
+		}) {
+			emit(strings.ToLower(w), 1)
+		}
+	},
+	Reduce: func(_ string, ones []int) int { return len(ones) },
+}
+
+// lines sends every line of r until r is exhausted or ctx is done.
+func lines(ctx context.Context, r io.Reader) (<-chan string, <-chan error) {
+	out := make(chan string)
+	errc := make(chan error, 1)
+	go func() {
+		defer close(out)
+		s := bufio.NewScanner(r)
+		for s.Scan() {
+			select {
+			case out <- s.Text():
+			case <-ctx.Done():
+				errc <- ctx.Err()
+				return
+			}
+		}
+		errc <- s.Err()
+	}()
+	return out, errc
+}
+
+type count struct {
+	Word string
+	N    int
+}
+
+// top returns the n most frequent words, ties broken alphabetically.
+func top(counts map[string]int, n int) []count {
+	s := make([]count, 0, len(counts))
+	for w, c := range counts {
+		s = append(s, count{w, c})
+	}
+	slices.SortFunc(s, func(a, b count) int {
+		return cmp.Or(cmp.Compare(b.N, a.N), cmp.Compare(a.Word, b.Word))
+	})
+	return s[:min(n, len(s))]
+}
+
+func main() {
+	n := flag.Int("top", 10, "number of words to print")
+	flag.IntVar(&wordCount.Mappers, "mappers", 4, "map workers")
+	flag.IntVar(&wordCount.Reducers, "reducers", 2, "reduce workers")
+	flag.Parse()
+
+	var r io.Reader = strings.NewReader(sample)
+	if flag.NArg() > 0 {
+		var files []io.Reader
+		for _, name := range flag.Args() {
+			f, err := os.Open(name)
+			if err != nil {
+				log.Fatal(err)
+			}
+			defer f.Close()
+			files = append(files, f)
+		}
+		r = io.MultiReader(files...)
+	}
+
+	ctx := context.Background()
+	in, errc := lines(ctx, r)
+	counts, err := mapreduce.Collect(ctx, in, wordCount)
+	if err == nil {
+		err = <-errc
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("%d distinct words\n", len(counts))
+	for _, c := range top(counts, *n) {
+		fmt.Printf("%6d %s\n", c.N, c.Word)
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/lotusirous/gochan/internal/exampletest"
+	"github.com/lotusirous/gochan/mapreduce"
+)
+
+func TestMain(m *testing.M) { exampletest.Main(m, main) }
+
+func TestWordCount(t *testing.T) {
+	in, errc := lines(context.Background(), strings.NewReader("a b a\nB, c's a\n"))
+	counts, err := mapreduce.Collect(context.Background(), in, wordCount)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := <-errc; err != nil {
+		t.Fatal(err)
+	}
+	want := []count{{"a", 3}, {"b", 2}, {"c's", 1}}
+	if got := top(counts, 5); !slices.Equal(got, want) {
+		t.Errorf("top = %v, want %v", got, want)
+	}
+}
+
+func TestMainOutput(t *testing.T) {
+	out := exampletest.Run(t, "-top=3")
+	for _, want := range []string{"20 distinct words", "    10 it", "    10 of", "    10 the"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output lacks %q:\n%s", want, out)
+		}
+	}
+
+	file := filepath.Join(t.TempDir(), "words.txt")
+	if err := os.WriteFile(file, []byte("go go gophers\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	out = exampletest.Run(t, file, file)
+	if !strings.Contains(out, "     4 go\n") {
+		t.Errorf("counting files:\n%s", out)
+	}
+}
@@ -0,0 +1,166 @@
+// Parallel merge sort and quicksort with a goroutine budget.
+//
+// Divide and conquer algorithms split naturally into independent halves, and
+// the obvious parallel version spawns a goroutine for each one. Done without
+// a limit that starts one goroutine per element: millions of goroutines
+// fighting over a handful of cores, each doing too little to pay for its
+// own creation.
+//
+// Here a semaphore holds a budget of extra goroutines, about one per core.
+// A split that can take a token sorts one half on a new goroutine; one that
+// cannot sorts both halves itself, so the program never oversubscribes the
+// CPU. Below a cutoff the halves are sorted sequentially whatever the
+// budget, since small slices finish faster than a goroutine starts.
+//
+// The benchmarks compare both against sort.Slice over growing inputs. For
+// small slices the coordination costs more than it saves; the crossover
+// point depends on the number of cores.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"runtime"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+// cutoff is the length below which a slice is sorted sequentially.
+const cutoff = 2048
+
+// budget is a counting semaphore of spare goroutines.
+type budget chan struct{}
+
+func newBudget(n int) budget { return make(budget, n) }
+
+// tryGo runs fn on a new goroutine if a token is free and returns true, or
+// returns false without running fn.
+func (b budget) tryGo(wg *sync.WaitGroup, fn func()) bool {
+	select {
+	case b <- struct{}{}:
+	default:
+		return false
+	}
+	wg.Add(1)
+	go func() {
+		defer func() { <-b; wg.Done() }()
+		fn()
+	}()
+	return true
+}
+
+// mergeSort sorts s using buf, of the same length, as scratch space.
+func mergeSort(s, buf []int, b budget) {
+	if len(s) <= cutoff {
+		slices.Sort(s)
+		return
+	}
+	mid := len(s) / 2
+	var wg sync.WaitGroup
+	left := func() { mergeSort(s[:mid], buf[:mid], b) }
+	if !b.tryGo(&wg, left) {
+		left()
+	}
+	mergeSort(s[mid:], buf[mid:], b)
+	wg.Wait()
+
+	copy(buf, s)
+	i, j, k := 0, mid, 0
+	for i < mid && j < len(s) {
+		if buf[j] < buf[i] {
+			s[k] = buf[j]
+			j++
+		} else {
+			s[k] = buf[i]
+			i++
+		}
+		k++
+	}
+	k += copy(s[k:], buf[i:mid])
+	copy(s[k:], buf[j:])
+}
+
+// quickSort sorts s in place.
+func quickSort(s []int, b budget) {
+	var wg sync.WaitGroup
+	for len(s) > cutoff {
+		lt, gt := partition(s)
+		left, right := s[:lt], s[gt:]
+		// Hand the smaller side to another goroutine if there is budget
+		// and keep the larger one, or recurse into the smaller side and
+		// loop on the larger, which bounds the stack depth.
+		if len(left) > len(right) {
+			left, right = right, left
+		}
+		if !b.tryGo(&wg, func() { quickSort(left, b) }) {
+			quickSort(left, b)
+		}
+		s = right
+	}
+	slices.Sort(s)
+	wg.Wait()
+}
+
+// partition rearranges s around a median of three pivot into the elements
+// less than it, s[:lt], those equal to it, s[lt:gt], and those greater,
+// s[gt:]. Grouping the equal elements keeps inputs with many duplicates
+// from degrading to quadratic time.
+func partition(s []int) (lt, gt int) {
+	a, b, c := s[0], s[len(s)/2], s[len(s)-1]
+	pivot := max(min(a, b), min(max(a, b), c))
+	lt, i, gt := 0, 0, len(s)
+	for i < gt {
+		switch {
+		case s[i] < pivot:
+			s[lt], s[i] = s[i], s[lt]
+			lt++
+			i++
+		case s[i] > pivot:
+			gt--
+			s[i], s[gt] = s[gt], s[i]
+		default:
+			i++
+		}
+	}
+	return lt, gt
+}
+
+func randomInts(n int, seed int64) []int {
+	rng := rand.New(rand.NewSource(seed))
+	s := make([]int, n)
+	for i := range s {
+		s[i] = rng.Int()
+	}
+	return s
+}
+
+func main() {
+	n := flag.Int("n", 1000000, "number of integers to sort")
+	workers := flag.Int("workers", runtime.GOMAXPROCS(0), "goroutines sorting at the same time")
+	flag.Parse()
+	if *workers < 1 || *n < 0 {
+		fmt.Fprintln(os.Stderr, "-workers must be at least 1 and -n not negative")
+		os.Exit(2)
+	}
+
+	input := randomInts(*n, 1)
+	sorts := []struct {
+		name string
+		fn   func([]int)
+	}{
+		{"sort.Slice", func(s []int) { sort.Slice(s, func(i, j int) bool { return s[i] < s[j] }) }},
+		{"merge sort", func(s []int) { mergeSort(s, make([]int, len(s)), newBudget(*workers-1)) }},
+		{"quicksort", func(s []int) { quickSort(s, newBudget(*workers-1)) }},
+	}
+	fmt.Printf("sorting %d integers on up to %d goroutines\n", *n, *workers)
+	for _, st := range sorts {
+		s := slices.Clone(input)
+		start := time.Now()
+		st.fn(s)
+		fmt.Printf("%-10s %v sorted=%v\n", st.name, time.Since(start).Round(time.Microsecond), slices.IsSorted(s))
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/lotusirous/gochan/internal/exampletest"
+)
+
+func TestMain(m *testing.M) { exampletest.Main(m, main) }
+
+func TestSorts(t *testing.T) {
+	for _, n := range []int{0, 1, 2, 100, cutoff + 1, 100000} {
+		for _, workers := range []int{0, 1, 8} {
+			input := randomInts(n, int64(n))
+			want := slices.Sorted(slices.Values(input))
+
+			s := slices.Clone(input)
+			mergeSort(s, make([]int, n), newBudget(workers))
+			if !slices.Equal(s, want) {
+				t.Errorf("merge sort of %d with budget %d is wrong", n, workers)
+			}
+
+			s = slices.Clone(input)
+			quickSort(s, newBudget(workers))
+			if !slices.Equal(s, want) {
+				t.Errorf("quicksort of %d with budget %d is wrong", n, workers)
+			}
+		}
+	}
+}
+
+func TestQuickSortDuplicatesAndSortedInput(t *testing.T) {
+	inputs := map[string][]int{
+		"sorted":   make([]int, 50000),
+		"reversed": make([]int, 50000),
+		"few keys": randomInts(50000, 2),
+	}
+	for i := range 50000 {
+		inputs["sorted"][i] = i
+		inputs["reversed"][i] = 50000 - i
+		inputs["few keys"][i] %= 3
+	}
+	for name, s := range inputs {
+		quickSort(s, newBudget(4))
+		if !slices.IsSorted(s) {
+			t.Errorf("%s: not sorted", name)
+		}
+	}
+}
+
+func TestBudgetIsReturned(t *testing.T) {
+	b := newBudget(3)
+	quickSort(randomInts(200000, 3), b)
+	mergeSort(randomInts(200000, 4), make([]int, 200000), b)
+	if len(b) != 0 {
+		t.Errorf("%d tokens still held after sorting", len(b))
+	}
+}
+
+func TestMainOutput(t *testing.T) {
+	out := exampletest.Run(t, "-n=50000", "-workers=4")
+	if strings.Count(out, "sorted=true") != 3 {
+		t.Errorf("output:\n%s", out)
+	}
+}
+
+var sizes = []int{1000, 10000, 100000, 1000000}
+
+func benchmarkSort(b *testing.B, fn func([]int)) {
+	for _, n := range sizes {
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			input := randomInts(n, 1)
+			s := make([]int, n)
+			for i := 0; i < b.N; i++ {
+				copy(s, input)
+				fn(s)
+			}
+		})
+	}
+}
+
+func BenchmarkSortSlice(b *testing.B) {
+	benchmarkSort(b, func(s []int) { sort.Slice(s, func(i, j int) bool { return s[i] < s[j] }) })
+}
+
+func BenchmarkMergeSort(b *testing.B) {
+	budget := newBudget(runtime.GOMAXPROCS(0) - 1)
+	benchmarkSort(b, func(s []int) { mergeSort(s, make([]int, len(s)), budget) })
+}
+
+func BenchmarkQuickSort(b *testing.B) {
+	budget := newBudget(runtime.GOMAXPROCS(0) - 1)
+	benchmarkSort(b, func(s []int) { quickSort(s, budget) })
+}
@@ -0,0 +1,197 @@
+// Compressing a large input with gzip on every core.
+//
+// A gzip stream is inherently sequential, but a gzip file may hold several
+// members one after the other, and every decompressor joins them back into
+// one output. So the input is cut into blocks, each block is compressed on
+// its own into a member, and the members are written out in input order.
+//
+// The order is kept with a queue of futures: the reader starts compressing
+// a block and immediately puts its future in the queue; the writer takes
+// futures from the queue in order and waits for each one. A semaphore caps
+// the compressions running at once and the queue's capacity caps the
+// blocks in memory, so a fast reader cannot outrun a slow writer.
+//
+// Blocks compress a little worse than one stream, since each starts with
+// an empty dictionary; bigger blocks close the gap.
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lotusirous/gochan/future"
+)
+
+// writers recycles gzip writers, which are expensive to allocate.
+var writers = sync.Pool{New: func() any {
+	w, _ := gzip.NewWriterLevel(nil, gzip.DefaultCompression)
+	return w
+}}
+
+// compressBlock compresses block into one complete gzip member.
+func compressBlock(block []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := writers.Get().(*gzip.Writer)
+	defer writers.Put(zw)
+	zw.Reset(&buf)
+	if _, err := zw.Write(block); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// serial compresses r into w as a single gzip stream.
+func serial(r io.Reader, w io.Writer) (int64, error) {
+	cw := &countingWriter{w: w}
+	zw := gzip.NewWriter(cw)
+	if _, err := io.Copy(zw, r); err != nil {
+		return cw.n, err
+	}
+	err := zw.Close()
+	return cw.n, err
+}
+
+// parallel compresses r into w as a sequence of gzip members of up to
+// blockSize input bytes each, compressing up to workers blocks at a time.
+func parallel(r io.Reader, w io.Writer, blockSize, workers int) (int64, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sem := make(chan struct{}, workers)
+	queue := make(chan *future.Future[[]byte], workers)
+	readErr := make(chan error, 1)
+	go func() {
+		defer close(queue)
+		for {
+			block := make([]byte, blockSize)
+			n, err := io.ReadFull(r, block)
+			if n > 0 {
+				select {
+				case sem <- struct{}{}:
+				case <-ctx.Done():
+					readErr <- ctx.Err()
+					return
+				}
+				f := future.Go(ctx, func(context.Context) ([]byte, error) {
+					defer func() { <-sem }()
+					return compressBlock(block[:n])
+				})
+				select {
+				case queue <- f:
+				case <-ctx.Done():
+					readErr <- ctx.Err()
+					return
+				}
+			}
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				readErr <- nil
+				return
+			}
+			if err != nil {
+				readErr <- err
+				return
+			}
+		}
+	}()
+
+	var written int64
+	for f := range queue {
+		member, err := f.Get(ctx)
+		if err == nil {
+			var n int
+			n, err = w.Write(member)
+			written += int64(n)
+		}
+		if err != nil {
+			cancel() // stop the reader, then let it drain the queue
+			for range queue {
+			}
+			return written, err
+		}
+	}
+	return written, <-readErr
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// sampleText returns about size bytes of compressible, not too repetitive
+// text.
+func sampleText(size int) []byte {
+	words := strings.Fields("the quick brown fox jumps over the lazy dog while " +
+		"gophers send values over channels and select on many of them at once")
+	var b bytes.Buffer
+	for i := 0; b.Len() < size; i++ {
+		fmt.Fprintf(&b, "%d %s %s %s\n", i, words[i%len(words)], words[(i*7)%len(words)], words[(i*13)%len(words)])
+	}
+	return b.Bytes()[:size]
+}
+
+func main() {
+	in := flag.String("in", "", "file to compress (default: generated text)")
+	out := flag.String("out", "", "write the parallel output to this file")
+	size := flag.Int("size", 64<<20, "bytes of text to generate without -in")
+	block := flag.Int("block", 1<<20, "block size in bytes")
+	workers := flag.Int("workers", runtime.GOMAXPROCS(0), "blocks compressed at once")
+	flag.Parse()
+	if *workers < 1 || *block < 1 {
+		fmt.Fprintln(os.Stderr, "-workers and -block must be at least 1")
+		os.Exit(2)
+	}
+
+	var data []byte
+	if *in != "" {
+		var err error
+		if data, err = os.ReadFile(*in); err != nil {
+			log.Fatal(err)
+		}
+	} else {
+		data = sampleText(*size)
+	}
+	var w io.Writer = io.Discard
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	fmt.Printf("%d bytes in\n", len(data))
+	start := time.Now()
+	n, err := serial(bytes.NewReader(data), io.Discard)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("serial   %10d bytes out in %v\n", n, time.Since(start).Round(time.Millisecond))
+
+	start = time.Now()
+	n, err = parallel(bytes.NewReader(data), w, *block, *workers)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("parallel %10d bytes out in %v (%d workers, %d KiB blocks)\n",
+		n, time.Since(start).Round(time.Millisecond), *workers, *block>>10)
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/lotusirous/gochan/internal/exampletest"
+)
+
+func TestMain(m *testing.M) { exampletest.Main(m, main) }
+
+func gunzip(t *testing.T, data []byte) []byte {
+	t.Helper()
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func TestParallelRoundTrip(t *testing.T) {
+	for _, size := range []int{0, 1, 4095, 4096, 100000} {
+		data := sampleText(size)
+		var buf bytes.Buffer
+		n, err := parallel(bytes.NewReader(data), &buf, 4096, 3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != int64(buf.Len()) {
+			t.Errorf("reported %d bytes, wrote %d", n, buf.Len())
+		}
+		if size == 0 {
+			continue
+		}
+		if got := gunzip(t, buf.Bytes()); !bytes.Equal(got, data) {
+			t.Errorf("size %d: round trip gave %d bytes", size, len(got))
+		}
+	}
+}
+
+type failingWriter struct{ n int }
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if w.n == 0 {
+		return 0, errors.New("disk full")
+	}
+	w.n--
+	return len(p), nil
+}
+
+func TestParallelStopsOnWriteError(t *testing.T) {
+	_, err := parallel(bytes.NewReader(sampleText(1<<20)), &failingWriter{n: 3}, 4096, 4)
+	if err == nil || err.Error() != "disk full" {
+		t.Errorf("err = %v, want disk full", err)
+	}
+}
+
+func TestMainOutput(t *testing.T) {
+	out := exampletest.Run(t, "-size=1000000", "-block=65536", "-workers=2")
+	for _, want := range []string{"1000000 bytes in", "serial", "parallel", "2 workers, 64 KiB blocks"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output lacks %q:\n%s", want, out)
+		}
+	}
+}
+
+func benchmarkGzip(b *testing.B, compress func(io.Reader, io.Writer) (int64, error)) {
+	data := sampleText(16 << 20)
+	b.SetBytes(int64(len(data)))
+	for i := 0; i < b.N; i++ {
+		if _, err := compress(bytes.NewReader(data), io.Discard); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkSerial(b *testing.B) { benchmarkGzip(b, serial) }
+
+func BenchmarkParallel(b *testing.B) {
+	benchmarkGzip(b, func(r io.Reader, w io.Writer) (int64, error) {
+		return parallel(r, w, 1<<20, 4)
+	})
+}
@@ -0,0 +1,137 @@
+// Computing several checksums of a file in one pass.
+//
+// Reading a file three times to get its SHA-256, its MD5 and its size costs
+// three times the IO. Instead the file is read once, in blocks, and
+// chans.Tee hands every block to three consumers running side by side. Each
+// one feeds its own hash and reports when the stream closes, and the caller
+// gathers the three results.
+//
+// Tee moves in lockstep: a block is only read once every consumer has
+// taken the previous one, so memory stays at a block or two however big
+// the file is, and the pass takes as long as the slowest hash rather than
+// the sum of all three. The blocks are shared, so each reader must allocate
+// a fresh buffer per block and consumers must not modify it.
+package main
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"hash"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/lotusirous/gochan/chans"
+)
+
+// blocks reads r in blocks of size bytes until EOF or ctx is done. The error
+// channel receives the read error, or nil, once the blocks are closed.
+func blocks(ctx context.Context, r io.Reader, size int) (<-chan []byte, <-chan error) {
+	out := make(chan []byte)
+	errc := make(chan error, 1)
+	go func() {
+		defer close(out)
+		for {
+			buf := make([]byte, size)
+			n, err := io.ReadFull(r, buf)
+			if n > 0 {
+				select {
+				case out <- buf[:n]:
+				case <-ctx.Done():
+					errc <- ctx.Err()
+					return
+				}
+			}
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				errc <- nil
+				return
+			}
+			if err != nil {
+				errc <- err
+				return
+			}
+		}
+	}()
+	return out, errc
+}
+
+// sum is one result of the pass.
+type sum struct {
+	Name  string
+	Value string
+}
+
+// digester consumes a stream of blocks and reports a sum at the end.
+type digester struct {
+	name string
+	run  func(in <-chan []byte) string
+}
+
+func hashing(name string, h hash.Hash) digester {
+	return digester{name, func(in <-chan []byte) string {
+		for b := range in {
+			h.Write(b)
+		}
+		return hex.EncodeToString(h.Sum(nil))
+	}}
+}
+
+var size = digester{"size", func(in <-chan []byte) string {
+	n := 0
+	for b := range in {
+		n += len(b)
+	}
+	return fmt.Sprint(n)
+}}
+
+// checksums reads r once and runs every digester over it concurrently.
+func checksums(ctx context.Context, r io.Reader, blockSize int, ds ...digester) ([]sum, error) {
+	in, errc := blocks(ctx, r, blockSize)
+	outs := chans.Tee(ctx, in, len(ds))
+	sums := make([]sum, len(ds))
+	done := make(chan struct{})
+	for i, d := range ds {
+		go func() {
+			sums[i] = sum{d.name, d.run(outs[i])}
+			done <- struct{}{}
+		}()
+	}
+	for range ds {
+		<-done
+	}
+	if err := <-errc; err != nil {
+		return nil, err
+	}
+	return sums, nil
+}
+
+func main() {
+	blockSize := flag.Int("block", 256<<10, "read size in bytes")
+	flag.Parse()
+
+	var r io.Reader = strings.NewReader(strings.Repeat("all work and no play makes jack a dull boy\n", 100000))
+	name := "sample text"
+	if flag.NArg() > 0 {
+		f, err := os.Open(flag.Arg(0))
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer f.Close()
+		r, name = f, flag.Arg(0)
+	}
+
+	sums, err := checksums(context.Background(), r, *blockSize,
+		hashing("sha256", sha256.New()), hashing("md5", md5.New()), size)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(name)
+	for _, s := range sums {
+		fmt.Printf("  %-6s %s\n", s.Name, s.Value)
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/lotusirous/gochan/internal/exampletest"
+)
+
+func TestMain(m *testing.M) { exampletest.Main(m, main) }
+
+func TestChecksums(t *testing.T) {
+	data := bytes.Repeat([]byte("0123456789"), 12345)
+	sums, err := checksums(context.Background(), bytes.NewReader(data), 1000,
+		hashing("sha256", sha256.New()), hashing("md5", md5.New()), size)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []sum{
+		{"sha256", fmt.Sprintf("%x", sha256.Sum256(data))},
+		{"md5", fmt.Sprintf("%x", md5.Sum(data))},
+		{"size", "123450"},
+	}
+	for i := range want {
+		if sums[i] != want[i] {
+			t.Errorf("sum %d = %v, want %v", i, sums[i], want[i])
+		}
+	}
+}
+
+func TestChecksumsReadError(t *testing.T) {
+	boom := errors.New("boom")
+	r := io.MultiReader(strings.NewReader("some data"), iotest.ErrReader(boom))
+	if _, err := checksums(context.Background(), r, 4, size, hashing("md5", md5.New())); !errors.Is(err, boom) {
+		t.Errorf("err = %v, want boom", err)
+	}
+}
+
+func TestMainOutput(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "hello.txt")
+	if err := os.WriteFile(file, []byte("hello\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	out := exampletest.Run(t, file)
+	for _, want := range []string{
+		"sha256 5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03",
+		"md5    b1946ac92492d2347c6235b4d2611184",
+		"size   6",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output lacks %q:\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+// Bank transfers two ways: with a mutex per account, locked in a fixed
+// order, and with software transactional memory.
+//
+// A transfer must take money from one account and add it to another as one
+// step, or a concurrent audit sees money that is in neither account. With
+// mutexes that means holding both locks, and to avoid the deadlock of two
+// opposite transfers each holding one, every transfer must take the locks
+// in the same order: lowest account number first.
+//
+// With the stm package a transfer is written as if it ran alone. Nothing
+// needs ordering; a transaction that overlaps with another one runs again.
+// The price is the work thrown away, which the program counts: try fewer
+// -accounts to see it grow as transfers collide more often.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/lotusirous/gochan/internal/stm"
+)
+
+var errInsufficient = errors.New("insufficient funds")
+
+// bank is what both implementations offer.
+type bank interface {
+	transfer(ctx context.Context, from, to, amount int) error
+	total(ctx context.Context) int
+}
+
+// lockedBank guards each account with its own mutex.
+type lockedBank struct {
+	mu       []sync.Mutex
+	balances []int
+}
+
+func newLockedBank(accounts, balance int) *lockedBank {
+	b := &lockedBank{mu: make([]sync.Mutex, accounts), balances: make([]int, accounts)}
+	for i := range b.balances {
+		b.balances[i] = balance
+	}
+	return b
+}
+
+func (b *lockedBank) transfer(ctx context.Context, from, to, amount int) error {
+	// lock the lower account first, whichever way the money goes
+	first, second := min(from, to), max(from, to)
+	b.mu[first].Lock()
+	defer b.mu[first].Unlock()
+	b.mu[second].Lock()
+	defer b.mu[second].Unlock()
+
+	if b.balances[from] < amount {
+		return errInsufficient
+	}
+	b.balances[from] -= amount
+	b.balances[to] += amount
+	return nil
+}
+
+// total locks every account, in order, for a consistent audit.
+func (b *lockedBank) total(ctx context.Context) int {
+	for i := range b.mu {
+		b.mu[i].Lock()
+		defer b.mu[i].Unlock()
+	}
+	sum := 0
+	for _, v := range b.balances {
+		sum += v
+	}
+	return sum
+}
+
+// stmBank keeps each balance in a transactional variable.
+type stmBank struct {
+	balances []*stm.Var[int]
+	attempts atomic.Int64 // runs of transfer transactions, including reruns
+}
+
+func newSTMBank(accounts, balance int) *stmBank {
+	b := &stmBank{}
+	for range accounts {
+		b.balances = append(b.balances, stm.NewVar(balance))
+	}
+	return b
+}
+
+func (b *stmBank) transfer(ctx context.Context, from, to, amount int) error {
+	return stm.Atomically(ctx, func(tx *stm.Tx) error {
+		b.attempts.Add(1)
+		f := b.balances[from].Get(tx)
+		if f < amount {
+			return errInsufficient
+		}
+		b.balances[from].Set(tx, f-amount)
+		b.balances[to].Set(tx, b.balances[to].Get(tx)+amount)
+		return nil
+	})
+}
+
+func (b *stmBank) total(ctx context.Context) int {
+	sum := 0
+	stm.Atomically(ctx, func(tx *stm.Tx) error {
+		sum = 0 // the audit may run more than once
+		for _, v := range b.balances {
+			sum += v.Get(tx)
+		}
+		return nil
+	})
+	return sum
+}
+
+// result is what one run of the simulation observed.
+type result struct {
+	done, refused int64
+	badAudits     int64 // audits that did not see the money they should
+	elapsed       time.Duration
+}
+
+// simulate runs transfers between random accounts from several goroutines,
+// and an auditor that checks the total while they do.
+func simulate(ctx context.Context, b bank, accounts, workers, transfers, want int) result {
+	var r result
+	start := time.Now()
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range transfers {
+				from, to := rand.IntN(accounts), rand.IntN(accounts-1)
+				if to >= from {
+					to++ // never to the same account
+				}
+				if err := b.transfer(ctx, from, to, 1+rand.IntN(50)); err != nil {
+					atomic.AddInt64(&r.refused, 1)
+				} else {
+					atomic.AddInt64(&r.done, 1)
+				}
+			}
+		}()
+	}
+	quit, audited := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(audited)
+		for {
+			select {
+			case <-quit:
+				return
+			default:
+			}
+			if b.total(ctx) != want {
+				r.badAudits++
+			}
+		}
+	}()
+	wg.Wait()
+	r.elapsed = time.Since(start)
+	close(quit)
+	<-audited
+	if b.total(ctx) != want {
+		r.badAudits++
+	}
+	return r
+}
+
+func main() {
+	mode := flag.String("mode", "all", "mutex, stm or all")
+	accounts := flag.Int("accounts", 10, "number of accounts")
+	workers := flag.Int("workers", 8, "goroutines making transfers")
+	transfers := flag.Int("transfers", 20_000, "transfers per goroutine")
+	flag.Parse()
+	if *accounts < 2 {
+		fmt.Fprintln(os.Stderr, "need at least 2 accounts")
+		os.Exit(2)
+	}
+
+	const balance = 1000
+	want := *accounts * balance
+	run := func(name string, b bank) {
+		r := simulate(context.Background(), b, *accounts, *workers, *transfers, want)
+		fmt.Printf("%-6s %d transfers, %d refused, %d bad audits in %v\n",
+			name, r.done, r.refused, r.badAudits, r.elapsed.Round(time.Millisecond))
+	}
+	if *mode == "mutex" || *mode == "all" {
+		run("mutex", newLockedBank(*accounts, balance))
+	}
+	if *mode == "stm" || *mode == "all" {
+		b := newSTMBank(*accounts, balance)
+		run("stm", b)
+		n := int64(*workers * *transfers)
+		fmt.Printf("stm    %d transactions ran %d times: %d reruns after conflicts\n",
+			n, b.attempts.Load(), b.attempts.Load()-n)
+	}
+}
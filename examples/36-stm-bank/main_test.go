@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/lotusirous/gochan/internal/exampletest"
+)
+
+func TestMain(m *testing.M) { exampletest.Main(m, main) }
+
+func TestBanksKeepTheMoney(t *testing.T) {
+	for name, b := range map[string]bank{
+		"mutex": newLockedBank(4, 100),
+		"stm":   newSTMBank(4, 100),
+	} {
+		r := simulate(context.Background(), b, 4, 4, 2000, 400)
+		if r.badAudits != 0 || r.done+r.refused != 8000 {
+			t.Errorf("%s: %+v", name, r)
+		}
+	}
+}
+
+func TestTransferRefusesOverdraft(t *testing.T) {
+	for name, b := range map[string]bank{
+		"mutex": newLockedBank(2, 10),
+		"stm":   newSTMBank(2, 10),
+	} {
+		ctx := context.Background()
+		if err := b.transfer(ctx, 0, 1, 11); err != errInsufficient {
+			t.Errorf("%s: transfer of 11 from 10 = %v", name, err)
+		}
+		if err := b.transfer(ctx, 1, 0, 10); err != nil || b.total(ctx) != 20 {
+			t.Errorf("%s: transfer = %v, total %d", name, err, b.total(ctx))
+		}
+	}
+}
+
+func TestMainOutput(t *testing.T) {
+	out := exampletest.Run(t, "-transfers", "500")
+	if strings.Count(out, " 0 bad audits") != 2 || !strings.Contains(out, "stm    4000 transactions") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+}
@@ -0,0 +1,106 @@
+// Double-buffer handoff with an exchange point.
+//
+// A producer fills a buffer while a consumer works through the other one.
+// When both are done they meet at an exchange.Point and swap: the producer
+// leaves with the empty buffer and the consumer with the full one. Two
+// buffers serve the whole stream, with no allocation per batch, no queue
+// and no lock around the buffers, because at any moment each buffer has
+// exactly one owner.
+//
+// The consumer waits for each meeting with a timeout. Run with -stall to
+// make the producer stop for a while in the middle and watch the consumer
+// time out, report it, and wait again; an exchange that timed out hands
+// nothing over, so no batch is lost.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/lotusirous/gochan/exchange"
+)
+
+// buffer is a batch of readings and whether it is the last one.
+type buffer struct {
+	data []int
+	last bool
+}
+
+// produce fills buffers with the numbers 1 to n, batch by batch, and swaps
+// each full buffer for an empty one. It stalls once, halfway, if stall is
+// positive. It gives up once ctx is done.
+func produce(ctx context.Context, p *exchange.Point[*buffer, *buffer], n int, buf *buffer, stall time.Duration) {
+	for i := 1; i <= n; i++ {
+		buf.data = append(buf.data, i)
+		if i == n/2 && stall > 0 {
+			time.Sleep(stall)
+		}
+		if len(buf.data) == cap(buf.data) || i == n {
+			buf.last = i == n
+			var err error
+			if buf, err = p.ExchangeA(ctx, buf); err != nil {
+				return
+			}
+			buf.data = buf.data[:0]
+		}
+	}
+}
+
+// stats is what the consumer saw.
+type stats struct {
+	sum, batches, timeouts int
+	buffers                map[*int]bool // distinct backing arrays
+}
+
+// consume swaps its empty buffer for a full one until it gets the last
+// batch or ctx is done, waiting at most timeout for each swap.
+func consume(ctx context.Context, p *exchange.Point[*buffer, *buffer], buf *buffer, timeout time.Duration) stats {
+	s := stats{buffers: make(map[*int]bool)}
+	for {
+		swapCtx, cancel := context.WithTimeout(ctx, timeout)
+		full, err := p.ExchangeB(swapCtx, buf)
+		cancel()
+		if ctx.Err() != nil {
+			return s
+		}
+		if errors.Is(err, context.DeadlineExceeded) {
+			s.timeouts++
+			fmt.Printf("consumer: no batch within %v, waiting again\n", timeout)
+			continue
+		}
+		s.batches++
+		s.buffers[&full.data[:1][0]] = true
+		for _, v := range full.data {
+			s.sum += v
+		}
+		if full.last {
+			return s
+		}
+		buf = full
+	}
+}
+
+func main() {
+	n := flag.Int("n", 1_000_000, "numbers to produce")
+	size := flag.Int("size", 4096, "buffer capacity")
+	timeout := flag.Duration("timeout", 100*time.Millisecond, "how long the consumer waits for each batch")
+	stall := flag.Duration("stall", 0, "pause the producer once halfway, e.g. 350ms")
+	flag.Parse()
+
+	p := exchange.New[*buffer, *buffer]()
+	front := &buffer{data: make([]int, 0, *size)}
+	back := &buffer{data: make([]int, 0, *size)}
+
+	start := time.Now()
+	ctx := context.Background()
+	go produce(ctx, p, *n, back, *stall)
+	s := consume(ctx, p, front, *timeout)
+	elapsed := time.Since(start)
+
+	fmt.Printf("sum of 1..%d = %d (want %d)\n", *n, s.sum, *n*(*n+1)/2)
+	fmt.Printf("%d batches through %d buffers, %d timeouts, in %v\n",
+		s.batches, len(s.buffers), s.timeouts, elapsed.Round(time.Millisecond))
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lotusirous/gochan/exchange"
+	"github.com/lotusirous/gochan/internal/exampletest"
+)
+
+func TestMain(m *testing.M) { exampletest.Main(m, main) }
+
+func TestHandoffReusesTwoBuffers(t *testing.T) {
+	for _, n := range []int{1, 7, 8, 1000} {
+		p := exchange.New[*buffer, *buffer]()
+		go produce(context.Background(), p, n, &buffer{data: make([]int, 0, 8)}, 0)
+		s := consume(context.Background(), p, &buffer{data: make([]int, 0, 8)}, time.Second)
+		if s.sum != n*(n+1)/2 || s.batches != (n+7)/8 || len(s.buffers) > 2 {
+			t.Errorf("n=%d: sum %d in %d batches through %d buffers", n, s.sum, s.batches, len(s.buffers))
+		}
+	}
+}
+
+func TestStallTimesOutWithoutLoss(t *testing.T) {
+	out := exampletest.Run(t, "-n", "1000", "-size", "64", "-timeout", "10ms", "-stall", "35ms")
+	if !strings.Contains(out, "sum of 1..1000 = 500500 ") || !strings.Contains(out, "waiting again") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+}
@@ -0,0 +1,175 @@
+// Double buffering for snapshot readers.
+//
+// A writer keeps a large piece of state up to date, here a grid of sensor
+// readings, and many readers need a consistent view of all of it. The
+// obvious way is a lock and a copy: the writer updates under the lock and
+// each reader copies the whole grid under the read lock. Every read then
+// costs a copy of the grid, and the writer waits for the copies.
+//
+// With two buffers the readers copy nothing. The writer fills the back
+// buffer while readers use the front one, and publishing is a single
+// atomic pointer swap. The only wait left is the writer's: before it
+// overwrites the old front buffer it must know the last reader has let go
+// of it, which each buffer tracks with a count of its readers.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// store is what both implementations offer: the writer publishes a new
+// generation of the grid, and readers look at the current one.
+type store interface {
+	publish(fill func(grid []float64))
+	read(use func(grid []float64))
+}
+
+// lockedStore copies the grid under a read lock for every reader.
+type lockedStore struct {
+	mu   sync.RWMutex
+	grid []float64
+}
+
+func newLockedStore(size int) *lockedStore {
+	return &lockedStore{grid: make([]float64, size)}
+}
+
+func (s *lockedStore) publish(fill func([]float64)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fill(s.grid)
+}
+
+func (s *lockedStore) read(use func([]float64)) {
+	s.mu.RLock()
+	snapshot := make([]float64, len(s.grid))
+	copy(snapshot, s.grid)
+	s.mu.RUnlock()
+	use(snapshot)
+}
+
+// buffer is one of the two grids, with the number of readers using it.
+type buffer struct {
+	grid    []float64
+	readers atomic.Int64
+}
+
+// doubleBuffer publishes by swapping the front buffer pointer.
+type doubleBuffer struct {
+	front atomic.Pointer[buffer]
+	back  *buffer // only the writer touches it
+}
+
+func newDoubleBuffer(size int) *doubleBuffer {
+	d := &doubleBuffer{back: &buffer{grid: make([]float64, size)}}
+	d.front.Store(&buffer{grid: make([]float64, size)})
+	return d
+}
+
+// publish fills the back buffer, makes it the front one and keeps the old
+// front as the next back buffer once its readers are gone. There is one
+// writer.
+func (d *doubleBuffer) publish(fill func([]float64)) {
+	fill(d.back.grid)
+	old := d.front.Swap(d.back)
+	for old.readers.Load() > 0 {
+		runtime.Gosched() // readers hold a buffer only briefly
+	}
+	d.back = old
+}
+
+// read pins the front buffer while use runs. Between loading the pointer
+// and counting itself in, the reader may have been overtaken by a publish,
+// and the writer may already be refilling that buffer; then it lets go and
+// tries the new front.
+func (d *doubleBuffer) read(use func([]float64)) {
+	for {
+		b := d.front.Load()
+		b.readers.Add(1)
+		if d.front.Load() == b {
+			use(b.grid)
+			b.readers.Add(-1)
+			return
+		}
+		b.readers.Add(-1)
+	}
+}
+
+// fillGeneration sets every cell to gen, so a reader can tell a torn
+// snapshot, one with cells from two generations, at a glance.
+func fillGeneration(gen float64) func([]float64) {
+	return func(grid []float64) {
+		for i := range grid {
+			grid[i] = gen
+		}
+	}
+}
+
+// result is what one run observed.
+type result struct {
+	generations, reads, torn int64
+	elapsed                  time.Duration
+}
+
+// simulate publishes generations for d while readers check every snapshot
+// they see.
+func simulate(s store, readers int, d time.Duration) result {
+	var r result
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for range readers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				s.read(func(grid []float64) {
+					for _, v := range grid {
+						if v != grid[0] {
+							atomic.AddInt64(&r.torn, 1)
+							return
+						}
+					}
+				})
+				atomic.AddInt64(&r.reads, 1)
+			}
+		}()
+	}
+	start := time.Now()
+	for time.Since(start) < d {
+		r.generations++
+		s.publish(fillGeneration(float64(r.generations)))
+	}
+	close(stop)
+	wg.Wait()
+	r.elapsed = time.Since(start)
+	return r
+}
+
+func main() {
+	size := flag.Int("size", 1<<18, "cells in the grid")
+	readers := flag.Int("readers", 4, "reader goroutines")
+	d := flag.Duration("d", 300*time.Millisecond, "how long to run each store")
+	flag.Parse()
+
+	for _, s := range []struct {
+		name  string
+		store store
+	}{
+		{"copy under lock", newLockedStore(*size)},
+		{"double buffer", newDoubleBuffer(*size)},
+	} {
+		r := simulate(s.store, *readers, *d)
+		fmt.Printf("%-16s %6d generations, %7d reads, %d torn in %v\n",
+			s.name, r.generations, r.reads, r.torn, r.elapsed.Round(time.Millisecond))
+	}
+}
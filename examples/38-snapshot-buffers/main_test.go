@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lotusirous/gochan/internal/exampletest"
+)
+
+func TestMain(m *testing.M) { exampletest.Main(m, main) }
+
+func TestNoTornSnapshots(t *testing.T) {
+	for name, s := range map[string]store{
+		"locked": newLockedStore(1024),
+		"double": newDoubleBuffer(1024),
+	} {
+		if r := simulate(s, 4, 50*time.Millisecond); r.torn != 0 || r.generations == 0 || r.reads == 0 {
+			t.Errorf("%s: %+v", name, r)
+		}
+	}
+}
+
+func TestDoubleBufferReadersSeeLatest(t *testing.T) {
+	d := newDoubleBuffer(4)
+	for gen := 1.0; gen <= 3; gen++ {
+		d.publish(fillGeneration(gen))
+		d.read(func(grid []float64) {
+			if grid[0] != gen {
+				t.Errorf("read generation %v, want %v", grid[0], gen)
+			}
+		})
+	}
+}
+
+func TestMainOutput(t *testing.T) {
+	out := exampletest.Run(t, "-size", "4096", "-d", "20ms")
+	if strings.Count(out, " 0 torn") != 2 {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+}
+
+// BenchmarkSnapshotRead measures reads of a large grid while a writer keeps
+// publishing. Copying under the lock costs a copy of the grid per read;
+// the double buffer costs a few atomic operations, whatever the size.
+func BenchmarkSnapshotRead(b *testing.B) {
+	for _, size := range []int{1 << 10, 1 << 16, 1 << 20} {
+		for _, impl := range []struct {
+			name string
+			new  func(int) store
+		}{
+			{"lock-copy", func(n int) store { return newLockedStore(n) }},
+			{"double-buffer", func(n int) store { return newDoubleBuffer(n) }},
+		} {
+			b.Run(fmt.Sprintf("%s/size=%d", impl.name, size), func(b *testing.B) {
+				s := impl.new(size)
+				stop := make(chan struct{})
+				var wg sync.WaitGroup
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for gen := 1.0; ; gen++ {
+						select {
+						case <-stop:
+							return
+						default:
+						}
+						s.publish(fillGeneration(gen))
+					}
+				}()
+				b.ResetTimer()
+				b.RunParallel(func(pb *testing.PB) {
+					for pb.Next() {
+						s.read(func(grid []float64) { _ = grid[len(grid)-1] })
+					}
+				})
+				b.StopTimer()
+				close(stop)
+				wg.Wait()
+			})
+		}
+	}
+}
@@ -0,0 +1,161 @@
+// Read-copy-update for a routing table.
+//
+// Lookups vastly outnumber route changes, so readers should not pay for
+// the writers. Here they take no lock and write no shared memory besides
+// their pin: a lookup loads the current table with one atomic pointer load
+// and uses it. A writer never changes a published table. It copies it,
+// changes the copy and publishes the copy with one atomic store; readers
+// already holding the old table finish with it undisturbed.
+//
+// What RCU adds to "copy on write" is the grace period: a way for the
+// writer to know when no reader can still hold the old version, so it can
+// be reclaimed or, as here, reported. Readers mark their critical sections
+// with an epoch pin, and the writer either waits for the grace period with
+// Synchronize or hands the old version to Retire to be dealt with later.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math/rand/v2"
+	"net/netip"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/lotusirous/gochan/internal/lockfree"
+)
+
+// route sends the addresses in prefix to hop.
+type route struct {
+	prefix netip.Prefix
+	hop    string
+}
+
+// table is one published version of the routes. It is never modified
+// after publishing; reclaimed is set once no reader can hold it.
+type table struct {
+	version   int
+	routes    []route
+	reclaimed atomic.Bool
+}
+
+// lookup returns the hop of the longest prefix containing addr.
+func (t *table) lookup(addr netip.Addr) string {
+	best, hop := -1, "drop"
+	for _, r := range t.routes {
+		if r.prefix.Bits() > best && r.prefix.Contains(addr) {
+			best, hop = r.prefix.Bits(), r.hop
+		}
+	}
+	return hop
+}
+
+// router holds the current table.
+type router struct {
+	current atomic.Pointer[table]
+	rcu     lockfree.Domain
+
+	mu sync.Mutex // serializes writers; readers never take it
+}
+
+func newRouter(routes []route) *router {
+	r := &router{}
+	r.current.Store(&table{version: 1, routes: routes})
+	return r
+}
+
+// lookup is the read side: pin, load, use, unpin.
+func (r *router) lookup(addr netip.Addr) (hop string, usedReclaimed bool) {
+	g := r.rcu.Pin()
+	defer g.Unpin()
+	t := r.current.Load()
+	hop = t.lookup(addr)
+	return hop, t.reclaimed.Load() // must never be true while pinned
+}
+
+// update is the write side: copy, change, publish, then wait for the
+// grace period if wait is set or retire the old version otherwise.
+func (r *router) update(change func([]route) []route, wait bool) (old *table, grace time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	old = r.current.Load()
+	routes := change(append([]route(nil), old.routes...))
+	r.current.Store(&table{version: old.version + 1, routes: routes})
+
+	start := time.Now()
+	if wait {
+		r.rcu.Synchronize()
+		old.reclaimed.Store(true)
+		return old, time.Since(start)
+	}
+	r.rcu.Retire(func() { old.reclaimed.Store(true) })
+	return old, 0
+}
+
+func main() {
+	readers := flag.Int("readers", 4, "goroutines doing lookups")
+	updates := flag.Int("updates", 10, "route changes to publish")
+	every := flag.Duration("every", 20*time.Millisecond, "time between route changes")
+	async := flag.Bool("async", false, "retire old tables instead of waiting for each grace period")
+	flag.Parse()
+
+	r := newRouter([]route{
+		{netip.MustParsePrefix("0.0.0.0/0"), "upstream"},
+		{netip.MustParsePrefix("10.0.0.0/8"), "core"},
+		{netip.MustParsePrefix("10.1.0.0/16"), "rack-1"},
+	})
+
+	var lookups, violations atomic.Int64
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for range *readers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				addr := netip.AddrFrom4([4]byte{10, byte(rand.IntN(4)), byte(rand.IntN(256)), 1})
+				if _, bad := r.lookup(addr); bad {
+					violations.Add(1)
+				}
+				lookups.Add(1)
+			}
+		}()
+	}
+
+	moved := netip.MustParsePrefix("10.1.0.0/16")
+	for i := range *updates {
+		time.Sleep(*every)
+		hop := fmt.Sprintf("rack-%d", i+2)
+		old, grace := r.update(func(routes []route) []route {
+			for j := range routes {
+				if routes[j].prefix == moved {
+					routes[j].hop = hop
+				}
+			}
+			return routes
+		}, !*async)
+		if *async {
+			fmt.Printf("published v%d moving %s to %s; v%d retired\n", old.version+1, moved, hop, old.version)
+			continue
+		}
+		fmt.Printf("published v%d moving %s to %s; v%d unreferenced after %v\n",
+			old.version+1, moved, hop, old.version, grace.Round(time.Microsecond))
+	}
+	close(stop)
+	wg.Wait()
+	if *async {
+		r.rcu.Collect() // no reader is left to hold anything back
+		fmt.Printf("%d retired tables reclaimed\n", r.rcu.Freed())
+	}
+
+	addr := netip.MustParseAddr("10.1.2.3")
+	hop, _ := r.lookup(addr)
+	fmt.Printf("%d lookups, %d on reclaimed tables; %v goes to %s\n",
+		lookups.Load(), violations.Load(), addr, hop)
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"net/netip"
+	"strings"
+	"testing"
+
+	"github.com/lotusirous/gochan/internal/exampletest"
+)
+
+func TestMain(m *testing.M) { exampletest.Main(m, main) }
+
+func TestLongestPrefix(t *testing.T) {
+	r := newRouter([]route{
+		{netip.MustParsePrefix("0.0.0.0/0"), "upstream"},
+		{netip.MustParsePrefix("10.0.0.0/8"), "core"},
+		{netip.MustParsePrefix("10.1.0.0/16"), "rack-1"},
+	})
+	for addr, want := range map[string]string{"10.1.9.9": "rack-1", "10.2.0.1": "core", "8.8.8.8": "upstream"} {
+		if hop, _ := r.lookup(netip.MustParseAddr(addr)); hop != want {
+			t.Errorf("lookup(%s) = %s, want %s", addr, hop, want)
+		}
+	}
+}
+
+func TestUpdateLeavesOldTableIntact(t *testing.T) {
+	r := newRouter([]route{{netip.MustParsePrefix("10.0.0.0/8"), "a"}})
+	before := r.current.Load()
+	for _, wait := range []bool{true, false} {
+		r.update(func(routes []route) []route {
+			routes[0].hop = "b"
+			return routes
+		}, wait)
+	}
+	if before.routes[0].hop != "a" {
+		t.Error("update changed the published table in place")
+	}
+	if !before.reclaimed.Load() {
+		t.Error("the first table was not reclaimed after Synchronize")
+	}
+	if hop, _ := r.lookup(netip.MustParseAddr("10.0.0.1")); hop != "b" || r.current.Load().version != 3 {
+		t.Errorf("lookup = %s at v%d, want b at v3", hop, r.current.Load().version)
+	}
+}
+
+func TestMainOutput(t *testing.T) {
+	for _, args := range [][]string{{"-updates", "3", "-every", "1ms"}, {"-updates", "3", "-every", "1ms", "-async"}} {
+		out := exampletest.Run(t, args...)
+		if !strings.Contains(out, " 0 on reclaimed tables; 10.1.2.3 goes to rack-4") {
+			t.Errorf("%v: unexpected output:\n%s", args, out)
+		}
+	}
+}
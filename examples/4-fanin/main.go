@@ -1,9 +1,13 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"math/rand"
+	"runtime/trace"
 	"time"
+
+	"github.com/lotusirous/gochan/teach"
 )
 
 // the boring function return a channel to communicate with it.
@@ -11,8 +15,17 @@ func boring(msg string) <-chan string { // <-chan string means receives-only cha
 	c := make(chan string)
 	go func() { // we launch goroutine inside a function.
 		for i := 0; ; i++ {
-			c <- fmt.Sprintf("%s %d", msg, i)
-			time.Sleep(time.Duration(rand.Intn(1e3)) * time.Millisecond)
+			// one trace task per message, so go tool trace lists every
+			// message with its sender and how long the send blocked.
+			ctx, task := trace.NewTask(context.Background(), "message")
+			trace.Log(ctx, "from", msg)
+			m := fmt.Sprintf("%s %d", msg, i)
+			teach.Pass(msg, "fanIn", m)
+			trace.WithRegion(ctx, "send", func() {
+				teach.Send(ctx, msg, c, m) // c <- m, recorded for patterns run -record
+			})
+			task.End()
+			teach.Sleep(time.Duration(rand.Intn(1e3)) * time.Millisecond)
 		}
 
 	}()
@@ -44,7 +57,12 @@ func fanInSimple(cs ...<-chan string) <-chan string {
 
 		go func(cv <-chan string) { // cv is a channel value
 			for {
-				c <- <-cv
+				v := <-cv
+				teach.Pass("fanIn", "main", v)
+				ctx := context.Background()
+				trace.WithRegion(ctx, "forward", func() {
+					teach.Send(ctx, "fanIn", c, v)
+				})
 			}
 		}(ci) // send each channel to
 
@@ -53,12 +71,18 @@ func fanInSimple(cs ...<-chan string) <-chan string {
 }
 
 func main() {
+	// Stream events and write a trace for the patterns runner, if it
+	// started us.
+	defer teach.Start()()
+	teach.Layout("Joe", "fanIn", "main", "Ahn")
+
 	// merge 2 channels into 1 channel
 	// c := fanIn(boring("Joe"), boring("Ahn"))
 	c := fanInSimple(boring("Joe"), boring("Ahn"))
 
 	for i := 0; i < 5; i++ {
-		fmt.Println(<-c) // now we can read from 1 channel
+		v, _ := teach.Recv(context.Background(), "fanIn", c) // now we can read from 1 channel
+		fmt.Println(v)
 	}
 	fmt.Println("You're both boring. I'm leaving")
 }
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/lotusirous/gochan/internal/exampletest"
+)
+
+func TestMain(m *testing.M) { exampletest.Main(m, main) }
+
+func TestFanInMergesBothInputs(t *testing.T) {
+	for name, merge := range map[string]func(a, b <-chan string) <-chan string{
+		"fanIn":       fanIn,
+		"fanInSimple": func(a, b <-chan string) <-chan string { return fanInSimple(a, b) },
+	} {
+		t.Run(name, func(t *testing.T) {
+			c := merge(boring("Joe"), boring("Ann"))
+			seen := map[string]bool{}
+			for range 2 { // the first message of each is sent without delay
+				msg := <-c
+				seen[strings.Fields(msg)[0]] = true
+			}
+			if !seen["Joe"] || !seen["Ann"] {
+				t.Errorf("saw %v, want both Joe and Ann", seen)
+			}
+		})
+	}
+}
+
+func TestMainOutput(t *testing.T) {
+	if testing.Short() {
+		t.Skip("main waits for random delays")
+	}
+	out := exampletest.Run(t)
+	if n := strings.Count(out, "\n"); n != 6 {
+		t.Errorf("got %d lines, want 6:\n%s", n, out)
+	}
+}
@@ -0,0 +1,91 @@
+// One-shot result delivery with an IVar.
+//
+// A service needs its configuration before its workers can serve. It asks
+// every mirror at once and uses whichever answers first. Without an IVar
+// this takes a done channel, a variable for the result, a sync.Once so
+// that only the first mirror writes it and closes the channel, and care
+// that nobody reads the variable before the channel is closed. The IVar
+// is all four: mirrors call Put and the first one wins, workers call Get
+// and wait, each with its own deadline, for as long as it takes.
+//
+// Run with -mirror-failures 1 to make every mirror fail, and watch the
+// workers give up one by one after -timeout.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/lotusirous/gochan/internal/sim"
+	"github.com/lotusirous/gochan/ivar"
+)
+
+type config struct {
+	mirror    int
+	maxConns  int
+	fetchedIn time.Duration
+}
+
+// mirror simulates the config mirrors.
+var mirror = sim.Service{Latency: sim.Uniform(10*time.Millisecond, 80*time.Millisecond)}
+
+// fetch asks mirror i for the config and offers it to cfg. Losing the race
+// is not an error; it just means another mirror was faster.
+func fetch(ctx context.Context, i int, cfg *ivar.IVar[config], start time.Time) string {
+	if err := mirror.Call(ctx); err != nil {
+		return fmt.Sprintf("mirror %d: %v", i, err)
+	}
+	if cfg.Put(config{mirror: i, maxConns: 100, fetchedIn: time.Since(start)}) {
+		return fmt.Sprintf("mirror %d: delivered the config", i)
+	}
+	return fmt.Sprintf("mirror %d: too late, config already set", i)
+}
+
+// work waits for the config and reports what it got.
+func work(ctx context.Context, id int, cfg *ivar.IVar[config], timeout time.Duration) string {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	c, err := cfg.Get(ctx)
+	if err != nil {
+		return fmt.Sprintf("worker %d: no config: %v", id, err)
+	}
+	return fmt.Sprintf("worker %d: serving with max %d conns from mirror %d", id, c.maxConns, c.mirror)
+}
+
+func main() {
+	mirrors := flag.Int("mirrors", 3, "mirrors to fetch the config from")
+	workers := flag.Int("workers", 4, "workers waiting for the config")
+	timeout := flag.Duration("timeout", time.Second, "how long each worker waits for the config")
+	mirror.AddFlags(flag.CommandLine, "mirror-")
+	flag.Parse()
+
+	ctx := context.Background()
+	cfg := ivar.New[config]()
+	start := time.Now()
+
+	var wg sync.WaitGroup
+	for i := range *mirrors {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			fmt.Println(fetch(ctx, i+1, cfg, start))
+		}()
+	}
+	for id := range *workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			fmt.Println(work(ctx, id+1, cfg, *timeout))
+		}()
+	}
+	wg.Wait()
+
+	if c, ok := cfg.TryGet(); ok {
+		fmt.Printf("config from mirror %d after %v\n", c.mirror, c.fetchedIn.Round(time.Millisecond))
+	} else {
+		fmt.Println("no mirror delivered a config")
+	}
+}
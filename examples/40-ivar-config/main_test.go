@@ -0,0 +1,30 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/lotusirous/gochan/internal/exampletest"
+)
+
+func TestMain(m *testing.M) { exampletest.Main(m, main) }
+
+func TestFirstMirrorServesAllWorkers(t *testing.T) {
+	out := exampletest.Run(t, "-mirrors", "3", "-workers", "5", "-mirror-latency", "uniform:1ms,20ms")
+	if n := strings.Count(out, "delivered the config"); n != 1 {
+		t.Errorf("%d mirrors delivered, want 1:\n%s", n, out)
+	}
+	if n := strings.Count(out, "too late"); n != 2 {
+		t.Errorf("%d mirrors were too late, want 2:\n%s", n, out)
+	}
+	if n := strings.Count(out, "serving with"); n != 5 {
+		t.Errorf("%d workers served, want 5:\n%s", n, out)
+	}
+}
+
+func TestWorkersGiveUp(t *testing.T) {
+	out := exampletest.Run(t, "-workers", "2", "-mirror-failures", "1", "-mirror-latency", "1ms", "-timeout", "20ms")
+	if n := strings.Count(out, "no config: context deadline exceeded"); n != 2 || !strings.Contains(out, "no mirror delivered") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+}
@@ -0,0 +1,92 @@
+// A one-slot mailbox and a lock that carries its state, both MVars.
+//
+// A sensor puts readings into an MVar and a slower display takes them
+// out. The mailbox holds one reading, so a sensor that gets ahead waits
+// in Put until the display catches up: backpressure without a queue. With
+// -drop the sensor uses TryPut instead and drops readings the display is
+// not ready for, which is what a sensor that must not stall would do.
+//
+// The counts of delivered and dropped readings are in a second MVar, used
+// as a lock: Modify takes the counts, so nobody else can touch them, and
+// puts back the updated ones. The state and the lock are one thing, so it
+// is impossible to read the counts without holding them.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/lotusirous/gochan/mvar"
+)
+
+type reading struct {
+	seq   int
+	value float64
+	last  bool
+}
+
+type counts struct {
+	delivered, dropped int
+}
+
+func count(ctx context.Context, stats *mvar.MVar[counts], fn func(*counts)) {
+	stats.Modify(ctx, func(c counts) (counts, error) {
+		fn(&c)
+		return c, nil
+	})
+}
+
+// sense puts n readings into box, one every interval, and a last marker,
+// unless ctx is done first.
+func sense(ctx context.Context, box *mvar.MVar[reading], stats *mvar.MVar[counts], n int, every time.Duration, drop bool) {
+	for i := 1; i <= n; i++ {
+		time.Sleep(every)
+		r := reading{seq: i, value: 20 + float64(i%7)/2}
+		if drop {
+			if !box.TryPut(r) {
+				count(ctx, stats, func(c *counts) { c.dropped++ })
+			}
+			continue
+		}
+		if box.Put(ctx, r) != nil { // waits for the display
+			return
+		}
+	}
+	box.Put(ctx, reading{last: true})
+}
+
+// display takes readings from box until the last marker, taking slow to
+// show each. It stops early once ctx is done.
+func display(ctx context.Context, box *mvar.MVar[reading], stats *mvar.MVar[counts], slow time.Duration) {
+	for {
+		r, err := box.Take(ctx)
+		if err != nil || r.last {
+			return
+		}
+		fmt.Printf("reading %d: %.1f°C\n", r.seq, r.value)
+		count(ctx, stats, func(c *counts) { c.delivered++ })
+		time.Sleep(slow)
+	}
+}
+
+func main() {
+	n := flag.Int("readings", 10, "readings the sensor takes")
+	every := flag.Duration("every", 2*time.Millisecond, "time between readings")
+	slow := flag.Duration("slow", 5*time.Millisecond, "time the display takes per reading")
+	drop := flag.Bool("drop", false, "drop readings the display is not ready for instead of waiting")
+	flag.Parse()
+
+	box := mvar.New[reading]()
+	stats := mvar.NewFull(counts{})
+
+	ctx := context.Background()
+	start := time.Now()
+	go sense(ctx, box, stats, *n, *every, *drop)
+	display(ctx, box, stats, *slow)
+
+	c, _ := stats.Take(ctx)
+	fmt.Printf("%d readings delivered, %d dropped in %v\n",
+		c.delivered, c.dropped, time.Since(start).Round(time.Millisecond))
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/lotusirous/gochan/internal/exampletest"
+)
+
+func TestMain(m *testing.M) { exampletest.Main(m, main) }
+
+func TestBackpressureDeliversEverything(t *testing.T) {
+	out := exampletest.Run(t, "-readings", "8", "-every", "1ms", "-slow", "3ms")
+	if !strings.Contains(out, "8 readings delivered, 0 dropped") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+}
+
+func TestDropWhenDisplayIsBusy(t *testing.T) {
+	out := exampletest.Run(t, "-readings", "20", "-every", "1ms", "-slow", "10ms", "-drop")
+	var delivered, dropped int
+	for _, line := range strings.Split(out, "\n") {
+		if strings.Contains(line, "readings delivered") {
+			fmt.Sscanf(line, "%d readings delivered, %d dropped", &delivered, &dropped)
+		}
+	}
+	if delivered+dropped != 20 || dropped == 0 {
+		t.Errorf("%d delivered and %d dropped, want 20 in all with some dropped:\n%s", delivered, dropped, out)
+	}
+}
@@ -0,0 +1,350 @@
+// Raft-style leader election among goroutines that only talk over channels.
+//
+// Each node is a goroutine that is a follower, a candidate or the leader.
+// A leader sends heartbeats. A follower that hears none for its election
+// timeout, picked at random so that nodes rarely time out together, starts
+// an election: it moves to the next term, votes for itself and asks the
+// others for their votes. Each node votes at most once per term, so at
+// most one candidate can win a majority, and any message from a higher
+// term turns a leader or candidate back into a follower. That is all it
+// takes for every term to have at most one leader, whatever the network
+// does; the network only decides how quickly one is found.
+//
+// The network here delays every message by a random amount and can cut
+// the cluster in two. By default it isolates the leader after a second:
+// the majority elects a new one in a higher term, and when the partition
+// heals the old leader hears of that term and steps down.
+//
+// The run is deterministic. Time is a fake clock that the network moves
+// straight to the next delivery or timeout, every node is handed its
+// messages for that instant over its channel, and the network collects
+// the replies in node order before moving on, so the same -seed always
+// gives the same elections.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/lotusirous/gochan/clock"
+	"github.com/lotusirous/gochan/internal/sim"
+)
+
+type kind int
+
+const (
+	requestVote kind = iota
+	vote
+	heartbeat
+	staleTerm // a heartbeat's reply from a node in a later term
+)
+
+type message struct {
+	kind     kind
+	from, to int
+	term     int
+	granted  bool // for vote
+}
+
+type role int
+
+const (
+	follower role = iota
+	candidate
+	leader
+)
+
+// output is what a node sends back after handling one instant: messages
+// to deliver, events to print, and when it next wants to be woken.
+type output struct {
+	msgs    []message
+	events  []string
+	elected bool
+	wake    time.Time
+}
+
+type node struct {
+	id, size  int
+	clock     clock.Clock
+	rng       *rand.Rand
+	timeout   time.Duration // elections time out after timeout to 2*timeout
+	heartbeat time.Duration
+
+	role     role
+	term     int
+	votedFor int // -1 if none in this term
+	votes    int
+	deadline time.Time // next election timeout, or next heartbeat for a leader
+
+	in  chan []message
+	out chan output
+}
+
+func (n *node) run() {
+	for msgs := range n.in {
+		var o output
+		for _, m := range msgs {
+			n.handle(m, &o)
+		}
+		if !n.clock.Now().Before(n.deadline) {
+			n.expire(&o)
+		}
+		o.wake = n.deadline
+		n.out <- o
+	}
+}
+
+func (n *node) handle(m message, o *output) {
+	if m.term > n.term {
+		if n.role != follower {
+			o.events = append(o.events, fmt.Sprintf("node %d sees term %d and steps down", n.id, m.term))
+		}
+		n.term, n.role, n.votedFor = m.term, follower, -1
+	}
+	switch m.kind {
+	case requestVote:
+		granted := m.term == n.term && (n.votedFor == -1 || n.votedFor == m.from)
+		if granted {
+			n.votedFor = m.from
+			n.resetTimeout()
+		}
+		n.send(o, message{kind: vote, to: m.from, granted: granted})
+	case vote:
+		if n.role == candidate && m.term == n.term && m.granted {
+			n.votes++
+			n.checkWon(o)
+		}
+	case heartbeat:
+		if m.term < n.term {
+			n.send(o, message{kind: staleTerm, to: m.from})
+			return
+		}
+		n.role = follower // a candidate that lost to m.from
+		n.resetTimeout()
+	}
+}
+
+// expire runs when the deadline passes: a leader sends heartbeats, anyone
+// else starts an election.
+func (n *node) expire(o *output) {
+	if n.role == leader {
+		n.broadcast(o, heartbeat)
+		n.deadline = n.clock.Now().Add(n.heartbeat)
+		return
+	}
+	n.term++
+	n.role, n.votedFor, n.votes = candidate, n.id, 1
+	n.resetTimeout()
+	o.events = append(o.events, fmt.Sprintf("node %d times out and stands for term %d", n.id, n.term))
+	n.broadcast(o, requestVote)
+	n.checkWon(o)
+}
+
+func (n *node) checkWon(o *output) {
+	if n.votes <= n.size/2 {
+		return
+	}
+	n.role = leader
+	o.elected = true
+	o.events = append(o.events, fmt.Sprintf("node %d is leader of term %d with %d of %d votes", n.id, n.term, n.votes, n.size))
+	n.broadcast(o, heartbeat)
+	n.deadline = n.clock.Now().Add(n.heartbeat)
+}
+
+func (n *node) resetTimeout() {
+	n.deadline = n.clock.Now().Add(n.timeout + time.Duration(n.rng.Int64N(int64(n.timeout))))
+}
+
+func (n *node) send(o *output, m message) {
+	m.from, m.term = n.id, n.term
+	o.msgs = append(o.msgs, m)
+}
+
+func (n *node) broadcast(o *output, k kind) {
+	for to := range n.size {
+		if to != n.id {
+			n.send(o, message{kind: k, to: to})
+		}
+	}
+}
+
+type config struct {
+	nodes     int
+	seed      uint64
+	duration  time.Duration
+	delay     sim.Latency
+	timeout   time.Duration
+	heartbeat time.Duration
+	isolate   time.Duration // when to cut the leader off; 0 never
+	heal      time.Duration // when to heal the partition
+}
+
+type inFlight struct {
+	at time.Time
+	m  message
+}
+
+// summary is what a run found.
+type summary struct {
+	leaders map[int][]int // term -> nodes that became its leader
+	final   []int         // nodes that think they lead at the end
+}
+
+// simulate runs the cluster and writes its events to w.
+func simulate(cfg config, w io.Writer) summary {
+	start := time.Unix(0, 0)
+	clk := clock.NewFake(start)
+	rng := rand.New(rand.NewPCG(cfg.seed, 0))
+
+	nodes := make([]*node, cfg.nodes)
+	for i := range nodes {
+		nodes[i] = &node{
+			id: i, size: cfg.nodes, clock: clk,
+			rng:     rand.New(rand.NewPCG(cfg.seed, uint64(i)+1)),
+			timeout: cfg.timeout, heartbeat: cfg.heartbeat,
+			votedFor: -1,
+			in:       make(chan []message),
+			out:      make(chan output),
+		}
+		nodes[i].resetTimeout()
+		go nodes[i].run()
+	}
+	defer func() {
+		for _, n := range nodes {
+			close(n.in)
+		}
+	}()
+
+	say := func(format string, args ...any) {
+		fmt.Fprintf(w, "%8v  %s\n", clk.Now().Sub(start).Round(100*time.Microsecond), fmt.Sprintf(format, args...))
+	}
+	s := summary{leaders: map[int][]int{}}
+	isolated := -1 // the node cut off from the rest, if any
+	var pending []inFlight
+	wake := make([]time.Time, len(nodes))
+	for i, n := range nodes {
+		wake[i] = n.deadline
+	}
+
+	for {
+		// Jump to the next delivery, timeout or partition change.
+		next := slices.MinFunc(wake, time.Time.Compare)
+		for _, p := range pending {
+			if p.at.Before(next) {
+				next = p.at
+			}
+		}
+		for _, d := range []time.Duration{cfg.isolate, cfg.heal} {
+			if t := start.Add(d); d > 0 && t.After(clk.Now()) && t.Before(next) {
+				next = t
+			}
+		}
+		if end := start.Add(cfg.duration); next.After(end) {
+			clk.Advance(end.Sub(clk.Now()))
+			break
+		}
+		clk.Advance(next.Sub(clk.Now()))
+
+		now := clk.Now().Sub(start)
+		if now == cfg.isolate && cfg.isolate > 0 {
+			if isolated = currentLeader(nodes); isolated >= 0 {
+				say("network cuts node %d off from the rest", isolated)
+			} else {
+				say("no leader to cut off")
+			}
+		}
+		if now == cfg.heal && isolated >= 0 {
+			say("network heals")
+			isolated = -1
+		}
+
+		// Hand every node what is due, then collect their replies in order.
+		due := make([][]message, len(nodes))
+		var later []inFlight
+		for _, p := range pending {
+			switch {
+			case p.at.After(clk.Now()):
+				later = append(later, p)
+			case cut(isolated, p.m):
+				// dropped by the partition
+			default:
+				due[p.m.to] = append(due[p.m.to], p.m)
+			}
+		}
+		pending = later
+		for i, n := range nodes {
+			n.in <- due[i]
+		}
+		for i, n := range nodes {
+			o := <-n.out
+			for _, e := range o.events {
+				say("%s", e)
+			}
+			if o.elected {
+				s.leaders[n.term] = append(s.leaders[n.term], i)
+			}
+			for _, m := range o.msgs {
+				pending = append(pending, inFlight{clk.Now().Add(cfg.delay.Sample(rng)), m})
+			}
+			wake[i] = o.wake
+		}
+		// Deliver in time order; ties keep the order they were sent in.
+		slices.SortStableFunc(pending, func(a, b inFlight) int { return a.at.Compare(b.at) })
+	}
+
+	for _, n := range nodes {
+		if n.role == leader {
+			s.final = append(s.final, n.id)
+		}
+	}
+	return s
+}
+
+// cut reports whether the partition drops m.
+func cut(isolated int, m message) bool {
+	return isolated >= 0 && (m.from == isolated) != (m.to == isolated)
+}
+
+// currentLeader returns the leader of the highest term, or -1. It is only
+// called between steps, while every node is waiting for its next one.
+func currentLeader(nodes []*node) int {
+	best, term := -1, -1
+	for _, n := range nodes {
+		if n.role == leader && n.term > term {
+			best, term = n.id, n.term
+		}
+	}
+	return best
+}
+
+func main() {
+	cfg := config{delay: sim.Uniform(time.Millisecond, 10*time.Millisecond)}
+	flag.IntVar(&cfg.nodes, "nodes", 5, "nodes in the cluster")
+	flag.Uint64Var(&cfg.seed, "seed", 1, "seed for timeouts and delays; the same seed gives the same run")
+	flag.DurationVar(&cfg.duration, "duration", 3*time.Second, "simulated time to run for")
+	sim.LatencyVar(flag.CommandLine, &cfg.delay, "delay", "message `delay`: 5ms, uniform:min,max or pareto:scale,alpha")
+	flag.DurationVar(&cfg.timeout, "timeout", 150*time.Millisecond, "shortest election timeout; each is up to twice that")
+	flag.DurationVar(&cfg.heartbeat, "heartbeat", 50*time.Millisecond, "time between a leader's heartbeats")
+	flag.DurationVar(&cfg.isolate, "isolate", time.Second, "when to cut the leader off from the rest; 0 never")
+	flag.DurationVar(&cfg.heal, "heal", 2*time.Second, "when to heal the partition")
+	flag.Parse()
+
+	s := simulate(cfg, os.Stdout)
+
+	terms := make([]int, 0, len(s.leaders))
+	for t := range s.leaders {
+		terms = append(terms, t)
+	}
+	slices.Sort(terms)
+	safe := true
+	for _, t := range terms {
+		fmt.Printf("term %d: leader %v\n", t, s.leaders[t])
+		safe = safe && len(s.leaders[t]) == 1
+	}
+	fmt.Printf("%d elections won, at most one leader per term: %v; leading at the end: %v\n", len(terms), safe, s.final)
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lotusirous/gochan/internal/exampletest"
+	"github.com/lotusirous/gochan/internal/sim"
+)
+
+func TestMain(m *testing.M) { exampletest.Main(m, main) }
+
+func testConfig(seed uint64) config {
+	return config{
+		nodes: 5, seed: seed, duration: 3 * time.Second,
+		delay:   sim.Uniform(time.Millisecond, 20*time.Millisecond),
+		timeout: 150 * time.Millisecond, heartbeat: 50 * time.Millisecond,
+		isolate: time.Second, heal: 2 * time.Second,
+	}
+}
+
+func TestOneLeaderPerTerm(t *testing.T) {
+	for seed := range uint64(30) {
+		s := simulate(testConfig(seed), io.Discard)
+		for term, leaders := range s.leaders {
+			if len(leaders) > 1 {
+				t.Errorf("seed %d: term %d has leaders %v", seed, term, leaders)
+			}
+		}
+		// The isolated leader must have been replaced, and must have
+		// stepped down once it heard of the new term after the heal.
+		if len(s.leaders) < 2 || len(s.final) != 1 {
+			t.Errorf("seed %d: %d terms had leaders, %v lead at the end", seed, len(s.leaders), s.final)
+		}
+	}
+}
+
+func TestStableLeaderWithoutPartition(t *testing.T) {
+	// Heartbeats arrive well within the election timeout, so once elected
+	// a leader should keep its term for the rest of the run.
+	cfg := testConfig(3)
+	cfg.isolate = 0
+	var out strings.Builder
+	s := simulate(cfg, &out)
+	if len(s.leaders) != 1 || len(s.final) != 1 {
+		t.Errorf("leaders by term %v, leading at the end %v:\n%s", s.leaders, s.final, out.String())
+	}
+}
+
+func TestSameSeedSameRun(t *testing.T) {
+	args := []string{"-seed", "42", "-delay", "pareto:2ms,1.5"}
+	a, b := exampletest.Run(t, args...), exampletest.Run(t, args...)
+	if a != b {
+		t.Errorf("two runs with the same seed differ:\n%s\n---\n%s", a, b)
+	}
+	if !strings.Contains(a, "at most one leader per term: true") {
+		t.Errorf("unexpected output:\n%s", a)
+	}
+}
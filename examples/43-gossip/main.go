@@ -0,0 +1,164 @@
+// Gossip dissemination among a thousand goroutines over lossy channels.
+//
+// A few nodes learn of an update. Every round each node pushes what it
+// knows to a handful of peers picked at random, and merges whatever it
+// receives. Nobody coordinates who tells whom, nobody waits for an
+// acknowledgement and any message may be lost, yet the number of nodes
+// that know grows geometrically, and everyone knows after a number of
+// rounds that only grows with the logarithm of the cluster size.
+//
+// Every node's inbox is wrapped in a sim.Chaos link, so the messages are
+// lost, duplicated and delayed as the -loss, -dup and -delay flags say.
+// Raise -loss and watch convergence slow down rather than stop; raise
+// -fanout and watch it speed up at the price of more messages.
+//
+// Rounds are broadcast to the nodes through a watch.Value. Goroutines run
+// as the scheduler pleases, so unlike the Raft example the exact number of
+// rounds varies from run to run.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/lotusirous/gochan/internal/sim"
+	"github.com/lotusirous/gochan/watch"
+)
+
+// state is what a node knows: the version of each key, 0 if unheard of.
+type state []int
+
+// merge takes the newer version of each key from o and reports whether
+// anything changed.
+func (s state) merge(o state) bool {
+	changed := false
+	for k, v := range o {
+		if v > s[k] {
+			s[k] = v
+			changed = true
+		}
+	}
+	return changed
+}
+
+func (s state) complete() bool {
+	for _, v := range s {
+		if v == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+type cluster struct {
+	inboxes  []chan state // what peers send to
+	fanout   int
+	sent     atomic.Int64
+	overflow atomic.Int64 // sends dropped because an inbox was full
+	upToDate atomic.Int64 // nodes that know every key
+}
+
+// gossip is one node: it merges what arrives on in and pushes its state to
+// random peers on every round.
+func (c *cluster) gossip(ctx context.Context, id int, s state, in <-chan state, rounds <-chan int) {
+	if s.complete() {
+		c.upToDate.Add(1)
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case o, ok := <-in:
+			if !ok {
+				return
+			}
+			if !s.complete() && s.merge(o) && s.complete() {
+				c.upToDate.Add(1)
+			}
+		case <-rounds:
+			for range c.fanout {
+				peer := rand.IntN(len(c.inboxes) - 1)
+				if peer >= id {
+					peer++ // anyone but itself
+				}
+				select {
+				case c.inboxes[peer] <- append(state(nil), s...):
+					c.sent.Add(1)
+				default:
+					c.overflow.Add(1)
+				}
+			}
+		}
+	}
+}
+
+func main() {
+	n := flag.Int("nodes", 1000, "nodes in the cluster")
+	fanout := flag.Int("fanout", 3, "peers each node pushes to per round")
+	updates := flag.Int("updates", 3, "keys updated at random nodes before the first round")
+	every := flag.Duration("round", 20*time.Millisecond, "length of a round; too short and nodes cannot keep up")
+	maxRounds := flag.Int("max-rounds", 100, "rounds to give up after")
+	var link sim.Chaos
+	link.Loss = 0.1
+	link.AddFlags(flag.CommandLine, "")
+	flag.Parse()
+	*updates = min(*updates, *n)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c := &cluster{inboxes: make([]chan state, *n), fanout: *fanout}
+	for i := range c.inboxes {
+		c.inboxes[i] = make(chan state, 4**fanout)
+	}
+	round := watch.New(0)
+
+	// Each update starts at its own node.
+	starts := make([]state, *n)
+	for i := range starts {
+		starts[i] = make(state, *updates)
+	}
+	for k, i := range rand.Perm(*n)[:*updates] {
+		starts[i][k] = 1
+	}
+
+	var wg sync.WaitGroup
+	for i := range *n {
+		in, rounds := sim.Link(ctx, &link, c.inboxes[i]), round.Watch(ctx)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.gossip(ctx, i, starts[i], in, rounds)
+		}()
+	}
+	fmt.Printf("%d nodes, fanout %d, %d goroutines running\n", *n, *fanout, runtime.NumGoroutine())
+
+	ticker := time.NewTicker(*every)
+	defer ticker.Stop()
+	r := 0
+	for c.upToDate.Load() < int64(*n) && r < *maxRounds {
+		<-ticker.C
+		r++
+		round.Set(r)
+		fmt.Printf("round %3d: %5d of %d nodes up to date\n", r, c.upToDate.Load(), *n)
+	}
+	cancel()
+	wg.Wait()
+
+	// Push gossip takes about log(n)/log(1+fanout) rounds to reach most
+	// nodes and ln(n)/fanout more to reach the last ones.
+	ln := math.Log(float64(*n))
+	estimate := ln/math.Log(1+float64(*fanout)) + ln/float64(*fanout)
+	if got := c.upToDate.Load(); got < int64(*n) {
+		fmt.Printf("gave up after %d rounds with %d of %d nodes up to date\n", r, got, *n)
+	} else {
+		fmt.Printf("all %d nodes up to date after %d rounds (about %.0f expected without loss)\n", *n, r, estimate)
+	}
+	fmt.Printf("%d messages sent, %d lost on the way, %d dropped at full inboxes\n",
+		c.sent.Load(), link.Lost(), c.overflow.Load())
+}
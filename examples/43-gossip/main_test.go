@@ -0,0 +1,34 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/lotusirous/gochan/internal/exampletest"
+)
+
+func TestMain(m *testing.M) { exampletest.Main(m, main) }
+
+func TestMerge(t *testing.T) {
+	s := state{0, 2, 1}
+	if !s.merge(state{1, 1, 1}) || s[0] != 1 || s[1] != 2 || !s.complete() {
+		t.Errorf("merged to %v", s)
+	}
+	if s.merge(state{1, 0, 0}) {
+		t.Error("merging older versions reported a change")
+	}
+}
+
+func TestConvergesDespiteLoss(t *testing.T) {
+	out := exampletest.Run(t, "-nodes", "200", "-loss", "0.3", "-dup", "0.1", "-delay", "uniform:0s,2ms", "-round", "10ms")
+	if !strings.Contains(out, "all 200 nodes up to date after") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+}
+
+func TestTotalLossNeverConverges(t *testing.T) {
+	out := exampletest.Run(t, "-nodes", "50", "-updates", "2", "-loss", "1", "-max-rounds", "5", "-round", "2ms")
+	if !strings.Contains(out, "gave up after 5 rounds with 0 of 50 nodes up to date") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+}
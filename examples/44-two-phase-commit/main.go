@@ -0,0 +1,296 @@
+// Two-phase commit between a coordinator and participant goroutines.
+//
+// In the first phase the coordinator asks every participant to prepare. A
+// participant that can commit writes "prepared" to its log, which survives
+// a crash, and votes yes; from then on it may no longer decide alone. In
+// the second phase the coordinator commits if every vote was yes, aborts
+// otherwise, and tells everyone. A vote that does not arrive within the
+// prepare timeout counts as no, so a participant that crashed before
+// voting makes the transaction abort instead of hanging it.
+//
+// A participant that crashes after voting yes is in doubt when it comes
+// back: its log says prepared and it missed the outcome. It must not
+// guess. It asks the coordinator, which answers from the decision it
+// logged, and only then commits or aborts. (Had the coordinator crashed
+// instead, every prepared participant would have had to wait for it: the
+// blocking that makes two-phase commit unpopular.)
+//
+// Try -no bob, -crash carol -crash-at before-vote, and -crash carol
+// -crash-at after-vote, and check that nobody ever commits while someone
+// else aborts.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lotusirous/gochan/clock"
+)
+
+type kind int
+
+const (
+	prepare kind = iota
+	vote
+	commit
+	abort
+	query // a recovering participant asks for the outcome
+)
+
+type message struct {
+	kind kind
+	from string
+	yes  bool // for vote
+}
+
+// crash points of a participant.
+const (
+	beforeVote = "before-vote"
+	afterVote  = "after-vote"
+)
+
+type config struct {
+	participants []string
+	no           string // votes no
+	crash        string // crashes on prepare
+	crashAt      string
+	down         time.Duration // how long a crashed participant stays down
+	timeout      time.Duration // how long the coordinator waits for votes
+	clock        clock.Clock
+	out          io.Writer
+}
+
+type participant struct {
+	name    string
+	inbox   chan message
+	log     []string // durable: what survives a crash
+	cfg     *config
+	say     func(who, format string, args ...any)
+	coord   chan<- message
+	settled chan struct{} // closed once it has nothing left to do
+}
+
+// state is the participant's view of the transaction, from its log.
+func (p *participant) state() string {
+	if len(p.log) == 0 {
+		return "never prepared"
+	}
+	return p.log[len(p.log)-1]
+}
+
+func (p *participant) run(ctx context.Context) {
+	defer close(p.settled)
+	for {
+		var m message
+		select {
+		case m = <-p.inbox:
+		case <-ctx.Done():
+			return
+		}
+		switch m.kind {
+		case prepare:
+			if p.name == p.cfg.crash && p.cfg.crashAt == beforeVote {
+				p.crashAndRecover(ctx)
+				return // it never prepared, so there is nothing to finish
+			}
+			yes := p.name != p.cfg.no
+			if yes {
+				p.log = append(p.log, "prepared")
+			} else {
+				p.log = append(p.log, "aborted")
+			}
+			p.say(p.name, "votes %s", map[bool]string{true: "yes", false: "no"}[yes])
+			p.coord <- message{kind: vote, from: p.name, yes: yes}
+			if !yes {
+				return // already aborted; the outcome cannot change that
+			}
+			if p.name == p.cfg.crash && p.cfg.crashAt == afterVote {
+				p.crashAndRecover(ctx)
+				p.coord <- message{kind: query, from: p.name}
+			}
+		case commit, abort:
+			outcome := map[kind]string{commit: "committed", abort: "aborted"}[m.kind]
+			p.log = append(p.log, outcome)
+			p.say(p.name, "%s", outcome)
+			return
+		}
+	}
+}
+
+// crashAndRecover loses everything but the log: the participant is down
+// for a while, and whatever reached it meanwhile is gone.
+func (p *participant) crashAndRecover(ctx context.Context) {
+	p.say(p.name, "crashes")
+	select {
+	case <-p.cfg.clock.After(p.cfg.down):
+	case <-ctx.Done():
+		return
+	}
+	for lost := true; lost; {
+		select {
+		case <-p.inbox:
+		default:
+			lost = false
+		}
+	}
+	switch p.state() {
+	case "prepared":
+		p.say(p.name, "recovers in doubt, its log says prepared; asking the coordinator")
+	default:
+		p.say(p.name, "recovers; its log has nothing for the transaction")
+	}
+}
+
+type coordinator struct {
+	inbox   chan message
+	log     []string      // durable: the decision
+	decided chan struct{} // closed once the decision is logged
+}
+
+// run runs both phases and then answers queries until ctx is done.
+func (c *coordinator) run(ctx context.Context, cfg *config, ps map[string]*participant, say func(who, format string, args ...any)) {
+	say("coordinator", "asks %s to prepare", strings.Join(cfg.participants, ", "))
+	for _, name := range cfg.participants {
+		ps[name].inbox <- message{kind: prepare}
+	}
+
+	votes := map[string]bool{}
+	var queries []string // asked before the decision; answered after it
+	timeout := cfg.clock.After(cfg.timeout)
+	handle := func(m message) {
+		switch m.kind {
+		case vote:
+			votes[m.from] = m.yes
+		case query:
+			queries = append(queries, m.from)
+		}
+	}
+collect:
+	for len(votes) < len(ps) {
+		select {
+		case m := <-c.inbox:
+			handle(m)
+		case <-timeout:
+			// Count what already arrived before giving up on the rest.
+			for len(votes) < len(ps) {
+				select {
+				case m := <-c.inbox:
+					handle(m)
+				default:
+					break collect
+				}
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+
+	decision := commit
+	for _, name := range cfg.participants {
+		yes, voted := votes[name]
+		if !voted {
+			say("coordinator", "no vote from %s within %v", name, cfg.timeout)
+		}
+		if !yes {
+			decision = abort
+		}
+	}
+	c.log = append(c.log, map[kind]string{commit: "commit", abort: "abort"}[decision])
+	close(c.decided)
+	say("coordinator", "decides to %s", c.log[0])
+	for _, name := range cfg.participants {
+		ps[name].inbox <- message{kind: decision}
+	}
+	for _, name := range queries {
+		ps[name].inbox <- message{kind: decision}
+	}
+
+	for {
+		select {
+		case m := <-c.inbox:
+			if m.kind == query {
+				say("coordinator", "tells %s the outcome was %s", m.from, c.log[0])
+				ps[m.from].inbox <- message{kind: decision}
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// run plays one transaction and returns the coordinator's decision and
+// every participant's final state.
+func run(cfg config) (decision string, states map[string]string) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var mu sync.Mutex
+	start := cfg.clock.Now()
+	say := func(who, format string, args ...any) {
+		mu.Lock()
+		defer mu.Unlock()
+		fmt.Fprintf(cfg.out, "%8v  %-11s %s\n", cfg.clock.Now().Sub(start).Round(100*time.Microsecond), who, fmt.Sprintf(format, args...))
+	}
+
+	// Buffered so that nobody blocks sending to a participant that is down.
+	c := &coordinator{inbox: make(chan message, 2*len(cfg.participants)), decided: make(chan struct{})}
+	ps := map[string]*participant{}
+	for _, name := range cfg.participants {
+		ps[name] = &participant{
+			name: name, inbox: make(chan message, 4), cfg: &cfg, say: say,
+			coord: c.inbox, settled: make(chan struct{}),
+		}
+		go ps[name].run(ctx)
+	}
+	coordDone := make(chan struct{})
+	go func() {
+		defer close(coordDone)
+		c.run(ctx, &cfg, ps, say)
+	}()
+
+	states = map[string]string{}
+	for _, name := range cfg.participants {
+		<-ps[name].settled
+		states[name] = ps[name].state()
+	}
+	<-c.decided
+	cancel()
+	<-coordDone
+	return c.log[0], states
+}
+
+func main() {
+	cfg := config{clock: clock.Real, out: os.Stdout}
+	names := flag.String("participants", "alice,bob,carol", "comma-separated participant names")
+	flag.StringVar(&cfg.no, "no", "", "participant that votes no")
+	flag.StringVar(&cfg.crash, "crash", "", "participant that crashes during the transaction")
+	flag.StringVar(&cfg.crashAt, "crash-at", afterVote, "when it crashes: "+beforeVote+" or "+afterVote)
+	flag.DurationVar(&cfg.down, "down", 300*time.Millisecond, "how long a crashed participant stays down")
+	flag.DurationVar(&cfg.timeout, "timeout", 100*time.Millisecond, "how long the coordinator waits for votes")
+	flag.Parse()
+	cfg.participants = strings.Split(*names, ",")
+	if cfg.crashAt != beforeVote && cfg.crashAt != afterVote {
+		fmt.Fprintf(os.Stderr, "-crash-at must be %s or %s\n", beforeVote, afterVote)
+		os.Exit(2)
+	}
+
+	decision, states := run(cfg)
+	fmt.Printf("decision: %s\n", decision)
+	committed, aborted := 0, 0
+	for _, name := range cfg.participants {
+		fmt.Printf("  %-8s %s\n", name, states[name])
+		switch states[name] {
+		case "committed":
+			committed++
+		case "aborted":
+			aborted++
+		}
+	}
+	fmt.Printf("atomic: %v\n", committed == 0 || aborted == 0)
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"io"
+	"maps"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lotusirous/gochan/clock"
+	"github.com/lotusirous/gochan/internal/exampletest"
+)
+
+func TestMain(m *testing.M) { exampletest.Main(m, main) }
+
+// runFake runs a transaction on a fake clock. Whenever the goroutines have
+// had time to do everything they can without the clock, it jumps to the
+// next deadline, so timeouts and downtime take no real time.
+func runFake(t *testing.T, cfg config) (string, map[string]string) {
+	t.Helper()
+	clk := clock.NewFake(time.Unix(0, 0))
+	cfg.participants = []string{"alice", "bob", "carol"}
+	cfg.down, cfg.timeout = time.Minute, time.Second
+	cfg.clock, cfg.out = clk, io.Discard
+
+	type result struct {
+		decision string
+		states   map[string]string
+	}
+	done := make(chan result, 1)
+	go func() {
+		d, s := run(cfg)
+		done <- result{d, s}
+	}()
+	for {
+		select {
+		case r := <-done:
+			return r.decision, r.states
+		case <-time.After(10 * time.Millisecond):
+		}
+		if next, ok := clk.Next(); ok {
+			clk.Advance(next.Sub(clk.Now()))
+		}
+	}
+}
+
+func all(state string) map[string]string {
+	return map[string]string{"alice": state, "bob": state, "carol": state}
+}
+
+func TestOutcomes(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		cfg      config
+		decision string
+		states   map[string]string
+	}{
+		{"all yes", config{}, "commit", all("committed")},
+		{"one no", config{no: "bob"}, "abort", all("aborted")},
+		{
+			"crash before voting times out the prepare",
+			config{crash: "carol", crashAt: beforeVote},
+			"abort", map[string]string{"alice": "aborted", "bob": "aborted", "carol": "never prepared"},
+		},
+		{
+			"crash after voting recovers the commit",
+			config{crash: "carol", crashAt: afterVote},
+			"commit", all("committed"),
+		},
+		{
+			"crash after voting recovers the abort",
+			config{crash: "carol", crashAt: afterVote, no: "alice"},
+			"abort", all("aborted"),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			decision, states := runFake(t, tc.cfg)
+			if decision != tc.decision || !maps.Equal(states, tc.states) {
+				t.Errorf("decided %s with %v, want %s with %v", decision, states, tc.decision, tc.states)
+			}
+		})
+	}
+}
+
+func TestInDoubtParticipantAsks(t *testing.T) {
+	out := exampletest.Run(t, "-crash", "bob", "-crash-at", "after-vote", "-down", "20ms")
+	for _, want := range []string{"bob         recovers in doubt", "tells bob the outcome was commit", "atomic: true"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output lacks %q:\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,235 @@
+// Reliable event publication with a transactional outbox.
+//
+// A service that changes its database and then publishes an event about
+// it can crash, or find the broker down, in between: the change happened
+// but nobody hears of it. The outbox pattern writes the event into the
+// database in the same transaction as the change, so either both happen
+// or neither does, and leaves publishing to a dispatcher goroutine that
+// reads the outbox and publishes until the broker has taken everything.
+//
+// The dispatcher retries with backoff and only marks an event sent once
+// the broker acknowledged it, so every event is published at least once.
+// When an acknowledgement is lost the event is published again, which is
+// why consumers drop duplicates by event version. Events of one aggregate,
+// here one order, are published strictly in order: if one fails for good
+// the later ones of the same order wait for the next pass, while other
+// orders, published concurrently, carry on.
+//
+// Try -broker-failures 0.5 and -ack-loss 0.3: the dispatcher works harder
+// and the consumer sees duplicates, but never an order out of sequence.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/lotusirous/gochan/internal/sim"
+	"github.com/lotusirous/gochan/retry"
+)
+
+type event struct {
+	id      int    // outbox sequence number
+	key     string // the order it is about
+	version int    // the order's version after the change, from 1
+	kind    string
+}
+
+// store is the service's database: order versions and the outbox, which
+// change together under one lock standing in for a transaction.
+type store struct {
+	mu     sync.Mutex
+	orders map[string]int
+	outbox []event // not yet published, oldest first
+	next   int
+	wake   chan struct{} // nudges the dispatcher; one pending nudge is enough
+}
+
+func newStore() *store {
+	return &store{orders: map[string]int{}, wake: make(chan struct{}, 1)}
+}
+
+// change updates an order and records the event in the same transaction.
+func (s *store) change(key, kind string) {
+	s.mu.Lock()
+	s.orders[key]++
+	s.next++
+	s.outbox = append(s.outbox, event{s.next, key, s.orders[key], kind})
+	s.mu.Unlock()
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// pending returns the unpublished events grouped by order, each group in
+// outbox order.
+func (s *store) pending() map[string][]event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	byKey := map[string][]event{}
+	for _, e := range s.outbox {
+		byKey[e.key] = append(byKey[e.key], e)
+	}
+	return byKey
+}
+
+func (s *store) markSent(id int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, e := range s.outbox {
+		if e.id == id {
+			s.outbox = append(s.outbox[:i], s.outbox[i+1:]...)
+			return
+		}
+	}
+}
+
+func (s *store) unsent() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.outbox)
+}
+
+// broker is a flaky message broker in front of one consumer.
+type broker struct {
+	svc     sim.Service
+	ackLoss float64 // chance that a delivered event is reported as failed
+	out     chan event
+}
+
+var errAckLost = errors.New("ack lost")
+
+func (b *broker) publish(ctx context.Context, e event) error {
+	if err := b.svc.Call(ctx); err != nil {
+		return err
+	}
+	select {
+	case b.out <- e:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	if rand.Float64() < b.ackLoss {
+		return errAckLost
+	}
+	return nil
+}
+
+type dispatchStats struct {
+	published, attempts, heldBack atomic.Int64
+}
+
+// dispatch publishes the outbox until ctx is done, one goroutine per order
+// with events pending, each order's events in sequence.
+func dispatch(ctx context.Context, s *store, b *broker, poll time.Duration, st *dispatchStats) {
+	policy := retry.Policy{Attempts: 4, Backoff: func(n int) time.Duration { return time.Duration(n) * time.Millisecond }}
+	for {
+		var wg sync.WaitGroup
+		for _, events := range s.pending() {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i, e := range events {
+					err := policy.Do(ctx, func(ctx context.Context) error {
+						st.attempts.Add(1)
+						return b.publish(ctx, e)
+					})
+					if err != nil {
+						// Publishing the rest now would overtake e.
+						st.heldBack.Add(int64(len(events) - i))
+						return
+					}
+					s.markSent(e.id)
+					st.published.Add(1)
+				}
+			}()
+		}
+		wg.Wait()
+
+		select {
+		case <-s.wake:
+		case <-time.After(poll):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+type consumeStats struct {
+	applied, duplicates, outOfOrder int
+}
+
+// consume applies events, dropping the ones it has seen and counting any
+// that arrive ahead of their predecessor.
+func consume(in <-chan event) consumeStats {
+	var st consumeStats
+	last := map[string]int{}
+	for e := range in {
+		switch {
+		case e.version <= last[e.key]:
+			st.duplicates++
+		case e.version != last[e.key]+1:
+			st.outOfOrder++
+		default:
+			last[e.key] = e.version
+			st.applied++
+		}
+	}
+	return st
+}
+
+func main() {
+	writers := flag.Int("writers", 4, "goroutines changing orders")
+	changes := flag.Int("changes", 25, "changes per writer")
+	orders := flag.Int("orders", 6, "orders the writers change")
+	ackLoss := flag.Float64("ack-loss", 0.1, "chance that the broker takes an event but the acknowledgement is lost")
+	poll := flag.Duration("poll", 20*time.Millisecond, "how often the dispatcher looks at the outbox without being nudged")
+	b := &broker{svc: sim.Service{Latency: sim.Uniform(0, time.Millisecond), Failures: 0.2}, out: make(chan event)}
+	b.svc.AddFlags(flag.CommandLine, "broker-")
+	flag.Parse()
+	b.ackLoss = *ackLoss
+
+	s := newStore()
+	consumed := make(chan consumeStats)
+	go func() { consumed <- consume(b.out) }()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var st dispatchStats
+	dispatched := make(chan struct{})
+	go func() {
+		defer close(dispatched)
+		dispatch(ctx, s, b, *poll, &st)
+	}()
+
+	kinds := []string{"created", "paid", "packed", "shipped", "delivered"}
+	var wg sync.WaitGroup
+	for range *writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range *changes {
+				s.change(fmt.Sprintf("order-%d", rand.IntN(*orders)+1), kinds[rand.IntN(len(kinds))])
+			}
+		}()
+	}
+	wg.Wait()
+	total := *writers * *changes
+	fmt.Printf("%d changes committed with their events\n", total)
+
+	for s.unsent() > 0 {
+		time.Sleep(*poll)
+	}
+	cancel()
+	<-dispatched
+	close(b.out)
+	c := <-consumed
+
+	fmt.Printf("dispatcher: %d published in %d attempts, %d held back behind a failure for a later pass\n",
+		st.published.Load(), st.attempts.Load(), st.heldBack.Load())
+	fmt.Printf("consumer: %d applied, %d duplicates dropped, %d out of order\n", c.applied, c.duplicates, c.outOfOrder)
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/lotusirous/gochan/internal/exampletest"
+)
+
+func TestMain(m *testing.M) { exampletest.Main(m, main) }
+
+func TestConsumeDetectsDuplicatesAndGaps(t *testing.T) {
+	in := make(chan event, 5)
+	for _, v := range []int{1, 2, 2, 4, 3} {
+		in <- event{key: "a", version: v}
+	}
+	close(in)
+	if got := consume(in); got != (consumeStats{applied: 3, duplicates: 1, outOfOrder: 1}) {
+		t.Errorf("consume = %+v", got)
+	}
+}
+
+func TestEveryEventOnceInOrder(t *testing.T) {
+	out := exampletest.Run(t, "-writers", "3", "-changes", "40", "-orders", "4",
+		"-broker-failures", "0.5", "-ack-loss", "0.3", "-poll", "5ms")
+	if !strings.Contains(out, "consumer: 120 applied") || !strings.Contains(out, " 0 out of order") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/lotusirous/gochan/internal/exampletest"
+	"github.com/lotusirous/gochan/internal/vclock"
+)
+
+func TestMain(m *testing.M) { exampletest.Main(m, main) }
+
+func TestOnlyStatsRaces(t *testing.T) {
+	for range 20 {
+		log, _ := run(false)
+		for _, c := range conflicts(log) {
+			if race := c.order == vclock.Concurrent; race != (c.first.v == "stats") {
+				t.Fatalf("%v vs %v: %v", c.first, c.second, c.order)
+			}
+		}
+	}
+}
+
+func TestFixRemovesRace(t *testing.T) {
+	for _, tt := range []struct {
+		args []string
+		want string
+	}{
+		{nil, "data races found: 1"},
+		{[]string{"-fix"}, "data races found: 0"},
+	} {
+		if out := exampletest.Run(t, tt.args...); !strings.Contains(out, tt.want) {
+			t.Errorf("%v: unexpected output:\n%s", tt.args, out)
+		}
+	}
+}
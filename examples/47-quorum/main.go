@@ -0,0 +1,215 @@
+// Quorum reads and writes over replica goroutines.
+//
+// Every key is stored on N replicas. A write goes to all of them and
+// succeeds once W have acknowledged it; a read asks all of them, waits for
+// R answers and keeps the one with the highest version. Both give up at a
+// deadline, so replicas that are down or slow cost latency but not
+// availability, as long as enough of the others answer.
+//
+// When R+W > N, every read quorum shares at least one replica with every
+// write quorum, so a read always sees the latest acknowledged write. Lower
+// R or W and operations get faster and survive more failed replicas, but
+// reads start returning stale versions. Compare -r 2 -w 2 with -r 1 -w 1,
+// and take replicas down with -down 2 or -down 1,2.
+//
+// Replicas are goroutines serving requests over reqchan channels; the
+// coordinator gathers their replies with scatter.Quorum. Requests and
+// replies travel with the latency and failure rate of the -net- flags. A
+// write whose quorum failed is not rolled back: the replicas it did reach
+// keep it.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/lotusirous/gochan/internal/sim"
+	"github.com/lotusirous/gochan/reqchan"
+	"github.com/lotusirous/gochan/scatter"
+)
+
+type versioned struct {
+	value   string
+	version int
+}
+
+type op struct {
+	write bool
+	key   string
+	v     versioned // for a write
+}
+
+// replica serves reads and writes of its own copy of the data, one at a
+// time. A write only replaces an older version.
+func replica(ctx context.Context, srv reqchan.Server[op, versioned]) {
+	data := map[string]versioned{}
+	srv.Serve(ctx, func(o op) versioned {
+		cur := data[o.key]
+		if o.write && o.v.version > cur.version {
+			cur = o.v
+			data[o.key] = cur
+		}
+		return cur
+	})
+}
+
+type config struct {
+	n, r, w  int
+	down     map[int]bool
+	deadline time.Duration
+	clients  int
+	ops      int
+	net      *sim.Service
+}
+
+type stats struct {
+	writes, writesFailed, reads, readsFailed, stale atomic.Int64
+	writeTime, readTime                             atomic.Int64 // nanoseconds
+}
+
+type cluster struct {
+	replicas []scatter.Backend[op, versioned]
+	cfg      config
+}
+
+// newCluster starts the replicas that are not down. The others never
+// answer, like a crashed machine behind a network that drops the requests.
+func newCluster(ctx context.Context, cfg config) *cluster {
+	c := &cluster{cfg: cfg}
+	for i := range cfg.n {
+		client, server := reqchan.New[op, versioned]()
+		if !cfg.down[i] {
+			go replica(ctx, server)
+		}
+		c.replicas = append(c.replicas, scatter.Backend[op, versioned]{
+			Name: "replica-" + strconv.Itoa(i),
+			Call: func(ctx context.Context, o op) (versioned, error) {
+				if err := cfg.net.Call(ctx); err != nil {
+					return versioned{}, err // the request was lost
+				}
+				v, err := client.Call(ctx, o)
+				if err != nil {
+					return versioned{}, err
+				}
+				return v, cfg.net.Call(ctx) // the reply may be lost too
+			},
+		})
+	}
+	return c
+}
+
+func (c *cluster) write(ctx context.Context, key string, v versioned) error {
+	ctx, cancel := context.WithTimeout(ctx, c.cfg.deadline)
+	defer cancel()
+	_, err := scatter.Gather(ctx, op{write: true, key: key, v: v}, scatter.Quorum(c.cfg.w), c.replicas...)
+	return err
+}
+
+func (c *cluster) read(ctx context.Context, key string) (versioned, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.cfg.deadline)
+	defer cancel()
+	out, err := scatter.Gather(ctx, op{key: key}, scatter.Quorum(c.cfg.r), c.replicas...)
+	if err != nil {
+		return versioned{}, err
+	}
+	var latest versioned
+	for _, v := range out.Values() {
+		if v.version > latest.version {
+			latest = v
+		}
+	}
+	return latest, nil
+}
+
+// run has every client write and then read back its own key, over and
+// over, and counts the reads that missed the client's last acknowledged
+// write.
+func run(cfg config) *stats {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c := newCluster(ctx, cfg)
+	st := &stats{}
+	var wg sync.WaitGroup
+	for i := range cfg.clients {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			key := fmt.Sprint("key-", i)
+			acked := 0
+			for version := 1; version <= cfg.ops; version++ {
+				start := time.Now()
+				err := c.write(ctx, key, versioned{fmt.Sprintf("value %d", version), version})
+				st.writeTime.Add(int64(time.Since(start)))
+				if err != nil {
+					st.writesFailed.Add(1)
+				} else {
+					st.writes.Add(1)
+					acked = version
+				}
+
+				start = time.Now()
+				v, err := c.read(ctx, key)
+				st.readTime.Add(int64(time.Since(start)))
+				if err != nil {
+					st.readsFailed.Add(1)
+					continue
+				}
+				st.reads.Add(1)
+				if v.version < acked {
+					st.stale.Add(1)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	return st
+}
+
+func main() {
+	cfg := config{net: &sim.Service{Latency: sim.Uniform(0, 5*time.Millisecond)}}
+	flag.IntVar(&cfg.n, "n", 3, "replicas")
+	flag.IntVar(&cfg.r, "r", 2, "replies a read waits for")
+	flag.IntVar(&cfg.w, "w", 2, "acknowledgements a write waits for")
+	down := flag.String("down", "", "comma-separated replicas, from 0, that are down")
+	flag.DurationVar(&cfg.deadline, "deadline", 20*time.Millisecond, "how long an operation waits for its quorum")
+	flag.IntVar(&cfg.clients, "clients", 4, "concurrent clients, each with its own key")
+	flag.IntVar(&cfg.ops, "ops", 50, "writes, each followed by a read, per client")
+	cfg.net.AddFlags(flag.CommandLine, "net-")
+	flag.Parse()
+	if cfg.r < 1 || cfg.r > cfg.n || cfg.w < 1 || cfg.w > cfg.n {
+		fmt.Fprintln(os.Stderr, "-r and -w must be between 1 and -n")
+		os.Exit(2)
+	}
+	cfg.down = map[int]bool{}
+	if *down != "" {
+		for _, s := range strings.Split(*down, ",") {
+			i, err := strconv.Atoi(s)
+			if err != nil || i < 0 || i >= cfg.n {
+				fmt.Fprintf(os.Stderr, "-down: no replica %q\n", s)
+				os.Exit(2)
+			}
+			cfg.down[i] = true
+		}
+	}
+
+	overlap := "R+W > N: every read quorum overlaps every write quorum"
+	if cfg.r+cfg.w <= cfg.n {
+		overlap = "R+W <= N: a read quorum can miss the last write quorum"
+	}
+	fmt.Printf("N=%d (%d down) R=%d W=%d, %s\n", cfg.n, len(cfg.down), cfg.r, cfg.w, overlap)
+
+	st := run(cfg)
+	total := time.Duration(cfg.clients * cfg.ops)
+	fmt.Printf("writes: %d acknowledged, %d failed, %v on average\n",
+		st.writes.Load(), st.writesFailed.Load(), (time.Duration(st.writeTime.Load()) / total).Round(10*time.Microsecond))
+	fmt.Printf("reads:  %d answered, %d failed, %v on average\n",
+		st.reads.Load(), st.readsFailed.Load(), (time.Duration(st.readTime.Load()) / total).Round(10*time.Microsecond))
+	fmt.Printf("stale reads: %d\n", st.stale.Load())
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lotusirous/gochan/internal/exampletest"
+	"github.com/lotusirous/gochan/internal/sim"
+	"github.com/lotusirous/gochan/scatter"
+)
+
+func TestMain(m *testing.M) { exampletest.Main(m, main) }
+
+func testConfig(r, w int, down ...int) config {
+	cfg := config{
+		n: 3, r: r, w: w, down: map[int]bool{},
+		deadline: 200 * time.Millisecond, clients: 4, ops: 40,
+		net: &sim.Service{Latency: sim.Uniform(0, 5*time.Millisecond)},
+	}
+	for _, i := range down {
+		cfg.down[i] = true
+	}
+	return cfg
+}
+
+func TestOverlappingQuorumsNeverStale(t *testing.T) {
+	st := run(testConfig(2, 2, 0))
+	if st.writesFailed.Load() != 0 || st.readsFailed.Load() != 0 {
+		t.Errorf("%d writes and %d reads failed with one replica down", st.writesFailed.Load(), st.readsFailed.Load())
+	}
+	if n := st.stale.Load(); n != 0 {
+		t.Errorf("%d stale reads with R+W > N", n)
+	}
+}
+
+func TestSmallQuorumsGoStale(t *testing.T) {
+	if n := run(testConfig(1, 1)).stale.Load(); n == 0 {
+		t.Error("no stale reads with R=1 W=1")
+	}
+}
+
+func TestQuorumUnreachable(t *testing.T) {
+	cfg := testConfig(2, 2, 1, 2)
+	cfg.ops = 2
+	st := run(cfg)
+	if st.writes.Load() != 0 || st.reads.Load() != 0 {
+		t.Errorf("%d writes and %d reads succeeded with two of three replicas down", st.writes.Load(), st.reads.Load())
+	}
+}
+
+func TestFlags(t *testing.T) {
+	out := exampletest.Run(t, "-r", "1", "-w", "3", "-ops", "5")
+	if !strings.Contains(out, "R+W > N") || !strings.Contains(out, "stale reads: 0") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+}
+
+func TestReadKeepsNewestVersion(t *testing.T) {
+	answer := func(v versioned, after time.Duration) scatter.Backend[op, versioned] {
+		return scatter.Backend[op, versioned]{Call: func(ctx context.Context, o op) (versioned, error) {
+			time.Sleep(after)
+			return v, nil
+		}}
+	}
+	c := &cluster{cfg: config{r: 2, deadline: time.Second}, replicas: []scatter.Backend[op, versioned]{
+		answer(versioned{"old", 1}, 0),
+		answer(versioned{"new", 2}, 10*time.Millisecond),
+		answer(versioned{"never waited for", 3}, time.Second),
+	}}
+	if v, err := c.read(context.Background(), "k"); err != nil || v.value != "new" {
+		t.Errorf("read = %v, %v; want the newer of the first two answers", v, err)
+	}
+}
@@ -0,0 +1,130 @@
+// A phi accrual failure detector watching supervised workers.
+//
+// A fixed heartbeat timeout is either too short for a worker that is merely
+// slow now and then, or too long for one that hung. A phi accrual detector
+// learns how each worker's heartbeats are spaced and asks how unlikely the
+// current silence is: phi is -log10 of the chance that a healthy worker
+// would be this late. Above -threshold the worker is suspect; if it beats
+// again it is alive once more. Above -convict it is taken for hung: its
+// context is cancelled, and the supervisor restarts it.
+//
+// Four workers beat every -interval in their own way: steady always does,
+// jittery anywhere between half and one and a half intervals, pauser now
+// and then stops for -pause before carrying on, and hanger sooner or later
+// stops for good. Watch pauser get suspected and recover, and hanger get
+// convicted and restarted. Raise -threshold and suspicions get rarer but
+// slower; lower -convict towards it and pauser starts getting restarted
+// too.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/lotusirous/gochan/supervise"
+)
+
+type config struct {
+	interval, pause, duration time.Duration
+	threshold, convict        float64
+}
+
+type counts struct {
+	suspected, recovered, restarted int
+}
+
+// worker returns the body of a worker that beats with the given behaviour.
+func worker(kind string, cfg config) func(ctx context.Context, beat func()) error {
+	return func(ctx context.Context, beat func()) error {
+		hangAfter := 5 + rand.IntN(20) // beats
+		for n := 0; ; n++ {
+			beat()
+			wait := cfg.interval
+			switch kind {
+			case "jittery":
+				wait = cfg.interval/2 + rand.N(cfg.interval)
+			case "pauser":
+				if rand.IntN(15) == 0 {
+					wait = cfg.pause
+				}
+			case "hanger":
+				if n == hangAfter {
+					<-ctx.Done() // stuck, beating no more, until cancelled
+					return ctx.Err()
+				}
+			}
+			select {
+			case <-time.After(wait):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+}
+
+func run(cfg config, w io.Writer) map[string]*counts {
+	start := time.Now()
+	var mu sync.Mutex
+	stats := map[string]*counts{}
+	say := func(name, format string, args ...any) *counts {
+		mu.Lock()
+		defer mu.Unlock()
+		fmt.Fprintf(w, "%7.3fs  %-8s %s\n", time.Since(start).Seconds(), name, fmt.Sprintf(format, args...))
+		return stats[name]
+	}
+
+	m := &supervise.Monitor{
+		Interval:  cfg.interval,
+		Threshold: cfg.threshold,
+		Convict:   cfg.convict,
+		OnChange: func(e supervise.HealthEvent) {
+			if e.Suspect {
+				say(e.Name, "suspect (phi %.1f)", e.Phi).suspected++
+			} else {
+				say(e.Name, "alive again (phi %.1f at its heartbeat)", e.Phi).recovered++
+			}
+		},
+	}
+	s := &supervise.Supervisor{
+		Backoff: func(int) time.Duration { return cfg.interval },
+		OnRestart: func(e supervise.Event) {
+			if errors.Is(e.Err, supervise.ErrSuspected) {
+				say(e.Name, "convicted, restarting: %v", e.Err).restarted++
+			}
+		},
+	}
+
+	var specs []supervise.Spec
+	for _, kind := range []string{"steady", "jittery", "pauser", "hanger"} {
+		stats[kind] = &counts{}
+		specs = append(specs, m.Watch(kind, worker(kind, cfg)))
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.duration)
+	defer cancel()
+	s.Run(ctx, specs...)
+	return stats
+}
+
+func main() {
+	var cfg config
+	flag.DurationVar(&cfg.interval, "interval", 20*time.Millisecond, "how often workers beat")
+	flag.DurationVar(&cfg.pause, "pause", 50*time.Millisecond, "how long pauser stops now and then")
+	flag.DurationVar(&cfg.duration, "duration", 2*time.Second, "how long to run")
+	flag.Float64Var(&cfg.threshold, "threshold", 5, "phi at which a worker becomes suspect")
+	flag.Float64Var(&cfg.convict, "convict", 20, "phi at which a worker is restarted")
+	flag.Parse()
+
+	stats := run(cfg, os.Stdout)
+	fmt.Println()
+	for _, kind := range []string{"steady", "jittery", "pauser", "hanger"} {
+		c := stats[kind]
+		fmt.Printf("%-8s suspected %d times, recovered %d, restarted %d\n", kind, c.suspected, c.recovered, c.restarted)
+	}
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lotusirous/gochan/internal/exampletest"
+)
+
+func TestMain(m *testing.M) { exampletest.Main(m, main) }
+
+func TestHangerRestartedPauserRecovers(t *testing.T) {
+	cfg := config{interval: 10 * time.Millisecond, pause: 30 * time.Millisecond, duration: time.Second, threshold: 5, convict: 1000}
+	stats := run(cfg, io.Discard)
+	// With conviction out of reach nobody is restarted, and the pauser
+	// recovers from every suspicion but the last one at most.
+	if c := stats["pauser"]; c.suspected == 0 || c.recovered < c.suspected-1 || c.restarted != 0 {
+		t.Errorf("pauser %+v", *c)
+	}
+
+	cfg.convict = 20
+	if c := run(cfg, io.Discard)["hanger"]; c.restarted == 0 {
+		t.Errorf("hanger %+v, want restarts", *c)
+	}
+}
+
+func TestOutput(t *testing.T) {
+	out := exampletest.Run(t, "-duration", "1s")
+	if !strings.Contains(out, "hanger   convicted, restarting") || !strings.Contains(out, "steady   suspected") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/lotusirous/gochan/internal/exampletest"
+)
+
+func TestMain(m *testing.M) { exampletest.Main(m, main) }
+
+func TestSequenceIsRestored(t *testing.T) {
+	c := fanIn(boring("Joe"), boring("Ann"))
+	for round := range 2 {
+		msg1, msg2 := <-c, <-c
+		got := []string{msg1.str, msg2.str}
+		slices.Sort(got)
+		want := []string{fmt.Sprint("Ann ", round), fmt.Sprint("Joe ", round)}
+		if !slices.Equal(got, want) {
+			t.Fatalf("round %d: got %v, want %v", round, got, want)
+		}
+		// Nobody may speak twice before both were allowed to go on.
+		msg1.wait <- true
+		msg2.wait <- true
+	}
+}
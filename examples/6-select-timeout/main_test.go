@@ -0,0 +1,26 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/lotusirous/gochan/internal/exampletest"
+)
+
+func TestMain(m *testing.M) { exampletest.Main(m, main) }
+
+func TestBoring(t *testing.T) {
+	if got := <-boring("Joe"); got != "Joe 0" {
+		t.Fatalf("got %q, want Joe 0", got)
+	}
+}
+
+func TestMainTimesOut(t *testing.T) {
+	if testing.Short() {
+		t.Skip("main talks for 5s")
+	}
+	out := exampletest.Run(t)
+	if want := "no response, you talk too slow\n"; !strings.HasSuffix(out, want) {
+		t.Errorf("output does not end with %q:\n%s", want, out)
+	}
+}
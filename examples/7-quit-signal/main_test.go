@@ -0,0 +1,21 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/lotusirous/gochan/internal/exampletest"
+)
+
+func TestMain(m *testing.M) { exampletest.Main(m, main) }
+
+func TestQuitRoundTrip(t *testing.T) {
+	quit := make(chan string)
+	c := boring("Joe", quit)
+	if got := <-c; got != "Joe 0" {
+		t.Fatalf("got %q, want Joe 0", got)
+	}
+	quit <- "Bye"
+	if got := <-quit; got != "See you!" {
+		t.Fatalf("Joe said %q, want See you!", got)
+	}
+}
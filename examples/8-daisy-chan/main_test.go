@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/lotusirous/gochan/internal/exampletest"
+)
+
+func TestMain(m *testing.M) { exampletest.Main(m, main) }
+
+func TestChain(t *testing.T) {
+	for _, n := range []int{0, 1, 1000} {
+		if got := chain(n); got != n+1 {
+			t.Errorf("chain(%d) = %d, want %d", n, got, n+1)
+		}
+	}
+}
+
+func TestMainOutput(t *testing.T) {
+	out := exampletest.Run(t, "-n", "1000")
+	if first, _, _ := strings.Cut(out, "\n"); first != "1001" {
+		t.Errorf("got %q, want 1001 first", out)
+	}
+}
+
+// BenchmarkDaisyChain reports the cost of one hop (start a goroutine, make
+// a channel, pass the value on) in time and heap memory. B/hop does not
+// include the goroutine's initial stack (2 KiB), which lives outside the heap
+// and is reused once the goroutine exits.
+func BenchmarkDaisyChain(b *testing.B) {
+	for _, n := range []int{1_000, 10_000, 100_000} {
+		b.Run(fmt.Sprintf("n=%d", n), func(b *testing.B) {
+			var before, after runtime.MemStats
+			runtime.ReadMemStats(&before)
+			for i := 0; i < b.N; i++ {
+				chain(n)
+			}
+			runtime.ReadMemStats(&after)
+
+			hops := float64(b.N * n)
+			b.ReportMetric(float64(b.Elapsed().Nanoseconds())/hops, "ns/hop")
+			b.ReportMetric(float64(after.TotalAlloc-before.TotalAlloc)/hops, "B/hop")
+		})
+	}
+}
@@ -1,9 +1,13 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
-	"math/rand"
 	"time"
+
+	"github.com/lotusirous/gochan/internal/sim"
+	"github.com/lotusirous/gochan/teach"
 )
 
 type Result string
@@ -15,9 +19,15 @@ var (
 	Video = fakeSearch("video")
 )
 
+// backend is how every search behaves; the -latency and -failures flags
+// change it.
+var backend = sim.Service{Latency: sim.Uniform(0, 100*time.Millisecond)}
+
 func fakeSearch(kind string) Search {
 	return func(query string) Result {
-		time.Sleep(time.Duration(rand.Intn(100)) * time.Millisecond)
+		if err := backend.Call(context.Background()); err != nil {
+			return Result(fmt.Sprintf("%s failed: %v\n", kind, err))
+		}
 		return Result(fmt.Sprintf("%s result for %q\n", kind, query))
 	}
 }
@@ -31,6 +41,13 @@ func Google(query string) (results []Result) {
 }
 
 func main() {
+	// patterns compare measures this sequential baseline against the
+	// concurrent versions through the stats teach reports on exit.
+	defer teach.Start()()
+
+	backend.AddFlags(flag.CommandLine, "")
+	flag.Parse()
+
 	start := time.Now()
 	results := Google("golang")
 	elapsed := time.Since(start)
@@ -223,6 +223,45 @@ func TestQuitSignalPattern(t *testing.T) {
 	}
 }
 
+// Test the context-based quit pattern (example 20)
+func TestContextQuitPattern(t *testing.T) {
+	boring := func(ctx context.Context, msg string) <-chan string {
+		ch := make(chan string)
+		go func() {
+			defer close(ch)
+			for i := 0; ; i++ {
+				select {
+				case ch <- fmt.Sprintf("%s %d", msg, i):
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+		return ch
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := boring(ctx, "Ann")
+	for i := 0; i < 3; i++ {
+		if msg := <-ch; msg != fmt.Sprintf("Ann %d", i) {
+			t.Errorf("got %q", msg)
+		}
+	}
+	cancel()
+
+	timeout := time.After(100 * time.Millisecond)
+	for {
+		select {
+		case _, ok := <-ch:
+			if !ok {
+				return
+			}
+		case <-timeout:
+			t.Fatal("Channel did not close after cancel")
+		}
+	}
+}
+
 // Test the context pattern (example 16)
 func TestContextPattern(t *testing.T) {
 	sleepAndTalk := func(ctx context.Context, d time.Duration, msg string) error {
@@ -265,55 +304,56 @@ func TestContextPattern(t *testing.T) {
 	})
 }
 
-// Test the ping-pong pattern (example 13)
+// Test the ping-pong pattern (example 13). Only the referee ends the game,
+// by cancelling a context: a player that closed the shared table itself would
+// race with the other player sending on it (see example 27); chanutil's
+// tests play the game where both players close a CloseOnce table.
 func TestPingPongPattern(t *testing.T) {
 	type Ball struct{ hits int }
-	
-	player := func(name string, table chan *Ball, maxHits int, done chan bool) {
+	const maxHits = 10
+
+	ctx, cancel := context.WithCancel(context.Background())
+	table := make(chan *Ball)
+	over := make(chan *Ball, 1)
+	var wg sync.WaitGroup
+
+	player := func() {
+		defer wg.Done()
 		for {
+			var ball *Ball
 			select {
-			case ball, ok := <-table:
-				if !ok {
-					done <- true
-					return
-				}
-				ball.hits++
-				if ball.hits >= maxHits {
-					close(table)
-					done <- true
-					return
-				}
-				table <- ball
-			case <-time.After(100 * time.Millisecond):
-				// Timeout to prevent infinite waiting
-				done <- true
+			case ball = <-table:
+			case <-ctx.Done():
+				return
+			}
+			ball.hits++
+			out := table
+			if ball.hits >= maxHits {
+				out = over
+			}
+			select {
+			case out <- ball:
+			case <-ctx.Done():
 				return
 			}
 		}
 	}
-	
-	table := make(chan *Ball, 1)
-	done := make(chan bool, 2)
-	
-	go player("ping", table, 10, done)
-	go player("pong", table, 10, done)
-	
+
+	wg.Add(2)
+	go player()
+	go player()
 	table <- &Ball{}
-	
-	// Wait for one player to finish or timeout
-	select {
-	case <-done:
-		// Game finished
-	case <-time.After(200 * time.Millisecond):
-		// Test timeout
-		close(table)
-	}
-	
-	// Drain the done channel
+
 	select {
-	case <-done:
-	default:
+	case ball := <-over:
+		if ball.hits != maxHits {
+			t.Errorf("game ended after %d hits, want %d", ball.hits, maxHits)
+		}
+	case <-time.After(time.Second):
+		t.Error("game did not finish")
 	}
+	cancel()
+	wg.Wait()
 }
 
 // Test worker pool pattern with bounded parallelism (example 18)
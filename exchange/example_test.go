@@ -0,0 +1,20 @@
+package exchange_test
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lotusirous/gochan/exchange"
+)
+
+func Example() {
+	ctx := context.Background()
+	p := exchange.New[[]int, []int]()
+
+	// A filler hands over a full buffer and gets an empty one back.
+	go p.ExchangeA(ctx, []int{1, 2, 3})
+
+	full, _ := p.ExchangeB(ctx, make([]int, 0, 3))
+	fmt.Println(full)
+	// Output: [1 2 3]
+}
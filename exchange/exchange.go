@@ -0,0 +1,59 @@
+// Package exchange provides a rendezvous where two goroutines meet and
+// swap values, like Java's Exchanger.
+//
+// An unbuffered channel is a rendezvous that moves a value one way. A Point
+// moves one each way in the same meeting: either both goroutines leave
+// with the other's value or, if one gives up first, neither does. The
+// classic use is double buffering, where a producer hands over a full
+// buffer and gets back the empty one the consumer just finished with, so
+// the two buffers are reused without any other synchronization.
+package exchange
+
+import "context"
+
+type offer[A, B any] struct {
+	a     A
+	reply chan B
+}
+
+// Point is a meeting place for one goroutine holding an A and one holding
+// a B. Any number of goroutines may use each side; each exchange pairs one
+// from either side. The zero value is not usable; call New.
+type Point[A, B any] struct {
+	offers chan offer[A, B]
+}
+
+// New returns a Point.
+func New[A, B any]() *Point[A, B] {
+	return &Point[A, B]{offers: make(chan offer[A, B])}
+}
+
+// ExchangeA waits for a goroutine calling ExchangeB, gives it a and returns
+// its value. If ctx is done before they meet, it returns ctx.Err() and a
+// is not handed over.
+func (p *Point[A, B]) ExchangeA(ctx context.Context, a A) (B, error) {
+	o := offer[A, B]{a: a, reply: make(chan B, 1)}
+	select {
+	case p.offers <- o:
+	case <-ctx.Done():
+		var zero B
+		return zero, ctx.Err()
+	}
+	// The other side took a, so the exchange has happened; its value
+	// follows at once, whatever ctx does now.
+	return <-o.reply, nil
+}
+
+// ExchangeB waits for a goroutine calling ExchangeA, gives it b and returns
+// its value. If ctx is done before they meet, it returns ctx.Err() and b
+// is not handed over.
+func (p *Point[A, B]) ExchangeB(ctx context.Context, b B) (A, error) {
+	select {
+	case o := <-p.offers:
+		o.reply <- b // buffered: never blocks
+		return o.a, nil
+	case <-ctx.Done():
+		var zero A
+		return zero, ctx.Err()
+	}
+}
@@ -0,0 +1,70 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestExchange(t *testing.T) {
+	p := New[string, int]()
+	got := make(chan string, 1)
+	go func() {
+		s, err := p.ExchangeB(context.Background(), 42)
+		if err != nil {
+			t.Error(err)
+		}
+		got <- s
+	}()
+	n, err := p.ExchangeA(context.Background(), "hello")
+	if n != 42 || err != nil {
+		t.Errorf("ExchangeA = %d, %v; want 42, nil", n, err)
+	}
+	if s := <-got; s != "hello" {
+		t.Errorf("ExchangeB = %q, want hello", s)
+	}
+}
+
+func TestExchangeTimesOut(t *testing.T) {
+	p := New[int, int]()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	if _, err := p.ExchangeA(ctx, 1); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("ExchangeA = %v, want deadline exceeded", err)
+	}
+	if _, err := p.ExchangeB(ctx, 1); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("ExchangeB = %v, want deadline exceeded", err)
+	}
+
+	// A value offered by a side that gave up is not handed to the next one.
+	go func() {
+		time.Sleep(5 * time.Millisecond)
+		p.ExchangeA(context.Background(), 2)
+	}()
+	if v, err := p.ExchangeB(context.Background(), 0); v != 2 || err != nil {
+		t.Errorf("ExchangeB = %d, %v; want 2 from the later offer", v, err)
+	}
+}
+
+// TestExchangePairs checks that with many goroutines on each side, every
+// exchange is a swap between exactly two of them.
+func TestExchangePairs(t *testing.T) {
+	const n = 100
+	p := New[int, int]()
+	gotA, gotB := make([]int, n), make([]int, n)
+	var wg sync.WaitGroup
+	for i := range n {
+		wg.Add(2)
+		go func() { defer wg.Done(); gotA[i], _ = p.ExchangeA(context.Background(), i) }()
+		go func() { defer wg.Done(); gotB[i], _ = p.ExchangeB(context.Background(), n+i) }()
+	}
+	wg.Wait()
+	for i, b := range gotA {
+		// a got b's value n+j, so b must have got a's value i
+		if j := b - n; gotB[j] != i {
+			t.Errorf("A%d got B%d's value, but B%d got %d", i, j, j, gotB[j])
+		}
+	}
+}
@@ -0,0 +1,23 @@
+// Package bounded is an exercise: bounded parallelism.
+//
+// Implement Map, then check it with
+//
+//	go run ./cmd/patterns verify bounded
+//
+// Example 15 (bounded-parallelism) shows one way to cap the number of
+// goroutines doing work.
+package bounded
+
+import "context"
+
+// Map calls fn on every element of in and returns the results in the
+// order of in.
+//
+//   - At most n calls of fn run at the same time, and with enough work n
+//     of them do.
+//   - If a call fails, Map cancels the context passed to the calls still
+//     running, starts no new ones and returns the first error.
+//   - Map leaves no goroutine behind when it returns.
+func Map[In, Out any](ctx context.Context, in []In, n int, fn func(ctx context.Context, v In) (Out, error)) ([]Out, error) {
+	panic("TODO: implement Map")
+}
@@ -0,0 +1,21 @@
+// Package fanin is an exercise: merge several channels into one.
+//
+// Implement FanIn, then check it with
+//
+//	go run ./cmd/patterns verify fanin
+//
+// Example 4 (fanin) shows the idea, but its goroutines run forever; here
+// they must stop.
+package fanin
+
+import "context"
+
+// FanIn returns a channel that delivers every value received from ins.
+//
+//   - A slow or blocked input must not hold up the others.
+//   - The returned channel is closed once every input is closed.
+//   - When ctx is done, FanIn stops, closes the returned channel and
+//     leaves no goroutine behind, even if inputs are still open.
+func FanIn[T any](ctx context.Context, ins ...<-chan T) <-chan T {
+	panic("TODO: implement FanIn")
+}
@@ -0,0 +1,25 @@
+// Package first is an exercise: replicated requests.
+//
+// Implement First, then check it with
+//
+//	go run ./cmd/patterns verify first
+//
+// Example 12 (google3.0) sends a query to several replicas and keeps the
+// fastest answer.
+package first
+
+import "context"
+
+// First calls every replica at the same time and returns the first
+// successful result.
+//
+//   - Once a replica succeeds, the context passed to the others is
+//     cancelled and First returns without waiting for them.
+//   - If every replica fails, First returns all of their errors joined
+//     with errors.Join.
+//   - If ctx is done first, First returns ctx.Err().
+//   - The goroutines First starts all exit, even the ones it did not wait
+//     for.
+func First[T any](ctx context.Context, replicas ...func(ctx context.Context) (T, error)) (T, error) {
+	panic("TODO: implement First")
+}
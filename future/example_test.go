@@ -0,0 +1,32 @@
+package future_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/lotusirous/gochan/future"
+)
+
+func ExampleAll() {
+	ctx := context.Background()
+	square := func(n int) *future.Future[int] {
+		return future.Go(ctx, func(context.Context) (int, error) { return n * n, nil })
+	}
+
+	values, err := future.All(ctx, square(1), square(2), square(3))
+	fmt.Println(values, err)
+	// Output: [1 4 9] <nil>
+}
+
+func ExampleAny() {
+	ctx := context.Background()
+	down := future.Go(ctx, func(context.Context) (string, error) {
+		return "", errors.New("primary: connection refused")
+	})
+	up := future.Go(ctx, func(context.Context) (string, error) { return "replica", nil })
+
+	v, err := future.Any(ctx, down, up)
+	fmt.Println(v, err)
+	// Output: replica <nil>
+}
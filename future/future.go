@@ -0,0 +1,134 @@
+// Package future runs a function in the background and lets callers wait for
+// its result later, alone or in groups.
+//
+// The combinators mirror the search examples from the talk: All waits for
+// every replica, Any takes the first successful response and Race takes the
+// first response of any kind.
+package future
+
+import (
+	"context"
+	"errors"
+
+	"github.com/lotusirous/gochan/result"
+)
+
+// ErrEmpty is returned by Any and Race when called without futures.
+var ErrEmpty = errors.New("future: no futures")
+
+// Future is the eventual result of a function running in its own goroutine.
+type Future[T any] struct {
+	done chan struct{}
+	r    result.Result[T]
+}
+
+// Go runs fn in a new goroutine and returns its Future. fn receives ctx and
+// should return when ctx is done.
+func Go[T any](ctx context.Context, fn func(ctx context.Context) (T, error)) *Future[T] {
+	f := &Future[T]{done: make(chan struct{})}
+	go func() {
+		defer close(f.done)
+		f.r = result.Of(fn(ctx))
+	}()
+	return f
+}
+
+// Done returns a channel that is closed once the result is available.
+func (f *Future[T]) Done() <-chan struct{} { return f.done }
+
+// Get waits for the result. It returns ctx.Err() if ctx is done first; the
+// function keeps running and a later Get can still collect its result.
+func (f *Future[T]) Get(ctx context.Context) (T, error) {
+	select {
+	case <-f.done:
+		return f.r.Unwrap()
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	}
+}
+
+// All waits for every future and returns their values in the same order. It
+// returns the first error it sees without waiting for the rest.
+func All[T any](ctx context.Context, futures ...*Future[T]) ([]T, error) {
+	stop := make(chan struct{})
+	defer close(stop)
+
+	out := make([]T, len(futures))
+	c := completed(futures, stop)
+	for range futures {
+		select {
+		case i := <-c:
+			f := futures[i]
+			if f.r.Err != nil {
+				return nil, f.r.Err
+			}
+			out[i] = f.r.Value
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	return out, nil
+}
+
+// Any returns the value of the first future that succeeds. If every future
+// fails it returns all their errors joined together.
+func Any[T any](ctx context.Context, futures ...*Future[T]) (T, error) {
+	var zero T
+	if len(futures) == 0 {
+		return zero, ErrEmpty
+	}
+	stop := make(chan struct{})
+	defer close(stop)
+
+	var errs []error
+	c := completed(futures, stop)
+	for range futures {
+		select {
+		case i := <-c:
+			f := futures[i]
+			if f.r.Err == nil {
+				return f.r.Value, nil
+			}
+			errs = append(errs, f.r.Err)
+		case <-ctx.Done():
+			return zero, ctx.Err()
+		}
+	}
+	return zero, errors.Join(errs...)
+}
+
+// Race returns the result of the first future to finish, whether it
+// succeeded or not.
+func Race[T any](ctx context.Context, futures ...*Future[T]) (T, error) {
+	var zero T
+	if len(futures) == 0 {
+		return zero, ErrEmpty
+	}
+	stop := make(chan struct{})
+	defer close(stop)
+
+	select {
+	case i := <-completed(futures, stop):
+		return futures[i].r.Unwrap()
+	case <-ctx.Done():
+		return zero, ctx.Err()
+	}
+}
+
+// completed delivers the positions of futures in the order they finish,
+// once per position even if a future is listed twice. Closing stop releases
+// the goroutines still waiting for slow futures.
+func completed[T any](futures []*Future[T], stop <-chan struct{}) <-chan int {
+	c := make(chan int, len(futures))
+	for i, f := range futures {
+		go func() {
+			select {
+			case <-f.done:
+				c <- i
+			case <-stop:
+			}
+		}()
+	}
+	return c
+}
@@ -0,0 +1,112 @@
+package future
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+var errBoom = errors.New("boom")
+
+// after returns a function that yields v (or err) after d, or ctx.Err().
+func after[T any](d time.Duration, v T, err error) func(context.Context) (T, error) {
+	return func(ctx context.Context) (T, error) {
+		select {
+		case <-time.After(d):
+			return v, err
+		case <-ctx.Done():
+			var zero T
+			return zero, ctx.Err()
+		}
+	}
+}
+
+func TestGet(t *testing.T) {
+	ctx := t.Context()
+	f := Go(ctx, after(time.Millisecond, 42, nil))
+	if v, err := f.Get(ctx); v != 42 || err != nil {
+		t.Fatalf("Get = %v, %v", v, err)
+	}
+
+	slow := Go(ctx, after(time.Hour, 0, nil))
+	short, cancel := context.WithTimeout(ctx, 5*time.Millisecond)
+	defer cancel()
+	if _, err := slow.Get(short); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Get on slow future = %v, want deadline exceeded", err)
+	}
+}
+
+func TestAll(t *testing.T) {
+	ctx := t.Context()
+	got, err := All(ctx,
+		Go(ctx, after(20*time.Millisecond, "web", nil)),
+		Go(ctx, after(1*time.Millisecond, "image", nil)),
+		Go(ctx, after(10*time.Millisecond, "video", nil)),
+	)
+	if err != nil || !slices.Equal(got, []string{"web", "image", "video"}) {
+		t.Fatalf("All = %v, %v", got, err)
+	}
+
+	start := time.Now()
+	_, err = All(ctx,
+		Go(ctx, after(time.Hour, "slow", nil)),
+		Go(ctx, after(time.Millisecond, "", errBoom)),
+	)
+	if err != errBoom || time.Since(start) > time.Second {
+		t.Fatalf("All = %v after %v, want %v right away", err, time.Since(start), errBoom)
+	}
+}
+
+func TestAllDuplicateFutures(t *testing.T) {
+	ctx := t.Context()
+	web := Go(ctx, after(time.Millisecond, "web", nil))
+	image := Go(ctx, after(time.Millisecond, "image", nil))
+	got, err := All(ctx, web, image, web)
+	if err != nil || !slices.Equal(got, []string{"web", "image", "web"}) {
+		t.Fatalf("All = %q, %v", got, err)
+	}
+}
+
+func TestAny(t *testing.T) {
+	ctx := t.Context()
+	got, err := Any(ctx,
+		Go(ctx, after(time.Millisecond, "", errBoom)),
+		Go(ctx, after(5*time.Millisecond, "replica2", nil)),
+		Go(ctx, after(time.Hour, "replica3", nil)),
+	)
+	if err != nil || got != "replica2" {
+		t.Fatalf("Any = %q, %v", got, err)
+	}
+
+	errOther := errors.New("other")
+	_, err = Any(ctx,
+		Go(ctx, after(time.Millisecond, 0, errBoom)),
+		Go(ctx, after(time.Millisecond, 0, errOther)),
+	)
+	if !errors.Is(err, errBoom) || !errors.Is(err, errOther) {
+		t.Fatalf("Any error = %v, want both errors", err)
+	}
+
+	if _, err := Any[int](ctx); err != ErrEmpty {
+		t.Fatalf("Any() = %v, want ErrEmpty", err)
+	}
+}
+
+func TestRace(t *testing.T) {
+	ctx := t.Context()
+	_, err := Race(ctx,
+		Go(ctx, after(time.Millisecond, "", errBoom)),
+		Go(ctx, after(time.Hour, "slow", nil)),
+	)
+	if err != errBoom {
+		t.Fatalf("Race = %v, want %v", err, errBoom)
+	}
+
+	short, cancel := context.WithTimeout(ctx, 5*time.Millisecond)
+	defer cancel()
+	if _, err := Race(short, Go(ctx, after(time.Hour, 0, nil))); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Race = %v, want deadline exceeded", err)
+	}
+}
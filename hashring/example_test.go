@@ -0,0 +1,23 @@
+package hashring_test
+
+import (
+	"fmt"
+
+	"github.com/lotusirous/gochan/hashring"
+)
+
+func Example() {
+	ring := hashring.New[string, string](0, "cache-a", "cache-b", "cache-c")
+	before, _ := ring.Get("user:42")
+
+	// Removing another node leaves the key where it was.
+	for _, n := range ring.Nodes() {
+		if n != before {
+			ring.Remove(n)
+			break
+		}
+	}
+	after, _ := ring.Get("user:42")
+	fmt.Println(before == after)
+	// Output: true
+}
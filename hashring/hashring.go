@@ -0,0 +1,88 @@
+// Package hashring assigns keys to nodes with consistent hashing, so that
+// adding or removing a node moves only the keys it takes over or gives up.
+//
+// Hashing a key modulo the number of nodes reassigns almost every key when
+// that number changes. A ring instead places every node at many pseudo-
+// random points on a circle of hash values, and a key belongs to the first
+// node point at or after the key's hash. A new node takes over the arcs in
+// front of its points, about 1/n of the keys, all from other nodes and none
+// shuffled among them; a removed node hands its arcs to its successors.
+// The many points per node, called virtual nodes, even out the arc lengths.
+package hashring
+
+import (
+	"cmp"
+	"hash/maphash"
+	"slices"
+)
+
+// DefaultReplicas is the number of points per node New uses when given 0.
+const DefaultReplicas = 100
+
+// Ring maps keys of type K to nodes of type N. It is not safe for
+// concurrent use.
+type Ring[K, N comparable] struct {
+	replicas int
+	seed     maphash.Seed
+	points   []point[N] // sorted by hash
+	nodes    []N        // in the order they were added
+}
+
+type point[N comparable] struct {
+	hash uint64
+	node N
+}
+
+// vnode is what a node's points are hashed from.
+type vnode[N comparable] struct {
+	node N
+	i    int
+}
+
+// New returns a ring with replicas points per node, or DefaultReplicas if
+// replicas is 0, holding nodes.
+func New[K, N comparable](replicas int, nodes ...N) *Ring[K, N] {
+	if replicas <= 0 {
+		replicas = DefaultReplicas
+	}
+	r := &Ring[K, N]{replicas: replicas, seed: maphash.MakeSeed()}
+	r.Add(nodes...)
+	return r
+}
+
+// Add adds nodes. Nodes already on the ring are ignored.
+func (r *Ring[K, N]) Add(nodes ...N) {
+	for _, n := range nodes {
+		if slices.Contains(r.nodes, n) {
+			continue
+		}
+		r.nodes = append(r.nodes, n)
+		for i := range r.replicas {
+			r.points = append(r.points, point[N]{maphash.Comparable(r.seed, vnode[N]{n, i}), n})
+		}
+	}
+	slices.SortFunc(r.points, func(a, b point[N]) int { return cmp.Compare(a.hash, b.hash) })
+}
+
+// Remove removes nodes. Nodes not on the ring are ignored.
+func (r *Ring[K, N]) Remove(nodes ...N) {
+	r.nodes = slices.DeleteFunc(r.nodes, func(n N) bool { return slices.Contains(nodes, n) })
+	r.points = slices.DeleteFunc(r.points, func(p point[N]) bool { return slices.Contains(nodes, p.node) })
+}
+
+// Get returns the node key belongs to, or false if the ring is empty.
+func (r *Ring[K, N]) Get(key K) (N, bool) {
+	if len(r.points) == 0 {
+		var zero N
+		return zero, false
+	}
+	h := maphash.Comparable(r.seed, key)
+	i, _ := slices.BinarySearchFunc(r.points, h, func(p point[N], h uint64) int { return cmp.Compare(p.hash, h) })
+	if i == len(r.points) {
+		i = 0 // past the last point: wrap around to the first
+	}
+	return r.points[i].node, true
+}
+
+// Nodes returns the nodes in the order they were added.
+func (r *Ring[K, N]) Nodes() []N { return slices.Clone(r.nodes) }
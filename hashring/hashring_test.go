@@ -0,0 +1,92 @@
+package hashring
+
+import (
+	"fmt"
+	"testing"
+)
+
+const keys = 100000
+
+// assign returns the node of every key.
+func assign(r *Ring[string, string]) map[string]string {
+	m := make(map[string]string, keys)
+	for i := range keys {
+		k := fmt.Sprint("key-", i)
+		m[k], _ = r.Get(k)
+	}
+	return m
+}
+
+func nodeNames(n int) []string {
+	var names []string
+	for i := range n {
+		names = append(names, fmt.Sprint("node-", i))
+	}
+	return names
+}
+
+func TestAddMovesOnlyToNewNode(t *testing.T) {
+	r := New[string](0, nodeNames(10)...)
+	before := assign(r)
+	r.Add("node-10")
+	moved := 0
+	for k, n := range assign(r) {
+		if n == before[k] {
+			continue
+		}
+		moved++
+		if n != "node-10" {
+			t.Fatalf("%s moved from %s to %s, not to the new node", k, before[k], n)
+		}
+	}
+	// The new node should take about 1/11 of the keys, where hashing
+	// modulo the node count would have moved 10/11 of them.
+	frac := float64(moved) / keys
+	t.Logf("adding an 11th node moved %.1f%% of the keys", 100*frac)
+	if frac < 0.5/11 || frac > 2.0/11 {
+		t.Errorf("adding an 11th node moved %.1f%% of the keys, want about %.1f%%", 100*frac, 100.0/11)
+	}
+}
+
+func TestRemoveMovesOnlyItsKeys(t *testing.T) {
+	r := New[string](0, nodeNames(10)...)
+	before := assign(r)
+	r.Remove("node-3")
+	for k, n := range assign(r) {
+		if n == "node-3" {
+			t.Fatalf("%s still on the removed node", k)
+		}
+		if before[k] != "node-3" && n != before[k] {
+			t.Fatalf("%s moved from %s to %s, though its node stayed", k, before[k], n)
+		}
+	}
+}
+
+func TestBalance(t *testing.T) {
+	r := New[string](0, nodeNames(10)...)
+	counts := map[string]int{}
+	for _, n := range assign(r) {
+		counts[n]++
+	}
+	for n, c := range counts {
+		if c < keys/10/2 || c > keys/10*2 {
+			t.Errorf("%s holds %d keys, want about %d", n, c, keys/10)
+		}
+	}
+}
+
+func TestEmptyAndDuplicates(t *testing.T) {
+	r := New[int, string](3)
+	if _, ok := r.Get(1); ok {
+		t.Error("Get on an empty ring succeeded")
+	}
+	r.Add("a", "a", "b")
+	r.Add("a")
+	if got := r.Nodes(); len(got) != 2 || len(r.points) != 6 {
+		t.Errorf("nodes %v with %d points, want [a b] with 6", got, len(r.points))
+	}
+	r.Remove("a", "b", "c")
+	if _, ok := r.Get(1); ok || len(r.points) != 0 {
+		t.Error("ring not empty after removing every node")
+	}
+}
@@ -0,0 +1,142 @@
+// Package dlock defines a lock shared by processes that do not share
+// memory, such as replicas of a service, and an in-memory implementation of
+// it for tests and simulations.
+//
+// A distributed lock is a lease: it expires after a time to live unless its
+// holder renews it, so that a crashed holder cannot keep it forever. The
+// price is that a holder that stalls, in a long GC pause or on a frozen VM,
+// can lose the lock without noticing and carry on as if it still held it.
+// Every acquisition therefore comes with a fencing token, a number that
+// grows with every acquisition. The holder passes it along with every write
+// to the protected resource, and the resource rejects writes carrying a
+// lower token than one it has already seen, which shuts out the stalled
+// holder once a newer one has written.
+package dlock
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/lotusirous/gochan/clock"
+)
+
+// ErrNotHeld is returned by Renew and Release when the lease has expired,
+// or was released, and so is no longer held.
+var ErrNotHeld = errors.New("dlock: lease not held")
+
+// Lease is a held lock.
+type Lease struct {
+	Key     string
+	Token   uint64    // fencing token, larger than that of any earlier lease
+	Expires time.Time // when the lock is free for others unless renewed
+}
+
+// Locker grants leases on keys.
+type Locker interface {
+	// Acquire waits until key is free, or its lease has expired, and
+	// leases it for ttl. It returns ctx.Err() if ctx is done first.
+	Acquire(ctx context.Context, key string, ttl time.Duration) (Lease, error)
+	// Renew extends l to expire ttl from now, and returns the extended
+	// lease. It returns ErrNotHeld if l has expired.
+	Renew(ctx context.Context, l Lease, ttl time.Duration) (Lease, error)
+	// Release frees l's key. It returns ErrNotHeld if l has expired, in
+	// which case the key may already belong to someone else.
+	Release(ctx context.Context, l Lease) error
+}
+
+// Memory is a Locker for goroutines of one process, telling time with a
+// clock.Clock so that expiry can be tested with a clock.Fake.
+type Memory struct {
+	clock clock.Clock
+
+	mu    sync.Mutex
+	token uint64
+	held  map[string]*lease
+}
+
+type lease struct {
+	Lease
+	released chan struct{} // closed on Release
+}
+
+var _ Locker = (*Memory)(nil)
+
+// NewMemory returns an in-memory Locker using clk, or clock.Real if nil.
+func NewMemory(clk clock.Clock) *Memory {
+	if clk == nil {
+		clk = clock.Real
+	}
+	return &Memory{clock: clk, held: make(map[string]*lease)}
+}
+
+// Acquire implements Locker.
+func (m *Memory) Acquire(ctx context.Context, key string, ttl time.Duration) (Lease, error) {
+	var expired clock.Timer // made on the first wait, reset for the next
+	defer func() {
+		if expired != nil {
+			expired.Stop()
+		}
+	}()
+	for {
+		m.mu.Lock()
+		now := m.clock.Now()
+		h, ok := m.held[key]
+		if !ok || !now.Before(h.Expires) {
+			m.token++
+			l := &lease{Lease{key, m.token, now.Add(ttl)}, make(chan struct{})}
+			m.held[key] = l
+			m.mu.Unlock()
+			return l.Lease, nil
+		}
+		released, expiry := h.released, h.Expires.Sub(now)
+		m.mu.Unlock()
+
+		if expired == nil {
+			expired = m.clock.NewTimer(expiry)
+		} else {
+			expired.Reset(expiry)
+		}
+		select {
+		case <-released:
+		case <-expired.C():
+		case <-ctx.Done():
+			return Lease{}, ctx.Err()
+		}
+	}
+}
+
+// current returns the entry of l if l is still held.
+func (m *Memory) current(l Lease) (*lease, bool) {
+	h, ok := m.held[l.Key]
+	if !ok || h.Token != l.Token || !m.clock.Now().Before(h.Expires) {
+		return nil, false
+	}
+	return h, true
+}
+
+// Renew implements Locker.
+func (m *Memory) Renew(ctx context.Context, l Lease, ttl time.Duration) (Lease, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	h, ok := m.current(l)
+	if !ok {
+		return Lease{}, ErrNotHeld
+	}
+	h.Expires = m.clock.Now().Add(ttl)
+	return h.Lease, nil
+}
+
+// Release implements Locker.
+func (m *Memory) Release(ctx context.Context, l Lease) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	h, ok := m.current(l)
+	if !ok {
+		return ErrNotHeld
+	}
+	delete(m.held, l.Key)
+	close(h.released)
+	return nil
+}
@@ -0,0 +1,151 @@
+package dlock
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lotusirous/gochan/clock"
+)
+
+var epoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// acquireAsync acquires key in a goroutine and delivers the lease.
+func acquireAsync(m *Memory, key string, ttl time.Duration) <-chan Lease {
+	c := make(chan Lease, 1)
+	go func() {
+		l, err := m.Acquire(context.Background(), key, ttl)
+		if err == nil {
+			c <- l
+		}
+	}()
+	return c
+}
+
+func TestReleaseHandsOver(t *testing.T) {
+	clk := clock.NewFake(epoch)
+	m := NewMemory(clk)
+	ctx := context.Background()
+	first, err := m.Acquire(ctx, "k", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other, err := m.Acquire(ctx, "other", time.Minute); err != nil || other.Token <= first.Token {
+		t.Errorf("lease on another key = %+v, %v", other, err)
+	}
+
+	next := acquireAsync(m, "k", time.Minute)
+	clk.BlockUntil(1)
+	select {
+	case l := <-next:
+		t.Fatalf("acquired %+v while the key was held", l)
+	default:
+	}
+	if err := m.Release(ctx, first); err != nil {
+		t.Fatal(err)
+	}
+	if l := <-next; l.Token <= first.Token {
+		t.Errorf("token %d after %d, want it to grow", l.Token, first.Token)
+	}
+	if n := clk.Waiters(); n != 0 {
+		t.Errorf("%d timers left on the clock after the handover, want 0", n)
+	}
+	if err := m.Release(ctx, first); !errors.Is(err, ErrNotHeld) {
+		t.Errorf("second Release = %v, want ErrNotHeld", err)
+	}
+}
+
+func TestExpiry(t *testing.T) {
+	clk := clock.NewFake(epoch)
+	m := NewMemory(clk)
+	ctx := context.Background()
+	stalled, _ := m.Acquire(ctx, "k", 10*time.Second)
+
+	next := acquireAsync(m, "k", 10*time.Second)
+	clk.BlockUntil(1)
+	clk.Advance(10 * time.Second)
+	l := <-next
+	if l.Token <= stalled.Token || !l.Expires.Equal(epoch.Add(20*time.Second)) {
+		t.Errorf("lease after expiry = %+v", l)
+	}
+	if _, err := m.Renew(ctx, stalled, time.Minute); !errors.Is(err, ErrNotHeld) {
+		t.Errorf("Renew of an expired lease = %v, want ErrNotHeld", err)
+	}
+	if err := m.Release(ctx, stalled); !errors.Is(err, ErrNotHeld) {
+		t.Errorf("Release of an expired lease = %v, want ErrNotHeld", err)
+	}
+	if err := m.Release(ctx, l); err != nil {
+		t.Errorf("the new holder's Release = %v", err)
+	}
+}
+
+func TestRenewKeepsLease(t *testing.T) {
+	clk := clock.NewFake(epoch)
+	m := NewMemory(clk)
+	ctx := context.Background()
+	l, _ := m.Acquire(ctx, "k", 10*time.Second)
+	next := acquireAsync(m, "k", 10*time.Second)
+	for range 3 {
+		clk.BlockUntil(1)
+		clk.Advance(8 * time.Second)
+		var err error
+		if l, err = m.Renew(ctx, l, 10*time.Second); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The waiter woke at the old expiry, found the lease renewed and is
+	// waiting again.
+	clk.BlockUntil(1)
+	select {
+	case got := <-next:
+		t.Fatalf("acquired %+v from a renewed lease", got)
+	default:
+	}
+	clk.Advance(10 * time.Second)
+	<-next
+}
+
+func TestAcquireHonorsContext(t *testing.T) {
+	m := NewMemory(clock.NewFake(epoch))
+	m.Acquire(context.Background(), "k", time.Minute)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := m.Acquire(ctx, "k", time.Minute); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Acquire = %v, want DeadlineExceeded", err)
+	}
+}
+
+func TestMutualExclusion(t *testing.T) {
+	m := NewMemory(nil)
+	var mu sync.Mutex // only to detect overlap, never contended if dlock works
+	var wg sync.WaitGroup
+	count := 0
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 50 {
+				l, err := m.Acquire(context.Background(), "k", time.Minute)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if mu.TryLock() {
+					count++
+					mu.Unlock()
+				} else {
+					t.Error("two holders at once")
+				}
+				if err := m.Release(context.Background(), l); err != nil {
+					t.Error(err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if count != 400 {
+		t.Errorf("count = %d, want 400", count)
+	}
+}
@@ -0,0 +1,28 @@
+package dlock_test
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/lotusirous/gochan/clock"
+	"github.com/lotusirous/gochan/internal/dlock"
+)
+
+func Example() {
+	ctx := context.Background()
+	clk := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	locks := dlock.NewMemory(clk)
+
+	old, _ := locks.Acquire(ctx, "report", time.Minute)
+
+	// The holder stalls past its lease, and another worker takes over with
+	// a larger fencing token.
+	clk.Advance(2 * time.Minute)
+	fresh, _ := locks.Acquire(ctx, "report", time.Minute)
+	fmt.Println(old.Token < fresh.Token)
+	fmt.Println(locks.Release(ctx, old))
+	// Output:
+	// true
+	// dlock: lease not held
+}
@@ -0,0 +1,48 @@
+// Package exampletest lets the tests of an example check what its main
+// function prints.
+//
+// The examples print with fmt and log and often leave goroutines running, so
+// swapping os.Stdout inside the test process is racy. Instead, the test
+// binary starts a copy of itself that runs main and the test inspects the
+// copy's output.
+package exampletest
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+)
+
+const env = "EXAMPLETEST_RUN_MAIN"
+
+// Main replaces TestMain in an example package:
+//
+//	func TestMain(m *testing.M) { exampletest.Main(m, main) }
+//
+// In the child process started by Run it calls main instead of the tests.
+func Main(m *testing.M, main func()) {
+	if os.Getenv(env) == "1" {
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// Run runs the example's main with args and returns everything it wrote to
+// stdout and stderr. The test fails if main exits with an error or takes
+// longer than a minute.
+func Run(t testing.TB, args ...string) string {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(t.Context(), time.Minute)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, os.Args[0], args...)
+	cmd.Env = append(os.Environ(), env+"=1")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("running main: %v\n%s", err, out)
+	}
+	return string(out)
+}
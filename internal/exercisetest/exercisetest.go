@@ -0,0 +1,49 @@
+// Package exercisetest holds the checks shared by the harnesses that
+// "patterns verify" runs against the exercises.
+package exercisetest
+
+import (
+	"runtime"
+	"testing"
+	"time"
+)
+
+// NoLeaks records how many goroutines are running and returns a function
+// that fails t if more are still running a second later:
+//
+//	defer exercisetest.NoLeaks(t)()
+func NoLeaks(t testing.TB) func() {
+	t.Helper()
+	before := runtime.NumGoroutine()
+	return func() {
+		t.Helper()
+		deadline := time.Now().Add(time.Second)
+		for runtime.NumGoroutine() > before {
+			if time.Now().After(deadline) {
+				t.Errorf("goroutine leak: %d goroutines still running, had %d before", runtime.NumGoroutine(), before)
+				return
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+}
+
+// Within fails t if fn has not returned after d, which catches an
+// implementation that deadlocks instead of hanging the whole run. A panic
+// in fn, such as the one in an exercise not done yet, fails t too.
+func Within(t testing.TB, d time.Duration, fn func()) {
+	t.Helper()
+	done := make(chan any, 1)
+	go func() {
+		defer func() { done <- recover() }()
+		fn()
+	}()
+	select {
+	case p := <-done:
+		if p != nil {
+			t.Fatalf("panic: %v", p)
+		}
+	case <-time.After(d):
+		t.Fatalf("did not return within %v; is something blocked forever?", d)
+	}
+}
@@ -0,0 +1,190 @@
+package lockfree
+
+import (
+	"context"
+	"runtime"
+	"runtime/metrics"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/lotusirous/gochan/pad"
+)
+
+// cpuSeconds returns the CPU time the Go runtime has spent running user code.
+// The runtime only updates the CPU class metrics during a GC, so force one.
+func cpuSeconds() float64 {
+	runtime.GC()
+	s := []metrics.Sample{{Name: "/cpu/classes/user:cpu-seconds"}}
+	metrics.Read(s)
+	return s[0].Value.Float64()
+}
+
+// BenchmarkWaitStrategies measures the round-trip latency of a ping-pong
+// between two goroutines over a pair of SPSC queues, and the CPU time burned
+// per round trip (cpu-ns/op). Spinning wins on latency when both goroutines
+// have their own core and loses badly on CPU; parking is the opposite. With
+// GOMAXPROCS=1 busy spinning only makes progress through preemption, so
+// compare with -cpu=1,4.
+func BenchmarkWaitStrategies(b *testing.B) {
+	for _, name := range []string{"BusySpin", "SpinThenYield", "Park"} {
+		strategy := strategies[name]
+		b.Run(name, func(b *testing.B) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			ping := NewSPSC[int](64, strategy)
+			pong := NewSPSC[int](64, strategy)
+
+			go func() {
+				for {
+					v, err := ping.Pop(ctx)
+					if err != nil {
+						return
+					}
+					pong.Push(ctx, v)
+				}
+			}()
+
+			cpu := cpuSeconds()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				ping.Push(ctx, i)
+				pong.Pop(ctx)
+			}
+			b.StopTimer()
+			b.ReportMetric((cpuSeconds()-cpu)*1e9/float64(b.N), "cpu-ns/op")
+		})
+	}
+
+	b.Run("Channel", func(b *testing.B) {
+		ping, pong := make(chan int, 64), make(chan int, 64)
+		defer close(ping)
+		go func() {
+			for v := range ping {
+				pong <- v
+			}
+		}()
+
+		cpu := cpuSeconds()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			ping <- i
+			<-pong
+		}
+		b.StopTimer()
+		b.ReportMetric((cpuSeconds()-cpu)*1e9/float64(b.N), "cpu-ns/op")
+	})
+}
+
+// BenchmarkFalseSharing runs one writer per GOMAXPROCS, each incrementing its
+// own counter. Packed counters share cache lines and slow each other down;
+// padded counters do not. The difference only shows with -cpu=2 or more.
+func BenchmarkFalseSharing(b *testing.B) {
+	type packed struct {
+		n atomic.Int64
+	}
+	type padded struct {
+		n atomic.Int64
+		_ pad.CacheLinePad
+	}
+
+	b.Run("Packed", func(b *testing.B) {
+		counters := make([]packed, runtime.GOMAXPROCS(0))
+		var next atomic.Int64
+		b.RunParallel(func(pb *testing.PB) {
+			c := &counters[int(next.Add(1)-1)%len(counters)]
+			for pb.Next() {
+				c.n.Add(1)
+			}
+		})
+	})
+
+	b.Run("Padded", func(b *testing.B) {
+		counters := make([]padded, runtime.GOMAXPROCS(0))
+		var next atomic.Int64
+		b.RunParallel(func(pb *testing.PB) {
+			c := &counters[int(next.Add(1)-1)%len(counters)]
+			for pb.Next() {
+				c.n.Add(1)
+			}
+		})
+	})
+
+	b.Run("StripedCounter", func(b *testing.B) {
+		c := NewStripedCounter(0)
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				c.Add(1)
+			}
+		})
+	})
+}
+
+// BenchmarkCombining compares a mutex with flat combining for a shared
+// counter and a shared map, with many goroutines per core contending for
+// them. ops/pass is the average number of operations one combiner applied
+// at a time. Combining pays off as contention and core count grow, so
+// compare with -cpu=1,4,8.
+func BenchmarkCombining(b *testing.B) {
+	const parallelism = 8 // goroutines per GOMAXPROCS
+
+	b.Run("Counter/Mutex", func(b *testing.B) {
+		var mu sync.Mutex
+		var n int
+		b.SetParallelism(parallelism)
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				mu.Lock()
+				n++
+				mu.Unlock()
+			}
+		})
+	})
+
+	b.Run("Counter/Combiner", func(b *testing.B) {
+		c := NewCombiner(0)
+		b.SetParallelism(parallelism)
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				c.Do(func(n *int) { *n++ })
+			}
+		})
+		reportBatch(b, c)
+	})
+
+	b.Run("Map/Mutex", func(b *testing.B) {
+		var mu sync.Mutex
+		m := map[int]int{}
+		var next atomic.Int64
+		b.SetParallelism(parallelism)
+		b.RunParallel(func(pb *testing.PB) {
+			k := int(next.Add(1))
+			for pb.Next() {
+				mu.Lock()
+				m[k%64]++
+				mu.Unlock()
+				k++
+			}
+		})
+	})
+
+	b.Run("Map/Combiner", func(b *testing.B) {
+		c := NewCombiner(map[int]int{})
+		var next atomic.Int64
+		b.SetParallelism(parallelism)
+		b.RunParallel(func(pb *testing.PB) {
+			k := int(next.Add(1))
+			for pb.Next() {
+				c.Do(func(m *map[int]int) { (*m)[k%64]++ })
+				k++
+			}
+		})
+		reportBatch(b, c)
+	})
+}
+
+func reportBatch[S any](b *testing.B, c *Combiner[S]) {
+	if ops, passes := c.Stats(); passes > 0 {
+		b.ReportMetric(float64(ops)/float64(passes), "ops/pass")
+	}
+}
@@ -0,0 +1,96 @@
+package lockfree
+
+import (
+	"runtime"
+	"sync/atomic"
+)
+
+// Combiner is flat combining: an alternative to guarding a data structure
+// of type S with a mutex, for when many goroutines hammer it at once.
+//
+// With a mutex every operation takes the lock itself, and under contention
+// the lock, the data and the goroutines waiting for them all move from
+// core to core once per operation. Here a goroutine instead publishes its
+// operation in a record on the publication list and tries to become the
+// combiner. Whoever succeeds walks the list and applies every pending
+// operation in one pass while the data stays hot in its cache; the others
+// just wait for their record to be cleared. The more contention, the
+// bigger the batches.
+//
+// Operations are closures over S that run on the combiner's goroutine and
+// hand back results through the variables they capture. They must not
+// call Do themselves, and must not panic.
+type Combiner[S any] struct {
+	state S
+	busy  atomic.Bool // held by the current combiner
+
+	head atomic.Pointer[pubRecord[S]] // every record ever created
+
+	ops, passes atomic.Int64
+}
+
+// pubRecord is one goroutine's slot on the publication list. op is set by
+// its owner and cleared by the combiner once it has been applied.
+type pubRecord[S any] struct {
+	op     atomic.Pointer[func(*S)]
+	active atomic.Bool   // owned by a call to Do
+	next   *pubRecord[S] // set before the record is linked in, then fixed
+}
+
+// NewCombiner returns a Combiner guarding state.
+func NewCombiner[S any](state S) *Combiner[S] {
+	return &Combiner[S]{state: state}
+}
+
+// Do applies op to the state and returns once it has been applied, by
+// this goroutine or by another one combining on its behalf.
+func (c *Combiner[S]) Do(op func(*S)) {
+	r := c.record()
+	defer r.active.Store(false)
+	r.op.Store(&op)
+	for r.op.Load() != nil {
+		if c.busy.CompareAndSwap(false, true) {
+			c.combine()
+			c.busy.Store(false)
+			return // our own op was published before the pass, so it is done
+		}
+		runtime.Gosched()
+	}
+}
+
+// Stats reports how many operations have been applied in how many
+// combining passes. ops/passes is the average batch.
+func (c *Combiner[S]) Stats() (ops, passes int64) {
+	return c.ops.Load(), c.passes.Load()
+}
+
+func (c *Combiner[S]) combine() {
+	var n int64
+	for r := c.head.Load(); r != nil; r = r.next {
+		if op := r.op.Load(); op != nil {
+			(*op)(&c.state)
+			r.op.Store(nil)
+			n++
+		}
+	}
+	c.ops.Add(n)
+	c.passes.Add(1)
+}
+
+// record returns an unused record, linking a new one into the list if
+// none is free. The list only grows to the most calls ever in flight.
+func (c *Combiner[S]) record() *pubRecord[S] {
+	for r := c.head.Load(); r != nil; r = r.next {
+		if !r.active.Load() && r.active.CompareAndSwap(false, true) {
+			return r
+		}
+	}
+	r := new(pubRecord[S])
+	r.active.Store(true)
+	for {
+		r.next = c.head.Load()
+		if c.head.CompareAndSwap(r.next, r) {
+			return r
+		}
+	}
+}
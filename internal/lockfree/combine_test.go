@@ -0,0 +1,70 @@
+package lockfree
+
+import (
+	"runtime"
+	"sync"
+	"testing"
+)
+
+func TestCombinerCounter(t *testing.T) {
+	c := NewCombiner(0)
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 1000 {
+				c.Do(func(n *int) { *n++ })
+			}
+		}()
+	}
+	wg.Wait()
+	var got int
+	c.Do(func(n *int) { got = *n })
+	if got != 8000 {
+		t.Errorf("counter = %d, want 8000", got)
+	}
+	if ops, _ := c.Stats(); ops != 8001 {
+		t.Errorf("%d ops applied, want 8001", ops)
+	}
+}
+
+func TestCombinerBatches(t *testing.T) {
+	c := NewCombiner(map[string]int{})
+	c.busy.Store(true) // as if a combiner were slow to finish
+
+	const waiters = 5
+	var wg sync.WaitGroup
+	for i := range waiters {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.Do(func(m *map[string]int) { (*m)["k"] += i + 1 })
+		}()
+	}
+	for pending(c) < waiters {
+		runtime.Gosched()
+	}
+	c.busy.Store(false)
+	wg.Wait()
+
+	// Whichever waiter got to combine first applied all of them.
+	if ops, passes := c.Stats(); ops != waiters || passes != 1 {
+		t.Errorf("%d ops in %d passes, want %d in one", ops, passes, waiters)
+	}
+	var got int
+	c.Do(func(m *map[string]int) { got = (*m)["k"] })
+	if got != 1+2+3+4+5 {
+		t.Errorf("k = %d, want 15", got)
+	}
+}
+
+func pending[S any](c *Combiner[S]) int {
+	n := 0
+	for r := c.head.Load(); r != nil; r = r.next {
+		if r.op.Load() != nil {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,47 @@
+package lockfree
+
+import (
+	"math/rand/v2"
+	"runtime"
+	"sync/atomic"
+
+	"github.com/lotusirous/gochan/pad"
+)
+
+// StripedCounter is a counter optimized for many concurrent writers and rare
+// readers. Add updates one of several stripes picked at random, so writers on
+// different cores rarely touch the same memory; Load sums all stripes.
+type StripedCounter struct {
+	stripes []stripe
+}
+
+// stripe is padded by a full cache line so neighbouring stripes never share
+// one. Without the padding eight stripes would fit in a single line and the
+// striping would buy almost nothing.
+type stripe struct {
+	n atomic.Int64
+	_ pad.CacheLinePad
+}
+
+// NewStripedCounter returns a counter with n stripes. If n <= 0 it uses one
+// stripe per GOMAXPROCS.
+func NewStripedCounter(n int) *StripedCounter {
+	if n <= 0 {
+		n = runtime.GOMAXPROCS(0)
+	}
+	return &StripedCounter{stripes: make([]stripe, n)}
+}
+
+// Add adds delta to the counter.
+func (c *StripedCounter) Add(delta int64) {
+	c.stripes[rand.IntN(len(c.stripes))].n.Add(delta)
+}
+
+// Load returns the current total. Concurrent Adds may or may not be included.
+func (c *StripedCounter) Load() int64 {
+	var sum int64
+	for i := range c.stripes {
+		sum += c.stripes[i].n.Load()
+	}
+	return sum
+}
@@ -0,0 +1,33 @@
+package lockfree
+
+import (
+	"sync"
+	"testing"
+	"unsafe"
+
+	"github.com/lotusirous/gochan/pad"
+)
+
+func TestStripedCounter(t *testing.T) {
+	c := NewStripedCounter(0)
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 1000 {
+				c.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	if got := c.Load(); got != 8000 {
+		t.Errorf("Load = %d, want 8000", got)
+	}
+}
+
+func TestStripeFillsCacheLine(t *testing.T) {
+	if size := unsafe.Sizeof(stripe{}); size < pad.CacheLineSize {
+		t.Errorf("stripe is %d bytes, want at least %d", size, pad.CacheLineSize)
+	}
+}
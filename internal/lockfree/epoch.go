@@ -0,0 +1,154 @@
+package lockfree
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// Domain is epoch-based reclamation: it decides when a node removed from a
+// lock-free structure may be reused, because no goroutine can still be
+// looking at it.
+//
+// In Go the garbage collector already makes this safe for nodes that are
+// simply dropped. Reclamation matters as soon as nodes are recycled, for
+// example through a free list to avoid allocating: a goroutine that read a
+// pointer to a node just before another goroutine removed it may still
+// follow that pointer, and if the node has meanwhile been reused it reads
+// someone else's data, or a compare-and-swap succeeds against the wrong
+// node (the ABA problem).
+//
+// Goroutines Pin the domain around every access to shared nodes. The
+// domain keeps a global epoch, and every pin records the epoch it started
+// in. The epoch only moves on from e when no pin from before e is left, so
+// once it has moved on twice after a node was retired, every goroutine
+// that might have seen the node has unpinned, and the node is freed.
+type Domain struct {
+	epoch   atomic.Uint64
+	records atomic.Pointer[record] // every record ever created
+
+	mu      sync.Mutex
+	bags    [3][]func()  // frees of nodes retired in each epoch, mod 3
+	pending int          // retired since the last attempt to advance
+	freed   atomic.Int64 // nodes freed so far
+}
+
+// record is the pin state of one goroutine: 0 when unpinned, or the epoch
+// it pinned in shifted left with the low bit set. Records are never
+// removed; Pin reuses one no guard holds, so there are only ever as many
+// as the most goroutines pinned at once.
+type record struct {
+	state atomic.Uint64
+	inUse atomic.Bool
+	next  *record // set before the record is linked in, then fixed
+}
+
+// advanceEvery is how many retirements trigger an attempt to advance.
+const advanceEvery = 64
+
+// Guard is an active pin. Pointers to shared nodes read while it is held
+// stay valid until Unpin.
+type Guard struct {
+	r *record
+	d *Domain
+}
+
+// Pin starts a critical section.
+func (d *Domain) Pin() Guard {
+	r := d.acquire()
+	// A stale epoch here is harmless: it only holds the epoch back longer.
+	r.state.Store(d.epoch.Load()<<1 | 1)
+	return Guard{r, d}
+}
+
+// Unpin ends the critical section. The guard must not be used after.
+func (g Guard) Unpin() {
+	g.r.state.Store(0)
+	g.r.inUse.Store(false)
+}
+
+// acquire returns a record no guard holds, as Hazards.Acquire does.
+func (d *Domain) acquire() *record {
+	for r := d.records.Load(); r != nil; r = r.next {
+		if !r.inUse.Load() && r.inUse.CompareAndSwap(false, true) {
+			return r
+		}
+	}
+	r := new(record)
+	r.inUse.Store(true)
+	for {
+		r.next = d.records.Load()
+		if d.records.CompareAndSwap(r.next, r) {
+			return r
+		}
+	}
+}
+
+// Retire schedules free to run once no goroutine pinned now can still hold
+// the node it frees. The node must already be unreachable for goroutines
+// that pin from now on.
+func (d *Domain) Retire(free func()) {
+	d.mu.Lock()
+	e := d.epoch.Load()
+	d.bags[e%3] = append(d.bags[e%3], free)
+	d.pending++
+	var frees []func()
+	if d.pending >= advanceEvery {
+		d.pending = 0
+		frees = d.advance()
+	}
+	d.mu.Unlock()
+	d.run(frees)
+}
+
+// Collect advances the epoch as far as current pins allow, freeing what
+// becomes safe to free. Without pins, two calls free everything retired.
+func (d *Domain) Collect() {
+	for range 3 {
+		d.mu.Lock()
+		frees := d.advance()
+		d.mu.Unlock()
+		d.run(frees)
+	}
+}
+
+// Synchronize waits for a grace period: until every goroutine pinned when
+// it was called has unpinned. Anything unlinked before the call is then
+// unreachable, which is RCU's synchronize_rcu; Retire is the asynchronous
+// form. The caller must not be pinned itself.
+func (d *Domain) Synchronize() {
+	target := d.epoch.Load() + 2
+	for d.epoch.Load() < target {
+		d.Collect()
+		if d.epoch.Load() < target {
+			runtime.Gosched()
+		}
+	}
+}
+
+// Freed reports how many retired nodes have been freed.
+func (d *Domain) Freed() int64 { return d.freed.Load() }
+
+// advance moves the epoch from e to e+1 if every pinned goroutine is in
+// e, and returns the frees of the nodes retired in e-1, which can no
+// longer be seen. It is called with mu held.
+func (d *Domain) advance() []func() {
+	e := d.epoch.Load()
+	for r := d.records.Load(); r != nil; r = r.next {
+		if s := r.state.Load(); s&1 == 1 && s>>1 != e {
+			return nil
+		}
+	}
+	d.epoch.Store(e + 1)
+	// The bag of e+2 is the bag of e-1: retired before any current pin.
+	frees := d.bags[(e+2)%3]
+	d.bags[(e+2)%3] = nil
+	return frees
+}
+
+func (d *Domain) run(frees []func()) {
+	for _, free := range frees {
+		free()
+	}
+	d.freed.Add(int64(len(frees)))
+}
@@ -0,0 +1,91 @@
+package lockfree
+
+import (
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestDomainWaitsForPins(t *testing.T) {
+	var d Domain
+	g := d.Pin()
+	freed := false
+	d.Retire(func() { freed = true })
+	d.Collect()
+	if freed {
+		t.Fatal("freed a node while a goroutine that may see it is pinned")
+	}
+	g.Unpin()
+	d.Collect()
+	if !freed || d.Freed() != 1 {
+		t.Fatalf("after unpinning: freed %v, Freed() = %d", freed, d.Freed())
+	}
+}
+
+func TestDomainFreesAsItGoes(t *testing.T) {
+	var d Domain
+	for range 10 * advanceEvery {
+		g := d.Pin()
+		d.Retire(func() {})
+		g.Unpin()
+	}
+	// Nothing calls Collect: retiring advances the epoch by itself, so
+	// retired nodes do not pile up while the structure is in use.
+	if n := d.Freed(); n < 8*advanceEvery {
+		t.Fatalf("freed %d of %d retired nodes", n, 10*advanceEvery)
+	}
+}
+
+func TestDomainReusesRecords(t *testing.T) {
+	var d Domain
+	a, b := d.Pin(), d.Pin()
+	a.Unpin()
+	b.Unpin()
+	for range 100 {
+		d.Pin().Unpin()
+		runtime.GC()
+	}
+	n := 0
+	for r := d.records.Load(); r != nil; r = r.next {
+		n++
+	}
+	if n != 2 {
+		t.Fatalf("%d records after at most 2 pins at once, want 2", n)
+	}
+}
+
+func TestDomainSynchronize(t *testing.T) {
+	var d Domain
+	g := d.Pin()
+	done := make(chan struct{})
+	go func() {
+		d.Synchronize()
+		close(done)
+	}()
+	select {
+	case <-done:
+		t.Fatal("Synchronize returned while a reader was pinned")
+	case <-time.After(20 * time.Millisecond):
+	}
+	g.Unpin()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Synchronize did not return after the reader unpinned")
+	}
+
+	// Readers that keep pinning anew do not hold it up.
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			d.Pin().Unpin()
+		}
+	}()
+	d.Synchronize()
+}
@@ -0,0 +1,48 @@
+package lockfree_test
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/lotusirous/gochan/internal/lockfree"
+)
+
+func ExampleQueue() {
+	q := lockfree.NewQueue[int]()
+
+	var wg sync.WaitGroup
+	for i := range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			q.Enqueue(i)
+		}()
+	}
+	wg.Wait()
+
+	sum := 0
+	for v, ok := q.Dequeue(); ok; v, ok = q.Dequeue() {
+		sum += v
+	}
+	fmt.Println(sum)
+	// Output: 6
+}
+
+func ExampleCombiner() {
+	hits := lockfree.NewCombiner(map[string]int{})
+
+	var wg sync.WaitGroup
+	for range 100 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			hits.Do(func(m *map[string]int) { (*m)["/"]++ })
+		}()
+	}
+	wg.Wait()
+
+	var n int
+	hits.Do(func(m *map[string]int) { n = (*m)["/"] })
+	fmt.Println(n)
+	// Output: 100
+}
@@ -0,0 +1,105 @@
+package lockfree
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Hazards is a hazard pointer domain for nodes of type T, the other
+// classic answer, besides epochs, to when a removed node may be reused.
+//
+// Where an epoch pin protects everything a goroutine might read, a hazard
+// pointer protects one node: before following a pointer to a shared node,
+// a goroutine publishes it in its Hazard, then checks that the node is
+// still reachable. A node that was removed is freed only once no Hazard
+// holds it. A stalled goroutine therefore holds back one node, not every
+// node retired since it pinned, at the price of a store and a re-check per
+// node visited.
+type Hazards[T any] struct {
+	head atomic.Pointer[Hazard[T]] // every Hazard ever acquired
+
+	mu      sync.Mutex
+	retired []retiredNode[T]
+	freed   atomic.Int64
+}
+
+type retiredNode[T any] struct {
+	p    *T
+	free func()
+}
+
+// scanAt is how many retired nodes trigger a scan of the hazards.
+const scanAt = 32
+
+// Hazard is one published pointer, owned by one goroutine at a time.
+type Hazard[T any] struct {
+	p      atomic.Pointer[T]
+	active atomic.Bool
+	next   *Hazard[T] // set before the Hazard is linked in, then fixed
+}
+
+// Acquire returns an unused Hazard, reusing a released one if it can.
+func (h *Hazards[T]) Acquire() *Hazard[T] {
+	for hp := h.head.Load(); hp != nil; hp = hp.next {
+		if !hp.active.Load() && hp.active.CompareAndSwap(false, true) {
+			return hp
+		}
+	}
+	hp := new(Hazard[T])
+	hp.active.Store(true)
+	for {
+		hp.next = h.head.Load()
+		if h.head.CompareAndSwap(hp.next, hp) {
+			return hp
+		}
+	}
+}
+
+// Protect publishes p. The caller must then check that p is still
+// reachable, such as still the top of the stack, before using it: the
+// node may have been removed between reading p and publishing it.
+func (hp *Hazard[T]) Protect(p *T) { hp.p.Store(p) }
+
+// Release clears the Hazard and gives it back. It must not be used after.
+func (hp *Hazard[T]) Release() {
+	hp.p.Store(nil)
+	hp.active.Store(false)
+}
+
+// Retire schedules free to run once no Hazard holds p. The node must
+// already be unreachable. Every so often it scans the Hazards and frees
+// whatever none of them holds.
+func (h *Hazards[T]) Retire(p *T, free func()) {
+	h.mu.Lock()
+	h.retired = append(h.retired, retiredNode[T]{p, free})
+	if len(h.retired) < scanAt {
+		h.mu.Unlock()
+		return
+	}
+	held := make(map[*T]bool)
+	for hp := h.head.Load(); hp != nil; hp = hp.next {
+		if p := hp.p.Load(); p != nil {
+			held[p] = true
+		}
+	}
+	var frees []func()
+	keep := h.retired[:0]
+	for _, r := range h.retired {
+		if held[r.p] {
+			keep = append(keep, r)
+		} else {
+			frees = append(frees, r.free)
+		}
+	}
+	clear(h.retired[len(keep):])
+	h.retired = keep
+	h.mu.Unlock()
+
+	for _, free := range frees {
+		free()
+	}
+	h.freed.Add(int64(len(frees)))
+}
+
+// Freed reports how many retired nodes have been freed.
+func (h *Hazards[T]) Freed() int64 { return h.freed.Load() }
@@ -0,0 +1,102 @@
+package lockfree
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestHazardHoldsNode(t *testing.T) {
+	var h Hazards[int]
+	held, other := new(int), new(int)
+	hp := h.Acquire()
+	hp.Protect(held)
+
+	var freed []*int
+	h.Retire(held, func() { freed = append(freed, held) })
+	for range scanAt - 1 {
+		h.Retire(other, func() { freed = append(freed, other) })
+	}
+	if slices.Contains(freed, held) || len(freed) != scanAt-1 {
+		t.Fatalf("the scan freed %d nodes, held one included: %v", len(freed), slices.Contains(freed, held))
+	}
+
+	hp.Release()
+	for range scanAt {
+		h.Retire(other, func() {})
+	}
+	if !slices.Contains(freed, held) {
+		t.Fatal("the node was not freed after its hazard was released")
+	}
+	if h.Acquire() != hp {
+		t.Error("a released hazard was not reused")
+	}
+}
+
+// TestABA plays the ABA problem out step by step on one goroutine. A pop
+// reads top = A and next = B, and stops just before its compare-and-swap.
+// Meanwhile, from the window hook, other pops take A and B and a push
+// reuses A's node for a new value X. The stack is now A(X) alone.
+//
+// With nodes recycled at once, the stopped pop resumes, finds the top is
+// still the node A, and swaps in B: a node that was popped and sits on the
+// free list. The stack now "contains" a freed node, and popping it yields
+// a value that was never pushed. An epoch pin or a hazard pointer on A
+// keeps its node from being reused while the first pop is in flight, so X
+// lands in a fresh node, the swap fails, and the pop retries correctly.
+func TestABA(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		s      *Stack[string]
+		broken bool
+	}{
+		{"recycle at once", &Stack[string]{recycle: true}, true},
+		{"epoch", NewRecyclingStack[string](new(Domain)), false},
+		{"hazard pointers", NewHazardStack[string](), false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s := tc.s
+			s.Push("B")
+			s.Push("A")
+
+			var interleaved []string
+			window = func() {
+				window = nil // only the first pop is interrupted
+				a, _ := s.Pop()
+				b, _ := s.Pop()
+				s.Push("X")
+				interleaved = append(interleaved, a, b)
+			}
+			t.Cleanup(func() { window = nil })
+
+			first, _ := s.Pop()
+			var rest []string
+			for v, ok := s.Pop(); ok; v, ok = s.Pop() {
+				rest = append(rest, v)
+			}
+			t.Logf("interleaved pops %q, first pop %q, left on the stack %q", interleaved, first, rest)
+
+			if tc.broken {
+				// B came back from the free list with its value cleared.
+				if first != "X" || !slices.Equal(rest, []string{""}) || s.useAfterFree.Load() == 0 {
+					t.Errorf("expected the ABA corruption, got first %q, rest %q", first, rest)
+				}
+				return
+			}
+			if first != "X" || len(rest) != 0 || s.useAfterFree.Load() != 0 {
+				t.Errorf("first pop %q, rest %q, %d freed reads; want X, nothing, 0", first, rest, s.useAfterFree.Load())
+			}
+		})
+	}
+}
+
+func TestHazardStackStress(t *testing.T) {
+	widenWindows(t)
+	s := NewHazardStack[int]()
+	stress(t, s.Push, s.Pop)
+	if n := s.useAfterFree.Load(); n != 0 {
+		t.Errorf("%d pops read a freed node", n)
+	}
+	if s.hazards.Freed() == 0 {
+		t.Error("no node was ever recycled")
+	}
+}
@@ -0,0 +1,118 @@
+package lockfree
+
+import "sync/atomic"
+
+// Queue is the Michael-Scott queue: an unbounded linked list with a dummy
+// node at the head, where enqueuers swing the tail and dequeuers the head
+// with compare-and-swap. It is safe for any number of goroutines.
+//
+// As with Stack, a Queue made with NewRecyclingQueue reuses dequeued nodes
+// and relies on an epoch Domain to do so safely. Both ends need the pin
+// here: a slow enqueuer may still be reading the old tail after it has
+// become the dummy and been dequeued.
+type Queue[T any] struct {
+	head atomic.Pointer[node[T]] // the dummy; the first value is in its next
+	tail atomic.Pointer[node[T]] // the last node, or one behind it
+
+	domain *Domain
+	free   freeList[T]
+
+	useAfterFree atomic.Int64 // freed nodes read by an operation; always 0
+}
+
+// NewQueue returns an empty queue.
+func NewQueue[T any]() *Queue[T] { return newQueue[T](nil) }
+
+// NewRecyclingQueue returns an empty queue that reuses dequeued nodes once
+// d says nobody can still see them.
+func NewRecyclingQueue[T any](d *Domain) *Queue[T] { return newQueue[T](d) }
+
+func newQueue[T any](d *Domain) *Queue[T] {
+	q := &Queue[T]{domain: d}
+	dummy := q.newNode()
+	q.head.Store(dummy)
+	q.tail.Store(dummy)
+	return q
+}
+
+// Enqueue adds v at the tail.
+func (q *Queue[T]) Enqueue(v T) {
+	if q.domain != nil {
+		g := q.domain.Pin()
+		defer g.Unpin()
+	}
+	n := q.newNode()
+	n.v = v
+	for {
+		tail := q.tail.Load()
+		widen()
+		next := tail.next.Load()
+		q.check(tail)
+		if tail != q.tail.Load() {
+			continue
+		}
+		if next != nil {
+			// Another enqueuer linked its node but has not moved the
+			// tail yet; help it along.
+			q.tail.CompareAndSwap(tail, next)
+			continue
+		}
+		if tail.next.CompareAndSwap(nil, n) {
+			q.tail.CompareAndSwap(tail, n)
+			return
+		}
+	}
+}
+
+// Dequeue removes the value at the head and reports whether there was one.
+func (q *Queue[T]) Dequeue() (T, bool) {
+	if q.domain != nil {
+		g := q.domain.Pin()
+		defer g.Unpin()
+	}
+	for {
+		head, tail := q.head.Load(), q.tail.Load()
+		widen()
+		next := head.next.Load()
+		q.check(head)
+		if head != q.head.Load() {
+			continue
+		}
+		if next == nil {
+			var zero T
+			return zero, false
+		}
+		if head == tail {
+			q.tail.CompareAndSwap(tail, next) // the tail lags; fix it first
+			continue
+		}
+		v := next.v // read before the CAS: next becomes the dummy after it
+		q.check(next)
+		if q.head.CompareAndSwap(head, next) {
+			q.retire(head)
+			return v, true
+		}
+	}
+}
+
+// check counts a read of a node that was already back on the free list.
+func (q *Queue[T]) check(n *node[T]) {
+	if n.state.Load() == nodeFree {
+		q.useAfterFree.Add(1)
+	}
+}
+
+func (q *Queue[T]) newNode() *node[T] {
+	if q.domain == nil {
+		return new(node[T])
+	}
+	return q.free.get()
+}
+
+func (q *Queue[T]) retire(n *node[T]) {
+	if q.domain == nil {
+		return
+	}
+	n.state.Store(nodeRetired)
+	q.domain.Retire(func() { q.free.put(n) })
+}
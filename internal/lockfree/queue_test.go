@@ -0,0 +1,37 @@
+package lockfree
+
+import "testing"
+
+func TestQueueFIFO(t *testing.T) {
+	for name, q := range map[string]*Queue[int]{"gc": NewQueue[int](), "recycling": NewRecyclingQueue[int](new(Domain))} {
+		for i := range 3 {
+			q.Enqueue(i)
+		}
+		for want := range 3 {
+			if v, ok := q.Dequeue(); !ok || v != want {
+				t.Errorf("%s: Dequeue = %d, %v; want %d", name, v, ok, want)
+			}
+		}
+		if _, ok := q.Dequeue(); ok {
+			t.Errorf("%s: Dequeue on an empty queue succeeded", name)
+		}
+	}
+}
+
+func TestQueueStress(t *testing.T) {
+	q := NewQueue[int]()
+	stress(t, q.Enqueue, q.Dequeue)
+}
+
+func TestRecyclingQueueStress(t *testing.T) {
+	widenWindows(t)
+	d := new(Domain)
+	q := NewRecyclingQueue[int](d)
+	stress(t, q.Enqueue, q.Dequeue)
+	if n := q.useAfterFree.Load(); n != 0 {
+		t.Errorf("%d operations read a freed node", n)
+	}
+	if d.Freed() == 0 {
+		t.Error("no node was ever recycled")
+	}
+}
@@ -0,0 +1,142 @@
+package lockfree
+
+import (
+	"encoding/binary"
+	"fmt"
+	"reflect"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+)
+
+// SeqLock is a sequence lock around a small value that is read far more
+// often than it is written, such as a snapshot of counters.
+//
+// A writer makes the sequence number odd, writes the value and makes it
+// even again. A reader notes the sequence number, copies the value and
+// checks the number again: if it changed, or was odd to begin with, a
+// write overlapped the copy, which may be torn, so the reader tries again.
+// Readers therefore write no shared memory at all, unlike with an RWMutex
+// where every RLock bounces the reader count between cores, and a writer
+// never waits for readers. The price is that a reader can retry for as
+// long as writes keep coming.
+//
+// Go does not allow the unsynchronized copy a seqlock in C does, so the
+// value is kept in atomic words and copied word by word. That is also why
+// T may not contain pointers: the garbage collector does not look inside
+// the words.
+type SeqLock[T any] struct {
+	seq     atomic.Uint64 // odd while a write is in progress
+	mu      sync.Mutex    // serializes writers
+	words   []atomic.Uint64
+	aligned bool // T is whole words and can be copied as []uint64
+}
+
+// NewSeqLock returns a SeqLock holding v. It panics if T contains
+// pointers, including strings, slices, maps and interfaces.
+func NewSeqLock[T any](v T) *SeqLock[T] {
+	if t := reflect.TypeFor[T](); !pointerFree(t) {
+		panic(fmt.Sprintf("lockfree: NewSeqLock: %v contains pointers", t))
+	}
+	s := &SeqLock[T]{
+		words:   make([]atomic.Uint64, (unsafe.Sizeof(v)+7)/8),
+		aligned: unsafe.Sizeof(v)%8 == 0 && unsafe.Alignof(v)%8 == 0,
+	}
+	s.Store(v)
+	return s
+}
+
+// Load returns a consistent copy of the value.
+func (s *SeqLock[T]) Load() T {
+	var v T
+	for {
+		seq := s.seq.Load()
+		if seq&1 == 1 {
+			runtime.Gosched() // a write is in progress; let it finish
+			continue
+		}
+		s.copyOut(&v)
+		if s.seq.Load() == seq {
+			return v
+		}
+	}
+}
+
+// Store replaces the value.
+func (s *SeqLock[T]) Store(v T) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.write(&v)
+}
+
+// Update replaces the value with fn applied to it. Writers are serialized,
+// so no other write can happen in between.
+func (s *SeqLock[T]) Update(fn func(*T)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var v T
+	s.copyOut(&v)
+	fn(&v)
+	s.write(&v)
+}
+
+func (s *SeqLock[T]) write(v *T) {
+	s.seq.Add(1)
+	if s.aligned {
+		for i, w := range unsafe.Slice((*uint64)(unsafe.Pointer(v)), len(s.words)) {
+			s.words[i].Store(w)
+		}
+	} else {
+		b := unsafe.Slice((*byte)(unsafe.Pointer(v)), unsafe.Sizeof(*v))
+		var buf [8]byte
+		for i := range s.words {
+			clear(buf[:])
+			copy(buf[:], b[i*8:])
+			s.words[i].Store(binary.NativeEndian.Uint64(buf[:]))
+		}
+	}
+	s.seq.Add(1)
+}
+
+func (s *SeqLock[T]) copyOut(v *T) {
+	if s.aligned {
+		dst := unsafe.Slice((*uint64)(unsafe.Pointer(v)), len(s.words))
+		for i := range s.words {
+			if i == len(s.words)/2 {
+				widen() // where a write can tear the copy
+			}
+			dst[i] = s.words[i].Load()
+		}
+		return
+	}
+	b := unsafe.Slice((*byte)(unsafe.Pointer(v)), unsafe.Sizeof(*v))
+	var buf [8]byte
+	for i := range s.words {
+		if i == len(s.words)/2 {
+			widen()
+		}
+		binary.NativeEndian.PutUint64(buf[:], s.words[i].Load())
+		copy(b[i*8:], buf[:])
+	}
+}
+
+// pointerFree reports whether values of t hold no pointers.
+func pointerFree(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.Complex64, reflect.Complex128:
+		return true
+	case reflect.Array:
+		return t.Len() == 0 || pointerFree(t.Elem())
+	case reflect.Struct:
+		for i := range t.NumField() {
+			if !pointerFree(t.Field(i).Type) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+package lockfree
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type snapshot struct {
+	requests, errors uint64
+	latency          time.Duration
+	healthy          bool
+	version          uint16
+}
+
+func TestSeqLockNoTornReads(t *testing.T) {
+	widenWindows(t)
+	s := NewSeqLock(snapshot{})
+	var stop atomic.Bool
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for !stop.Load() {
+				v := s.Load()
+				// Every write keeps these fields in step; a torn read would not.
+				n := v.requests
+				if v.errors != n/2 || v.latency != time.Duration(n) || v.healthy != (n%2 == 0) || v.version != uint16(n) {
+					t.Errorf("torn read: %+v", v)
+					return
+				}
+			}
+		}()
+	}
+	for i := uint64(1); i <= 20000; i++ {
+		s.Update(func(v *snapshot) {
+			n := v.requests + 1
+			*v = snapshot{n, n / 2, time.Duration(n), n%2 == 0, uint16(n)}
+		})
+	}
+	stop.Store(true)
+	wg.Wait()
+	if got := s.Load().requests; got != 20000 {
+		t.Errorf("requests = %d, want 20000", got)
+	}
+}
+
+func TestSeqLockOddSize(t *testing.T) {
+	type small struct {
+		a [3]byte
+		b int16
+	}
+	s := NewSeqLock(small{[3]byte{1, 2, 3}, -7})
+	if got := s.Load(); got != (small{[3]byte{1, 2, 3}, -7}) {
+		t.Errorf("Load = %+v", got)
+	}
+}
+
+func TestSeqLockRejectsPointers(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("NewSeqLock accepted a struct holding a string")
+		}
+	}()
+	NewSeqLock(struct{ name string }{})
+}
@@ -0,0 +1,111 @@
+// Package lockfree contains queue and stack implementations built on
+// sync/atomic instead of mutexes or channels.
+//
+// They exist to show what channels do for you and what it costs to do it
+// yourself; prefer channels unless a benchmark says otherwise.
+package lockfree
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/lotusirous/gochan/pad"
+)
+
+// SPSC is a bounded single-producer single-consumer ring buffer.
+//
+// Exactly one goroutine may push and exactly one goroutine may pop. Under
+// that rule the only synchronization needed is an atomic load/store of the
+// head and tail indexes, each of which is written by one side only.
+type SPSC[T any] struct {
+	buf  []T
+	mask uint64
+
+	// head and tail are written by different goroutines on (usually)
+	// different cores, so each gets its own cache line.
+	_    pad.CacheLinePad
+	head atomic.Uint64 // next slot to read, written by the consumer
+	_    pad.CacheLinePad
+	tail atomic.Uint64 // next slot to write, written by the producer
+	_    pad.CacheLinePad
+
+	notEmpty Waiter // the consumer waits on it
+	notFull  Waiter // the producer waits on it
+}
+
+// NewSPSC returns a queue holding at least capacity items (rounded up to a
+// power of two) whose blocking operations wait using strategy. A nil
+// strategy defaults to Park.
+func NewSPSC[T any](capacity int, strategy WaitStrategy) *SPSC[T] {
+	if strategy == nil {
+		strategy = Park
+	}
+	size := 1
+	for size < capacity {
+		size <<= 1
+	}
+	return &SPSC[T]{
+		buf:      make([]T, size),
+		mask:     uint64(size - 1),
+		notEmpty: strategy(),
+		notFull:  strategy(),
+	}
+}
+
+// TryPush adds v to the queue and reports whether there was room.
+func (q *SPSC[T]) TryPush(v T) bool {
+	tail := q.tail.Load()
+	if tail-q.head.Load() == uint64(len(q.buf)) {
+		return false
+	}
+	q.buf[tail&q.mask] = v
+	q.tail.Store(tail + 1) // publishes the slot to the consumer
+	q.notEmpty.Signal()
+	return true
+}
+
+// TryPop removes the oldest item and reports whether there was one.
+func (q *SPSC[T]) TryPop() (T, bool) {
+	var zero T
+	head := q.head.Load()
+	if head == q.tail.Load() {
+		return zero, false
+	}
+	v := q.buf[head&q.mask]
+	q.buf[head&q.mask] = zero // do not keep the value alive
+	q.head.Store(head + 1)    // hands the slot back to the producer
+	q.notFull.Signal()
+	return v, true
+}
+
+// Push adds v, waiting while the queue is full.
+func (q *SPSC[T]) Push(ctx context.Context, v T) error {
+	for attempt := 0; !q.TryPush(v); attempt++ {
+		if err := q.notFull.Wait(ctx, attempt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Pop removes the oldest item, waiting while the queue is empty.
+func (q *SPSC[T]) Pop(ctx context.Context) (T, error) {
+	for attempt := 0; ; attempt++ {
+		if v, ok := q.TryPop(); ok {
+			return v, nil
+		}
+		if err := q.notEmpty.Wait(ctx, attempt); err != nil {
+			var zero T
+			return zero, err
+		}
+	}
+}
+
+// Len returns the number of queued items. It is only a snapshot.
+func (q *SPSC[T]) Len() int {
+	head := q.head.Load() // load head first so it can never exceed tail
+	return int(q.tail.Load() - head)
+}
+
+// Cap returns the capacity of the queue.
+func (q *SPSC[T]) Cap() int { return len(q.buf) }
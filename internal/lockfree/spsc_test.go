@@ -0,0 +1,85 @@
+package lockfree
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+var strategies = map[string]WaitStrategy{
+	"BusySpin":      BusySpin,
+	"SpinThenYield": SpinThenYield(100),
+	"Park":          Park,
+}
+
+func TestSPSCTryOperations(t *testing.T) {
+	q := NewSPSC[int](3, nil)
+	if q.Cap() != 4 {
+		t.Fatalf("Cap = %d, want 4", q.Cap())
+	}
+	for i := range 4 {
+		if !q.TryPush(i) {
+			t.Fatalf("TryPush(%d) failed on a non-full queue", i)
+		}
+	}
+	if q.TryPush(4) {
+		t.Fatal("TryPush succeeded on a full queue")
+	}
+	if q.Len() != 4 {
+		t.Fatalf("Len = %d, want 4", q.Len())
+	}
+	for i := range 4 {
+		if v, ok := q.TryPop(); !ok || v != i {
+			t.Fatalf("TryPop = %d, %v; want %d", v, ok, i)
+		}
+	}
+	if _, ok := q.TryPop(); ok {
+		t.Fatal("TryPop succeeded on an empty queue")
+	}
+}
+
+func TestSPSCPreservesOrder(t *testing.T) {
+	for name, strategy := range strategies {
+		t.Run(name, func(t *testing.T) {
+			const n = 1_000
+			ctx := context.Background()
+			q := NewSPSC[int](16, strategy)
+
+			go func() {
+				for i := range n {
+					if err := q.Push(ctx, i); err != nil {
+						t.Error(err)
+						return
+					}
+				}
+			}()
+			for i := range n {
+				v, err := q.Pop(ctx)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if v != i {
+					t.Fatalf("Pop = %d, want %d", v, i)
+				}
+			}
+		})
+	}
+}
+
+func TestSPSCWaitHonorsContext(t *testing.T) {
+	for name, strategy := range strategies {
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+			q := NewSPSC[int](1, strategy)
+			if _, err := q.Pop(ctx); !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("Pop on empty queue returned %v", err)
+			}
+			q.TryPush(1)
+			if err := q.Push(ctx, 2); !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("Push on full queue returned %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,172 @@
+package lockfree
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// node is a link of Stack and Queue. Recycled nodes go through the states
+// below; a goroutine that finds a free node it is still reading has been
+// handed memory that was reclaimed too early.
+type node[T any] struct {
+	v     T
+	next  atomic.Pointer[node[T]]
+	state atomic.Int32
+}
+
+const (
+	nodeLive    = iota // in the structure, or being put in
+	nodeRetired        // removed, waiting for readers to move on
+	nodeFree           // on the free list, to be reused
+)
+
+// window, when set by tests, runs between reading a shared node pointer
+// and using the node, to widen the window in which another goroutine can
+// remove and recycle it.
+var window func()
+
+func widen() {
+	if window != nil {
+		window()
+	}
+}
+
+// freeList holds nodes ready for reuse, oldest first. It is locked: the
+// point of the structures here is how they share nodes, not how they
+// recycle them.
+type freeList[T any] struct {
+	mu    sync.Mutex
+	nodes []*node[T]
+}
+
+func (f *freeList[T]) get() *node[T] {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.nodes) == 0 {
+		return new(node[T])
+	}
+	n := f.nodes[0]
+	f.nodes = f.nodes[1:]
+	n.state.Store(nodeLive)
+	return n
+}
+
+func (f *freeList[T]) put(n *node[T]) {
+	var zero T
+	n.v = zero
+	n.next.Store(nil)
+	n.state.Store(nodeFree)
+	f.mu.Lock()
+	f.nodes = append(f.nodes, n)
+	f.mu.Unlock()
+}
+
+// Stack is a Treiber stack: a linked list whose top is swapped in and out
+// with compare-and-swap. It is safe for any number of goroutines.
+//
+// A Stack made with NewStack leaves popped nodes to the garbage collector.
+// The others reuse them, and make sure a node is never reused while a
+// goroutine that read it may still look at it: NewRecyclingStack with an
+// epoch Domain, NewHazardStack with hazard pointers.
+type Stack[T any] struct {
+	top atomic.Pointer[node[T]]
+
+	recycle bool              // reuse popped nodes through free
+	domain  *Domain           // if set, reuse after an epoch grace period
+	hazards *Hazards[node[T]] // if set, reuse once no hazard holds the node
+	free    freeList[T]       // with neither, reuse at once, which is unsafe
+
+	useAfterFree atomic.Int64 // freed nodes read by a pop; always 0
+}
+
+// NewStack returns an empty stack.
+func NewStack[T any]() *Stack[T] { return &Stack[T]{} }
+
+// NewRecyclingStack returns an empty stack that reuses popped nodes once d
+// says nobody can still see them.
+func NewRecyclingStack[T any](d *Domain) *Stack[T] {
+	return &Stack[T]{recycle: true, domain: d}
+}
+
+// NewHazardStack returns an empty stack that reuses popped nodes once no
+// pop holds a hazard pointer to them.
+func NewHazardStack[T any]() *Stack[T] {
+	return &Stack[T]{recycle: true, hazards: new(Hazards[node[T]])}
+}
+
+// Push adds v on top.
+func (s *Stack[T]) Push(v T) {
+	n := s.newNode()
+	n.v = v
+	for {
+		top := s.top.Load()
+		n.next.Store(top)
+		if s.top.CompareAndSwap(top, n) {
+			return
+		}
+	}
+}
+
+// Pop removes the top value and reports whether there was one.
+func (s *Stack[T]) Pop() (T, bool) {
+	if s.domain != nil {
+		g := s.domain.Pin()
+		defer g.Unpin()
+	}
+	var hp *Hazard[node[T]]
+	if s.hazards != nil {
+		hp = s.hazards.Acquire()
+		defer hp.Release()
+	}
+	for {
+		top := s.top.Load()
+		if top == nil {
+			var zero T
+			return zero, false
+		}
+		if hp != nil {
+			hp.Protect(top)
+			if s.top.Load() != top {
+				continue // popped before the hazard was up; it may be freed
+			}
+		}
+		next := top.next.Load()
+		// Between reading next and the swap below, other goroutines may pop
+		// top and next and push top's node again, recycled. The swap then
+		// succeeds and makes next, a node no longer in the stack, the top:
+		// the ABA problem. It cannot happen while top is pinned or
+		// protected, because top's node is not reused until then.
+		widen()
+		if top.state.Load() == nodeFree {
+			s.useAfterFree.Add(1)
+		}
+		if s.top.CompareAndSwap(top, next) {
+			v := top.v
+			s.retire(top)
+			return v, true
+		}
+	}
+}
+
+func (s *Stack[T]) newNode() *node[T] {
+	if !s.recycle {
+		return new(node[T])
+	}
+	return s.free.get()
+}
+
+func (s *Stack[T]) retire(n *node[T]) {
+	if !s.recycle {
+		return
+	}
+	n.state.Store(nodeRetired)
+	put := func() { s.free.put(n) }
+	switch {
+	case s.domain != nil:
+		s.domain.Retire(put)
+	case s.hazards != nil:
+		s.hazards.Retire(n, put)
+	default:
+		put()
+	}
+}
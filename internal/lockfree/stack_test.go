@@ -0,0 +1,98 @@
+package lockfree
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestStackLIFO(t *testing.T) {
+	for name, s := range map[string]*Stack[int]{"gc": NewStack[int](), "recycling": NewRecyclingStack[int](new(Domain))} {
+		for i := range 3 {
+			s.Push(i)
+		}
+		for want := 2; want >= 0; want-- {
+			if v, ok := s.Pop(); !ok || v != want {
+				t.Errorf("%s: Pop = %d, %v; want %d", name, v, ok, want)
+			}
+		}
+		if _, ok := s.Pop(); ok {
+			t.Errorf("%s: Pop on an empty stack succeeded", name)
+		}
+	}
+}
+
+// widenWindows makes every 64th operation stop between reading a node
+// pointer and using the node for long enough that other goroutines can
+// remove and recycle the node, if reclamation lets them. Without it the
+// window is a few instructions wide and a broken Domain goes unnoticed.
+func widenWindows(t *testing.T) {
+	var calls atomic.Int64
+	window = func() {
+		if calls.Add(1)%64 == 0 {
+			for range 100 {
+				runtime.Gosched()
+			}
+		}
+	}
+	t.Cleanup(func() { window = nil })
+}
+
+// stress pushes and pops from many goroutines and checks that every value
+// comes out exactly once.
+func stress(t *testing.T, push func(int), pop func() (int, bool)) {
+	const goroutines, perGoroutine = 8, 5000
+	seen := make([]int32, goroutines*perGoroutine)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for g := range goroutines {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var got []int
+			for i := range perGoroutine {
+				push(g*perGoroutine + i)
+				if v, ok := pop(); ok {
+					got = append(got, v)
+				}
+			}
+			mu.Lock()
+			for _, v := range got {
+				seen[v]++
+			}
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	for v, ok := pop(); ok; v, ok = pop() {
+		seen[v]++
+	}
+	for v, n := range seen {
+		if n != 1 {
+			t.Fatalf("value %d came out %d times", v, n)
+		}
+	}
+}
+
+func TestStackStress(t *testing.T) {
+	s := NewStack[int]()
+	stress(t, s.Push, s.Pop)
+}
+
+// TestRecyclingStackStress is the point of the epoch Domain: nodes are
+// reused thousands of times while other goroutines race to pop them, and
+// no pop ever reads a node that was already reused. With a Domain whose
+// Pin records nothing, dozens of pops do. Run it with -race too.
+func TestRecyclingStackStress(t *testing.T) {
+	widenWindows(t)
+	d := new(Domain)
+	s := NewRecyclingStack[int](d)
+	stress(t, s.Push, s.Pop)
+	if n := s.useAfterFree.Load(); n != 0 {
+		t.Errorf("%d pops read a freed node", n)
+	}
+	if d.Freed() == 0 {
+		t.Error("no node was ever recycled")
+	}
+}
@@ -0,0 +1,75 @@
+package lockfree
+
+import (
+	"context"
+	"runtime"
+)
+
+// Waiter is how one side of a queue waits for the other side to make
+// progress. Wait is called in a loop with an increasing attempt number while
+// the queue is empty (consumer) or full (producer); after every successful
+// operation the opposite side's Signal is called.
+type Waiter interface {
+	Wait(ctx context.Context, attempt int) error
+	Signal()
+}
+
+// WaitStrategy creates a Waiter. A queue creates one for its producer side
+// and one for its consumer side.
+//
+// The strategies mirror the options of the LMAX Disruptor and trade latency
+// against CPU usage:
+//
+//	BusySpin         lowest latency, burns a whole core while waiting
+//	SpinThenYield    spins briefly, then yields the processor between checks
+//	Park             blocks on a channel; no CPU while idle, highest latency
+type WaitStrategy func() Waiter
+
+// BusySpin re-checks the queue in a tight loop. Only use it when producer and
+// consumer have dedicated cores; with GOMAXPROCS=1 it relies on preemption.
+func BusySpin() Waiter { return busySpin{} }
+
+// SpinThenYield spins for the given number of attempts and then calls
+// runtime.Gosched between checks.
+func SpinThenYield(spins int) WaitStrategy {
+	return func() Waiter { return spinThenYield{spins: spins} }
+}
+
+// Park blocks the waiting goroutine until the other side signals progress.
+func Park() Waiter { return &park{ch: make(chan struct{}, 1)} }
+
+type busySpin struct{}
+
+func (busySpin) Wait(ctx context.Context, attempt int) error { return ctx.Err() }
+func (busySpin) Signal()                                     {}
+
+type spinThenYield struct{ spins int }
+
+func (s spinThenYield) Wait(ctx context.Context, attempt int) error {
+	if attempt >= s.spins {
+		runtime.Gosched()
+	}
+	return ctx.Err()
+}
+
+func (spinThenYield) Signal() {}
+
+// park uses a one-slot channel as a wake-up token. A Signal that arrives
+// before Wait leaves the token in the buffer, so no wake-up is ever lost.
+type park struct{ ch chan struct{} }
+
+func (p *park) Wait(ctx context.Context, attempt int) error {
+	select {
+	case <-p.ch:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (p *park) Signal() {
+	select {
+	case p.ch <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,138 @@
+package sim
+
+import (
+	"context"
+	"flag"
+	"math/rand/v2"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Chaos describes an unreliable link. Values sent through a channel wrapped
+// with Link are lost, duplicated and delayed the way a network treats
+// packets, so code written against channels can be tried against the
+// failures it would meet between machines. The zero value is a perfect
+// link.
+type Chaos struct {
+	Loss      float64 // chance that a value is dropped, from 0 to 1
+	Duplicate float64 // chance that a value is delivered twice
+	Delay     Latency // nil means none; delayed values can overtake others
+
+	lost atomic.Int64
+
+	mu  sync.Mutex
+	rng *rand.Rand // nil until Seed or the first value
+}
+
+// Seed makes the link's draws repeatable, as far as the order in which
+// goroutines send allows.
+func (c *Chaos) Seed(seed uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.rng = rand.New(rand.NewPCG(seed, seed))
+}
+
+// Lost reports how many values the link has dropped.
+func (c *Chaos) Lost() int64 { return c.lost.Load() }
+
+// fate draws what happens to one value: the delay of each copy delivered,
+// none if it is lost.
+func (c *Chaos) fate() []time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.rng == nil {
+		c.rng = rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64()))
+	}
+	if c.rng.Float64() < c.Loss {
+		c.lost.Add(1)
+		return nil
+	}
+	copies := 1
+	if c.rng.Float64() < c.Duplicate {
+		copies = 2
+	}
+	delays := make([]time.Duration, copies)
+	if c.Delay != nil {
+		for i := range delays {
+			delays[i] = c.Delay.Sample(c.rng)
+		}
+	}
+	return delays
+}
+
+// Link forwards the values from in to the returned channel, through c. A
+// value that is not delayed is forwarded in order; a delayed one is
+// forwarded by a goroutine of its own once its delay is up. The returned
+// channel is closed when in is closed and every delayed value has been
+// forwarded, or when ctx is done, and values still in flight then are
+// dropped.
+func Link[T any](ctx context.Context, c *Chaos, in <-chan T) <-chan T {
+	out := make(chan T)
+	send := func(v T) {
+		select {
+		case out <- v:
+		case <-ctx.Done():
+		}
+	}
+	go func() {
+		var wg sync.WaitGroup
+		defer func() {
+			wg.Wait()
+			close(out)
+		}()
+		for {
+			var v T
+			var ok bool
+			select {
+			case v, ok = <-in:
+			case <-ctx.Done():
+				return
+			}
+			if !ok {
+				return
+			}
+			for _, d := range c.fate() {
+				if d <= 0 {
+					send(v)
+					continue
+				}
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					t := time.NewTimer(d)
+					defer t.Stop()
+					select {
+					case <-t.C:
+						send(v)
+					case <-ctx.Done():
+					}
+				}()
+			}
+		}
+	}()
+	return out
+}
+
+// AddFlags registers flags setting c on fs, named with prefix:
+//
+//	-<prefix>loss   chance that a value is dropped
+//	-<prefix>dup    chance that a value is delivered twice
+//	-<prefix>delay  distribution, as read by ParseLatency
+//	-<prefix>seed   seed for repeatable runs
+//
+// The current fields of c are the defaults.
+func (c *Chaos) AddFlags(fs *flag.FlagSet, prefix string) {
+	fs.Float64Var(&c.Loss, prefix+"loss", c.Loss, "chance from 0 to 1 that a message is lost")
+	fs.Float64Var(&c.Duplicate, prefix+"dup", c.Duplicate, "chance from 0 to 1 that a message is delivered twice")
+	LatencyVar(fs, &c.Delay, prefix+"delay", "message `delay`: 5ms, uniform:min,max or pareto:scale,alpha")
+	fs.Func(prefix+"seed", "seed the link's draws", func(v string) error {
+		n, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			return err
+		}
+		c.Seed(n)
+		return nil
+	})
+}
@@ -0,0 +1,99 @@
+package sim
+
+import (
+	"context"
+	"flag"
+	"slices"
+	"testing"
+	"time"
+)
+
+// through sends 0..n-1 through a link and collects what comes out.
+func through(c *Chaos, n int) []int {
+	in := make(chan int)
+	out := Link(context.Background(), c, in)
+	go func() {
+		defer close(in)
+		for i := range n {
+			in <- i
+		}
+	}()
+	var got []int
+	for v := range out {
+		got = append(got, v)
+	}
+	return got
+}
+
+func TestPerfectLink(t *testing.T) {
+	if got := through(&Chaos{}, 100); len(got) != 100 || !slices.IsSorted(got) {
+		t.Errorf("the zero Chaos changed the stream: %v", got)
+	}
+}
+
+func TestLossAndDuplicates(t *testing.T) {
+	c := &Chaos{Loss: 0.2, Duplicate: 0.1}
+	c.Seed(1)
+	const n = 10_000
+	got := through(c, n)
+	// Each value arrives 0, 1 or 2 times; count what happened.
+	seen := make([]int, n)
+	for _, v := range got {
+		seen[v]++
+	}
+	lost, dup := 0, 0
+	for _, k := range seen {
+		switch k {
+		case 0:
+			lost++
+		case 2:
+			dup++
+		}
+	}
+	if lost < n*18/100 || lost > n*22/100 || int64(lost) != c.Lost() {
+		t.Errorf("%d lost (counted %d), want about a fifth", lost, c.Lost())
+	}
+	if dup < n*7/100 || dup > n*9/100 { // 10% of the 80% delivered
+		t.Errorf("%d duplicated, want about 8%%", dup)
+	}
+}
+
+func TestDelayReorders(t *testing.T) {
+	c := &Chaos{Delay: Uniform(0, 5*time.Millisecond)}
+	c.Seed(2)
+	got := through(c, 200)
+	if len(got) != 200 {
+		t.Fatalf("%d of 200 values arrived", len(got))
+	}
+	if slices.IsSorted(got) {
+		t.Error("random delays kept every value in order")
+	}
+}
+
+func TestLinkStopsWithContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	in := make(chan int, 1)
+	out := Link(ctx, &Chaos{Delay: Fixed(time.Hour)}, in)
+	in <- 1
+	cancel()
+	select {
+	case _, ok := <-out:
+		if ok {
+			t.Error("a value an hour late was delivered")
+		}
+	case <-time.After(time.Second):
+		t.Error("the link did not close after cancel")
+	}
+}
+
+func TestChaosAddFlags(t *testing.T) {
+	c := &Chaos{Loss: 0.1}
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	c.AddFlags(fs, "net-")
+	if err := fs.Parse([]string{"-net-loss", "0.3", "-net-dup", "0.05", "-net-delay", "uniform:1ms,2ms", "-net-seed", "9"}); err != nil {
+		t.Fatal(err)
+	}
+	if c.Loss != 0.3 || c.Duplicate != 0.05 || c.Delay.String() != "uniform:1ms,2ms" || c.rng == nil {
+		t.Errorf("after parsing: %+v", c)
+	}
+}
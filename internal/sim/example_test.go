@@ -0,0 +1,22 @@
+package sim_test
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lotusirous/gochan/internal/sim"
+)
+
+func ExampleService() {
+	s := &sim.Service{Failures: 0.2}
+	s.Seed(1) // the same calls fail on every run
+
+	failed := 0
+	for range 100 {
+		if s.Call(context.Background()) != nil {
+			failed++
+		}
+	}
+	fmt.Println(failed, "of 100 calls failed")
+	// Output: 23 of 100 calls failed
+}
@@ -0,0 +1,218 @@
+// Package sim simulates how remote services behave: how long a call takes
+// and how often it fails. Examples that fake their backends with a Service
+// can be run under different conditions from the command line, such as a
+// heavy latency tail or a flaky server, without editing code. Chaos does
+// the same for the channels between goroutines, losing, duplicating and
+// delaying what they carry.
+package sim
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrInjected is the error of a call that the Service failed on purpose.
+var ErrInjected = errors.New("sim: injected failure")
+
+// Latency is a distribution of call durations.
+type Latency interface {
+	// Sample draws one duration using rng.
+	Sample(rng *rand.Rand) time.Duration
+	// String formats the distribution the way ParseLatency reads it.
+	String() string
+}
+
+type fixed time.Duration
+
+// Fixed is a latency of exactly d.
+func Fixed(d time.Duration) Latency { return fixed(d) }
+
+func (f fixed) Sample(*rand.Rand) time.Duration { return time.Duration(f) }
+func (f fixed) String() string                  { return "fixed:" + time.Duration(f).String() }
+
+type uniform struct{ lo, hi time.Duration }
+
+// Uniform is a latency spread evenly between lo and hi.
+func Uniform(lo, hi time.Duration) Latency {
+	if hi < lo {
+		lo, hi = hi, lo
+	}
+	return uniform{lo, hi}
+}
+
+func (u uniform) Sample(rng *rand.Rand) time.Duration {
+	return u.lo + time.Duration(rng.Int64N(int64(u.hi-u.lo)+1))
+}
+
+func (u uniform) String() string { return "uniform:" + u.lo.String() + "," + u.hi.String() }
+
+type pareto struct {
+	scale time.Duration
+	alpha float64
+}
+
+// Pareto is a heavy-tailed latency: never below scale, usually close to
+// it, and now and then many times larger. The smaller alpha, the heavier
+// the tail; below 2 it is heavy enough for tail latency to dominate, which
+// is what hedged and replicated requests are for.
+func Pareto(scale time.Duration, alpha float64) Latency { return pareto{scale, alpha} }
+
+func (p pareto) Sample(rng *rand.Rand) time.Duration {
+	u := 1 - rng.Float64() // in (0, 1]
+	return time.Duration(float64(p.scale) / math.Pow(u, 1/p.alpha))
+}
+
+func (p pareto) String() string {
+	return "pareto:" + p.scale.String() + "," + strconv.FormatFloat(p.alpha, 'g', -1, 64)
+}
+
+// ParseLatency reads a distribution written as one of
+//
+//	50ms  or  fixed:50ms
+//	uniform:10ms,100ms
+//	pareto:20ms,1.5      (scale, alpha)
+func ParseLatency(s string) (Latency, error) {
+	kind, args, ok := strings.Cut(s, ":")
+	if !ok {
+		kind, args = "fixed", s
+	}
+	a, b, two := strings.Cut(args, ",")
+	bad := func(err error) (Latency, error) {
+		return nil, fmt.Errorf("sim: latency %q: %v", s, err)
+	}
+	switch kind {
+	case "fixed":
+		d, err := time.ParseDuration(args)
+		if err != nil {
+			return bad(err)
+		}
+		return Fixed(d), nil
+	case "uniform":
+		if !two {
+			return bad(errors.New("want uniform:min,max"))
+		}
+		lo, err := time.ParseDuration(a)
+		if err != nil {
+			return bad(err)
+		}
+		hi, err := time.ParseDuration(b)
+		if err != nil {
+			return bad(err)
+		}
+		return Uniform(lo, hi), nil
+	case "pareto":
+		if !two {
+			return bad(errors.New("want pareto:scale,alpha"))
+		}
+		scale, err := time.ParseDuration(a)
+		if err != nil {
+			return bad(err)
+		}
+		alpha, err := strconv.ParseFloat(b, 64)
+		if err != nil || alpha <= 0 {
+			return bad(errors.New("alpha must be a positive number"))
+		}
+		return Pareto(scale, alpha), nil
+	}
+	return bad(errors.New("unknown distribution; want fixed, uniform or pareto"))
+}
+
+// Service is a simulated remote service. The zero value answers at once
+// and never fails.
+type Service struct {
+	Latency  Latency // nil means no delay
+	Failures float64 // chance that a call fails, from 0 to 1
+
+	mu  sync.Mutex
+	rng *rand.Rand // nil until Seed or the first call
+}
+
+// Seed makes the service's draws repeatable.
+func (s *Service) Seed(seed uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rng = rand.New(rand.NewPCG(seed, seed))
+}
+
+// Call waits as long as the latency says, then fails with ErrInjected as
+// often as Failures says. A failure takes as long as a success, like a
+// server that does the work and then errors. If ctx is done first, Call
+// returns ctx.Err().
+func (s *Service) Call(ctx context.Context) error {
+	s.mu.Lock()
+	if s.rng == nil {
+		s.rng = rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64()))
+	}
+	var d time.Duration
+	if s.Latency != nil {
+		d = s.Latency.Sample(s.rng)
+	}
+	fail := s.rng.Float64() < s.Failures
+	s.mu.Unlock()
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	if fail {
+		return ErrInjected
+	}
+	return nil
+}
+
+// AddFlags registers flags setting s on fs, named with prefix so several
+// services can be configured side by side:
+//
+//	-<prefix>latency  distribution, as read by ParseLatency
+//	-<prefix>failures chance that a call fails
+//	-<prefix>seed     seed for repeatable runs
+//
+// The current fields of s are the defaults.
+func (s *Service) AddFlags(fs *flag.FlagSet, prefix string) {
+	LatencyVar(fs, &s.Latency, prefix+"latency", "call `latency`: 50ms, uniform:min,max or pareto:scale,alpha")
+	fs.Float64Var(&s.Failures, prefix+"failures", s.Failures, "chance from 0 to 1 that a call fails")
+	fs.Func(prefix+"seed", "seed the latency and failure draws for repeatable runs", func(v string) error {
+		n, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			return err
+		}
+		s.Seed(n)
+		return nil
+	})
+}
+
+// LatencyVar registers a flag name on fs that sets *l with ParseLatency.
+// The current *l is the default.
+func LatencyVar(fs *flag.FlagSet, l *Latency, name, usage string) {
+	fs.Var(latencyValue{l}, name, usage)
+}
+
+// latencyValue is the flag.Value of a latency.
+type latencyValue struct{ l *Latency }
+
+func (v latencyValue) String() string {
+	if v.l == nil || *v.l == nil {
+		return "0s"
+	}
+	return (*v.l).String()
+}
+
+func (v latencyValue) Set(text string) error {
+	l, err := ParseLatency(text)
+	if err != nil {
+		return err
+	}
+	*v.l = l
+	return nil
+}
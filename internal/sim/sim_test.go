@@ -0,0 +1,125 @@
+package sim
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"math/rand/v2"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestParseLatency(t *testing.T) {
+	for _, tc := range []struct{ in, want string }{
+		{"50ms", "fixed:50ms"},
+		{"fixed:1s", "fixed:1s"},
+		{"uniform:10ms,100ms", "uniform:10ms,100ms"},
+		{"uniform:100ms,10ms", "uniform:10ms,100ms"},
+		{"pareto:20ms,1.5", "pareto:20ms,1.5"},
+	} {
+		l, err := ParseLatency(tc.in)
+		if err != nil || l.String() != tc.want {
+			t.Errorf("ParseLatency(%q) = %v, %v; want %s", tc.in, l, err, tc.want)
+		}
+	}
+	for _, in := range []string{"", "fast", "uniform:10ms", "pareto:20ms,0", "pareto:20ms,x", "normal:1s,2s"} {
+		if l, err := ParseLatency(in); err == nil {
+			t.Errorf("ParseLatency(%q) = %v, want an error", in, l)
+		}
+	}
+}
+
+func TestDistributions(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	const n = 100_000
+	sample := func(l Latency) []time.Duration {
+		s := make([]time.Duration, n)
+		for i := range s {
+			s[i] = l.Sample(rng)
+		}
+		slices.Sort(s)
+		return s
+	}
+
+	if s := sample(Fixed(5 * time.Millisecond)); s[0] != s[n-1] || s[0] != 5*time.Millisecond {
+		t.Errorf("fixed ranges over %v..%v", s[0], s[n-1])
+	}
+
+	s := sample(Uniform(10*time.Millisecond, 20*time.Millisecond))
+	if s[0] < 10*time.Millisecond || s[n-1] > 20*time.Millisecond {
+		t.Errorf("uniform ranges over %v..%v", s[0], s[n-1])
+	}
+	if median := s[n/2]; median < 14900*time.Microsecond || median > 15100*time.Microsecond {
+		t.Errorf("uniform median = %v, want 15ms", median)
+	}
+
+	// For Pareto the share above x is (scale/x)^alpha: with alpha 1, a
+	// tenth of the calls take over ten times the scale.
+	s = sample(Pareto(time.Millisecond, 1))
+	if s[0] < time.Millisecond {
+		t.Errorf("pareto sample %v below the scale", s[0])
+	}
+	if p90 := s[n*9/10]; p90 < 9500*time.Microsecond || p90 > 10500*time.Microsecond {
+		t.Errorf("pareto p90 = %v, want 10ms", p90)
+	}
+}
+
+func TestServiceFailures(t *testing.T) {
+	s := &Service{Failures: 0.25}
+	s.Seed(7)
+	failed := 0
+	const n = 10_000
+	for range n {
+		if err := s.Call(context.Background()); errors.Is(err, ErrInjected) {
+			failed++
+		} else if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if failed < n*23/100 || failed > n*27/100 {
+		t.Errorf("%d of %d calls failed, want about a quarter", failed, n)
+	}
+}
+
+func TestServiceSeedRepeats(t *testing.T) {
+	draws := func() []time.Duration {
+		s := &Service{Latency: Pareto(time.Millisecond, 1.5)}
+		s.Seed(42)
+		var out []time.Duration
+		for range 5 {
+			out = append(out, s.Latency.Sample(s.rng))
+		}
+		return out
+	}
+	if a, b := draws(), draws(); !slices.Equal(a, b) {
+		t.Errorf("same seed, different draws: %v and %v", a, b)
+	}
+}
+
+func TestServiceCallRespectsContext(t *testing.T) {
+	s := &Service{Latency: Fixed(time.Hour)}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := s.Call(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Call = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestAddFlags(t *testing.T) {
+	s := &Service{Latency: Uniform(0, 100*time.Millisecond)}
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	s.AddFlags(fs, "web-")
+	if def := fs.Lookup("web-latency").DefValue; def != "uniform:0s,100ms" {
+		t.Errorf("default latency = %q", def)
+	}
+	if err := fs.Parse([]string{"-web-latency", "pareto:10ms,1.2", "-web-failures", "0.1", "-web-seed", "3"}); err != nil {
+		t.Fatal(err)
+	}
+	if s.Latency.String() != "pareto:10ms,1.2" || s.Failures != 0.1 || s.rng == nil {
+		t.Errorf("after parsing: latency %v, failures %v, seeded %v", s.Latency, s.Failures, s.rng != nil)
+	}
+	if err := fs.Parse([]string{"-web-latency", "soon"}); err == nil {
+		t.Error("a bad latency parsed")
+	}
+}
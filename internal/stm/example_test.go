@@ -0,0 +1,55 @@
+package stm_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/lotusirous/gochan/internal/stm"
+)
+
+func ExampleAtomically() {
+	ctx := context.Background()
+	checking, savings := stm.NewVar(100), stm.NewVar(0)
+
+	transfer := func(tx *stm.Tx, amount int) error {
+		if checking.Get(tx) < amount {
+			return errors.New("insufficient funds")
+		}
+		checking.Set(tx, checking.Get(tx)-amount)
+		savings.Set(tx, savings.Get(tx)+amount)
+		return nil
+	}
+
+	fmt.Println(stm.Atomically(ctx, func(tx *stm.Tx) error { return transfer(tx, 70) }))
+	fmt.Println(stm.Atomically(ctx, func(tx *stm.Tx) error { return transfer(tx, 70) }))
+	fmt.Println(checking.Load(), savings.Load())
+	// Output:
+	// <nil>
+	// insufficient funds
+	// 30 70
+}
+
+func ExampleTx_Retry() {
+	ctx := context.Background()
+	stock := stm.NewVar(0)
+
+	go func() {
+		stm.Atomically(ctx, func(tx *stm.Tx) error {
+			stock.Set(tx, 5)
+			return nil
+		})
+	}()
+
+	// If the shelf is still empty, Retry blocks until a variable the
+	// transaction read changes.
+	stm.Atomically(ctx, func(tx *stm.Tx) error {
+		if stock.Get(tx) == 0 {
+			tx.Retry()
+		}
+		stock.Set(tx, stock.Get(tx)-1)
+		return nil
+	})
+	fmt.Println(stock.Load())
+	// Output: 4
+}
@@ -0,0 +1,218 @@
+// Package stm is a minimal software transactional memory: goroutines share
+// Vars and change them only inside Atomically, which runs a function as
+// one transaction that either commits all of its writes or none.
+//
+// There are no locks to order and nothing to forget to unlock. A
+// transaction reads and writes freely; if another transaction committed a
+// Var it read in the meantime, it is thrown away and run again. This is the
+// optimistic alternative to lock ordering: cheap when transactions rarely
+// touch the same Vars, wasteful when they often do.
+//
+// The implementation follows TL2: a global version clock, a version on
+// every Var, reads checked against the version the transaction started at,
+// and a commit that locks the Vars it touched in a fixed order.
+package stm
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+// clock counts commits. A Var's version is the clock value of the commit
+// that last wrote it.
+var clock atomic.Uint64
+
+// nextID numbers Vars, which fixes the order commits lock them in.
+var nextID atomic.Uint64
+
+// committed wakes transactions waiting in Retry after every commit.
+var (
+	commitMu  sync.Mutex
+	committed = sync.NewCond(&commitMu)
+)
+
+// Var is a transactional variable holding a T.
+type Var[T any] struct {
+	id      uint64
+	mu      sync.Mutex // held while reading and while committing
+	version uint64
+	v       T
+}
+
+// NewVar returns a Var holding v.
+func NewVar[T any](v T) *Var[T] {
+	return &Var[T]{id: nextID.Add(1), v: v}
+}
+
+// Get returns the value of v in tx: the value tx wrote, or the value
+// committed before tx started. If v changed since, tx is aborted and run
+// again, so a transaction never sees a mix of old and new state.
+func (v *Var[T]) Get(tx *Tx) T {
+	if w, ok := tx.writes[v]; ok {
+		return w.(T)
+	}
+	v.mu.Lock()
+	version, val := v.version, v.v
+	v.mu.Unlock()
+	if version > tx.start {
+		panic(abort{})
+	}
+	tx.reads[v] = struct{}{}
+	return val
+}
+
+// Set writes x to v in tx. Nobody else sees it before tx commits.
+func (v *Var[T]) Set(tx *Tx, x T) {
+	tx.writes[v] = x
+}
+
+// Load returns the committed value of v, outside any transaction.
+func (v *Var[T]) Load() T {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.v
+}
+
+// tvar is a Var of any type, as a transaction tracks it.
+type tvar interface {
+	order() uint64
+	lock()
+	unlock()
+	changedSince(version uint64) bool
+	store(x any, version uint64)
+}
+
+func (v *Var[T]) order() uint64 { return v.id }
+func (v *Var[T]) lock()         { v.mu.Lock() }
+func (v *Var[T]) unlock()       { v.mu.Unlock() }
+
+func (v *Var[T]) changedSince(version uint64) bool { return v.version > version }
+
+func (v *Var[T]) store(x any, version uint64) {
+	v.v = x.(T)
+	v.version = version
+}
+
+// Tx is a running transaction.
+type Tx struct {
+	start  uint64 // clock when the transaction began
+	reads  map[tvar]struct{}
+	writes map[tvar]any
+}
+
+// Retry abandons tx and runs it again once another transaction has
+// committed, which may have changed what tx read. Use it to wait for a
+// condition, such as a balance large enough to withdraw from, without a
+// condition variable.
+func (tx *Tx) Retry() {
+	panic(retry{})
+}
+
+// abort and retry unwind a transaction through fn back to Atomically.
+type (
+	abort struct{}
+	retry struct{}
+)
+
+// Atomically runs fn as a transaction and commits its writes, running it
+// again for as long as it conflicts with other transactions. fn may run
+// several times, so it must not have effects outside the Vars it sets. If
+// fn returns an error, its writes are discarded and Atomically returns the
+// error. If ctx is done while fn waits in Retry or before fn runs again
+// after a conflict, Atomically gives up and returns ctx.Err().
+func Atomically(ctx context.Context, fn func(tx *Tx) error) error {
+	for {
+		tx := &Tx{start: clock.Load(), reads: make(map[tvar]struct{}), writes: make(map[tvar]any)}
+		outcome, err := run(tx, fn)
+		switch {
+		case outcome == (abort{}):
+		case outcome == (retry{}):
+			if err := waitCommit(ctx, tx.start); err != nil {
+				return err
+			}
+			continue
+		case err != nil:
+			return err
+		case tx.commit():
+			return nil
+		}
+		// A conflict: run again, unless the caller has stopped waiting.
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+	}
+}
+
+// run calls fn and catches the aborts and retries it raises.
+func run(tx *Tx, fn func(tx *Tx) error) (outcome any, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			if r != (abort{}) && r != (retry{}) {
+				panic(r)
+			}
+			outcome = r
+		}
+	}()
+	return nil, fn(tx)
+}
+
+// commit locks every Var tx touched, checks that none of those it read
+// changed since tx started and writes its values with a new version. It
+// reports false if tx must run again.
+func (tx *Tx) commit() bool {
+	if len(tx.writes) == 0 {
+		return true // every read was consistent when it was made
+	}
+	vars := make([]tvar, 0, len(tx.reads)+len(tx.writes))
+	for v := range tx.reads {
+		vars = append(vars, v)
+	}
+	for v := range tx.writes {
+		if _, ok := tx.reads[v]; !ok {
+			vars = append(vars, v)
+		}
+	}
+	slices.SortFunc(vars, func(a, b tvar) int { return cmp.Compare(a.order(), b.order()) })
+	for _, v := range vars {
+		v.lock()
+	}
+	defer func() {
+		for _, v := range vars {
+			v.unlock()
+		}
+	}()
+	for v := range tx.reads {
+		if v.changedSince(tx.start) {
+			return false
+		}
+	}
+	version := clock.Add(1)
+	for v, x := range tx.writes {
+		v.store(x, version)
+	}
+	commitMu.Lock()
+	committed.Broadcast()
+	commitMu.Unlock()
+	return true
+}
+
+// waitCommit blocks until the clock moves past version or ctx is done.
+func waitCommit(ctx context.Context, version uint64) error {
+	defer context.AfterFunc(ctx, func() {
+		commitMu.Lock()
+		committed.Broadcast()
+		commitMu.Unlock()
+	})()
+	commitMu.Lock()
+	defer commitMu.Unlock()
+	for clock.Load() == version {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		committed.Wait()
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+package stm
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTransfersKeepTotal(t *testing.T) {
+	const accounts, goroutines, transfers = 5, 8, 500
+	vars := make([]*Var[int], accounts)
+	for i := range vars {
+		vars[i] = NewVar(100)
+	}
+	var wg sync.WaitGroup
+	for g := range goroutines {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range transfers {
+				from, to := vars[(g+i)%accounts], vars[(g+2*i+1)%accounts]
+				Atomically(context.Background(), func(tx *Tx) error {
+					from.Set(tx, from.Get(tx)-1)
+					to.Set(tx, to.Get(tx)+1)
+					return nil
+				})
+				// A read-only transaction always sees a consistent total.
+				Atomically(context.Background(), func(tx *Tx) error {
+					total := 0
+					for _, v := range vars {
+						total += v.Get(tx)
+					}
+					if total != accounts*100 {
+						t.Errorf("a transaction saw a total of %d", total)
+					}
+					return nil
+				})
+			}
+		}()
+	}
+	wg.Wait()
+	total := 0
+	for _, v := range vars {
+		total += v.Load()
+	}
+	if total != accounts*100 {
+		t.Errorf("total = %d, want %d", total, accounts*100)
+	}
+}
+
+func TestReadYourWrites(t *testing.T) {
+	v := NewVar("a")
+	Atomically(context.Background(), func(tx *Tx) error {
+		v.Set(tx, "b")
+		if got := v.Get(tx); got != "b" {
+			t.Errorf("Get after Set = %q", got)
+		}
+		if got := v.Load(); got != "a" {
+			t.Errorf("Load before commit = %q", got)
+		}
+		return nil
+	})
+	if got := v.Load(); got != "b" {
+		t.Errorf("Load after commit = %q", got)
+	}
+}
+
+func TestErrorDiscardsWrites(t *testing.T) {
+	v := NewVar(1)
+	errNo := errors.New("no")
+	if err := Atomically(context.Background(), func(tx *Tx) error {
+		v.Set(tx, 2)
+		return errNo
+	}); err != errNo {
+		t.Errorf("Atomically = %v, want %v", err, errNo)
+	}
+	if got := v.Load(); got != 1 {
+		t.Errorf("value = %d after a failed transaction, want 1", got)
+	}
+}
+
+func TestRetryWaitsForChange(t *testing.T) {
+	balance := NewVar(0)
+	withdrawn := make(chan struct{})
+	go func() {
+		Atomically(context.Background(), func(tx *Tx) error {
+			b := balance.Get(tx)
+			if b < 50 {
+				tx.Retry()
+			}
+			balance.Set(tx, b-50)
+			return nil
+		})
+		close(withdrawn)
+	}()
+
+	for range 3 {
+		time.Sleep(10 * time.Millisecond)
+		select {
+		case <-withdrawn:
+			t.Fatal("withdrew before the balance was large enough")
+		default:
+		}
+		Atomically(context.Background(), func(tx *Tx) error {
+			balance.Set(tx, balance.Get(tx)+20)
+			return nil
+		})
+	}
+	select {
+	case <-withdrawn:
+	case <-time.After(time.Second):
+		t.Fatal("the withdrawal never went through")
+	}
+	if got := balance.Load(); got != 10 {
+		t.Errorf("balance = %d, want 10", got)
+	}
+}
+
+func TestRetryGivesUpWhenDone(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	balance := NewVar(0)
+	err := Atomically(ctx, func(tx *Tx) error {
+		if balance.Get(tx) < 50 {
+			tx.Retry() // nobody ever deposits
+		}
+		return nil
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Atomically = %v, want the deadline", err)
+	}
+}
+
+func TestConflictsGiveUpWhenDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	x, y := NewVar(0), NewVar(0)
+	runs := 0
+	err := Atomically(ctx, func(tx *Tx) error {
+		if runs++; runs == 3 {
+			cancel()
+		}
+		y.Set(tx, x.Get(tx))
+		// A writer that always commits first, so this never can.
+		Atomically(context.Background(), func(tx *Tx) error {
+			x.Set(tx, x.Get(tx)+1)
+			return nil
+		})
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Atomically = %v, want context.Canceled", err)
+	}
+	if runs != 3 {
+		t.Errorf("fn ran %d times, want 3", runs)
+	}
+}
+
+func TestPanicsPassThrough(t *testing.T) {
+	defer func() {
+		if r := recover(); r != "boom" {
+			t.Errorf("recovered %v, want boom", r)
+		}
+	}()
+	Atomically(context.Background(), func(tx *Tx) error { panic("boom") })
+}
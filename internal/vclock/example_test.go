@@ -0,0 +1,24 @@
+package vclock_test
+
+import (
+	"fmt"
+
+	"github.com/lotusirous/gochan/internal/vclock"
+)
+
+func Example() {
+	alice := vclock.NewProcess("alice")
+	bob := vclock.NewProcess("bob")
+
+	sent := alice.Tick()
+	bobWrite := bob.Tick() // bob has not heard from alice yet
+	received := bob.Receive(sent)
+
+	fmt.Println(received)
+	fmt.Println(vclock.Compare(sent, received))
+	fmt.Println(vclock.Compare(sent, bobWrite))
+	// Output:
+	// {alice:1 bob:2}
+	// happened before
+	// concurrent
+}
@@ -0,0 +1,141 @@
+// Package vclock implements vector clocks, which timestamp events so that
+// comparing two timestamps tells whether one event happened before the
+// other or whether they were concurrent.
+//
+// Every process (here, typically a goroutine) counts its own events, and
+// carries along the highest count it has heard of from every other
+// process. Whatever passes between processes and orders them, such as a
+// message on a channel, an unlocked mutex or the start of a goroutine,
+// carries the sender's clock, and the receiver merges it into its own.
+// Event a then happened before event b exactly when a's clock is at most
+// b's in every entry and they differ, and two events are concurrent when
+// neither is at most the other. This is the happens-before relation of the
+// Go memory model, and what the race detector tracks to find two accesses
+// to the same memory that nothing orders.
+package vclock
+
+import (
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Clock maps process names to event counts. A missing name counts 0.
+type Clock map[string]uint64
+
+// Order is how two clocks compare.
+type Order int
+
+const (
+	Equal      Order = iota
+	Before           // every entry at most the other's, and one less
+	After            // the other way round
+	Concurrent       // neither is at most the other
+)
+
+func (o Order) String() string {
+	switch o {
+	case Equal:
+		return "equal"
+	case Before:
+		return "happened before"
+	case After:
+		return "happened after"
+	}
+	return "concurrent"
+}
+
+// Compare reports how a is ordered relative to b.
+func Compare(a, b Clock) Order {
+	less, more := false, false
+	for id, n := range a {
+		if n > b[id] {
+			more = true
+		} else if n < b[id] {
+			less = true
+		}
+	}
+	for id, n := range b {
+		if _, ok := a[id]; !ok && n > 0 {
+			less = true
+		}
+	}
+	switch {
+	case less && more:
+		return Concurrent
+	case less:
+		return Before
+	case more:
+		return After
+	}
+	return Equal
+}
+
+// Merge raises every entry of c to at least o's.
+func (c Clock) Merge(o Clock) {
+	for id, n := range o {
+		if n > c[id] {
+			c[id] = n
+		}
+	}
+}
+
+// Copy returns an independent copy of c.
+func (c Clock) Copy() Clock { return maps.Clone(c) }
+
+// String formats c as {a:1 b:2}, sorted by name.
+func (c Clock) String() string {
+	ids := slices.Sorted(maps.Keys(c))
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, id := range ids {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(id + ":" + strconv.FormatUint(c[id], 10))
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// Process is the clock of one process. It is not safe for concurrent use:
+// each goroutine keeps its own, and shares only the copies it returns.
+type Process struct {
+	id string
+	c  Clock
+}
+
+// NewProcess returns the clock of a process named id, before any event.
+func NewProcess(id string) *Process {
+	return &Process{id: id, c: Clock{}}
+}
+
+// ID returns the process name.
+func (p *Process) ID() string { return p.id }
+
+// Tick records a local event, or a send, and returns its timestamp. To
+// send, attach the timestamp to whatever is sent.
+func (p *Process) Tick() Clock {
+	p.c[p.id]++
+	return p.c.Copy()
+}
+
+// Receive records receiving something that carried the timestamp t and
+// returns the timestamp of the receipt.
+func (p *Process) Receive(t Clock) Clock {
+	p.c.Merge(t)
+	return p.Tick()
+}
+
+// Fork records starting another process, such as a goroutine, and returns
+// its clock. Everything p did so far happened before anything the new
+// process does.
+func (p *Process) Fork(id string) *Process {
+	child := &Process{id: id, c: p.Tick()}
+	child.c[id]++
+	return child
+}
+
+// Now returns the timestamp of p's latest event.
+func (p *Process) Now() Clock { return p.c.Copy() }
@@ -0,0 +1,59 @@
+package vclock
+
+import "testing"
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		a, b Clock
+		want Order
+	}{
+		{Clock{}, Clock{}, Equal},
+		{Clock{"a": 1}, Clock{"a": 1, "b": 0}, Equal},
+		{Clock{"a": 1}, Clock{"a": 2}, Before},
+		{Clock{"a": 1}, Clock{"a": 1, "b": 1}, Before},
+		{Clock{"a": 2, "b": 1}, Clock{"a": 1, "b": 1}, After},
+		{Clock{"a": 1}, Clock{"b": 1}, Concurrent},
+		{Clock{"a": 2, "b": 1}, Clock{"a": 1, "b": 2}, Concurrent},
+	}
+	for _, tt := range tests {
+		if got := Compare(tt.a, tt.b); got != tt.want {
+			t.Errorf("Compare(%v, %v) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestMessages(t *testing.T) {
+	a := NewProcess("a")
+	b := a.Fork("b")
+	forked := a.Now()
+
+	a1 := a.Tick()
+	b1 := b.Tick()
+	if got := Compare(a1, b1); got != Concurrent {
+		t.Errorf("independent events: %v", got)
+	}
+	if got := Compare(forked, b1); got != Before {
+		t.Errorf("fork and child event: %v", got)
+	}
+
+	sent := a.Tick()
+	got := b.Receive(sent)
+	if Compare(a1, got) != Before || Compare(b1, got) != Before {
+		t.Errorf("receipt %v not after %v and %v", got, a1, b1)
+	}
+	if a2 := a.Tick(); Compare(a2, got) != Concurrent {
+		t.Errorf("event after send %v vs receipt %v: %v", a2, got, Compare(a2, got))
+	}
+	if s := got.String(); s != "{a:3 b:3}" {
+		t.Errorf("String = %s", s)
+	}
+}
+
+func TestTimestampsAreCopies(t *testing.T) {
+	p := NewProcess("p")
+	ts := p.Tick()
+	p.Tick()
+	if ts["p"] != 1 {
+		t.Errorf("timestamp changed to %v", ts)
+	}
+}
@@ -0,0 +1,33 @@
+package ivar_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/lotusirous/gochan/ivar"
+)
+
+func Example() {
+	config := ivar.New[string]()
+
+	var wg sync.WaitGroup
+	for range 3 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, _ := config.Get(context.Background())
+			fmt.Println("started with", v)
+		}()
+	}
+
+	first := config.Put("prod")
+	second := config.Put("staging") // too late: the first Put wins
+	wg.Wait()
+	fmt.Println(first, second)
+	// Output:
+	// started with prod
+	// started with prod
+	// started with prod
+	// true false
+}
@@ -0,0 +1,66 @@
+// Package ivar provides IVar, a variable that is written once and read by
+// any number of goroutines, each waiting until it is written.
+//
+// It is what a done channel plus a result variable add up to, packaged so
+// the two cannot get out of step: a value can only be read once it is
+// there, and writing it is what wakes the readers. Use it to deliver a
+// one-shot result, such as a configuration loaded at startup or the
+// answer of whichever replica responds first.
+package ivar
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// IVar is a write-once variable. The zero value is not usable; call New.
+type IVar[T any] struct {
+	written atomic.Bool // claimed by the first Put
+	done    chan struct{}
+	v       T
+}
+
+// New returns an empty IVar.
+func New[T any]() *IVar[T] {
+	return &IVar[T]{done: make(chan struct{})}
+}
+
+// Put writes v and wakes every waiting Get if the IVar is still empty,
+// and reports whether it was. Later Puts change nothing, so racing
+// writers can all call Put and the first one wins.
+func (iv *IVar[T]) Put(v T) bool {
+	if !iv.written.CompareAndSwap(false, true) {
+		return false
+	}
+	iv.v = v
+	close(iv.done)
+	return true
+}
+
+// Get waits until the IVar is written and returns its value. It returns
+// ctx.Err() if ctx is done first.
+func (iv *IVar[T]) Get(ctx context.Context) (T, error) {
+	select {
+	case <-iv.done:
+		return iv.v, nil
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	}
+}
+
+// TryGet returns the value and true if the IVar has been written, or the
+// zero value and false without waiting.
+func (iv *IVar[T]) TryGet() (T, bool) {
+	select {
+	case <-iv.done:
+		return iv.v, true
+	default:
+		var zero T
+		return zero, false
+	}
+}
+
+// Done returns a channel that is closed once the IVar is written, for use
+// in a select.
+func (iv *IVar[T]) Done() <-chan struct{} { return iv.done }
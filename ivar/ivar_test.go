@@ -0,0 +1,80 @@
+package ivar
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestManyWaiters(t *testing.T) {
+	iv := New[string]()
+	var wg sync.WaitGroup
+	got := make([]string, 10)
+	for i := range got {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err := iv.Get(context.Background())
+			if err != nil {
+				t.Error(err)
+			}
+			got[i] = v
+		}()
+	}
+	if _, ok := iv.TryGet(); ok {
+		t.Error("TryGet succeeded before Put")
+	}
+	if !iv.Put("ready") {
+		t.Error("first Put lost")
+	}
+	wg.Wait()
+	for i, v := range got {
+		if v != "ready" {
+			t.Errorf("waiter %d got %q", i, v)
+		}
+	}
+	if v, ok := iv.TryGet(); !ok || v != "ready" {
+		t.Errorf("TryGet = %q, %v", v, ok)
+	}
+}
+
+func TestFirstPutWins(t *testing.T) {
+	iv := New[int]()
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var winners []int
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if iv.Put(i) {
+				mu.Lock()
+				winners = append(winners, i)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if len(winners) != 1 {
+		t.Fatalf("%d Puts won, want 1", len(winners))
+	}
+	if v, _ := iv.Get(context.Background()); v != winners[0] {
+		t.Errorf("Get = %d, but Put(%d) won", v, winners[0])
+	}
+}
+
+func TestGetTimesOut(t *testing.T) {
+	iv := New[int]()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	if _, err := iv.Get(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Get = %v, want deadline exceeded", err)
+	}
+	select {
+	case <-iv.Done():
+		t.Error("Done closed before Put")
+	default:
+	}
+}
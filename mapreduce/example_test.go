@@ -0,0 +1,27 @@
+package mapreduce_test
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/lotusirous/gochan/chans"
+	"github.com/lotusirous/gochan/mapreduce"
+)
+
+func ExampleCollect() {
+	ctx := context.Background()
+	lines := chans.FromSeq(ctx, slices.Values([]string{"the cat", "the dog", "a cat"}))
+
+	counts, err := mapreduce.Collect(ctx, lines, mapreduce.Job[string, string, int, int]{
+		Map: func(line string, emit func(string, int)) {
+			for _, w := range strings.Fields(line) {
+				emit(w, 1)
+			}
+		},
+		Reduce: func(_ string, ones []int) int { return len(ones) },
+	})
+	fmt.Println(counts, err)
+	// Output: map[a:1 cat:2 dog:1 the:2] <nil>
+}
@@ -0,0 +1,160 @@
+// Package mapreduce is a small, in-process MapReduce built from the chans
+// combinators.
+//
+// Map workers read the input and emit key/value pairs. The shuffle sends
+// each pair to the reduce partition that owns its key, so all values of a
+// key meet in one place. When the input is exhausted every reduce worker
+// groups its pairs by key and reduces each group once.
+package mapreduce
+
+import (
+	"context"
+	"hash/maphash"
+	"runtime"
+	"sync"
+
+	"github.com/lotusirous/gochan/chans"
+)
+
+// KV is a key and a value.
+type KV[K comparable, V any] struct {
+	Key   K
+	Value V
+}
+
+// Job describes a MapReduce computation from In records to one Out per
+// distinct key.
+type Job[In any, K comparable, V, Out any] struct {
+	// Map turns one input record into any number of pairs.
+	Map func(in In, emit func(K, V))
+	// Reduce combines all values emitted for key. It is called once per key.
+	Reduce func(key K, values []V) Out
+	// Mappers and Reducers set the number of workers of each phase. The
+	// default is GOMAXPROCS.
+	Mappers, Reducers int
+	// Partition picks the reducer, in [0, n), that owns key. The default
+	// hashes the key.
+	Partition func(key K, n int) int
+}
+
+// shuffleBatch is how many pairs a mapper buffers per partition before
+// sending them on, to keep channel operations off the per-pair path.
+const shuffleBatch = 256
+
+// Run runs j over in and streams one pair per distinct key, in no
+// particular order. The output is closed when every key has been reduced,
+// or early if ctx is done.
+func Run[In any, K comparable, V, Out any](ctx context.Context, in <-chan In, j Job[In, K, V, Out]) <-chan KV[K, Out] {
+	mappers, reducers := j.Mappers, j.Reducers
+	if mappers <= 0 {
+		mappers = runtime.GOMAXPROCS(0)
+	}
+	if reducers <= 0 {
+		reducers = runtime.GOMAXPROCS(0)
+	}
+	partition := j.Partition
+	if partition == nil {
+		seed := maphash.MakeSeed()
+		partition = func(key K, n int) int { return int(maphash.Comparable(seed, key) % uint64(n)) }
+	}
+
+	shuffle := make([]chan []KV[K, V], reducers)
+	for i := range shuffle {
+		shuffle[i] = make(chan []KV[K, V], mappers)
+	}
+	var wg sync.WaitGroup
+	for range mappers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			mapWorker(ctx, in, j.Map, partition, shuffle)
+		}()
+	}
+	go func() {
+		wg.Wait()
+		for _, ch := range shuffle {
+			close(ch)
+		}
+	}()
+
+	outs := make([]<-chan KV[K, Out], reducers)
+	for i, ch := range shuffle {
+		outs[i] = reduceWorker(ctx, chans.Flatten(ctx, ch), j.Reduce)
+	}
+	return chans.FanIn(ctx, outs...)
+}
+
+func mapWorker[In any, K comparable, V any](ctx context.Context, in <-chan In, fn func(In, func(K, V)), partition func(K, int) int, shuffle []chan []KV[K, V]) {
+	bufs := make([][]KV[K, V], len(shuffle))
+	flush := func(p int) bool {
+		select {
+		case shuffle[p] <- bufs[p]:
+			bufs[p] = nil
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+	ok := true
+	emit := func(k K, v V) {
+		if !ok {
+			return
+		}
+		p := partition(k, len(shuffle))
+		bufs[p] = append(bufs[p], KV[K, V]{k, v})
+		if len(bufs[p]) == shuffleBatch {
+			ok = flush(p)
+		}
+	}
+	for ok {
+		var rec In
+		select {
+		case rec, ok = <-in:
+		case <-ctx.Done():
+			return
+		}
+		if ok {
+			fn(rec, emit)
+		}
+	}
+	for p := range bufs {
+		if len(bufs[p]) > 0 && !flush(p) {
+			return
+		}
+	}
+}
+
+func reduceWorker[K comparable, V, Out any](ctx context.Context, in <-chan KV[K, V], fn func(K, []V) Out) <-chan KV[K, Out] {
+	out := make(chan KV[K, Out])
+	go func() {
+		defer close(out)
+		groups := make(map[K][]V)
+		for kv := range in {
+			groups[kv.Key] = append(groups[kv.Key], kv.Value)
+		}
+		if ctx.Err() != nil {
+			return // the input was cut short
+		}
+		for k, vs := range groups {
+			select {
+			case out <- KV[K, Out]{k, fn(k, vs)}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// Collect runs j over in and returns the results as a map. It returns
+// ctx's error if ctx is done before the job completes.
+func Collect[In any, K comparable, V, Out any](ctx context.Context, in <-chan In, j Job[In, K, V, Out]) (map[K]Out, error) {
+	m := make(map[K]Out)
+	for kv := range Run(ctx, in, j) {
+		m[kv.Key] = kv.Value
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
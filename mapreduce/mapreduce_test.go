@@ -0,0 +1,122 @@
+package mapreduce
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/lotusirous/gochan/chans"
+)
+
+var text = strings.Fields(strings.Repeat("the quick brown fox jumps over the lazy dog and the cat ", 200))
+
+func words(ctx context.Context, n int) <-chan string {
+	return chans.Generate(ctx, n, func(i int) string { return text[i%len(text)] })
+}
+
+func wordCount(mappers, reducers int) Job[string, string, int, int] {
+	return Job[string, string, int, int]{
+		Map: func(w string, emit func(string, int)) { emit(w, 1) },
+		Reduce: func(_ string, ones []int) int {
+			return len(ones)
+		},
+		Mappers:  mappers,
+		Reducers: reducers,
+	}
+}
+
+func TestWordCount(t *testing.T) {
+	const n = 10000
+	want := make(map[string]int)
+	for i := range n {
+		want[text[i%len(text)]]++
+	}
+	// A key split over two partitions would be reduced twice and Collect
+	// would keep only one of the partial counts.
+	for _, size := range [][2]int{{1, 1}, {4, 3}, {8, 16}} {
+		t.Run(fmt.Sprint(size), func(t *testing.T) {
+			got, err := Collect(context.Background(), words(context.Background(), n), wordCount(size[0], size[1]))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !maps.Equal(got, want) {
+				t.Errorf("counts = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestEveryKeyReducedOnceInItsPartition(t *testing.T) {
+	const reducers = 5
+	var mu sync.Mutex
+	owner := make(map[string]int) // partition each key was sent to
+	reduced := make(map[string]int)
+	j := Job[int, string, int, int]{
+		Map: func(i int, emit func(string, int)) {
+			emit(fmt.Sprintf("k%d", i%37), i)
+		},
+		Partition: func(key string, n int) int {
+			if n != reducers {
+				t.Errorf("Partition called with n = %d", n)
+			}
+			p := len(key) % n
+			mu.Lock()
+			if q, ok := owner[key]; ok && q != p {
+				t.Errorf("key %s sent to partitions %d and %d", key, q, p)
+			}
+			owner[key] = p
+			mu.Unlock()
+			return p
+		},
+		Reduce: func(key string, vs []int) int {
+			mu.Lock()
+			reduced[key]++
+			mu.Unlock()
+			sum := 0
+			for _, v := range vs {
+				if fmt.Sprintf("k%d", v%37) != key {
+					t.Errorf("value %d reduced under key %s", v, key)
+				}
+				sum += v
+			}
+			return sum
+		},
+		Mappers:  4,
+		Reducers: reducers,
+	}
+	in := chans.Generate(context.Background(), 3700, func(i int) int { return i })
+	got, err := Collect(context.Background(), in, j)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 37 {
+		t.Errorf("%d keys, want 37", len(got))
+	}
+	for k, n := range reduced {
+		if n != 1 {
+			t.Errorf("key %s reduced %d times", k, n)
+		}
+	}
+}
+
+func TestRunStopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	in := make(chan string) // never closed
+	out := Run(ctx, in, wordCount(2, 2))
+	in <- "a"
+	cancel()
+	for range out {
+	}
+	if _, err := Collect(ctx, in, wordCount(2, 2)); err != context.Canceled {
+		t.Errorf("Collect after cancel = %v, want context.Canceled", err)
+	}
+}
+
+func BenchmarkWordCount(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		Collect(context.Background(), words(context.Background(), 100000), wordCount(0, 0))
+	}
+}
@@ -0,0 +1,39 @@
+package mvar_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/lotusirous/gochan/mvar"
+)
+
+func ExampleMVar_Modify() {
+	ctx := context.Background()
+	balance := mvar.NewFull(100)
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			balance.Modify(ctx, func(b int) (int, error) { return b + 10, nil })
+		}()
+	}
+	wg.Wait()
+
+	fmt.Println(balance.Take(ctx))
+	// Output: 200 <nil>
+}
+
+func ExampleMVar_TryTake() {
+	m := mvar.New[string]()
+	_, ok := m.TryTake()
+	fmt.Println(ok)
+
+	m.TryPut("token")
+	fmt.Println(m.TryTake())
+	// Output:
+	// false
+	// token true
+}
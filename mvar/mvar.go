@@ -0,0 +1,101 @@
+// Package mvar provides MVar, a box that is either empty or holds one
+// value, after Haskell's MVar.
+//
+// Take empties the box, waiting for a value if there is none; Put fills
+// it, waiting for it to be emptied if it is full. That makes an MVar both
+// a one-slot mailbox between goroutines and a lock that carries the state
+// it protects: whoever has taken the value owns it until they put it back,
+// and everyone else waits in Take.
+//
+// An MVar is a channel with a buffer of one. The type exists to give the
+// operations names that say what the channel is for, and to make every
+// wait respect a context.
+package mvar
+
+import "context"
+
+// MVar is a box holding at most one T. The zero value is not usable; call
+// New or NewFull.
+type MVar[T any] struct {
+	c chan T
+}
+
+// New returns an empty MVar.
+func New[T any]() *MVar[T] {
+	return &MVar[T]{c: make(chan T, 1)}
+}
+
+// NewFull returns an MVar holding v.
+func NewFull[T any](v T) *MVar[T] {
+	m := New[T]()
+	m.c <- v
+	return m
+}
+
+// Take waits until the MVar is full, empties it and returns the value. It
+// returns ctx.Err() if ctx is done first. Waiting Takes are served one
+// value each.
+func (m *MVar[T]) Take(ctx context.Context) (T, error) {
+	select {
+	case v := <-m.c:
+		return v, nil
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	}
+}
+
+// Put waits until the MVar is empty and fills it with v. It returns
+// ctx.Err() if ctx is done first, and v is then not stored.
+func (m *MVar[T]) Put(ctx context.Context, v T) error {
+	select {
+	case m.c <- v:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// TryTake empties the MVar and returns its value and true if it is full,
+// or returns the zero value and false without waiting.
+func (m *MVar[T]) TryTake() (T, bool) {
+	select {
+	case v := <-m.c:
+		return v, true
+	default:
+		var zero T
+		return zero, false
+	}
+}
+
+// TryPut fills the MVar with v and returns true if it is empty, or returns
+// false without waiting.
+func (m *MVar[T]) TryPut(v T) bool {
+	select {
+	case m.c <- v:
+		return true
+	default:
+		return false
+	}
+}
+
+// Modify takes the value, replaces it with fn's result and puts that back,
+// so that nobody else sees the MVar between the two. It returns ctx.Err()
+// if ctx is done before the value could be taken. If fn returns an error
+// the old value is put back and the error returned.
+//
+// This only holds while everyone who puts has taken first, which is how
+// an MVar used as a lock is meant to be used.
+func (m *MVar[T]) Modify(ctx context.Context, fn func(T) (T, error)) error {
+	v, err := m.Take(ctx)
+	if err != nil {
+		return err
+	}
+	nv, err := fn(v)
+	if err != nil {
+		m.c <- v
+		return err
+	}
+	m.c <- nv
+	return nil
+}
@@ -0,0 +1,73 @@
+package mvar
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTakePut(t *testing.T) {
+	ctx := context.Background()
+	m := New[int]()
+	if _, ok := m.TryTake(); ok {
+		t.Error("TryTake succeeded on an empty MVar")
+	}
+	got := make(chan int)
+	go func() {
+		v, err := m.Take(ctx)
+		if err != nil {
+			t.Error(err)
+		}
+		got <- v
+	}()
+	if err := m.Put(ctx, 7); err != nil {
+		t.Fatal(err)
+	}
+	if v := <-got; v != 7 {
+		t.Errorf("Take = %d, want 7", v)
+	}
+	if !m.TryPut(8) || m.TryPut(9) {
+		t.Error("TryPut should fill an empty MVar and fail on a full one")
+	}
+}
+
+func TestWaitsTimeOut(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	if _, err := New[int]().Take(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Take on empty = %v, want deadline exceeded", err)
+	}
+	full := NewFull(1)
+	if err := full.Put(ctx, 2); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Put on full = %v, want deadline exceeded", err)
+	}
+	if v, _ := full.TryTake(); v != 1 {
+		t.Errorf("a timed out Put replaced the value with %d", v)
+	}
+}
+
+func TestModifyAsLock(t *testing.T) {
+	ctx := context.Background()
+	balance := NewFull(0)
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 100 {
+				balance.Modify(ctx, func(b int) (int, error) { return b + 1, nil })
+			}
+		}()
+	}
+	wg.Wait()
+
+	errNo := errors.New("no")
+	if err := balance.Modify(ctx, func(b int) (int, error) { return -1, errNo }); err != errNo {
+		t.Errorf("Modify = %v, want %v", err, errNo)
+	}
+	if v, _ := balance.Take(ctx); v != 800 {
+		t.Errorf("balance = %d, want 800", v)
+	}
+}
@@ -0,0 +1,21 @@
+// Package pad helps keep hot, independently written fields on separate CPU
+// cache lines.
+//
+// When two cores write to different variables that happen to share a cache
+// line, the line bounces between the cores as if the variables were shared
+// ("false sharing"). Putting a CacheLinePad between such fields avoids it at
+// the cost of some memory.
+package pad
+
+// CacheLineSize is the assumed size of a CPU cache line in bytes. 64 bytes is
+// right for amd64 and most arm64 cores; it is a performance hint only.
+const CacheLineSize = 64
+
+// CacheLinePad is a blank field that fills a whole cache line.
+//
+//	type counters struct {
+//		a atomic.Int64
+//		_ pad.CacheLinePad
+//		b atomic.Int64
+//	}
+type CacheLinePad struct{ _ [CacheLineSize]byte }
@@ -0,0 +1,195 @@
+// Package patterns is the supported entry point to the module: a small,
+// curated set of the concurrency utilities, under one import path.
+//
+// The compatibility promise covers what this package declares: the names
+// of its functions, types and variables, and the signatures of its
+// functions, keep compiling and behaving the same. Its types are aliases,
+// so values pass freely between this package and the one they come from;
+// the other side of that is that their methods and fields belong to those
+// packages, and change with them. The package offers:
+//
+//   - channel combinators from chans: FanIn, Map, Filter, Batch, Take, Tee,
+//     Generate, Collect, the iter.Seq bridges and Stream;
+//   - the goroutine worker pool from pool;
+//   - staged pipelines that fail as a whole from pipeline;
+//   - the token bucket rate limiter from ratelimit;
+//   - topic-based publish/subscribe from pubsub, which delivers every
+//     message, and latest-value broadcast to any number of watchers from
+//     watch, which delivers only the newest.
+//
+// The other packages of the module, including the richer APIs of those
+// above, are importable but may change between versions; reach for them
+// when this package is not enough, and expect to follow their changes.
+// The experimental ones, such as stm, lockfree and sim, live under
+// internal and serve the examples only.
+package patterns
+
+import (
+	"context"
+	"iter"
+	"time"
+
+	"github.com/lotusirous/gochan/chans"
+	"github.com/lotusirous/gochan/pipeline"
+	"github.com/lotusirous/gochan/pool"
+	"github.com/lotusirous/gochan/pubsub"
+	"github.com/lotusirous/gochan/ratelimit"
+	"github.com/lotusirous/gochan/watch"
+)
+
+// Channels.
+
+// FanIn merges inputs into one channel. See chans.FanIn.
+func FanIn[T any](ctx context.Context, inputs ...<-chan T) <-chan T {
+	return chans.FanIn(ctx, inputs...)
+}
+
+// Map applies fn to every value of in. See chans.Map.
+func Map[In, Out any](ctx context.Context, in <-chan In, fn func(In) Out) <-chan Out {
+	return chans.Map(ctx, in, fn)
+}
+
+// Filter passes on the values of in that keep accepts. See chans.Filter.
+func Filter[T any](ctx context.Context, in <-chan T, keep func(T) bool) <-chan T {
+	return chans.Filter(ctx, in, keep)
+}
+
+// Batch groups the values of in into slices of up to size. See chans.Batch.
+func Batch[T any](ctx context.Context, in <-chan T, size int) <-chan []T {
+	return chans.Batch(ctx, in, size)
+}
+
+// Take passes on the first n values of in. See chans.Take.
+func Take[T any](ctx context.Context, in <-chan T, n int) <-chan T {
+	return chans.Take(ctx, in, n)
+}
+
+// Tee copies every value of in to n channels. See chans.Tee.
+func Tee[T any](ctx context.Context, in <-chan T, n int) []<-chan T {
+	return chans.Tee(ctx, in, n)
+}
+
+// Generate sends fn(0), fn(1) and so on, n values unless n < 0. See
+// chans.Generate.
+func Generate[T any](ctx context.Context, n int, fn func(i int) T) <-chan T {
+	return chans.Generate(ctx, n, fn)
+}
+
+// Collect receives every value of in. See chans.Collect.
+func Collect[T any](ctx context.Context, in <-chan T) []T {
+	return chans.Collect(ctx, in)
+}
+
+// FromSeq sends the values of seq. See chans.FromSeq.
+func FromSeq[T any](ctx context.Context, seq iter.Seq[T]) <-chan T {
+	return chans.FromSeq(ctx, seq)
+}
+
+// ToSeq ranges over the values of c. See chans.ToSeq.
+func ToSeq[T any](ctx context.Context, c <-chan T) iter.Seq[T] {
+	return chans.ToSeq(ctx, c)
+}
+
+// Stream is a chainable channel. See chans.Stream.
+type Stream[T any] = chans.Stream[T]
+
+// NewStream wraps c. See chans.NewStream.
+func NewStream[T any](ctx context.Context, c <-chan T) Stream[T] {
+	return chans.NewStream(ctx, c)
+}
+
+// Worker pools.
+
+// Pool is what every worker pool implements. See pool.Pool.
+type Pool[In, Out any] = pool.Pool[In, Out]
+
+// WorkerPool runs jobs on a fixed number of goroutines. See
+// pool.GoroutinePool.
+type WorkerPool[In, Out any] = pool.GoroutinePool[In, Out]
+
+// Func processes one job. See pool.Func.
+type Func[In, Out any] = pool.Func[In, Out]
+
+// Result is the outcome of a job. See pool.Result.
+type Result[In, Out any] = pool.Result[In, Out]
+
+// PoolOption configures a worker pool. See pool.Option.
+type PoolOption = pool.Option
+
+// ErrClosed is returned by Submit after the pool has been closed.
+var ErrClosed = pool.ErrClosed
+
+// NewWorkerPool starts a pool that processes jobs with fn. See pool.New.
+func NewWorkerPool[In, Out any](fn Func[In, Out], opts ...PoolOption) *WorkerPool[In, Out] {
+	return pool.New(fn, opts...)
+}
+
+// WithWorkers sets the number of workers. See pool.WithWorkers.
+func WithWorkers(n int) PoolOption { return pool.WithWorkers(n) }
+
+// WithQueue sets how many jobs may wait for a worker. See pool.WithQueue.
+func WithQueue(n int) PoolOption { return pool.WithQueue(n) }
+
+// Pipelines.
+
+// Pipeline is a set of stages that fail together. See pipeline.Pipeline.
+type Pipeline = pipeline.Pipeline
+
+// StageError is the failure of a pipeline stage. See pipeline.StageError.
+type StageError = pipeline.StageError
+
+// PipelineOption configures a pipeline. See pipeline.Option.
+type PipelineOption = pipeline.Option
+
+// NewPipeline starts an empty pipeline. See pipeline.New.
+func NewPipeline(ctx context.Context, opts ...PipelineOption) *Pipeline {
+	return pipeline.New(ctx, opts...)
+}
+
+// Source adds a stage that produces values. See pipeline.Source.
+func Source[Out any](p *Pipeline, name string, fn func(ctx context.Context, emit func(Out) bool) error) <-chan Out {
+	return pipeline.Source(p, name, fn)
+}
+
+// Stage adds a stage that transforms values. See pipeline.Stage.
+func Stage[In, Out any](p *Pipeline, name string, in <-chan In, fn func(ctx context.Context, v In) (Out, error)) <-chan Out {
+	return pipeline.Stage(p, name, in, fn)
+}
+
+// Broadcast.
+
+// Value broadcasts the latest value to its watchers. See watch.Value.
+type Value[T any] = watch.Value[T]
+
+// ValueOption configures a Value. See watch.Option.
+type ValueOption = watch.Option
+
+// NewValue returns a Value holding initial. See watch.New.
+func NewValue[T any](initial T, opts ...ValueOption) *Value[T] {
+	return watch.New(initial, opts...)
+}
+
+// Broker delivers messages published on a topic to its subscribers. See
+// pubsub.Broker.
+type Broker[T any] = pubsub.Broker[T]
+
+// ErrBrokerClosed is returned by Publish after the Broker has been closed.
+var ErrBrokerClosed = pubsub.ErrClosed
+
+// NewBroker returns a Broker with no subscribers. See pubsub.New.
+func NewBroker[T any]() *Broker[T] { return pubsub.New[T]() }
+
+// Rate limiting.
+
+// Limiter lets jobs start at most once per interval, with bursts. See
+// ratelimit.Limiter.
+type Limiter = ratelimit.Limiter
+
+// LimiterOption configures a Limiter. See ratelimit.Option.
+type LimiterOption = ratelimit.Option
+
+// NewLimiter returns a full Limiter that earns a token every interval and
+// holds at most burst. See ratelimit.New.
+func NewLimiter(every time.Duration, burst int, opts ...LimiterOption) *Limiter {
+	return ratelimit.New(every, burst, opts...)
+}
@@ -0,0 +1,103 @@
+package patterns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/lotusirous/gochan/pool"
+	"github.com/lotusirous/gochan/pubsub"
+	"github.com/lotusirous/gochan/ratelimit"
+	"github.com/lotusirous/gochan/watch"
+)
+
+func TestTogether(t *testing.T) {
+	ctx := context.Background()
+	evens := Generate(ctx, 5, func(i int) int { return 2 * i })
+	odds := Generate(ctx, 5, func(i int) int { return 2*i + 1 })
+
+	p := NewPipeline(ctx)
+	merged := Source(p, "merge", func(ctx context.Context, emit func(int) bool) error {
+		for v := range ToSeq(ctx, FanIn(ctx, evens, odds)) {
+			if !emit(v) {
+				return nil
+			}
+		}
+		return nil
+	})
+	squares := Stage(p, "square", merged, func(ctx context.Context, v int) (int, error) { return v * v, nil })
+	got := Collect(ctx, squares)
+	if err := p.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	slices.Sort(got)
+	if want := []int{0, 1, 4, 9, 16, 25, 36, 49, 64, 81}; !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestAliasesAreTheSameTypes(t *testing.T) {
+	square := func(ctx context.Context, n int) (int, error) { return n * n, nil }
+	var wp *pool.GoroutinePool[int, int] = NewWorkerPool(square, WithWorkers(2), pool.WithQueue(4))
+	var _ Pool[int, int] = wp
+	wp.Close()
+	if err := wp.Submit(context.Background(), 1); !errors.Is(err, pool.ErrClosed) || !errors.Is(err, ErrClosed) {
+		t.Errorf("Submit after Close = %v", err)
+	}
+}
+
+func TestBrokerAndLimiter(t *testing.T) {
+	ctx := context.Background()
+	var b *pubsub.Broker[string] = NewBroker[string]()
+	msgs := b.Subscribe(ctx, "news", 1)
+	if err := b.Publish(ctx, "news", "hello"); err != nil {
+		t.Fatal(err)
+	}
+	b.Close()
+	if got := <-msgs; got != "hello" {
+		t.Errorf("got %q, want hello", got)
+	}
+	if err := b.Publish(ctx, "news", "late"); !errors.Is(err, ErrBrokerClosed) {
+		t.Errorf("Publish after Close = %v", err)
+	}
+
+	var l *ratelimit.Limiter = NewLimiter(time.Hour, 2)
+	if !l.Allow() || !l.Allow() || l.Allow() {
+		t.Error("want a burst of exactly 2")
+	}
+}
+
+type publishes int
+
+func (p *publishes) Published(int) { *p++ }
+func (p *publishes) Coalesced()    {}
+
+func TestNewValuePassesOptions(t *testing.T) {
+	var m publishes
+	v := NewValue(0, watch.WithMetrics(&m))
+	v.Set(1)
+	if m != 1 {
+		t.Errorf("metrics saw %d publishes, want 1", m)
+	}
+}
+
+func Example() {
+	ctx := context.Background()
+	words := FromSeq(ctx, slices.Values([]string{"fan", "in", "and", "out"}))
+	lengths := NewWorkerPool(func(ctx context.Context, w string) (int, error) { return len(w), nil }, WithWorkers(2))
+	go func() {
+		for w := range ToSeq(ctx, words) {
+			lengths.Submit(ctx, w)
+		}
+		lengths.Close()
+	}()
+	total := 0
+	for r := range lengths.Results() {
+		total += r.Must()
+	}
+	fmt.Println(total)
+	// Output: 11
+}
@@ -0,0 +1,71 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+// perMessage is the element-per-message design: every element travels to a
+// worker over one channel and its result comes back over another.
+func perMessage(in []int, workers int, fn func(int) int) []int {
+	type item struct{ i, v int }
+	jobs := make(chan item, 128)
+	results := make(chan item, 128)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for it := range jobs {
+				results <- item{it.i, fn(it.v)}
+			}
+		}()
+	}
+	go func() {
+		for i, v := range in {
+			jobs <- item{i, v}
+		}
+		close(jobs)
+		wg.Wait()
+		close(results)
+	}()
+	out := make([]int, len(in))
+	for r := range results {
+		out[r.i] = r.v
+	}
+	return out
+}
+
+// BenchmarkChunkedVsPerMessage shows where each design wins. Per element,
+// the channel round trip costs a few hundred nanoseconds; once fn costs much
+// more than that the two designs converge, and below it MapChunks wins by an
+// order of magnitude.
+func BenchmarkChunkedVsPerMessage(b *testing.B) {
+	const size = 100_000
+	in := make([]int, size)
+	for i := range in {
+		in[i] = i
+	}
+	for _, cost := range []int{1, 100, 1000} {
+		fn := func(v int) int {
+			for j := 0; j < cost; j++ {
+				v = v*31 + j
+			}
+			return v
+		}
+		b.Run(fmt.Sprintf("Cost%d/PerMessage", cost), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				perMessage(in, 4, fn)
+			}
+		})
+		for _, chunk := range []int{64, 4096} {
+			b.Run(fmt.Sprintf("Cost%d/Chunks%d", cost, chunk), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					MapChunks(context.Background(), in, chunk, 4, fn)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,69 @@
+// Package pipeline contains processing stages that are larger than a single
+// channel combinator, and Pipeline, which connects stages through channels
+// and keeps the reason it stopped as the context's cancellation cause.
+package pipeline
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"sync/atomic"
+
+	"github.com/lotusirous/gochan/result"
+)
+
+// MapChunks applies fn to every element of in and returns the results in the
+// same order.
+//
+// Instead of sending every element through a channel, the index range of in
+// is split into chunks of chunkSize and workers claim whole chunks through an
+// atomic counter. Each worker writes its results straight into its part of
+// the output slice, so there is no per-element synchronization at all. The
+// price is that results are only available once everything is done.
+//
+// If workers <= 0 it uses GOMAXPROCS. It returns context.Cause(ctx) if ctx
+// is done before all chunks were processed.
+func MapChunks[In, Out any](ctx context.Context, in []In, chunkSize, workers int, fn func(In) Out) ([]Out, error) {
+	if chunkSize <= 0 {
+		chunkSize = 1
+	}
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	chunks := (len(in) + chunkSize - 1) / chunkSize
+	workers = min(workers, chunks)
+
+	out := make([]Out, len(in))
+	var next atomic.Int64
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ctx.Err() == nil {
+				c := int(next.Add(1) - 1)
+				if c >= chunks {
+					return
+				}
+				lo := c * chunkSize
+				hi := min(lo+chunkSize, len(in))
+				for i := lo; i < hi; i++ {
+					out[i] = fn(in[i])
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if ctx.Err() != nil {
+		return nil, context.Cause(ctx)
+	}
+	return out, nil
+}
+
+// TryMapChunks is MapChunks for functions that can fail. One failing element
+// does not stop the others; its error is kept in its own Result.
+func TryMapChunks[In, Out any](ctx context.Context, in []In, chunkSize, workers int, fn func(In) (Out, error)) ([]result.Result[Out], error) {
+	return MapChunks(ctx, in, chunkSize, workers, func(v In) result.Result[Out] {
+		return result.Of(fn(v))
+	})
+}
@@ -0,0 +1,119 @@
+package pipeline
+
+import (
+	"hash/maphash"
+	"math"
+	"math/bits"
+	"sync"
+	"sync/atomic"
+)
+
+// Seen remembers the keys Distinct has let through.
+type Seen[K comparable] interface {
+	// TestAndAdd records k and reports whether it had been recorded
+	// before.
+	TestAndAdd(k K) bool
+}
+
+// Bloom is a Bloom filter: a Seen that needs a fixed number of bits per key
+// but may answer true for a key it has never recorded. It is safe for
+// concurrent use; bits are set with atomic ORs, so recording never waits
+// for a lock. Two goroutines recording the same new key at once may both be
+// told it is new.
+type Bloom[K comparable] struct {
+	bits []atomic.Uint64
+	m    uint64 // number of bits
+	k    int    // number of hash functions
+	seed maphash.Seed
+}
+
+// NewBloom returns a Bloom filter sized for n keys with a false positive
+// rate of about fp.
+func NewBloom[K comparable](n int, fp float64) *Bloom[K] {
+	n = max(n, 1)
+	m := uint64(math.Ceil(-float64(n) * math.Log(fp) / (math.Ln2 * math.Ln2)))
+	m = max(m, 64)
+	k := max(int(math.Round(float64(m)/float64(n)*math.Ln2)), 1)
+	return &Bloom[K]{
+		bits: make([]atomic.Uint64, (m+63)/64),
+		m:    m,
+		k:    k,
+		seed: maphash.MakeSeed(),
+	}
+}
+
+// TestAndAdd implements Seen. It sets the key's k bits and reports whether
+// all of them were set already.
+func (b *Bloom[K]) TestAndAdd(key K) bool {
+	// Double hashing: the i-th bit is picked by h1 + i*h2, which is as
+	// good as k independent hashes for a Bloom filter. h2 is derived from
+	// h1 by a multiplicative mix rather than a second hash of the key.
+	h1 := maphash.Comparable(b.seed, key)
+	h2 := (h1>>29^h1)*0x9e3779b97f4a7c15 | 1
+	seen := true
+	for i := range b.k {
+		// The high word of (h * m) maps h to [0, m) without a division.
+		bit, _ := bits.Mul64(h1+uint64(i)*h2, b.m)
+		mask := uint64(1) << (bit % 64)
+		if b.bits[bit/64].Or(mask)&mask == 0 {
+			seen = false
+		}
+	}
+	return seen
+}
+
+// Bytes returns the size of the filter's bit array.
+func (b *Bloom[K]) Bytes() int { return len(b.bits) * 8 }
+
+// ExactSet is a Seen that never errs, at the cost of storing every key.
+type ExactSet[K comparable] struct {
+	mu   sync.Mutex
+	keys map[K]struct{}
+}
+
+// NewExactSet returns an empty ExactSet.
+func NewExactSet[K comparable]() *ExactSet[K] {
+	return &ExactSet[K]{keys: make(map[K]struct{})}
+}
+
+// TestAndAdd implements Seen.
+func (s *ExactSet[K]) TestAndAdd(k K) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.keys[k]; ok {
+		return true
+	}
+	s.keys[k] = struct{}{}
+	return false
+}
+
+// Distinct adds a stage that passes on the values from in whose key seen
+// has not recorded yet, and drops the others. With an ExactSet it drops
+// exactly the duplicates; with a Bloom filter memory stays fixed however
+// long the stream, and a small fraction of first occurrences is dropped as
+// well. Several stages may share seen.
+func Distinct[T any, K comparable](p *Pipeline, name string, in <-chan T, key func(T) K, seen Seen[K]) <-chan T {
+	out := make(chan T, p.buffer)
+	p.start(name, func() {
+		defer close(out)
+		for {
+			select {
+			case v, ok := <-in:
+				if !ok {
+					return
+				}
+				if seen.TestAndAdd(key(v)) {
+					continue
+				}
+				select {
+				case out <- v:
+				case <-p.ctx.Done():
+					return
+				}
+			case <-p.ctx.Done():
+				return
+			}
+		}
+	})
+	return out
+}
@@ -0,0 +1,127 @@
+package pipeline
+
+import (
+	"context"
+	"runtime"
+	"slices"
+	"sync"
+	"testing"
+)
+
+func identity(v int) int { return v }
+
+// repeats emits 0..n-1 twice, the second time interleaved with the first.
+func repeats(n int) func(ctx context.Context, emit func(int) bool) error {
+	return func(ctx context.Context, emit func(int) bool) error {
+		for i := range n {
+			if !emit(i) || (i%2 == 1 && !emit(i-1)) {
+				break
+			}
+		}
+		for i := 1; i < n; i += 2 {
+			if !emit(i) {
+				break
+			}
+		}
+		return nil
+	}
+}
+
+func TestDistinctExact(t *testing.T) {
+	p := New(context.Background())
+	out := Distinct(p, "distinct", Source(p, "nums", repeats(1000)), identity, NewExactSet[int]())
+	var got []int
+	for v := range out {
+		got = append(got, v)
+	}
+	if err := p.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	want := make([]int, 1000)
+	for i := range want {
+		want[i] = i
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %d values, want 0..999 once each in order", len(got))
+	}
+}
+
+func TestDistinctBloom(t *testing.T) {
+	const n = 100000
+	p := New(context.Background())
+	out := Distinct(p, "distinct", Source(p, "nums", repeats(n)), identity, NewBloom[int](n, 0.01))
+	seen := make(map[int]bool)
+	for v := range out {
+		if seen[v] {
+			t.Fatalf("duplicate %d let through", v)
+		}
+		seen[v] = true
+	}
+	p.Wait()
+	// Only false positives are dropped, about 1% of the keys.
+	if lost := n - len(seen); lost > n/50 {
+		t.Errorf("dropped %d of %d distinct keys, want about 1%%", lost, n)
+	}
+}
+
+func TestBloomConcurrentAdd(t *testing.T) {
+	const n, workers = 20000, 8
+	b := NewBloom[int](n*workers, 0.001)
+	var wg sync.WaitGroup
+	for w := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range n {
+				b.TestAndAdd(w*n + i)
+			}
+		}()
+	}
+	wg.Wait()
+	for i := range n * workers {
+		if !b.TestAndAdd(i) {
+			t.Fatalf("key %d was added but is not in the filter", i)
+		}
+	}
+}
+
+// BenchmarkSeen10M records a stream of 10M keys, half of them repeats, and
+// reports the memory each Seen needed for it.
+func BenchmarkSeen10M(b *testing.B) {
+	const n = 10000000
+	for _, tc := range []struct {
+		name string
+		new  func() (Seen[uint64], func() int)
+	}{
+		{"bloom-1%", func() (Seen[uint64], func() int) {
+			s := NewBloom[uint64](n/2, 0.01)
+			return s, s.Bytes
+		}},
+		{"exact", func() (Seen[uint64], func() int) {
+			var before runtime.MemStats
+			runtime.GC()
+			runtime.ReadMemStats(&before)
+			s := NewExactSet[uint64]()
+			return s, func() int {
+				var after runtime.MemStats
+				runtime.GC()
+				runtime.ReadMemStats(&after)
+				runtime.KeepAlive(s)
+				return int(after.HeapAlloc - before.HeapAlloc)
+			}
+		}},
+	} {
+		b.Run(tc.name, func(b *testing.B) {
+			var size int
+			for i := 0; i < b.N; i++ {
+				s, bytes := tc.new()
+				for j := range uint64(n) {
+					s.TestAndAdd(j / 2)
+				}
+				size = bytes()
+			}
+			b.ReportMetric(float64(size)/(1<<20), "MB")
+			b.ReportMetric(float64(b.Elapsed().Nanoseconds())/float64(b.N*n), "ns/key")
+		})
+	}
+}
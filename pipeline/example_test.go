@@ -0,0 +1,45 @@
+package pipeline_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/lotusirous/gochan/pipeline"
+)
+
+func ExampleNew() {
+	p := pipeline.New(context.Background())
+	lines := pipeline.Source(p, "read", func(ctx context.Context, emit func(string) bool) error {
+		for _, line := range []string{"1", "2", "three", "4"} {
+			if !emit(line) {
+				return nil
+			}
+		}
+		return nil
+	})
+	numbers := pipeline.Stage(p, "parse", lines, func(ctx context.Context, line string) (int, error) {
+		return strconv.Atoi(line)
+	})
+	doubled := pipeline.Stage(p, "double", numbers, func(ctx context.Context, n int) (int, error) {
+		return 2 * n, nil
+	})
+	for n := range doubled {
+		_ = n // 2 and 4; values in flight when parse fails may be dropped
+	}
+
+	// The failing stage stopped every other stage, and says why.
+	err := p.Wait()
+	var se *pipeline.StageError
+	if errors.As(err, &se) {
+		fmt.Println("stopped by", se.Stage+":", se.Err)
+	}
+	// Output: stopped by parse: strconv.Atoi: parsing "three": invalid syntax
+}
+
+func ExampleMapChunks() {
+	squares, err := pipeline.MapChunks(context.Background(), []int{1, 2, 3, 4, 5}, 2, 3, func(n int) int { return n * n })
+	fmt.Println(squares, err)
+	// Output: [1 4 9 16 25] <nil>
+}
@@ -0,0 +1,69 @@
+package pipeline
+
+import (
+	"hash/maphash"
+	"sync"
+)
+
+// GroupBy adds a sink stage that folds the values from in into one
+// accumulator per key, starting from the zero A. Its workers read in
+// concurrently and fold each value into the shard of the map that owns its
+// key, so workers only contend when they touch keys of the same shard
+// rather than on every value. The shards hold disjoint keys and are merged
+// into the one map sent on the returned channel once in is drained. If the
+// pipeline stops first the channel is closed without a value.
+func GroupBy[In any, K comparable, A any](p *Pipeline, name string, in <-chan In, shards int, key func(In) K, fold func(acc A, v In) A) <-chan map[K]A {
+	shards = max(shards, 1)
+	type shard struct {
+		mu sync.Mutex
+		m  map[K]A
+	}
+	parts := make([]shard, shards)
+	for i := range parts {
+		parts[i].m = make(map[K]A)
+	}
+	seed := maphash.MakeSeed()
+
+	out := make(chan map[K]A, 1)
+	p.start(name, func() {
+		defer close(out)
+		var wg sync.WaitGroup
+		for range shards {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for {
+					select {
+					case v, ok := <-in:
+						if !ok {
+							return
+						}
+						k := key(v)
+						s := &parts[maphash.Comparable(seed, k)%uint64(shards)]
+						s.mu.Lock()
+						s.m[k] = fold(s.m[k], v)
+						s.mu.Unlock()
+					case <-p.ctx.Done():
+						return
+					}
+				}
+			}()
+		}
+		wg.Wait()
+		if p.ctx.Err() != nil {
+			return
+		}
+		n := 0
+		for i := range parts {
+			n += len(parts[i].m)
+		}
+		merged := make(map[K]A, n)
+		for i := range parts {
+			for k, a := range parts[i].m {
+				merged[k] = a
+			}
+		}
+		out <- merged
+	})
+	return out
+}
@@ -0,0 +1,106 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"sync"
+	"testing"
+)
+
+type sale struct {
+	Region string
+	Amount int
+}
+
+func sales(n int) func(ctx context.Context, emit func(sale) bool) error {
+	return func(ctx context.Context, emit func(sale) bool) error {
+		for i := range n {
+			if !emit(sale{fmt.Sprintf("r%d", i%50), i}) {
+				break
+			}
+		}
+		return nil
+	}
+}
+
+func region(s sale) string { return s.Region }
+
+func sum(acc int, s sale) int { return acc + s.Amount }
+
+func TestGroupBy(t *testing.T) {
+	const n = 10000
+	want := make(map[string]int)
+	for i := range n {
+		want[fmt.Sprintf("r%d", i%50)] += i
+	}
+	for _, shards := range []int{0, 1, 4, 16} {
+		p := New(context.Background())
+		totals := GroupBy(p, "group", Source(p, "sales", sales(n)), shards, region, sum)
+		if err := p.Wait(); err != nil {
+			t.Fatal(err)
+		}
+		if got := <-totals; !maps.Equal(got, want) {
+			t.Errorf("%d shards: totals = %v, want %v", shards, got, want)
+		}
+	}
+}
+
+func TestGroupByStopsWithPipeline(t *testing.T) {
+	p := New(context.Background())
+	nums := Source(p, "sales", sales(1000))
+	checked := Stage(p, "check", nums, func(ctx context.Context, s sale) (sale, error) {
+		if s.Amount == 500 {
+			return s, errBoom
+		}
+		return s, nil
+	})
+	totals := GroupBy(p, "group", checked, 4, region, sum)
+	if err := p.Wait(); !errors.Is(err, errBoom) {
+		t.Fatalf("Wait = %v, want boom", err)
+	}
+	if m, ok := <-totals; ok {
+		t.Errorf("got partial totals %v from a failed pipeline", m)
+	}
+}
+
+// lockedGroupBy is the baseline: the same workers fold into one map behind
+// one mutex.
+func lockedGroupBy(in <-chan sale, workers int) map[string]int {
+	var mu sync.Mutex
+	m := make(map[string]int)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for s := range in {
+				mu.Lock()
+				m[s.Region] += s.Amount
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	return m
+}
+
+func BenchmarkGroupBy(b *testing.B) {
+	const n, workers = 100000, 8
+	b.Run("single-mutex", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			p := New(context.Background())
+			lockedGroupBy(Source(p, "sales", sales(n)), workers)
+			p.Wait()
+		}
+	})
+	b.Run("sharded", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			p := New(context.Background())
+			totals := GroupBy(p, "group", Source(p, "sales", sales(n)), workers, region, sum)
+			p.Wait()
+			<-totals
+		}
+	})
+}
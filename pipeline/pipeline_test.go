@@ -0,0 +1,63 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestMapChunks(t *testing.T) {
+	in := make([]int, 1001)
+	for i := range in {
+		in[i] = i
+	}
+	for _, chunk := range []int{0, 1, 7, 100, 5000} {
+		out, err := MapChunks(context.Background(), in, chunk, 4, func(v int) int { return v * 2 })
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, v := range out {
+			if v != i*2 {
+				t.Fatalf("chunk=%d: out[%d] = %d", chunk, i, v)
+			}
+		}
+	}
+}
+
+func TestMapChunksEmpty(t *testing.T) {
+	out, err := MapChunks(context.Background(), []int{}, 10, 4, func(v int) int { return v })
+	if err != nil || len(out) != 0 {
+		t.Errorf("MapChunks(empty) = %v, %v", out, err)
+	}
+}
+
+func TestMapChunksCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := MapChunks(ctx, make([]int, 100), 10, 2, func(v int) int { return v })
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("MapChunks returned %v, want context.Canceled", err)
+	}
+}
+
+func TestTryMapChunks(t *testing.T) {
+	errOdd := errors.New("odd")
+	got, err := TryMapChunks(context.Background(), []int{1, 2, 3, 4}, 3, 2, func(v int) (int, error) {
+		if v%2 == 1 {
+			return 0, errOdd
+		}
+		return v * 10, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, r := range got {
+		if v := i + 1; v%2 == 1 {
+			if r.Err != errOdd {
+				t.Errorf("result %d error = %v, want %v", i, r.Err, errOdd)
+			}
+		} else if r.Must() != v*10 {
+			t.Errorf("result %d = %d, want %d", i, r.Value, v*10)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package pipeline
+
+import (
+	"math/rand"
+	"sync"
+)
+
+// Reservoir keeps a uniform random sample of at most k of the values added
+// to it, however many that is, in O(k) memory (Vitter's algorithm R). It is
+// safe for concurrent use.
+type Reservoir[T any] struct {
+	mu     sync.Mutex
+	k      int
+	rng    *rand.Rand
+	seen   int64
+	sample []T
+}
+
+// NewReservoir returns an empty reservoir of size k drawing from a random
+// source seeded with seed, so the same stream gives the same sample. A k
+// below 0 is taken as 0, which keeps the sample empty.
+func NewReservoir[T any](k int, seed int64) *Reservoir[T] {
+	k = max(k, 0)
+	return &Reservoir[T]{k: k, rng: rand.New(rand.NewSource(seed)), sample: make([]T, 0, k)}
+}
+
+// Add offers v to the sample. The n-th value added ends up in the sample
+// with probability k/n, evicting a random earlier one.
+func (r *Reservoir[T]) Add(v T) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.seen++
+	if len(r.sample) < r.k {
+		r.sample = append(r.sample, v)
+		return
+	}
+	if j := r.rng.Int63n(r.seen); j < int64(r.k) {
+		r.sample[j] = v
+	}
+}
+
+// Sample returns a copy of the current sample.
+func (r *Reservoir[T]) Sample() []T {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]T(nil), r.sample...)
+}
+
+// Seen returns the number of values added.
+func (r *Reservoir[T]) Seen() int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.seen
+}
+
+// SampleK adds a sink stage that draws a uniform sample of k values from
+// in, which may be unbounded, and sends it once in is drained. If the
+// pipeline stops first the channel is closed without a value. The sample
+// depends only on the order of the values and seed.
+func SampleK[T any](p *Pipeline, name string, in <-chan T, k int, seed int64) <-chan []T {
+	r := NewReservoir[T](k, seed)
+	out := make(chan []T, 1)
+	p.start(name, func() {
+		defer close(out)
+		for {
+			select {
+			case v, ok := <-in:
+				if !ok {
+					out <- r.Sample()
+					return
+				}
+				r.Add(v)
+			case <-p.ctx.Done():
+				return
+			}
+		}
+	})
+	return out
+}
@@ -0,0 +1,98 @@
+package pipeline
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+)
+
+func count(n int) func(ctx context.Context, emit func(int) bool) error {
+	return func(ctx context.Context, emit func(int) bool) error {
+		for i := range n {
+			if !emit(i) {
+				break
+			}
+		}
+		return nil
+	}
+}
+
+func TestSampleKIsUniform(t *testing.T) {
+	const n, k, trials = 100, 10, 4000
+	hits := make([]int, n)
+	for seed := range int64(trials) {
+		p := New(context.Background())
+		sample := SampleK(p, "sample", Source(p, "count", count(n)), k, seed)
+		if err := p.Wait(); err != nil {
+			t.Fatal(err)
+		}
+		s := <-sample
+		if len(s) != k {
+			t.Fatalf("sample of %d, want %d", len(s), k)
+		}
+		for _, v := range s {
+			hits[v]++
+		}
+	}
+
+	// Every value should be picked trials*k/n times. Chi-squared with n-1 =
+	// 99 degrees of freedom exceeds 148 with probability 0.001; the seeds
+	// are fixed, so this either always passes or always fails.
+	expected := float64(trials*k) / n
+	chi2 := 0.0
+	for _, h := range hits {
+		d := float64(h) - expected
+		chi2 += d * d / expected
+	}
+	if chi2 > 148 {
+		t.Errorf("chi-squared = %.1f, sample is not uniform: %v", chi2, hits)
+	}
+	// Early and late values must be equally likely. The difference between
+	// the halves has a standard deviation of about sqrt(trials*k); allow 4.
+	first, last := 0, 0
+	for i := range n / 2 {
+		first += hits[i]
+		last += hits[n/2+i]
+	}
+	if d := first - last; d*d > 16*trials*k {
+		t.Errorf("first half picked %d times, second half %d", first, last)
+	}
+}
+
+func TestSampleKShortStream(t *testing.T) {
+	p := New(context.Background())
+	sample := SampleK(p, "sample", Source(p, "count", count(3)), 10, 1)
+	p.Wait()
+	if got := <-sample; !slices.Equal(got, []int{0, 1, 2}) {
+		t.Errorf("sample = %v, want the whole stream", got)
+	}
+}
+
+func TestReservoirNegativeK(t *testing.T) {
+	r := NewReservoir[int](-1, 1)
+	for i := range 10 {
+		r.Add(i)
+	}
+	if s := r.Sample(); len(s) != 0 {
+		t.Errorf("Sample = %v, want empty", s)
+	}
+}
+
+func TestReservoirConcurrentAdd(t *testing.T) {
+	r := NewReservoir[int](5, 1)
+	var wg sync.WaitGroup
+	for w := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 1000 {
+				r.Add(w*1000 + i)
+			}
+		}()
+	}
+	wg.Wait()
+	if r.Seen() != 8000 || len(r.Sample()) != 5 {
+		t.Errorf("seen %d, sample %v", r.Seen(), r.Sample())
+	}
+}
@@ -0,0 +1,214 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"runtime/trace"
+	"sync"
+	"time"
+
+	"github.com/lotusirous/gochan/clock"
+)
+
+// StageError is the cancellation cause recorded when a stage fails. Every
+// other stage sees it through context.Cause, so the reason a pipeline stopped
+// is not lost as a bare context.Canceled somewhere downstream.
+type StageError struct {
+	Stage string
+	Err   error
+}
+
+func (e *StageError) Error() string { return "pipeline: stage " + e.Stage + ": " + e.Err.Error() }
+
+func (e *StageError) Unwrap() error { return e.Err }
+
+// Exit records how a stage ended. Err is nil for a stage that drained its
+// input, and context.Cause of the pipeline's context otherwise.
+type Exit struct {
+	Stage string
+	Err   error
+}
+
+// Pipeline is a set of stages connected by channels and sharing a context.
+// The first stage to fail cancels that context with a *StageError as the
+// cause, which stops every other stage.
+type Pipeline struct {
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+	wg     sync.WaitGroup
+
+	buffer  int
+	clock   clock.Clock
+	logger  *slog.Logger
+	metrics Metrics
+
+	mu    sync.Mutex
+	exits []Exit
+}
+
+// Option configures a Pipeline.
+type Option func(*Pipeline)
+
+// WithBuffer makes the channels that Source, Stage and Distinct return
+// buffered with room for n values, so that a stage can run ahead of a
+// slower one after it. By default they are unbuffered.
+func WithBuffer(n int) Option {
+	return func(p *Pipeline) { p.buffer = n }
+}
+
+// WithClock makes the pipeline time the calls it reports to Metrics with
+// clk instead of clock.Real.
+func WithClock(clk clock.Clock) Option {
+	return func(p *Pipeline) { p.clock = clk }
+}
+
+// WithLogger makes the pipeline log to l how each stage exited: at level
+// Warn for the stage that failed, and Debug for the others. By default
+// nothing is logged.
+func WithLogger(l *slog.Logger) Option {
+	return func(p *Pipeline) { p.logger = l }
+}
+
+// WithMetrics makes the pipeline report every call of a Stage's function
+// to m.
+func WithMetrics(m Metrics) Option {
+	return func(p *Pipeline) { p.metrics = m }
+}
+
+// Metrics receives measurements of a pipeline's stages. It is called by
+// the stages' goroutines, several at a time.
+type Metrics interface {
+	// Processed is called after the function of the stage named stage
+	// handled a value, with how long it took and the error it returned.
+	Processed(stage string, took time.Duration, err error)
+}
+
+// New returns an empty pipeline whose stages stop when ctx is done.
+func New(ctx context.Context, opts ...Option) *Pipeline {
+	ctx, cancel := context.WithCancelCause(ctx)
+	p := &Pipeline{ctx: ctx, cancel: cancel}
+	for _, opt := range opts {
+		opt(p)
+	}
+	if p.clock == nil {
+		p.clock = clock.Real
+	}
+	return p
+}
+
+// Context returns the context shared by the stages.
+func (p *Pipeline) Context() context.Context { return p.ctx }
+
+// Wait waits for every stage to exit and returns why the pipeline stopped:
+// nil if every stage drained its input, the failing stage's *StageError, or
+// the cause of the parent context being done.
+func (p *Pipeline) Wait() error {
+	p.wg.Wait()
+	err := context.Cause(p.ctx)
+	p.cancel(nil)
+	return err
+}
+
+// Exits returns how each stage ended, in the order they exited.
+func (p *Pipeline) Exits() []Exit {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]Exit(nil), p.exits...)
+}
+
+func (p *Pipeline) start(name string, run func()) {
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		run()
+		exit := Exit{name, context.Cause(p.ctx)}
+		p.mu.Lock()
+		p.exits = append(p.exits, exit)
+		p.mu.Unlock()
+		p.log(exit)
+	}()
+}
+
+func (p *Pipeline) log(e Exit) {
+	if p.logger == nil {
+		return
+	}
+	var se *StageError
+	if errors.As(e.Err, &se) && se.Stage == e.Stage {
+		p.logger.Warn("pipeline: stage failed", "stage", e.Stage, "err", se.Err)
+		return
+	}
+	p.logger.Debug("pipeline: stage exited", "stage", e.Stage, "err", e.Err)
+}
+
+// observe returns the function to call with the error of a call of the
+// stage's function that starts now, to report it to Metrics.
+func (p *Pipeline) observe(name string) func(error) {
+	if p.metrics == nil {
+		return func(error) {}
+	}
+	began := p.clock.Now()
+	return func(err error) { p.metrics.Processed(name, p.clock.Now().Sub(began), err) }
+}
+
+func (p *Pipeline) fail(name string, err error) {
+	p.cancel(&StageError{Stage: name, Err: err})
+}
+
+// Source adds a stage that produces values by calling emit. emit returns
+// false once the pipeline is stopping; fn should then return. A non-nil
+// error from fn fails the pipeline.
+func Source[Out any](p *Pipeline, name string, fn func(ctx context.Context, emit func(Out) bool) error) <-chan Out {
+	out := make(chan Out, p.buffer)
+	emit := func(v Out) bool {
+		select {
+		case out <- v:
+			return true
+		case <-p.ctx.Done():
+			return false
+		}
+	}
+	p.start(name, func() {
+		defer close(out)
+		if err := fn(p.ctx, emit); err != nil {
+			p.fail(name, err)
+		}
+	})
+	return out
+}
+
+// Stage adds a stage that applies fn to every value from in. An error from
+// fn fails the pipeline. Each call of fn is a runtime/trace region named
+// after the stage, so an execution trace shows where the time went.
+func Stage[In, Out any](p *Pipeline, name string, in <-chan In, fn func(ctx context.Context, v In) (Out, error)) <-chan Out {
+	out := make(chan Out, p.buffer)
+	p.start(name, func() {
+		defer close(out)
+		for {
+			select {
+			case v, ok := <-in:
+				if !ok {
+					return
+				}
+				region := trace.StartRegion(p.ctx, name)
+				done := p.observe(name)
+				r, err := fn(p.ctx, v)
+				done(err)
+				region.End()
+				if err != nil {
+					p.fail(name, err)
+					return
+				}
+				select {
+				case out <- r:
+				case <-p.ctx.Done():
+					return
+				}
+			case <-p.ctx.Done():
+				return
+			}
+		}
+	})
+	return out
+}
@@ -0,0 +1,152 @@
+package pipeline
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lotusirous/gochan/clock"
+)
+
+var errBoom = errors.New("boom")
+
+// counter emits 1, 2, 3, ... until the pipeline stops.
+func counter(ctx context.Context, emit func(int) bool) error {
+	for i := 1; emit(i); i++ {
+	}
+	return nil
+}
+
+func TestStageFailureCauseReachesEveryStage(t *testing.T) {
+	p := New(context.Background())
+	nums := Source(p, "count", counter)
+	checked := Stage(p, "check", nums, func(ctx context.Context, v int) (int, error) {
+		if v == 5 {
+			return 0, errBoom
+		}
+		return v, nil
+	})
+	doubled := Stage(p, "double", checked, func(ctx context.Context, v int) (int, error) {
+		return v * 2, nil
+	})
+
+	var got []int
+	for v := range doubled {
+		got = append(got, v)
+	}
+	err := p.Wait()
+
+	var se *StageError
+	if !errors.As(err, &se) || se.Stage != "check" || !errors.Is(err, errBoom) {
+		t.Fatalf("Wait = %v, want a StageError from check wrapping boom", err)
+	}
+	// Values already in flight may be dropped once the pipeline is stopping.
+	for i, v := range got {
+		if i >= 4 || v != (i+1)*2 {
+			t.Errorf("got %v, want a prefix of [2 4 6 8]", got)
+			break
+		}
+	}
+
+	exits := p.Exits()
+	if len(exits) != 3 {
+		t.Fatalf("exits = %v, want 3", exits)
+	}
+	for _, e := range exits {
+		if !errors.As(e.Err, &se) || se.Stage != "check" {
+			t.Errorf("stage %s exited with %v, want check's StageError", e.Stage, e.Err)
+		}
+	}
+}
+
+func TestPipelineDrains(t *testing.T) {
+	p := New(context.Background())
+	nums := Source(p, "three", func(ctx context.Context, emit func(int) bool) error {
+		for i := range 3 {
+			emit(i)
+		}
+		return nil
+	})
+	squared := Stage(p, "square", nums, func(ctx context.Context, v int) (int, error) { return v * v, nil })
+	sum := 0
+	for v := range squared {
+		sum += v
+	}
+	if err := p.Wait(); err != nil || sum != 5 {
+		t.Errorf("sum = %d, err = %v; want 5, nil", sum, err)
+	}
+	for _, e := range p.Exits() {
+		if e.Err != nil {
+			t.Errorf("stage %s exited with %v", e.Stage, e.Err)
+		}
+	}
+}
+
+func TestPipelineParentCause(t *testing.T) {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	p := New(ctx)
+	out := Stage(p, "pass", Source(p, "count", counter), func(ctx context.Context, v int) (int, error) { return v, nil })
+	<-out
+	cancel(errBoom)
+	for range out {
+	}
+	if err := p.Wait(); err != errBoom {
+		t.Errorf("Wait = %v, want the parent's cause", err)
+	}
+}
+
+// stageTimes is a Metrics that adds up the time each stage took.
+type stageTimes struct {
+	mu    sync.Mutex
+	took  map[string]time.Duration
+	calls int
+}
+
+func (m *stageTimes) Processed(stage string, took time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.took[stage] += took
+	m.calls++
+}
+
+func TestPipelineOptions(t *testing.T) {
+	clk := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	m := &stageTimes{took: map[string]time.Duration{}}
+	var log bytes.Buffer
+	p := New(context.Background(), WithBuffer(3), WithClock(clk), WithMetrics(m),
+		WithLogger(slog.New(slog.NewTextHandler(&log, &slog.HandlerOptions{Level: slog.LevelDebug}))))
+
+	emitted := make(chan struct{})
+	nums := Source(p, "three", func(ctx context.Context, emit func(int) bool) error {
+		for i := range 3 {
+			emit(i)
+		}
+		close(emitted) // without a buffer, nobody has received yet
+		return nil
+	})
+	<-emitted
+	slow := Stage(p, "slow", nums, func(ctx context.Context, v int) (int, error) {
+		clk.Advance(time.Second)
+		if v == 2 {
+			return 0, errBoom
+		}
+		return v, nil
+	})
+	for range slow {
+	}
+	p.Wait()
+
+	if m.calls != 3 || m.took["slow"] != 3*time.Second {
+		t.Errorf("metrics saw %d calls taking %v, want 3 taking 3s", m.calls, m.took["slow"])
+	}
+	out := log.String()
+	if !strings.Contains(out, `level=WARN msg="pipeline: stage failed" stage=slow err=boom`) ||
+		!strings.Contains(out, `level=DEBUG msg="pipeline: stage exited" stage=three`) {
+		t.Errorf("log:\n%s\nwant slow failing and three exiting", out)
+	}
+}
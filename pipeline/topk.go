@@ -0,0 +1,83 @@
+package pipeline
+
+import (
+	"container/heap"
+	"slices"
+	"sync"
+)
+
+// TopK adds a sink stage that keeps the k greatest values from in, as
+// ordered by cmp, and sends them greatest first once in is drained. If the
+// pipeline stops first the channel is closed without a value.
+//
+// Each of its workers keeps the top k of the values it reads in a min-heap
+// of its own, so they never wait for each other; the heaps are only merged
+// at the end. The top k overall is among the union of the local top ks.
+func TopK[T any](p *Pipeline, name string, in <-chan T, k, workers int, cmp func(a, b T) int) <-chan []T {
+	workers = max(workers, 1)
+	out := make(chan []T, 1)
+	p.start(name, func() {
+		defer close(out)
+		locals := make([]topHeap[T], workers)
+		var wg sync.WaitGroup
+		for i := range locals {
+			locals[i].cmp = cmp
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				h := &locals[i]
+				for {
+					select {
+					case v, ok := <-in:
+						if !ok {
+							return
+						}
+						h.offer(v, k)
+					case <-p.ctx.Done():
+						return
+					}
+				}
+			}()
+		}
+		wg.Wait()
+		if p.ctx.Err() != nil {
+			return
+		}
+		var all []T
+		for _, h := range locals {
+			all = append(all, h.items...)
+		}
+		slices.SortFunc(all, func(a, b T) int { return cmp(b, a) })
+		out <- all[:min(k, len(all))]
+	})
+	return out
+}
+
+// topHeap is a min-heap holding the greatest values offered to it.
+type topHeap[T any] struct {
+	items []T
+	cmp   func(a, b T) int
+}
+
+// offer adds v if the heap has fewer than k values or v is greater than its
+// least one, which it then replaces.
+func (h *topHeap[T]) offer(v T, k int) {
+	switch {
+	case k <= 0:
+	case len(h.items) < k:
+		heap.Push(h, v)
+	case h.cmp(v, h.items[0]) > 0:
+		h.items[0] = v
+		heap.Fix(h, 0)
+	}
+}
+
+func (h topHeap[T]) Len() int           { return len(h.items) }
+func (h topHeap[T]) Less(i, j int) bool { return h.cmp(h.items[i], h.items[j]) < 0 }
+func (h topHeap[T]) Swap(i, j int)      { h.items[i], h.items[j] = h.items[j], h.items[i] }
+func (h *topHeap[T]) Push(x any)        { h.items = append(h.items, x.(T)) }
+func (h *topHeap[T]) Pop() any {
+	v := h.items[len(h.items)-1]
+	h.items = h.items[:len(h.items)-1]
+	return v
+}
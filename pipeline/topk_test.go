@@ -0,0 +1,83 @@
+package pipeline
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"math/rand"
+	"slices"
+	"sync"
+	"testing"
+)
+
+func randomSource(n int, seed int64) func(ctx context.Context, emit func(int) bool) error {
+	return func(ctx context.Context, emit func(int) bool) error {
+		rng := rand.New(rand.NewSource(seed))
+		for range n {
+			if !emit(rng.Intn(1000000)) {
+				break
+			}
+		}
+		return nil
+	}
+}
+
+func TestTopK(t *testing.T) {
+	const n = 20000
+	var all []int
+	randomSource(n, 1)(context.Background(), func(v int) bool { all = append(all, v); return true })
+	slices.SortFunc(all, func(a, b int) int { return b - a })
+
+	for _, tc := range []struct{ k, workers int }{{10, 1}, {10, 4}, {100, 8}, {0, 2}, {n + 5, 3}} {
+		p := New(context.Background())
+		top := TopK(p, "top", Source(p, "nums", randomSource(n, 1)), tc.k, tc.workers, cmp.Compare[int])
+		if err := p.Wait(); err != nil {
+			t.Fatal(err)
+		}
+		if got, want := <-top, all[:min(tc.k, n)]; !slices.Equal(got, want) {
+			t.Errorf("k=%d workers=%d: got %d values, want %d: %v", tc.k, tc.workers, len(got), len(want), got[:min(len(got), 5)])
+		}
+	}
+}
+
+// lockedTopK is the baseline: every worker offers to one heap behind one
+// mutex.
+func lockedTopK(in <-chan int, k, workers int) []int {
+	var mu sync.Mutex
+	h := topHeap[int]{cmp: cmp.Compare[int]}
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for v := range in {
+				mu.Lock()
+				h.offer(v, k)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	return h.items
+}
+
+func BenchmarkTopK(b *testing.B) {
+	const n, k = 100000, 100
+	for _, workers := range []int{1, 4, 8} {
+		b.Run(fmt.Sprintf("locked/%d", workers), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				p := New(context.Background())
+				lockedTopK(Source(p, "nums", randomSource(n, 1)), k, workers)
+				p.Wait()
+			}
+		})
+		b.Run(fmt.Sprintf("local-heaps/%d", workers), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				p := New(context.Background())
+				top := TopK(p, "top", Source(p, "nums", randomSource(n, 1)), k, workers, cmp.Compare[int])
+				p.Wait()
+				<-top
+			}
+		})
+	}
+}
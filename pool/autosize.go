@@ -0,0 +1,40 @@
+package pool
+
+import "runtime"
+
+// Workload hints at what jobs spend their time on, for WithAutoSize.
+type Workload int
+
+const (
+	// CPUBound jobs keep a core busy; more workers than cores only adds
+	// scheduling overhead.
+	CPUBound Workload = iota
+	// Mixed jobs alternate between computing and waiting.
+	Mixed
+	// IOBound jobs mostly wait on the network or disk, so many workers can
+	// share a core.
+	IOBound
+)
+
+// workersPerProc is how many workers AutoSize starts per GOMAXPROCS.
+var workersPerProc = map[Workload]int{
+	CPUBound: 1,
+	Mixed:    2,
+	IOBound:  16,
+}
+
+// AutoSize returns the number of workers WithAutoSize would use for w:
+// GOMAXPROCS multiplied by a per-workload factor.
+func AutoSize(w Workload) int {
+	factor, ok := workersPerProc[w]
+	if !ok {
+		factor = 1
+	}
+	return runtime.GOMAXPROCS(0) * factor
+}
+
+// WithAutoSize sizes the pool from GOMAXPROCS and a workload hint instead of
+// a fixed number. It overrides WithWorkers.
+func WithAutoSize(w Workload) Option {
+	return func(c *config) { c.autoSize = &w }
+}
@@ -0,0 +1,26 @@
+package pool
+
+import (
+	"context"
+	"fmt"
+)
+
+// Batch adapts a per-item function into one that processes a whole slice per
+// job, so a pool built with New(Batch(fn)) pays for one channel send per
+// batch instead of one per item.
+//
+// Items are processed in order. Processing stops at the first error, which is
+// returned together with the outputs produced so far.
+func Batch[In, Out any](fn Func[In, Out]) Func[[]In, []Out] {
+	return func(ctx context.Context, batch []In) ([]Out, error) {
+		out := make([]Out, 0, len(batch))
+		for i, in := range batch {
+			v, err := fn(ctx, in)
+			if err != nil {
+				return out, fmt.Errorf("batch item %d: %w", i, err)
+			}
+			out = append(out, v)
+		}
+		return out, nil
+	}
+}
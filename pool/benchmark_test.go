@@ -0,0 +1,220 @@
+package pool
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// spin is a CPU-bound job whose cost is proportional to n.
+func spin(ctx context.Context, n int) (int, error) {
+	sum := 0
+	for i := range n {
+		sum += i * i
+	}
+	return sum, nil
+}
+
+func benchmarkPool(b *testing.B, p Pool[int, int], work int) {
+	const jobs = 100
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		go func() {
+			for range jobs {
+				p.Submit(context.Background(), work)
+			}
+		}()
+		for range jobs {
+			<-p.Results()
+		}
+	}
+	b.StopTimer()
+	p.Close()
+	for range p.Results() {
+	}
+}
+
+// BenchmarkPools compares in-process goroutine workers with child process
+// workers. Process workers add a fixed per-job cost for encoding and pipe
+// round-trips that only becomes negligible once jobs are heavy.
+func BenchmarkPools(b *testing.B) {
+	for _, work := range []struct {
+		name string
+		n    int
+	}{{"LightJobs", 1_000}, {"HeavyJobs", 1_000_000}} {
+		b.Run(work.name+"/Goroutines", func(b *testing.B) {
+			benchmarkPool(b, New(spin, WithWorkers(4)), work.n)
+		})
+		b.Run(work.name+"/Processes", func(b *testing.B) {
+			p, err := NewProcessPool[int, int](spinCommand, WithWorkers(4))
+			if err != nil {
+				b.Fatal(err)
+			}
+			benchmarkPool(b, p, work.n)
+		})
+	}
+}
+
+// BenchmarkAutoSize sweeps fixed pool sizes for a CPU-bound and an IO-bound
+// workload and compares them with the size picked by WithAutoSize. CPU-bound
+// jobs stop improving past GOMAXPROCS workers; IO-bound jobs keep improving
+// until the number of workers covers the jobs in flight, which is why the
+// IOBound factor is a starting point to tune rather than an answer.
+func BenchmarkAutoSize(b *testing.B) {
+	sleep := func(ctx context.Context, n int) (int, error) {
+		time.Sleep(time.Millisecond)
+		return n, nil
+	}
+	workloads := []struct {
+		name string
+		hint Workload
+		fn   Func[int, int]
+		work int
+	}{
+		{"CPU", CPUBound, spin, 100_000},
+		{"IO", IOBound, sleep, 0},
+	}
+
+	for _, w := range workloads {
+		for _, size := range []int{1, 2, 4, 8, 16, 32, 64} {
+			b.Run(fmt.Sprintf("%s/Fixed%d", w.name, size), func(b *testing.B) {
+				benchmarkPool(b, New(w.fn, WithWorkers(size)), w.work)
+			})
+		}
+		b.Run(fmt.Sprintf("%s/Auto%d", w.name, AutoSize(w.hint)), func(b *testing.B) {
+			benchmarkPool(b, New(w.fn, WithAutoSize(w.hint)), w.work)
+		})
+	}
+}
+
+// BenchmarkDispatch compares the single shared job channel with per-worker
+// local queues when many goroutines submit tiny jobs at once.
+func BenchmarkDispatch(b *testing.B) {
+	noop := func(ctx context.Context, n int) (int, error) { return n, nil }
+	designs := []struct {
+		name string
+		opts []Option
+	}{
+		{"Shared", []Option{WithWorkers(8), WithQueue(256)}},
+		{"LocalQueues", []Option{WithWorkers(8), WithQueue(256), WithLocalQueues(32)}},
+	}
+	for _, d := range designs {
+		b.Run(d.name, func(b *testing.B) {
+			p := New(noop, d.opts...)
+			done := make(chan struct{})
+			go func() {
+				for range p.Results() {
+				}
+				close(done)
+			}()
+
+			b.SetParallelism(4)
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					p.Submit(context.Background(), 1)
+				}
+			})
+			p.Close()
+			<-done
+		})
+	}
+}
+
+// BenchmarkAggregation compares streaming every result through the Results
+// channel with Collect's per-worker slices merged once at the end. The
+// cheaper the job, the larger the share of time spent on the channel and
+// the more Collect wins; with heavy jobs the two converge.
+func BenchmarkAggregation(b *testing.B) {
+	const jobs = 10_000
+	feed := func() <-chan int {
+		c := make(chan int, 256)
+		go func() {
+			defer close(c)
+			for i := range jobs {
+				c <- i
+			}
+		}()
+		return c
+	}
+
+	for _, work := range []struct {
+		name string
+		n    int
+	}{{"CheapJobs", 10}, {"HeavyJobs", 10_000}} {
+		fn := func(ctx context.Context, i int) (int, error) { return spin(ctx, work.n) }
+
+		b.Run(work.name+"/Streaming", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				p := New(fn, WithWorkers(4), WithQueue(256))
+				go func() {
+					defer p.Close()
+					for job := range feed() {
+						p.Submit(context.Background(), job)
+					}
+				}()
+				results := make([]Result[int, int], 0, jobs)
+				for r := range p.Results() {
+					results = append(results, r)
+				}
+			}
+		})
+
+		b.Run(work.name+"/Collect", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				Collect(context.Background(), feed(), fn, WithWorkers(4))
+			}
+		})
+	}
+}
+
+// BenchmarkFairWeights saturates a Fair dispatcher with three tenants of
+// weight 1, 2 and 4 that always have work queued, and reports the share of
+// completed jobs each one got. The shares should come out near 1/7, 2/7 and
+// 4/7 however long the benchmark runs.
+func BenchmarkFairWeights(b *testing.B) {
+	tenants := []struct {
+		name   string
+		weight int
+	}{{"w1", 1}, {"w2", 2}, {"w4", 4}}
+	weights := map[string]int{}
+	for _, t := range tenants {
+		weights[t.name] = t.weight
+	}
+	work := func(ctx context.Context, j tenantJob) (string, error) {
+		spin(ctx, 1_000)
+		return j.Tenant, nil
+	}
+	f := NewFair(New(work, WithWorkers(4)), FairConfig[tenantJob]{
+		PerTenant: 64,
+		Weight:    func(tenant string) int { return weights[tenant] },
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	for _, t := range tenants {
+		go func() {
+			for i := 0; ctx.Err() == nil; i++ {
+				f.Submit(ctx, t.name, tenantJob{t.name, i})
+			}
+		}()
+	}
+
+	// Let every queue fill up before measuring.
+	for range 256 {
+		<-f.Results()
+	}
+	counts := map[string]int{}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		counts[(<-f.Results()).Value]++
+	}
+	b.StopTimer()
+	cancel()
+	f.Close()
+	for range f.Results() {
+	}
+
+	for _, t := range tenants {
+		b.ReportMetric(float64(counts[t.name])/float64(b.N), t.name+"-share")
+	}
+}
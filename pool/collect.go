@@ -0,0 +1,58 @@
+package pool
+
+import (
+	"context"
+	"sync"
+
+	"github.com/lotusirous/gochan/result"
+	"github.com/lotusirous/gochan/safego"
+)
+
+// Collect is the aggregation alternative to streaming results through a
+// pool's Results channel. Every worker appends its results to a private
+// slice and the slices are merged once, after jobs is closed and drained.
+//
+// Collect wins when the caller needs all results anyway and jobs are cheap:
+// it saves one channel send and receive per job. Streaming wins when results
+// should be acted on as they arrive, when the result set is too large to hold
+// in memory, or when the caller may stop early. Results are not in job order.
+//
+// Jobs run with ctx; once ctx is done the remaining jobs are drained without
+// being run and Collect returns what was produced so far. As in the pools,
+// a job that panics fails alone, with a *safego.PanicError.
+func Collect[In, Out any](ctx context.Context, jobs <-chan In, fn Func[In, Out], opts ...Option) []Result[In, Out] {
+	c := newConfig(opts)
+	perWorker := make([][]Result[In, Out], c.workers)
+
+	var wg sync.WaitGroup
+	for i := range perWorker {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var local []Result[In, Out]
+			for job := range jobs {
+				if ctx.Err() != nil {
+					continue // keep draining so the producer is not stuck
+				}
+				var v Out
+				err := safego.Do(func() (err error) {
+					v, err = fn(ctx, job)
+					return err
+				})
+				local = append(local, Result[In, Out]{Job: job, Result: result.Of(v, err)})
+			}
+			perWorker[i] = local
+		}()
+	}
+	wg.Wait()
+
+	n := 0
+	for _, local := range perWorker {
+		n += len(local)
+	}
+	merged := make([]Result[In, Out], 0, n)
+	for _, local := range perWorker {
+		merged = append(merged, local...)
+	}
+	return merged
+}
@@ -0,0 +1,226 @@
+package pool
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/lotusirous/gochan/clock"
+	"github.com/lotusirous/gochan/cron"
+)
+
+// Overlap decides what happens when a recurring job is due while its
+// previous run is still going.
+type Overlap int
+
+const (
+	// OverlapAllow starts another run anyway.
+	OverlapAllow Overlap = iota
+	// OverlapSkip drops the run that is due.
+	OverlapSkip
+	// OverlapDelay runs once the previous run finishes. Several runs due
+	// in the meantime are merged into that one.
+	OverlapDelay
+)
+
+// Cron runs recurring jobs on a goroutine pool, each on its own
+// cron.Schedule. A single goroutine keeps time for every entry, so the
+// cost of an entry is its slot in a list, not a goroutine or a ticker.
+type Cron[In, Out any] struct {
+	clock   clock.Clock
+	pool    *GoroutinePool[*cronEntry[In], Out]
+	results chan Result[In, Out]
+	wake    chan struct{}
+
+	mu      sync.Mutex
+	entries []*cronEntry[In]
+	closed  bool
+}
+
+type cronEntry[In any] struct {
+	ctx      context.Context
+	schedule cron.Schedule
+	job      In
+	overlap  Overlap
+	next     time.Time
+
+	// Guarded by Cron.mu.
+	running int
+	pending bool // a delayed run is owed
+}
+
+// String makes failures reported by Wait name the job rather than the entry.
+func (e *cronEntry[In]) String() string { return fmt.Sprint(e.job) }
+
+// NewCron starts a scheduler that runs jobs with fn on a goroutine pool
+// configured by opts. It tells time with the clock set by WithClock.
+func NewCron[In, Out any](fn Func[In, Out], opts ...Option) *Cron[In, Out] {
+	c := &Cron[In, Out]{
+		clock:   newConfig(opts).clock,
+		results: make(chan Result[In, Out]),
+		wake:    make(chan struct{}, 1),
+	}
+	c.pool = New(func(ctx context.Context, e *cronEntry[In]) (Out, error) {
+		defer c.finished(e)
+		return fn(ctx, e.job)
+	}, opts...)
+
+	go c.run()
+	go func() {
+		defer close(c.results)
+		for r := range c.pool.Results() {
+			c.results <- Result[In, Out]{Job: r.Job.job, Result: r.Result}
+		}
+	}()
+	return c
+}
+
+// Add runs job on schedule, starting with the first activation after now,
+// until ctx is done or c is closed. Every run uses ctx. It returns
+// ErrClosed after Close.
+func (c *Cron[In, Out]) Add(ctx context.Context, schedule cron.Schedule, job In, overlap Overlap) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return ErrClosed
+	}
+	e := &cronEntry[In]{ctx: ctx, schedule: schedule, job: job, overlap: overlap}
+	e.next = schedule.Next(c.clock.Now())
+	if !e.next.IsZero() {
+		c.entries = append(c.entries, e)
+		c.signal()
+	}
+	return nil
+}
+
+// Results streams the outcome of every run. It is closed after Close, once
+// the runs in progress have finished.
+func (c *Cron[In, Out]) Results() <-chan Result[In, Out] { return c.results }
+
+// Close stops starting new runs. Runs in progress finish and deliver their
+// Results.
+func (c *Cron[In, Out]) Close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	c.signal()
+}
+
+// Wait closes c, drains Results until the runs in progress have finished
+// and returns their errors, as Pool.Wait does.
+func (c *Cron[In, Out]) Wait(ctx context.Context) error {
+	c.Close()
+	for {
+		select {
+		case _, ok := <-c.results:
+			if !ok {
+				return c.pool.failures.err()
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (c *Cron[In, Out]) signal() {
+	select {
+	case c.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (c *Cron[In, Out]) run() {
+	defer c.pool.Close()
+	// One timer, reset to each new earliest activation; an After per pass
+	// would leave a channel behind every time an entry is added.
+	timer := c.clock.NewTimer(0)
+	defer timer.Stop()
+	timer.Stop()
+	for {
+		c.mu.Lock()
+		if c.closed {
+			c.mu.Unlock()
+			return
+		}
+		now := c.clock.Now()
+		var start []*cronEntry[In]
+		var earliest time.Time
+		live := c.entries[:0]
+		for _, e := range c.entries {
+			if e.ctx.Err() != nil {
+				continue
+			}
+			if !e.next.After(now) {
+				if c.due(e) {
+					start = append(start, e)
+				}
+				// Activations missed while the clock jumped ahead are not
+				// made up for: the next one is counted from now.
+				if e.next = e.schedule.Next(now); e.next.IsZero() {
+					continue
+				}
+			}
+			if earliest.IsZero() || e.next.Before(earliest) {
+				earliest = e.next
+			}
+			live = append(live, e)
+		}
+		clear(c.entries[len(live):])
+		c.entries = live
+		if earliest.IsZero() {
+			timer.Stop()
+		} else {
+			timer.Reset(earliest.Sub(now))
+		}
+		c.mu.Unlock()
+
+		for _, e := range start {
+			c.submit(e)
+		}
+		select {
+		case <-timer.C():
+		case <-c.wake:
+		}
+	}
+}
+
+// due applies the overlap policy to an activation of e and reports whether
+// a run should start now. The caller holds c.mu.
+func (c *Cron[In, Out]) due(e *cronEntry[In]) bool {
+	if e.running > 0 {
+		switch e.overlap {
+		case OverlapSkip:
+			return false
+		case OverlapDelay:
+			e.pending = true
+			return false
+		}
+	}
+	e.running++
+	return true
+}
+
+func (c *Cron[In, Out]) submit(e *cronEntry[In]) {
+	if c.pool.Submit(e.ctx, e) != nil {
+		c.finished(e)
+	}
+}
+
+// finished is called when a run of e ends, or failed to start. It starts
+// the delayed run, if one is owed.
+func (c *Cron[In, Out]) finished(e *cronEntry[In]) {
+	c.mu.Lock()
+	e.running--
+	again := e.pending && e.running == 0 && !c.closed && e.ctx.Err() == nil
+	if again {
+		e.pending = false
+		e.running++
+	}
+	c.mu.Unlock()
+	if again {
+		// Submitting from a worker into its own pool could block on a full
+		// queue that only workers drain.
+		go c.submit(e)
+	}
+}
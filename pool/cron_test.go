@@ -0,0 +1,127 @@
+package pool
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lotusirous/gochan/clock"
+	"github.com/lotusirous/gochan/cron"
+)
+
+func TestCronRunsOnSchedule(t *testing.T) {
+	start := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	fake := clock.NewFake(start)
+	c := NewCron(func(ctx context.Context, name string) (time.Time, error) {
+		return fake.Now(), nil
+	}, WithClock(fake))
+	if err := c.Add(context.Background(), cron.MustParse("*/15 * * * *"), "quarter", OverlapSkip); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 1; i <= 4; i++ {
+		fake.BlockUntil(1)
+		fake.Advance(15 * time.Minute)
+		r := <-c.Results()
+		if want := start.Add(time.Duration(i) * 15 * time.Minute); !r.Value.Equal(want) {
+			t.Errorf("run %d at %v, want %v", i, r.Value, want)
+		}
+	}
+	if err := c.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Add(context.Background(), cron.Every(time.Minute), "late", OverlapSkip); err != ErrClosed {
+		t.Errorf("Add after Close = %v, want ErrClosed", err)
+	}
+}
+
+func TestCronOverlap(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		overlap Overlap
+		runs    int
+	}{
+		{"allow", OverlapAllow, 3},
+		{"skip", OverlapSkip, 1},
+		{"delay", OverlapDelay, 2}, // the two ticks missed are merged
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fake := clock.NewFake(time.Time{})
+			started := make(chan struct{}, 10)
+			release := make(chan struct{})
+			c := NewCron(func(ctx context.Context, n int) (int, error) {
+				started <- struct{}{}
+				<-release
+				return n, nil
+			}, WithClock(fake), WithWorkers(4))
+			c.Add(context.Background(), cron.Every(time.Minute), 1, tc.overlap)
+
+			// Three ticks while the first run is still going.
+			for range 3 {
+				fake.BlockUntil(1)
+				fake.Advance(time.Minute)
+			}
+			fake.BlockUntil(1) // the third tick has been handled
+			close(release)
+
+			for i := range tc.runs {
+				select {
+				case <-started:
+				case <-time.After(time.Second):
+					t.Fatalf("only %d of %d runs started", i, tc.runs)
+				}
+			}
+			if err := c.Wait(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			if len(started) != 0 {
+				t.Errorf("%d runs too many", len(started))
+			}
+		})
+	}
+}
+
+func TestCronEntryEndsWithContext(t *testing.T) {
+	fake := clock.NewFake(time.Time{})
+	runs := make(chan int, 10)
+	c := NewCron(func(ctx context.Context, n int) (int, error) {
+		runs <- n
+		return n, nil
+	}, WithClock(fake))
+	ctx, cancel := context.WithCancel(context.Background())
+	c.Add(ctx, cron.Every(time.Minute), 1, OverlapSkip)
+	c.Add(context.Background(), cron.Every(time.Minute), 2, OverlapSkip)
+
+	fake.BlockUntil(1)
+	fake.Advance(time.Minute)
+	<-runs
+	<-runs
+	cancel()
+
+	fake.BlockUntil(1)
+	fake.Advance(time.Minute)
+	if n := <-runs; n != 2 {
+		t.Errorf("cancelled entry ran")
+	}
+	if err := c.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 0 {
+		t.Errorf("cancelled entry ran")
+	}
+}
+
+func TestCronKeepsOneTimer(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	fake := clock.NewFake(start)
+	c := NewCron(func(ctx context.Context, n int) (int, error) { return n, nil }, WithClock(fake))
+	defer c.Wait(context.Background())
+	// Each entry is due earlier than the last, so each moves the timer.
+	for h := 3; h >= 1; h-- {
+		c.Add(context.Background(), cron.Every(time.Duration(h)*time.Hour), h, OverlapSkip)
+		waitNext(t, fake, start.Add(time.Duration(h)*time.Hour))
+	}
+	if n := fake.Waiters(); n != 1 {
+		t.Errorf("%d timers waiting for 3 entries, want 1", n)
+	}
+}
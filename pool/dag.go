@@ -0,0 +1,227 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/lotusirous/gochan/result"
+)
+
+var (
+	// ErrCycle is returned by NewDAG when the dependencies form a cycle.
+	ErrCycle = errors.New("pool: dependency cycle")
+	// ErrSkipped is the error of a node that did not run because one of its
+	// dependencies failed or was skipped.
+	ErrSkipped = errors.New("pool: skipped")
+)
+
+// Node is a job in a DAG together with the names of the nodes that must
+// succeed before it may run.
+type Node[In any] struct {
+	Name string
+	Job  In
+	Deps []string
+}
+
+// DAG is a validated set of nodes: names are unique, every dependency
+// exists and there are no cycles.
+type DAG[In any] struct {
+	nodes      []Node[In]
+	index      map[string]int
+	dependents [][]int // dependents[i] lists the nodes that depend on node i
+}
+
+// NewDAG checks nodes and returns them as a DAG. A cycle is reported as
+// an error wrapping ErrCycle that spells out the cycle.
+func NewDAG[In any](nodes ...Node[In]) (*DAG[In], error) {
+	d := &DAG[In]{
+		nodes:      nodes,
+		index:      make(map[string]int, len(nodes)),
+		dependents: make([][]int, len(nodes)),
+	}
+	for i, n := range nodes {
+		if _, dup := d.index[n.Name]; dup {
+			return nil, fmt.Errorf("pool: duplicate node %q", n.Name)
+		}
+		d.index[n.Name] = i
+	}
+	for i, n := range nodes {
+		for _, dep := range n.Deps {
+			j, ok := d.index[dep]
+			if !ok {
+				return nil, fmt.Errorf("pool: node %q depends on unknown node %q", n.Name, dep)
+			}
+			d.dependents[j] = append(d.dependents[j], i)
+		}
+	}
+	if cycle := d.findCycle(); cycle != nil {
+		return nil, fmt.Errorf("%w: %s", ErrCycle, strings.Join(cycle, " -> "))
+	}
+	return d, nil
+}
+
+// findCycle returns the names along a dependency cycle, first name
+// repeated at the end, or nil if there is none.
+func (d *DAG[In]) findCycle() []string {
+	const (
+		unvisited = iota
+		onPath
+		done
+	)
+	state := make([]int, len(d.nodes))
+	var path []int
+	var visit func(i int) []string
+	visit = func(i int) []string {
+		state[i] = onPath
+		path = append(path, i)
+		for _, j := range d.dependents[i] {
+			switch state[j] {
+			case onPath:
+				var names []string
+				for k := len(path) - 1; k >= 0; k-- {
+					if path[k] == j {
+						for _, p := range path[k:] {
+							names = append(names, d.nodes[p].Name)
+						}
+						break
+					}
+				}
+				return append(names, d.nodes[j].Name)
+			case unvisited:
+				if cycle := visit(j); cycle != nil {
+					return cycle
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		state[i] = done
+		return nil
+	}
+	for i := range d.nodes {
+		if state[i] == unvisited {
+			if cycle := visit(i); cycle != nil {
+				return cycle
+			}
+		}
+	}
+	return nil
+}
+
+// Progress is a summary of a running DAG.
+type Progress struct {
+	Total, Succeeded, Failed, Skipped int
+	Last                              string // the node whose completion produced this summary
+}
+
+// Finished reports whether every node has an outcome.
+func (p Progress) Finished() bool { return p.Succeeded+p.Failed+p.Skipped == p.Total }
+
+type dagJob[In any] struct {
+	node int
+	job  In
+}
+
+// RunDAG runs the nodes of d with fn on a goroutine pool configured by opts.
+// A node is released to the pool once all of its dependencies succeeded.
+// When a node fails, every node that depends on it, directly or not, is
+// skipped with an error wrapping ErrSkipped. When ctx is done no further
+// node is started and the remaining ones fail with ctx.Err().
+//
+// If progress is not nil, a summary is sent on it after every outcome and it
+// is closed when RunDAG returns. Sends never block: a summary that does not
+// fit is dropped, as the next one supersedes it.
+//
+// RunDAG returns the outcome of every node by name.
+func RunDAG[In, Out any](ctx context.Context, d *DAG[In], fn Func[In, Out], progress chan<- Progress, opts ...Option) map[string]result.Result[Out] {
+	if progress != nil {
+		defer close(progress)
+	}
+	c := newConfig(opts)
+	p := New(func(ctx context.Context, j dagJob[In]) (Out, error) { return fn(ctx, j.job) }, opts...)
+	defer p.Close()
+
+	outcomes := make(map[string]result.Result[Out], len(d.nodes))
+	sum := Progress{Total: len(d.nodes)}
+	finish := func(i int, r result.Result[Out]) {
+		outcomes[d.nodes[i].Name] = r
+		switch {
+		case r.OK():
+			sum.Succeeded++
+		case errors.Is(r.Err, ErrSkipped):
+			sum.Skipped++
+		default:
+			sum.Failed++
+		}
+		sum.Last = d.nodes[i].Name
+		select {
+		case progress <- sum:
+		default:
+		}
+	}
+
+	// skip marks every node downstream of i as skipped.
+	var skip func(i int)
+	skip = func(i int) {
+		for _, j := range d.dependents[i] {
+			if _, seen := outcomes[d.nodes[j].Name]; seen {
+				continue
+			}
+			finish(j, result.Err[Out](fmt.Errorf("%w: %s did not succeed", ErrSkipped, d.nodes[i].Name)))
+			skip(j)
+		}
+	}
+
+	waiting := make([]int, len(d.nodes)) // unfinished dependencies per node
+	var ready []int
+	for i, n := range d.nodes {
+		waiting[i] = len(n.Deps)
+		if waiting[i] == 0 {
+			ready = append(ready, i)
+		}
+	}
+
+	// Keeping no more jobs in flight than the pool's workers and queue can
+	// hold means Submit never blocks while results wait to be read.
+	capacity := c.workers + c.queue
+	inflight := 0
+	for {
+		for ctx.Err() == nil && len(ready) > 0 && inflight < capacity {
+			i := ready[0]
+			ready = ready[1:]
+			if err := p.Submit(ctx, dagJob[In]{i, d.nodes[i].Job}); err != nil {
+				finish(i, result.Err[Out](err))
+				skip(i)
+				continue
+			}
+			inflight++
+		}
+		if inflight == 0 {
+			break
+		}
+
+		r := <-p.Results()
+		inflight--
+		i := r.Job.node
+		finish(i, r.Result)
+		if !r.OK() {
+			skip(i)
+			continue
+		}
+		for _, j := range d.dependents[i] {
+			waiting[j]--
+			if waiting[j] == 0 {
+				ready = append(ready, j)
+			}
+		}
+	}
+
+	// Only left when ctx ended before everything could run.
+	for i := range d.nodes {
+		if _, seen := outcomes[d.nodes[i].Name]; !seen {
+			finish(i, result.Err[Out](ctx.Err()))
+		}
+	}
+	return outcomes
+}
@@ -0,0 +1,127 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+)
+
+// build is a fake build step; steps named "broken" fail.
+type build struct {
+	mu    sync.Mutex
+	order []string
+}
+
+func (b *build) run(ctx context.Context, step string) (string, error) {
+	b.mu.Lock()
+	b.order = append(b.order, step)
+	b.mu.Unlock()
+	if step == "broken" {
+		return "", errors.New("compile error")
+	}
+	return step + " ok", nil
+}
+
+func TestDAGRunsInDependencyOrder(t *testing.T) {
+	//   fetch -> compile -> test -> release
+	//         -> lint ---------/
+	d, err := NewDAG(
+		Node[string]{Name: "release", Job: "release", Deps: []string{"test", "lint"}},
+		Node[string]{Name: "test", Job: "test", Deps: []string{"compile"}},
+		Node[string]{Name: "compile", Job: "compile", Deps: []string{"fetch"}},
+		Node[string]{Name: "lint", Job: "lint", Deps: []string{"fetch"}},
+		Node[string]{Name: "fetch", Job: "fetch"},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b build
+	progress := make(chan Progress, 10)
+	out := RunDAG(context.Background(), d, b.run, progress, WithWorkers(3))
+
+	pos := func(step string) int { return slices.Index(b.order, step) }
+	for _, edge := range [][2]string{{"fetch", "compile"}, {"fetch", "lint"}, {"compile", "test"}, {"test", "release"}, {"lint", "release"}} {
+		if pos(edge[0]) > pos(edge[1]) {
+			t.Errorf("%s ran before %s: %v", edge[1], edge[0], b.order)
+		}
+	}
+	if len(out) != 5 || out["release"].Must() != "release ok" {
+		t.Errorf("outcomes = %v", out)
+	}
+
+	var last Progress
+	for p := range progress {
+		last = p
+	}
+	if !last.Finished() || last.Succeeded != 5 {
+		t.Errorf("last progress = %+v, want 5 succeeded", last)
+	}
+}
+
+func TestDAGSkipsDependentsOfFailures(t *testing.T) {
+	d, err := NewDAG(
+		Node[string]{Name: "a", Job: "a"},
+		Node[string]{Name: "b", Job: "broken", Deps: []string{"a"}},
+		Node[string]{Name: "c", Job: "c", Deps: []string{"b"}},
+		Node[string]{Name: "d", Job: "d", Deps: []string{"c", "a"}},
+		Node[string]{Name: "e", Job: "e", Deps: []string{"a"}},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b build
+	out := RunDAG(context.Background(), d, b.run, nil, WithWorkers(2))
+
+	if out["b"].Err == nil || errors.Is(out["b"].Err, ErrSkipped) {
+		t.Errorf("b = %v, want its own failure", out["b"].Err)
+	}
+	for _, name := range []string{"c", "d"} {
+		if !errors.Is(out[name].Err, ErrSkipped) {
+			t.Errorf("%s = %v, want ErrSkipped", name, out[name].Err)
+		}
+	}
+	if !out["e"].OK() {
+		t.Errorf("e = %v, an unrelated branch must still run", out["e"].Err)
+	}
+	if slices.Contains(b.order, "c") || slices.Contains(b.order, "d") {
+		t.Errorf("skipped steps ran: %v", b.order)
+	}
+}
+
+func TestDAGValidation(t *testing.T) {
+	_, err := NewDAG(
+		Node[int]{Name: "a", Deps: []string{"c"}},
+		Node[int]{Name: "b", Deps: []string{"a"}},
+		Node[int]{Name: "c", Deps: []string{"b"}},
+		Node[int]{Name: "d"},
+	)
+	if !errors.Is(err, ErrCycle) || err.Error() != "pool: dependency cycle: a -> b -> c -> a" {
+		t.Errorf("err = %v, want the cycle spelled out", err)
+	}
+	if _, err := NewDAG(Node[int]{Name: "a", Deps: []string{"a"}}); !errors.Is(err, ErrCycle) {
+		t.Errorf("self dependency: err = %v, want ErrCycle", err)
+	}
+	if _, err := NewDAG(Node[int]{Name: "a", Deps: []string{"zzz"}}); err == nil {
+		t.Error("unknown dependency accepted")
+	}
+	if _, err := NewDAG(Node[int]{Name: "a"}, Node[int]{Name: "a"}); err == nil {
+		t.Error("duplicate name accepted")
+	}
+}
+
+func TestDAGStopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	d, _ := NewDAG(
+		Node[int]{Name: "first"},
+		Node[int]{Name: "second", Deps: []string{"first"}},
+	)
+	out := RunDAG(ctx, d, func(ctx context.Context, _ int) (int, error) {
+		cancel()
+		return 1, nil
+	}, nil)
+	if !out["first"].OK() || !errors.Is(out["second"].Err, context.Canceled) {
+		t.Errorf("outcomes = %v", out)
+	}
+}
@@ -0,0 +1,174 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/lotusirous/gochan/result"
+)
+
+// ErrDuplicate is returned by Dedup.Submit in Reject mode when a job with
+// the same key is already queued or running.
+var ErrDuplicate = errors.New("pool: duplicate job")
+
+// DedupMode decides what happens to a job whose key is already in flight.
+type DedupMode int
+
+const (
+	// Coalesce does not run the duplicate. It gets a Result of its own,
+	// carrying the value and error of the job already in flight, like
+	// golang.org/x/sync/singleflight.
+	Coalesce DedupMode = iota
+	// Reject refuses the duplicate with ErrDuplicate.
+	Reject
+)
+
+// Dedup wraps a Pool so that jobs with the same key never run at the same
+// time. It is useful when several producers fan in requests for the same
+// thing, such as the same URL or cache entry: the work is done once and
+// every requester gets the answer.
+//
+// A key is in flight from the moment its job is submitted until its Result
+// has been passed on. A job submitted after that runs again.
+type Dedup[In, Out any, K comparable] struct {
+	pool     Pool[In, Out]
+	key      func(In) K
+	mode     DedupMode
+	results  chan Result[In, Out]
+	failures failures
+
+	// mu is held for reading by every Submit and for writing by forward
+	// once the inner pool's results are done, so that no Submit queues a
+	// failed Result after forward has passed on the last ones.
+	mu     sync.RWMutex
+	closed bool
+
+	imu      sync.Mutex
+	inflight map[K][]In // coalesced duplicates waiting per key
+	// failed holds the Results of duplicates whose original could not be
+	// submitted, for forward to pass on; wake tells it there are some.
+	failed []Result[In, Out]
+	wake   chan struct{}
+}
+
+var _ Pool[int, int] = (*Dedup[int, int, int])(nil)
+
+// NewDedup wraps p, identifying jobs by key. Dedup takes ownership of p:
+// use the Dedup's methods, not p's.
+func NewDedup[In, Out any, K comparable](p Pool[In, Out], key func(In) K, mode DedupMode) *Dedup[In, Out, K] {
+	d := &Dedup[In, Out, K]{
+		pool:     p,
+		key:      key,
+		mode:     mode,
+		results:  make(chan Result[In, Out]),
+		inflight: make(map[K][]In),
+		wake:     make(chan struct{}, 1),
+	}
+	go d.forward()
+	return d
+}
+
+// Submit implements Pool. A duplicate is coalesced or rejected depending on
+// the mode; in Coalesce mode Submit returns nil and the duplicate's Result
+// arrives together with the original's. If the original cannot be
+// submitted, its duplicates get its error as their Result; Submit queues
+// those Results rather than wait for someone to receive them.
+func (d *Dedup[In, Out, K]) Submit(ctx context.Context, job In) error {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if d.closed {
+		return ErrClosed
+	}
+
+	k := d.key(job)
+	d.imu.Lock()
+	if waiting, ok := d.inflight[k]; ok {
+		defer d.imu.Unlock()
+		if d.mode == Reject {
+			return ErrDuplicate
+		}
+		d.inflight[k] = append(waiting, job)
+		return nil
+	}
+	d.inflight[k] = nil
+	d.imu.Unlock()
+
+	err := d.pool.Submit(ctx, job)
+	if err != nil {
+		// The original never runs: its duplicates fail the same way.
+		d.imu.Lock()
+		for _, dup := range d.inflight[k] {
+			d.failed = append(d.failed, Result[In, Out]{Job: dup, Result: result.Err[Out](err)})
+		}
+		delete(d.inflight, k)
+		d.imu.Unlock()
+		select {
+		case d.wake <- struct{}{}:
+		default: // forward has not picked up the last wake yet
+		}
+	}
+	return err
+}
+
+// release removes k from the in-flight set and returns its duplicates.
+func (d *Dedup[In, Out, K]) release(k K) []In {
+	d.imu.Lock()
+	defer d.imu.Unlock()
+	dups := d.inflight[k]
+	delete(d.inflight, k)
+	return dups
+}
+
+// takeFailed returns the queued Results of duplicates whose original could
+// not be submitted, and empties the queue.
+func (d *Dedup[In, Out, K]) takeFailed() []Result[In, Out] {
+	d.imu.Lock()
+	defer d.imu.Unlock()
+	failed := d.failed
+	d.failed = nil
+	return failed
+}
+
+func (d *Dedup[In, Out, K]) forward() {
+	send := func(r Result[In, Out]) {
+		d.failures.record(r.Job, r.Err)
+		d.results <- r
+	}
+	in := d.pool.Results()
+	for in != nil {
+		select {
+		case r, ok := <-in:
+			if !ok {
+				in = nil
+				continue
+			}
+			send(r)
+			for _, dup := range d.release(d.key(r.Job)) {
+				send(Result[In, Out]{Job: dup, Result: r.Result})
+			}
+		case <-d.wake:
+			for _, r := range d.takeFailed() {
+				send(r)
+			}
+		}
+	}
+	d.mu.Lock()
+	d.closed = true
+	d.mu.Unlock()
+	for _, r := range d.takeFailed() {
+		send(r)
+	}
+	close(d.results)
+}
+
+// Results implements Pool.
+func (d *Dedup[In, Out, K]) Results() <-chan Result[In, Out] { return d.results }
+
+// Close implements Pool.
+func (d *Dedup[In, Out, K]) Close() { d.pool.Close() }
+
+// Wait implements Pool.
+func (d *Dedup[In, Out, K]) Wait(ctx context.Context) error {
+	return wait(ctx, d, &d.failures)
+}
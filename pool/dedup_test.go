@@ -0,0 +1,143 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type fetch struct {
+	URL string
+	By  int // requester
+}
+
+func TestDedupCoalesces(t *testing.T) {
+	gate := make(chan struct{})
+	var runs atomic.Int32
+	fn := func(ctx context.Context, f fetch) (string, error) {
+		runs.Add(1)
+		<-gate
+		return "body of " + f.URL, nil
+	}
+	d := NewDedup(New(fn, WithWorkers(2)), func(f fetch) string { return f.URL }, Coalesce)
+
+	ctx := context.Background()
+	for by := range 5 {
+		if err := d.Submit(ctx, fetch{"/a", by}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	d.Submit(ctx, fetch{"/b", 0})
+	close(gate)
+	d.Close()
+
+	got := map[int]string{}
+	for r := range d.Results() {
+		if r.Job.URL == "/a" {
+			got[r.Job.By] = r.Must()
+		}
+	}
+	if runs.Load() != 2 {
+		t.Errorf("fetched %d times, want once per URL", runs.Load())
+	}
+	if len(got) != 5 {
+		t.Errorf("%d requesters of /a got a result, want 5: %v", len(got), got)
+	}
+	for by, body := range got {
+		if body != "body of /a" {
+			t.Errorf("requester %d got %q", by, body)
+		}
+	}
+}
+
+func TestDedupRejects(t *testing.T) {
+	gate := make(chan struct{})
+	fn := func(ctx context.Context, n int) (int, error) { <-gate; return n, nil }
+	d := NewDedup(New(fn, WithWorkers(1)), func(n int) int { return n % 10 }, Reject)
+
+	ctx := context.Background()
+	if err := d.Submit(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Submit(ctx, 11); !errors.Is(err, ErrDuplicate) {
+		t.Errorf("Submit of a duplicate = %v, want ErrDuplicate", err)
+	}
+	close(gate)
+	<-d.Results()
+
+	// Once the result is out, the key is free again.
+	if err := d.Submit(ctx, 21); err != nil {
+		t.Errorf("Submit after completion = %v, want nil", err)
+	}
+	if err := d.Wait(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDedupSharesFailures(t *testing.T) {
+	gate := make(chan struct{})
+	fn := func(ctx context.Context, n int) (int, error) { <-gate; return square(ctx, n) }
+	d := NewDedup(New(fn, WithWorkers(1)), func(n int) bool { return n < 0 }, Coalesce)
+	d.Submit(context.Background(), -3)
+	d.Submit(context.Background(), -4)
+	close(gate)
+
+	err := d.Wait(context.Background())
+	if err == nil || err.Error() != "job -3: negative input -3\njob -4: negative input -3" {
+		t.Errorf("Wait = %v, want the failure reported for both jobs", err)
+	}
+}
+
+// stuckPool is a Pool whose Submit signals entered and then fails once
+// fail is closed, like a full pool whose caller gives up.
+type stuckPool struct {
+	entered, fail chan struct{}
+	results       chan Result[int, int]
+}
+
+func (p *stuckPool) Submit(ctx context.Context, job int) error {
+	p.entered <- struct{}{}
+	<-p.fail
+	return errors.New("queue full")
+}
+func (p *stuckPool) Results() <-chan Result[int, int] { return p.results }
+func (p *stuckPool) Close()                           { close(p.results) }
+func (p *stuckPool) Wait(ctx context.Context) error   { p.Close(); return nil }
+
+func TestDedupFailedSubmitDoesNotWaitForReader(t *testing.T) {
+	p := &stuckPool{entered: make(chan struct{}), fail: make(chan struct{}), results: make(chan Result[int, int])}
+	d := NewDedup[int, int](p, func(n int) int { return n % 10 }, Coalesce)
+
+	ctx := context.Background()
+	submitted := make(chan error)
+	go func() { submitted <- d.Submit(ctx, 1) }()
+	<-p.entered
+	if err := d.Submit(ctx, 11); err != nil {
+		t.Fatalf("Submit of a duplicate = %v, want nil", err)
+	}
+	close(p.fail)
+
+	// Nobody reads Results yet, as when every job is submitted first.
+	select {
+	case err := <-submitted:
+		if err == nil {
+			t.Fatal("Submit succeeded, want the inner pool's error")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Submit blocked delivering the duplicate's Result")
+	}
+
+	d.Close()
+	var got []Result[int, int]
+	for r := range d.Results() {
+		got = append(got, r)
+	}
+	if len(got) != 1 || got[0].Job != 11 || got[0].Err == nil {
+		t.Errorf("Results = %v, want the duplicate failing", got)
+	}
+	if err := d.Submit(ctx, 2); !errors.Is(err, ErrClosed) {
+		t.Errorf("Submit after Results closed = %v, want ErrClosed", err)
+	}
+}
@@ -0,0 +1,183 @@
+package pool
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/lotusirous/gochan/clock"
+	"github.com/lotusirous/gochan/result"
+	"github.com/lotusirous/gochan/safego"
+)
+
+// ErrDeadlineMissed is returned for a job whose deadline passed before a
+// worker could start it: by Submit if it has already passed, or as the
+// job's Result error if it expired while queued. The job is not run.
+var ErrDeadlineMissed = errors.New("pool: job deadline missed")
+
+// EDFPool is a goroutine pool that schedules earliest deadline first. A
+// job's deadline is the deadline of the context it is submitted with; free
+// workers always take the queued job whose deadline is nearest, and jobs
+// without a deadline run only when no job with one is waiting. Jobs with
+// equal deadlines run in submission order.
+//
+// Running urgent work first maximizes the number of deadlines met as long
+// as the pool is not overloaded. Once it is, rejecting expired jobs with
+// ErrDeadlineMissed keeps the workers from wasting time on answers nobody
+// waits for any more.
+type EDFPool[In, Out any] struct {
+	clock    clock.Clock
+	slots    chan struct{} // one per queued job, bounds the queue
+	quit     chan struct{} // closed by Close to wake blocked submitters
+	results  chan Result[In, Out]
+	failures failures
+
+	mu     sync.Mutex
+	ready  *sync.Cond // signaled when a job is queued or the pool closes
+	queue  edfQueue[In]
+	seq    uint64
+	closed bool
+}
+
+var _ Pool[int, int] = (*EDFPool[int, int])(nil)
+
+type edfTask[In any] struct {
+	task[In]
+	deadline time.Time // zero if the job has none
+	seq      uint64
+}
+
+// NewEDF starts an earliest-deadline-first pool that processes jobs with
+// fn. WithLocalQueues is ignored.
+func NewEDF[In, Out any](fn Func[In, Out], opts ...Option) *EDFPool[In, Out] {
+	c := newConfig(opts)
+	p := &EDFPool[In, Out]{
+		clock:   c.clock,
+		slots:   make(chan struct{}, max(c.queue, 1)),
+		quit:    make(chan struct{}),
+		results: make(chan Result[In, Out], c.queue),
+	}
+	p.ready = sync.NewCond(&p.mu)
+	p.failures.limit = c.errorLimit
+
+	var wg sync.WaitGroup
+	for range c.workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				t, ok := p.take()
+				if !ok {
+					return
+				}
+				var v Out
+				var err error
+				if !t.deadline.IsZero() && !c.clock.Now().Before(t.deadline) {
+					err = ErrDeadlineMissed
+				} else {
+					ctx, end := c.start(t.ctx, t.job)
+					err = safego.Do(func() (err error) {
+						v, err = fn(ctx, t.job)
+						return err
+					})
+					end(err)
+				}
+				p.failures.record(t.job, err)
+				p.results <- Result[In, Out]{Job: t.job, Result: result.Of(v, err)}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(p.results)
+	}()
+	return p
+}
+
+// Submit implements Pool. It returns ErrDeadlineMissed without queueing the
+// job if ctx's deadline has already passed.
+func (p *EDFPool[In, Out]) Submit(ctx context.Context, job In) error {
+	deadline, _ := ctx.Deadline()
+	if !deadline.IsZero() && !p.clock.Now().Before(deadline) {
+		return ErrDeadlineMissed
+	}
+	select {
+	case p.slots <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-p.quit:
+		return ErrClosed
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		<-p.slots
+		return ErrClosed
+	}
+	p.seq++
+	heap.Push(&p.queue, edfTask[In]{task[In]{ctx, job}, deadline, p.seq})
+	p.ready.Signal()
+	return nil
+}
+
+// take returns the most urgent job, waiting for one if the queue is empty.
+// It returns false once the pool is closed and drained.
+func (p *EDFPool[In, Out]) take() (edfTask[In], bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for len(p.queue) == 0 {
+		if p.closed {
+			return edfTask[In]{}, false
+		}
+		p.ready.Wait()
+	}
+	t := heap.Pop(&p.queue).(edfTask[In])
+	<-p.slots
+	return t, true
+}
+
+// Results implements Pool.
+func (p *EDFPool[In, Out]) Results() <-chan Result[In, Out] { return p.results }
+
+// Close implements Pool.
+func (p *EDFPool[In, Out]) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.closed {
+		close(p.quit)
+	}
+	p.closed = true
+	p.ready.Broadcast()
+}
+
+// Wait implements Pool.
+func (p *EDFPool[In, Out]) Wait(ctx context.Context) error {
+	return wait(ctx, p, &p.failures)
+}
+
+// edfQueue is a min-heap ordered by deadline, then submission order. Jobs
+// without a deadline sort after every job with one.
+type edfQueue[In any] []edfTask[In]
+
+func (q edfQueue[In]) Len() int { return len(q) }
+func (q edfQueue[In]) Less(i, j int) bool {
+	a, b := q[i], q[j]
+	switch {
+	case a.deadline.IsZero() != b.deadline.IsZero():
+		return b.deadline.IsZero()
+	case !a.deadline.Equal(b.deadline):
+		return a.deadline.Before(b.deadline)
+	}
+	return a.seq < b.seq
+}
+func (q edfQueue[In]) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+func (q *edfQueue[In]) Push(x any)   { *q = append(*q, x.(edfTask[In])) }
+func (q *edfQueue[In]) Pop() any {
+	old := *q
+	t := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return t
+}
@@ -0,0 +1,93 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+// gated returns a job function that blocks job 0 until the gate is closed,
+// so tests can queue jobs behind it. started is closed once job 0 runs.
+func gated(gate, started chan struct{}, ran *[]int) Func[int, int] {
+	return func(ctx context.Context, n int) (int, error) {
+		if n == 0 {
+			close(started)
+			<-gate
+		}
+		*ran = append(*ran, n) // one worker, no race
+		return n, nil
+	}
+}
+
+func TestEDFRunsEarliestDeadlineFirst(t *testing.T) {
+	gate, started := make(chan struct{}), make(chan struct{})
+	var ran []int
+	p := NewEDF(gated(gate, started, &ran), WithWorkers(1), WithQueue(10))
+	p.Submit(context.Background(), 0)
+	<-started
+
+	now := time.Now()
+	for _, job := range []struct {
+		n  int
+		in time.Duration // 0 means no deadline
+	}{{5, 5 * time.Hour}, {1, time.Hour}, {6, 0}, {3, 3 * time.Hour}, {2, 2 * time.Hour}, {7, 0}} {
+		ctx := context.Background()
+		if job.in > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithDeadline(ctx, now.Add(job.in))
+			defer cancel()
+		}
+		if err := p.Submit(ctx, job.n); err != nil {
+			t.Fatal(err)
+		}
+	}
+	close(gate)
+	if err := p.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{0, 1, 2, 3, 5, 6, 7}; !slices.Equal(ran, want) {
+		t.Errorf("ran %v, want %v", ran, want)
+	}
+}
+
+func TestEDFRejectsMissedDeadlines(t *testing.T) {
+	expired, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	p := NewEDF(square, WithWorkers(1))
+	if err := p.Submit(expired, 1); !errors.Is(err, ErrDeadlineMissed) {
+		t.Errorf("Submit with a passed deadline = %v, want ErrDeadlineMissed", err)
+	}
+	p.Close()
+
+	gate, started := make(chan struct{}), make(chan struct{})
+	var ran []int
+	p = NewEDF(gated(gate, started, &ran), WithWorkers(1))
+	p.Submit(context.Background(), 0)
+	<-started
+	soon, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	p.Submit(soon, 1)
+	time.Sleep(30 * time.Millisecond)
+	close(gate)
+
+	p.Close()
+	for r := range p.Results() {
+		if r.Job == 1 && !errors.Is(r.Err, ErrDeadlineMissed) {
+			t.Errorf("expired job result = %+v, want ErrDeadlineMissed", r)
+		}
+	}
+	if !slices.Equal(ran, []int{0}) {
+		t.Errorf("ran %v, the expired job must not run", ran)
+	}
+}
+
+func TestEDFSubmitWokenByClose(t *testing.T) {
+	gate, started := make(chan struct{}), make(chan struct{})
+	var ran []int
+	p := NewEDF(gated(gate, started, &ran), WithWorkers(1), WithQueue(1))
+	if err := submitAtClose(t, p, gate, started); !errors.Is(err, ErrClosed) {
+		t.Errorf("Submit blocked at Close = %v, want ErrClosed", err)
+	}
+}
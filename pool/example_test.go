@@ -0,0 +1,76 @@
+package pool_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/lotusirous/gochan/clock"
+	"github.com/lotusirous/gochan/pool"
+)
+
+func ExampleNew() {
+	square := func(ctx context.Context, n int) (int, error) { return n * n, nil }
+	p := pool.New(square, pool.WithWorkers(3))
+	go func() {
+		defer p.Close()
+		for n := range 5 {
+			p.Submit(context.Background(), n)
+		}
+	}()
+
+	// Results arrive as jobs finish, not in submission order.
+	var squares []int
+	for r := range p.Results() {
+		squares = append(squares, r.Value)
+	}
+	slices.Sort(squares)
+	fmt.Println(squares)
+	// Output: [0 1 4 9 16]
+}
+
+func ExampleWithMetrics() {
+	var m pool.Counters
+	clk := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	check := func(ctx context.Context, n int) (int, error) {
+		clk.Advance(time.Duration(n) * time.Second) // the job takes n seconds
+		if n == 3 {
+			return 0, errors.New("three is bad luck")
+		}
+		return n, nil
+	}
+	p := pool.New(check, pool.WithWorkers(1), pool.WithQueue(4), pool.WithClock(clk), pool.WithMetrics(&m))
+	for n := range 4 {
+		p.Submit(context.Background(), n)
+	}
+	fmt.Println(p.Wait(context.Background()))
+	fmt.Println(m.Succeeded.Load(), "succeeded,", m.Failed.Load(), "failed, busy for", m.Busy())
+	// Output:
+	// job 3: three is bad luck
+	// 3 succeeded, 1 failed, busy for 6s
+}
+
+func ExampleNewScheduler() {
+	clk := clock.NewFake(time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC))
+	remind := func(ctx context.Context, what string) (string, error) {
+		return clk.Now().Format("15:04 ") + what, nil
+	}
+	s := pool.NewScheduler(pool.New(remind, pool.WithWorkers(1)), pool.WithClock(clk))
+	ctx := context.Background()
+	s.SubmitAfter(ctx, 30*time.Minute, "stand-up")
+	s.SubmitAfter(ctx, 3*time.Hour, "lunch")
+
+	for range 2 {
+		// Move the fake time to the next job the scheduler sleeps for.
+		clk.BlockUntil(1)
+		next, _ := clk.Next()
+		clk.Advance(next.Sub(clk.Now()))
+		fmt.Println((<-s.Results()).Value)
+	}
+	s.Wait(ctx)
+	// Output:
+	// 09:30 stand-up
+	// 12:00 lunch
+}
@@ -0,0 +1,211 @@
+package pool
+
+import (
+	"context"
+	"sync"
+)
+
+// FairConfig configures a Fair dispatcher. The zero value is usable.
+type FairConfig[In any] struct {
+	// PerTenant is how many jobs each tenant may have queued; Submit blocks
+	// at the limit. If <= 0 it defaults to 64.
+	PerTenant int
+	// Cost, if set, returns the cost of a job in arbitrary units. Tenants
+	// then get equal shares of cost instead of equal numbers of jobs. A job
+	// must cost at least 1.
+	Cost func(In) int
+	// Weight, if set, returns the weight of a tenant. A tenant of weight w
+	// earns w units of credit per round, so under saturation tenants share
+	// the pool in proportion to their weights (weighted fair queueing).
+	// Weights below 1 count as 1. It is called once per tenant, when the
+	// tenant submits its first job.
+	Weight func(tenant string) int
+}
+
+// Fair feeds the jobs of many tenants into one Pool so that a tenant
+// submitting a flood of jobs cannot starve the others.
+//
+// Every tenant has its own queue. A dispatcher goroutine visits the queues
+// in turn using deficit round-robin: on every visit a tenant earns credit
+// equal to its weight and sends jobs to the pool while it has enough credit
+// to pay for them. With the default cost and weight of 1 this is plain
+// round-robin. The pool's
+// own queue stays short, so the order in which jobs reach the workers is the
+// dispatcher's, not the order in which they were submitted.
+type Fair[In, Out any] struct {
+	pool Pool[In, Out]
+	cfg  FairConfig[In]
+
+	wake   chan struct{} // signaled, without blocking, on every Submit
+	quit   chan struct{} // closed by Close to wake blocked submitters
+	sealed chan struct{} // closed once no Submit can add a job any more
+	done   chan struct{} // closed when the dispatcher has handed off every job
+
+	mu        sync.RWMutex
+	closed    bool
+	closeOnce sync.Once
+
+	tmu     sync.Mutex
+	tenants map[string]*tenant[In]
+	order   []*tenant[In] // in order of first submission
+}
+
+type tenant[In any] struct {
+	name    string
+	weight  int
+	queue   chan task[In]
+	head    *task[In] // taken from queue but not yet dispatched
+	deficit int
+}
+
+// NewFair starts a dispatcher in front of p. Fair takes ownership of p: it
+// closes p once Fair is closed and every queued job has been handed over.
+func NewFair[In, Out any](p Pool[In, Out], cfg FairConfig[In]) *Fair[In, Out] {
+	if cfg.PerTenant <= 0 {
+		cfg.PerTenant = 64
+	}
+	if cfg.Cost == nil {
+		cfg.Cost = func(In) int { return 1 }
+	}
+	f := &Fair[In, Out]{
+		pool:    p,
+		cfg:     cfg,
+		wake:    make(chan struct{}, 1),
+		quit:    make(chan struct{}),
+		sealed:  make(chan struct{}),
+		done:    make(chan struct{}),
+		tenants: make(map[string]*tenant[In]),
+	}
+	go f.dispatch()
+	return f
+}
+
+// Submit queues job for tenant. It blocks while the tenant's queue is full,
+// without affecting other tenants, and returns ctx.Err() if ctx is done
+// first or ErrClosed after Close. The job runs with ctx; a job whose ctx is
+// done before it reaches the pool is dropped without a Result.
+func (f *Fair[In, Out]) Submit(ctx context.Context, tenantName string, job In) error {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	if f.closed {
+		return ErrClosed
+	}
+	t := f.tenant(tenantName)
+	select {
+	case t.queue <- task[In]{ctx, job}:
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-f.quit:
+		return ErrClosed
+	}
+	select {
+	case f.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// tenant returns the queue of name, creating it on first use.
+func (f *Fair[In, Out]) tenant(name string) *tenant[In] {
+	f.tmu.Lock()
+	defer f.tmu.Unlock()
+	if t, ok := f.tenants[name]; ok {
+		return t
+	}
+	t := &tenant[In]{name: name, weight: 1, queue: make(chan task[In], f.cfg.PerTenant)}
+	if f.cfg.Weight != nil {
+		t.weight = max(f.cfg.Weight(name), 1)
+	}
+	f.tenants[name] = t
+	f.order = append(f.order, t)
+	return t
+}
+
+// Results returns the Results of the underlying pool.
+func (f *Fair[In, Out]) Results() <-chan Result[In, Out] { return f.pool.Results() }
+
+// Close stops accepting jobs. Jobs already queued are still dispatched.
+func (f *Fair[In, Out]) Close() {
+	f.closeOnce.Do(func() {
+		close(f.quit)
+		f.mu.Lock()
+		f.closed = true
+		f.mu.Unlock()
+		close(f.sealed)
+	})
+}
+
+// Wait closes f, waits until every queued job has run and returns the
+// result of the pool's Wait.
+func (f *Fair[In, Out]) Wait(ctx context.Context) error {
+	f.Close()
+	select {
+	case <-f.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return f.pool.Wait(ctx)
+}
+
+func (f *Fair[In, Out]) dispatch() {
+	defer close(f.done)
+	defer f.pool.Close()
+	for {
+		f.tmu.Lock()
+		tenants := f.order
+		f.tmu.Unlock()
+
+		busy := false
+		for _, t := range tenants {
+			if !t.next() {
+				t.deficit = 0 // idle tenants do not bank credit
+				continue
+			}
+			busy = true
+			t.deficit += t.weight
+			for t.head != nil && f.cfg.Cost(t.head.job) <= t.deficit {
+				t.deficit -= f.cfg.Cost(t.head.job)
+				// Errors only mean the job's own ctx is done; drop it.
+				_ = f.pool.Submit(t.head.ctx, t.head.job)
+				t.head = nil
+				t.next()
+			}
+		}
+		if busy {
+			continue
+		}
+
+		select {
+		case <-f.wake:
+		case <-f.sealed:
+			if f.drained() {
+				return
+			}
+		}
+	}
+}
+
+// drained reports whether every tenant queue is empty. It is only
+// meaningful once f is sealed and no job can be added.
+func (f *Fair[In, Out]) drained() bool {
+	f.tmu.Lock()
+	defer f.tmu.Unlock()
+	for _, t := range f.order {
+		if t.head != nil || len(t.queue) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// next makes sure t.head holds the tenant's next job, if it has one.
+func (t *tenant[In]) next() bool {
+	if t.head == nil {
+		select {
+		case tk := <-t.queue:
+			t.head = &tk
+		default:
+		}
+	}
+	return t.head != nil
+}
@@ -0,0 +1,127 @@
+package pool
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+type tenantJob struct {
+	Tenant string
+	N      int
+}
+
+func echoTenant(ctx context.Context, j tenantJob) (string, error) { return j.Tenant, nil }
+
+// TestFairBoundsWaitBehindAFlood queues 500 jobs for one tenant before a
+// second tenant submits 10. In FIFO order the small tenant would finish
+// last; with Fair its jobs alternate with the flood, so all 10 are done
+// within roughly the first 20 results.
+func TestFairBoundsWaitBehindAFlood(t *testing.T) {
+	const flood, small = 500, 10
+	f := NewFair(New(echoTenant, WithWorkers(1)), FairConfig[tenantJob]{PerTenant: flood})
+
+	ctx := context.Background()
+	for i := range flood {
+		if err := f.Submit(ctx, "flood", tenantJob{"flood", i}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := range small {
+		if err := f.Submit(ctx, "small", tenantJob{"small", i}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	f.Close()
+
+	var order []string
+	for r := range f.Results() {
+		order = append(order, r.Value)
+	}
+	if len(order) != flood+small {
+		t.Fatalf("%d results, want %d", len(order), flood+small)
+	}
+	// The bound allows for the flood jobs already in the pool (queue and
+	// worker) and in the dispatcher's hands before the small tenant arrived.
+	last := -1
+	for i, tenant := range order {
+		if tenant == "small" {
+			last = i
+		}
+	}
+	if bound := 2*small + 4; last >= bound {
+		t.Errorf("last small job finished at position %d, want < %d:\n%s", last, bound, strings.Join(order[:last+1], " "))
+	}
+}
+
+func TestFairCost(t *testing.T) {
+	// "big" jobs cost 4, "tiny" jobs 1: per unit of credit the dispatcher
+	// should run about four tiny jobs per big one.
+	f := NewFair(New(echoTenant, WithWorkers(1), WithQueue(0)), FairConfig[tenantJob]{
+		PerTenant: 100,
+		Cost: func(j tenantJob) int {
+			if j.Tenant == "big" {
+				return 4
+			}
+			return 1
+		},
+	})
+	for i := range 100 {
+		f.Submit(context.Background(), "big", tenantJob{"big", i})
+		f.Submit(context.Background(), "tiny", tenantJob{"tiny", i})
+	}
+	f.Close()
+
+	counts := map[string]int{}
+	n := 0
+	for r := range f.Results() {
+		if n < 50 {
+			counts[r.Value]++
+		}
+		n++
+	}
+	if counts["tiny"] < 3*counts["big"] {
+		t.Errorf("first 50 results: %v, want about 4 tiny per big", counts)
+	}
+}
+
+func TestFairClose(t *testing.T) {
+	f := NewFair(New(echoTenant, WithWorkers(2)), FairConfig[tenantJob]{})
+	f.Submit(context.Background(), "a", tenantJob{"a", 1})
+	if err := f.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Submit(context.Background(), "a", tenantJob{"a", 2}); err != ErrClosed {
+		t.Errorf("Submit after Close = %v, want ErrClosed", err)
+	}
+}
+
+func TestFairWeights(t *testing.T) {
+	weights := map[string]int{"gold": 4, "silver": 2, "bronze": 1}
+	f := NewFair(New(echoTenant, WithWorkers(1), WithQueue(0)), FairConfig[tenantJob]{
+		PerTenant: 200,
+		Weight:    func(tenant string) int { return weights[tenant] },
+	})
+	for i := range 200 {
+		for tenant := range weights {
+			f.Submit(context.Background(), tenant, tenantJob{tenant, i})
+		}
+	}
+	f.Close()
+
+	// While every tenant still has work queued, shares follow the weights.
+	counts := map[string]int{}
+	n := 0
+	for r := range f.Results() {
+		if n < 210 {
+			counts[r.Value]++
+		}
+		n++
+	}
+	for tenant, w := range weights {
+		want := 210 * w / 7
+		if got := counts[tenant]; got < want-10 || got > want+10 {
+			t.Errorf("%s (weight %d): %d of the first 210 jobs, want about %d", tenant, w, got, want)
+		}
+	}
+}
@@ -0,0 +1,289 @@
+package pool
+
+import (
+	"context"
+	"sync"
+
+	"github.com/lotusirous/gochan/hashring"
+	"github.com/lotusirous/gochan/result"
+	"github.com/lotusirous/gochan/safego"
+)
+
+// Keyed is a goroutine pool that runs every job on the worker its key maps
+// to, so jobs with the same key never run at the same time, and jobs with
+// the same key submitted one after another run in that order. Each worker
+// has its own queue of the size set by WithQueue.
+//
+// Keys are assigned to workers on a consistent hash ring, so Resize moves
+// only about the share of keys that the added or removed workers take over
+// or give up, and per-key state a worker keeps, such as a cache, mostly
+// stays where it is. A key that Resize moves waits until its jobs queued on
+// the old worker have finished before it starts on the new one.
+type Keyed[K comparable, In, Out any] struct {
+	fn       Func[In, Out]
+	key      func(In) K
+	size     int
+	config   config
+	results  chan Result[In, Out]
+	failures failures
+	running  sync.WaitGroup // every worker, including those Resize removed
+
+	quit      chan struct{} // closed by Close to wake blocked submitters
+	closeOnce sync.Once
+
+	// mu is held for reading by Submit while it picks a worker and for
+	// writing while Resize or Close change the workers. Nobody holds it
+	// while waiting: a Submit blocked on a full queue or on a moved key
+	// must not hold up Resize or Close.
+	mu      sync.RWMutex
+	closed  bool
+	ring    *hashring.Ring[K, int]
+	ids     []int // live workers, oldest first
+	workers map[int]*keyedWorker[In]
+	nextID  int // worker ids are never reused
+	kmu     sync.Mutex
+	drained *sync.Cond // broadcast when a key has no jobs queued any more
+	keys    map[K]*keyState
+}
+
+// keyState is where a key's queued and running jobs are.
+type keyState struct {
+	worker int
+	queued int
+}
+
+// keyedWorker is a worker of a Keyed pool and its queue.
+type keyedWorker[In any] struct {
+	queue   chan task[In]
+	removed chan struct{}  // closed by Resize or Close to stop the worker
+	sending sync.WaitGroup // submitters that may still send to queue
+}
+
+var _ Pool[int, int] = (*Keyed[int, int, int])(nil)
+
+// NewKeyed starts a keyed pool that processes jobs with fn, identifying
+// them by key.
+func NewKeyed[K comparable, In, Out any](fn Func[In, Out], key func(In) K, opts ...Option) *Keyed[K, In, Out] {
+	c := newConfig(opts)
+	p := &Keyed[K, In, Out]{
+		fn:      fn,
+		key:     key,
+		size:    c.queue,
+		config:  c,
+		results: make(chan Result[In, Out], c.queue),
+		quit:    make(chan struct{}),
+		ring:    hashring.New[K, int](0),
+		workers: make(map[int]*keyedWorker[In]),
+		keys:    make(map[K]*keyState),
+	}
+	p.failures.limit = c.errorLimit
+	p.drained = sync.NewCond(&p.kmu)
+	p.Resize(c.workers)
+	return p
+}
+
+// Resize changes the number of workers to n, at least 1. Added workers
+// start at once; removed ones finish the jobs already queued for them and
+// exit. It returns ErrClosed after Close.
+func (p *Keyed[K, In, Out]) Resize(n int) error {
+	n = max(n, 1)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return ErrClosed
+	}
+	for len(p.ids) < n {
+		id := p.nextID
+		p.nextID++
+		w := &keyedWorker[In]{queue: make(chan task[In], p.size), removed: make(chan struct{})}
+		p.ids = append(p.ids, id)
+		p.workers[id] = w
+		p.ring.Add(id)
+		p.running.Add(1)
+		go p.work(w)
+	}
+	for len(p.ids) > n {
+		id := p.ids[len(p.ids)-1]
+		p.ids = p.ids[:len(p.ids)-1]
+		p.ring.Remove(id)
+		close(p.workers[id].removed)
+		delete(p.workers, id)
+	}
+	return nil
+}
+
+// Workers returns the current number of workers.
+func (p *Keyed[K, In, Out]) Workers() int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return len(p.ids)
+}
+
+// Submit implements Pool. It also blocks while the job's key, moved by
+// Resize, still has jobs queued on its old worker.
+func (p *Keyed[K, In, Out]) Submit(ctx context.Context, job In) error {
+	k := p.key(job)
+	for {
+		id, ok := p.pick(k)
+		if !ok {
+			return ErrClosed
+		}
+		if err := p.claim(ctx, k, id); err != nil {
+			return err
+		}
+		// Resize may have moved k or removed the worker meanwhile.
+		w, ok := p.enter(k, id)
+		if !ok {
+			p.release(k)
+			continue
+		}
+		select {
+		case w.queue <- task[In]{ctx, job}:
+			w.sending.Done()
+			return nil
+		case <-ctx.Done():
+			w.sending.Done()
+			p.release(k)
+			return ctx.Err()
+		case <-p.quit:
+			w.sending.Done()
+			p.release(k)
+			return ErrClosed
+		case <-w.removed:
+			w.sending.Done()
+			p.release(k)
+		}
+	}
+}
+
+// pick returns the worker k maps to, or false after Close.
+func (p *Keyed[K, In, Out]) pick(k K) (int, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	id, _ := p.ring.Get(k)
+	return id, !p.closed
+}
+
+// enter returns worker id, registered as having a job about to be sent to
+// it, if k still maps to it. The caller must call w.sending.Done once the
+// send is over.
+func (p *Keyed[K, In, Out]) enter(k K, id int) (*keyedWorker[In], bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if now, _ := p.ring.Get(k); p.closed || now != id {
+		return nil, false
+	}
+	w := p.workers[id]
+	w.sending.Add(1)
+	return w, true
+}
+
+// claim counts a job of k as queued on worker w, once k's jobs on any other
+// worker have finished. It returns ErrClosed if the pool is closed first.
+func (p *Keyed[K, In, Out]) claim(ctx context.Context, k K, w int) error {
+	p.kmu.Lock()
+	defer p.kmu.Unlock()
+	for waiting := false; ; waiting = true {
+		s, ok := p.keys[k]
+		if !ok {
+			s = &keyState{worker: w}
+			p.keys[k] = s
+		}
+		if s.worker == w || s.queued == 0 {
+			s.worker = w
+			s.queued++
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		select {
+		case <-p.quit:
+			return ErrClosed
+		default:
+		}
+		if !waiting {
+			defer context.AfterFunc(ctx, func() {
+				p.kmu.Lock()
+				p.drained.Broadcast()
+				p.kmu.Unlock()
+			})()
+		}
+		p.drained.Wait()
+	}
+}
+
+// release counts a job of k as no longer queued.
+func (p *Keyed[K, In, Out]) release(k K) {
+	p.kmu.Lock()
+	defer p.kmu.Unlock()
+	s := p.keys[k]
+	if s.queued--; s.queued == 0 {
+		delete(p.keys, k)
+		p.drained.Broadcast()
+	}
+}
+
+// work runs w's jobs until w is removed, and then those still queued.
+func (p *Keyed[K, In, Out]) work(w *keyedWorker[In]) {
+	defer p.running.Done()
+	for {
+		select {
+		case t := <-w.queue:
+			p.run(t)
+		case <-w.removed:
+			// Submitters see removed too and give up or finish their send.
+			w.sending.Wait()
+			for {
+				select {
+				case t := <-w.queue:
+					p.run(t)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (p *Keyed[K, In, Out]) run(t task[In]) {
+	ctx, end := p.config.start(t.ctx, t.job)
+	var v Out
+	err := safego.Do(func() (err error) {
+		v, err = p.fn(ctx, t.job)
+		return err
+	})
+	end(err)
+	p.release(p.key(t.job))
+	p.failures.record(t.job, err)
+	p.results <- Result[In, Out]{Job: t.job, Result: result.Of(v, err)}
+}
+
+// Results implements Pool.
+func (p *Keyed[K, In, Out]) Results() <-chan Result[In, Out] { return p.results }
+
+// Close implements Pool.
+func (p *Keyed[K, In, Out]) Close() {
+	p.closeOnce.Do(func() {
+		close(p.quit)
+		// Wake submitters waiting for a moved key to drain.
+		p.kmu.Lock()
+		p.drained.Broadcast()
+		p.kmu.Unlock()
+		p.mu.Lock()
+		p.closed = true
+		for _, w := range p.workers {
+			close(w.removed)
+		}
+		p.mu.Unlock()
+		go func() {
+			p.running.Wait()
+			close(p.results)
+		}()
+	})
+}
+
+// Wait implements Pool.
+func (p *Keyed[K, In, Out]) Wait(ctx context.Context) error {
+	return wait(ctx, p, &p.failures)
+}
@@ -0,0 +1,283 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+type keyedJob struct {
+	Key, Seq int
+}
+
+// orderCheck is a job function that fails a test when jobs of a key run
+// concurrently or out of sequence.
+type orderCheck struct {
+	t       *testing.T
+	mu      sync.Mutex
+	last    map[int]int
+	running map[int]bool
+}
+
+func newOrderCheck(t *testing.T) *orderCheck {
+	return &orderCheck{t: t, last: map[int]int{}, running: map[int]bool{}}
+}
+
+func (o *orderCheck) enter(j keyedJob) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.running[j.Key] {
+		o.t.Errorf("job %v runs alongside another of its key", j)
+	}
+	if j.Seq != o.last[j.Key]+1 {
+		o.t.Errorf("job %v runs after seq %d", j, o.last[j.Key])
+	}
+	o.running[j.Key] = true
+	o.last[j.Key] = j.Seq
+}
+
+func (o *orderCheck) leave(j keyedJob) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.running[j.Key] = false
+}
+
+func TestKeyedOrderAcrossResize(t *testing.T) {
+	check := newOrderCheck(t)
+	fn := func(ctx context.Context, j keyedJob) (int, error) {
+		check.enter(j)
+		defer check.leave(j)
+		if rand.IntN(8) == 0 {
+			time.Sleep(50 * time.Microsecond)
+		}
+		runtime.Gosched()
+		return j.Seq, nil
+	}
+	p := NewKeyed(fn, func(j keyedJob) int { return j.Key }, WithWorkers(4), WithQueue(16))
+
+	consumed := make(chan struct{})
+	go func() {
+		defer close(consumed)
+		for range p.Results() {
+		}
+	}()
+	stop := make(chan struct{})
+	resized := make(chan struct{})
+	go func() {
+		defer close(resized)
+		for {
+			select {
+			case <-stop:
+				return
+			case <-time.After(200 * time.Microsecond):
+				p.Resize(1 + rand.IntN(8))
+			}
+		}
+	}()
+	ctx := context.Background()
+	seq := map[int]int{}
+	for range 5000 {
+		k := rand.IntN(50)
+		seq[k]++
+		if err := p.Submit(ctx, keyedJob{k, seq[k]}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	close(stop)
+	<-resized
+	p.Close()
+	<-consumed
+	for k, n := range seq {
+		if check.last[k] != n {
+			t.Errorf("key %d: last job run was %d of %d", k, check.last[k], n)
+		}
+	}
+}
+
+func TestKeyedMovedKeyWaitsForOldWorker(t *testing.T) {
+	gate := make(chan struct{})
+	check := newOrderCheck(t)
+	fn := func(ctx context.Context, j keyedJob) (int, error) {
+		check.enter(j)
+		defer check.leave(j)
+		if j.Key == 0 {
+			<-gate
+		}
+		return j.Seq, nil
+	}
+	p := NewKeyed(fn, func(j keyedJob) int { return j.Key }, WithWorkers(1), WithQueue(32))
+	ctx := context.Background()
+
+	// Key 0 blocks the only worker, with the first jobs of the others
+	// queued behind it.
+	for k := range 20 {
+		p.Submit(ctx, keyedJob{k, 1})
+	}
+	p.Resize(2)
+	moved := -1
+	for k := 1; k < 20 && moved < 0; k++ {
+		if w, _ := p.ring.Get(k); w != 0 {
+			moved = k
+		}
+	}
+	if moved < 0 {
+		t.Skip("no key moved to the new worker")
+	}
+
+	submitted := make(chan error)
+	go func() { submitted <- p.Submit(ctx, keyedJob{moved, 2}) }()
+	select {
+	case err := <-submitted:
+		t.Fatalf("second job of moved key %d submitted (%v) while its first was queued on the old worker", moved, err)
+	case <-time.After(20 * time.Millisecond):
+	}
+	close(gate)
+	if err := <-submitted; err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Wait(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if check.last[moved] != 2 {
+		t.Errorf("moved key ran up to job %d, want 2", check.last[moved])
+	}
+}
+
+func TestKeyedMovedKeyHonorsContext(t *testing.T) {
+	gate := make(chan struct{})
+	defer close(gate)
+	fn := func(ctx context.Context, k int) (int, error) { <-gate; return k, nil }
+	p := NewKeyed(fn, func(k int) int { return k }, WithWorkers(1), WithQueue(32))
+	for k := range 20 {
+		p.Submit(context.Background(), k)
+	}
+	p.Resize(2)
+	for k := range 20 {
+		if w, _ := p.ring.Get(k); w == 0 {
+			continue
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		if err := p.Submit(ctx, k); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Submit of moved key %d = %v, want DeadlineExceeded", k, err)
+		}
+		return
+	}
+	t.Skip("no key moved to the new worker")
+}
+
+// returnsSoon fails t if fn has not returned within a second.
+func returnsSoon(t *testing.T, what string, fn func()) {
+	t.Helper()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		fn()
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("%s blocked", what)
+	}
+}
+
+func TestKeyedCloseWakesMovedKeySubmitter(t *testing.T) {
+	gate := make(chan struct{})
+	defer close(gate)
+	fn := func(ctx context.Context, k int) (int, error) { <-gate; return k, nil }
+	p := NewKeyed(fn, func(k int) int { return k }, WithWorkers(1), WithQueue(32))
+	for k := range 20 {
+		p.Submit(context.Background(), k)
+	}
+	p.Resize(2)
+	moved := -1
+	for k := 0; k < 20 && moved < 0; k++ {
+		if w, _ := p.ring.Get(k); w != 0 {
+			moved = k
+		}
+	}
+	if moved < 0 {
+		t.Skip("no key moved to the new worker")
+	}
+
+	submitted := make(chan error)
+	go func() { submitted <- p.Submit(context.Background(), moved) }()
+	time.Sleep(20 * time.Millisecond) // until it waits for the old worker
+	returnsSoon(t, "Close", p.Close)
+	if err := <-submitted; !errors.Is(err, ErrClosed) {
+		t.Errorf("Submit of moved key = %v, want ErrClosed", err)
+	}
+}
+
+func TestKeyedResizeWithSubmitterBlocked(t *testing.T) {
+	gate := make(chan struct{})
+	fn := func(ctx context.Context, k int) (int, error) { <-gate; return k, nil }
+	p := NewKeyed(fn, func(k int) int { return k }, WithWorkers(1), WithQueue(1))
+	ctx := context.Background()
+	p.Submit(ctx, 0) // runs
+	p.Submit(ctx, 0) // fills the queue
+
+	submitted := make(chan error)
+	go func() { submitted <- p.Submit(ctx, 0) }()
+	time.Sleep(20 * time.Millisecond) // until it waits for room in the queue
+	returnsSoon(t, "Resize", func() { p.Resize(3) })
+	returnsSoon(t, "Resize", func() { p.Resize(1) })
+
+	close(gate)
+	if err := <-submitted; err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Wait(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestKeyedResize(t *testing.T) {
+	p := NewKeyed(square, func(n int) int { return n }, WithWorkers(3), WithQueue(16))
+	for _, n := range []int{5, 1, 0, 2} {
+		p.Resize(n)
+		if got, want := p.Workers(), max(n, 1); got != want {
+			t.Errorf("Resize(%d): %d workers, want %d", n, got, want)
+		}
+	}
+	for n := range 10 {
+		p.Submit(context.Background(), n)
+	}
+	if err := p.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Resize(4); !errors.Is(err, ErrClosed) {
+		t.Errorf("Resize after Close = %v, want ErrClosed", err)
+	}
+	if err := p.Submit(context.Background(), 1); !errors.Is(err, ErrClosed) {
+		t.Errorf("Submit after Close = %v, want ErrClosed", err)
+	}
+}
+
+func ExampleKeyed() {
+	// Jobs of one account run in order, one at a time.
+	balances := map[string]int{}
+	var mu sync.Mutex
+	apply := func(ctx context.Context, tx [2]string) (string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		var amount int
+		fmt.Sscan(tx[1], &amount)
+		balances[tx[0]] += amount
+		return tx[0], nil
+	}
+	p := NewKeyed(apply, func(tx [2]string) string { return tx[0] }, WithWorkers(2))
+	for _, tx := range [][2]string{{"alice", "10"}, {"bob", "5"}, {"alice", "-3"}} {
+		p.Submit(context.Background(), tx)
+	}
+	p.Resize(4)
+	p.Submit(context.Background(), [2]string{"bob", "7"})
+	p.Wait(context.Background())
+	fmt.Println(balances["alice"], balances["bob"])
+	// Output: 7 12
+}
@@ -0,0 +1,170 @@
+package pool
+
+import (
+	"context"
+	"runtime"
+	"sync"
+)
+
+// ObjectsConfig describes how an Objects pool creates, recycles and disposes
+// of its values. Only New is required.
+type ObjectsConfig[T any] struct {
+	// New creates a value when no idle one is available.
+	New func(ctx context.Context) (T, error)
+	// Reset, if set, clears a value when it is returned with Put so the next
+	// Get sees a clean one. It runs with the pool locked, so it must not
+	// call back into the pool.
+	Reset func(T)
+	// Destroy, if set, releases a value that leaves the pool for good: it
+	// did not fit in the idle list, was discarded, or the pool was closed.
+	Destroy func(T)
+	// MaxIdle caps how many returned values are kept for reuse. If <= 0 it
+	// defaults to GOMAXPROCS.
+	MaxIdle int
+	// MaxActive caps how many values can be checked out at once; Get blocks
+	// at the limit. If <= 0 there is no limit.
+	MaxActive int
+}
+
+// Objects is a typed pool of reusable values, such as buffers or
+// connections.
+//
+// Unlike sync.Pool it never drops values behind the caller's back, runs
+// Reset and Destroy hooks, and can bound the number of values in use, which
+// makes Get block (and honor its context) when the pool is exhausted.
+type Objects[T any] struct {
+	cfg    ObjectsConfig[T]
+	active chan struct{} // one token per checked-out value; nil if unbounded
+
+	mu     sync.Mutex
+	idle   []T
+	closed bool
+}
+
+// NewObjects returns an empty pool. It panics if cfg.New is nil.
+func NewObjects[T any](cfg ObjectsConfig[T]) *Objects[T] {
+	if cfg.New == nil {
+		panic("pool: ObjectsConfig.New is nil")
+	}
+	if cfg.MaxIdle <= 0 {
+		cfg.MaxIdle = runtime.GOMAXPROCS(0)
+	}
+	o := &Objects[T]{cfg: cfg}
+	if cfg.MaxActive > 0 {
+		o.active = make(chan struct{}, cfg.MaxActive)
+	}
+	return o
+}
+
+// Get returns an idle value or creates one. If MaxActive values are already
+// checked out it waits for one to be returned, or returns ctx.Err() if ctx
+// is done first. After Close it returns ErrClosed.
+func (o *Objects[T]) Get(ctx context.Context) (T, error) {
+	var zero T
+	if o.active != nil {
+		select {
+		case o.active <- struct{}{}:
+		case <-ctx.Done():
+			return zero, ctx.Err()
+		}
+	}
+
+	o.mu.Lock()
+	if o.closed {
+		o.mu.Unlock()
+		o.release()
+		return zero, ErrClosed
+	}
+	if n := len(o.idle); n > 0 {
+		v := o.idle[n-1]
+		o.idle[n-1] = zero // do not keep a reference in the spare capacity
+		o.idle = o.idle[:n-1]
+		o.mu.Unlock()
+		return v, nil
+	}
+	o.mu.Unlock()
+
+	v, err := o.cfg.New(ctx)
+	if err != nil {
+		o.release()
+		return zero, err
+	}
+	return v, nil
+}
+
+// Put returns v to the pool. The caller must not use v afterwards.
+//
+// Every value must come from Get and be returned once, with Put or
+// Discard. With MaxActive set, returning more values than were checked
+// out panics rather than corrupt the count; without it the extra value is
+// simply kept or destroyed.
+func (o *Objects[T]) Put(v T) {
+	// Free v's slot with the lock held: a Get waiting for the slot cannot
+	// look at idle before v is there, so it reuses v instead of calling
+	// New. Taking the slot first also makes a Put too many panic before v
+	// is reset or kept.
+	o.mu.Lock()
+	if !o.tryRelease() {
+		o.mu.Unlock()
+		panic(returnedTwice)
+	}
+	if o.closed || len(o.idle) >= o.cfg.MaxIdle {
+		o.mu.Unlock()
+		o.destroy(v)
+		return
+	}
+	if o.cfg.Reset != nil {
+		o.cfg.Reset(v)
+	}
+	o.idle = append(o.idle, v)
+	o.mu.Unlock()
+}
+
+// Discard destroys v instead of returning it, for values that turned out to
+// be broken. It frees v's slot for the next Get. Like Put, it must be
+// called once per value from Get.
+func (o *Objects[T]) Discard(v T) {
+	o.release()
+	o.destroy(v)
+}
+
+// Close destroys every idle value. Values still checked out are destroyed
+// when they are returned. Close is idempotent.
+func (o *Objects[T]) Close() {
+	o.mu.Lock()
+	idle := o.idle
+	o.idle, o.closed = nil, true
+	o.mu.Unlock()
+	for _, v := range idle {
+		o.destroy(v)
+	}
+}
+
+func (o *Objects[T]) destroy(v T) {
+	if o.cfg.Destroy != nil {
+		o.cfg.Destroy(v)
+	}
+}
+
+const returnedTwice = "pool: Objects value returned more times than it was checked out"
+
+// release frees the slot of a checked-out value.
+func (o *Objects[T]) release() {
+	if !o.tryRelease() {
+		panic(returnedTwice)
+	}
+}
+
+// tryRelease frees the slot of a checked-out value and reports whether
+// there was one to free. Without MaxActive there is nothing to count.
+func (o *Objects[T]) tryRelease() bool {
+	if o.active == nil {
+		return true
+	}
+	select {
+	case <-o.active:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,166 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type conn struct {
+	id    int64
+	dirty bool
+}
+
+// connConfig counts created and destroyed conns.
+func connConfig(created, destroyed *atomic.Int64) ObjectsConfig[*conn] {
+	return ObjectsConfig[*conn]{
+		New: func(ctx context.Context) (*conn, error) {
+			return &conn{id: created.Add(1)}, nil
+		},
+		Reset:   func(c *conn) { c.dirty = false },
+		Destroy: func(*conn) { destroyed.Add(1) },
+	}
+}
+
+func TestObjectsReuseAndReset(t *testing.T) {
+	var created, destroyed atomic.Int64
+	o := NewObjects(connConfig(&created, &destroyed))
+	ctx := context.Background()
+
+	c, _ := o.Get(ctx)
+	c.dirty = true
+	o.Put(c)
+
+	again, err := o.Get(ctx)
+	if err != nil || again != c || again.dirty {
+		t.Fatalf("Get = %+v, %v; want the same, reset conn", again, err)
+	}
+	if created.Load() != 1 {
+		t.Errorf("created %d conns, want 1", created.Load())
+	}
+}
+
+func TestObjectsMaxIdle(t *testing.T) {
+	var created, destroyed atomic.Int64
+	cfg := connConfig(&created, &destroyed)
+	cfg.MaxIdle = 2
+	o := NewObjects(cfg)
+	ctx := context.Background()
+
+	var conns []*conn
+	for range 5 {
+		c, _ := o.Get(ctx)
+		conns = append(conns, c)
+	}
+	for _, c := range conns {
+		o.Put(c)
+	}
+	if destroyed.Load() != 3 {
+		t.Errorf("destroyed %d conns, want 3", destroyed.Load())
+	}
+
+	o.Close()
+	if destroyed.Load() != 5 {
+		t.Errorf("destroyed %d conns after Close, want 5", destroyed.Load())
+	}
+	if _, err := o.Get(ctx); err != ErrClosed {
+		t.Errorf("Get after Close = %v, want ErrClosed", err)
+	}
+}
+
+func TestObjectsMaxActiveBlocks(t *testing.T) {
+	var created, destroyed atomic.Int64
+	cfg := connConfig(&created, &destroyed)
+	cfg.MaxActive = 1
+	o := NewObjects(cfg)
+
+	c, _ := o.Get(context.Background())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := o.Get(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Get at the limit = %v, want deadline exceeded", err)
+	}
+
+	go func() {
+		time.Sleep(5 * time.Millisecond)
+		o.Discard(c)
+	}()
+	next, err := o.Get(context.Background())
+	if err != nil || next == c {
+		t.Fatalf("Get after Discard = %+v, %v; want a new conn", next, err)
+	}
+	if destroyed.Load() != 1 {
+		t.Errorf("destroyed %d conns, want 1", destroyed.Load())
+	}
+}
+
+func TestObjectsNewError(t *testing.T) {
+	errDial := errors.New("dial failed")
+	o := NewObjects(ObjectsConfig[int]{
+		New:       func(context.Context) (int, error) { return 0, errDial },
+		MaxActive: 1,
+	})
+	for range 2 { // the failed Get must give its slot back
+		if _, err := o.Get(context.Background()); err != errDial {
+			t.Fatalf("Get = %v, want %v", err, errDial)
+		}
+	}
+}
+
+func TestObjectsReturnedTwicePanics(t *testing.T) {
+	var created, destroyed atomic.Int64
+	cfg := connConfig(&created, &destroyed)
+	cfg.MaxActive = 2
+	o := NewObjects(cfg)
+	c, _ := o.Get(context.Background())
+	o.Put(c)
+
+	for name, give := range map[string]func(*conn){"Put": o.Put, "Discard": o.Discard} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s of a value returned already did not panic", name)
+				}
+			}()
+			give(c)
+		}()
+	}
+	if _, err := o.Get(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if destroyed.Load() != 0 {
+		t.Errorf("%d values destroyed by the bad returns, want none", destroyed.Load())
+	}
+}
+
+func TestObjectsPutHandsValueToWaitingGet(t *testing.T) {
+	var created, destroyed atomic.Int64
+	cfg := connConfig(&created, &destroyed)
+	cfg.MaxActive = 2
+	cfg.Reset = func(*conn) { time.Sleep(5 * time.Millisecond) } // give the Get time to race
+	o := NewObjects(cfg)
+	ctx := context.Background()
+
+	a, _ := o.Get(ctx)
+	b, _ := o.Get(ctx)
+	got := make(chan *conn)
+	go func() {
+		c, err := o.Get(ctx)
+		if err != nil {
+			t.Error(err)
+		}
+		got <- c
+	}()
+	time.Sleep(5 * time.Millisecond) // let the Get block at MaxActive
+	o.Put(a)
+	if c := <-got; c != a {
+		t.Errorf("waiting Get = conn %d, want the returned conn %d", c.id, a.id)
+	}
+	o.Put(b)
+	if created.Load() != 2 || destroyed.Load() != 0 {
+		t.Errorf("created %d and destroyed %d conns, want 2 and 0", created.Load(), destroyed.Load())
+	}
+}
@@ -0,0 +1,101 @@
+package pool
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/lotusirous/gochan/clock"
+)
+
+// WithClock makes the pool tell time with clk instead of clock.Real: for
+// the deadlines of EDFPool, the aging of PriorityPool, the run times passed
+// to Metrics and WithLogger, and the schedules of Cron and Scheduler.
+func WithClock(clk clock.Clock) Option {
+	return func(c *config) { c.clock = clk }
+}
+
+// WithLogger makes the pool log every failed job to l, at level Warn, with
+// the job, its error and how long it ran. By default nothing is logged.
+func WithLogger(l *slog.Logger) Option {
+	return func(c *config) { c.logger = l }
+}
+
+// WithMetrics makes the pool report every job it runs to m.
+func WithMetrics(m Metrics) Option {
+	return func(c *config) { c.metrics = m }
+}
+
+// Metrics receives measurements of the jobs a pool runs, for instance to
+// export them to a monitoring system. Its methods are called by the workers,
+// several at a time.
+type Metrics interface {
+	// JobStarted is called when a worker starts a job.
+	JobStarted()
+	// JobDone is called when the job ends, with how long it ran and the
+	// error it failed with, if any.
+	JobDone(took time.Duration, err error)
+}
+
+// Counters is a Metrics that keeps running totals. The zero value is ready
+// to use, and can be shared by several pools.
+type Counters struct {
+	Running   atomic.Int64
+	Succeeded atomic.Int64
+	Failed    atomic.Int64
+	busy      atomic.Int64 // nanoseconds
+}
+
+var _ Metrics = (*Counters)(nil)
+
+// JobStarted implements Metrics.
+func (m *Counters) JobStarted() { m.Running.Add(1) }
+
+// JobDone implements Metrics.
+func (m *Counters) JobDone(took time.Duration, err error) {
+	m.busy.Add(int64(took))
+	if err != nil {
+		m.Failed.Add(1)
+	} else {
+		m.Succeeded.Add(1)
+	}
+	m.Running.Add(-1)
+}
+
+// Busy returns the total time jobs have run, which divided by the elapsed
+// time and the number of workers is how busy the workers were.
+func (m *Counters) Busy() time.Duration { return time.Duration(m.busy.Load()) }
+
+// start registers a job that is about to run with the pool's Tracker and
+// Metrics, and returns the context to run it with and a function to call
+// with its error when it ends.
+func (c *config) start(ctx context.Context, job any) (context.Context, func(error)) {
+	ctx, end := c.progress.start(ctx, job)
+	done := c.observe(job)
+	return ctx, func(err error) {
+		end()
+		done(err)
+	}
+}
+
+// observe reports a job starting to the pool's Metrics and returns the
+// function that reports it ending, to Metrics and the logger.
+func (c *config) observe(job any) func(error) {
+	if c.metrics == nil && c.logger == nil {
+		return func(error) {}
+	}
+	began := c.clock.Now()
+	if c.metrics != nil {
+		c.metrics.JobStarted()
+	}
+	return func(err error) {
+		took := c.clock.Now().Sub(began)
+		if c.metrics != nil {
+			c.metrics.JobDone(took, err)
+		}
+		if err != nil && c.logger != nil {
+			c.logger.Warn("pool: job failed", "job", job, "err", err, "took", took)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package pool
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lotusirous/gochan/clock"
+)
+
+func TestMetricsAndLogger(t *testing.T) {
+	clk := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	var m Counters
+	var log bytes.Buffer
+	p := New(func(ctx context.Context, n int) (int, error) {
+		clk.Advance(time.Duration(n) * time.Second)
+		if n%2 == 1 {
+			return 0, errors.New("odd")
+		}
+		return n, nil
+	}, WithWorkers(1), WithQueue(10), WithClock(clk), WithMetrics(&m), WithLogger(slog.New(slog.NewTextHandler(&log, nil))))
+	for n := range 5 {
+		p.Submit(context.Background(), n)
+	}
+	if err := p.Wait(context.Background()); err == nil {
+		t.Fatal("Wait = nil, want the odd jobs' errors")
+	}
+
+	if s, f, r := m.Succeeded.Load(), m.Failed.Load(), m.Running.Load(); s != 3 || f != 2 || r != 0 {
+		t.Errorf("succeeded, failed, running = %d, %d, %d, want 3, 2, 0", s, f, r)
+	}
+	if got := m.Busy(); got != 10*time.Second {
+		t.Errorf("Busy = %v, want 10s of fake time", got)
+	}
+	lines := strings.Split(strings.TrimSpace(log.String()), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[0], "job=1") || !strings.Contains(lines[1], "took=3s") {
+		t.Errorf("log:\n%s\nwant the failures of jobs 1 and 3", log.String())
+	}
+}
+
+func TestEDFWithClock(t *testing.T) {
+	// An hour from now has long passed on a clock set in the next century.
+	clk := clock.NewFake(time.Date(2100, 1, 1, 0, 0, 0, 0, time.UTC))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+	p := NewEDF(square, WithWorkers(1), WithClock(clk))
+	defer p.Close()
+	if err := p.Submit(ctx, 1); !errors.Is(err, ErrDeadlineMissed) {
+		t.Errorf("Submit = %v, want ErrDeadlineMissed by the fake clock", err)
+	}
+}
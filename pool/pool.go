@@ -0,0 +1,299 @@
+// Package pool runs jobs on a bounded set of workers.
+//
+// It packages the jobs/results idiom from the 18-worker-pool example behind a
+// small Pool interface so different worker implementations can be swapped
+// and benchmarked against each other:
+//
+//   - GoroutinePool runs jobs on goroutines inside the current process.
+//   - ProcessPool runs jobs in child OS processes for isolation.
+//   - EDFPool runs jobs on goroutines, earliest context deadline first.
+//   - PriorityPool runs jobs on goroutines, highest priority first, and
+//     lets urgent jobs preempt long ones that call Checkpoint.
+//   - Keyed runs jobs with the same key on the same goroutine, in order,
+//     and can be resized while moving few keys.
+package pool
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/lotusirous/gochan/clock"
+	"github.com/lotusirous/gochan/result"
+	"github.com/lotusirous/gochan/safego"
+)
+
+// ErrClosed is returned by Submit after the pool has been closed.
+var ErrClosed = errors.New("pool: pool is closed")
+
+// Func processes a single job. A panic in Func fails only that job: its
+// Result carries a *safego.PanicError.
+type Func[In, Out any] func(ctx context.Context, in In) (Out, error)
+
+// Result is the outcome of a submitted job. The embedded result.Result
+// provides Value, Err, Unwrap and Must.
+type Result[In, Out any] struct {
+	Job In
+	result.Result[Out]
+}
+
+// Pool is the common interface of every worker pool implementation.
+type Pool[In, Out any] interface {
+	// Submit queues a job. It blocks while the queue is full and returns
+	// ctx.Err() if ctx is done first. The job itself runs with ctx.
+	Submit(ctx context.Context, job In) error
+	// Results streams the outcome of every submitted job. It is closed once
+	// the pool is closed and all queued jobs have finished.
+	Results() <-chan Result[In, Out]
+	// Close stops accepting new jobs. Jobs already queued still run.
+	Close()
+	// Wait closes the pool and waits for every queued job. Results not
+	// received yet are discarded, so Wait can replace reading Results, or
+	// follow it. It returns the errors of all failed jobs joined with
+	// errors.Join, each prefixed with its job, or ctx.Err() if ctx is done
+	// first. Do not call Wait while another goroutine is receiving Results.
+	Wait(ctx context.Context) error
+}
+
+type config struct {
+	workers    int
+	queue      int
+	localQueue int
+	autoSize   *Workload
+	errorLimit int
+	progress   *Tracker
+	aging      time.Duration
+	clock      clock.Clock
+	logger     *slog.Logger
+	metrics    Metrics
+}
+
+// Option configures a pool.
+type Option func(*config)
+
+// WithWorkers sets the number of workers. The default is GOMAXPROCS.
+func WithWorkers(n int) Option {
+	return func(c *config) { c.workers = n }
+}
+
+// WithQueue sets how many jobs may wait for a free worker before Submit
+// blocks. The default is the number of workers.
+func WithQueue(n int) Option {
+	return func(c *config) { c.queue = n }
+}
+
+// WithLocalQueues gives every worker of a GoroutinePool its own queue of
+// size n. Submit spreads jobs round-robin over the local queues and only
+// falls back to the shared queue when the chosen local queue is full, so
+// submitters and workers rarely contend on the same channel.
+//
+// The trade-off is that a job parked in the local queue of a worker busy with
+// a slow job waits for that worker even if others are idle. ProcessPool
+// ignores this option.
+func WithLocalQueues(n int) Option {
+	return func(c *config) { c.localQueue = n }
+}
+
+func newConfig(opts []Option) config {
+	c := config{queue: -1}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	if c.autoSize != nil {
+		c.workers = AutoSize(*c.autoSize)
+	}
+	if c.workers <= 0 {
+		c.workers = runtime.GOMAXPROCS(0)
+	}
+	if c.queue < 0 {
+		c.queue = c.workers
+	}
+	if c.clock == nil {
+		c.clock = clock.Real
+	}
+	return c
+}
+
+// task is a queued job together with the context it was submitted with.
+type task[In any] struct {
+	ctx context.Context
+	job In
+}
+
+// queue is the submission side shared by every pool implementation.
+type queue[In any] struct {
+	tasks chan task[In] // shared by all workers
+
+	locals []chan task[In] // optional, one per worker
+	next   atomic.Uint64   // round-robin cursor over locals
+
+	mu        sync.RWMutex
+	closed    bool
+	quit      chan struct{}
+	closeOnce sync.Once
+}
+
+func newQueue[In any](size int) *queue[In] {
+	return &queue[In]{
+		tasks: make(chan task[In], size),
+		quit:  make(chan struct{}),
+	}
+}
+
+// withLocals adds a local queue of the given size for each of n workers.
+func (q *queue[In]) withLocals(n, size int) *queue[In] {
+	if size <= 0 {
+		return q
+	}
+	q.locals = make([]chan task[In], n)
+	for i := range q.locals {
+		q.locals[i] = make(chan task[In], size)
+	}
+	return q
+}
+
+func (q *queue[In]) submit(ctx context.Context, job In) error {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	if q.closed {
+		return ErrClosed
+	}
+	t := task[In]{ctx, job}
+	if len(q.locals) > 0 {
+		i := q.next.Add(1) % uint64(len(q.locals))
+		select {
+		case q.locals[i] <- t:
+			return nil
+		default: // local queue full, overflow to the shared one
+		}
+	}
+	select {
+	case q.tasks <- t:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-q.quit:
+		return ErrClosed
+	}
+}
+
+func (q *queue[In]) close() {
+	q.closeOnce.Do(func() {
+		// Wake up blocked submitters before taking the write lock, otherwise
+		// Close would wait for a worker to free a queue slot.
+		close(q.quit)
+		q.mu.Lock()
+		q.closed = true
+		close(q.tasks)
+		for _, local := range q.locals {
+			close(local)
+		}
+		q.mu.Unlock()
+	})
+}
+
+// take returns the next task for worker i, preferring its local queue. It
+// returns false once the queue is closed and drained.
+func (q *queue[In]) take(i int) (task[In], bool) {
+	var local chan task[In]
+	if i < len(q.locals) {
+		local = q.locals[i]
+	}
+	shared := q.tasks
+	for local != nil || shared != nil {
+		if local != nil {
+			select {
+			case t, ok := <-local:
+				if ok {
+					return t, true
+				}
+				local = nil
+				continue
+			default:
+			}
+		}
+		// A nil channel blocks forever, which disables its case.
+		select {
+		case t, ok := <-local:
+			if ok {
+				return t, true
+			}
+			local = nil
+		case t, ok := <-shared:
+			if ok {
+				return t, true
+			}
+			shared = nil
+		}
+	}
+	return task[In]{}, false
+}
+
+// GoroutinePool runs jobs on a fixed number of goroutines.
+type GoroutinePool[In, Out any] struct {
+	fn       Func[In, Out]
+	q        *queue[In]
+	results  chan Result[In, Out]
+	failures failures
+}
+
+var _ Pool[int, int] = (*GoroutinePool[int, int])(nil)
+
+// New starts a goroutine pool that processes jobs with fn.
+func New[In, Out any](fn Func[In, Out], opts ...Option) *GoroutinePool[In, Out] {
+	c := newConfig(opts)
+	p := &GoroutinePool[In, Out]{
+		fn:      fn,
+		q:       newQueue[In](c.queue).withLocals(c.workers, c.localQueue),
+		results: make(chan Result[In, Out], c.queue),
+	}
+	p.failures.limit = c.errorLimit
+
+	var wg sync.WaitGroup
+	for i := range c.workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				t, ok := p.q.take(i)
+				if !ok {
+					return
+				}
+				ctx, end := c.start(t.ctx, t.job)
+				var v Out
+				err := safego.Do(func() (err error) {
+					v, err = fn(ctx, t.job)
+					return err
+				})
+				end(err)
+				p.failures.record(t.job, err)
+				p.results <- Result[In, Out]{Job: t.job, Result: result.Of(v, err)}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(p.results)
+	}()
+	return p
+}
+
+// Submit implements Pool.
+func (p *GoroutinePool[In, Out]) Submit(ctx context.Context, job In) error {
+	return p.q.submit(ctx, job)
+}
+
+// Results implements Pool.
+func (p *GoroutinePool[In, Out]) Results() <-chan Result[In, Out] { return p.results }
+
+// Close implements Pool.
+func (p *GoroutinePool[In, Out]) Close() { p.q.close() }
+
+// Wait implements Pool.
+func (p *GoroutinePool[In, Out]) Wait(ctx context.Context) error {
+	return wait(ctx, p, &p.failures)
+}
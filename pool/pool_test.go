@@ -0,0 +1,420 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lotusirous/gochan/safego"
+)
+
+// childFuncEnv selects which job function a child test process serves.
+const childFuncEnv = "POOL_TEST_FUNC"
+
+// TestMain doubles as the child process of the ProcessPool tests: the pool
+// re-executes the test binary with ChildEnv set.
+func TestMain(m *testing.M) {
+	if IsChild() {
+		fn := square
+		if os.Getenv(childFuncEnv) == "spin" {
+			fn = spin
+		}
+		if err := Serve(context.Background(), os.Stdin, os.Stdout, fn); err != nil {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+const (
+	crash   = -1
+	panicky = -2
+)
+
+// square fails for negative input, panics on panicky and kills its own
+// process on crash.
+func square(ctx context.Context, n int) (int, error) {
+	switch {
+	case n == crash && IsChild():
+		os.Exit(3)
+	case n == panicky:
+		panic("bad input")
+	case n < 0:
+		return 0, fmt.Errorf("negative input %d", n)
+	}
+	return n * n, nil
+}
+
+func selfCommand() *exec.Cmd { return exec.Command(os.Args[0]) }
+
+func spinCommand() *exec.Cmd {
+	cmd := exec.Command(os.Args[0])
+	cmd.Env = append(os.Environ(), childFuncEnv+"=spin")
+	return cmd
+}
+
+func newPools(t *testing.T) map[string]Pool[int, int] {
+	t.Helper()
+	pp, err := NewProcessPool[int, int](selfCommand, WithWorkers(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return map[string]Pool[int, int]{
+		"goroutines":   New(square, WithWorkers(3)),
+		"local-queues": New(square, WithWorkers(3), WithLocalQueues(2)),
+		"edf":          NewEDF(square, WithWorkers(3)),
+		"priority":     NewPriority(square, WithWorkers(3)),
+		"processes":    pp,
+	}
+}
+
+func TestPoolProcessesEveryJob(t *testing.T) {
+	for name, p := range newPools(t) {
+		t.Run(name, func(t *testing.T) {
+			const n = 50
+			go func() {
+				defer p.Close()
+				for i := range n {
+					if err := p.Submit(context.Background(), i); err != nil {
+						t.Error(err)
+					}
+				}
+			}()
+
+			var got []int
+			for r := range p.Results() {
+				if r.Err != nil {
+					t.Errorf("job %d: %v", r.Job, r.Err)
+				}
+				if r.Value != r.Job*r.Job {
+					t.Errorf("job %d = %d", r.Job, r.Value)
+				}
+				got = append(got, r.Job)
+			}
+			sort.Ints(got)
+			if len(got) != n || got[0] != 0 || got[n-1] != n-1 {
+				t.Errorf("got jobs %v", got)
+			}
+		})
+	}
+}
+
+func TestPoolReportsJobErrors(t *testing.T) {
+	for name, p := range newPools(t) {
+		t.Run(name, func(t *testing.T) {
+			if err := p.Submit(context.Background(), -5); err != nil {
+				t.Fatal(err)
+			}
+			p.Close()
+			r := <-p.Results()
+			if r.Err == nil || r.Err.Error() != "negative input -5" {
+				t.Errorf("got error %v", r.Err)
+			}
+		})
+	}
+}
+
+func TestPoolContainsPanics(t *testing.T) {
+	for name, p := range newPools(t) {
+		t.Run(name, func(t *testing.T) {
+			for _, job := range []int{panicky, 3} {
+				if err := p.Submit(context.Background(), job); err != nil {
+					t.Fatal(err)
+				}
+			}
+			p.Close()
+			for r := range p.Results() {
+				switch r.Job {
+				case panicky:
+					if r.Err == nil || r.Err.Error() != "panic: bad input" {
+						t.Errorf("panicking job: got error %v", r.Err)
+					}
+					var pe *safego.PanicError
+					if _, isProcess := p.(*ProcessPool[int, int]); !isProcess && !errors.As(r.Err, &pe) {
+						t.Errorf("error %T is not a *safego.PanicError", r.Err)
+					}
+				default:
+					if r.Err != nil || r.Value != 9 {
+						t.Errorf("job after the panic = %+v", r)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestWaitJoinsJobErrors(t *testing.T) {
+	for name, p := range newPools(t) {
+		t.Run(name, func(t *testing.T) {
+			for _, job := range []int{1, -3, 4, -5} {
+				if err := p.Submit(context.Background(), job); err != nil {
+					t.Fatal(err)
+				}
+			}
+			// Nobody reads Results: Wait drains them itself.
+			err := p.Wait(context.Background())
+			if err == nil {
+				t.Fatal("Wait returned nil, want the failed jobs")
+			}
+			for _, want := range []string{"job -3: negative input -3", "job -5: negative input -5"} {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("Wait error lacks %q:\n%v", want, err)
+				}
+			}
+			if strings.Contains(err.Error(), "job 1") {
+				t.Errorf("Wait reports a successful job:\n%v", err)
+			}
+		})
+	}
+}
+
+func TestWaitAfterReadingResults(t *testing.T) {
+	p := New(square, WithWorkers(2))
+	for _, job := range []int{-3, 2} {
+		p.Submit(context.Background(), job)
+	}
+	p.Close()
+	var failed int
+	for r := range p.Results() {
+		if r.Err != nil {
+			failed++
+		}
+	}
+	if err := p.Wait(context.Background()); failed != 1 || err == nil {
+		t.Errorf("%d failed results, Wait = %v; want 1 and the same failure", failed, err)
+	}
+	if err := New(square).Wait(context.Background()); err != nil {
+		t.Errorf("Wait on an idle pool = %v, want nil", err)
+	}
+}
+
+func TestWaitErrorLimit(t *testing.T) {
+	p := New(square, WithWorkers(2), WithErrorLimit(2))
+	go func() {
+		for job := range 10 {
+			p.Submit(context.Background(), -job-3)
+		}
+		p.Close()
+	}()
+	for range p.Results() {
+	}
+	err := p.Wait(context.Background())
+	if err == nil || strings.Count(err.Error(), "negative input") != 2 || !strings.HasSuffix(err.Error(), "and 8 more errors") {
+		t.Errorf("Wait = %v, want 2 errors and a summary of 8 more", err)
+	}
+}
+
+func TestWaitHonorsContext(t *testing.T) {
+	stuck := make(chan struct{})
+	defer close(stuck)
+	p := New(func(ctx context.Context, n int) (int, error) { <-stuck; return n, nil }, WithWorkers(1))
+	p.Submit(context.Background(), 0)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := p.Wait(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Wait = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestSubmitAfterClose(t *testing.T) {
+	for name, p := range newPools(t) {
+		t.Run(name, func(t *testing.T) {
+			p.Close()
+			if err := p.Submit(context.Background(), 1); !errors.Is(err, ErrClosed) {
+				t.Errorf("Submit after Close returned %v", err)
+			}
+			for range p.Results() {
+			}
+		})
+	}
+}
+
+func TestSubmitHonorsContext(t *testing.T) {
+	block := make(chan struct{})
+	p := New(func(ctx context.Context, n int) (int, error) {
+		<-block
+		return n, nil
+	}, WithWorkers(1), WithQueue(0))
+	defer func() {
+		close(block)
+		p.Close()
+		for range p.Results() {
+		}
+	}()
+
+	// The only worker is busy with the first job, so the second one blocks.
+	go p.Submit(context.Background(), 1)
+	time.Sleep(10 * time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := p.Submit(ctx, 2); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Submit returned %v, want deadline exceeded", err)
+	}
+}
+
+func TestProcessPoolReplacesCrashedChild(t *testing.T) {
+	p, err := NewProcessPool[int, int](selfCommand, WithWorkers(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, job := range []int{crash, 4} {
+		if err := p.Submit(context.Background(), job); err != nil {
+			t.Fatal(err)
+		}
+	}
+	p.Close()
+
+	first, second := <-p.Results(), <-p.Results()
+	if first.Err == nil {
+		t.Errorf("expected crashed job to fail")
+	}
+	if second.Err != nil || second.Value != 16 {
+		t.Errorf("job after crash = %+v", second)
+	}
+}
+
+func TestProcessPoolPipeError(t *testing.T) {
+	_, err := NewProcessPool[int, int](func() *exec.Cmd {
+		cmd := selfCommand()
+		cmd.Stdout = os.Stdout // StdoutPipe refuses, after StdinPipe succeeded
+		return cmd
+	}, WithWorkers(1))
+	if err == nil {
+		t.Fatal("NewProcessPool with Stdout set did not fail")
+	}
+}
+
+func TestBatch(t *testing.T) {
+	p := New(Batch(square), WithWorkers(2))
+	go func() {
+		defer p.Close()
+		p.Submit(context.Background(), []int{1, 2, 3})
+		p.Submit(context.Background(), []int{4, -1, 6})
+	}()
+
+	for r := range p.Results() {
+		switch r.Job[0] {
+		case 1:
+			if r.Err != nil || len(r.Value) != 3 || r.Value[2] != 9 {
+				t.Errorf("batch %v = %v, %v", r.Job, r.Value, r.Err)
+			}
+		case 4:
+			if r.Err == nil || len(r.Value) != 1 || r.Value[0] != 16 {
+				t.Errorf("batch %v = %v, %v; want partial output and error", r.Job, r.Value, r.Err)
+			}
+		}
+	}
+}
+
+func TestAutoSize(t *testing.T) {
+	procs := runtime.GOMAXPROCS(0)
+	if got := AutoSize(CPUBound); got != procs {
+		t.Errorf("AutoSize(CPUBound) = %d, want %d", got, procs)
+	}
+	if AutoSize(IOBound) <= AutoSize(Mixed) || AutoSize(Mixed) <= AutoSize(CPUBound) {
+		t.Errorf("expected IOBound > Mixed > CPUBound")
+	}
+	c := newConfig([]Option{WithWorkers(1), WithAutoSize(IOBound)})
+	if c.workers != AutoSize(IOBound) {
+		t.Errorf("WithAutoSize did not override WithWorkers: %d workers", c.workers)
+	}
+}
+
+func TestCollect(t *testing.T) {
+	jobs := make(chan int)
+	go func() {
+		defer close(jobs)
+		for i := range 100 {
+			jobs <- i
+		}
+	}()
+
+	results := Collect(context.Background(), jobs, square, WithWorkers(4))
+	if len(results) != 100 {
+		t.Fatalf("got %d results, want 100", len(results))
+	}
+	seen := make(map[int]bool)
+	for _, r := range results {
+		if r.Err != nil || r.Value != r.Job*r.Job {
+			t.Errorf("result %+v", r)
+		}
+		seen[r.Job] = true
+	}
+	if len(seen) != 100 {
+		t.Errorf("got %d distinct jobs, want 100", len(seen))
+	}
+}
+
+func TestCollectContainsPanics(t *testing.T) {
+	jobs := make(chan int, 2)
+	jobs <- panicky
+	jobs <- 3
+	close(jobs)
+
+	results := Collect(context.Background(), jobs, square, WithWorkers(1))
+	if len(results) != 2 {
+		t.Fatalf("got %d results, want 2", len(results))
+	}
+	for _, r := range results {
+		var pe *safego.PanicError
+		switch {
+		case r.Job == panicky && !errors.As(r.Err, &pe):
+			t.Errorf("panicking job: got error %v, want a *safego.PanicError", r.Err)
+		case r.Job != panicky && (r.Err != nil || r.Value != 9):
+			t.Errorf("job after the panic = %+v", r)
+		}
+	}
+}
+
+func TestCollectStopsRunningJobsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	jobs := make(chan int)
+	go func() {
+		defer close(jobs)
+		for i := range 100 {
+			if i == 10 {
+				cancel()
+			}
+			jobs <- i
+		}
+	}()
+
+	results := Collect(ctx, jobs, square, WithWorkers(1))
+	if len(results) > 11 {
+		t.Errorf("got %d results after cancel, want at most 11", len(results))
+	}
+}
+
+// submitAtClose queues a job behind job 0 of p, which has one worker, a
+// queue of one and runs gated jobs, then closes p while a third Submit
+// waits for room, and returns that Submit's error.
+func submitAtClose(t *testing.T, p Pool[int, int], gate, started chan struct{}) error {
+	t.Helper()
+	p.Submit(context.Background(), 0)
+	<-started
+	p.Submit(context.Background(), 1)
+	errc := make(chan error, 1)
+	go func() { errc <- p.Submit(context.Background(), 2) }()
+	time.Sleep(5 * time.Millisecond) // let it block on the full queue
+	p.Close()
+	defer func() {
+		close(gate)
+		for range p.Results() {
+		}
+	}()
+	select {
+	case err := <-errc:
+		return err
+	case <-time.After(time.Second):
+		t.Fatal("Submit still blocked a second after Close")
+		return nil
+	}
+}
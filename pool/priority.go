@@ -0,0 +1,315 @@
+package pool
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/lotusirous/gochan/clock"
+	"github.com/lotusirous/gochan/result"
+	"github.com/lotusirous/gochan/safego"
+)
+
+// ErrPreempted is returned by Checkpoint when a running job has been asked
+// to make way for a more urgent one. A job that returns it, or an error
+// wrapping it, is put back in the queue instead of producing a Result and
+// runs again when its turn comes. Jobs that should resume rather than start
+// over keep their progress in the job value.
+var ErrPreempted = errors.New("pool: job preempted")
+
+type priorityKey struct{}
+
+type preemptKey struct{}
+
+// WithPriority returns a context that submits a job to a PriorityPool with
+// the given priority. Higher runs first; the default is 0.
+func WithPriority(ctx context.Context, priority int) context.Context {
+	return context.WithValue(ctx, priorityKey{}, priority)
+}
+
+func priorityOf(ctx context.Context) int {
+	p, _ := ctx.Value(priorityKey{}).(int)
+	return p
+}
+
+// Preempted returns a channel that is closed when the job running with ctx
+// should yield its worker. Outside a PriorityPool it returns nil, which
+// blocks forever in a select.
+func Preempted(ctx context.Context) <-chan struct{} {
+	ch, _ := ctx.Value(preemptKey{}).(chan struct{})
+	return ch
+}
+
+// Checkpoint is called by long jobs between units of work. It returns
+// ctx's error if ctx is done, ErrPreempted if the job should yield, and nil
+// if it should carry on.
+func Checkpoint(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	select {
+	case <-Preempted(ctx):
+		return ErrPreempted
+	default:
+		return nil
+	}
+}
+
+// WithAging makes a PriorityPool raise the priority of a queued job by one
+// for every d it has waited, so a steady stream of urgent jobs cannot starve
+// the others: a job overtakes every job ranked k above it that was
+// submitted more than k*d later. Other pools ignore this option.
+func WithAging(d time.Duration) Option {
+	return func(c *config) { c.aging = d }
+}
+
+// PriorityPool is a goroutine pool that runs the queued job with the
+// highest priority first, set with WithPriority on the context it is
+// submitted with. Jobs with equal priorities run in submission order.
+// WithAging lets the priority of waiting jobs grow over time.
+//
+// Preemption is cooperative. When a job arrives that outranks a running
+// job and no worker is free, the lowest ranked running job is signaled
+// through Preempted. If it notices, usually by calling Checkpoint, and
+// returns ErrPreempted, it goes back in the queue and its worker picks up
+// the urgent job. A job that never checks simply runs to the end. With
+// WithAging, the raised priority of a queued job counts too, as of the
+// last Submit.
+//
+// A job put back after preemption does not count against the WithQueue
+// bound: it already held a worker, so the queue can hold up to one such
+// job per worker on top of the bound.
+type PriorityPool[In, Out any] struct {
+	clock    clock.Clock
+	slots    chan struct{} // one per submitted job queued, bounds the queue
+	quit     chan struct{} // closed by Close to wake blocked submitters
+	results  chan Result[In, Out]
+	failures failures
+
+	mu       sync.Mutex
+	ready    *sync.Cond // signaled when a job is queued or the pool closes
+	queue    priorityQueue[In]
+	seq      uint64
+	running  map[*priorityRun]struct{}
+	idle     int // workers waiting for a job
+	yielding int // running jobs signaled but not yet returned
+	closed   bool
+}
+
+var _ Pool[int, int] = (*PriorityPool[int, int])(nil)
+
+type priorityTask[In any] struct {
+	task[In]
+	priority int
+	queued   time.Time
+	seq      uint64
+	slot     bool // holds a slot; jobs put back after preemption do not
+}
+
+type priorityRun struct {
+	priority int // of the job when it was taken, aging included
+	started  time.Time
+	preempt  chan struct{}
+	signaled bool
+}
+
+// NewPriority starts a priority pool that processes jobs with fn.
+// WithLocalQueues is ignored.
+func NewPriority[In, Out any](fn Func[In, Out], opts ...Option) *PriorityPool[In, Out] {
+	c := newConfig(opts)
+	p := &PriorityPool[In, Out]{
+		clock:   c.clock,
+		slots:   make(chan struct{}, max(c.queue, 1)),
+		quit:    make(chan struct{}),
+		results: make(chan Result[In, Out], c.queue),
+		running: make(map[*priorityRun]struct{}),
+		queue:   priorityQueue[In]{aging: c.aging},
+	}
+	p.ready = sync.NewCond(&p.mu)
+	p.failures.limit = c.errorLimit
+
+	var wg sync.WaitGroup
+	for range c.workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				t, run, ok := p.take()
+				if !ok {
+					return
+				}
+				ctx, end := c.start(context.WithValue(t.ctx, preemptKey{}, run.preempt), t.job)
+				var v Out
+				err := safego.Do(func() (err error) {
+					v, err = fn(ctx, t.job)
+					return err
+				})
+				end(err)
+				if p.done(t, run, err) {
+					continue
+				}
+				p.failures.record(t.job, err)
+				p.results <- Result[In, Out]{Job: t.job, Result: result.Of(v, err)}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(p.results)
+	}()
+	return p
+}
+
+// Submit implements Pool.
+func (p *PriorityPool[In, Out]) Submit(ctx context.Context, job In) error {
+	select {
+	case p.slots <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-p.quit:
+		return ErrClosed
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		<-p.slots
+		return ErrClosed
+	}
+	p.seq++
+	t := priorityTask[In]{task[In]{ctx, job}, priorityOf(ctx), p.clock.Now(), p.seq, true}
+	heap.Push(&p.queue, t)
+	p.ready.Signal()
+	p.preempt()
+	return nil
+}
+
+// preempt signals the lowest ranked running job below the most urgent
+// queued one, unless the queued jobs will find a worker anyway. The caller
+// holds p.mu.
+func (p *PriorityPool[In, Out]) preempt() {
+	if p.queue.Len() <= p.idle+p.yielding {
+		return
+	}
+	priority := p.queue.rank(p.queue.tasks[0], p.clock.Now())
+	var victim *priorityRun
+	for r := range p.running {
+		if !r.signaled && r.priority < priority && (victim == nil || r.priority < victim.priority) {
+			victim = r
+		}
+	}
+	if victim != nil {
+		victim.signaled = true
+		p.yielding++
+		close(victim.preempt)
+	}
+}
+
+// take returns the most urgent job, waiting for one if the queue is empty.
+// It returns false once the pool is closed and drained.
+func (p *PriorityPool[In, Out]) take() (priorityTask[In], *priorityRun, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.idle++
+	defer func() { p.idle-- }()
+	for p.queue.Len() == 0 {
+		if p.closed {
+			return priorityTask[In]{}, nil, false
+		}
+		p.ready.Wait()
+	}
+	t := heap.Pop(&p.queue).(priorityTask[In])
+	if t.slot {
+		<-p.slots
+		t.slot = false
+	}
+	now := p.clock.Now()
+	run := &priorityRun{priority: p.queue.rank(t, now), started: now, preempt: make(chan struct{})}
+	p.running[run] = struct{}{}
+	return t, run, true
+}
+
+// done retires a run and reports whether the job yielded and was put back
+// in the queue. Only a job this pool signaled yields: ErrPreempted from
+// anywhere else, such as the Checkpoint of another pool, is a failure like
+// any other, or the job would be put back forever.
+func (p *PriorityPool[In, Out]) done(t priorityTask[In], run *priorityRun, err error) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.running, run)
+	if run.signaled {
+		p.yielding--
+	}
+	if !run.signaled || !errors.Is(err, ErrPreempted) {
+		return false
+	}
+	// It keeps its sequence number, so it goes ahead of the jobs of equal
+	// priority submitted after it, and the aging it earned while queued:
+	// only the time spent running is taken off.
+	t.queued = t.queued.Add(p.clock.Now().Sub(run.started))
+	heap.Push(&p.queue, t)
+	p.ready.Signal()
+	return true
+}
+
+// Results implements Pool.
+func (p *PriorityPool[In, Out]) Results() <-chan Result[In, Out] { return p.results }
+
+// Close implements Pool.
+func (p *PriorityPool[In, Out]) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.closed {
+		close(p.quit)
+	}
+	p.closed = true
+	p.ready.Broadcast()
+}
+
+// Wait implements Pool.
+func (p *PriorityPool[In, Out]) Wait(ctx context.Context) error {
+	return wait(ctx, p, &p.failures)
+}
+
+// priorityQueue is a heap ordered by priority, highest first, then
+// submission order. With aging, priorities grow at the same rate for every
+// queued job, so comparing them at any instant amounts to comparing
+// priority*aging minus the time queued, which does not change while the
+// jobs wait and keeps the heap valid.
+type priorityQueue[In any] struct {
+	tasks []priorityTask[In]
+	aging time.Duration
+}
+
+// rank returns t's priority raised by the aging it has earned at now.
+func (q priorityQueue[In]) rank(t priorityTask[In], now time.Time) int {
+	if q.aging <= 0 {
+		return t.priority
+	}
+	return t.priority + int(now.Sub(t.queued)/q.aging)
+}
+
+func (q priorityQueue[In]) Len() int { return len(q.tasks) }
+func (q priorityQueue[In]) Less(i, j int) bool {
+	a, b := q.tasks[i], q.tasks[j]
+	if q.aging > 0 {
+		// a.priority + (now-a.queued)/aging > b.priority + (now-b.queued)/aging
+		lead := time.Duration(a.priority-b.priority) * q.aging
+		if waited := b.queued.Sub(a.queued); lead+waited != 0 {
+			return lead+waited > 0
+		}
+	} else if a.priority != b.priority {
+		return a.priority > b.priority
+	}
+	return a.seq < b.seq
+}
+func (q priorityQueue[In]) Swap(i, j int) { q.tasks[i], q.tasks[j] = q.tasks[j], q.tasks[i] }
+func (q *priorityQueue[In]) Push(x any)   { q.tasks = append(q.tasks, x.(priorityTask[In])) }
+func (q *priorityQueue[In]) Pop() any {
+	old := q.tasks
+	t := old[len(old)-1]
+	q.tasks = old[:len(old)-1]
+	return t
+}
@@ -0,0 +1,205 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/lotusirous/gochan/clock"
+)
+
+func TestPriorityRunsHighestFirst(t *testing.T) {
+	gate, started := make(chan struct{}), make(chan struct{})
+	var ran []int
+	p := NewPriority(gated(gate, started, &ran), WithWorkers(1), WithQueue(10))
+	p.Submit(context.Background(), 0)
+	<-started
+
+	for _, job := range []struct{ n, priority int }{{4, 1}, {1, 3}, {3, 2}, {2, 3}, {5, 0}} {
+		if err := p.Submit(WithPriority(context.Background(), job.priority), job.n); err != nil {
+			t.Fatal(err)
+		}
+	}
+	close(gate)
+	if err := p.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{0, 1, 2, 3, 4, 5}; !slices.Equal(ran, want) {
+		t.Errorf("ran %v, want %v", ran, want)
+	}
+}
+
+func TestPriorityPreemptsAtCheckpoint(t *testing.T) {
+	const long, urgent = 1, 2
+	started := make(chan int, 3)
+	finish := make(chan struct{})
+	var steps int // units of work done by the long job, kept across runs
+	p := NewPriority(func(ctx context.Context, n int) (int, error) {
+		started <- n
+		if n == urgent {
+			close(finish)
+			return n, nil
+		}
+		for ; steps < 10; steps++ {
+			if steps == 5 {
+				select {
+				case <-finish:
+				case <-Preempted(ctx):
+				}
+			}
+			if err := Checkpoint(ctx); err != nil {
+				return 0, err
+			}
+		}
+		return n, nil
+	}, WithWorkers(1))
+
+	p.Submit(context.Background(), long)
+	<-started
+	p.Submit(WithPriority(context.Background(), 1), urgent)
+
+	var got []int
+	for range 2 {
+		r := <-p.Results()
+		if r.Err != nil {
+			t.Fatalf("job %d: %v", r.Job, r.Err)
+		}
+		got = append(got, r.Job)
+	}
+	p.Close()
+	if want := []int{urgent, long}; !slices.Equal(got, want) {
+		t.Errorf("finished %v, want %v", got, want)
+	}
+	if got, want := drain(started), []int{urgent, long}; !slices.Equal(got, want) {
+		t.Errorf("started %v after the first run, want %v", got, want)
+	}
+	if steps != 10 {
+		t.Errorf("long job did %d steps, want 10", steps)
+	}
+}
+
+func TestPriorityUnsignaledErrPreemptedFails(t *testing.T) {
+	var runs int
+	p := NewPriority(func(ctx context.Context, n int) (int, error) {
+		runs++
+		// Passed on from another pool's Checkpoint, not this one's.
+		return 0, fmt.Errorf("inner pool: %w", ErrPreempted)
+	}, WithWorkers(1))
+	p.Submit(context.Background(), 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := p.Wait(ctx); !errors.Is(err, ErrPreempted) {
+		t.Errorf("Wait = %v, want the job failing with ErrPreempted", err)
+	}
+	if runs != 1 {
+		t.Errorf("job ran %d times, want once", runs)
+	}
+}
+
+// lowJobPosition submits one low priority job while keeping the queue full
+// of high priority ones, and returns how many jobs finished before it.
+func lowJobPosition(t *testing.T, opts ...Option) int {
+	t.Helper()
+	const high, low, n = 10, 0, 300
+	p := NewPriority(func(ctx context.Context, n int) (int, error) {
+		time.Sleep(time.Millisecond)
+		return n, nil
+	}, append([]Option{WithWorkers(1), WithQueue(4)}, opts...)...)
+	go func() {
+		defer p.Close()
+		hi := WithPriority(context.Background(), high)
+		for i := range n {
+			if i == 8 {
+				p.Submit(WithPriority(context.Background(), low), -1)
+			}
+			p.Submit(hi, i)
+		}
+	}()
+	pos := -1
+	i := 0
+	for r := range p.Results() {
+		if r.Job == -1 {
+			pos = i
+		}
+		i++
+	}
+	return pos
+}
+
+func TestPriorityStarvesWithoutAging(t *testing.T) {
+	if pos := lowJobPosition(t); pos < 200 {
+		t.Errorf("low priority job ran after %d jobs, expected it to wait for the high priority load to stop", pos)
+	}
+}
+
+func TestPriorityAgingBoundsWait(t *testing.T) {
+	// After 10 agings the low job outranks every newly submitted high one,
+	// so it waits for about 10*2ms of work plus the jobs queued before it.
+	if pos := lowJobPosition(t, WithAging(2*time.Millisecond)); pos < 0 || pos > 60 {
+		t.Errorf("low priority job ran after %d jobs, want at most 60", pos)
+	}
+}
+
+func TestPrioritySubmitWokenByClose(t *testing.T) {
+	gate, started := make(chan struct{}), make(chan struct{})
+	var ran []int
+	p := NewPriority(gated(gate, started, &ran), WithWorkers(1), WithQueue(1))
+	if err := submitAtClose(t, p, gate, started); !errors.Is(err, ErrClosed) {
+		t.Errorf("Submit blocked at Close = %v, want ErrClosed", err)
+	}
+}
+
+func TestPriorityAgedJobPreempts(t *testing.T) {
+	clk := clock.NewFake(time.Unix(0, 0))
+	started := make(chan int, 4)
+	var yielded bool
+	p := NewPriority(func(ctx context.Context, n int) (int, error) {
+		started <- n
+		if n == 0 && !yielded {
+			select {
+			case <-Preempted(ctx):
+				yielded = true
+				return 0, ErrPreempted
+			case <-time.After(time.Second):
+			}
+		}
+		return n, nil
+	}, WithWorkers(1), WithQueue(2), WithAging(time.Second), WithClock(clk))
+
+	ctx := context.Background()
+	p.Submit(WithPriority(ctx, 5), 0)
+	<-started
+	p.Submit(ctx, 1) // ranks below the running job
+	clk.Advance(10 * time.Second)
+	p.Submit(ctx, 2) // by now job 1 has aged past it
+	p.Close()
+	for range p.Results() {
+	}
+	if got, want := drain(started), []int{1, 0, 2}; !slices.Equal(got, want) {
+		t.Errorf("started %v after the first run, want %v", got, want)
+	}
+}
+
+func TestCheckpointOutsidePool(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := Checkpoint(ctx); err != nil {
+		t.Errorf("Checkpoint = %v, want nil", err)
+	}
+	cancel()
+	if err := Checkpoint(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Checkpoint after cancel = %v, want context.Canceled", err)
+	}
+}
+
+func drain(ch chan int) []int {
+	close(ch)
+	var s []int
+	for v := range ch {
+		s = append(s, v)
+	}
+	return s
+}
@@ -0,0 +1,245 @@
+package pool
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+
+	"github.com/lotusirous/gochan/result"
+	"github.com/lotusirous/gochan/safego"
+)
+
+// ChildEnv is set to "1" in the environment of every process started by a
+// ProcessPool. Programs that double as their own workers check it with
+// IsChild and call Serve instead of running their normal main.
+const ChildEnv = "POOL_CHILD"
+
+// IsChild reports whether the current process was started by a ProcessPool.
+func IsChild() bool { return os.Getenv(ChildEnv) == "1" }
+
+// response is the wire format a child writes for every job. Errors cross the
+// process boundary as strings, so callers can only inspect their text.
+type response[Out any] struct {
+	Value Out    `json:"value"`
+	Err   string `json:"err,omitempty"`
+}
+
+// Serve is the child side of a ProcessPool. It reads JSON encoded jobs from r,
+// processes them one at a time with fn and writes JSON encoded results to w.
+// A panicking job is reported as that job's error rather than crashing the
+// child.
+// It returns nil when r reaches EOF, which is how the pool asks it to exit.
+func Serve[In, Out any](ctx context.Context, r io.Reader, w io.Writer, fn Func[In, Out]) error {
+	dec := json.NewDecoder(bufio.NewReader(r))
+	enc := json.NewEncoder(w)
+	for {
+		var in In
+		if err := dec.Decode(&in); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		var out Out
+		err := safego.Do(func() (err error) {
+			out, err = fn(ctx, in)
+			return err
+		})
+		resp := response[Out]{Value: out}
+		if err != nil {
+			resp.Err = err.Error()
+		}
+		if err := enc.Encode(resp); err != nil {
+			return err
+		}
+	}
+}
+
+// ProcessPool runs jobs in child OS processes, one job at a time per child.
+// Jobs and results are exchanged as JSON over the child's stdin and stdout.
+//
+// Compared to GoroutinePool every job pays for encoding and two pipe writes,
+// but a crashing or leaking job cannot take down the parent: a child that
+// dies is replaced before the next job.
+type ProcessPool[In, Out any] struct {
+	command  func() *exec.Cmd
+	q        *queue[In]
+	results  chan Result[In, Out]
+	failures failures
+	config   config
+}
+
+var _ Pool[int, int] = (*ProcessPool[int, int])(nil)
+
+// NewProcessPool starts one child per worker using command, which must return
+// a fresh, unstarted *exec.Cmd on every call. The children must call Serve.
+func NewProcessPool[In, Out any](command func() *exec.Cmd, opts ...Option) (*ProcessPool[In, Out], error) {
+	c := newConfig(opts)
+	p := &ProcessPool[In, Out]{
+		command: command,
+		q:       newQueue[In](c.queue),
+		results: make(chan Result[In, Out], c.queue),
+		config:  c,
+	}
+	p.failures.limit = c.errorLimit
+
+	children := make([]*child, c.workers)
+	for i := range children {
+		ch, err := p.start()
+		if err != nil {
+			for _, started := range children[:i] {
+				started.kill()
+			}
+			return nil, err
+		}
+		children[i] = ch
+	}
+
+	var wg sync.WaitGroup
+	for _, ch := range children {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.work(ch)
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(p.results)
+	}()
+	return p, nil
+}
+
+// Submit implements Pool.
+func (p *ProcessPool[In, Out]) Submit(ctx context.Context, job In) error {
+	return p.q.submit(ctx, job)
+}
+
+// Results implements Pool.
+func (p *ProcessPool[In, Out]) Results() <-chan Result[In, Out] { return p.results }
+
+// Close implements Pool. Children exit once their stdin is closed after the
+// last queued job.
+func (p *ProcessPool[In, Out]) Close() { p.q.close() }
+
+// Wait implements Pool.
+func (p *ProcessPool[In, Out]) Wait(ctx context.Context) error {
+	return wait(ctx, p, &p.failures)
+}
+
+func (p *ProcessPool[In, Out]) work(ch *child) {
+	for t := range p.q.tasks {
+		var err error
+		if ch == nil {
+			if ch, err = p.start(); err != nil {
+				p.failures.record(t.job, err)
+				p.results <- Result[In, Out]{Job: t.job, Result: result.Err[Out](err)}
+				continue
+			}
+		}
+		// The job's ctx stays in this process, so the child cannot report
+		// progress, but the Tracker still lists the job while it runs.
+		_, end := p.config.start(t.ctx, t.job)
+		v, err := p.do(t, ch)
+		end(err)
+		if ch.broken {
+			ch.kill()
+			ch = nil
+		}
+		p.failures.record(t.job, err)
+		p.results <- Result[In, Out]{Job: t.job, Result: result.Of(v, err)}
+	}
+	if ch != nil {
+		ch.stop()
+	}
+}
+
+func (p *ProcessPool[In, Out]) do(t task[In], ch *child) (Out, error) {
+	var zero Out
+	if err := t.ctx.Err(); err != nil {
+		return zero, err
+	}
+	if err := ch.enc.Encode(t.job); err != nil {
+		ch.broken = true
+		return zero, fmt.Errorf("pool: send job to child: %w", err)
+	}
+
+	var resp response[Out]
+	done := make(chan error, 1)
+	go func() { done <- ch.dec.Decode(&resp) }()
+	select {
+	case err := <-done:
+		if err != nil {
+			ch.broken = true
+			return zero, fmt.Errorf("pool: child exited: %w", err)
+		}
+	case <-t.ctx.Done():
+		// The child is busy with a job nobody wants any more. Killing it
+		// is the only way to stop it; a new child takes over next time.
+		ch.broken = true
+		ch.cmd.Process.Kill()
+		<-done
+		return zero, t.ctx.Err()
+	}
+	if resp.Err != "" {
+		return resp.Value, errors.New(resp.Err)
+	}
+	return resp.Value, nil
+}
+
+func (p *ProcessPool[In, Out]) start() (*child, error) {
+	cmd := p.command()
+	if cmd.Env == nil {
+		cmd.Env = os.Environ()
+	}
+	cmd.Env = append(cmd.Env, ChildEnv+"=1")
+	if cmd.Stderr == nil {
+		cmd.Stderr = os.Stderr
+	}
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		stdin.Close()
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		stdin.Close()
+		return nil, fmt.Errorf("pool: start child: %w", err)
+	}
+	return &child{
+		cmd:   cmd,
+		stdin: stdin,
+		enc:   json.NewEncoder(stdin),
+		dec:   json.NewDecoder(bufio.NewReader(stdout)),
+	}, nil
+}
+
+// child is a running worker process owned by exactly one pool goroutine.
+type child struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	enc    *json.Encoder
+	dec    *json.Decoder
+	broken bool
+}
+
+// stop asks the child to exit by closing its stdin and waits for it.
+func (c *child) stop() {
+	c.stdin.Close()
+	c.cmd.Wait()
+}
+
+func (c *child) kill() {
+	c.cmd.Process.Kill()
+	c.stdin.Close()
+	c.cmd.Wait()
+}
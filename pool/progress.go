@@ -0,0 +1,111 @@
+package pool
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"sync"
+	"time"
+)
+
+// WithProgress makes the pool register every running job with t, so the
+// job can report its progress with ReportProgress. ProcessPool registers
+// its jobs too, but they run in another process, where ReportProgress does
+// nothing.
+func WithProgress(t *Tracker) Option {
+	return func(c *config) { c.progress = t }
+}
+
+// JobProgress is the last progress reported by a running job.
+type JobProgress struct {
+	ID      uint64 // unique per run, in start order
+	Job     any
+	Percent float64
+	Message string
+	Started time.Time
+	Updated time.Time // zero until the job first reports
+}
+
+// Tracker collects the progress of the jobs running on one or more pools.
+type Tracker struct {
+	mu      sync.Mutex
+	seq     uint64
+	running map[uint64]*JobProgress
+	changed chan struct{}
+}
+
+// NewTracker returns an empty Tracker.
+func NewTracker() *Tracker {
+	return &Tracker{
+		running: make(map[uint64]*JobProgress),
+		changed: make(chan struct{}, 1),
+	}
+}
+
+// Snapshot returns the progress of every running job, oldest first.
+func (t *Tracker) Snapshot() []JobProgress {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	s := make([]JobProgress, 0, len(t.running))
+	for _, p := range t.running {
+		s = append(s, *p)
+	}
+	slices.SortFunc(s, func(a, b JobProgress) int { return cmp.Compare(a.ID, b.ID) })
+	return s
+}
+
+// Changed returns a channel that receives a value after a job starts,
+// reports or finishes. Changes made while nobody receives are merged into
+// one, so a dashboard can redraw from Snapshot at its own pace.
+func (t *Tracker) Changed() <-chan struct{} { return t.changed }
+
+type trackerKey struct{}
+
+type reporter struct {
+	t  *Tracker
+	id uint64
+}
+
+// ReportProgress records that the job running with ctx is percent done,
+// with an optional message. It does nothing if the job's pool has no
+// Tracker.
+func ReportProgress(ctx context.Context, percent float64, message string) {
+	r, ok := ctx.Value(trackerKey{}).(reporter)
+	if !ok {
+		return
+	}
+	r.t.mu.Lock()
+	if p := r.t.running[r.id]; p != nil {
+		p.Percent, p.Message, p.Updated = percent, message, time.Now()
+	}
+	r.t.mu.Unlock()
+	r.t.notify()
+}
+
+// start registers a job that is about to run and returns the context to
+// run it with and a function to call when it ends.
+func (t *Tracker) start(ctx context.Context, job any) (context.Context, func()) {
+	if t == nil {
+		return ctx, func() {}
+	}
+	t.mu.Lock()
+	t.seq++
+	id := t.seq
+	t.running[id] = &JobProgress{ID: id, Job: job, Started: time.Now()}
+	t.mu.Unlock()
+	t.notify()
+
+	return context.WithValue(ctx, trackerKey{}, reporter{t, id}), func() {
+		t.mu.Lock()
+		delete(t.running, id)
+		t.mu.Unlock()
+		t.notify()
+	}
+}
+
+func (t *Tracker) notify() {
+	select {
+	case t.changed <- struct{}{}:
+	default:
+	}
+}
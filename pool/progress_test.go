@@ -0,0 +1,77 @@
+package pool
+
+import (
+	"context"
+	"testing"
+)
+
+func TestTrackerSnapshot(t *testing.T) {
+	tr := NewTracker()
+	halfway, finish := make(chan struct{}), make(chan struct{})
+	p := New(func(ctx context.Context, n int) (int, error) {
+		ReportProgress(ctx, 50, "halfway")
+		halfway <- struct{}{}
+		<-finish
+		return n, nil
+	}, WithWorkers(2), WithProgress(tr))
+
+	p.Submit(context.Background(), 1)
+	p.Submit(context.Background(), 2)
+	<-halfway
+	<-halfway
+
+	s := tr.Snapshot()
+	if len(s) != 2 {
+		t.Fatalf("Snapshot has %d jobs, want 2: %+v", len(s), s)
+	}
+	for i, jp := range s {
+		if jp.ID != uint64(i+1) || jp.Percent != 50 || jp.Message != "halfway" || jp.Updated.IsZero() {
+			t.Errorf("Snapshot[%d] = %+v", i, jp)
+		}
+	}
+	select {
+	case <-tr.Changed():
+	default:
+		t.Error("Changed not signaled")
+	}
+
+	close(finish)
+	if err := p.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if s := tr.Snapshot(); len(s) != 0 {
+		t.Errorf("finished jobs still tracked: %+v", s)
+	}
+}
+
+func TestProcessPoolRegistersJobs(t *testing.T) {
+	tr := NewTracker()
+	p, err := NewProcessPool[int, int](selfCommand, WithWorkers(1), WithProgress(tr))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Submit(context.Background(), 3)
+	p.Close()
+	if err := p.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-tr.Changed():
+	default:
+		t.Error("Changed not signaled for a job run in a child")
+	}
+	if s := tr.Snapshot(); len(s) != 0 {
+		t.Errorf("finished jobs still tracked: %+v", s)
+	}
+}
+
+func TestReportProgressWithoutTracker(t *testing.T) {
+	p := New(func(ctx context.Context, n int) (int, error) {
+		ReportProgress(ctx, 100, "done")
+		return n, nil
+	})
+	p.Submit(context.Background(), 1)
+	if err := p.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
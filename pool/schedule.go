@@ -0,0 +1,181 @@
+package pool
+
+import (
+	"container/heap"
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/lotusirous/gochan/clock"
+)
+
+// Scheduler submits jobs to a Pool at a later time.
+//
+// Pending jobs wait in a min-heap ordered by due time, watched by a single
+// goroutine that sleeps until the earliest one is due. Compared with
+// starting a goroutine with time.Sleep (or time.AfterFunc) per job, a
+// million pending jobs cost a million heap entries and one timer, not a
+// million goroutines or timers.
+//
+// The timer goroutine submits due jobs one at a time; while the pool's
+// queue is full, later jobs wait behind the blocked Submit.
+type Scheduler[In, Out any] struct {
+	pool   Pool[In, Out]
+	clock  clock.Clock
+	logger *slog.Logger
+
+	wake chan struct{} // signaled when a job becomes the earliest one
+	done chan struct{} // closed when the timer goroutine has exited
+
+	mu      sync.Mutex
+	pending delayed[In]
+	seq     uint64
+	closed  bool
+}
+
+type delayedTask[In any] struct {
+	task[In]
+	at  time.Time
+	seq uint64
+}
+
+// NewScheduler starts a scheduler in front of p. It takes ownership of p
+// and closes it once the scheduler is closed and every pending job has been
+// submitted. Of the options, it uses the clock set by WithClock, and logs
+// the jobs it drops to the logger set by WithLogger, at level Debug; p
+// has options of its own for the rest.
+func NewScheduler[In, Out any](p Pool[In, Out], opts ...Option) *Scheduler[In, Out] {
+	c := newConfig(opts)
+	s := &Scheduler[In, Out]{
+		pool:   p,
+		clock:  c.clock,
+		logger: c.logger,
+		wake:   make(chan struct{}, 1),
+		done:   make(chan struct{}),
+	}
+	go s.run()
+	return s
+}
+
+// SubmitAfter submits job to the pool once d has elapsed. The job runs with
+// ctx; if ctx is done by then, it is dropped without a Result. It returns
+// ErrClosed after Close.
+func (s *Scheduler[In, Out]) SubmitAfter(ctx context.Context, d time.Duration, job In) error {
+	return s.SubmitAt(ctx, s.clock.Now().Add(d), job)
+}
+
+// SubmitAt submits job to the pool at t, or as soon as possible if t has
+// passed. Jobs due at the same time are submitted in the order they were
+// scheduled.
+func (s *Scheduler[In, Out]) SubmitAt(ctx context.Context, t time.Time, job In) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return ErrClosed
+	}
+	s.seq++
+	heap.Push(&s.pending, delayedTask[In]{task[In]{ctx, job}, t, s.seq})
+	if s.pending[0].seq == s.seq {
+		s.signal()
+	}
+	return nil
+}
+
+// Pending returns how many jobs are waiting for their time.
+func (s *Scheduler[In, Out]) Pending() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.pending)
+}
+
+// Results returns the Results of the underlying pool.
+func (s *Scheduler[In, Out]) Results() <-chan Result[In, Out] { return s.pool.Results() }
+
+// Close stops accepting jobs. Pending jobs are still submitted when due.
+func (s *Scheduler[In, Out]) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	s.signal()
+}
+
+// Wait closes s, waits until every pending job has been submitted and run
+// and returns the result of the pool's Wait.
+func (s *Scheduler[In, Out]) Wait(ctx context.Context) error {
+	s.Close()
+	select {
+	case <-s.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return s.pool.Wait(ctx)
+}
+
+func (s *Scheduler[In, Out]) signal() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (s *Scheduler[In, Out]) run() {
+	defer close(s.done)
+	defer s.pool.Close()
+	// One timer, reset to each new earliest due time; an After per pass
+	// would leave a channel behind every time a job is scheduled earlier.
+	timer := s.clock.NewTimer(0)
+	defer timer.Stop()
+	timer.Stop()
+	for {
+		s.mu.Lock()
+		var due []delayedTask[In]
+		now := s.clock.Now()
+		for len(s.pending) > 0 && !s.pending[0].at.After(now) {
+			due = append(due, heap.Pop(&s.pending).(delayedTask[In]))
+		}
+		if len(due) > 0 {
+			s.mu.Unlock()
+			for _, t := range due {
+				// An error only means the job's own ctx is done; drop it.
+				if err := s.pool.Submit(t.ctx, t.job); err != nil && s.logger != nil {
+					s.logger.Debug("pool: scheduled job dropped", "job", t.job, "err", err)
+				}
+			}
+			continue
+		}
+		if len(s.pending) > 0 {
+			timer.Reset(s.pending[0].at.Sub(now))
+		} else if s.closed {
+			s.mu.Unlock()
+			return
+		} else {
+			timer.Stop()
+		}
+		s.mu.Unlock()
+
+		select {
+		case <-timer.C():
+		case <-s.wake:
+		}
+	}
+}
+
+// delayed is a min-heap of jobs ordered by due time, then scheduling order.
+type delayed[In any] []delayedTask[In]
+
+func (d delayed[In]) Len() int { return len(d) }
+func (d delayed[In]) Less(i, j int) bool {
+	if !d[i].at.Equal(d[j].at) {
+		return d[i].at.Before(d[j].at)
+	}
+	return d[i].seq < d[j].seq
+}
+func (d delayed[In]) Swap(i, j int) { d[i], d[j] = d[j], d[i] }
+func (d *delayed[In]) Push(x any)   { *d = append(*d, x.(delayedTask[In])) }
+func (d *delayed[In]) Pop() any {
+	old := *d
+	t := old[len(old)-1]
+	*d = old[:len(old)-1]
+	return t
+}
@@ -0,0 +1,109 @@
+package pool
+
+import (
+	"context"
+	"math/rand"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/lotusirous/gochan/clock"
+)
+
+func TestSchedulerThousandsOfDelayedJobs(t *testing.T) {
+	const jobs = 5000
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	fake := clock.NewFake(start)
+	identity := func(ctx context.Context, d time.Duration) (time.Duration, error) { return d, nil }
+	s := NewScheduler(New(identity, WithWorkers(4)), WithClock(fake))
+
+	before := runtime.NumGoroutine()
+	rng := rand.New(rand.NewSource(1))
+	due := make(map[time.Duration]int) // jobs due per minute offset
+	for range jobs {
+		d := time.Duration(rng.Intn(60)+1) * time.Minute
+		due[d]++
+		if err := s.SubmitAfter(context.Background(), d, d); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if s.Pending() != jobs {
+		t.Errorf("Pending = %d, want %d", s.Pending(), jobs)
+	}
+	if n := runtime.NumGoroutine() - before; n > 1 {
+		t.Errorf("%d goroutines started for %d delayed jobs", n, jobs)
+	}
+
+	// Walk the clock forward a minute at a time. Exactly the jobs due by
+	// then must come out, none of them early.
+	for m := time.Minute; m <= time.Hour; m += time.Minute {
+		fake.BlockUntil(1)
+		fake.Advance(time.Minute)
+		for range due[m] {
+			r := <-s.Results()
+			if now := fake.Now().Sub(start); r.Value > now {
+				t.Fatalf("job due at %v ran at %v", r.Value, now)
+			}
+		}
+	}
+	if err := s.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSchedulerOrderAndClose(t *testing.T) {
+	fake := clock.NewFake(time.Time{})
+	s := NewScheduler(New(func(ctx context.Context, n int) (int, error) { return n, nil }, WithWorkers(1)), WithClock(fake))
+	ctx := context.Background()
+	s.SubmitAfter(ctx, 3*time.Second, 3)
+	s.SubmitAfter(ctx, time.Second, 1)
+	s.SubmitAfter(ctx, 2*time.Second, 2)
+	s.SubmitAt(ctx, fake.Now().Add(-time.Hour), 0) // already due
+	if r := <-s.Results(); r.Value != 0 {
+		t.Errorf("first result = %d, want the overdue job", r.Value)
+	}
+
+	s.Close()
+	if err := s.SubmitAfter(ctx, time.Second, 9); err != ErrClosed {
+		t.Errorf("SubmitAfter after Close = %v, want ErrClosed", err)
+	}
+	// Pending jobs still run after Close, in due order.
+	for want := 1; want <= 3; want++ {
+		fake.BlockUntil(1)
+		fake.Advance(time.Second)
+		if r := <-s.Results(); r.Value != want {
+			t.Errorf("got %d, want %d", r.Value, want)
+		}
+	}
+	if _, ok := <-s.Results(); ok {
+		t.Error("Results not closed after the last pending job")
+	}
+}
+
+func TestSchedulerKeepsOneTimer(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	fake := clock.NewFake(start)
+	s := NewScheduler(New(func(ctx context.Context, n int) (int, error) { return n, nil }), WithClock(fake))
+	defer s.Wait(context.Background())
+	// Each job is due earlier than the last, so each moves the timer.
+	for h := 3; h >= 1; h-- {
+		s.SubmitAfter(context.Background(), time.Duration(h)*time.Hour, h)
+		waitNext(t, fake, start.Add(time.Duration(h)*time.Hour))
+	}
+	if n := fake.Waiters(); n != 1 {
+		t.Errorf("%d timers waiting for 3 pending jobs, want 1", n)
+	}
+	fake.Advance(3 * time.Hour)
+}
+
+// waitNext waits until the earliest deadline waiting on fake is want.
+func waitNext(t *testing.T, fake *clock.Fake, want time.Time) {
+	t.Helper()
+	for range 1000 {
+		if next, ok := fake.Next(); ok && next.Equal(want) {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("nothing waiting for %v", want)
+}
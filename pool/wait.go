@@ -0,0 +1,63 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// WithErrorLimit caps how many job errors Wait reports. Errors beyond the
+// first n are counted and summarized as "and N more errors". The default,
+// 0, keeps every error.
+func WithErrorLimit(n int) Option {
+	return func(c *config) { c.errorLimit = n }
+}
+
+// failures records the errors of failed jobs for Wait, independently of
+// whether anybody reads the Results channel.
+type failures struct {
+	limit int
+
+	mu      sync.Mutex
+	errs    []error
+	dropped int
+}
+
+func (f *failures) record(job any, err error) {
+	if err == nil {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.limit > 0 && len(f.errs) >= f.limit {
+		f.dropped++
+		return
+	}
+	f.errs = append(f.errs, fmt.Errorf("job %v: %w", job, err))
+}
+
+func (f *failures) err() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	errs := f.errs
+	if f.dropped > 0 {
+		errs = append(errs[:len(errs):len(errs)], fmt.Errorf("and %d more errors", f.dropped))
+	}
+	return errors.Join(errs...)
+}
+
+// wait implements Pool.Wait for every pool type.
+func wait[In, Out any](ctx context.Context, p Pool[In, Out], f *failures) error {
+	p.Close()
+	for {
+		select {
+		case _, ok := <-p.Results():
+			if !ok {
+				return f.err()
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package pubsub_test
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lotusirous/gochan/pubsub"
+)
+
+func ExampleBroker() {
+	ctx := context.Background()
+	b := pubsub.New[string]()
+	audit := b.Subscribe(ctx, "orders", 10)
+	billing := b.Subscribe(ctx, "orders", 10)
+
+	b.Publish(ctx, "orders", "order 1")
+	b.Publish(ctx, "orders", "order 2")
+	b.Close()
+
+	for msg := range audit {
+		fmt.Println("audit:", msg)
+	}
+	for msg := range billing {
+		fmt.Println("billing:", msg)
+	}
+	// Output:
+	// audit: order 1
+	// audit: order 2
+	// billing: order 1
+	// billing: order 2
+}
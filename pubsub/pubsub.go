@@ -0,0 +1,148 @@
+// Package pubsub delivers messages published on named topics to every
+// subscriber of the topic.
+//
+// Unlike watch, which keeps only the latest value, a Broker delivers every
+// message to every subscriber, in the order each publisher sent them. The
+// price is backpressure: a subscriber that falls behind fills its buffer
+// and then slows down Publish, until it catches up or goes away.
+package pubsub
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrClosed is returned by Publish after the Broker has been closed.
+var ErrClosed = errors.New("pubsub: broker is closed")
+
+// Broker routes messages from publishers to the subscribers of their
+// topic. The zero value is not usable; create one with New.
+type Broker[T any] struct {
+	mu     sync.Mutex
+	topics map[string]map[*subscription[T]]struct{}
+	closed bool
+}
+
+type subscription[T any] struct {
+	c    chan T
+	gone chan struct{} // closed when unsubscribing, to free a blocked Publish
+	stop func() bool   // unregisters the context.AfterFunc
+	once sync.Once
+
+	mu     sync.Mutex // held while sending on c and to close it
+	closed bool
+}
+
+// New returns a Broker with no subscribers.
+func New[T any]() *Broker[T] {
+	return &Broker[T]{topics: make(map[string]map[*subscription[T]]struct{})}
+}
+
+// Subscribe returns a channel that receives every message published on
+// topic from now on, with room for buffer messages the reader has not
+// taken yet. The channel is closed when ctx is done or the Broker is
+// closed; after Close it is closed at once.
+func (b *Broker[T]) Subscribe(ctx context.Context, topic string, buffer int) <-chan T {
+	s := &subscription[T]{c: make(chan T, max(buffer, 0)), gone: make(chan struct{})}
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		close(s.c)
+		return s.c
+	}
+	subs := b.topics[topic]
+	if subs == nil {
+		subs = make(map[*subscription[T]]struct{})
+		b.topics[topic] = subs
+	}
+	subs[s] = struct{}{}
+	// Set before b.mu is released, for Close. If ctx is done already,
+	// the function runs on its own goroutine and waits for b.mu.
+	s.stop = context.AfterFunc(ctx, func() {
+		b.mu.Lock()
+		delete(b.topics[topic], s)
+		if len(b.topics[topic]) == 0 {
+			delete(b.topics, topic)
+		}
+		b.mu.Unlock()
+		s.end()
+	})
+	b.mu.Unlock()
+	return s.c
+}
+
+// Publish sends v to every current subscriber of topic, one after the
+// other, waiting for room in the buffer of those that are behind. It
+// returns ctx.Err() if ctx is done before every subscriber has v, and
+// ErrClosed after Close. A topic without subscribers drops v.
+func (b *Broker[T]) Publish(ctx context.Context, topic string, v T) error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return ErrClosed
+	}
+	subs := make([]*subscription[T], 0, len(b.topics[topic]))
+	for s := range b.topics[topic] {
+		subs = append(subs, s)
+	}
+	b.mu.Unlock()
+
+	for _, s := range subs {
+		if err := s.send(ctx, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Subscribers returns how many subscribers topic has.
+func (b *Broker[T]) Subscribers(topic string) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.topics[topic])
+}
+
+// Close closes every subscription and makes Publish return ErrClosed.
+// Close is idempotent.
+func (b *Broker[T]) Close() {
+	b.mu.Lock()
+	topics := b.topics
+	b.topics, b.closed = nil, true
+	b.mu.Unlock()
+	for _, subs := range topics {
+		for s := range subs {
+			s.stop()
+			s.end()
+		}
+	}
+}
+
+// send delivers v to s, unless s goes away first.
+func (s *subscription[T]) send(ctx context.Context, v T) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil
+	}
+	select {
+	case s.c <- v:
+		return nil
+	case <-s.gone:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// end closes s's channel once. Closing gone first frees a Publish blocked
+// on s, so that s.mu can be taken.
+func (s *subscription[T]) end() {
+	s.once.Do(func() {
+		close(s.gone)
+		s.mu.Lock()
+		s.closed = true
+		close(s.c)
+		s.mu.Unlock()
+	})
+}
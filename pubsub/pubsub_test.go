@@ -0,0 +1,74 @@
+package pubsub
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestEverySubscriberGetsEveryMessage(t *testing.T) {
+	b := New[int]()
+	ctx := context.Background()
+	a, c := b.Subscribe(ctx, "orders", 10), b.Subscribe(ctx, "orders", 10)
+	other := b.Subscribe(ctx, "refunds", 10)
+	for i := range 3 {
+		if err := b.Publish(ctx, "orders", i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	b.Close()
+	for name, ch := range map[string]<-chan int{"a": a, "c": c} {
+		var got []int
+		for v := range ch {
+			got = append(got, v)
+		}
+		if want := []int{0, 1, 2}; !slices.Equal(got, want) {
+			t.Errorf("subscriber %s got %v, want %v", name, got, want)
+		}
+	}
+	if v, ok := <-other; ok {
+		t.Errorf("refunds subscriber got %d", v)
+	}
+	if err := b.Publish(ctx, "orders", 3); !errors.Is(err, ErrClosed) {
+		t.Errorf("Publish after Close = %v, want ErrClosed", err)
+	}
+	if _, ok := <-b.Subscribe(ctx, "orders", 1); ok {
+		t.Error("Subscribe after Close returned an open channel")
+	}
+}
+
+func TestSlowSubscriberBlocksPublish(t *testing.T) {
+	b := New[int]()
+	defer b.Close()
+	b.Subscribe(context.Background(), "t", 1) // never read
+	if err := b.Publish(context.Background(), "t", 1); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := b.Publish(ctx, "t", 2); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Publish to a full subscriber = %v, want deadline exceeded", err)
+	}
+}
+
+func TestUnsubscribeFreesBlockedPublish(t *testing.T) {
+	b := New[int]()
+	defer b.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := b.Subscribe(ctx, "t", 0)
+	errc := make(chan error)
+	go func() { errc <- b.Publish(context.Background(), "t", 1) }()
+	time.Sleep(5 * time.Millisecond) // let Publish block on the reader
+	cancel()
+	if err := <-errc; err != nil {
+		t.Errorf("Publish = %v, want nil once the subscriber left", err)
+	}
+	if _, ok := <-ch; ok {
+		t.Error("channel of a canceled subscription still open")
+	}
+	if n := b.Subscribers("t"); n != 0 {
+		t.Errorf("%d subscribers left, want 0", n)
+	}
+}
@@ -0,0 +1,36 @@
+package ratelimit_test
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/lotusirous/gochan/clock"
+	"github.com/lotusirous/gochan/ratelimit"
+)
+
+func ExampleLimiter_Wait() {
+	clk := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	start := clk.Now()
+	// Two requests a second, with bursts of two.
+	l := ratelimit.New(500*time.Millisecond, 2, ratelimit.WithClock(clk))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := range 4 {
+			l.Wait(context.Background())
+			fmt.Printf("request %d at %v\n", i+1, clk.Now().Sub(start))
+		}
+	}()
+	for range 2 {
+		clk.BlockUntil(1)
+		clk.Advance(500 * time.Millisecond)
+	}
+	<-done
+	// Output:
+	// request 1 at 0s
+	// request 2 at 0s
+	// request 3 at 500ms
+	// request 4 at 1s
+}
@@ -0,0 +1,106 @@
+// Package ratelimit spaces out work so that it starts no faster than a
+// given rate, while letting short bursts through.
+//
+// A Limiter is a token bucket: it earns one token every interval, holds at
+// most burst of them, and every job spends one. It is implemented as the
+// generic cell rate algorithm, which keeps a single timestamp, the time the
+// bucket would be full again, instead of counting tokens.
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/lotusirous/gochan/clock"
+)
+
+// Limiter lets jobs start at most once per interval on average, and up to
+// burst at once after a quiet period. It is safe for concurrent use.
+type Limiter struct {
+	clock clock.Clock
+	every time.Duration
+	slack time.Duration // how far ahead of now full may run: (burst-1)*every
+
+	mu   sync.Mutex
+	full time.Time // when the bucket is full again; the past if it is
+}
+
+// Option configures a Limiter.
+type Option func(*Limiter)
+
+// WithClock makes the Limiter tell time with clk instead of clock.Real.
+func WithClock(clk clock.Clock) Option {
+	return func(l *Limiter) { l.clock = clk }
+}
+
+// New returns a full Limiter that earns a token every interval and holds
+// at most burst. It panics if every <= 0 or burst < 1.
+func New(every time.Duration, burst int, opts ...Option) *Limiter {
+	if every <= 0 {
+		panic("ratelimit: New: interval must be positive")
+	}
+	if burst < 1 {
+		panic("ratelimit: New: burst must be at least 1")
+	}
+	l := &Limiter{clock: clock.Real, every: every, slack: time.Duration(burst-1) * every}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+// Allow spends a token and reports true if one is available now, and
+// reports false without waiting otherwise.
+func (l *Limiter) Allow() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.clock.Now()
+	full := l.full
+	if full.Before(now) {
+		full = now
+	}
+	if full.Sub(now) > l.slack {
+		return false
+	}
+	l.full = full.Add(l.every)
+	return true
+}
+
+// Wait blocks until a token is available and spends it, or returns
+// ctx.Err() if ctx is done first. A Wait that gives up hands its token
+// back.
+func (l *Limiter) Wait(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	delay := l.reserve()
+	if delay <= 0 {
+		return nil
+	}
+	t := l.clock.NewTimer(delay)
+	defer t.Stop()
+	select {
+	case <-t.C():
+		return nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		l.full = l.full.Add(-l.every)
+		l.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// reserve spends a token, available now or later, and returns how long
+// until it is.
+func (l *Limiter) reserve() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.clock.Now()
+	full := l.full
+	if full.Before(now) {
+		full = now
+	}
+	l.full = full.Add(l.every)
+	return full.Sub(now) - l.slack
+}
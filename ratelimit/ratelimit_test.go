@@ -0,0 +1,97 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/lotusirous/gochan/clock"
+)
+
+func newFake() *clock.Fake { return clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)) }
+
+func TestAllowBurstThenRate(t *testing.T) {
+	clk := newFake()
+	l := New(time.Second, 3, WithClock(clk))
+	for i := range 3 {
+		if !l.Allow() {
+			t.Fatalf("Allow %d of the burst = false", i+1)
+		}
+	}
+	if l.Allow() {
+		t.Fatal("Allow past the burst = true")
+	}
+	clk.Advance(time.Second)
+	if !l.Allow() || l.Allow() {
+		t.Error("want exactly one token after one interval")
+	}
+	clk.Advance(time.Hour)
+	n := 0
+	for l.Allow() {
+		n++
+	}
+	if n != 3 {
+		t.Errorf("%d tokens after a quiet hour, want the burst of 3", n)
+	}
+}
+
+func TestWaitSpacesCalls(t *testing.T) {
+	clk := newFake()
+	l := New(100*time.Millisecond, 1, WithClock(clk))
+	start := clk.Now()
+	done := make(chan time.Time)
+	go func() {
+		for range 3 {
+			if err := l.Wait(context.Background()); err != nil {
+				t.Error(err)
+			}
+			done <- clk.Now()
+		}
+	}()
+	if at := <-done; !at.Equal(start) {
+		t.Errorf("first Wait returned at %v, want at once", at.Sub(start))
+	}
+	for i := 1; i < 3; i++ {
+		clk.BlockUntil(1)
+		clk.Advance(100 * time.Millisecond)
+		if at := <-done; at.Sub(start) != time.Duration(i)*100*time.Millisecond {
+			t.Errorf("Wait %d returned at %v", i+1, at.Sub(start))
+		}
+	}
+}
+
+func TestWaitGivesTokenBackWhenDone(t *testing.T) {
+	clk := newFake()
+	l := New(time.Second, 1, WithClock(clk))
+	l.Allow()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error)
+	go func() { errc <- l.Wait(ctx) }()
+	clk.BlockUntil(1)
+	cancel()
+	if err := <-errc; !errors.Is(err, context.Canceled) {
+		t.Fatalf("Wait = %v, want context.Canceled", err)
+	}
+	clk.Advance(time.Second)
+	if !l.Allow() {
+		t.Error("the token of the canceled Wait was not given back")
+	}
+}
+
+func TestNewRejectsBadArguments(t *testing.T) {
+	for name, f := range map[string]func(){
+		"interval": func() { New(0, 1) },
+		"burst":    func() { New(time.Second, 0) },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("New with a bad %s did not panic", name)
+				}
+			}()
+			f()
+		}()
+	}
+}
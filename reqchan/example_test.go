@@ -0,0 +1,20 @@
+package reqchan_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/lotusirous/gochan/reqchan"
+)
+
+func Example() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	client, server := reqchan.New[string, string]()
+	go server.Serve(ctx, strings.ToUpper)
+
+	fmt.Println(client.Call(ctx, "hello"))
+	// Output: HELLO <nil>
+}
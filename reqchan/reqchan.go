@@ -0,0 +1,64 @@
+// Package reqchan packages the "reply channel inside the message" idiom: a
+// client sends a request together with a channel, and the server answers on
+// that channel.
+//
+// Written by hand, the idiom is easy to get subtly wrong: a client that
+// gives up waiting leaves the server blocked on the reply forever. Here every
+// reply channel is buffered, so the server never waits for an absent client,
+// and both sides honor their context.
+package reqchan
+
+import "context"
+
+type request[Req, Resp any] struct {
+	req   Req
+	reply chan Resp
+}
+
+// Client sends requests. It is safe for concurrent use.
+type Client[Req, Resp any] struct {
+	c chan<- request[Req, Resp]
+}
+
+// Server answers requests. Serve may run in several goroutines at once to
+// handle requests in parallel.
+type Server[Req, Resp any] struct {
+	c <-chan request[Req, Resp]
+}
+
+// New returns the two ends of a request channel.
+func New[Req, Resp any]() (Client[Req, Resp], Server[Req, Resp]) {
+	c := make(chan request[Req, Resp])
+	return Client[Req, Resp]{c}, Server[Req, Resp]{c}
+}
+
+// Call sends req and waits for the response. It returns ctx.Err() if ctx is
+// done before a server picked up the request or before it answered.
+func (c Client[Req, Resp]) Call(ctx context.Context, req Req) (Resp, error) {
+	var zero Resp
+	r := request[Req, Resp]{req: req, reply: make(chan Resp, 1)}
+	select {
+	case c.c <- r:
+	case <-ctx.Done():
+		return zero, ctx.Err()
+	}
+	select {
+	case resp := <-r.reply:
+		return resp, nil
+	case <-ctx.Done():
+		return zero, ctx.Err()
+	}
+}
+
+// Serve answers requests with handle, one at a time, until ctx is done, and
+// then returns ctx.Err().
+func (s Server[Req, Resp]) Serve(ctx context.Context, handle func(Req) Resp) error {
+	for {
+		select {
+		case r := <-s.c:
+			r.reply <- handle(r.req) // buffered: never blocks
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package reqchan
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCall(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	client, server := New[string, string]()
+	go server.Serve(ctx, strings.ToUpper)
+
+	var wg sync.WaitGroup
+	for _, word := range []string{"ping", "pong", "gopher"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			got, err := client.Call(ctx, word)
+			if err != nil || got != strings.ToUpper(word) {
+				t.Errorf("Call(%q) = %q, %v", word, got, err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestCallTimesOutWithoutServer(t *testing.T) {
+	client, _ := New[int, int]()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	if _, err := client.Call(ctx, 1); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Call = %v, want deadline exceeded", err)
+	}
+}
+
+func TestSlowServerDoesNotBlockAfterTimeout(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	client, server := New[int, int]()
+	handled := make(chan int)
+	go server.Serve(ctx, func(v int) int {
+		time.Sleep(20 * time.Millisecond)
+		handled <- v
+		return v
+	})
+
+	short, cancelShort := context.WithTimeout(ctx, 5*time.Millisecond)
+	defer cancelShort()
+	if _, err := client.Call(short, 1); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Call = %v, want deadline exceeded", err)
+	}
+	<-handled
+
+	// The server replied to nobody and must still be serving.
+	go func() { <-handled }()
+	if got, err := client.Call(ctx, 2); got != 2 || err != nil {
+		t.Fatalf("second Call = %d, %v", got, err)
+	}
+}
+
+func TestServeStopsOnCancel(t *testing.T) {
+	_, server := New[int, int]()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := server.Serve(ctx, func(v int) int { return v }); err != context.Canceled {
+		t.Fatalf("Serve = %v, want context.Canceled", err)
+	}
+}
@@ -0,0 +1,30 @@
+package result_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/lotusirous/gochan/result"
+)
+
+func ExampleGo() {
+	r := <-result.Go(func() (int, error) { return strconv.Atoi("42") })
+	if n, err := r.Unwrap(); err == nil {
+		fmt.Println(n)
+	}
+	// Output: 42
+}
+
+func ExampleCollect() {
+	c := make(chan result.Result[int], 3)
+	c <- result.Ok(1)
+	c <- result.Err[int](errors.New("disk full"))
+	c <- result.Ok(3)
+	close(c)
+
+	values, err := result.Collect(context.Background(), c)
+	fmt.Println(values, err)
+	// Output: [1] disk full
+}
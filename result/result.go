@@ -0,0 +1,78 @@
+// Package result provides Result, a value-or-error pair that can travel over
+// a single channel.
+//
+// Concurrent code often needs to hand back both a value and an error from a
+// goroutine. Rather than a pair of channels or an ad-hoc struct per example,
+// the packages in this module send Result values.
+package result
+
+import "context"
+
+// Result holds the outcome of an operation: a value, or an error.
+type Result[T any] struct {
+	Value T
+	Err   error
+}
+
+// Ok returns a successful Result.
+func Ok[T any](v T) Result[T] { return Result[T]{Value: v} }
+
+// Err returns a failed Result.
+func Err[T any](err error) Result[T] { return Result[T]{Err: err} }
+
+// Of wraps the usual (value, error) return pair.
+func Of[T any](v T, err error) Result[T] { return Result[T]{Value: v, Err: err} }
+
+// Unwrap returns the value and the error, ready for the usual if err != nil.
+func (r Result[T]) Unwrap() (T, error) { return r.Value, r.Err }
+
+// Must returns the value and panics if the Result holds an error. Use it in
+// tests and examples where an error is a bug.
+func (r Result[T]) Must() T {
+	if r.Err != nil {
+		panic(r.Err)
+	}
+	return r.Value
+}
+
+// OK reports whether the Result holds no error.
+func (r Result[T]) OK() bool { return r.Err == nil }
+
+// Go runs fn in a new goroutine and returns a channel that receives its
+// Result. The channel is buffered, so the goroutine never leaks if nobody
+// reads it.
+func Go[T any](fn func() (T, error)) <-chan Result[T] {
+	c := make(chan Result[T], 1)
+	go func() { c <- Of(fn()) }()
+	return c
+}
+
+// Send sends Of(v, err) on c. It returns false if ctx is done first.
+func Send[T any](ctx context.Context, c chan<- Result[T], v T, err error) bool {
+	select {
+	case c <- Of(v, err):
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// Collect drains c and returns all values, stopping at the first error or
+// with ctx.Err() once ctx is done.
+func Collect[T any](ctx context.Context, c <-chan Result[T]) ([]T, error) {
+	var out []T
+	for {
+		select {
+		case r, ok := <-c:
+			if !ok {
+				return out, nil
+			}
+			if r.Err != nil {
+				return out, r.Err
+			}
+			out = append(out, r.Value)
+		case <-ctx.Done():
+			return out, ctx.Err()
+		}
+	}
+}
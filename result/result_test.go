@@ -0,0 +1,56 @@
+package result
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+)
+
+var errBoom = errors.New("boom")
+
+func TestUnwrapAndMust(t *testing.T) {
+	if v, err := Ok(3).Unwrap(); v != 3 || err != nil {
+		t.Errorf("Ok(3).Unwrap() = %v, %v", v, err)
+	}
+	if _, err := Err[int](errBoom).Unwrap(); err != errBoom {
+		t.Errorf("Err.Unwrap() error = %v", err)
+	}
+	if Ok(1).Must() != 1 || !Ok(1).OK() || Err[int](errBoom).OK() {
+		t.Error("unexpected Must/OK")
+	}
+
+	defer func() {
+		if r := recover(); r != errBoom {
+			t.Errorf("Must panicked with %v, want %v", r, errBoom)
+		}
+	}()
+	Err[int](errBoom).Must()
+}
+
+func TestGo(t *testing.T) {
+	r := <-Go(func() (string, error) { return "hi", nil })
+	if r.Must() != "hi" {
+		t.Errorf("Go = %+v", r)
+	}
+}
+
+func TestSendAndCollect(t *testing.T) {
+	c := make(chan Result[int], 3)
+	ctx := context.Background()
+	Send(ctx, c, 1, nil)
+	Send(ctx, c, 2, nil)
+	Send(ctx, c, 0, errBoom)
+	close(c)
+
+	got, err := Collect(ctx, c)
+	if !slices.Equal(got, []int{1, 2}) || err != errBoom {
+		t.Errorf("Collect = %v, %v", got, err)
+	}
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	if Send(canceled, make(chan Result[int]), 1, nil) {
+		t.Error("Send succeeded on a canceled context")
+	}
+}
@@ -0,0 +1,89 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrBudgetExhausted is returned, wrapping the last error, when a retry was
+// refused by the Budget.
+var ErrBudgetExhausted = errors.New("retry: budget exhausted")
+
+// Budget limits retries to a share of the calls made. Every call deposits
+// ratio tokens, up to a maximum, and every retry spends one. A budget with
+// ratio 0.1 therefore allows, over time, one retry per ten calls, plus the
+// burst held in the bucket.
+//
+// Methods on a nil *Budget allow everything, so code can use BudgetFrom
+// without checking whether a budget was set.
+type Budget struct {
+	ratio float64
+	max   float64
+
+	mu     sync.Mutex
+	tokens float64
+	stats  BudgetStats
+}
+
+// BudgetStats counts what a Budget has seen.
+type BudgetStats struct {
+	Calls   int // first attempts
+	Retries int // retries allowed
+	Denied  int // retries refused
+}
+
+// NewBudget returns a full budget that earns ratio retries per call and
+// holds at most burst of them.
+func NewBudget(ratio float64, burst int) *Budget {
+	return &Budget{ratio: ratio, max: float64(burst), tokens: float64(burst)}
+}
+
+// Stats returns the counters so far.
+func (b *Budget) Stats() BudgetStats {
+	if b == nil {
+		return BudgetStats{}
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.stats
+}
+
+func (b *Budget) request() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.stats.Calls++
+	b.tokens = min(b.tokens+b.ratio, b.max)
+}
+
+func (b *Budget) withdraw() bool {
+	if b == nil {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.tokens < 1 {
+		b.stats.Denied++
+		return false
+	}
+	b.tokens--
+	b.stats.Retries++
+	return true
+}
+
+type budgetKey struct{}
+
+// WithBudget returns a copy of ctx carrying b. Every retry.Do under that
+// context draws from b.
+func WithBudget(ctx context.Context, b *Budget) context.Context {
+	return context.WithValue(ctx, budgetKey{}, b)
+}
+
+// BudgetFrom returns the Budget carried by ctx, or nil.
+func BudgetFrom(ctx context.Context) *Budget {
+	b, _ := ctx.Value(budgetKey{}).(*Budget)
+	return b
+}
@@ -0,0 +1,91 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+)
+
+func TestBudgetStopsRetries(t *testing.T) {
+	b := NewBudget(0, 2)
+	ctx := WithBudget(t.Context(), b)
+	p := Policy{Attempts: 10, Backoff: noBackoff}
+
+	var calls int
+	err := p.Do(ctx, failing(100, &calls))
+	if !errors.Is(err, ErrBudgetExhausted) || !errors.Is(err, errFlaky) {
+		t.Errorf("err = %v, want ErrBudgetExhausted wrapping errFlaky", err)
+	}
+	if calls != 3 {
+		t.Errorf("%d calls, want 1 + the 2 retries in the budget", calls)
+	}
+	if s := b.Stats(); s != (BudgetStats{Calls: 1, Retries: 2, Denied: 1}) {
+		t.Errorf("stats = %+v", s)
+	}
+}
+
+// TestBudgetSharedAcrossCallSites simulates an outage: many requests, each
+// fanning out to several call sites that all fail. Without a budget every
+// call site retries to the limit; with one, the whole graph retries at most
+// the ratio of its calls plus the burst.
+func TestBudgetSharedAcrossCallSites(t *testing.T) {
+	const (
+		requests  = 50
+		callSites = 4
+		attempts  = 4
+		ratio     = 0.1
+		burst     = 5
+	)
+	down := func(context.Context) error { return errFlaky }
+	p := Policy{Attempts: attempts, Backoff: noBackoff}
+
+	run := func(ctx context.Context) (calls int) {
+		var mu sync.Mutex
+		var wg sync.WaitGroup
+		for range requests {
+			for range callSites {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					p.Do(ctx, func(ctx context.Context) error {
+						mu.Lock()
+						calls++
+						mu.Unlock()
+						return down(ctx)
+					})
+				}()
+			}
+		}
+		wg.Wait()
+		return calls
+	}
+
+	if got, want := run(t.Context()), requests*callSites*attempts; got != want {
+		t.Errorf("without a budget: %d calls, want %d", got, want)
+	}
+
+	b := NewBudget(ratio, burst)
+	calls := run(WithBudget(t.Context(), b))
+	s := b.Stats()
+	if s.Calls != requests*callSites {
+		t.Errorf("budget saw %d calls, want %d", s.Calls, requests*callSites)
+	}
+	if limit := int(ratio*requests*callSites) + burst; s.Retries > limit {
+		t.Errorf("%d retries, want at most %d", s.Retries, limit)
+	}
+	if calls != s.Calls+s.Retries {
+		t.Errorf("%d calls made, budget accounts for %d", calls, s.Calls+s.Retries)
+	}
+}
+
+func TestNilBudget(t *testing.T) {
+	var b *Budget
+	if BudgetFrom(t.Context()) != nil {
+		t.Error("BudgetFrom of a bare context is not nil")
+	}
+	b.request()
+	if !b.withdraw() || b.Stats() != (BudgetStats{}) {
+		t.Error("a nil budget must allow every retry")
+	}
+}
@@ -0,0 +1,27 @@
+package retry_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lotusirous/gochan/retry"
+)
+
+func ExamplePolicy_Do() {
+	p := retry.Policy{
+		Attempts: 4,
+		Backoff:  func(int) time.Duration { return 0 }, // DefaultBackoff in production
+	}
+	calls := 0
+	err := p.Do(context.Background(), func(context.Context) error {
+		calls++
+		if calls < 3 {
+			return errors.New("503 service unavailable")
+		}
+		return nil
+	})
+	fmt.Println(calls, err)
+	// Output: 3 <nil>
+}
@@ -0,0 +1,91 @@
+// Package retry calls a function again when it fails, waiting a little
+// longer before every attempt.
+//
+// Retries multiply load exactly when a dependency is struggling. A Budget,
+// shared through the context by every call site working on the same request
+// (or by a whole client), limits retries to a fraction of the calls made, so
+// concurrent call sites cannot amplify an outage between them.
+package retry
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/lotusirous/gochan/ctxutil"
+	"github.com/lotusirous/gochan/errs"
+)
+
+// Policy describes how often and how patiently to retry.
+// The zero value makes 3 attempts with DefaultBackoff and retries every
+// error except cancellations.
+type Policy struct {
+	// Attempts is the total number of calls, the first one included.
+	// Zero means 3.
+	Attempts int
+
+	// Backoff returns how long to wait before the n-th retry (n starts at
+	// 1). If nil, DefaultBackoff is used.
+	Backoff func(n int) time.Duration
+
+	// Retryable reports whether a failed call is worth repeating. If nil,
+	// every error but a cancellation (errs.IsCanceled) is retried.
+	Retryable func(error) bool
+}
+
+// DefaultBackoff doubles the delay on every retry, starting at 10ms and
+// capping at 1s.
+func DefaultBackoff(n int) time.Duration {
+	const (
+		base = 10 * time.Millisecond
+		max  = time.Second
+	)
+	d := base
+	for i := 1; i < n; i++ {
+		d *= 2
+		if d >= max {
+			return max
+		}
+	}
+	return d
+}
+
+// Do calls fn with the zero Policy.
+func Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	var p Policy
+	return p.Do(ctx, fn)
+}
+
+// Do calls fn until it succeeds, returns an error that is not retryable, the
+// attempts are used up, ctx is done or the Budget in ctx refuses a retry. It
+// returns nil or the last error, wrapped in ErrBudgetExhausted when the
+// budget was the reason to stop.
+func (p *Policy) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	attempts := p.Attempts
+	if attempts <= 0 {
+		attempts = 3
+	}
+	backoff := p.Backoff
+	if backoff == nil {
+		backoff = DefaultBackoff
+	}
+	retryable := p.Retryable
+	if retryable == nil {
+		retryable = func(err error) bool { return !errs.IsCanceled(err) }
+	}
+
+	budget := BudgetFrom(ctx)
+	budget.request()
+	for n := 1; ; n++ {
+		err := fn(ctx)
+		if err == nil || n == attempts || !retryable(err) || ctx.Err() != nil {
+			return err
+		}
+		if !budget.withdraw() {
+			return fmt.Errorf("%w: %w", ErrBudgetExhausted, err)
+		}
+		if ctxutil.Sleep(ctx, backoff(n)) != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+var errFlaky = errors.New("flaky")
+
+func noBackoff(int) time.Duration { return 0 }
+
+// failing returns a function that fails its first n calls.
+func failing(n int, calls *int) func(context.Context) error {
+	return func(context.Context) error {
+		*calls++
+		if *calls <= n {
+			return errFlaky
+		}
+		return nil
+	}
+}
+
+func TestDoRetriesUntilSuccess(t *testing.T) {
+	var calls int
+	p := Policy{Attempts: 5, Backoff: noBackoff}
+	if err := p.Do(t.Context(), failing(3, &calls)); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 4 {
+		t.Errorf("%d calls, want 4", calls)
+	}
+}
+
+func TestDoGivesUp(t *testing.T) {
+	var calls int
+	if err := Do(t.Context(), failing(10, &calls)); err != errFlaky {
+		t.Errorf("err = %v, want errFlaky", err)
+	}
+	if calls != 3 {
+		t.Errorf("%d calls, want the default 3", calls)
+	}
+
+	calls = 0
+	p := Policy{Backoff: noBackoff, Retryable: func(err error) bool { return false }}
+	if err := p.Do(t.Context(), failing(10, &calls)); err != errFlaky || calls != 1 {
+		t.Errorf("non-retryable: err %v after %d calls", err, calls)
+	}
+}
+
+func TestDoStopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	var calls int
+	err := Do(ctx, func(ctx context.Context) error {
+		calls++
+		cancel()
+		return ctx.Err()
+	})
+	if err != context.Canceled || calls != 1 {
+		t.Errorf("err %v after %d calls, want context.Canceled after 1", err, calls)
+	}
+
+	// Cancelled during the backoff.
+	ctx, cancel = context.WithTimeout(t.Context(), 20*time.Millisecond)
+	defer cancel()
+	p := Policy{Attempts: 100, Backoff: func(int) time.Duration { return time.Hour }}
+	start := time.Now()
+	if err := p.Do(ctx, func(context.Context) error { return errFlaky }); err != errFlaky {
+		t.Errorf("err = %v, want errFlaky", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("took %v, the backoff ignored the context", elapsed)
+	}
+}
@@ -0,0 +1,24 @@
+package safego_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/lotusirous/gochan/safego"
+)
+
+func ExampleGo() {
+	err := <-safego.Go(context.Background(), func(context.Context) error {
+		var m map[string]int
+		m["hits"]++ // panics
+		return nil
+	})
+
+	var p *safego.PanicError
+	fmt.Println(errors.As(err, &p))
+	fmt.Println(p.Value)
+	// Output:
+	// true
+	// assignment to entry in nil map
+}
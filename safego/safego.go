@@ -0,0 +1,53 @@
+// Package safego draws a boundary around a function that may panic.
+//
+// An unrecovered panic in any goroutine kills the whole program, and recover
+// only works in the goroutine that panicked. Code that runs other people's
+// functions on its own goroutines (worker pools, supervisors) should
+// therefore recover inside every goroutine it starts and turn the panic into
+// an ordinary error. Do and Go do that and keep the stack of the panic, which
+// is otherwise lost once the goroutine unwinds.
+package safego
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+)
+
+// PanicError is a recovered panic.
+type PanicError struct {
+	Value any    // the value passed to panic
+	Stack []byte // stack of the panicking goroutine, as from debug.Stack
+}
+
+func (e *PanicError) Error() string { return fmt.Sprintf("panic: %v", e.Value) }
+
+// Unwrap returns the panic value if it is an error, so errors.Is and
+// errors.As see through the panic.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// Do calls fn and returns its error. If fn panics, Do recovers and returns a
+// *PanicError instead.
+func Do(fn func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &PanicError{Value: r, Stack: debug.Stack()}
+		}
+	}()
+	return fn()
+}
+
+// Go runs fn in a new goroutine with Do. The returned channel receives fn's
+// error, nil included, and is then closed. It is buffered, so the goroutine
+// finishes even if nobody receives.
+func Go(ctx context.Context, fn func(ctx context.Context) error) <-chan error {
+	c := make(chan error, 1)
+	go func() {
+		defer close(c)
+		c <- Do(func() error { return fn(ctx) })
+	}()
+	return c
+}
@@ -0,0 +1,49 @@
+package safego
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestDo(t *testing.T) {
+	if err := Do(func() error { return io.EOF }); err != io.EOF {
+		t.Errorf("Do = %v, want io.EOF", err)
+	}
+
+	err := Do(func() error { panic("boom") })
+	var pe *PanicError
+	if !errors.As(err, &pe) {
+		t.Fatalf("Do = %v, want a *PanicError", err)
+	}
+	if pe.Value != "boom" || err.Error() != "panic: boom" {
+		t.Errorf("PanicError = %v (value %v)", err, pe.Value)
+	}
+	if !strings.Contains(string(pe.Stack), "TestDo") {
+		t.Errorf("stack does not show where the panic happened:\n%s", pe.Stack)
+	}
+
+	err = Do(func() error { panic(io.ErrUnexpectedEOF) })
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("Do = %v, want it to wrap the panicked error", err)
+	}
+}
+
+func TestGo(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	c := Go(ctx, func(ctx context.Context) error { return ctx.Err() })
+	if err := <-c; err != context.Canceled {
+		t.Errorf("got %v, want context.Canceled", err)
+	}
+	if _, ok := <-c; ok {
+		t.Error("channel not closed after the result")
+	}
+
+	var pe *PanicError
+	if err := <-Go(t.Context(), func(context.Context) error { panic(1) }); !errors.As(err, &pe) {
+		t.Errorf("got %v, want a *PanicError", err)
+	}
+}
@@ -0,0 +1,36 @@
+package saga_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/lotusirous/gochan/saga"
+)
+
+func Example() {
+	step := func(name string, err error) saga.Step {
+		return saga.Step{
+			Name: name,
+			Do:   func(context.Context) error { return err },
+			Compensate: func(context.Context) error {
+				fmt.Println("undo", name)
+				return nil
+			},
+		}
+	}
+
+	var s saga.Saga
+	s.Add(step("reserve flight", nil))
+	s.Add(step("reserve hotel", nil))
+	s.Add(step("charge card", errors.New("card declined")))
+
+	var e *saga.Error
+	if err := s.Run(context.Background()); errors.As(err, &e) {
+		fmt.Println(e.Step, "failed:", e.Err)
+	}
+	// Output:
+	// undo reserve hotel
+	// undo reserve flight
+	// charge card failed: card declined
+}
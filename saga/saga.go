@@ -0,0 +1,158 @@
+// Package saga runs a sequence of steps that cannot share a transaction,
+// such as calls to different services, and undoes the completed ones when
+// a later step fails.
+//
+// Each step comes with a compensating action that semantically reverses
+// it: a refund for a payment, a release for a reservation. When a step
+// fails, the steps already done are compensated in reverse order, so that
+// each compensation runs while what it depends on is still in place.
+// Steps added together in one stage are independent of each other; they
+// run concurrently, and so do their compensations.
+package saga
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+)
+
+// Step is one action of a saga and the action that undoes it.
+//
+// Do must leave nothing behind when it fails, since a failed step is not
+// compensated. Compensate may be nil for a step with nothing to undo, and
+// should be safe to retry, as callers often do with what Run reports.
+type Step struct {
+	Name       string
+	Do         func(ctx context.Context) error
+	Compensate func(ctx context.Context) error
+}
+
+// Saga is a sequence of stages of steps. The zero value is an empty saga.
+type Saga struct {
+	stages [][]Step
+}
+
+// Add appends a stage. Its steps run concurrently once every earlier stage
+// has completed, and must not depend on one another.
+func (s *Saga) Add(steps ...Step) *Saga {
+	s.stages = append(s.stages, steps)
+	return s
+}
+
+// Error is what Run returns when the saga did not complete.
+type Error struct {
+	Step string // the step that failed, or "" if ctx was done between stages
+	Err  error  // why the step failed, or ctx.Err()
+
+	Compensated []string // steps undone, in the order their stages were
+	Failed      []string // steps whose compensation failed
+	CompErr     error    // the errors of those compensations, joined
+}
+
+func (e *Error) Error() string {
+	var b strings.Builder
+	b.WriteString("saga: ")
+	if e.Step != "" {
+		b.WriteString("step " + e.Step + ": ")
+	}
+	b.WriteString(e.Err.Error())
+	if e.CompErr != nil {
+		b.WriteString("; compensation failed for " + strings.Join(e.Failed, ", ") + ": " + e.CompErr.Error())
+	}
+	return b.String()
+}
+
+// Unwrap returns the step's error and the compensations' errors.
+func (e *Error) Unwrap() []error {
+	if e.CompErr == nil {
+		return []error{e.Err}
+	}
+	return []error{e.Err, e.CompErr}
+}
+
+// Run runs the stages in order. If a step fails, the other steps of its
+// stage are cancelled, and every step that completed is compensated, stage
+// by stage from the last, each stage's compensations concurrently. The
+// same happens if ctx is done.
+//
+// Compensations run with a context that keeps ctx's values but not its
+// cancellation: a saga cancelled halfway must still be undone. Run returns
+// nil if every step completed and an *Error otherwise.
+func (s *Saga) Run(ctx context.Context) error {
+	var done [][]Step // completed steps, by stage
+	for _, stage := range s.stages {
+		if err := ctx.Err(); err != nil {
+			return s.compensate(ctx, done, &Error{Err: err})
+		}
+		ok, failure := runStage(ctx, stage)
+		done = append(done, ok)
+		if failure != nil {
+			return s.compensate(ctx, done, failure)
+		}
+	}
+	return nil
+}
+
+// runStage runs the steps of one stage and returns those that completed,
+// in stage order, and the first failure if any.
+func runStage(ctx context.Context, stage []Step) ([]Step, *Error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	errs := make([]error, len(stage))
+	var failure *Error
+	var once sync.Once
+	var wg sync.WaitGroup
+	for i, step := range stage {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if errs[i] = step.Do(ctx); errs[i] != nil {
+				once.Do(func() {
+					failure = &Error{Step: step.Name, Err: errs[i]}
+					cancel()
+				})
+			}
+		}()
+	}
+	wg.Wait()
+
+	var ok []Step
+	for i, step := range stage {
+		if errs[i] == nil {
+			ok = append(ok, step)
+		}
+	}
+	return ok, failure
+}
+
+func (s *Saga) compensate(ctx context.Context, done [][]Step, e *Error) error {
+	ctx = context.WithoutCancel(ctx)
+	var compErrs []error
+	for i := len(done) - 1; i >= 0; i-- {
+		errs := make([]error, len(done[i]))
+		var wg sync.WaitGroup
+		for j, step := range done[i] {
+			if step.Compensate == nil {
+				continue
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs[j] = step.Compensate(ctx)
+			}()
+		}
+		wg.Wait()
+		for j, step := range done[i] {
+			if errs[j] != nil {
+				e.Failed = append(e.Failed, step.Name)
+				compErrs = append(compErrs, errs[j])
+			} else {
+				e.Compensated = append(e.Compensated, step.Name)
+			}
+		}
+	}
+	e.CompErr = errors.Join(compErrs...)
+	return e
+}
@@ -0,0 +1,183 @@
+package saga
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+var errBoom = errors.New("boom")
+
+// journal records what steps did, in order.
+type journal struct {
+	mu      sync.Mutex
+	entries []string
+}
+
+func (j *journal) add(s string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.entries = append(j.entries, s)
+}
+
+func (j *journal) get() []string {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return slices.Clone(j.entries)
+}
+
+// step returns a step that records itself, and fails with err.
+func (j *journal) step(name string, err error) Step {
+	return Step{
+		Name: name,
+		Do: func(context.Context) error {
+			if err != nil {
+				return err
+			}
+			j.add(name)
+			return nil
+		},
+		Compensate: func(context.Context) error {
+			j.add("undo " + name)
+			return nil
+		},
+	}
+}
+
+func TestCompletes(t *testing.T) {
+	var j journal
+	var s Saga
+	s.Add(j.step("reserve", nil)).Add(j.step("charge", nil)).Add(j.step("ship", nil))
+	if err := s.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := j.get(); !slices.Equal(got, []string{"reserve", "charge", "ship"}) {
+		t.Errorf("journal = %q", got)
+	}
+}
+
+func TestPartialCompletionIsUndoneInReverse(t *testing.T) {
+	var j journal
+	var s Saga
+	s.Add(j.step("reserve", nil)).Add(j.step("charge", nil)).Add(j.step("ship", errBoom)).Add(j.step("notify", nil))
+
+	err := s.Run(context.Background())
+	var se *Error
+	if !errors.As(err, &se) || !errors.Is(err, errBoom) || se.Step != "ship" {
+		t.Fatalf("Run = %v", err)
+	}
+	want := []string{"reserve", "charge", "undo charge", "undo reserve"}
+	if got := j.get(); !slices.Equal(got, want) {
+		t.Errorf("journal = %q, want %q", got, want)
+	}
+	if !slices.Equal(se.Compensated, []string{"charge", "reserve"}) || se.CompErr != nil {
+		t.Errorf("compensated %q, compensation error %v", se.Compensated, se.CompErr)
+	}
+}
+
+func TestFailureInStageCancelsSiblings(t *testing.T) {
+	var j journal
+	slow := Step{
+		Name: "slow",
+		Do: func(ctx context.Context) error {
+			<-ctx.Done() // only stops because its sibling failed
+			return ctx.Err()
+		},
+		Compensate: func(context.Context) error { j.add("undo slow"); return nil },
+	}
+	var s Saga
+	s.Add(j.step("book", nil)).Add(j.step("fast", nil), slow, j.step("bad", errBoom))
+
+	err := s.Run(context.Background())
+	if !errors.Is(err, errBoom) {
+		t.Fatalf("Run = %v", err)
+	}
+	// fast completed and is undone with its stage; slow and bad never did.
+	got := j.get()
+	if len(got) != 4 || !slices.Contains(got[:2], "book") || !slices.Contains(got[:2], "fast") ||
+		!slices.Equal(got[2:], []string{"undo fast", "undo book"}) {
+		t.Errorf("journal = %q", got)
+	}
+}
+
+func TestStageCompensatesConcurrently(t *testing.T) {
+	// Each compensation waits for the other to start: done one after the
+	// other, they would deadlock.
+	var started sync.WaitGroup
+	started.Add(2)
+	undo := func(context.Context) error {
+		started.Done()
+		started.Wait()
+		return nil
+	}
+	ok := func(context.Context) error { return nil }
+	var s Saga
+	s.Add(Step{Name: "a", Do: ok, Compensate: undo}, Step{Name: "b", Do: ok, Compensate: undo})
+	s.Add(Step{Name: "fail", Do: func(context.Context) error { return errBoom }})
+
+	errc := make(chan error, 1)
+	go func() { errc <- s.Run(context.Background()) }()
+	select {
+	case err := <-errc:
+		if !errors.Is(err, errBoom) {
+			t.Errorf("Run = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("compensations of one stage ran one after the other")
+	}
+}
+
+func TestCancelStillCompensates(t *testing.T) {
+	var j journal
+	ctx, cancel := context.WithCancel(context.Background())
+	var s Saga
+	s.Add(j.step("reserve", nil))
+	s.Add(Step{
+		Name: "charge",
+		Do: func(context.Context) error {
+			cancel() // the caller gives up while this step runs
+			j.add("charge")
+			return nil
+		},
+		Compensate: func(ctx context.Context) error {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			j.add("undo charge")
+			return nil
+		},
+	})
+	s.Add(j.step("ship", nil))
+
+	err := s.Run(ctx)
+	var se *Error
+	if !errors.As(err, &se) || se.Step != "" || !errors.Is(err, context.Canceled) {
+		t.Fatalf("Run = %v", err)
+	}
+	want := []string{"reserve", "charge", "undo charge", "undo reserve"}
+	if got := j.get(); !slices.Equal(got, want) {
+		t.Errorf("journal = %q, want %q", got, want)
+	}
+}
+
+func TestCompensationFailureIsReported(t *testing.T) {
+	var j journal
+	errStuck := errors.New("refund rejected")
+	charge := j.step("charge", nil)
+	charge.Compensate = func(context.Context) error { return errStuck }
+	var s Saga
+	s.Add(j.step("reserve", nil)).Add(charge).Add(j.step("ship", errBoom))
+
+	err := s.Run(context.Background())
+	var se *Error
+	if !errors.As(err, &se) || !errors.Is(err, errStuck) || !errors.Is(err, errBoom) {
+		t.Fatalf("Run = %v", err)
+	}
+	if !slices.Equal(se.Failed, []string{"charge"}) || !slices.Equal(se.Compensated, []string{"reserve"}) {
+		t.Errorf("failed %q, compensated %q", se.Failed, se.Compensated)
+	}
+	t.Log(err)
+}
@@ -0,0 +1,25 @@
+package scatter_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/lotusirous/gochan/scatter"
+)
+
+func ExampleQuorum() {
+	replica := func(name string, err error) scatter.Backend[string, int] {
+		return scatter.Backend[string, int]{Name: name, Call: func(ctx context.Context, key string) (int, error) {
+			return 7, err
+		}}
+	}
+
+	out, err := scatter.Gather(context.Background(), "stock", scatter.Quorum(2),
+		replica("eu", nil),
+		replica("us", errors.New("timeout")),
+		replica("ap", nil),
+	)
+	fmt.Println(out.Values(), err)
+	// Output: [7 7] <nil>
+}
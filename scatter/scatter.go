@@ -0,0 +1,148 @@
+// Package scatter sends one query to several backends at once and gathers
+// their replies according to a Policy: wait for all of them, take what
+// arrives before a deadline, or stop at a quorum.
+package scatter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrNotEnough is returned when too few backends replied to satisfy the
+// policy.
+var ErrNotEnough = errors.New("scatter: not enough replies")
+
+// Backend is one destination of a scatter.
+type Backend[Q, R any] struct {
+	Name string
+	Call func(ctx context.Context, q Q) (R, error)
+}
+
+// Policy decides when Gather stops waiting and whether a partial answer is
+// acceptable.
+type Policy struct {
+	need    int           // replies to wait for; 0 means every backend
+	partial bool          // return what arrived instead of failing
+	timeout time.Duration // 0 means only the context deadline applies
+}
+
+// RequireAll waits for every backend. Any failure, or the context ending
+// first, fails the whole gather.
+func RequireAll() Policy { return Policy{} }
+
+// BestEffort returns whatever replies arrived within timeout, or before the
+// context ends if timeout is 0. Failed and slow backends are left out; it
+// never returns an error.
+func BestEffort(timeout time.Duration) Policy {
+	return Policy{partial: true, timeout: timeout}
+}
+
+// Quorum returns as soon as n backends replied successfully and cancels the
+// rest. It fails once n successes are no longer possible.
+func Quorum(n int) Policy { return Policy{need: n} }
+
+// Reply is a successful response from one backend.
+type Reply[R any] struct {
+	Backend string
+	Value   R
+}
+
+// Outcome is what Gather collected.
+type Outcome[R any] struct {
+	Replies []Reply[R] // in arrival order
+	Missing []string   // backends that failed, were too slow or were not waited for
+}
+
+// Contributors returns the names of the backends that replied, in arrival
+// order.
+func (o Outcome[R]) Contributors() []string {
+	names := make([]string, len(o.Replies))
+	for i, r := range o.Replies {
+		names[i] = r.Backend
+	}
+	return names
+}
+
+// Values returns the reply values in arrival order.
+func (o Outcome[R]) Values() []R {
+	values := make([]R, len(o.Replies))
+	for i, r := range o.Replies {
+		values[i] = r.Value
+	}
+	return values
+}
+
+type reply[R any] struct {
+	backend string
+	value   R
+	err     error
+}
+
+// Gather calls every backend concurrently with q and collects replies until
+// p is satisfied. Backends still running when Gather returns see their
+// context cancelled; their late replies are discarded without blocking them.
+// The Outcome is valid even when an error is returned, and lists the
+// replies that did arrive.
+func Gather[Q, R any](ctx context.Context, q Q, p Policy, backends ...Backend[Q, R]) (Outcome[R], error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	if p.timeout > 0 {
+		var stop context.CancelFunc
+		ctx, stop = context.WithTimeout(ctx, p.timeout)
+		defer stop()
+	}
+
+	need := p.need
+	if need <= 0 || need > len(backends) {
+		need = len(backends)
+	}
+
+	// Buffered for every backend so none of them blocks after we stop
+	// listening.
+	c := make(chan reply[R], len(backends))
+	for _, b := range backends {
+		go func() {
+			v, err := b.Call(ctx, q)
+			c <- reply[R]{b.Name, v, err}
+		}()
+	}
+
+	var (
+		out    Outcome[R]
+		failed []error
+		err    error
+	)
+loop:
+	for pending := len(backends); pending > 0 && len(out.Replies) < need; pending-- {
+		select {
+		case r := <-c:
+			if r.err != nil {
+				failed = append(failed, fmt.Errorf("%s: %w", r.backend, r.err))
+				if !p.partial && len(out.Replies)+pending-1 < need {
+					err = errors.Join(append([]error{ErrNotEnough}, failed...)...)
+					break loop
+				}
+				continue
+			}
+			out.Replies = append(out.Replies, Reply[R]{r.backend, r.value})
+		case <-ctx.Done():
+			if !p.partial {
+				err = fmt.Errorf("%w: %d of %d: %w", ErrNotEnough, len(out.Replies), need, ctx.Err())
+			}
+			break loop
+		}
+	}
+
+	got := make(map[string]bool, len(out.Replies))
+	for _, r := range out.Replies {
+		got[r.Backend] = true
+	}
+	for _, b := range backends {
+		if !got[b.Name] {
+			out.Missing = append(out.Missing, b.Name)
+		}
+	}
+	return out, err
+}
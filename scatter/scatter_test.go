@@ -0,0 +1,116 @@
+package scatter
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/lotusirous/gochan/errs"
+)
+
+var errDown = errors.New("down")
+
+// backend replies with its name after d, or fails if err is set.
+func backend(name string, d time.Duration, err error) Backend[string, string] {
+	return Backend[string, string]{name, func(ctx context.Context, q string) (string, error) {
+		select {
+		case <-time.After(d):
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+		if err != nil {
+			return "", err
+		}
+		return name + ":" + q, nil
+	}}
+}
+
+func TestRequireAll(t *testing.T) {
+	out, err := Gather(t.Context(), "q", RequireAll(),
+		backend("a", 20*time.Millisecond, nil),
+		backend("b", 0, nil),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := out.Contributors(), []string{"b", "a"}; !slices.Equal(got, want) {
+		t.Errorf("contributors = %v, want %v", got, want)
+	}
+	if got := out.Values(); got[0] != "b:q" {
+		t.Errorf("values = %v", got)
+	}
+
+	out, err = Gather(t.Context(), "q", RequireAll(),
+		backend("a", 0, nil),
+		backend("b", 10*time.Millisecond, errDown),
+		backend("c", time.Hour, nil),
+	)
+	if !errors.Is(err, ErrNotEnough) || !errors.Is(err, errDown) {
+		t.Errorf("err = %v, want ErrNotEnough and errDown", err)
+	}
+	if !slices.Equal(out.Missing, []string{"b", "c"}) {
+		t.Errorf("missing = %v, want [b c]", out.Missing)
+	}
+}
+
+func TestBestEffort(t *testing.T) {
+	start := time.Now()
+	out, err := Gather(t.Context(), "q", BestEffort(30*time.Millisecond),
+		backend("fast", 0, nil),
+		backend("broken", 0, errDown),
+		backend("slow", time.Hour, nil),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("took %v, want about 30ms", elapsed)
+	}
+	if got := out.Contributors(); !slices.Equal(got, []string{"fast"}) {
+		t.Errorf("contributors = %v, want [fast]", got)
+	}
+	if !slices.Equal(out.Missing, []string{"broken", "slow"}) {
+		t.Errorf("missing = %v, want [broken slow]", out.Missing)
+	}
+}
+
+func TestQuorum(t *testing.T) {
+	out, err := Gather(t.Context(), "q", Quorum(2),
+		backend("a", 0, nil),
+		backend("b", 10*time.Millisecond, errDown),
+		backend("c", 20*time.Millisecond, nil),
+		backend("d", time.Hour, nil),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := out.Contributors(); !slices.Equal(got, []string{"a", "c"}) {
+		t.Errorf("contributors = %v, want [a c]", got)
+	}
+
+	_, err = Gather(t.Context(), "q", Quorum(2),
+		backend("a", 0, nil),
+		backend("b", 0, errDown),
+		backend("c", 10*time.Millisecond, errDown),
+	)
+	if !errors.Is(err, ErrNotEnough) {
+		t.Errorf("err = %v, want ErrNotEnough", err)
+	}
+}
+
+func TestDeadline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(t.Context(), 20*time.Millisecond)
+	defer cancel()
+	out, err := Gather(ctx, "q", Quorum(2),
+		backend("a", 0, nil),
+		backend("b", time.Hour, nil),
+	)
+	if !errors.Is(err, ErrNotEnough) || !errs.IsTimeout(err) {
+		t.Errorf("err = %v, want ErrNotEnough and a timeout", err)
+	}
+	if len(out.Replies) != 1 {
+		t.Errorf("replies = %v, want the one that arrived", out.Replies)
+	}
+}
@@ -0,0 +1,64 @@
+package selectutil
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/lotusirous/gochan/chans"
+)
+
+// producers starts n goroutines that each send perChan values and close
+// their channel.
+func producers(n, perChan int) []<-chan int {
+	cs := make([]<-chan int, n)
+	for i := range cs {
+		c := make(chan int)
+		go func() {
+			defer close(c)
+			for j := range perChan {
+				c <- j
+			}
+		}()
+		cs[i] = c
+	}
+	return cs
+}
+
+// BenchmarkRecvVsFanIn drains n channels either with a single loop calling
+// Recv (dropping channels as they close), through Merge, which builds the
+// cases once and disables channels as they close, or through chans.FanIn,
+// which runs one forwarding goroutine per channel. The cost of Recv and
+// Merge grows with the number of channels because every receive scans all
+// the cases.
+func BenchmarkRecvVsFanIn(b *testing.B) {
+	const perChan = 100
+	ctx := context.Background()
+
+	for _, n := range []int{4, 16, 64} {
+		b.Run(fmt.Sprintf("Recv/chans=%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				cs := producers(n, perChan)
+				for len(cs) > 0 {
+					i, _, err := Recv(ctx, cs)
+					if err == ErrClosed {
+						cs = slices.Delete(cs, i, i+1)
+					}
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("Merge/chans=%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				for range Merge(ctx, producers(n, perChan)...) {
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("FanIn/chans=%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				for range chans.FanIn(ctx, producers(n, perChan)...) {
+				}
+			}
+		})
+	}
+}
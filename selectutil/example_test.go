@@ -0,0 +1,93 @@
+package selectutil_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/lotusirous/gochan/selectutil"
+)
+
+func ExamplePrioritized() {
+	urgent, normal := make(chan string, 1), make(chan string, 1)
+	urgent <- "page the on-call"
+	normal <- "rotate logs"
+
+	// Both are ready; a select statement would pick either, Prioritized
+	// always takes the earlier case.
+	for range 2 {
+		selectutil.Prioritized(context.Background(),
+			selectutil.OnRecv(urgent, func(v string, _ bool) { fmt.Println(v) }),
+			selectutil.OnRecv(normal, func(v string, _ bool) { fmt.Println(v) }),
+		)
+	}
+	// Output:
+	// page the on-call
+	// rotate logs
+}
+
+func ExampleRecv() {
+	a, b := make(chan int), make(chan int, 1)
+	b <- 7
+	close(a)
+	close(b) // still delivers 7 first
+
+	chans := []<-chan int{a, b}
+	for len(chans) > 0 {
+		i, v, err := selectutil.Recv(context.Background(), chans)
+		if errors.Is(err, selectutil.ErrClosed) {
+			chans = append(chans[:i], chans[i+1:]...)
+			continue
+		}
+		fmt.Println(v)
+	}
+	// Output: 7
+}
+
+func ExampleDisable() {
+	a, b := make(chan int, 2), make(chan int, 2)
+	a <- 1
+	close(a)
+	b <- 2
+	b <- 3
+	close(b)
+
+	var in1, in2 <-chan int = a, b
+	sum := 0
+	for in1 != nil || in2 != nil {
+		select {
+		case v, ok := <-in1:
+			if !ok {
+				selectutil.Disable(&in1) // or this case wins forever
+				continue
+			}
+			sum += v
+		case v, ok := <-in2:
+			if !ok {
+				selectutil.Disable(&in2)
+				continue
+			}
+			sum += v
+		}
+	}
+	fmt.Println(sum)
+	// Output: 6
+}
+
+func ExampleMerge() {
+	ctx := context.Background()
+	a, b := make(chan string, 1), make(chan string, 1)
+	a <- "a"
+	close(a)
+	b <- "b"
+	close(b)
+
+	var got []string
+	for v := range selectutil.Merge(ctx, a, b) {
+		got = append(got, v)
+	}
+	slices.Sort(got) // inputs interleave in no particular order
+	fmt.Println(got)
+	// Output: [a b]
+}
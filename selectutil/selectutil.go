@@ -0,0 +1,203 @@
+// Package selectutil helps with select statements whose set of channels is
+// only known at run time, or that need a choice a select statement does not
+// make, such as preferring one channel over another.
+//
+// It also names the idiom that keeps such selects from spinning: a closed
+// channel is always ready, so a select keeps choosing its case, while a
+// nil channel is never ready, so setting the variable to nil switches the
+// case off. Disable does that, and Merge applies it to any number of
+// channels.
+package selectutil
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"slices"
+)
+
+// ErrClosed is returned by Recv when the selected channel is closed.
+var ErrClosed = errors.New("selectutil: channel closed")
+
+// Recv waits until one of chans can receive, like a select statement with one
+// case per channel, and returns the index of that channel and the value.
+// If the chosen channel is closed it returns its index and ErrClosed, so the
+// caller can drop it from the set. If ctx is done first it returns -1 and
+// ctx.Err(). Nil channels are never chosen, just as in a select statement.
+//
+// Recv is built on reflect.Select, which costs an allocation and some
+// reflection per call. For a set of channels that is merged for a long time,
+// a goroutine per channel (chans.FanIn) is usually faster; Recv wins when the
+// set changes often or spawning goroutines is not an option. See
+// BenchmarkRecvVsFanIn.
+func Recv[T any](ctx context.Context, chans []<-chan T) (int, T, error) {
+	cases := make([]reflect.SelectCase, len(chans)+1)
+	cases[0] = reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())}
+	for i, c := range chans {
+		cases[i+1] = reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(c)}
+	}
+
+	var zero T
+	chosen, v, ok := reflect.Select(cases)
+	if chosen == 0 {
+		return -1, zero, ctx.Err()
+	}
+	if !ok {
+		return chosen - 1, zero, ErrClosed
+	}
+	return chosen - 1, as[T](v), nil
+}
+
+// as converts a value received by reflect.Select back to T. A nil sent on
+// a channel of interface type comes back as an invalid interface, which a
+// plain type assertion would panic on; it becomes the zero T.
+func as[T any](v reflect.Value) T {
+	t, _ := v.Interface().(T)
+	return t
+}
+
+// Disable sets *c to nil, which switches off the cases of a select
+// statement on it: receiving from a nil channel never proceeds. Call it once
+// a receive reports the channel closed, or the select would keep choosing
+// that case with the zero value and never block:
+//
+//	case v, ok := <-in:
+//		if !ok {
+//			selectutil.Disable(&in)
+//			continue
+//		}
+func Disable[C ~chan T | ~<-chan T, T any](c *C) { *c = nil }
+
+// Merge receives from every input in a single goroutine and sends the values
+// on the returned channel, which is closed once every input is closed or ctx
+// is done. Each input is disabled as soon as it is closed, so finished
+// inputs cost nothing while the others keep sending.
+//
+// Unlike chans.FanIn, which runs a goroutine per input, Merge selects over
+// all of them with reflect.Select. That is cheaper to start, but every
+// receive scans every input, which adds up with many inputs; see
+// BenchmarkRecvVsFanIn.
+func Merge[T any](ctx context.Context, inputs ...<-chan T) <-chan T {
+	out := make(chan T)
+	go func() {
+		defer close(out)
+		ins := slices.Clone(inputs)
+		cases := make([]reflect.SelectCase, len(ins)+1)
+		cases[0] = reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())}
+		open := 0
+		for i, c := range ins {
+			cases[i+1] = reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(c)}
+			if c != nil {
+				open++
+			}
+		}
+		for open > 0 {
+			chosen, v, ok := reflect.Select(cases)
+			if chosen == 0 {
+				return
+			}
+			if !ok {
+				Disable(&ins[chosen-1])
+				cases[chosen].Chan = reflect.ValueOf(ins[chosen-1])
+				open--
+				continue
+			}
+			select {
+			case out <- as[T](v):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// Case is one arm of a Prioritized select, made with OnRecv or OnSend.
+type Case struct {
+	dir   reflect.SelectDir
+	ch    reflect.Value
+	send  reflect.Value
+	guard func() bool
+	do    func(v reflect.Value, ok bool)
+}
+
+// OnRecv is a case that receives from c and calls fn, if not nil, with the
+// value and whether it was sent rather than the zero value of a closed
+// channel.
+func OnRecv[T any](c <-chan T, fn func(v T, ok bool)) Case {
+	return Case{dir: reflect.SelectRecv, ch: reflect.ValueOf(c), do: func(v reflect.Value, ok bool) {
+		if fn == nil {
+			return
+		}
+		var t T
+		if ok {
+			t = as[T](v)
+		}
+		fn(t, ok)
+	}}
+}
+
+// OnSend is a case that sends v on c and then calls fn, if not nil.
+func OnSend[T any](c chan<- T, v T, fn func()) Case {
+	return Case{dir: reflect.SelectSend, ch: reflect.ValueOf(c), send: reflect.ValueOf(&v).Elem(), do: func(reflect.Value, bool) {
+		if fn != nil {
+			fn()
+		}
+	}}
+}
+
+// When returns the case guarded by ok: it takes part in a Prioritized
+// select only if ok returns true when the select starts, like the guarded
+// commands of CSP. A guard is how a case is switched off, such as a send
+// on an output when there is nothing to send.
+func (c Case) When(ok func() bool) Case {
+	c.guard = ok
+	return c
+}
+
+// Prioritized is a select that prefers earlier cases. It first tries each
+// enabled case on its own without blocking, in order, and takes the first
+// that is ready. Only if none is does it block on all of them at once,
+// where, as in a select statement, the first case to become ready wins. It
+// returns the index of the case taken, after running its function, or -1
+// and ctx.Err() if ctx is done first.
+//
+// A plain select picks at random among ready cases, which is fair: no
+// case waits forever while it is ready. Prioritized is not: while an early
+// case stays ready, later ones starve. Guard the early cases, for example
+// with a budget of consecutive wins, when that matters.
+func Prioritized(ctx context.Context, cases ...Case) (int, error) {
+	enabled := make([]bool, len(cases))
+	for i, c := range cases {
+		enabled[i] = c.guard == nil || c.guard()
+	}
+	for i, c := range cases {
+		if !enabled[i] {
+			continue
+		}
+		sc := []reflect.SelectCase{c.selectCase(), {Dir: reflect.SelectDefault}}
+		if chosen, v, ok := reflect.Select(sc); chosen == 0 {
+			c.do(v, ok)
+			return i, nil
+		}
+	}
+
+	sc := []reflect.SelectCase{{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())}}
+	index := []int{-1}
+	for i, c := range cases {
+		if enabled[i] {
+			sc = append(sc, c.selectCase())
+			index = append(index, i)
+		}
+	}
+	chosen, v, ok := reflect.Select(sc)
+	if chosen == 0 {
+		return -1, ctx.Err()
+	}
+	cases[index[chosen]].do(v, ok)
+	return index[chosen], nil
+}
+
+func (c Case) selectCase() reflect.SelectCase {
+	return reflect.SelectCase{Dir: c.dir, Chan: c.ch, Send: c.send}
+}
@@ -0,0 +1,227 @@
+package selectutil
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/lotusirous/gochan/chans"
+)
+
+func TestRecv(t *testing.T) {
+	ctx := context.Background()
+	a, b := make(chan int, 1), make(chan int, 1)
+	b <- 42
+
+	i, v, err := Recv(ctx, []<-chan int{a, b})
+	if i != 1 || v != 42 || err != nil {
+		t.Fatalf("Recv = %d, %d, %v; want 1, 42, nil", i, v, err)
+	}
+
+	close(a)
+	if i, _, err := Recv(ctx, []<-chan int{a, b}); i != 0 || err != ErrClosed {
+		t.Fatalf("Recv = %d, %v; want 0, ErrClosed", i, err)
+	}
+}
+
+func TestRecvIgnoresNilChannels(t *testing.T) {
+	c := make(chan string, 1)
+	c <- "hi"
+	if i, v, err := Recv(context.Background(), []<-chan string{nil, c, nil}); i != 1 || v != "hi" || err != nil {
+		t.Fatalf("Recv = %d, %q, %v", i, v, err)
+	}
+}
+
+func TestRecvHonorsContext(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	i, _, err := Recv(ctx, []<-chan int{make(chan int)})
+	if i != -1 || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Recv = %d, %v; want -1, deadline exceeded", i, err)
+	}
+}
+
+func TestPrioritizedPrefersEarlierCases(t *testing.T) {
+	high, low := make(chan int, 100), make(chan int, 100)
+	for i := range 100 {
+		high <- i
+		low <- i
+	}
+	var got []string
+	for range 150 {
+		_, err := Prioritized(context.Background(),
+			OnRecv(high, func(int, bool) { got = append(got, "high") }),
+			OnRecv(low, func(int, bool) { got = append(got, "low") }),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Every high value goes before any low one; a select statement would
+	// have mixed them about evenly.
+	for i, g := range got {
+		if want := map[bool]string{true: "high", false: "low"}[i < 100]; g != want {
+			t.Fatalf("case %d was %s, want %s", i, g, want)
+		}
+	}
+}
+
+func TestPrioritizedGuards(t *testing.T) {
+	high, low := make(chan int, 1), make(chan int, 1)
+	high <- 1
+	low <- 2
+	var v int
+	i, err := Prioritized(context.Background(),
+		OnRecv(high, func(x int, _ bool) { v = x }).When(func() bool { return false }),
+		OnRecv(low, func(x int, _ bool) { v = x }),
+	)
+	if i != 1 || v != 2 || err != nil {
+		t.Errorf("Prioritized = %d, %v with %d; want the unguarded case 1", i, err, v)
+	}
+}
+
+func TestPrioritizedBlocksAndSends(t *testing.T) {
+	in, out := make(chan int), make(chan string)
+	go func() { time.Sleep(5 * time.Millisecond); in <- 7 }()
+	go func() { time.Sleep(50 * time.Millisecond); <-out }()
+	var got int
+	sent := false
+	i, err := Prioritized(context.Background(),
+		OnSend(out, "x", func() { sent = true }),
+		OnRecv(in, func(v int, _ bool) { got = v }),
+	)
+	if i != 1 || got != 7 || sent || err != nil {
+		t.Errorf("Prioritized = %d, %v; got %d, sent %v", i, err, got, sent)
+	}
+	if i, err := Prioritized(context.Background(), OnSend(out, "x", func() { sent = true })); i != 0 || !sent || err != nil {
+		t.Errorf("send: Prioritized = %d, %v; sent %v", i, err, sent)
+	}
+}
+
+func TestPrioritizedClosedAndContext(t *testing.T) {
+	c := make(chan int)
+	close(c)
+	open := true
+	if i, _ := Prioritized(context.Background(), OnRecv(c, func(_ int, ok bool) { open = ok })); i != 0 || open {
+		t.Errorf("closed channel: case %d, ok %v", i, open)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	i, err := Prioritized(ctx, OnRecv(make(chan int), nil))
+	if i != -1 || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Prioritized = %d, %v; want -1, deadline exceeded", i, err)
+	}
+}
+
+// TestPrioritizedStarvation shows the cost of bias: a high priority case
+// that is always ready keeps a ready low priority case waiting, until a
+// guard limits how many times in a row the high case may win.
+func TestPrioritizedStarvation(t *testing.T) {
+	high, low := make(chan int, 1), make(chan int, 1)
+	high <- 1
+	low <- 1
+	count := func(budget int) (lows int) {
+		streak := 0
+		for range 200 {
+			i, _ := Prioritized(context.Background(),
+				OnRecv(high, nil).When(func() bool { return budget == 0 || streak < budget }),
+				OnRecv(low, nil),
+			)
+			if i == 0 {
+				streak++
+				high <- 1
+				continue
+			}
+			lows++
+			streak = 0
+			low <- 1
+		}
+		return lows
+	}
+	if n := count(0); n != 0 {
+		t.Errorf("without a budget, low won %d of 200 times; want it starved", n)
+	}
+	if n := count(4); n != 200/5 {
+		t.Errorf("with a budget of 4, low won %d of 200 times; want one in five", n)
+	}
+}
+
+func TestDisable(t *testing.T) {
+	in := make(chan int)
+	close(in)
+	var recv <-chan int = in
+	Disable(&in)
+	Disable(&recv)
+	select {
+	case <-in:
+		t.Error("disabled channel chosen")
+	case <-recv:
+		t.Error("disabled receive-only channel chosen")
+	default:
+	}
+}
+
+func TestMerge(t *testing.T) {
+	ctx := context.Background()
+	closed := make(chan int)
+	close(closed)
+	late := make(chan int)
+	go func() {
+		defer close(late)
+		// Give Merge time to receive from the closed input again, which it
+		// would if the input were not disabled, and end early.
+		time.Sleep(10 * time.Millisecond)
+		late <- 3
+	}()
+
+	got := slices.Sorted(chans.ToSeq(ctx, Merge(ctx, producers(2, 2)[0], closed, nil, late)))
+	if want := []int{0, 1, 3}; !slices.Equal(got, want) {
+		t.Fatalf("Merge = %v, want %v", got, want)
+	}
+	if _, ok := <-Merge[int](ctx); ok {
+		t.Error("Merge of no inputs sent a value")
+	}
+}
+
+func TestMergeCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	out := Merge(ctx, make(chan int))
+	cancel()
+	if _, ok := <-out; ok {
+		t.Error("Merge sent a value after cancel")
+	}
+}
+
+func TestRecvNilInterface(t *testing.T) {
+	c := make(chan error, 1)
+	c <- nil
+	if i, v, err := Recv(context.Background(), []<-chan error{c}); i != 0 || v != nil || err != nil {
+		t.Fatalf("Recv = %d, %v, %v; want 0, nil, nil", i, v, err)
+	}
+}
+
+func TestOnRecvNilInterface(t *testing.T) {
+	c := make(chan error, 1)
+	c <- nil
+	var got error = errors.New("not called")
+	var open bool
+	if i, err := Prioritized(context.Background(), OnRecv(c, func(v error, ok bool) { got, open = v, ok })); i != 0 || err != nil {
+		t.Fatalf("Prioritized = %d, %v", i, err)
+	}
+	if got != nil || !open {
+		t.Errorf("fn got %v, %v; want nil, true", got, open)
+	}
+}
+
+func TestMergeNilInterface(t *testing.T) {
+	c := make(chan error, 1)
+	c <- nil
+	close(c)
+	got := chans.Collect(context.Background(), Merge(context.Background(), c))
+	if len(got) != 1 || got[0] != nil {
+		t.Fatalf("Merge = %v, want [<nil>]", got)
+	}
+}
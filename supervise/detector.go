@@ -0,0 +1,219 @@
+package supervise
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/lotusirous/gochan/clock"
+)
+
+// ErrSuspected is the failure of a child that Watch convicted of having
+// hung.
+var ErrSuspected = errors.New("supervise: heartbeats stopped")
+
+// HealthEvent is reported when a member becomes suspect or alive again.
+type HealthEvent struct {
+	Name    string
+	Suspect bool    // false when a suspect beats again
+	Phi     float64 // suspicion at the time
+}
+
+// Monitor is a phi accrual failure detector. Instead of declaring a member
+// dead after a fixed timeout, it learns the distribution of the member's
+// heartbeat intervals and reports how unlikely the current silence is:
+// phi is -log10 of the chance that a healthy member would still be silent
+// this long. Slow but regular members, and a scheduler that delays
+// everyone, raise the expected interval rather than the alarms.
+//
+// The zero value is usable.
+type Monitor struct {
+	// Interval is how often members are expected to beat. It seeds their
+	// interval history, and phi is checked four times per Interval. If 0,
+	// it is 100ms.
+	Interval time.Duration
+	// Threshold is the phi at which a member becomes suspect. A phi of 1
+	// means a healthy member is this late one time in ten, 8 one time in a
+	// hundred million; lower detects failures sooner and errs more often.
+	// If 0, it is 8.
+	Threshold float64
+	// Convict is the phi at which Watch fails a child so that it is
+	// restarted. If 0, it is Threshold; higher gives suspects time to
+	// recover first.
+	Convict float64
+	// Window is how many recent intervals a member's history keeps. If 0,
+	// it is 100.
+	Window int
+	// MinStdDev is the least deviation assumed for the intervals, so that
+	// very regular heartbeats do not make the detector hair-triggered. If
+	// 0, it is a quarter of Interval.
+	MinStdDev time.Duration
+	// Clock tells time. If nil, it is clock.Real.
+	Clock clock.Clock
+	// OnChange, if set, is called when a member becomes suspect or alive
+	// again. It may be called from several goroutines at once.
+	OnChange func(HealthEvent)
+}
+
+func (m *Monitor) interval() time.Duration {
+	if m.Interval <= 0 {
+		return 100 * time.Millisecond
+	}
+	return m.Interval
+}
+
+func (m *Monitor) threshold() float64 {
+	if m.Threshold <= 0 {
+		return 8
+	}
+	return m.Threshold
+}
+
+func (m *Monitor) clock() clock.Clock {
+	if m.Clock == nil {
+		return clock.Real
+	}
+	return m.Clock
+}
+
+// Follow watches a member that sends the time of each heartbeat on beats,
+// and reports its changes with OnChange until ctx is done.
+func (m *Monitor) Follow(ctx context.Context, name string, beats <-chan time.Time) {
+	m.watch(ctx, name, beats, math.Inf(1))
+}
+
+// Watch returns a Spec for a child that calls beat regularly while it
+// makes progress. If the child's phi reaches Convict, its context is
+// cancelled and, unless it returns nil all the same, it fails with an
+// error wrapping ErrSuspected, so that its supervisor restarts it. Each
+// start of the child begins with a fresh interval history.
+func (m *Monitor) Watch(name string, run func(ctx context.Context, beat func()) error) Spec {
+	convict := m.Convict
+	if convict <= 0 {
+		convict = m.threshold()
+	}
+	return Spec{Name: name, Run: func(ctx context.Context) error {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		// One pending beat is enough: the next one carries a later time.
+		beats := make(chan time.Time, 1)
+		beat := func() {
+			select {
+			case beats <- m.clock().Now():
+			default:
+			}
+		}
+
+		var (
+			phi       float64
+			convicted bool
+		)
+		watched := make(chan struct{})
+		go func() {
+			defer close(watched)
+			if phi, convicted = m.watch(ctx, name, beats, convict); convicted {
+				cancel()
+			}
+		}()
+		err := run(ctx, beat)
+		cancel()
+		<-watched // no OnChange for this child once Run returns
+		if err != nil && convicted {
+			return fmt.Errorf("%w: %s (phi %.1f)", ErrSuspected, name, phi)
+		}
+		return err
+	}}
+}
+
+// watch follows beats until ctx is done or phi reaches convict, and
+// returns the phi at which it convicted.
+func (m *Monitor) watch(ctx context.Context, name string, beats <-chan time.Time, convict float64) (float64, bool) {
+	clk := m.clock()
+	h := newHistory(m.Window, m.interval(), m.MinStdDev)
+	threshold := m.threshold()
+	report := func(suspect bool, phi float64) {
+		if m.OnChange != nil {
+			m.OnChange(HealthEvent{Name: name, Suspect: suspect, Phi: phi})
+		}
+	}
+
+	last := clk.Now()
+	suspect := false
+	check := clk.NewTimer(m.interval() / 4)
+	defer check.Stop()
+	for {
+		select {
+		case t := <-beats:
+			if t.Before(last) {
+				continue // sent before a beat that arrived sooner
+			}
+			phi := h.phi(t.Sub(last))
+			h.add(t.Sub(last))
+			last = t
+			if suspect {
+				suspect = false
+				report(false, phi)
+			}
+		case now := <-check.C():
+			phi := h.phi(now.Sub(last))
+			if phi >= threshold && !suspect {
+				suspect = true
+				report(true, phi)
+			}
+			if phi >= convict {
+				return phi, true
+			}
+			check.Reset(m.interval() / 4)
+		case <-ctx.Done():
+			return 0, false
+		}
+	}
+}
+
+// history holds a member's recent heartbeat intervals, in seconds.
+type history struct {
+	intervals []float64
+	next      int // where the next interval goes once the window is full
+	size      int
+	minStdDev float64
+}
+
+func newHistory(window int, interval, minStdDev time.Duration) *history {
+	if window <= 0 {
+		window = 100
+	}
+	if minStdDev <= 0 {
+		minStdDev = interval / 4
+	}
+	h := &history{size: window, minStdDev: minStdDev.Seconds()}
+	h.add(interval)
+	return h
+}
+
+func (h *history) add(d time.Duration) {
+	if len(h.intervals) < h.size {
+		h.intervals = append(h.intervals, d.Seconds())
+		return
+	}
+	h.intervals[h.next] = d.Seconds()
+	h.next = (h.next + 1) % h.size
+}
+
+// phi returns the suspicion level after a silence of elapsed, modelling the
+// intervals as normally distributed.
+func (h *history) phi(elapsed time.Duration) float64 {
+	var sum, sq float64
+	for _, x := range h.intervals {
+		sum += x
+		sq += x * x
+	}
+	n := float64(len(h.intervals))
+	mean := sum / n
+	stdDev := max(math.Sqrt(max(sq/n-mean*mean, 0)), h.minStdDev)
+
+	// The chance that a heartbeat comes even later than this.
+	later := 0.5 * math.Erfc((elapsed.Seconds()-mean)/(stdDev*math.Sqrt2))
+	return -math.Log10(later) // +Inf once later underflows
+}
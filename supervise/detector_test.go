@@ -0,0 +1,128 @@
+package supervise
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lotusirous/gochan/clock"
+)
+
+func TestPhiAdaptsToIntervals(t *testing.T) {
+	h := newHistory(0, 100*time.Millisecond, 0)
+	prev := h.phi(0)
+	for _, ms := range []int{50, 100, 150, 200, 300, 500} {
+		phi := h.phi(time.Duration(ms) * time.Millisecond)
+		if phi <= prev {
+			t.Errorf("phi after %dms = %.2f, not above %.2f", ms, phi, prev)
+		}
+		prev = phi
+	}
+	if phi := h.phi(300 * time.Millisecond); phi < 8 {
+		t.Errorf("phi after 3 expected intervals = %.2f, want suspect", phi)
+	}
+
+	// A member that turns out to beat every 300ms is not suspect at 300ms.
+	for range 100 {
+		h.add(300 * time.Millisecond)
+	}
+	if phi := h.phi(300 * time.Millisecond); phi > 1 {
+		t.Errorf("phi at the usual interval = %.2f", phi)
+	}
+}
+
+func TestFollowReportsSuspectAndAlive(t *testing.T) {
+	clk := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	events := make(chan HealthEvent, 10)
+	m := &Monitor{Interval: 100 * time.Millisecond, Clock: clk, OnChange: func(e HealthEvent) { events <- e }}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	beats := make(chan time.Time)
+	go m.Follow(ctx, "node", beats)
+
+	// Every tick runs one check, and returns once it is done.
+	clk.BlockUntil(1)
+	tick := func() {
+		clk.Advance(25 * time.Millisecond)
+		clk.BlockUntil(1)
+	}
+	for range 10 {
+		for range 4 {
+			tick()
+		}
+		beats <- clk.Now()
+	}
+	select {
+	case e := <-events:
+		t.Fatalf("%+v while beating on time", e)
+	default:
+	}
+
+	silence := time.Duration(0)
+	for len(events) == 0 && silence < time.Second {
+		tick()
+		silence += 25 * time.Millisecond
+	}
+	if e := <-events; !e.Suspect || e.Phi < 8 {
+		t.Errorf("event %+v, want suspect", e)
+	}
+	if silence < 200*time.Millisecond || silence > 300*time.Millisecond {
+		t.Errorf("suspected after %v of silence, want about 2.5 intervals", silence)
+	}
+	beats <- clk.Now()
+	if e := <-events; e.Suspect || e.Name != "node" {
+		t.Errorf("event %+v, want alive", e)
+	}
+}
+
+func TestWatchRestartsHungChild(t *testing.T) {
+	var starts atomic.Int32
+	var events []Event
+	m := &Monitor{Interval: 10 * time.Millisecond}
+	s := Supervisor{Backoff: noBackoff, OnRestart: func(e Event) { events = append(events, e) }}
+	child := m.Watch("worker", func(ctx context.Context, beat func()) error {
+		first := starts.Add(1) == 1
+		for range 5 {
+			beat()
+			time.Sleep(5 * time.Millisecond)
+		}
+		if first {
+			<-ctx.Done() // hangs, beating no more, until cancelled
+			return ctx.Err()
+		}
+		return nil
+	})
+	if err := s.Run(context.Background(), child); err != nil {
+		t.Fatal(err)
+	}
+	if len(events) == 0 || !errors.Is(events[0].Err, ErrSuspected) {
+		t.Errorf("restart events %v, want the first for ErrSuspected", events)
+	}
+}
+
+func TestConvictAboveThresholdLetsSuspectsRecover(t *testing.T) {
+	var suspects, restarts atomic.Int32
+	m := &Monitor{
+		Interval: 10 * time.Millisecond, Threshold: 3, Convict: 1000,
+		OnChange: func(e HealthEvent) {
+			if e.Suspect {
+				suspects.Add(1)
+			}
+		},
+	}
+	s := Supervisor{Backoff: noBackoff, OnRestart: func(Event) { restarts.Add(1) }}
+	child := m.Watch("worker", func(ctx context.Context, beat func()) error {
+		beat()
+		time.Sleep(40 * time.Millisecond) // a long pause, not a hang
+		beat()
+		return nil
+	})
+	if err := s.Run(context.Background(), child); err != nil {
+		t.Fatal(err)
+	}
+	if suspects.Load() == 0 || restarts.Load() != 0 {
+		t.Errorf("%d suspicions and %d restarts, want some and none", suspects.Load(), restarts.Load())
+	}
+}
@@ -0,0 +1,64 @@
+package supervise_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lotusirous/gochan/clock"
+	"github.com/lotusirous/gochan/supervise"
+)
+
+func ExampleSupervisor() {
+	s := &supervise.Supervisor{
+		Backoff: func(int) time.Duration { return 0 }, // DefaultBackoff in production
+		OnRestart: func(e supervise.Event) {
+			fmt.Printf("restart %s #%d: %v\n", e.Name, e.Restarts, e.Err)
+		},
+	}
+	attempts := 0
+	err := s.Run(context.Background(), supervise.Spec{Name: "consumer", Run: func(context.Context) error {
+		if attempts++; attempts < 3 {
+			return errors.New("broker unreachable")
+		}
+		return nil // done for good
+	}})
+	fmt.Println(err)
+	// Output:
+	// restart consumer #1: broker unreachable
+	// restart consumer #2: broker unreachable
+	// <nil>
+}
+
+func ExampleMonitor() {
+	ctx, cancel := context.WithCancel(context.Background())
+	clk := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	m := &supervise.Monitor{
+		Interval: 100 * time.Millisecond,
+		Clock:    clk,
+		OnChange: func(e supervise.HealthEvent) {
+			fmt.Printf("%s suspect=%t phi=%.1f\n", e.Name, e.Suspect, e.Phi)
+		},
+	}
+	beats := make(chan time.Time)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		m.Follow(ctx, "worker", beats)
+	}()
+
+	// The worker goes quiet; phi is checked every quarter interval.
+	for range 10 {
+		clk.BlockUntil(1)
+		clk.Advance(25 * time.Millisecond)
+	}
+	clk.BlockUntil(1)
+	beats <- clk.Now()
+
+	cancel()
+	<-done
+	// Output:
+	// worker suspect=true phi=9.0
+	// worker suspect=false phi=9.0
+}
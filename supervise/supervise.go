@@ -0,0 +1,312 @@
+// Package supervise keeps long-running goroutines alive.
+//
+// A supervisor starts every child described by a Spec and watches it. When a
+// child returns an error or panics, the supervisor waits for a backoff delay
+// and starts it again. A child that returns nil is considered finished and is
+// not restarted.
+//
+// Three strategies are supported:
+//   - OneForOne restarts only the child that failed.
+//   - OneForAll cancels every sibling and restarts the whole group, which is
+//     useful when children depend on each other's state.
+//   - Escalate restarts nothing: it stops the group and returns the failure
+//     to whoever runs the supervisor.
+//
+// As in Erlang/OTP, a supervisor that restarts more than MaxRestarts times
+// within Window gives up, stops its children and returns an error. Spec turns
+// a supervisor into the child of another one, so supervisors form a tree and
+// a failure that one level cannot fix escalates to the level above.
+//
+// A child that hangs instead of failing is caught by a Monitor, a phi
+// accrual failure detector fed by the child's heartbeats. It reports
+// children whose silence grows suspicious and fails those it convicts, so
+// the supervisor restarts them like any other failure.
+package supervise
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/lotusirous/gochan/clock"
+	"github.com/lotusirous/gochan/safego"
+)
+
+// Strategy decides which children are restarted after a failure.
+type Strategy int
+
+const (
+	// OneForOne restarts only the failed child.
+	OneForOne Strategy = iota
+	// OneForAll stops all children and restarts them together.
+	OneForAll
+	// Escalate stops all children on the first failure and returns it.
+	Escalate
+)
+
+// ErrTooManyRestarts is returned, wrapping the last failure, when a
+// supervisor exceeds its restart intensity.
+var ErrTooManyRestarts = errors.New("supervise: too many restarts")
+
+func (s Strategy) String() string {
+	switch s {
+	case OneForOne:
+		return "one-for-one"
+	case OneForAll:
+		return "one-for-all"
+	case Escalate:
+		return "escalate"
+	}
+	return fmt.Sprintf("Strategy(%d)", int(s))
+}
+
+// Spec describes a supervised child.
+type Spec struct {
+	// Name identifies the child in errors and events.
+	Name string
+	// Run is the body of the child. It must return when ctx is done.
+	Run func(ctx context.Context) error
+}
+
+// Event is reported every time a child fails and is about to be restarted.
+type Event struct {
+	Name string // child that failed
+	Err  error  // error returned by the child (or its panic)
+	// Restarts counts consecutive failures so far, starting at 1. A run
+	// that lasted longer than Window was healthy and starts the count
+	// again.
+	Restarts int
+	Delay    time.Duration // backoff before the restart
+}
+
+// Supervisor restarts failed children according to Strategy.
+// The zero value is a one-for-one supervisor with DefaultBackoff.
+type Supervisor struct {
+	Strategy Strategy
+
+	// Backoff returns how long to wait before the n-th consecutive restart
+	// (n starts at 1, as in Event.Restarts). If nil, DefaultBackoff is used.
+	Backoff func(n int) time.Duration
+
+	// OnRestart, if set, is called before every restart. With OneForOne it
+	// may be called from several goroutines at once.
+	OnRestart func(Event)
+
+	// MaxRestarts and Window bound the restart intensity: the restart that
+	// would be the (MaxRestarts+1)-th within Window makes the supervisor give
+	// up instead. Zero MaxRestarts restarts forever; zero Window means 5s.
+	// A run that lasts longer than Window also resets the backoff.
+	MaxRestarts int
+	Window      time.Duration
+
+	// Clock tells time for the backoff, the restart intensity and the
+	// length of runs. If nil, it is clock.Real.
+	Clock clock.Clock
+
+	mu       sync.Mutex
+	restarts []time.Time // recent restarts, oldest first
+}
+
+// DefaultBackoff doubles the delay on every consecutive failure, starting at
+// 10ms and capping at 5s.
+func DefaultBackoff(n int) time.Duration {
+	const (
+		base = 10 * time.Millisecond
+		max  = 5 * time.Second
+	)
+	d := base
+	for i := 1; i < n; i++ {
+		d *= 2
+		if d >= max {
+			return max
+		}
+	}
+	return d
+}
+
+// Run supervises specs with a zero Supervisor (one-for-one, default backoff).
+func Run(ctx context.Context, specs ...Spec) error {
+	var s Supervisor
+	return s.Run(ctx, specs...)
+}
+
+// Run starts every child and blocks until all of them have finished
+// successfully, ctx is done or the supervisor gives up. It returns nil when
+// every child returned nil and ctx.Err() when canceled. Giving up returns an
+// error wrapping ErrTooManyRestarts or, with Escalate, the child's failure.
+func (s *Supervisor) Run(ctx context.Context, specs ...Spec) error {
+	// Like a restarted Erlang supervisor, every Run starts with a clean
+	// restart history.
+	s.mu.Lock()
+	s.restarts = nil
+	s.mu.Unlock()
+
+	if s.Strategy == OneForAll {
+		return s.runOneForAll(ctx, specs)
+	}
+	return s.runOneForOne(ctx, specs)
+}
+
+// Spec returns a Spec that runs s over specs, making s the child of another
+// supervisor. When s gives up, its error becomes the child's failure and the
+// parent's strategy decides what happens next.
+func (s *Supervisor) Spec(name string, specs ...Spec) Spec {
+	return Spec{Name: name, Run: func(ctx context.Context) error { return s.Run(ctx, specs...) }}
+}
+
+func (s *Supervisor) clock() clock.Clock {
+	if s.Clock == nil {
+		return clock.Real
+	}
+	return s.Clock
+}
+
+func (s *Supervisor) window() time.Duration {
+	if s.Window <= 0 {
+		return 5 * time.Second
+	}
+	return s.Window
+}
+
+// consecutive returns the count of consecutive failures after a run that
+// began at started and failed, given the count n before it.
+func (s *Supervisor) consecutive(n int, started time.Time) int {
+	if s.clock().Now().Sub(started) > s.window() {
+		return 1
+	}
+	return n + 1
+}
+
+// runOneForOne also implements Escalate, which is OneForOne without restarts.
+func (s *Supervisor) runOneForOne(parent context.Context, specs []Spec) error {
+	ctx, cancel := context.WithCancelCause(parent)
+	defer cancel(nil)
+
+	var wg sync.WaitGroup
+	for _, spec := range specs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := 0; ; {
+				started := s.clock().Now()
+				err := call(ctx, spec)
+				if err == nil || ctx.Err() != nil {
+					return
+				}
+				n = s.consecutive(n, started)
+				if s.Strategy == Escalate {
+					cancel(fmt.Errorf("supervise: %s failed: %w", spec.Name, err))
+					return
+				}
+				if err := s.restart(ctx, spec.Name, err, n); err != nil {
+					cancel(err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if parent.Err() != nil {
+		return parent.Err()
+	}
+	return context.Cause(ctx)
+}
+
+func (s *Supervisor) runOneForAll(ctx context.Context, specs []Spec) error {
+	for n := 0; ; {
+		started := s.clock().Now()
+		gctx, cancel := context.WithCancel(ctx)
+		type exit struct {
+			name string
+			err  error
+		}
+		exits := make(chan exit, len(specs))
+		for _, spec := range specs {
+			go func() { exits <- exit{spec.Name, call(gctx, spec)} }()
+		}
+
+		// The first failure brings the whole group down. Errors returned by
+		// siblings after that are only a consequence of the cancellation.
+		var failed *exit
+		for range specs {
+			e := <-exits
+			if e.err != nil && failed == nil && gctx.Err() == nil {
+				failed = &e
+				cancel()
+			}
+		}
+		cancel()
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if failed == nil {
+			return nil
+		}
+		n = s.consecutive(n, started)
+		if err := s.restart(ctx, failed.name, failed.err, n); err != nil {
+			return err
+		}
+	}
+}
+
+// restart checks the restart intensity, reports the failure and waits for
+// the backoff delay. It returns an error if the supervisor has to give up or
+// ctx was canceled while waiting.
+func (s *Supervisor) restart(ctx context.Context, name string, err error, n int) error {
+	if !s.allowRestart(s.clock().Now()) {
+		return fmt.Errorf("%w: %s: %w", ErrTooManyRestarts, name, err)
+	}
+	backoff := s.Backoff
+	if backoff == nil {
+		backoff = DefaultBackoff
+	}
+	delay := backoff(n)
+	if s.OnRestart != nil {
+		s.OnRestart(Event{Name: name, Err: err, Restarts: n, Delay: delay})
+	}
+
+	t := s.clock().NewTimer(delay)
+	defer t.Stop()
+	select {
+	case <-t.C():
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// allowRestart records a restart at now, unless it would exceed the restart
+// intensity.
+func (s *Supervisor) allowRestart(now time.Time) bool {
+	if s.MaxRestarts <= 0 {
+		return true
+	}
+	window := s.window()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := 0
+	for i < len(s.restarts) && now.Sub(s.restarts[i]) >= window {
+		i++
+	}
+	s.restarts = s.restarts[i:]
+	if len(s.restarts) >= s.MaxRestarts {
+		return false
+	}
+	s.restarts = append(s.restarts, now)
+	return true
+}
+
+// call runs the child and converts a panic into an error so a single
+// misbehaving child cannot crash the whole program. The error wraps a
+// *safego.PanicError that carries the child's stack.
+func call(ctx context.Context, spec Spec) error {
+	err := safego.Do(func() error { return spec.Run(ctx) })
+	if pe, ok := err.(*safego.PanicError); ok {
+		return fmt.Errorf("supervise: %s panicked: %w", spec.Name, pe)
+	}
+	return err
+}
@@ -0,0 +1,306 @@
+package supervise
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lotusirous/gochan/clock"
+	"github.com/lotusirous/gochan/safego"
+)
+
+func noBackoff(int) time.Duration { return 0 }
+
+// flaky fails the first n runs and then returns nil.
+func flaky(n int32, runs *atomic.Int32) func(context.Context) error {
+	return func(ctx context.Context) error {
+		if runs.Add(1) <= n {
+			return errors.New("injected failure")
+		}
+		return nil
+	}
+}
+
+func TestOneForOneRestartsFailedChild(t *testing.T) {
+	var runs atomic.Int32
+	var events []Event
+	s := Supervisor{
+		Backoff:   noBackoff,
+		OnRestart: func(e Event) { events = append(events, e) },
+	}
+
+	err := s.Run(context.Background(), Spec{Name: "flaky", Run: flaky(3, &runs)})
+	if err != nil {
+		t.Fatalf("Run returned %v, want nil", err)
+	}
+	if got := runs.Load(); got != 4 {
+		t.Errorf("child ran %d times, want 4", got)
+	}
+	if len(events) != 3 {
+		t.Fatalf("got %d restart events, want 3", len(events))
+	}
+	for i, e := range events {
+		if e.Name != "flaky" || e.Restarts != i+1 {
+			t.Errorf("event %d = %+v", i, e)
+		}
+	}
+}
+
+func TestOneForOneRecoversPanics(t *testing.T) {
+	var runs atomic.Int32
+	var mu sync.Mutex
+	var errs []error
+	s := Supervisor{
+		Backoff: noBackoff,
+		OnRestart: func(e Event) {
+			mu.Lock()
+			errs = append(errs, e.Err)
+			mu.Unlock()
+		},
+	}
+
+	err := s.Run(context.Background(), Spec{Name: "panicky", Run: func(ctx context.Context) error {
+		if runs.Add(1) == 1 {
+			panic("boom")
+		}
+		return nil
+	}})
+	if err != nil {
+		t.Fatalf("Run returned %v, want nil", err)
+	}
+	if len(errs) != 1 || errs[0] == nil {
+		t.Fatalf("expected one panic error, got %v", errs)
+	}
+	var pe *safego.PanicError
+	if !errors.As(errs[0], &pe) || len(pe.Stack) == 0 {
+		t.Errorf("panic error %v does not carry a stack", errs[0])
+	}
+}
+
+func TestOneForOneLeavesSiblingsAlone(t *testing.T) {
+	var flakyRuns, steadyRuns atomic.Int32
+	s := Supervisor{Backoff: noBackoff}
+
+	err := s.Run(context.Background(),
+		Spec{Name: "flaky", Run: flaky(5, &flakyRuns)},
+		Spec{Name: "steady", Run: func(ctx context.Context) error {
+			steadyRuns.Add(1)
+			return nil
+		}},
+	)
+	if err != nil {
+		t.Fatalf("Run returned %v, want nil", err)
+	}
+	if got := steadyRuns.Load(); got != 1 {
+		t.Errorf("steady child ran %d times, want 1", got)
+	}
+}
+
+func TestOneForAllRestartsEveryChild(t *testing.T) {
+	var flakyRuns, siblingRuns atomic.Int32
+	s := Supervisor{Strategy: OneForAll, Backoff: noBackoff}
+
+	err := s.Run(context.Background(),
+		Spec{Name: "flaky", Run: flaky(2, &flakyRuns)},
+		Spec{Name: "sibling", Run: func(ctx context.Context) error {
+			siblingRuns.Add(1)
+			// Stay up until the group is either canceled or allowed to finish.
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(20 * time.Millisecond):
+				return nil
+			}
+		}},
+	)
+	if err != nil {
+		t.Fatalf("Run returned %v, want nil", err)
+	}
+	if got := siblingRuns.Load(); got != 3 {
+		t.Errorf("sibling ran %d times, want 3", got)
+	}
+}
+
+func TestRunStopsOnCancel(t *testing.T) {
+	for _, strategy := range []Strategy{OneForOne, OneForAll} {
+		t.Run(strategy.String(), func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+
+			s := Supervisor{Strategy: strategy, Backoff: func(int) time.Duration { return time.Millisecond }}
+			err := s.Run(ctx, Spec{Name: "always-fails", Run: func(ctx context.Context) error {
+				return errors.New("injected failure")
+			}})
+			if !errors.Is(err, context.DeadlineExceeded) {
+				t.Fatalf("Run returned %v, want deadline exceeded", err)
+			}
+		})
+	}
+}
+
+func TestDefaultBackoff(t *testing.T) {
+	if got := DefaultBackoff(1); got != 10*time.Millisecond {
+		t.Errorf("DefaultBackoff(1) = %v", got)
+	}
+	if got := DefaultBackoff(3); got != 40*time.Millisecond {
+		t.Errorf("DefaultBackoff(3) = %v", got)
+	}
+	if got := DefaultBackoff(100); got != 5*time.Second {
+		t.Errorf("DefaultBackoff(100) = %v", got)
+	}
+}
+
+// crasher fails every run, counting them.
+func crasher(runs *atomic.Int32) func(context.Context) error {
+	return func(ctx context.Context) error {
+		runs.Add(1)
+		return errors.New("crash")
+	}
+}
+
+// blocker runs until canceled, counting its starts and how many are live.
+func blocker(starts, live *atomic.Int32) func(context.Context) error {
+	return func(ctx context.Context) error {
+		starts.Add(1)
+		live.Add(1)
+		defer live.Add(-1)
+		<-ctx.Done()
+		return ctx.Err()
+	}
+}
+
+func TestCrashStormExceedsIntensity(t *testing.T) {
+	for _, strategy := range []Strategy{OneForOne, OneForAll} {
+		t.Run(strategy.String(), func(t *testing.T) {
+			var crashes, starts, live atomic.Int32
+			s := Supervisor{Strategy: strategy, Backoff: noBackoff, MaxRestarts: 10, Window: time.Hour}
+
+			specs := []Spec{{Name: "steady", Run: blocker(&starts, &live)}}
+			for range 5 {
+				specs = append(specs, Spec{Name: "crasher", Run: crasher(&crashes)})
+			}
+			err := s.Run(context.Background(), specs...)
+			if !errors.Is(err, ErrTooManyRestarts) {
+				t.Fatalf("Run returned %v, want ErrTooManyRestarts", err)
+			}
+			if live.Load() != 0 {
+				t.Errorf("%d children still running after giving up", live.Load())
+			}
+			if strategy == OneForOne && starts.Load() != 1 {
+				t.Errorf("steady child started %d times, want 1", starts.Load())
+			}
+			if strategy == OneForAll && starts.Load() != 11 {
+				t.Errorf("steady child started %d times, want 11", starts.Load())
+			}
+		})
+	}
+}
+
+func TestIntensityWindowForgetsOldRestarts(t *testing.T) {
+	var runs atomic.Int32
+	s := Supervisor{
+		Backoff:     func(int) time.Duration { return 10 * time.Millisecond },
+		MaxRestarts: 1,
+		Window:      5 * time.Millisecond,
+	}
+	// Five failures, but never two within the window.
+	if err := s.Run(context.Background(), Spec{Name: "flaky", Run: flaky(5, &runs)}); err != nil {
+		t.Fatalf("Run returned %v, want nil", err)
+	}
+	if runs.Load() != 6 {
+		t.Errorf("child ran %d times, want 6", runs.Load())
+	}
+}
+
+func TestHealthyRunResetsBackoff(t *testing.T) {
+	for _, strategy := range []Strategy{OneForOne, OneForAll} {
+		t.Run(strategy.String(), func(t *testing.T) {
+			clk := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+			var runs atomic.Int32
+			var events []Event
+			s := Supervisor{
+				Strategy:  strategy,
+				Clock:     clk,
+				OnRestart: func(e Event) { events = append(events, e) },
+			}
+			done := make(chan error)
+			go func() {
+				done <- s.Run(context.Background(), Spec{Name: "daily", Run: func(ctx context.Context) error {
+					switch runs.Add(1) {
+					case 3:
+						clk.Sleep(time.Hour) // a healthy run, longer than Window
+					case 4:
+						return nil
+					}
+					return errors.New("injected failure")
+				}})
+			}()
+			// Wake the backoffs after runs 1 and 2, the healthy run 3 and
+			// the backoff after it.
+			for _, d := range []time.Duration{10 * time.Millisecond, 20 * time.Millisecond, time.Hour, 10 * time.Millisecond} {
+				clk.BlockUntil(1)
+				clk.Advance(d)
+			}
+			if err := <-done; err != nil {
+				t.Fatalf("Run returned %v, want nil", err)
+			}
+			var got []int
+			for _, e := range events {
+				got = append(got, e.Restarts)
+			}
+			if want := []int{1, 2, 1}; !slices.Equal(got, want) {
+				t.Errorf("Restarts %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestEscalateStopsSiblings(t *testing.T) {
+	var crashes, starts, live atomic.Int32
+	s := Supervisor{Strategy: Escalate}
+	err := s.Run(context.Background(),
+		Spec{Name: "steady", Run: blocker(&starts, &live)},
+		Spec{Name: "crasher", Run: crasher(&crashes)},
+	)
+	if err == nil || err.Error() != "supervise: crasher failed: crash" {
+		t.Fatalf("Run returned %v", err)
+	}
+	if crashes.Load() != 1 || live.Load() != 0 {
+		t.Errorf("crashes = %d, live = %d; want 1 and 0", crashes.Load(), live.Load())
+	}
+}
+
+// TestTreeEscalation builds root -> group -> {steady, crasher}. The group
+// gives up after 2 restarts, the root restarts the group twice and then gives
+// up too, so the crash storm takes 3 group runs of 3 crashes each.
+func TestTreeEscalation(t *testing.T) {
+	var crashes, starts, live atomic.Int32
+	var rootEvents []Event
+	group := &Supervisor{Backoff: noBackoff, MaxRestarts: 2, Window: time.Hour}
+	root := Supervisor{
+		Backoff:     noBackoff,
+		MaxRestarts: 2,
+		Window:      time.Hour,
+		OnRestart:   func(e Event) { rootEvents = append(rootEvents, e) },
+	}
+
+	err := root.Run(context.Background(), group.Spec("group",
+		Spec{Name: "steady", Run: blocker(&starts, &live)},
+		Spec{Name: "crasher", Run: crasher(&crashes)},
+	))
+	if !errors.Is(err, ErrTooManyRestarts) || !strings.Contains(err.Error(), "group") {
+		t.Fatalf("Run returned %v, want the group to exhaust the root", err)
+	}
+	if crashes.Load() != 9 || starts.Load() != 3 || live.Load() != 0 {
+		t.Errorf("crashes = %d, starts = %d, live = %d; want 9, 3, 0",
+			crashes.Load(), starts.Load(), live.Load())
+	}
+	if len(rootEvents) != 2 || rootEvents[0].Name != "group" || !errors.Is(rootEvents[0].Err, ErrTooManyRestarts) {
+		t.Errorf("root events = %+v", rootEvents)
+	}
+}
@@ -0,0 +1,203 @@
+package teach
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"runtime"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// replayGrace is how long after the end of a recording a replay keeps
+// holding back the sends that did not happen in it.
+const replayGrace = time.Second
+
+// replay is a recorded run whose sends are being reproduced, loaded by
+// Start when the runner replays one.
+var replay struct {
+	sync.Mutex
+	cond  *sync.Cond
+	start time.Time
+	end   time.Duration  // when the recording ended
+	sends []recordedSend // in the order they happened
+}
+
+type recordedSend struct {
+	name, text    string
+	at            time.Duration // since the recording began
+	claimed, done bool
+}
+
+// Send sends v on ch and records it as a send on the named channel. It
+// reports false, without sending, if ctx is done first. When the runner
+// replays a recording, Send first waits for the moment the same message
+// was sent in the recorded run and for the sends before it on the same
+// channel, so messages arrive in the recorded order and with the recorded
+// timing.
+func Send[T any](ctx context.Context, name string, ch chan<- T, v T) bool {
+	replaying := replayed()
+	var i int
+	if replaying {
+		i = waitTurn(name, fmt.Sprint(v))
+	}
+	select {
+	case ch <- v:
+	case <-ctx.Done():
+	}
+	if replaying {
+		sent(i) // even if given up, so later sends do not wait for it
+	}
+	if ctx.Err() != nil {
+		return false
+	}
+	if Enabled() {
+		emitWait(Event{Kind: KindSend, Name: name, Text: fmt.Sprint(v), G: goid()})
+	}
+	return true
+}
+
+// Recv receives from ch and records it as a receive on the named channel.
+// ok is false if ch is closed or ctx is done first.
+func Recv[T any](ctx context.Context, name string, ch <-chan T) (v T, ok bool) {
+	select {
+	case v, ok = <-ch:
+	case <-ctx.Done():
+		return v, false
+	}
+	if ok && Enabled() {
+		emitWait(Event{Kind: KindRecv, Name: name, Text: fmt.Sprint(v), G: goid()})
+	}
+	return v, ok
+}
+
+// Sleep is time.Sleep, except during a replay: then the recorded timing
+// of the sends decides the pace, so Sleep returns at once. Examples pace
+// their instrumented sends with it.
+func Sleep(d time.Duration) {
+	if !replayed() {
+		time.Sleep(d)
+	}
+}
+
+func replayed() bool {
+	replay.Lock()
+	defer replay.Unlock()
+	return replay.cond != nil
+}
+
+// waitTurn claims the first recorded send of text on the named channel
+// and waits until it is due. It returns the send's index, or -1 if the
+// recording does not have it; such a send never completed in the recorded
+// run, so it waits until replayGrace after the recording ends. If the
+// replay strays from the recording so that an earlier send never comes,
+// waiting ends then too.
+//
+// Only sends on the same channel wait for each other. The recorded order
+// of sends on different channels is the order their events were emitted,
+// which can invert a send and the one it caused.
+func waitTurn(name, text string) int {
+	replay.Lock()
+	defer replay.Unlock()
+	end := replay.start.Add(replay.end + replayGrace)
+	i := slices.IndexFunc(replay.sends, func(s recordedSend) bool {
+		return !s.claimed && s.name == name && s.text == text
+	})
+	if i < 0 {
+		replay.Unlock()
+		time.Sleep(time.Until(end))
+		replay.Lock()
+		return -1
+	}
+	replay.sends[i].claimed = true
+
+	replay.Unlock()
+	time.Sleep(time.Until(replay.start.Add(replay.sends[i].at)))
+	replay.Lock()
+	wake := time.AfterFunc(time.Until(end), func() {
+		replay.Lock()
+		defer replay.Unlock()
+		replay.cond.Broadcast()
+	})
+	defer wake.Stop()
+	for earlierPending(i) && time.Now().Before(end) {
+		replay.cond.Wait()
+	}
+	return i
+}
+
+// earlierPending reports whether a send before i on the same channel has
+// not happened yet.
+func earlierPending(i int) bool {
+	return slices.ContainsFunc(replay.sends[:i], func(s recordedSend) bool {
+		return s.name == replay.sends[i].name && !s.done
+	})
+}
+
+// sent marks send i of the recording done.
+func sent(i int) {
+	if i < 0 {
+		return
+	}
+	replay.Lock()
+	defer replay.Unlock()
+	replay.sends[i].done = true
+	replay.cond.Broadcast()
+}
+
+// loadReplay reads the sends of a recording, a file of JSON events as
+// written by the runner's -record flag. The first event marks the start.
+func loadReplay(file string) {
+	if file == "" {
+		return
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "teach:", err)
+		return
+	}
+	defer f.Close()
+	var first, last time.Time
+	var sends []recordedSend
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		var e Event
+		if err := json.Unmarshal(s.Bytes(), &e); err != nil {
+			fmt.Fprintln(os.Stderr, "teach: replay:", err)
+			return
+		}
+		if first.IsZero() {
+			first = e.Time
+		}
+		last = e.Time
+		if e.Kind == KindSend {
+			sends = append(sends, recordedSend{name: e.Name, text: e.Text, at: e.Time.Sub(first)})
+		}
+	}
+	replay.Lock()
+	replay.cond = sync.NewCond(&replay.Mutex)
+	replay.start, replay.sends, replay.end = time.Now(), sends, last.Sub(first)
+	replay.Unlock()
+}
+
+func endReplay() {
+	replay.Lock()
+	defer replay.Unlock()
+	replay.cond, replay.sends = nil, nil
+}
+
+// goid returns the runtime's ID of the calling goroutine, read from the
+// first line of its stack trace, "goroutine 18 [running]:".
+func goid() int64 {
+	var buf [64]byte
+	b := buf[:runtime.Stack(buf[:], false)]
+	b = bytes.TrimPrefix(b, []byte("goroutine "))
+	b, _, _ = bytes.Cut(b, []byte(" "))
+	id, _ := strconv.ParseInt(string(b), 10, 64)
+	return id
+}
@@ -0,0 +1,110 @@
+package teach
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSendRecvEvents(t *testing.T) {
+	events := listen(t)
+	stop := Start()
+	ch := make(chan int)
+	go Send(context.Background(), "numbers", ch, 42)
+	if v, ok := Recv(context.Background(), "numbers", ch); v != 42 || !ok {
+		t.Fatalf("Recv = %d, %v", v, ok)
+	}
+	stop()
+
+	got := make(map[string]Event)
+	for e := range events {
+		got[e.Kind] = e
+	}
+	send, recv := got[KindSend], got[KindRecv]
+	if send.Name != "numbers" || send.Text != "42" || recv.Name != "numbers" || recv.Text != "42" {
+		t.Errorf("send = %+v, recv = %+v", send, recv)
+	}
+	if send.G == 0 || recv.G == 0 || send.G == recv.G {
+		t.Errorf("goroutines %d and %d, want two different IDs", send.G, recv.G)
+	}
+}
+
+func TestSendRecvGiveUpWhenDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	ch := make(chan int) // nobody on the other side
+	if Send(ctx, "numbers", ch, 42) {
+		t.Error("Send reported a send nobody received")
+	}
+	if _, ok := Recv(ctx, "numbers", ch); ok {
+		t.Error("Recv reported a value nobody sent")
+	}
+}
+
+// record writes a recording of sends on channel c, each at its offset.
+func record(t *testing.T, sends ...recordedSend) string {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), "run.rec")
+	f, err := os.Create(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc := json.NewEncoder(f)
+	start := time.Now()
+	enc.Encode(Event{Time: start, Kind: KindGoroutines, Value: 1})
+	for _, s := range sends {
+		enc.Encode(Event{Time: start.Add(s.at), Kind: KindSend, Name: "c", Text: s.text})
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func TestReplayReproducesOrderAndTiming(t *testing.T) {
+	t.Setenv(EnvEvents, "")
+	t.Setenv(EnvReplay, record(t,
+		recordedSend{text: "b", at: 0},
+		recordedSend{text: "a", at: 30 * time.Millisecond},
+		recordedSend{text: "c", at: 60 * time.Millisecond},
+	))
+	stop := Start()
+	defer stop()
+
+	start := time.Now()
+	ch := make(chan string)
+	var wg sync.WaitGroup
+	for _, v := range []string{"a", "c", "b"} { // all try to go at once
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			Sleep(time.Hour) // returns at once while replaying
+			Send(context.Background(), "c", ch, v)
+		}()
+		runtime.Gosched()
+	}
+	var got []string
+	for range 3 {
+		got = append(got, <-ch)
+	}
+	wg.Wait()
+	if got[0] != "b" || got[1] != "a" || got[2] != "c" {
+		t.Errorf("received %v, want the recorded order [b a c]", got)
+	}
+	if d := time.Since(start); d < 60*time.Millisecond {
+		t.Errorf("replay took %v, want the recorded 60ms", d)
+	}
+}
+
+func TestGoid(t *testing.T) {
+	ids := make(chan int64, 1)
+	go func() { ids <- goid() }()
+	if a, b := goid(), <-ids; a == 0 || b == 0 || a == b {
+		t.Errorf("goid = %d and %d", a, b)
+	}
+}
@@ -0,0 +1,58 @@
+package teach
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+// explaining reports whether the runner asked for explain mode.
+var explaining = sync.OnceValue(func() bool { return os.Getenv(EnvExplain) != "" })
+
+// stdout is where Printf and Println write.
+var stdout io.Writer = os.Stdout
+
+type roleKey struct{}
+
+// As returns a context naming the goroutine that uses it by its role,
+// numbered when several goroutines share one: "producer-2", "worker-5",
+// "merger". The name is chosen by the example, so it is the same on every
+// run, unlike the runtime's goroutine IDs.
+func As(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, roleKey{}, name)
+}
+
+// Role returns the name given to ctx by As, or "" if there is none.
+func Role(ctx context.Context) string {
+	name, _ := ctx.Value(roleKey{}).(string)
+	return name
+}
+
+// Printf is fmt.Printf, except in explain mode: then the line starts with
+// the role of ctx, such as "[worker-5] ", so interleaved output shows which
+// goroutine printed what. The line also goes to the runner's event log.
+func Printf(ctx context.Context, format string, args ...any) {
+	printAs(ctx, fmt.Sprintf(format, args...))
+}
+
+// Println is fmt.Println with the role of ctx in front in explain mode.
+func Println(ctx context.Context, args ...any) {
+	printAs(ctx, fmt.Sprintln(args...))
+}
+
+func printAs(ctx context.Context, s string) {
+	if explaining() {
+		name := Role(ctx)
+		if name == "" {
+			name = "?"
+		}
+		s = "[" + name + "] " + s
+	}
+	fmt.Fprint(stdout, s)
+	if Enabled() {
+		Emit(Event{Kind: KindLog, Text: strings.TrimSuffix(s, "\n")})
+	}
+}
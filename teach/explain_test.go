@@ -0,0 +1,48 @@
+package teach
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+// explain switches explain mode and captures what Printf writes.
+func explain(t *testing.T, on bool) *bytes.Buffer {
+	t.Helper()
+	var out bytes.Buffer
+	oldExplaining, oldStdout := explaining, stdout
+	explaining, stdout = func() bool { return on }, &out
+	t.Cleanup(func() { explaining, stdout = oldExplaining, oldStdout })
+	return &out
+}
+
+func TestPrintfNamesTheRole(t *testing.T) {
+	out := explain(t, true)
+	ctx := As(context.Background(), "worker-5")
+	Printf(ctx, "started job %d\n", 3)
+	Println(context.Background(), "no role")
+	if want := "[worker-5] started job 3\n[?] no role\n"; out.String() != want {
+		t.Errorf("printed %q, want %q", out.String(), want)
+	}
+}
+
+func TestPrintfWithoutExplain(t *testing.T) {
+	out := explain(t, false)
+	Println(As(context.Background(), "merger"), "job", 1)
+	if want := "job 1\n"; out.String() != want {
+		t.Errorf("printed %q, want %q", out.String(), want)
+	}
+}
+
+func TestRole(t *testing.T) {
+	ctx := As(context.Background(), "producer-2")
+	if r := Role(As(ctx, "merger")); r != "merger" {
+		t.Errorf("Role = %q after renaming", r)
+	}
+	if r := Role(ctx); r != "producer-2" {
+		t.Errorf("Role = %q", r)
+	}
+	if r := Role(context.Background()); r != "" {
+		t.Errorf("Role of a plain context = %q", r)
+	}
+}
@@ -0,0 +1,330 @@
+// Package teach lets the patterns runner watch an example while it runs.
+//
+// An example opts in with one line at the top of main:
+//
+//	defer teach.Start()()
+//
+// When the runner starts the example it passes the address of its event
+// listener in the PATTERNS_EVENTS environment variable. Start connects to
+// it and from then on the hooks in this package stream Events there: the
+// goroutine count and the fill level of watched channels every
+// SampleInterval, plus whatever the example logs or counts. When it stops
+// it reports how long it ran and how much it allocated. Without the
+// runner every hook is a cheap no-op, so an instrumented example runs
+// exactly as before.
+//
+// If PATTERNS_TRACE names a file, Start also records an execution trace
+// there for "go tool trace". Examples mark their work with runtime/trace
+// tasks and regions so the trace shows named pattern activity.
+//
+// If PATTERNS_STEP is set as well, the connection also carries replies:
+// every Pause waits for the runner to send a line saying Resume, which
+// turns an example into a guided walkthrough.
+package teach
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"runtime"
+	"runtime/trace"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Environment variables set by the runner.
+const (
+	EnvEvents  = "PATTERNS_EVENTS"  // address of the event listener
+	EnvTrace   = "PATTERNS_TRACE"   // file to write an execution trace to
+	EnvStep    = "PATTERNS_STEP"    // set when Pause should wait for the runner
+	EnvReplay  = "PATTERNS_REPLAY"  // recording whose send timing to reproduce
+	EnvExplain = "PATTERNS_EXPLAIN" // set when Printf should name goroutines
+)
+
+// Resume is the line the runner sends to let one paused goroutine go on.
+const Resume = "continue"
+
+// SampleInterval is how often goroutines and channels are sampled.
+const SampleInterval = 100 * time.Millisecond
+
+// Event kinds.
+const (
+	KindGoroutines = "goroutines" // Value: runtime.NumGoroutine
+	KindChan       = "chan"       // Name, Value: len, Cap: cap
+	KindStat       = "stat"       // Name, Value
+	KindLog        = "log"        // Text
+	KindLayout     = "layout"     // Text: participants, one per line
+	KindPass       = "pass"       // Name: from, To: to, Text: what moved
+	KindPause      = "pause"      // Text: label
+	KindExit       = "exit"       // Name: elapsed (seconds), allocs or bytes; Value
+	KindSend       = "send"       // Name: channel, Text: value, G: goroutine
+	KindRecv       = "recv"       // Name: channel, Text: value, G: goroutine
+)
+
+// Event is one observation sent to the runner, as a line of JSON.
+type Event struct {
+	Time  time.Time `json:"time"`
+	Kind  string    `json:"kind"`
+	Name  string    `json:"name,omitempty"`
+	To    string    `json:"to,omitempty"`
+	Value float64   `json:"value,omitempty"`
+	Cap   int       `json:"cap,omitempty"`
+	Text  string    `json:"text,omitempty"`
+	G     int64     `json:"g,omitempty"`
+}
+
+// session is the connection to the runner, nil when there is none.
+var session struct {
+	sync.Mutex
+	events chan Event
+	gauges map[string]func() (n, c int)
+	resume chan struct{} // nil unless stepping; closed on disconnect
+	// waiting counts the emitWait calls sending outside the lock, which
+	// must be done before events is closed.
+	waiting sync.WaitGroup
+}
+
+// Start connects to the runner if the example was started by one, and
+// returns a function that flushes the pending events and disconnects.
+func Start() (stop func()) {
+	stopTrace := startTrace(os.Getenv(EnvTrace))
+	loadReplay(os.Getenv(EnvReplay))
+	stopEvents := startEvents(os.Getenv(EnvEvents))
+	return func() {
+		stopEvents()
+		stopTrace()
+		endReplay()
+	}
+}
+
+func startTrace(file string) (stop func()) {
+	if file == "" {
+		return func() {}
+	}
+	f, err := os.Create(file)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "teach:", err)
+		return func() {}
+	}
+	if err := trace.Start(f); err != nil {
+		fmt.Fprintln(os.Stderr, "teach:", err)
+		f.Close()
+		return func() {}
+	}
+	return func() {
+		trace.Stop()
+		f.Close()
+	}
+}
+
+func startEvents(addr string) (stop func()) {
+	if addr == "" {
+		return func() {}
+	}
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "teach:", err)
+		return func() {}
+	}
+
+	start := time.Now()
+	var before runtime.MemStats
+	runtime.ReadMemStats(&before)
+
+	events := make(chan Event, 1024)
+	session.Lock()
+	session.events = events
+	session.gauges = make(map[string]func() (int, int))
+	session.Unlock()
+
+	written := make(chan struct{})
+	go func() {
+		defer close(written)
+		w := bufio.NewWriter(conn)
+		enc := json.NewEncoder(w)
+		for e := range events {
+			enc.Encode(e)
+			if len(events) == 0 {
+				w.Flush()
+			}
+		}
+		w.Flush()
+	}()
+	quit, sampled := make(chan struct{}), make(chan struct{})
+	if os.Getenv(EnvStep) != "" {
+		resume := make(chan struct{})
+		session.Lock()
+		session.resume = resume
+		session.Unlock()
+		go func() {
+			defer close(resume)
+			s := bufio.NewScanner(conn)
+			for s.Scan() {
+				if s.Text() != Resume {
+					continue
+				}
+				select {
+				case resume <- struct{}{}:
+				case <-quit:
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		defer close(sampled)
+		tick := time.NewTicker(SampleInterval)
+		defer tick.Stop()
+		for {
+			sample()
+			select {
+			case <-tick.C:
+			case <-quit:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(quit)
+		<-sampled
+		sample() // the final state
+		var after runtime.MemStats
+		runtime.ReadMemStats(&after)
+		emitWait(Event{Kind: KindExit, Name: "elapsed", Value: time.Since(start).Seconds()})
+		emitWait(Event{Kind: KindExit, Name: "allocs", Value: float64(after.Mallocs - before.Mallocs)})
+		emitWait(Event{Kind: KindExit, Name: "bytes", Value: float64(after.TotalAlloc - before.TotalAlloc)})
+		session.Lock()
+		session.events = nil
+		session.resume = nil
+		session.Unlock()
+		// Emit sends under the lock, and the writer keeps draining events
+		// until they are closed, so the waiting senders finish.
+		session.waiting.Wait()
+		close(events)
+		<-written
+		conn.Close()
+	}
+}
+
+// Enabled reports whether the example is connected to the runner.
+func Enabled() bool {
+	session.Lock()
+	defer session.Unlock()
+	return session.events != nil
+}
+
+// Emit sends e to the runner, stamping it with the current time if it has
+// none. If the runner falls behind the event is dropped rather than slowing
+// the example down.
+func Emit(e Event) {
+	session.Lock()
+	defer session.Unlock()
+	if session.events == nil {
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	select {
+	case session.events <- e:
+	default:
+	}
+}
+
+// emitWait is Emit for the events the runner must not miss: it waits for
+// room rather than dropping e. Only the calling goroutine waits; it sends
+// without the lock, so Emit elsewhere still drops instead.
+func emitWait(e Event) {
+	session.Lock()
+	events := session.events
+	if events == nil {
+		session.Unlock()
+		return
+	}
+	session.waiting.Add(1)
+	session.Unlock()
+	defer session.waiting.Done()
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	events <- e
+}
+
+// Logf records a line in the runner's list of recent events.
+func Logf(format string, args ...any) {
+	if Enabled() {
+		Emit(Event{Kind: KindLog, Text: fmt.Sprintf(format, args...)})
+	}
+}
+
+// Stat records the current value of a named counter or gauge.
+func Stat(name string, v float64) {
+	Emit(Event{Kind: KindStat, Name: name, Value: v})
+}
+
+// Layout names the participants of an animated example in the order to
+// draw them, left to right. Participants that are not in the layout are
+// drawn after it in the order they first appear.
+func Layout(names ...string) {
+	Emit(Event{Kind: KindLayout, Text: strings.Join(names, "\n")})
+}
+
+// Pass records that what moved from one participant to another, such as
+// the ball from a player to the table. The runner's -animate mode draws
+// it.
+func Pass(from, to, what string) {
+	Emit(Event{Kind: KindPass, Name: from, To: to, Text: what})
+}
+
+// Pause marks an interesting moment in the example. When the runner is
+// stepping through the example, Pause reports label and blocks until the
+// runner says to continue or ctx is done; otherwise it does nothing.
+func Pause(ctx context.Context, label string) {
+	session.Lock()
+	resume := session.resume
+	session.Unlock()
+	if resume == nil {
+		return
+	}
+	// Unlike other events a pause must not be dropped, or the runner
+	// never answers it.
+	emitWait(Event{Kind: KindPause, Text: label})
+	select {
+	case <-resume:
+	case <-ctx.Done():
+	}
+}
+
+// WatchChan samples the fill level of ch every SampleInterval while the
+// example is connected. It returns ch, so a channel can be watched where
+// it is made:
+//
+//	jobs := teach.WatchChan("jobs", make(chan int, 8))
+func WatchChan[T any](name string, ch chan T) chan T {
+	session.Lock()
+	defer session.Unlock()
+	if session.events != nil {
+		session.gauges[name] = func() (int, int) { return len(ch), cap(ch) }
+	}
+	return ch
+}
+
+func sample() {
+	now := time.Now()
+	Emit(Event{Time: now, Kind: KindGoroutines, Value: float64(runtime.NumGoroutine())})
+	session.Lock()
+	gauges := make(map[string]func() (int, int), len(session.gauges))
+	for name, g := range session.gauges {
+		gauges[name] = g
+	}
+	session.Unlock()
+	for name, g := range gauges {
+		n, c := g()
+		Emit(Event{Time: now, Kind: KindChan, Name: name, Value: float64(n), Cap: c})
+	}
+}
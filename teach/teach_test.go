@@ -0,0 +1,212 @@
+package teach
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime/trace"
+	"testing"
+	"time"
+)
+
+// listen starts a fake runner and returns the events it receives from one
+// connection, closed when the example disconnects.
+func listen(t *testing.T) <-chan Event {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	t.Setenv(EnvEvents, ln.Addr().String())
+
+	events := make(chan Event, 1024)
+	go func() {
+		defer close(events)
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		s := bufio.NewScanner(conn)
+		for s.Scan() {
+			var e Event
+			if err := json.Unmarshal(s.Bytes(), &e); err != nil {
+				t.Error(err)
+				return
+			}
+			events <- e
+		}
+	}()
+	return events
+}
+
+func TestStartStreamsEvents(t *testing.T) {
+	events := listen(t)
+	stop := Start()
+	if !Enabled() {
+		t.Fatal("not enabled after Start")
+	}
+	jobs := WatchChan("jobs", make(chan int, 4))
+	jobs <- 1
+	Stat("done", 3)
+	Logf("worker %d started", 2)
+	stop()
+	if Enabled() {
+		t.Error("still enabled after stop")
+	}
+
+	seen := make(map[string]Event)
+	for e := range events {
+		seen[e.Kind+"/"+e.Name] = e
+		if e.Time.IsZero() {
+			t.Errorf("event without time: %+v", e)
+		}
+	}
+	if e, ok := seen["chan/jobs"]; !ok || e.Value != 1 || e.Cap != 4 {
+		t.Errorf("chan event = %+v, want jobs 1/4", e)
+	}
+	if e := seen["stat/done"]; e.Value != 3 {
+		t.Errorf("stat event = %+v", e)
+	}
+	if e := seen["log/"]; e.Text != "worker 2 started" {
+		t.Errorf("log event = %+v", e)
+	}
+	if e := seen["goroutines/"]; e.Value < 1 {
+		t.Errorf("goroutines event = %+v", e)
+	}
+	for _, name := range []string{"elapsed", "allocs", "bytes"} {
+		if e, ok := seen["exit/"+name]; !ok || e.Value <= 0 {
+			t.Errorf("exit event %s = %+v", name, e)
+		}
+	}
+}
+
+func TestHooksWithoutRunner(t *testing.T) {
+	t.Setenv(EnvEvents, "")
+	stop := Start()
+	defer stop()
+	if Enabled() {
+		t.Error("enabled without a runner")
+	}
+	ch := make(chan int)
+	if WatchChan("c", ch) != ch {
+		t.Error("WatchChan did not return its channel")
+	}
+	Logf("ignored")
+	Stat("ignored", 1)
+}
+
+func TestStartWritesTrace(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "out.trace")
+	t.Setenv(EnvEvents, "")
+	t.Setenv(EnvTrace, file)
+	stop := Start()
+	ctx, task := trace.NewTask(context.Background(), "job")
+	trace.WithRegion(ctx, "work", func() {})
+	task.End()
+	stop()
+
+	fi, err := os.Stat(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Size() == 0 {
+		t.Error("empty trace")
+	}
+}
+
+func TestPauseWaitsForRunner(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	t.Setenv(EnvEvents, ln.Addr().String())
+	t.Setenv(EnvStep, "1")
+
+	labels := make(chan string)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		s := bufio.NewScanner(conn)
+		for s.Scan() {
+			var e Event
+			if json.Unmarshal(s.Bytes(), &e) == nil && e.Kind == KindPause {
+				labels <- e.Text
+				fmt.Fprintln(conn, Resume)
+			}
+		}
+	}()
+
+	stop := Start()
+	defer stop()
+	resumed := make(chan struct{})
+	go func() {
+		Pause(context.Background(), "jobs queued")
+		close(resumed)
+	}()
+	if l := <-labels; l != "jobs queued" {
+		t.Errorf("paused at %q", l)
+	}
+	<-resumed
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	go func() { <-labels }() // the runner answers, but nobody waits
+	Pause(ctx, "cancelled")
+}
+
+func TestPauseWithoutStepping(t *testing.T) {
+	listen(t)
+	stop := Start()
+	defer stop()
+	Pause(context.Background(), "nothing waits") // must not block
+}
+
+// A goroutine waiting for room for an event it must not drop holds up
+// nobody else: Emit from other goroutines drops instead.
+func TestEmitWaitBlocksOnlyItsCaller(t *testing.T) {
+	events := make(chan Event, 1)
+	session.Lock()
+	session.events = events
+	session.Unlock()
+	defer func() {
+		session.Lock()
+		session.events = nil
+		session.Unlock()
+	}()
+
+	events <- Event{} // the runner has fallen behind
+	sent := make(chan struct{})
+	go func() {
+		defer close(sent)
+		emitWait(Event{Kind: KindSend})
+	}()
+	time.Sleep(20 * time.Millisecond) // until it waits for room
+	emitted := make(chan struct{})
+	go func() {
+		defer close(emitted)
+		for range 10 {
+			Emit(Event{Kind: KindStat})
+		}
+	}()
+	select {
+	case <-emitted:
+	case <-time.After(time.Second):
+		<-events // unblock everyone before giving up
+		t.Fatal("Emit blocked behind emitWait")
+	}
+	<-events
+	<-sent
+	if e := <-events; e.Kind != KindSend {
+		t.Errorf("got %+v, want the send event", e)
+	}
+}
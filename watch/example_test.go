@@ -0,0 +1,23 @@
+package watch_test
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lotusirous/gochan/watch"
+)
+
+func ExampleValue_Watch() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	level := watch.New("info")
+
+	updates := level.Watch(ctx)
+	fmt.Println(<-updates) // the current value, right away
+
+	level.Set("debug")
+	fmt.Println(<-updates)
+	// Output:
+	// info
+	// debug
+}
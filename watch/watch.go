@@ -0,0 +1,109 @@
+// Package watch broadcasts the latest value of something that changes, such
+// as a configuration or a status, to any number of readers.
+//
+// Readers are not meant to see every update, only the most recent one: a
+// reader that falls behind skips the intermediate values instead of slowing
+// down the writer.
+package watch
+
+import (
+	"context"
+	"sync"
+)
+
+// Value holds a value that writers Set and readers Watch.
+type Value[T any] struct {
+	mu      sync.Mutex
+	v       T
+	subs    map[chan T]struct{}
+	metrics Metrics
+}
+
+// Option configures a Value.
+type Option func(*options)
+
+type options struct {
+	metrics Metrics
+}
+
+// WithMetrics makes the Value report its broadcasts to m.
+func WithMetrics(m Metrics) Option {
+	return func(o *options) { o.metrics = m }
+}
+
+// Metrics receives measurements of a Value's broadcasts. Its methods are
+// called while the Value is locked, so they must be quick and must not use
+// the Value.
+type Metrics interface {
+	// Published is called by every Set with the number of watchers it
+	// notified.
+	Published(watchers int)
+	// Coalesced is called when Set replaces a value that a watcher had not
+	// received yet.
+	Coalesced()
+}
+
+// New returns a Value holding initial.
+func New[T any](initial T, opts ...Option) *Value[T] {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &Value[T]{v: initial, subs: make(map[chan T]struct{}), metrics: o.metrics}
+}
+
+// Get returns the current value.
+func (w *Value[T]) Get() T {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.v
+}
+
+// Set stores v and notifies every watcher. It never blocks on a slow reader.
+func (w *Value[T]) Set(v T) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.v = v
+	for c := range w.subs {
+		if offer(c, v) && w.metrics != nil {
+			w.metrics.Coalesced()
+		}
+	}
+	if w.metrics != nil {
+		w.metrics.Published(len(w.subs))
+	}
+}
+
+// Watch returns a channel that receives the current value right away and
+// then the latest value after every Set. Updates made while the reader is
+// busy are coalesced: only the newest one is delivered. The channel is
+// closed when ctx is done.
+func (w *Value[T]) Watch(ctx context.Context) <-chan T {
+	c := make(chan T, 1)
+	w.mu.Lock()
+	c <- w.v
+	w.subs[c] = struct{}{}
+	w.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		w.mu.Lock()
+		delete(w.subs, c)
+		close(c)
+		w.mu.Unlock()
+	}()
+	return c
+}
+
+// offer replaces whatever is waiting in c with v, and reports whether
+// something was. Only Set writes to c, and it holds the lock, so after
+// draining there is always room.
+func offer[T any](c chan T, v T) (replaced bool) {
+	select {
+	case <-c:
+		replaced = true
+	default:
+	}
+	c <- v
+	return replaced
+}
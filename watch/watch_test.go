@@ -0,0 +1,93 @@
+package watch
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestWatchStartsWithCurrentValue(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	w := New("v1")
+	if got := <-w.Watch(ctx); got != "v1" {
+		t.Fatalf("first value = %q, want v1", got)
+	}
+}
+
+func TestWatchCoalescesUpdates(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	w := New(0)
+	c := w.Watch(ctx)
+	for i := 1; i <= 100; i++ {
+		w.Set(i) // nobody is reading: must not block
+	}
+	if got := <-c; got != 100 {
+		t.Fatalf("slow reader got %d, want the latest value 100", got)
+	}
+	if got := w.Get(); got != 100 {
+		t.Fatalf("Get = %d, want 100", got)
+	}
+}
+
+func TestWatchManyReaders(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	w := New(0)
+	readers := []<-chan int{w.Watch(ctx), w.Watch(ctx), w.Watch(ctx)}
+	for _, c := range readers {
+		<-c // initial value
+	}
+	w.Set(7)
+	for i, c := range readers {
+		if got := <-c; got != 7 {
+			t.Errorf("reader %d got %d, want 7", i, got)
+		}
+	}
+}
+
+func TestWatchClosesOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	w := New(0)
+	c := w.Watch(ctx)
+	cancel()
+
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case _, ok := <-c:
+			if !ok {
+				w.Set(1) // must not panic on the closed channel
+				return
+			}
+		case <-timeout:
+			t.Fatal("channel not closed after cancel")
+		}
+	}
+}
+
+type counters struct{ published, watchers, coalesced int }
+
+func (m *counters) Published(n int) { m.published++; m.watchers += n }
+func (m *counters) Coalesced()      { m.coalesced++ }
+
+func TestWithMetrics(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var m counters
+	w := New(0, WithMetrics(&m))
+	a, b := w.Watch(ctx), w.Watch(ctx)
+	<-a
+	w.Set(1) // b still holds 0
+	<-a
+	<-b
+	w.Set(2)
+	if m.published != 2 || m.watchers != 4 || m.coalesced != 1 {
+		t.Errorf("metrics = %+v, want 2 sets to 2 watchers, 1 coalesced", m)
+	}
+}